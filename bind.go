@@ -0,0 +1,432 @@
+package poltergeist
+
+import (
+	"encoding"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// =============================================================================
+// BINDING - Decode request bodies and values into structs
+// =============================================================================
+
+// Bind decodes the request body into v according to its Content-Type:
+// application/x-www-form-urlencoded and multipart/form-data bodies are mapped
+// onto struct fields by `form` tags, XML and YAML bodies are decoded with
+// BindXML and BindYAML, and anything else is decoded as JSON.
+//
+//	type SignupForm struct {
+//	    Email     string                `form:"email"`
+//	    Interests []string              `form:"interest"`
+//	    Birthday  time.Time             `form:"birthday" time_format:"2006-01-02"`
+//	    Avatar    *multipart.FileHeader `form:"avatar"`
+//	}
+//
+// Form fields fall back to the json tag and then the field name; nested
+// structs use dotted names ("address.city"), embedded structs are flattened.
+// Decoding errors are *BindError values, answered with 400 Bad Request.
+func (c *Context) Bind(v any) error {
+	mediaType, _, _ := mime.ParseMediaType(c.Request.Header.Get(HeaderContentType))
+	switch mediaType {
+	case ContentTypeForm:
+		if err := c.Request.ParseForm(); err != nil {
+			return &BindError{Err: err}
+		}
+		return bindValues(v, "form", BindSourceBody, c.Request.PostForm, nil)
+	case ContentTypeMultipart:
+		if err := c.parseMultipart(DefaultMaxMultipartMemory); err != nil {
+			return err
+		}
+		form := c.Request.MultipartForm
+		return bindValues(v, "form", BindSourceBody, form.Value, form.File)
+	case "application/xml", "text/xml":
+		return c.BindXML(v)
+	case "application/yaml", "application/x-yaml", "text/yaml", "text/x-yaml":
+		return c.BindYAML(v)
+	default:
+		return c.bindJSON(v)
+	}
+}
+
+// BindYAML decodes a YAML request body into v using `yaml` struct tags. An
+// empty body leaves v unchanged.
+func (c *Context) BindYAML(v any) error {
+	defer c.Request.Body.Close()
+	if err := yaml.NewDecoder(c.Request.Body).Decode(v); err != nil && !errors.Is(err, io.EOF) {
+		return &BindError{Err: err}
+	}
+	return nil
+}
+
+// BindXML decodes an XML request body into v using encoding/xml struct tags
+func (c *Context) BindXML(v any) error {
+	defer c.Request.Body.Close()
+	if err := xml.NewDecoder(c.Request.Body).Decode(v); err != nil {
+		return &BindError{Err: err}
+	}
+	return nil
+}
+
+// BindQuery maps query parameters onto struct fields by `query` tags, the
+// same tags the docs generator reads from Route.Query
+//
+//	type ListFilter struct {
+//	    Status []string  `query:"status"`
+//	    Since  time.Time `query:"since"`
+//	    Active bool      `query:"active"`
+//	    Page   int       `query:"page" default:"1"`
+//	}
+//
+//	var filter ListFilter
+//	if err := c.BindQuery(&filter); err != nil {
+//	    return err // 400 Invalid query parameters
+//	}
+//
+// Conversions follow Bind: repeated keys fill slices, blank values leave
+// fields unset (or at their default).
+func (c *Context) BindQuery(v any) error {
+	return bindValues(v, "query", BindSourceQuery, c.Request.URL.Query(), nil)
+}
+
+// BindParams maps path parameters onto struct fields by `param` tags, the
+// same tags the docs generator reads from Route.Params. Conversion failures
+// answer 400 "Invalid path parameters" naming the parameter.
+//
+//	type OrderPath struct {
+//	    UserID  int64  `param:"user_id"`
+//	    OrderID int64  `param:"order_id"`
+//	    Format  string `param:"format" default:"json"` // optional ":format?"
+//	}
+//
+//	app.GET("/users/:user_id/orders/:order_id/:format?", func(c *poltergeist.Context) error {
+//	    var in OrderPath
+//	    if err := c.BindParams(&in); err != nil {
+//	        return err
+//	    }
+//	    ...
+//	})
+func (c *Context) BindParams(v any) error {
+	values := make(map[string][]string, len(c.Params))
+	for k, param := range c.Params {
+		values[k] = []string{param}
+	}
+	return bindValues(v, "param", BindSourcePath, values, nil)
+}
+
+// bindJSON decodes a JSON request body into v
+func (c *Context) bindJSON(v any) error {
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		return &BindError{Err: err}
+	}
+	defer c.Request.Body.Close()
+	if err := json.Unmarshal(body, v); err != nil {
+		return &BindError{Err: err}
+	}
+	return nil
+}
+
+// binder maps string values (and multipart files) onto struct fields named
+// by tag
+type binder struct {
+	tag    string
+	source string
+	values map[string][]string
+	files  map[string][]*multipart.FileHeader
+}
+
+var (
+	timeType       = reflect.TypeOf(time.Time{})
+	durationType   = reflect.TypeOf(time.Duration(0))
+	fileHeaderType = reflect.TypeOf((*multipart.FileHeader)(nil))
+	unmarshalType  = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// bindValues fills the struct v points to from values and files
+func bindValues(v any, tag, source string, values map[string][]string, files map[string][]*multipart.FileHeader) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return &BindError{Source: source, Err: fmt.Errorf("bind target must be a non-nil struct pointer, got %T", v)}
+	}
+	b := &binder{tag: tag, source: source, values: values, files: files}
+	_, err := b.bindStruct(rv.Elem(), "")
+	return err
+}
+
+// bindStruct fills the fields of a struct value, reporting whether any
+// request value was found for it
+func (b *binder) bindStruct(rv reflect.Value, prefix string) (bool, error) {
+	found := false
+	t := rv.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		value := rv.Field(i)
+
+		// Flatten embedded structs
+		if field.Anonymous && field.Tag.Get(b.tag) == "" {
+			ft := field.Type
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if ft.Kind() != reflect.Struct || (!field.IsExported() && field.Type.Kind() == reflect.Pointer) {
+				continue
+			}
+			ok, err := b.bindNested(value, prefix)
+			if err != nil {
+				return false, err
+			}
+			found = found || ok
+			continue
+		}
+		if !field.IsExported() {
+			continue
+		}
+
+		name := bindFieldName(field, b.tag)
+		if name == "" {
+			continue
+		}
+		ok, err := b.bindField(value, field, prefix+name)
+		if err != nil {
+			return false, err
+		}
+		found = found || ok
+	}
+	return found, nil
+}
+
+// bindNested binds a struct or struct pointer field, allocating pointers only
+// when the request has values for them
+func (b *binder) bindNested(value reflect.Value, prefix string) (bool, error) {
+	if value.Kind() != reflect.Pointer {
+		return b.bindStruct(value, prefix)
+	}
+	target := reflect.New(value.Type().Elem())
+	found, err := b.bindStruct(target.Elem(), prefix)
+	if found && err == nil {
+		value.Set(target)
+	}
+	return found, err
+}
+
+// bindField sets one field from the values (or files) under name
+func (b *binder) bindField(value reflect.Value, field reflect.StructField, name string) (bool, error) {
+	if isFileField(field.Type) {
+		files := b.files[name]
+		if len(files) == 0 {
+			return false, nil
+		}
+		if field.Type.Kind() == reflect.Slice {
+			value.Set(reflect.ValueOf(files))
+		} else {
+			value.Set(reflect.ValueOf(files[0]))
+		}
+		return true, nil
+	}
+	if isNestedStruct(field.Type) {
+		return b.bindNested(value, name+".")
+	}
+
+	values := nonEmpty(b.values[name])
+	if len(values) == 0 {
+		def, ok := field.Tag.Lookup("default")
+		if !ok {
+			return false, nil
+		}
+		values = []string{def}
+		if field.Type.Kind() == reflect.Slice {
+			values = strings.Split(def, ",")
+		}
+	}
+	if err := setFieldValues(value, values, field.Tag.Get("time_format")); err != nil {
+		return false, &BindError{Source: b.source, Field: name, Err: err}
+	}
+	return true, nil
+}
+
+// setFieldValues converts values into value, one element per value for slices
+func setFieldValues(value reflect.Value, values []string, timeFormat string) error {
+	if value.Kind() == reflect.Slice && !value.Type().Implements(unmarshalType) && value.Type().Elem().Kind() != reflect.Uint8 {
+		slice := reflect.MakeSlice(value.Type(), len(values), len(values))
+		for i, v := range values {
+			if err := setFieldValue(slice.Index(i), v, timeFormat); err != nil {
+				return err
+			}
+		}
+		value.Set(slice)
+		return nil
+	}
+	return setFieldValue(value, values[0], timeFormat)
+}
+
+// setFieldValue converts one string into value
+func setFieldValue(value reflect.Value, s, timeFormat string) error {
+	if value.Kind() == reflect.Pointer {
+		target := reflect.New(value.Type().Elem())
+		if err := setFieldValue(target.Elem(), s, timeFormat); err != nil {
+			return err
+		}
+		value.Set(target)
+		return nil
+	}
+
+	switch {
+	case value.Type() == timeType:
+		t, err := parseTime(s, timeFormat)
+		if err != nil {
+			return err
+		}
+		value.Set(reflect.ValueOf(t))
+		return nil
+	case value.Type() == durationType:
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return err
+		}
+		value.SetInt(int64(d))
+		return nil
+	case reflect.PointerTo(value.Type()).Implements(unmarshalType):
+		return value.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(s))
+	}
+
+	switch value.Kind() {
+	case reflect.String:
+		value.SetString(s)
+	case reflect.Bool:
+		b, err := parseBool(s)
+		if err != nil {
+			return err
+		}
+		value.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(s, 10, value.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("%q is not a valid integer", s)
+		}
+		value.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(s, 10, value.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("%q is not a valid unsigned integer", s)
+		}
+		value.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(s, value.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("%q is not a valid number", s)
+		}
+		value.SetFloat(f)
+	case reflect.Slice: // []byte
+		value.SetBytes([]byte(s))
+	default:
+		return fmt.Errorf("unsupported field type %s", value.Type())
+	}
+	return nil
+}
+
+// bindTimeLayouts are tried in order for time fields without a time_format
+var bindTimeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05", // HTML datetime-local with seconds
+	"2006-01-02T15:04",    // HTML datetime-local
+	"2006-01-02",          // HTML date
+}
+
+// parseTime parses s with layout, "unix" for Unix seconds, or the default
+// layouts
+func parseTime(s, layout string) (time.Time, error) {
+	switch layout {
+	case "":
+		for _, l := range bindTimeLayouts {
+			if t, err := time.Parse(l, s); err == nil {
+				return t, nil
+			}
+		}
+		return time.Time{}, fmt.Errorf("%q is not a valid time (use RFC 3339 or 2006-01-02)", s)
+	case "unix":
+		sec, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("%q is not a valid Unix timestamp", s)
+		}
+		return time.Unix(sec, 0), nil
+	default:
+		t, err := time.Parse(layout, s)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("%q does not match the time format %s", s, layout)
+		}
+		return t, nil
+	}
+}
+
+// parseBool accepts strconv booleans plus "on"/"off" (HTML checkboxes) and
+// "yes"/"no"
+func parseBool(s string) (bool, error) {
+	switch strings.ToLower(s) {
+	case "on", "yes":
+		return true, nil
+	case "off", "no":
+		return false, nil
+	}
+	b, err := strconv.ParseBool(s)
+	if err != nil {
+		return false, fmt.Errorf("%q is not a valid boolean", s)
+	}
+	return b, nil
+}
+
+// bindFieldName returns the value name for a struct field: the binding tag,
+// falling back to the json tag and then the field name. "-" skips the field.
+func bindFieldName(field reflect.StructField, tag string) string {
+	name, _, _ := strings.Cut(field.Tag.Get(tag), ",")
+	if name == "" {
+		name, _, _ = strings.Cut(field.Tag.Get("json"), ",")
+	}
+	if name == "-" {
+		return ""
+	}
+	if name == "" {
+		name = field.Name
+	}
+	return name
+}
+
+// isFileField reports whether t holds multipart file uploads
+func isFileField(t reflect.Type) bool {
+	return t == fileHeaderType || (t.Kind() == reflect.Slice && t.Elem() == fileHeaderType)
+}
+
+// isNestedStruct reports whether t is bound field by field rather than
+// parsed from a single value
+func isNestedStruct(t reflect.Type) bool {
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t.Kind() == reflect.Struct && t != timeType && !reflect.PointerTo(t).Implements(unmarshalType)
+}
+
+// nonEmpty drops empty values, so blank inputs leave fields unset
+func nonEmpty(values []string) []string {
+	for _, v := range values {
+		if v == "" {
+			out := make([]string, 0, len(values))
+			for _, v := range values {
+				if v != "" {
+					out = append(out, v)
+				}
+			}
+			return out
+		}
+	}
+	return values
+}
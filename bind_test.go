@@ -0,0 +1,360 @@
+package poltergeist
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+// =============================================================================
+// BINDING TESTS
+// =============================================================================
+
+type bindAddress struct {
+	City string `form:"city"`
+	Zip  string `form:"zip"`
+}
+
+type bindAudit struct {
+	Source string `form:"source"`
+}
+
+type bindSignup struct {
+	bindAudit
+	Email     string        `form:"email"`
+	Age       int           `form:"age"`
+	Score     float64       `form:"score"`
+	Subscribe bool          `form:"subscribe"`
+	Interests []string      `form:"interest"`
+	IDs       []int64       `form:"id"`
+	Birthday  time.Time     `form:"birthday" time_format:"2006-01-02"`
+	Joined    time.Time     `form:"joined"`
+	Reminders []time.Time   `form:"remind"`
+	Timeout   time.Duration `form:"timeout"`
+	Nickname  *string       `form:"nickname"`
+	Role      string        `form:"role" default:"member"`
+	Address   bindAddress   `form:"address"`
+	Billing   *bindAddress  `form:"billing"`
+	Internal  string        `form:"-"`
+	Name      string        `json:"name"`
+}
+
+func TestContext_BindForm(t *testing.T) {
+	form := url.Values{
+		"source":       {"landing"},
+		"email":        {"ada@example.com"},
+		"age":          {"36"},
+		"score":        {"9.5"},
+		"subscribe":    {"on"},
+		"interest":     {"go", "math"},
+		"id":           {"1", "2"},
+		"birthday":     {"1815-12-10"},
+		"joined":       {"2024-03-01T10:30:00Z"},
+		"remind":       {"2024-05-01", "2024-06-01T09:00"},
+		"timeout":      {"90s"},
+		"Internal":     {"leak"},
+		"name":         {"Ada"},
+		"address.city": {"London"},
+		"age2":         {""},
+	}
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(form.Encode()))
+	req.Header.Set(HeaderContentType, ContentTypeForm+"; charset=utf-8")
+	c := NewContext(httptest.NewRecorder(), req)
+
+	var got bindSignup
+	if err := c.Bind(&got); err != nil {
+		t.Fatalf("Bind() error = %v", err)
+	}
+
+	if got.Source != "landing" || got.Email != "ada@example.com" || got.Age != 36 || got.Score != 9.5 || !got.Subscribe {
+		t.Errorf("scalars = %+v", got)
+	}
+	if strings.Join(got.Interests, ",") != "go,math" || len(got.IDs) != 2 || got.IDs[1] != 2 {
+		t.Errorf("slices = %v %v", got.Interests, got.IDs)
+	}
+	if got.Birthday.Year() != 1815 || got.Joined.Hour() != 10 || len(got.Reminders) != 2 || got.Reminders[1].Hour() != 9 {
+		t.Errorf("times = %v %v %v", got.Birthday, got.Joined, got.Reminders)
+	}
+	if got.Timeout != 90*time.Second || got.Nickname != nil || got.Role != "member" {
+		t.Errorf("timeout/nickname/role = %v %v %q", got.Timeout, got.Nickname, got.Role)
+	}
+	if got.Address.City != "London" || got.Billing != nil {
+		t.Errorf("nested = %+v %+v", got.Address, got.Billing)
+	}
+	if got.Internal != "" || got.Name != "Ada" {
+		t.Errorf("skipped/json-named = %q %q", got.Internal, got.Name)
+	}
+}
+
+func TestContext_BindMultipart(t *testing.T) {
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	mw.WriteField("title", "Report")
+	mw.WriteField("tag", "q1")
+	mw.WriteField("tag", "finance")
+	for _, name := range []string{"a.txt", "b.txt"} {
+		part, _ := mw.CreateFormFile("attachments", name)
+		part.Write([]byte("content of " + name))
+	}
+	part, _ := mw.CreateFormFile("cover", "cover.png")
+	part.Write([]byte("png"))
+	mw.Close()
+
+	req := httptest.NewRequest(http.MethodPost, "/", &body)
+	req.Header.Set(HeaderContentType, mw.FormDataContentType())
+	c := NewContext(httptest.NewRecorder(), req)
+
+	var got struct {
+		Title       string                  `form:"title"`
+		Tags        []string                `form:"tag"`
+		Cover       *multipart.FileHeader   `form:"cover"`
+		Attachments []*multipart.FileHeader `form:"attachments"`
+		Missing     *multipart.FileHeader   `form:"missing"`
+	}
+	if err := c.Bind(&got); err != nil {
+		t.Fatalf("Bind() error = %v", err)
+	}
+	if got.Title != "Report" || len(got.Tags) != 2 {
+		t.Errorf("fields = %q %v", got.Title, got.Tags)
+	}
+	if got.Cover == nil || got.Cover.Filename != "cover.png" || got.Missing != nil {
+		t.Fatalf("cover = %v, missing = %v", got.Cover, got.Missing)
+	}
+	if len(got.Attachments) != 2 || got.Attachments[1].Filename != "b.txt" {
+		t.Fatalf("attachments = %v", got.Attachments)
+	}
+	f, _ := got.Attachments[0].Open()
+	defer f.Close()
+	if data, _ := io.ReadAll(f); string(data) != "content of a.txt" {
+		t.Errorf("attachment content = %q", data)
+	}
+}
+
+func TestContext_BindErrors(t *testing.T) {
+	app := New()
+	app.POST("/", func(c *Context) error {
+		var v struct {
+			Age int `form:"age"`
+		}
+		return c.Bind(&v)
+	})
+
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		wantField   string
+	}{
+		{"invalid int", ContentTypeForm, "age=old", "age"},
+		{"invalid json", "application/json", "{", ""},
+		{"json without content type", "", "{", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(tt.body))
+			if tt.contentType != "" {
+				req.Header.Set(HeaderContentType, tt.contentType)
+			}
+			w := httptest.NewRecorder()
+			app.ServeHTTP(w, req)
+			if w.Code != http.StatusBadRequest {
+				t.Fatalf("status = %d, want 400", w.Code)
+			}
+			var resp struct {
+				Details []FieldError `json:"details"`
+			}
+			json.Unmarshal(w.Body.Bytes(), &resp)
+			if tt.wantField == "" && len(resp.Details) != 0 || tt.wantField != "" && (len(resp.Details) != 1 || resp.Details[0].Field != tt.wantField) {
+				t.Errorf("details = %+v, want field %q", resp.Details, tt.wantField)
+			}
+		})
+	}
+
+	c := NewContext(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/", strings.NewReader("a=1")))
+	c.Request.Header.Set(HeaderContentType, ContentTypeForm)
+	var notStruct int
+	var bindErr *BindError
+	if err := c.Bind(&notStruct); !errors.As(err, &bindErr) {
+		t.Errorf("Bind(*int) error = %v, want *BindError", err)
+	}
+}
+
+func TestContext_BindQuery(t *testing.T) {
+	type listFilter struct {
+		Status []string   `query:"status"`
+		Since  *time.Time `query:"since"`
+		Until  time.Time  `query:"until" time_format:"unix"`
+		Active bool       `query:"active"`
+		Page   int        `query:"page" default:"1"`
+		Limit  uint       `query:"limit" default:"20"`
+		Sort   string     `query:"sort"`
+	}
+
+	tests := []struct {
+		name  string
+		query string
+		check func(t *testing.T, f listFilter)
+	}{
+		{"defaults", "", func(t *testing.T, f listFilter) {
+			if f.Page != 1 || f.Limit != 20 || f.Since != nil || f.Active || f.Status != nil {
+				t.Errorf("filter = %+v", f)
+			}
+		}},
+		{"values", "status=open&status=pending&since=2024-01-02&until=1700000000&active=true&page=3&sort=name", func(t *testing.T, f listFilter) {
+			if len(f.Status) != 2 || f.Status[1] != "pending" || f.Page != 3 || f.Sort != "name" || !f.Active {
+				t.Errorf("filter = %+v", f)
+			}
+			if f.Since == nil || f.Since.Day() != 2 || f.Until.Unix() != 1700000000 {
+				t.Errorf("times = %v %v", f.Since, f.Until)
+			}
+		}},
+		{"blank values", "page=&limit=", func(t *testing.T, f listFilter) {
+			if f.Page != 1 || f.Limit != 20 {
+				t.Errorf("filter = %+v", f)
+			}
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewContext(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/items?"+tt.query, nil))
+			var f listFilter
+			if err := c.BindQuery(&f); err != nil {
+				t.Fatalf("BindQuery() error = %v", err)
+			}
+			tt.check(t, f)
+		})
+	}
+
+	app := New()
+	app.GET("/items", func(c *Context) error {
+		var f listFilter
+		return c.BindQuery(&f)
+	})
+	w := httptest.NewRecorder()
+	app.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/items?limit=-1", nil))
+	var resp struct {
+		Error   string       `json:"error"`
+		Details []FieldError `json:"details"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	if w.Code != http.StatusBadRequest || resp.Error != "Invalid query parameters" || len(resp.Details) != 1 || resp.Details[0].Field != "limit" {
+		t.Errorf("invalid query = %d %+v", w.Code, resp)
+	}
+}
+
+func TestContext_BindParams(t *testing.T) {
+	type orderPath struct {
+		UserID  int64  `param:"user_id"`
+		OrderID uint   `param:"order_id"`
+		Format  string `param:"format" default:"json"`
+	}
+
+	app := New()
+	var got orderPath
+	app.GET("/users/:user_id/orders/:order_id/:format?", func(c *Context) error {
+		got = orderPath{}
+		if err := c.BindParams(&got); err != nil {
+			return err
+		}
+		return c.NoContent()
+	})
+
+	tests := []struct {
+		path      string
+		wantCode  int
+		want      orderPath
+		wantField string
+	}{
+		{"/users/7/orders/42", http.StatusNoContent, orderPath{7, 42, "json"}, ""},
+		{"/users/7/orders/42/xml", http.StatusNoContent, orderPath{7, 42, "xml"}, ""},
+		{"/users/me/orders/42", http.StatusBadRequest, orderPath{}, "user_id"},
+		{"/users/7/orders/-1", http.StatusBadRequest, orderPath{}, "order_id"},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		app.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.path, nil))
+		if w.Code != tt.wantCode {
+			t.Errorf("%s: status = %d, want %d", tt.path, w.Code, tt.wantCode)
+			continue
+		}
+		if tt.wantField == "" {
+			if got != tt.want {
+				t.Errorf("%s: bound %+v, want %+v", tt.path, got, tt.want)
+			}
+			continue
+		}
+		var resp struct {
+			Error   string       `json:"error"`
+			Details []FieldError `json:"details"`
+		}
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		if resp.Error != "Invalid path parameters" || len(resp.Details) != 1 || resp.Details[0].Field != tt.wantField {
+			t.Errorf("%s: error = %+v, want field %q", tt.path, resp, tt.wantField)
+		}
+	}
+}
+
+func TestContext_BindXML(t *testing.T) {
+	type order struct {
+		ID    int      `xml:"id,attr"`
+		Items []string `xml:"item"`
+	}
+	body := `<order id="7"><item>tea</item><item>cake</item></order>`
+
+	for _, contentType := range []string{"application/xml", "text/xml; charset=utf-8"} {
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+		req.Header.Set(HeaderContentType, contentType)
+		var got order
+		if err := NewContext(httptest.NewRecorder(), req).Bind(&got); err != nil {
+			t.Fatalf("%s: Bind() error = %v", contentType, err)
+		}
+		if got.ID != 7 || len(got.Items) != 2 || got.Items[1] != "cake" {
+			t.Errorf("%s: bound %+v", contentType, got)
+		}
+	}
+
+	c := NewContext(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/", strings.NewReader("<order>")))
+	var bindErr *BindError
+	if err := c.BindXML(&order{}); !errors.As(err, &bindErr) {
+		t.Errorf("BindXML(malformed) error = %v, want *BindError", err)
+	}
+}
+
+func TestContext_BindYAML(t *testing.T) {
+	type deployment struct {
+		Name     string            `yaml:"name"`
+		Replicas int               `yaml:"replicas"`
+		Labels   map[string]string `yaml:"labels"`
+	}
+	body := "name: api\nreplicas: 3\nlabels:\n  tier: backend\n"
+
+	for _, contentType := range []string{"application/yaml", "application/x-yaml", "text/yaml"} {
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+		req.Header.Set(HeaderContentType, contentType)
+		var got deployment
+		if err := NewContext(httptest.NewRecorder(), req).Bind(&got); err != nil {
+			t.Fatalf("%s: Bind() error = %v", contentType, err)
+		}
+		if got.Name != "api" || got.Replicas != 3 || got.Labels["tier"] != "backend" {
+			t.Errorf("%s: bound %+v", contentType, got)
+		}
+	}
+
+	c := NewContext(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/", strings.NewReader("")))
+	if err := c.BindYAML(&deployment{}); err != nil {
+		t.Errorf("BindYAML(empty) error = %v", err)
+	}
+	c = NewContext(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/", strings.NewReader("replicas: [")))
+	var bindErr *BindError
+	if err := c.BindYAML(&deployment{}); !errors.As(err, &bindErr) {
+		t.Errorf("BindYAML(malformed) error = %v, want *BindError", err)
+	}
+}
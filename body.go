@@ -0,0 +1,54 @@
+package poltergeist
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+)
+
+// =============================================================================
+// REQUEST BODY - Buffered, re-readable request bodies
+// =============================================================================
+
+// Body reads and returns the whole request body, keeping it so it can be read
+// again: every call rewinds c.Request.Body, so middleware that verifies a
+// signature can read the payload and the handler can still Bind it.
+//
+//	payload, err := c.Body()
+//	if err != nil {
+//	    return err
+//	}
+//	if !validSignature(payload, c.Header("X-Signature")) {
+//	    return poltergeist.ErrUnauthorized
+//	}
+//	return next(c) // the handler's c.Bind sees the full body
+//
+// Bodies larger than Config.MaxBodyBuffer (4MB by default) are rejected
+// with 413.
+func (c *Context) Body() ([]byte, error) {
+	if c.body == nil {
+		limit := int64(DefaultMaxBodyBuffer)
+		if c.router != nil && c.router.maxBodyBuffer > 0 {
+			limit = c.router.maxBodyBuffer
+		}
+		if c.Request.ContentLength > limit {
+			return nil, bodyTooLarge(limit)
+		}
+		data, err := io.ReadAll(io.LimitReader(c.Request.Body, limit+1))
+		c.Request.Body.Close()
+		if err != nil {
+			return nil, &BindError{Err: err}
+		}
+		if int64(len(data)) > limit {
+			return nil, bodyTooLarge(limit)
+		}
+		c.body = data
+	}
+	c.Request.Body = io.NopCloser(bytes.NewReader(c.body))
+	return c.body, nil
+}
+
+// bodyTooLarge is returned for bodies over the buffer limit
+func bodyTooLarge(limit int64) error {
+	return ErrRequestEntityTooLarge.WithMessage(fmt.Sprintf("request body exceeds %d bytes", limit))
+}
@@ -0,0 +1,80 @@
+package poltergeist
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// =============================================================================
+// REQUEST BODY TESTS
+// =============================================================================
+
+func TestContext_BodyRewind(t *testing.T) {
+	app := New()
+	var seen string
+	verify := func(next HandlerFunc) HandlerFunc {
+		return func(c *Context) error {
+			payload, err := c.Body()
+			if err != nil {
+				return err
+			}
+			seen = string(payload)
+			return next(c)
+		}
+	}
+	var got struct {
+		Name string `json:"name"`
+	}
+	handler := func(c *Context) error {
+		if err := c.Bind(&got); err != nil {
+			return err
+		}
+		again, _ := c.Body()
+		return c.String(http.StatusOK, string(again))
+	}
+	app.POST("/hooks", handler, verify)
+	app.POST("/timed", handler, verify).Timeout(time.Second)
+
+	for _, path := range []string{"/hooks", "/timed"} {
+		got.Name, seen = "", ""
+		body := `{"name":"ada"}`
+		w := httptest.NewRecorder()
+		app.ServeHTTP(w, httptest.NewRequest(http.MethodPost, path, strings.NewReader(body)))
+		if w.Code != http.StatusOK || seen != body || got.Name != "ada" || w.Body.String() != body {
+			t.Errorf("%s: code = %d, middleware saw %q, bound %q, body %q", path, w.Code, seen, got.Name, w.Body.String())
+		}
+	}
+}
+
+func TestContext_BodyLimit(t *testing.T) {
+	app := NewWithConfig(&Config{MaxBodyBuffer: 8})
+	app.POST("/", func(c *Context) error {
+		_, err := c.Body()
+		return err
+	})
+
+	tests := []struct {
+		name    string
+		body    string
+		chunked bool
+		want    int
+	}{
+		{"within limit", "12345678", false, http.StatusOK},
+		{"declared too large", "123456789", false, http.StatusRequestEntityTooLarge},
+		{"streamed too large", "123456789", true, http.StatusRequestEntityTooLarge},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(tt.body))
+		if tt.chunked {
+			req.ContentLength = -1
+		}
+		w := httptest.NewRecorder()
+		app.ServeHTTP(w, req)
+		if w.Code != tt.want {
+			t.Errorf("%s: status = %d, want %d", tt.name, w.Code, tt.want)
+		}
+	}
+}
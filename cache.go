@@ -0,0 +1,182 @@
+package poltergeist
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync/atomic"
+	"time"
+)
+
+// =============================================================================
+// CACHE - Key/value cache shared by middleware and handlers
+// =============================================================================
+
+// Cache stores byte values under string keys with an optional TTL (0 means
+// no expiry). Get reports a miss with ok=false and a nil error. Add stores
+// only when key is absent (or expired), atomically, and reports whether it
+// did; it is the building block of locks and replay checks.
+//
+// The cache package provides a sharded in-memory LRU (cache.NewMemory) and
+// a Redis implementation (cache.NewRedis).
+type Cache interface {
+	Get(ctx context.Context, key string) (value []byte, ok bool, err error)
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	Add(ctx context.Context, key string, value []byte, ttl time.Duration) (added bool, err error)
+	Delete(ctx context.Context, key string) error
+}
+
+// NopCache never stores anything; it is the cache until UseCache is called.
+// Its Add fails with ErrNoCache, since claiming a key it can't remember
+// would silently disable locks and replay checks.
+var NopCache Cache = nopCache{}
+
+// ErrNoCache is returned by NopCache.Add
+var ErrNoCache = errors.New("poltergeist: no cache configured (see Server.UseCache)")
+
+type nopCache struct{}
+
+func (nopCache) Get(context.Context, string) ([]byte, bool, error)        { return nil, false, nil }
+func (nopCache) Set(context.Context, string, []byte, time.Duration) error { return nil }
+func (nopCache) Delete(context.Context, string) error                     { return nil }
+
+func (nopCache) Add(context.Context, string, []byte, time.Duration) (bool, error) {
+	return false, ErrNoCache
+}
+
+// cacheBox lets atomic.Value hold any Cache implementation
+type cacheBox struct{ cache Cache }
+
+// cacheSlot holds the server cache; unset means NopCache
+type cacheSlot struct {
+	current atomic.Value // cacheBox
+}
+
+// get returns the configured cache or NopCache
+func (s *cacheSlot) get() Cache {
+	if s != nil {
+		if box, ok := s.current.Load().(cacheBox); ok && box.cache != nil {
+			return box.cache
+		}
+	}
+	return NopCache
+}
+
+// set replaces the cache; nil restores NopCache
+func (s *cacheSlot) set(cache Cache) {
+	s.current.Store(cacheBox{cache})
+}
+
+// meteredCache reports each operation into the active metrics backend
+type meteredCache struct {
+	Cache
+	metrics *metricsRegistry
+}
+
+// Unwrap returns the cache passed to UseCache
+func (m *meteredCache) Unwrap() Cache { return m.Cache }
+
+func (m *meteredCache) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	start := time.Now()
+	value, ok, err := m.Cache.Get(ctx, key)
+	result := metricCacheMiss
+	if err != nil {
+		result = metricCacheError
+	} else if ok {
+		result = metricCacheHit
+	}
+	m.metrics.get().observeCache("get", result, start)
+	return value, ok, err
+}
+
+func (m *meteredCache) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	start := time.Now()
+	err := m.Cache.Set(ctx, key, value, ttl)
+	m.metrics.get().observeCache("set", cacheResult(err, metricCacheStored), start)
+	return err
+}
+
+func (m *meteredCache) Add(ctx context.Context, key string, value []byte, ttl time.Duration) (bool, error) {
+	start := time.Now()
+	added, err := m.Cache.Add(ctx, key, value, ttl)
+	result := metricCacheExists
+	if added {
+		result = metricCacheStored
+	}
+	m.metrics.get().observeCache("add", cacheResult(err, result), start)
+	return added, err
+}
+
+func (m *meteredCache) Delete(ctx context.Context, key string) error {
+	start := time.Now()
+	err := m.Cache.Delete(ctx, key)
+	m.metrics.get().observeCache("delete", cacheResult(err, metricCacheDeleted), start)
+	return err
+}
+
+// cacheResult is result, or error when err is set
+func cacheResult(err error, result string) string {
+	if err != nil {
+		return metricCacheError
+	}
+	return result
+}
+
+// Memoize returns the cached JSON value for key, or computes it with fn and
+// caches it for ttl. Cache errors are logged and fall through to fn, so a
+// cache outage degrades to uncached lookups:
+//
+//	user, err := poltergeist.Memoize(c, "user:"+id, time.Minute, func() (*User, error) {
+//	    return repo.FindUser(c.Request.Context(), id)
+//	})
+func Memoize[T any](c *Context, key string, ttl time.Duration, fn func() (T, error)) (T, error) {
+	cache := c.Cache()
+	ctx := c.Context()
+	if data, ok, err := cache.Get(ctx, key); err != nil {
+		c.Logger().Warn("cache get failed", "key", key, "error", err)
+	} else if ok {
+		var v T
+		if err := json.Unmarshal(data, &v); err == nil {
+			return v, nil
+		}
+	}
+
+	v, err := fn()
+	if err != nil {
+		return v, err
+	}
+	if data, err := json.Marshal(v); err == nil {
+		if err := cache.Set(ctx, key, data, ttl); err != nil {
+			c.Logger().Warn("cache set failed", "key", key, "error", err)
+		}
+	}
+	return v, nil
+}
+
+// =============================================================================
+// SERVER INTEGRATION
+// =============================================================================
+
+// UseCache sets the cache used by c.Cache(), Memoize and caching middleware.
+// Operations through it are reported as cache_requests_total and
+// cache_request_duration_seconds.
+//
+//	app.UseCache(cache.NewMemory(nil))
+func (s *Server) UseCache(cache Cache) *Server {
+	if cache != nil && cache != NopCache {
+		cache = &meteredCache{Cache: cache, metrics: s.router.pipeline.metrics}
+	}
+	s.router.pipeline.caches.set(cache)
+	return s
+}
+
+// Cache returns the server cache (NopCache until UseCache is called), wrapped
+// to report metrics
+func (s *Server) Cache() Cache {
+	return s.router.pipeline.caches.get()
+}
+
+// Cache returns the server cache
+func (c *Context) Cache() Cache {
+	return c.pipeline.cache()
+}
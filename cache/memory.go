@@ -0,0 +1,223 @@
+// Package cache provides poltergeist.Cache implementations: a sharded
+// in-memory LRU with TTLs for single instances, and a Redis-backed cache
+// for values shared across instances.
+//
+//	app.UseCache(cache.NewMemory(&cache.MemoryConfig{MaxEntries: 50_000}))
+//	app.UseCache(cache.NewRedis(redis.NewClient(&redis.Options{Addr: "localhost:6379"}), "myapp:"))
+//
+// Handlers reach it with c.Cache() or poltergeist.Memoize.
+package cache
+
+import (
+	"container/list"
+	"context"
+	"hash/fnv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gofuckbiz/poltergeist"
+)
+
+// =============================================================================
+// MEMORY CACHE - Sharded LRU with TTL
+// =============================================================================
+
+// MemoryConfig holds in-memory cache configuration
+type MemoryConfig struct {
+	Shards     int           // Independently locked shards (default: 16)
+	MaxEntries int           // Entries kept across all shards before LRU eviction (default: 10000)
+	DefaultTTL time.Duration // TTL used when Set is called with 0 (default: none)
+}
+
+// DefaultMemoryConfig returns default in-memory cache configuration
+func DefaultMemoryConfig() *MemoryConfig {
+	return &MemoryConfig{
+		Shards:     16,
+		MaxEntries: 10000,
+	}
+}
+
+// Memory is a sharded in-memory LRU cache. Expired entries are dropped when
+// read and are the first to go when a shard is full.
+type Memory struct {
+	shards     []*shard
+	defaultTTL time.Duration
+	hits       atomic.Int64
+	misses     atomic.Int64
+}
+
+// shard is one LRU partition
+type shard struct {
+	mu         sync.Mutex
+	items      map[string]*list.Element
+	lru        *list.List // front = most recently used
+	maxEntries int
+}
+
+// entry is a cached value
+type entry struct {
+	key     string
+	value   []byte
+	expires time.Time // zero for no expiry
+}
+
+// NewMemory creates an in-memory cache
+func NewMemory(config *MemoryConfig) *Memory {
+	cfg := DefaultMemoryConfig()
+	if config != nil {
+		if config.Shards > 0 {
+			cfg.Shards = config.Shards
+		}
+		if config.MaxEntries > 0 {
+			cfg.MaxEntries = config.MaxEntries
+		}
+		cfg.DefaultTTL = config.DefaultTTL
+	}
+
+	perShard := (cfg.MaxEntries + cfg.Shards - 1) / cfg.Shards
+	m := &Memory{shards: make([]*shard, cfg.Shards), defaultTTL: cfg.DefaultTTL}
+	for i := range m.shards {
+		m.shards[i] = &shard{items: make(map[string]*list.Element), lru: list.New(), maxEntries: perShard}
+	}
+	return m
+}
+
+// shardFor picks the shard owning key
+func (m *Memory) shardFor(key string) *shard {
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	return m.shards[h.Sum32()%uint32(len(m.shards))]
+}
+
+// Get returns a copy of the value stored under key
+func (m *Memory) Get(_ context.Context, key string) ([]byte, bool, error) {
+	s := m.shardFor(key)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	el, ok := s.items[key]
+	if !ok {
+		m.misses.Add(1)
+		return nil, false, nil
+	}
+	e := el.Value.(*entry)
+	if e.expired(time.Now()) {
+		s.remove(el)
+		m.misses.Add(1)
+		return nil, false, nil
+	}
+	s.lru.MoveToFront(el)
+	m.hits.Add(1)
+	return append([]byte(nil), e.value...), true, nil
+}
+
+// Set stores a copy of value under key; ttl 0 uses the default TTL
+func (m *Memory) Set(_ context.Context, key string, value []byte, ttl time.Duration) error {
+	m.store(key, value, ttl, false)
+	return nil
+}
+
+// Add stores a copy of value under key unless a live entry is there
+func (m *Memory) Add(_ context.Context, key string, value []byte, ttl time.Duration) (bool, error) {
+	return m.store(key, value, ttl, true), nil
+}
+
+// store sets key, or only adds it when onlyIfAbsent, reporting whether it
+// stored value
+func (m *Memory) store(key string, value []byte, ttl time.Duration, onlyIfAbsent bool) bool {
+	if ttl == 0 {
+		ttl = m.defaultTTL
+	}
+	e := &entry{key: key, value: append([]byte(nil), value...)}
+	if ttl > 0 {
+		e.expires = time.Now().Add(ttl)
+	}
+
+	s := m.shardFor(key)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if el, ok := s.items[key]; ok {
+		if onlyIfAbsent && !el.Value.(*entry).expired(time.Now()) {
+			return false
+		}
+		el.Value = e
+		s.lru.MoveToFront(el)
+		return true
+	}
+	if s.lru.Len() >= s.maxEntries {
+		s.evict()
+	}
+	s.items[key] = s.lru.PushFront(e)
+	return true
+}
+
+// Delete removes key
+func (m *Memory) Delete(_ context.Context, key string) error {
+	s := m.shardFor(key)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if el, ok := s.items[key]; ok {
+		s.remove(el)
+	}
+	return nil
+}
+
+// Len returns the number of entries, including expired ones not yet dropped
+func (m *Memory) Len() int {
+	n := 0
+	for _, s := range m.shards {
+		s.mu.Lock()
+		n += s.lru.Len()
+		s.mu.Unlock()
+	}
+	return n
+}
+
+// Stats returns the hit and miss counts since creation
+func (m *Memory) Stats() (hits, misses int64) {
+	return m.hits.Load(), m.misses.Load()
+}
+
+// Clear removes every entry
+func (m *Memory) Clear() {
+	for _, s := range m.shards {
+		s.mu.Lock()
+		s.items = make(map[string]*list.Element)
+		s.lru.Init()
+		s.mu.Unlock()
+	}
+}
+
+// evictScan bounds how many tail entries evict inspects for expiry
+const evictScan = 8
+
+// evict drops an expired entry if one is near the tail, else the least
+// recently used entry (caller holds mu)
+func (s *shard) evict() {
+	now := time.Now()
+	el := s.lru.Back()
+	for i := 0; el != nil && i < evictScan; el, i = el.Prev(), i+1 {
+		if el.Value.(*entry).expired(now) {
+			s.remove(el)
+			return
+		}
+	}
+	if el := s.lru.Back(); el != nil {
+		s.remove(el)
+	}
+}
+
+// remove unlinks an element (caller holds mu)
+func (s *shard) remove(el *list.Element) {
+	s.lru.Remove(el)
+	delete(s.items, el.Value.(*entry).key)
+}
+
+// expired reports whether the entry is past its TTL
+func (e *entry) expired(now time.Time) bool {
+	return !e.expires.IsZero() && now.After(e.expires)
+}
+
+var _ poltergeist.Cache = (*Memory)(nil)
@@ -0,0 +1,152 @@
+package cache
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+// =============================================================================
+// MEMORY CACHE TESTS
+// =============================================================================
+
+func TestMemory_GetSetDelete(t *testing.T) {
+	ctx := context.Background()
+	m := NewMemory(nil)
+
+	value := []byte("v1")
+	m.Set(ctx, "k", value, 0)
+	value[0] = 'x' // the cache keeps its own copy
+	got, ok, err := m.Get(ctx, "k")
+	if err != nil || !ok || string(got) != "v1" {
+		t.Fatalf("Get = %q, %v, %v; want v1", got, ok, err)
+	}
+	got[0] = 'x'
+	if again, _, _ := m.Get(ctx, "k"); string(again) != "v1" {
+		t.Errorf("returned value aliases the entry: %q", again)
+	}
+
+	m.Delete(ctx, "k")
+	if _, ok, _ := m.Get(ctx, "k"); ok {
+		t.Error("Get after Delete hit")
+	}
+	if hits, misses := m.Stats(); hits != 2 || misses != 1 {
+		t.Errorf("Stats = %d hits, %d misses; want 2, 1", hits, misses)
+	}
+}
+
+func TestMemory_TTL(t *testing.T) {
+	ctx := context.Background()
+	m := NewMemory(&MemoryConfig{DefaultTTL: 20 * time.Millisecond})
+	m.Set(ctx, "default", []byte("v"), 0)
+	m.Set(ctx, "short", []byte("v"), 10*time.Millisecond)
+	m.Set(ctx, "long", []byte("v"), time.Hour)
+
+	time.Sleep(30 * time.Millisecond)
+	for key, want := range map[string]bool{"default": false, "short": false, "long": true} {
+		if _, ok, _ := m.Get(ctx, key); ok != want {
+			t.Errorf("%s: hit = %v, want %v", key, ok, want)
+		}
+	}
+	if m.Len() != 1 {
+		t.Errorf("Len = %d, want expired entries dropped on read", m.Len())
+	}
+}
+
+func TestMemory_Add(t *testing.T) {
+	ctx := context.Background()
+	m := NewMemory(nil)
+
+	if added, _ := m.Add(ctx, "lock", []byte("a"), 10*time.Millisecond); !added {
+		t.Fatal("Add on an absent key did not store")
+	}
+	if added, _ := m.Add(ctx, "lock", []byte("b"), time.Minute); added {
+		t.Error("Add replaced a live entry")
+	}
+	if got, _, _ := m.Get(ctx, "lock"); string(got) != "a" {
+		t.Errorf("value = %q, want the first Add's", got)
+	}
+
+	time.Sleep(20 * time.Millisecond)
+	if added, _ := m.Add(ctx, "lock", []byte("c"), time.Minute); !added {
+		t.Error("Add did not replace an expired entry")
+	}
+
+	// Concurrent Adds: exactly one wins
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	winners := 0
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if added, _ := m.Add(ctx, "race", []byte{1}, 0); added {
+				mu.Lock()
+				winners++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	if winners != 1 {
+		t.Errorf("%d concurrent Adds won, want 1", winners)
+	}
+}
+
+func TestMemory_LRUEviction(t *testing.T) {
+	ctx := context.Background()
+	m := NewMemory(&MemoryConfig{Shards: 1, MaxEntries: 3})
+	for _, key := range []string{"a", "b", "c"} {
+		m.Set(ctx, key, []byte(key), 0)
+	}
+	m.Get(ctx, "a") // a is now the most recently used
+	m.Set(ctx, "d", []byte("d"), 0)
+
+	if _, ok, _ := m.Get(ctx, "b"); ok {
+		t.Error("least recently used entry b survived")
+	}
+	for _, key := range []string{"a", "c", "d"} {
+		if _, ok, _ := m.Get(ctx, key); !ok {
+			t.Errorf("%s was evicted", key)
+		}
+	}
+
+	// Expired entries near the tail go before live ones
+	m = NewMemory(&MemoryConfig{Shards: 1, MaxEntries: 3})
+	m.Set(ctx, "old", []byte("v"), 0)
+	m.Set(ctx, "stale", []byte("v"), time.Millisecond)
+	m.Set(ctx, "new", []byte("v"), 0)
+	time.Sleep(5 * time.Millisecond)
+	m.Set(ctx, "newer", []byte("v"), 0)
+	if _, ok, _ := m.Get(ctx, "old"); !ok {
+		t.Error("live entry evicted while an expired one was kept")
+	}
+}
+
+func TestMemory_Shards(t *testing.T) {
+	ctx := context.Background()
+	m := NewMemory(&MemoryConfig{Shards: 4, MaxEntries: 400})
+	if len(m.shards) != 4 || m.shards[0].maxEntries != 100 {
+		t.Fatalf("%d shards of %d entries, want 4 of 100", len(m.shards), m.shards[0].maxEntries)
+	}
+
+	for i := 0; i < 200; i++ {
+		m.Set(ctx, "key-"+strconv.Itoa(i), []byte{1}, 0)
+	}
+	used := 0
+	for _, s := range m.shards {
+		if s.lru.Len() > 0 {
+			used++
+		}
+	}
+	if used != 4 || m.Len() != 200 {
+		t.Errorf("%d entries spread over %d shards, want 200 over 4", m.Len(), used)
+	}
+
+	m.Clear()
+	if m.Len() != 0 {
+		t.Errorf("Len after Clear = %d", m.Len())
+	}
+}
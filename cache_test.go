@@ -0,0 +1,113 @@
+package poltergeist
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// =============================================================================
+// CACHE TESTS
+// =============================================================================
+
+// mapCache is a minimal Cache for tests
+type mapCache struct {
+	mu    sync.Mutex
+	items map[string][]byte
+	fail  bool
+}
+
+func (m *mapCache) Get(_ context.Context, key string) ([]byte, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.fail {
+		return nil, false, errors.New("cache down")
+	}
+	v, ok := m.items[key]
+	return v, ok, nil
+}
+
+func (m *mapCache) Set(_ context.Context, key string, value []byte, _ time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.fail {
+		return errors.New("cache down")
+	}
+	m.items[key] = value
+	return nil
+}
+
+func (m *mapCache) Add(_ context.Context, key string, value []byte, _ time.Duration) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.fail {
+		return false, errors.New("cache down")
+	}
+	if _, ok := m.items[key]; ok {
+		return false, nil
+	}
+	m.items[key] = value
+	return true, nil
+}
+
+func (m *mapCache) Delete(_ context.Context, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.items, key)
+	return nil
+}
+
+func TestServer_CacheDefaultsToNop(t *testing.T) {
+	app := New()
+	if app.Cache() != NopCache {
+		t.Fatal("Cache() should default to NopCache")
+	}
+	if added, err := NopCache.Add(context.Background(), "lock", []byte{1}, time.Minute); added || !errors.Is(err, ErrNoCache) {
+		t.Errorf("NopCache.Add = %v, %v; want ErrNoCache", added, err)
+	}
+	store := &mapCache{items: map[string][]byte{}}
+	app.UseCache(store)
+	if metered, ok := app.Cache().(*meteredCache); !ok || metered.Unwrap() != store {
+		t.Errorf("Cache() = %T, want the configured cache behind metrics", app.Cache())
+	}
+}
+
+func TestMemoize(t *testing.T) {
+	app := New().UseLogger(NopLogger)
+	store := &mapCache{items: map[string][]byte{}}
+	app.UseCache(store)
+
+	calls := 0
+	app.GET("/users/:id", func(c *Context) error {
+		user, err := Memoize(c, "user:"+c.Param("id"), time.Minute, func() (H, error) {
+			calls++
+			return H{"id": c.Param("id")}, nil
+		})
+		if err != nil {
+			return err
+		}
+		return c.JSON(200, user)
+	})
+
+	for i := 0; i < 3; i++ {
+		w := httptest.NewRecorder()
+		app.Router().ServeHTTP(w, httptest.NewRequest("GET", "/users/1", nil))
+		if w.Body.String() != "{\"id\":\"1\"}\n" {
+			t.Fatalf("body = %q", w.Body.String())
+		}
+	}
+	if calls != 1 {
+		t.Errorf("loader called %d times, want 1", calls)
+	}
+
+	// A failing cache degrades to uncached lookups
+	store.fail = true
+	w := httptest.NewRecorder()
+	app.Router().ServeHTTP(w, httptest.NewRequest("GET", "/users/1", nil))
+	if w.Code != 200 || calls != 2 {
+		t.Errorf("cache outage: status %d, calls %d", w.Code, calls)
+	}
+}
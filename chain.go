@@ -0,0 +1,248 @@
+package poltergeist
+
+import (
+	"reflect"
+	"runtime"
+	"sort"
+)
+
+// =============================================================================
+// MIDDLEWARE CHAIN - Named middleware, priorities and resolved ordering
+// =============================================================================
+
+// Middleware is a middleware with a name and an ordering priority. Lower
+// priorities run earlier (further out in the chain); middleware with equal
+// priority keep their registration order, server first, then group, then
+// route. Plain Use registers priority 0 without a name.
+//
+//	app.UseNamed(poltergeist.Middleware{Name: "recover", Priority: -100, Func: middleware.Recovery()})
+//	app.UseBefore("auth", poltergeist.Middleware{Name: "tenant", Func: tenantMiddleware})
+type Middleware struct {
+	Name     string         // Referenced by UseBefore/UseAfter and shown by Route.Chain
+	Priority int            // Lower runs first (default: 0)
+	Func     MiddlewareFunc // The middleware
+}
+
+// MiddlewareInfo describes one middleware of a resolved route chain
+type MiddlewareInfo struct {
+	Name     string // Middleware name, or the function name for unnamed middleware
+	Priority int    // Ordering priority
+	Scope    string // MiddlewareScopeServer, MiddlewareScopeGroup or MiddlewareScopeRoute
+}
+
+// Levels a middleware can be registered at
+const (
+	MiddlewareScopeServer = "server"
+	MiddlewareScopeGroup  = "group"
+	MiddlewareScopeRoute  = "route"
+)
+
+// middlewareEntry is a registered middleware with its ordering metadata
+type middlewareEntry struct {
+	Middleware
+	scope  string
+	anchor string // name of the middleware to sit next to (UseBefore/UseAfter)
+	after  bool   // sit right after anchor rather than before
+}
+
+// resolvedChain caches a route's ordered middleware for a router version
+type resolvedChain struct {
+	version uint64
+	entries []middlewareEntry
+}
+
+// plainEntries wraps unnamed middleware funcs
+func plainEntries(scope string, middlewares []MiddlewareFunc) []middlewareEntry {
+	entries := make([]middlewareEntry, len(middlewares))
+	for i, mw := range middlewares {
+		entries[i] = middlewareEntry{Middleware: Middleware{Func: mw}, scope: scope}
+	}
+	return entries
+}
+
+// namedEntries wraps named middleware, optionally anchored to another one
+func namedEntries(scope, anchor string, after bool, middlewares []Middleware) []middlewareEntry {
+	entries := make([]middlewareEntry, len(middlewares))
+	for i, mw := range middlewares {
+		entries[i] = middlewareEntry{Middleware: mw, scope: scope, anchor: anchor, after: after}
+	}
+	return entries
+}
+
+// orderMiddleware sorts entries by priority (stable), then moves anchored
+// entries next to their anchor. Anchors that are not registered leave the
+// entry at its priority position.
+func orderMiddleware(entries []middlewareEntry) []middlewareEntry {
+	ordered := make([]middlewareEntry, len(entries))
+	copy(ordered, entries)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		return ordered[i].Priority < ordered[j].Priority
+	})
+
+	for i := 0; i < len(ordered); i++ {
+		entry := ordered[i]
+		if entry.anchor == "" {
+			continue
+		}
+		rest := append(append([]middlewareEntry{}, ordered[:i]...), ordered[i+1:]...)
+		at := -1
+		for j, other := range rest {
+			if other.Name == entry.anchor {
+				at = j
+				break
+			}
+		}
+		if at < 0 {
+			continue
+		}
+		if entry.after {
+			at++
+		}
+		entry.anchor = "" // placed; don't move it again
+		ordered = append(rest[:at], append([]middlewareEntry{entry}, rest[at:]...)...)
+		i = -1 // restart: earlier anchored entries may reference this one
+	}
+	return ordered
+}
+
+// middlewareName returns a name for an unnamed middleware
+func middlewareName(mw MiddlewareFunc) string {
+	if fn := runtime.FuncForPC(reflect.ValueOf(mw).Pointer()); fn != nil {
+		return fn.Name()
+	}
+	return "anonymous"
+}
+
+// =============================================================================
+// ROUTER, GROUP & ROUTE API
+// =============================================================================
+
+// UseNamed adds named server middleware with priorities
+func (r *Router) UseNamed(middlewares ...Middleware) *Router {
+	return r.useEntries(namedEntries(MiddlewareScopeServer, "", false, middlewares))
+}
+
+// UseBefore adds server middleware right before the middleware named anchor,
+// wherever it is registered in a route's chain
+func (r *Router) UseBefore(anchor string, middlewares ...Middleware) *Router {
+	return r.useEntries(namedEntries(MiddlewareScopeServer, anchor, false, middlewares))
+}
+
+// UseAfter adds server middleware right after the middleware named anchor
+func (r *Router) UseAfter(anchor string, middlewares ...Middleware) *Router {
+	return r.useEntries(namedEntries(MiddlewareScopeServer, anchor, true, middlewares))
+}
+
+// useEntries adds server middleware and invalidates resolved chains
+func (r *Router) useEntries(entries []middlewareEntry) *Router {
+	r.middlewares = append(r.middlewares, entries...)
+	r.chainVersion.Add(1)
+	return r
+}
+
+// UseNamed adds named middleware to the group
+func (g *RouteGroup) UseNamed(middlewares ...Middleware) *RouteGroup {
+	g.middlewares = append(g.middlewares, namedEntries(MiddlewareScopeGroup, "", false, middlewares)...)
+	return g
+}
+
+// UseBefore adds group middleware right before the middleware named anchor
+func (g *RouteGroup) UseBefore(anchor string, middlewares ...Middleware) *RouteGroup {
+	g.middlewares = append(g.middlewares, namedEntries(MiddlewareScopeGroup, anchor, false, middlewares)...)
+	return g
+}
+
+// UseAfter adds group middleware right after the middleware named anchor
+func (g *RouteGroup) UseAfter(anchor string, middlewares ...Middleware) *RouteGroup {
+	g.middlewares = append(g.middlewares, namedEntries(MiddlewareScopeGroup, anchor, true, middlewares)...)
+	return g
+}
+
+// Use adds middleware to the route
+func (r *Route) Use(middlewares ...MiddlewareFunc) *Route {
+	r.Middlewares = append(r.Middlewares, middlewares...)
+	return r.useEntries(plainEntries(MiddlewareScopeRoute, middlewares))
+}
+
+// UseNamed adds named middleware to the route
+func (r *Route) UseNamed(middlewares ...Middleware) *Route {
+	return r.useEntries(namedEntries(MiddlewareScopeRoute, "", false, middlewares))
+}
+
+// UseBefore adds route middleware right before the middleware named anchor,
+// e.g. to run ahead of a server-wide middleware on this route only
+func (r *Route) UseBefore(anchor string, middlewares ...Middleware) *Route {
+	return r.useEntries(namedEntries(MiddlewareScopeRoute, anchor, false, middlewares))
+}
+
+// UseAfter adds route middleware right after the middleware named anchor
+func (r *Route) UseAfter(anchor string, middlewares ...Middleware) *Route {
+	return r.useEntries(namedEntries(MiddlewareScopeRoute, anchor, true, middlewares))
+}
+
+// useEntries adds route middleware and drops the resolved chain
+func (r *Route) useEntries(entries []middlewareEntry) *Route {
+	r.middlewares = append(r.middlewares, entries...)
+	r.chain.Store(nil)
+	return r
+}
+
+// Chain returns the route's resolved middleware, outermost first
+//
+//	for _, mw := range app.Router().Lookup("GET", "/users/1").Chain() {
+//	    fmt.Println(mw.Scope, mw.Priority, mw.Name)
+//	}
+func (r *Route) Chain() []MiddlewareInfo {
+	entries := r.resolveMiddleware()
+	infos := make([]MiddlewareInfo, len(entries))
+	for i, entry := range entries {
+		name := entry.Name
+		if name == "" {
+			name = middlewareName(entry.Func)
+		}
+		infos[i] = MiddlewareInfo{Name: name, Priority: entry.Priority, Scope: entry.scope}
+	}
+	return infos
+}
+
+// resolveMiddleware returns the ordered server and route middleware,
+// cached until middleware is added
+func (r *Route) resolveMiddleware() []middlewareEntry {
+	var version uint64
+	var global []middlewareEntry
+	if r.router != nil {
+		version = r.router.chainVersion.Load()
+		global = r.router.middlewares
+	}
+	if cached := r.chain.Load(); cached != nil && cached.version == version {
+		return cached.entries
+	}
+
+	all := make([]middlewareEntry, 0, len(global)+len(r.middlewares))
+	all = append(append(all, global...), r.middlewares...)
+	resolved := &resolvedChain{version: version, entries: orderMiddleware(all)}
+	r.chain.Store(resolved)
+	return resolved.entries
+}
+
+// =============================================================================
+// SERVER INTEGRATION
+// =============================================================================
+
+// UseNamed adds named server middleware with priorities
+func (s *Server) UseNamed(middlewares ...Middleware) *Server {
+	s.router.UseNamed(middlewares...)
+	return s
+}
+
+// UseBefore adds server middleware right before the middleware named anchor
+func (s *Server) UseBefore(anchor string, middlewares ...Middleware) *Server {
+	s.router.UseBefore(anchor, middlewares...)
+	return s
+}
+
+// UseAfter adds server middleware right after the middleware named anchor
+func (s *Server) UseAfter(anchor string, middlewares ...Middleware) *Server {
+	s.router.UseAfter(anchor, middlewares...)
+	return s
+}
@@ -0,0 +1,62 @@
+package poltergeist
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// =============================================================================
+// MIDDLEWARE CHAIN TESTS
+// =============================================================================
+
+// trace returns a middleware appending name to the X-Trace header
+func trace(name string) MiddlewareFunc {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(c *Context) error {
+			c.Writer.Header().Add("X-Trace", name)
+			return next(c)
+		}
+	}
+}
+
+func TestMiddlewareOrdering(t *testing.T) {
+	app := New()
+	app.Use(trace("logger"))
+	app.UseNamed(Middleware{Name: "auth", Func: trace("auth")})
+	app.UseNamed(Middleware{Name: "recover", Priority: -10, Func: trace("recover")})
+
+	api := app.Group("/api", trace("group"))
+	api.UseBefore("auth", Middleware{Name: "tenant", Func: trace("tenant")})
+
+	route := api.GET("/items", func(c *Context) error { return c.NoContent() }, trace("route"))
+	route.UseAfter("recover", Middleware{Name: "timing", Func: trace("timing")})
+
+	w := httptest.NewRecorder()
+	app.ServeHTTP(w, httptest.NewRequest("GET", "/api/items", nil))
+
+	want := "recover,timing,logger,tenant,auth,group,route"
+	if got := strings.Join(w.Header().Values("X-Trace"), ","); got != want {
+		t.Errorf("order = %s, want %s", got, want)
+	}
+
+	var names []string
+	for _, mw := range route.Chain() {
+		names = append(names, mw.Scope+":"+mw.Name)
+	}
+	if names[0] != "server:recover" || names[1] != "route:timing" || names[4] != "server:auth" {
+		t.Errorf("Chain() = %v", names)
+	}
+	if !strings.Contains(names[2], "trace") {
+		t.Errorf("unnamed middleware should report its function name, got %q", names[2])
+	}
+
+	// Server middleware added later still reaches existing routes
+	app.UseAfter("auth", Middleware{Name: "audit", Func: trace("audit")})
+	w = httptest.NewRecorder()
+	app.ServeHTTP(w, httptest.NewRequest("GET", "/api/items", nil))
+	want = "recover,timing,logger,tenant,auth,audit,group,route"
+	if got := strings.Join(w.Header().Values("X-Trace"), ","); got != want {
+		t.Errorf("after UseAfter: order = %s, want %s", got, want)
+	}
+}
@@ -11,12 +11,15 @@ import (
 
 // Content types
 const (
-	ContentTypeJSON      = "application/json; charset=utf-8"
-	ContentTypeText      = "text/plain; charset=utf-8"
-	ContentTypeHTML      = "text/html; charset=utf-8"
-	ContentTypeSSE       = "text/event-stream"
-	ContentTypeForm      = "application/x-www-form-urlencoded"
-	ContentTypeMultipart = "multipart/form-data"
+	ContentTypeJSON       = "application/json; charset=utf-8"
+	ContentTypeText       = "text/plain; charset=utf-8"
+	ContentTypeHTML       = "text/html; charset=utf-8"
+	ContentTypeSSE        = "text/event-stream"
+	ContentTypeForm       = "application/x-www-form-urlencoded"
+	ContentTypeMultipart  = "multipart/form-data"
+	ContentTypeXML        = "application/xml; charset=utf-8"
+	ContentTypeYAML       = "application/yaml; charset=utf-8"
+	ContentTypeJavaScript = "application/javascript; charset=utf-8"
 )
 
 // Header names
@@ -32,6 +35,26 @@ const (
 	HeaderXRealIP            = "X-Real-IP"
 	HeaderXRequestID         = "X-Request-ID"
 	HeaderAccessControlAllow = "Access-Control-Allow-Origin"
+	HeaderDeprecation        = "Deprecation"
+	HeaderSunset             = "Sunset"
+	HeaderLink               = "Link"
+	HeaderXTotalCount        = "X-Total-Count"
+	HeaderAllow              = "Allow"
+)
+
+// Context keys set by the framework
+const (
+	ContextKeyError     = "error"      // error returned by the handler chain
+	ContextKeyHTTPError = "http_error" // *HTTPError it was resolved to
+
+	ContextKeyResponseMeta = "response_meta" // meta object of the Envelope() transform
+	ContextKeyPagination   = "pagination"    // Pagination parsed by c.Pagination()
+	ContextKeyRequestID    = "request_id"    // request ID set by middleware.RequestID()
+	ContextKeyPrincipal    = "principal"     // authenticated caller resolved by auth middleware
+	ContextKeyLocale       = "locale"        // language negotiated by middleware.I18n
+	ContextKeyI18n         = "i18n"          // *I18n catalogs used by c.T
+	ContextKeyPanic        = "panic"         // *PanicError recovered by middleware.Recovery
+	ContextKeyBot          = "bot"           // *middleware.BotInfo of a client classified as a bot
 )
 
 // AllHTTPMethods contains all standard HTTP methods
@@ -46,6 +69,16 @@ var AllHTTPMethods = []string{
 	http.MethodHead,
 }
 
+// List query parameters
+const (
+	QueryPage    = "page"
+	QueryLimit   = "limit"
+	QueryPerPage = "per_page"
+	QueryCursor  = "cursor"
+	QuerySort    = "sort"
+	QueryFilter  = "filter"
+)
+
 // Default timeouts
 const (
 	DefaultReadTimeout     = 30 * time.Second
@@ -56,9 +89,11 @@ const (
 
 // Default sizes
 const (
-	DefaultMaxHeaderBytes = 1 << 20 // 1MB
-	DefaultBufferSize     = 256
-	DefaultMaxMessageSize = 512 * 1024 // 512KB
+	DefaultMaxHeaderBytes     = 1 << 20 // 1MB
+	DefaultBufferSize         = 256
+	DefaultMaxMessageSize     = 512 * 1024 // 512KB
+	DefaultMaxMultipartMemory = 32 << 20   // 32MB of multipart form held in memory by Bind
+	DefaultMaxBodyBuffer      = 4 << 20    // 4MB buffered by c.Body()
 )
 
 // WebSocket defaults
@@ -70,6 +105,7 @@ const (
 	DefaultWSWriteTimeout     = 10 * time.Second
 	DefaultWSReadTimeout      = 60 * time.Second
 	DefaultWSHandshakeTimeout = 10 * time.Second
+	DefaultWSResumeTTL        = 30 * time.Second // sessions kept for WSHub.EnableResumption
 )
 
 // SSE defaults
@@ -79,7 +115,22 @@ const (
 	DefaultSSEWriteTimeout      = 10 * time.Second
 )
 
+// Pagination defaults
+const (
+	DefaultPageLimit    = 20
+	DefaultMaxPageLimit = 100
+)
+
 // Hub shutdown defaults
 const (
 	DefaultHubShutdownTimeout = 30 * time.Second
 )
+
+// Task queue defaults
+const (
+	DefaultTaskWorkers      = 4
+	DefaultTaskQueueSize    = 256
+	DefaultTaskMaxRetries   = 3
+	DefaultTaskRetryBackoff = 1 * time.Second
+	DefaultTaskMaxBackoff   = 30 * time.Second
+)
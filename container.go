@@ -0,0 +1,362 @@
+package poltergeist
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// =============================================================================
+// CONTAINER - Lightweight dependency injection
+// =============================================================================
+
+// Container resolves dependencies from registered providers. A provider is a
+// constructor function whose parameters are themselves resolved:
+//
+//	app.Provide(NewDB)          // func NewDB() (*sql.DB, error)
+//	app.Provide(NewUserService) // func NewUserService(db *sql.DB) *UserService
+//
+// Providers build singletons on first use, except those that take *Context
+// or context.Context (directly or through a dependency): those are
+// request-scoped and built once per request.
+type Container struct {
+	mu        sync.Mutex
+	providers map[reflect.Type]*provider
+	built     []any // singletons in construction order, closed in reverse
+}
+
+// provider builds one type
+type provider struct {
+	mu    sync.Mutex
+	fn    reflect.Value // constructor; invalid for plain values
+	value reflect.Value // singleton instance once built
+	built bool
+}
+
+// Dependency injection errors
+var (
+	ErrNoProvider       = errors.New("no provider registered")
+	ErrDependencyCycle  = errors.New("dependency cycle")
+	ErrScopedDependency = errors.New("request-scoped dependency outside a request")
+)
+
+var (
+	contextPtrType = reflect.TypeOf((*Context)(nil))
+	stdContextType = reflect.TypeOf((*context.Context)(nil)).Elem()
+	errorType      = reflect.TypeOf((*error)(nil)).Elem()
+	handlerType    = reflect.TypeOf(HandlerFunc(nil))
+)
+
+// NewContainer creates an empty container
+func NewContainer() *Container {
+	return &Container{providers: make(map[reflect.Type]*provider)}
+}
+
+// Provide registers constructors returning T or (T, error). Values that are
+// not functions are registered as ready-made singletons of their own type.
+func (ct *Container) Provide(constructors ...any) error {
+	ct.mu.Lock()
+	defer ct.mu.Unlock()
+	for _, constructor := range constructors {
+		v := reflect.ValueOf(constructor)
+		if v.Kind() != reflect.Func {
+			ct.providers[v.Type()] = &provider{value: v, built: true}
+			continue
+		}
+		t := v.Type()
+		if t.NumOut() == 0 || t.NumOut() > 2 || (t.NumOut() == 2 && t.Out(1) != errorType) {
+			return fmt.Errorf("poltergeist: provider %s must return T or (T, error)", t)
+		}
+		ct.providers[t.Out(0)] = &provider{fn: v}
+	}
+	return nil
+}
+
+// Resolve returns the value for a type; c may be nil outside requests
+func (ct *Container) Resolve(t reflect.Type, c *Context) (reflect.Value, error) {
+	return ct.resolve(t, c, nil)
+}
+
+// resolve builds t, tracking the chain of types being built for cycle errors
+func (ct *Container) resolve(t reflect.Type, c *Context, chain []reflect.Type) (reflect.Value, error) {
+	switch t {
+	case contextPtrType:
+		if c == nil {
+			return reflect.Value{}, fmt.Errorf("%w: %s", ErrScopedDependency, t)
+		}
+		return reflect.ValueOf(c), nil
+	case stdContextType:
+		if c == nil || c.Request == nil {
+			return reflect.Value{}, fmt.Errorf("%w: %s", ErrScopedDependency, t)
+		}
+		return reflect.ValueOf(c.Request.Context()), nil
+	}
+
+	for _, seen := range chain {
+		if seen == t {
+			return reflect.Value{}, fmt.Errorf("%w: %s", ErrDependencyCycle, formatChain(append(chain, t)))
+		}
+	}
+	chain = append(chain, t)
+
+	ct.mu.Lock()
+	p, ok := ct.providers[t]
+	scoped := ok && ct.scoped(t, nil)
+	ct.mu.Unlock()
+	if !ok {
+		return reflect.Value{}, fmt.Errorf("%w for %s", ErrNoProvider, t)
+	}
+
+	if scoped {
+		if c == nil {
+			return reflect.Value{}, fmt.Errorf("%w: %s", ErrScopedDependency, t)
+		}
+		key := scopedKey(t)
+		if v, ok := c.Get(key); ok {
+			return v.(reflect.Value), nil
+		}
+		v, err := ct.call(p.fn, c, chain)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		c.Set(key, v)
+		return v, nil
+	}
+
+	// Singletons are built once; dependencies form a DAG, so holding the
+	// provider lock while building its dependencies cannot deadlock
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.built {
+		return p.value, nil
+	}
+	v, err := ct.call(p.fn, nil, chain)
+	if err != nil {
+		return reflect.Value{}, err
+	}
+	p.value, p.built = v, true
+	ct.mu.Lock()
+	ct.built = append(ct.built, v.Interface())
+	ct.mu.Unlock()
+	return v, nil
+}
+
+// scoped reports whether building t needs a request (caller holds mu)
+func (ct *Container) scoped(t reflect.Type, seen map[reflect.Type]bool) bool {
+	if t == contextPtrType || t == stdContextType {
+		return true
+	}
+	p, ok := ct.providers[t]
+	if !ok || !p.fn.IsValid() || seen[t] {
+		return false
+	}
+	if seen == nil {
+		seen = make(map[reflect.Type]bool)
+	}
+	seen[t] = true
+	for i := 0; i < p.fn.Type().NumIn(); i++ {
+		if ct.scoped(p.fn.Type().In(i), seen) {
+			return true
+		}
+	}
+	return false
+}
+
+// call invokes fn with resolved arguments
+func (ct *Container) call(fn reflect.Value, c *Context, chain []reflect.Type) (reflect.Value, error) {
+	args, err := ct.args(fn.Type(), c, chain)
+	if err != nil {
+		return reflect.Value{}, err
+	}
+	out := fn.Call(args)
+	if len(out) == 2 && !out[1].IsNil() {
+		return reflect.Value{}, out[1].Interface().(error)
+	}
+	return out[0], nil
+}
+
+// args resolves the parameters of a function type
+func (ct *Container) args(t reflect.Type, c *Context, chain []reflect.Type) ([]reflect.Value, error) {
+	args := make([]reflect.Value, t.NumIn())
+	for i := range args {
+		v, err := ct.resolve(t.In(i), c, chain)
+		if err != nil {
+			return nil, err
+		}
+		args[i] = v
+	}
+	return args, nil
+}
+
+// Close closes singletons implementing io.Closer, newest first
+func (ct *Container) Close(ctx context.Context) error {
+	ct.mu.Lock()
+	built := ct.built
+	ct.built = nil
+	ct.mu.Unlock()
+
+	var errs []error
+	for i := len(built) - 1; i >= 0; i-- {
+		if closer, ok := built[i].(io.Closer); ok {
+			if err := closer.Close(); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// scopedKey is the context store key of a request-scoped value
+func scopedKey(t reflect.Type) string {
+	return "poltergeist.di." + t.String()
+}
+
+// formatChain renders a dependency chain as "A -> B -> A"
+func formatChain(chain []reflect.Type) string {
+	names := make([]string, len(chain))
+	for i, t := range chain {
+		names[i] = t.String()
+	}
+	return strings.Join(names, " -> ")
+}
+
+// =============================================================================
+// INJECTION - Handlers and controllers built from the container
+// =============================================================================
+
+// Inject adapts fn into a handler. fn is either a handler taking injected
+// arguments, called per request:
+//
+//	app.GET("/users", app.Inject(func(c *poltergeist.Context, users *UserService) error { ... }))
+//
+// or a constructor returning a HandlerFunc, called once on first use:
+//
+//	app.GET("/users", app.Inject(func(users *UserService) poltergeist.HandlerFunc { ... }))
+func (ct *Container) Inject(fn any) HandlerFunc {
+	v := reflect.ValueOf(fn)
+	t := v.Type()
+	if v.Kind() != reflect.Func || t.NumOut() != 1 {
+		panic(fmt.Sprintf("poltergeist: Inject expects a handler or handler constructor, got %T", fn))
+	}
+
+	switch {
+	case t.Out(0) == errorType:
+		return func(c *Context) error {
+			args, err := ct.args(t, c, nil)
+			if err != nil {
+				return err
+			}
+			if out := v.Call(args)[0]; !out.IsNil() {
+				return out.Interface().(error)
+			}
+			return nil
+		}
+	case t.Out(0).ConvertibleTo(handlerType):
+		var once sync.Once
+		var handler HandlerFunc
+		var buildErr error
+		return func(c *Context) error {
+			once.Do(func() {
+				var out reflect.Value
+				if out, buildErr = ct.call(v, nil, nil); buildErr == nil {
+					handler = out.Convert(handlerType).Interface().(HandlerFunc)
+				}
+			})
+			if buildErr != nil {
+				return buildErr
+			}
+			return handler(c)
+		}
+	}
+	panic(fmt.Sprintf("poltergeist: Inject expects a handler or handler constructor, got %T", fn))
+}
+
+// Controller registers its routes; exported fields tagged `inject:""` are
+// filled from the container before Routes is called:
+//
+//	type UserController struct {
+//	    Users *UserService `inject:""`
+//	}
+//
+//	func (uc *UserController) Routes(r poltergeist.RouteRegistrar) {
+//	    r.GET("/users/:id", uc.Get)
+//	}
+type Controller interface {
+	Routes(r RouteRegistrar)
+}
+
+// InjectFields fills the `inject:""` fields of a struct pointer with
+// singletons from the container
+func (ct *Container) InjectFields(target any) error {
+	v := reflect.ValueOf(target)
+	if v.Kind() != reflect.Pointer || v.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("poltergeist: InjectFields expects a struct pointer, got %T", target)
+	}
+	v = v.Elem()
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Type().Field(i)
+		if _, ok := field.Tag.Lookup("inject"); !ok {
+			continue
+		}
+		if !field.IsExported() {
+			return fmt.Errorf("poltergeist: %s.%s is tagged inject but unexported", v.Type(), field.Name)
+		}
+		dep, err := ct.Resolve(field.Type, nil)
+		if err != nil {
+			return fmt.Errorf("poltergeist: injecting %s.%s: %w", v.Type(), field.Name, err)
+		}
+		v.Field(i).Set(dep)
+	}
+	return nil
+}
+
+// Resolve returns the dependency of type T for the request, e.g.
+//
+//	users, err := poltergeist.Resolve[*UserService](c)
+func Resolve[T any](c *Context) (T, error) {
+	var zero T
+	if c.container == nil {
+		return zero, fmt.Errorf("%w for %T: context has no container", ErrNoProvider, zero)
+	}
+	v, err := c.container.Resolve(reflect.TypeOf((*T)(nil)).Elem(), c)
+	if err != nil {
+		return zero, err
+	}
+	return v.Interface().(T), nil
+}
+
+// =============================================================================
+// SERVER INTEGRATION
+// =============================================================================
+
+// Container returns the dependency injection container. Singletons that
+// implement io.Closer are closed on shutdown.
+func (s *Server) Container() *Container {
+	s.containerOnce.Do(func() {
+		s.OnShutdown(s.router.container.Close)
+	})
+	return s.router.container
+}
+
+// Provide registers dependency constructors (see Container)
+func (s *Server) Provide(constructors ...any) error {
+	return s.Container().Provide(constructors...)
+}
+
+// Inject adapts an injected handler or handler constructor (see Container.Inject)
+func (s *Server) Inject(fn any) HandlerFunc {
+	return s.Container().Inject(fn)
+}
+
+// RegisterController injects the controller's fields and registers its routes
+func (s *Server) RegisterController(controller Controller) error {
+	if err := s.Container().InjectFields(controller); err != nil {
+		return err
+	}
+	controller.Routes(s)
+	return nil
+}
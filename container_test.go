@@ -0,0 +1,148 @@
+package poltergeist
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"testing"
+)
+
+// =============================================================================
+// DEPENDENCY INJECTION TESTS
+// =============================================================================
+
+type diConfig struct{ Name string }
+
+type diRepo struct {
+	config *diConfig
+	closed bool
+}
+
+func (r *diRepo) Close() error {
+	r.closed = true
+	return nil
+}
+
+type diService struct{ repo *diRepo }
+
+type diRequestUser struct{ Name string }
+
+type diController struct {
+	Service *diService `inject:""`
+}
+
+func (dc *diController) Routes(r RouteRegistrar) {
+	r.GET("/controller", dc.get)
+}
+
+func (dc *diController) get(c *Context) error {
+	return c.String(200, dc.Service.repo.config.Name)
+}
+
+func TestContainer_Singletons(t *testing.T) {
+	ct := NewContainer()
+	builds := 0
+	err := ct.Provide(
+		&diConfig{Name: "ghost"},
+		func(cfg *diConfig) *diRepo {
+			builds++
+			return &diRepo{config: cfg}
+		},
+		func(repo *diRepo) (*diService, error) { return &diService{repo: repo}, nil },
+	)
+	if err != nil {
+		t.Fatalf("Provide: %v", err)
+	}
+
+	var controller diController
+	if err := ct.InjectFields(&controller); err != nil {
+		t.Fatalf("InjectFields: %v", err)
+	}
+	if controller.Service == nil || controller.Service.repo.config.Name != "ghost" {
+		t.Fatalf("injected service = %+v", controller.Service)
+	}
+	if err := ct.InjectFields(&diController{}); err != nil {
+		t.Fatalf("InjectFields: %v", err)
+	}
+	if builds != 1 {
+		t.Errorf("repo built %d times, want 1", builds)
+	}
+
+	if err := ct.Close(context.Background()); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if !controller.Service.repo.closed {
+		t.Error("closer singleton was not closed")
+	}
+}
+
+func TestContainer_Errors(t *testing.T) {
+	ct := NewContainer()
+	if err := ct.Provide(func() {}); err == nil {
+		t.Error("Provide accepted a constructor without results")
+	}
+
+	type a struct{}
+	type b struct{}
+	ct.Provide(func(*b) *a { return nil }, func(*a) *b { return nil })
+	if err := ct.InjectFields(&struct {
+		A *a `inject:""`
+	}{}); !errors.Is(err, ErrDependencyCycle) {
+		t.Errorf("cycle error = %v", err)
+	}
+	if err := ct.InjectFields(&struct {
+		S *diService `inject:""`
+	}{}); !errors.Is(err, ErrNoProvider) {
+		t.Errorf("missing provider error = %v", err)
+	}
+
+	ct.Provide(func(c *Context) *diRequestUser { return &diRequestUser{} })
+	if err := ct.InjectFields(&struct {
+		U *diRequestUser `inject:""`
+	}{}); !errors.Is(err, ErrScopedDependency) {
+		t.Errorf("scoped outside request error = %v", err)
+	}
+}
+
+func TestServer_Injection(t *testing.T) {
+	app := New()
+	builds := 0
+	app.Provide(
+		&diConfig{Name: "ghost"},
+		func(cfg *diConfig) *diRepo { return &diRepo{config: cfg} },
+		func(repo *diRepo) *diService { return &diService{repo: repo} },
+		func(c *Context) *diRequestUser {
+			builds++
+			return &diRequestUser{Name: c.Header("X-User")}
+		},
+	)
+
+	if err := app.RegisterController(&diController{}); err != nil {
+		t.Fatalf("RegisterController: %v", err)
+	}
+	app.GET("/handler", app.Inject(func(c *Context, user *diRequestUser, svc *diService) error {
+		again, err := Resolve[*diRequestUser](c)
+		if err != nil || again != user {
+			t.Errorf("request-scoped value not reused: %v", err)
+		}
+		return c.String(200, user.Name+"@"+svc.repo.config.Name)
+	}))
+	app.GET("/constructor", app.Inject(func(svc *diService) HandlerFunc {
+		return func(c *Context) error {
+			return c.String(200, svc.repo.config.Name)
+		}
+	}))
+
+	for path, want := range map[string]string{"/controller": "ghost", "/handler": "casper@ghost", "/constructor": "ghost"} {
+		req := httptest.NewRequest("GET", path, nil)
+		req.Header.Set("X-User", "casper")
+		w := httptest.NewRecorder()
+		app.Router().ServeHTTP(w, req)
+		if w.Body.String() != want {
+			t.Errorf("GET %s = %d %q, want %q", path, w.Code, w.Body.String(), want)
+		}
+	}
+	if builds != 1 {
+		t.Errorf("request-scoped provider built %d times, want 1", builds)
+	}
+}
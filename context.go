@@ -2,11 +2,18 @@ package poltergeist
 
 import (
 	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
 	"io"
 	"net/http"
+	"reflect"
 	"strconv"
 	"strings"
 	"sync"
+	"time"
+
+	"gopkg.in/yaml.v3"
 )
 
 // =============================================================================
@@ -28,7 +35,17 @@ type Context struct {
 	SSE *SSEWriter // SSE writer (if streaming)
 
 	// Internal
-	pipeline *EventPipeline
+	pipeline  *EventPipeline
+	container *Container
+	router    *Router
+	route     *Route
+
+	paramValues []string         // reused buffer for values captured by the router
+	body        []byte           // request body buffered by Body()
+	transform   *transformWriter // response buffered for transforms, see bufferResponse
+	logger      Logger           // request logger built by Logger() or set by SetLogger()
+	loggerID    string           // request ID the built logger carries
+	loggerSet   bool             // logger was set by SetLogger()
 }
 
 // NewContext creates a new Context instance (exported for testing)
@@ -46,28 +63,28 @@ func NewContext(w http.ResponseWriter, r *http.Request) *Context {
 func (c *Context) reset(w http.ResponseWriter, r *http.Request) {
 	c.Writer = w
 	c.Request = r
-	c.Params = make(map[string]string)
+	if c.Params == nil {
+		c.Params = make(map[string]string)
+	} else {
+		clear(c.Params)
+	}
 	c.statusCode = http.StatusOK
 	c.written = false
 	c.keys = make(map[string]any)
 	c.WS = nil
 	c.SSE = nil
+	c.route = nil
+	c.body = nil
+	c.transform = nil
+	c.logger = nil
+	c.loggerID = ""
+	c.loggerSet = false
 }
 
 // =============================================================================
 // REQUEST HELPERS - Reading request data
 // =============================================================================
 
-// Bind parses JSON request body into the provided struct
-func (c *Context) Bind(v any) error {
-	body, err := io.ReadAll(c.Request.Body)
-	if err != nil {
-		return err
-	}
-	defer c.Request.Body.Close()
-	return json.Unmarshal(body, v)
-}
-
 // --- Query Parameters ---
 
 // Query returns a query parameter by key
@@ -102,6 +119,50 @@ func (c *Context) QueryBool(key string) bool {
 	return v == "true" || v == "1" || v == "yes"
 }
 
+// QueryFloat returns a query parameter as float64; invalid values return a
+// BindError (rendered as 400)
+func (c *Context) QueryFloat(key string) (float64, error) {
+	v, err := strconv.ParseFloat(c.Query(key), 64)
+	if err != nil {
+		return 0, &BindError{Source: BindSourceQuery, Field: key, Err: err}
+	}
+	return v, nil
+}
+
+// QueryTime parses a query parameter with layout (e.g. time.RFC3339 or
+// time.DateOnly); invalid values return a BindError (rendered as 400)
+func (c *Context) QueryTime(key, layout string) (time.Time, error) {
+	v, err := time.Parse(layout, c.Query(key))
+	if err != nil {
+		return time.Time{}, &BindError{Source: BindSourceQuery, Field: key, Err: err}
+	}
+	return v, nil
+}
+
+// QueryArray returns every value of a repeated query parameter, accepting
+// both "?tag=a&tag=b" and "?tag[]=a&tag[]=b"
+func (c *Context) QueryArray(key string) []string {
+	query := c.Request.URL.Query()
+	return append(query[key], query[key+"[]"]...)
+}
+
+// QueryMap returns the bracketed entries of a query parameter as a map, e.g.
+// "?filter[status]=open&filter[owner]=me" gives {"status": "open", "owner": "me"}
+// for key "filter"
+func (c *Context) QueryMap(key string) map[string]string {
+	result := make(map[string]string)
+	prefix := key + "["
+	for name, values := range c.Request.URL.Query() {
+		if len(values) == 0 || !strings.HasPrefix(name, prefix) || !strings.HasSuffix(name, "]") {
+			continue
+		}
+		if field := name[len(prefix) : len(name)-1]; field != "" {
+			result[field] = values[0]
+		}
+	}
+	return result
+}
+
 // --- Path Parameters ---
 
 // Param returns a path parameter by key
@@ -114,6 +175,58 @@ func (c *Context) ParamInt(key string) (int, error) {
 	return strconv.Atoi(c.Param(key))
 }
 
+// ParamUUID returns a path parameter that must be a UUID in canonical
+// 8-4-4-4-12 hex form, lowercased; other values return a BindError
+// (rendered as 400)
+func (c *Context) ParamUUID(key string) (string, error) {
+	v := c.Param(key)
+	if !isUUID(v) {
+		return "", &BindError{Source: BindSourcePath, Field: key, Err: errInvalidUUID}
+	}
+	return strings.ToLower(v), nil
+}
+
+// errInvalidUUID is the BindError cause for malformed UUID parameters
+var errInvalidUUID = errors.New("invalid UUID")
+
+// isUUID reports whether s is a hyphenated 36-character hex UUID
+func isUUID(s string) bool {
+	if len(s) != 36 {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		switch i {
+		case 8, 13, 18, 23:
+			if s[i] != '-' {
+				return false
+			}
+		default:
+			ch := s[i]
+			if !('0' <= ch && ch <= '9' || 'a' <= ch && ch <= 'f' || 'A' <= ch && ch <= 'F') {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// Route returns the matched route (nil for 404/405 and non-request contexts)
+func (c *Context) Route() *Route {
+	return c.route
+}
+
+// RequestID returns the request ID set by middleware.RequestID(), falling
+// back to the X-Request-ID request header
+func (c *Context) RequestID() string {
+	if id := c.GetString(ContextKeyRequestID); id != "" {
+		return id
+	}
+	if c.Request == nil {
+		return ""
+	}
+	return c.Header(HeaderXRequestID)
+}
+
 // --- Headers ---
 
 // Header returns a request header value
@@ -152,30 +265,6 @@ func (c *Context) FullURL() string {
 	return scheme + "://" + c.Request.Host + c.Request.RequestURI
 }
 
-// ClientIP extracts the client IP address from request
-// Checks proxy headers first, then falls back to RemoteAddr
-func (c *Context) ClientIP() string {
-	// Check proxy headers in order of preference
-	for _, header := range []string{HeaderXForwardedFor, HeaderXRealIP} {
-		if ip := c.Header(header); ip != "" {
-			// X-Forwarded-For can contain multiple IPs, take the first
-			if header == HeaderXForwardedFor {
-				if idx := strings.Index(ip, ","); idx != -1 {
-					ip = strings.TrimSpace(ip[:idx])
-				}
-			}
-			return ip
-		}
-	}
-
-	// Fall back to RemoteAddr (strip port if present)
-	addr := c.Request.RemoteAddr
-	if idx := strings.LastIndex(addr, ":"); idx != -1 {
-		return addr[:idx]
-	}
-	return addr
-}
-
 // =============================================================================
 // RESPONSE HELPERS - Writing responses (DRY: common write pattern)
 // =============================================================================
@@ -184,25 +273,58 @@ func (c *Context) ClientIP() string {
 func (c *Context) writeResponse(code int, contentType string, data []byte) error {
 	c.SetHeader(HeaderContentType, contentType)
 	c.Writer.WriteHeader(code)
+	c.statusCode = code
 	c.written = true
 	_, err := c.Writer.Write(data)
 	return err
 }
 
+// StatusCode returns the response status code set so far (200 until a
+// response is written or Status is called)
+func (c *Context) StatusCode() int {
+	return c.statusCode
+}
+
 // Status sets the response status code (chainable)
 func (c *Context) Status(code int) *Context {
 	c.statusCode = code
 	return c
 }
 
-// JSON sends a JSON response
+// JSON sends a JSON response (indented or prefixed when JSONConfig says so)
 func (c *Context) JSON(code int, v any) error {
+	if cfg := c.jsonConfig(); cfg.Indent != "" || cfg.Secure {
+		prefix := ""
+		if cfg.Secure {
+			prefix = cfg.SecurePrefix
+		}
+		return c.renderJSON(code, v, cfg.Indent, prefix)
+	}
 	c.SetHeader(HeaderContentType, ContentTypeJSON)
 	c.Writer.WriteHeader(code)
+	c.statusCode = code
 	c.written = true
 	return json.NewEncoder(c.Writer).Encode(v)
 }
 
+// XML sends an XML response with the standard XML declaration
+func (c *Context) XML(code int, v any) error {
+	data, err := xml.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return c.writeResponse(code, ContentTypeXML, append([]byte(xml.Header), data...))
+}
+
+// YAML sends a YAML response (gopkg.in/yaml.v3, `yaml` struct tags)
+func (c *Context) YAML(code int, v any) error {
+	data, err := yaml.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return c.writeResponse(code, ContentTypeYAML, data)
+}
+
 // String sends a plain text response
 func (c *Context) String(code int, s string) error {
 	return c.writeResponse(code, ContentTypeText, []byte(s))
@@ -221,6 +343,7 @@ func (c *Context) Bytes(code int, contentType string, data []byte) error {
 // NoContent sends a 204 No Content response
 func (c *Context) NoContent() error {
 	c.Writer.WriteHeader(http.StatusNoContent)
+	c.statusCode = http.StatusNoContent
 	c.written = true
 	return nil
 }
@@ -228,16 +351,39 @@ func (c *Context) NoContent() error {
 // Redirect sends a redirect response
 func (c *Context) Redirect(code int, url string) error {
 	http.Redirect(c.Writer, c.Request, url, code)
+	c.statusCode = code
 	c.written = true
 	return nil
 }
 
+// RedirectPermanent sends a 301 Moved Permanently redirect (use
+// Redirect(http.StatusPermanentRedirect, url) to keep the request method)
+func (c *Context) RedirectPermanent(url string) error {
+	return c.Redirect(http.StatusMovedPermanently, url)
+}
+
 // File serves a file from the filesystem
 func (c *Context) File(filepath string) {
 	http.ServeFile(c.Writer, c.Request, filepath)
 	c.written = true
 }
 
+// ServeContent sends content with Range support: single and multi-range
+// requests get 206 Partial Content, If-Range/If-Modified-Since/If-None-Match
+// are honored against modTime and a previously set ETag header, and name's
+// extension sets the Content-Type unless one is already set. Use it for
+// seekable media and resumable downloads.
+//
+//	f, err := os.Open(path)
+//	...
+//	defer f.Close()
+//	return c.ServeContent(info.Name(), info.ModTime(), f)
+func (c *Context) ServeContent(name string, modTime time.Time, content io.ReadSeeker) error {
+	http.ServeContent(c.Writer, c.Request, name, modTime, content)
+	c.written = true
+	return nil
+}
+
 // =============================================================================
 // ERROR RESPONSES - Convenient error helpers
 // =============================================================================
@@ -319,6 +465,47 @@ func (c *Context) GetInt(key string) int {
 	return 0
 }
 
+// ContextKey is a context store key bound to its value type, so middleware
+// and handlers agree on the type at compile time. Keys are plain strings in
+// the store, so c.Get(string(key)) sees the same value.
+//
+//	var CurrentUser = poltergeist.ContextKey[*User]("user")
+//
+//	poltergeist.SetTyped(c, CurrentUser, user)         // in middleware
+//	user, ok := poltergeist.GetTyped(c, CurrentUser)   // in the handler
+type ContextKey[T any] string
+
+// SetTyped stores a value under a typed key
+func SetTyped[T any](c *Context, key ContextKey[T], value T) {
+	c.Set(string(key), value)
+}
+
+// GetTyped returns the value stored under a typed key; ok is false if it is
+// missing
+func GetTyped[T any](c *Context, key ContextKey[T]) (T, bool) {
+	return GetAs[T](c, string(key))
+}
+
+// GetAs returns the value stored under key as T; ok is false if it is
+// missing or of another type
+//
+//	claims, ok := poltergeist.GetAs[*jwt.Claims](c, "claims")
+func GetAs[T any](c *Context, key string) (T, bool) {
+	value, _ := c.Get(key)
+	typed, ok := value.(T)
+	return typed, ok
+}
+
+// MustGetAs returns the value stored under key as T, panicking if it is
+// missing or of another type
+func MustGetAs[T any](c *Context, key string) T {
+	typed, ok := GetAs[T](c, key)
+	if !ok {
+		panic(fmt.Sprintf("Key %q does not hold a %s in context", key, reflect.TypeOf((*T)(nil)).Elem()))
+	}
+	return typed
+}
+
 // Written returns true if response has been written
 func (c *Context) Written() bool {
 	return c.written
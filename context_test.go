@@ -2,9 +2,12 @@ package poltergeist
 
 import (
 	"bytes"
+	"errors"
+	"net/http"
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 )
 
 // =============================================================================
@@ -59,6 +62,70 @@ func TestContext_Param(t *testing.T) {
 	}
 }
 
+func TestContext_TypedQuery(t *testing.T) {
+	req := httptest.NewRequest("GET", "/?tag=a&tag=b&tag[]=c&filter[status]=open&filter[owner]=me&filter[]=x&price=9.5&since=2024-03-01&bad=x", nil)
+	c := NewContext(httptest.NewRecorder(), req)
+
+	if got := strings.Join(c.QueryArray("tag"), ","); got != "a,b,c" {
+		t.Errorf("QueryArray('tag') = %q, want a,b,c", got)
+	}
+	if got := c.QueryArray("missing"); len(got) != 0 {
+		t.Errorf("QueryArray('missing') = %v", got)
+	}
+	if got := c.QueryMap("filter"); len(got) != 2 || got["status"] != "open" || got["owner"] != "me" {
+		t.Errorf("QueryMap('filter') = %v", got)
+	}
+	if got, err := c.QueryFloat("price"); err != nil || got != 9.5 {
+		t.Errorf("QueryFloat('price') = %v, %v", got, err)
+	}
+	if got, err := c.QueryTime("since", time.DateOnly); err != nil || !got.Equal(time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("QueryTime('since') = %v, %v", got, err)
+	}
+
+	// Parse failures are BindErrors, so returning them renders a 400
+	for name, err := range map[string]error{
+		"QueryFloat": func() error { _, err := c.QueryFloat("bad"); return err }(),
+		"QueryTime":  func() error { _, err := c.QueryTime("bad", time.DateOnly); return err }(),
+	} {
+		var bind *BindError
+		if !errors.As(err, &bind) || bind.Source != BindSourceQuery || bind.Field != "bad" {
+			t.Errorf("%s error = %v, want a query BindError", name, err)
+		}
+	}
+}
+
+func TestContext_ParamUUID(t *testing.T) {
+	app := New()
+	app.GET("/orders/:id", func(c *Context) error {
+		id, err := c.ParamUUID("id")
+		if err != nil {
+			return err
+		}
+		return c.String(200, id)
+	})
+
+	tests := []struct {
+		id       string
+		wantCode int
+		wantBody string
+	}{
+		{"3F2504E0-4F89-11D3-9A0C-0305E82C3301", 200, "3f2504e0-4f89-11d3-9a0c-0305e82c3301"},
+		{"3f2504e0-4f89-11d3-9a0c-0305e82c330", 400, ""},
+		{"3f2504e04f8911d39a0c0305e82c3301", 400, ""},
+		{"3f2504e0-4f89-11d3-9a0c-0305e82c330g", 400, ""},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		app.ServeHTTP(w, httptest.NewRequest("GET", "/orders/"+tt.id, nil))
+		if w.Code != tt.wantCode {
+			t.Errorf("%s: code = %d, want %d", tt.id, w.Code, tt.wantCode)
+		}
+		if tt.wantBody != "" && w.Body.String() != tt.wantBody {
+			t.Errorf("%s: body = %q, want %q", tt.id, w.Body.String(), tt.wantBody)
+		}
+	}
+}
+
 func TestContext_JSON(t *testing.T) {
 	req := httptest.NewRequest("GET", "/", nil)
 	w := httptest.NewRecorder()
@@ -83,6 +150,102 @@ func TestContext_JSON(t *testing.T) {
 	}
 }
 
+func TestContext_XML(t *testing.T) {
+	type user struct {
+		XMLName struct{} `xml:"user"`
+		ID      int      `xml:"id,attr"`
+		Name    string   `xml:"name"`
+	}
+	w := httptest.NewRecorder()
+	c := NewContext(w, httptest.NewRequest("GET", "/", nil))
+
+	if err := c.XML(201, user{ID: 1, Name: "Ada"}); err != nil {
+		t.Fatalf("XML() error = %v", err)
+	}
+	if w.Code != 201 {
+		t.Errorf("status = %d, want 201", w.Code)
+	}
+	if ct := w.Header().Get("Content-Type"); ct != ContentTypeXML {
+		t.Errorf("Content-Type = %q, want %q", ct, ContentTypeXML)
+	}
+	want := `<?xml version="1.0" encoding="UTF-8"?>` + "\n" + `<user id="1"><name>Ada</name></user>`
+	if got := w.Body.String(); got != want {
+		t.Errorf("body = %q, want %q", got, want)
+	}
+
+	// Unencodable values fail before anything is written
+	w = httptest.NewRecorder()
+	c = NewContext(w, httptest.NewRequest("GET", "/", nil))
+	if err := c.XML(200, map[string]int{"a": 1}); err == nil || c.Written() {
+		t.Errorf("XML(map) error = %v, written = %v", err, c.Written())
+	}
+}
+
+func TestContext_YAML(t *testing.T) {
+	type config struct {
+		Name     string `yaml:"name"`
+		Replicas int    `yaml:"replicas"`
+		Ports    []int  `yaml:"ports,flow"`
+	}
+	w := httptest.NewRecorder()
+	c := NewContext(w, httptest.NewRequest("GET", "/", nil))
+
+	if err := c.YAML(200, config{Name: "api", Replicas: 3, Ports: []int{80, 443}}); err != nil {
+		t.Fatalf("YAML() error = %v", err)
+	}
+	if ct := w.Header().Get("Content-Type"); ct != ContentTypeYAML {
+		t.Errorf("Content-Type = %q, want %q", ct, ContentTypeYAML)
+	}
+	want := "name: api\nreplicas: 3\nports: [80, 443]\n"
+	if got := w.Body.String(); got != want {
+		t.Errorf("body = %q, want %q", got, want)
+	}
+}
+
+func TestContext_ServeContent(t *testing.T) {
+	modTime := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	content := "0123456789"
+
+	tests := []struct {
+		name     string
+		headers  map[string]string
+		wantCode int
+		wantBody string
+		wantCR   string
+	}{
+		{"full", nil, 200, content, ""},
+		{"range", map[string]string{"Range": "bytes=2-5"}, 206, "2345", "bytes 2-5/10"},
+		{"suffix range", map[string]string{"Range": "bytes=-3"}, 206, "789", "bytes 7-9/10"},
+		{"unsatisfiable", map[string]string{"Range": "bytes=20-"}, 416, "", "bytes */10"},
+		{"if-range current", map[string]string{"Range": "bytes=0-1", "If-Range": modTime.Format(http.TimeFormat)}, 206, "01", "bytes 0-1/10"},
+		{"if-range stale", map[string]string{"Range": "bytes=0-1", "If-Range": modTime.Add(-time.Hour).Format(http.TimeFormat)}, 200, content, ""},
+		{"not modified", map[string]string{"If-Modified-Since": modTime.Format(http.TimeFormat)}, 304, "", ""},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest("GET", "/video.mp4", nil)
+		for k, v := range tt.headers {
+			req.Header.Set(k, v)
+		}
+		w := httptest.NewRecorder()
+		c := NewContext(w, req)
+		if err := c.ServeContent("video.mp4", modTime, strings.NewReader(content)); err != nil || !c.Written() {
+			t.Fatalf("%s: ServeContent() error = %v, written = %v", tt.name, err, c.Written())
+		}
+		if w.Code != tt.wantCode {
+			t.Errorf("%s: code = %d, want %d", tt.name, w.Code, tt.wantCode)
+		}
+		if tt.wantCode < 300 && w.Body.String() != tt.wantBody {
+			t.Errorf("%s: body = %q, want %q", tt.name, w.Body.String(), tt.wantBody)
+		}
+		if got := w.Header().Get("Content-Range"); got != tt.wantCR {
+			t.Errorf("%s: Content-Range = %q, want %q", tt.name, got, tt.wantCR)
+		}
+		if tt.wantCode == 200 && w.Header().Get("Accept-Ranges") != "bytes" {
+			t.Errorf("%s: missing Accept-Ranges", tt.name)
+		}
+	}
+}
+
 func TestContext_Bind(t *testing.T) {
 	body := `{"name":"John","email":"john@example.com"}`
 	req := httptest.NewRequest("POST", "/", bytes.NewBufferString(body))
@@ -131,6 +294,41 @@ func TestContext_SetGet(t *testing.T) {
 	}
 }
 
+func TestContext_TypedStore(t *testing.T) {
+	type user struct{ Name string }
+	currentUser := ContextKey[*user]("user")
+	c := NewContext(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+
+	if _, ok := GetTyped(c, currentUser); ok {
+		t.Error("GetTyped found a value before SetTyped")
+	}
+	SetTyped(c, currentUser, &user{Name: "ada"})
+	if u, ok := GetTyped(c, currentUser); !ok || u.Name != "ada" {
+		t.Errorf("GetTyped = %v, %v", u, ok)
+	}
+	if u, ok := GetAs[*user](c, "user"); !ok || u.Name != "ada" {
+		t.Errorf("GetAs by plain key = %v, %v", u, ok)
+	}
+
+	c.Set("count", 3)
+	if n, ok := GetAs[int](c, "count"); !ok || n != 3 {
+		t.Errorf("GetAs[int] = %v, %v", n, ok)
+	}
+	if s, ok := GetAs[string](c, "count"); ok || s != "" {
+		t.Errorf("GetAs[string] on an int = %q, %v", s, ok)
+	}
+	if got := MustGetAs[int](c, "count"); got != 3 {
+		t.Errorf("MustGetAs = %d", got)
+	}
+
+	defer func() {
+		if r := recover(); r == nil || !strings.Contains(r.(string), "error") {
+			t.Errorf("MustGetAs panic = %v, want type name", r)
+		}
+	}()
+	MustGetAs[error](c, "count")
+}
+
 func TestContext_ErrorResponses(t *testing.T) {
 	tests := []struct {
 		name     string
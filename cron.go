@@ -0,0 +1,229 @@
+package poltergeist
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// =============================================================================
+// CRON SCHEDULE - Standard 5-field cron expressions
+// =============================================================================
+
+// CronSchedule computes activation times for a parsed schedule expression
+type CronSchedule interface {
+	// Next returns the next activation time strictly after t
+	Next(t time.Time) time.Time
+}
+
+// cronSpec is a parsed 5-field cron expression (minute hour dom month dow)
+type cronSpec struct {
+	minute, hour, dom, month, dow uint64
+	domStar, dowStar              bool
+}
+
+// everySpec is a fixed-interval schedule ("@every 1m30s")
+type everySpec struct {
+	interval time.Duration
+}
+
+// cronField describes the bounds and names of a cron field
+type cronField struct {
+	name     string
+	min, max int
+	names    map[string]int
+}
+
+var (
+	cronMinute = cronField{name: "minute", min: 0, max: 59}
+	cronHour   = cronField{name: "hour", min: 0, max: 23}
+	cronDom    = cronField{name: "day of month", min: 1, max: 31}
+	cronMonth  = cronField{name: "month", min: 1, max: 12, names: map[string]int{
+		"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+		"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+	}}
+	cronDow = cronField{name: "day of week", min: 0, max: 7, names: map[string]int{
+		"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+	}}
+)
+
+// cronDescriptors maps predefined schedules to their 5-field equivalent
+var cronDescriptors = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// ParseCron parses a cron expression.
+// Supports:
+//   - Standard fields: "*/5 * * * *", "0 9-17 * * MON-FRI", "0 0 1,15 * *"
+//   - Descriptors: @yearly, @monthly, @weekly, @daily, @hourly
+//   - Intervals: "@every 90s"
+func ParseCron(expr string) (CronSchedule, error) {
+	expr = strings.TrimSpace(expr)
+
+	if strings.HasPrefix(expr, "@every ") {
+		interval, err := time.ParseDuration(strings.TrimSpace(strings.TrimPrefix(expr, "@every ")))
+		if err != nil || interval <= 0 {
+			return nil, fmt.Errorf("cron: invalid interval in %q", expr)
+		}
+		return everySpec{interval: interval}, nil
+	}
+
+	if descriptor, ok := cronDescriptors[strings.ToLower(expr)]; ok {
+		expr = descriptor
+	}
+
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("cron: expected 5 fields, got %d in %q", len(fields), expr)
+	}
+
+	spec := &cronSpec{}
+	var err error
+	if spec.minute, err = parseCronField(fields[0], cronMinute); err != nil {
+		return nil, err
+	}
+	if spec.hour, err = parseCronField(fields[1], cronHour); err != nil {
+		return nil, err
+	}
+	if spec.dom, err = parseCronField(fields[2], cronDom); err != nil {
+		return nil, err
+	}
+	if spec.month, err = parseCronField(fields[3], cronMonth); err != nil {
+		return nil, err
+	}
+	if spec.dow, err = parseCronField(fields[4], cronDow); err != nil {
+		return nil, err
+	}
+
+	// Sunday may be written as 0 or 7
+	if spec.dow&(1<<7) != 0 {
+		spec.dow |= 1
+	}
+	spec.domStar = fields[2] == "*" || fields[2] == "?"
+	spec.dowStar = fields[4] == "*" || fields[4] == "?"
+
+	return spec, nil
+}
+
+// parseCronField parses a comma-separated list of ranges into a bitset
+func parseCronField(expr string, field cronField) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(expr, ",") {
+		partBits, err := parseCronRange(part, field)
+		if err != nil {
+			return 0, err
+		}
+		bits |= partBits
+	}
+	return bits, nil
+}
+
+// parseCronRange parses "*", "a", "a-b", and any of those with a "/step" suffix
+func parseCronRange(expr string, field cronField) (uint64, error) {
+	rangeExpr, step := expr, 1
+	if idx := strings.Index(expr, "/"); idx != -1 {
+		rangeExpr = expr[:idx]
+		n, err := strconv.Atoi(expr[idx+1:])
+		if err != nil || n <= 0 {
+			return 0, fmt.Errorf("cron: invalid step %q in %s field", expr[idx+1:], field.name)
+		}
+		step = n
+	}
+
+	start, end := field.min, field.max
+	switch {
+	case rangeExpr == "*" || rangeExpr == "?":
+	case strings.Contains(rangeExpr, "-"):
+		bounds := strings.SplitN(rangeExpr, "-", 2)
+		var err error
+		if start, err = parseCronValue(bounds[0], field); err != nil {
+			return 0, err
+		}
+		if end, err = parseCronValue(bounds[1], field); err != nil {
+			return 0, err
+		}
+	default:
+		value, err := parseCronValue(rangeExpr, field)
+		if err != nil {
+			return 0, err
+		}
+		start = value
+		if step == 1 {
+			end = value
+		}
+	}
+
+	if start > end {
+		return 0, fmt.Errorf("cron: invalid range %q in %s field", rangeExpr, field.name)
+	}
+
+	var bits uint64
+	for v := start; v <= end; v += step {
+		bits |= 1 << uint(v)
+	}
+	return bits, nil
+}
+
+// parseCronValue parses a single numeric or named value within field bounds
+func parseCronValue(value string, field cronField) (int, error) {
+	if n, ok := field.names[strings.ToLower(value)]; ok {
+		return n, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n < field.min || n > field.max {
+		return 0, fmt.Errorf("cron: invalid value %q in %s field", value, field.name)
+	}
+	return n, nil
+}
+
+// Next returns the next activation time strictly after t (in t's location)
+func (s *cronSpec) Next(t time.Time) time.Time {
+	t = t.Truncate(time.Minute).Add(time.Minute)
+
+	// Bounded search: any valid expression matches within a few years
+	limit := t.AddDate(5, 0, 0)
+	for t.Before(limit) {
+		if s.month&(1<<uint(t.Month())) == 0 {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if !s.dayMatches(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if s.hour&(1<<uint(t.Hour())) == 0 {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+			continue
+		}
+		if s.minute&(1<<uint(t.Minute())) == 0 {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t
+	}
+	return time.Time{}
+}
+
+// dayMatches applies cron's day-of-month / day-of-week rule:
+// when both are restricted, either one matching is enough
+func (s *cronSpec) dayMatches(t time.Time) bool {
+	domMatch := s.dom&(1<<uint(t.Day())) != 0
+	dowMatch := s.dow&(1<<uint(t.Weekday())) != 0
+
+	if s.domStar || s.dowStar {
+		return domMatch && dowMatch
+	}
+	return domMatch || dowMatch
+}
+
+// Next returns t plus the fixed interval
+func (s everySpec) Next(t time.Time) time.Time {
+	return t.Add(s.interval)
+}
@@ -0,0 +1,401 @@
+package poltergeist
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// =============================================================================
+// DEV MODE - Rebuild and restart on change with browser live reload
+// =============================================================================
+
+// DevConfig holds development mode configuration
+type DevConfig struct {
+	Watch       []string      // Directories to watch (default: ".")
+	Extensions  []string      // File extensions that trigger a reload (default: Go sources, templates, web assets)
+	Ignore      []string      // Directory names to skip (default: .git, node_modules, vendor, tmp)
+	BuildTarget string        // Package to rebuild (default: ".")
+	BuildFlags  []string      // Extra `go build` flags
+	Interval    time.Duration // Polling interval (default: 500ms)
+	StopTimeout time.Duration // Time a process gets to shut down before it is killed (default: 5s)
+	ReloadPath  string        // Live-reload SSE endpoint (default: "/__poltergeist/livereload")
+	RebuildExts []string      // Extensions that need a rebuild rather than a restart (default: .go, .mod, .sum)
+
+	// Don't serve the live-reload endpoint or inject its script into HTML
+	DisableLiveReload bool
+}
+
+// DefaultDevConfig returns default development mode configuration
+func DefaultDevConfig() *DevConfig {
+	return &DevConfig{
+		Watch:       []string{"."},
+		Extensions:  []string{".go", ".mod", ".sum", ".html", ".tmpl", ".gohtml", ".css", ".js", ".json", ".yaml", ".yml"},
+		Ignore:      []string{".git", "node_modules", "vendor", "tmp"},
+		BuildTarget: ".",
+		Interval:    500 * time.Millisecond,
+		StopTimeout: 5 * time.Second,
+		ReloadPath:  "/__poltergeist/livereload",
+		RebuildExts: []string{".go", ".mod", ".sum"},
+	}
+}
+
+// Environment variables passed from the dev supervisor to the app process
+const (
+	envDevChild = "POLTERGEIST_DEV_CHILD"
+	envDevAddr  = "POLTERGEIST_DEV_ADDR"
+)
+
+// RunDev runs the server in development mode (Unix only). The calling
+// process becomes a supervisor that owns the listener and runs the app as a
+// child process; when watched files change it rebuilds (for Go sources) and
+// restarts the child on the same listener, so clients never see a refused
+// connection. Browsers viewing HTML pages reload once the new process is up.
+//
+//	if os.Getenv("APP_ENV") == "dev" {
+//	    if err := app.RunDev(":8080"); err != nil {
+//	        log.Fatal(err)
+//	    }
+//	}
+func (s *Server) RunDev(addr string, config ...*DevConfig) error {
+	cfg := getDevConfig(config)
+	if os.Getenv(envDevChild) != "" {
+		return s.runDevChild(cfg)
+	}
+	return s.runDevSupervisor(s.resolveAddress([]string{addr}), cfg)
+}
+
+// --- App process ---
+
+// runDevChild serves on the listener inherited from the supervisor. The app
+// always shuts down gracefully so in-flight requests finish before a restart.
+func (s *Server) runDevChild(cfg *DevConfig) error {
+	file := os.NewFile(3, "poltergeist-dev-listener")
+	listener, err := net.FileListener(file)
+	file.Close()
+	if err != nil {
+		return fmt.Errorf("dev: inherit listener: %w", err)
+	}
+
+	s.config.DevMode = true
+	s.config.GracefulShutdown = true
+	if !cfg.DisableLiveReload {
+		s.mountLiveReload(cfg.ReloadPath)
+	}
+	s.listener = listener
+	return s.Run(os.Getenv(envDevAddr))
+}
+
+// mountLiveReload serves the live-reload stream and injects its client
+// script into HTML responses. Each process has its own build ID; a browser
+// that reconnects to a different ID reloads the page.
+func (s *Server) mountLiveReload(path string) {
+	buildID := strconv.FormatInt(time.Now().UnixNano(), 36)
+
+	// Release open streams as soon as the supervisor asks us to stop, or
+	// graceful shutdown would wait on them until it is killed
+	stop := make(chan struct{})
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	go func() {
+		<-quit
+		signal.Stop(quit)
+		close(stop)
+	}()
+
+	route := s.GET(path, func(c *Context) error {
+		flusher, ok := c.Writer.(http.Flusher)
+		if !ok {
+			return ErrInternalServerError.WithMessage("streaming unsupported")
+		}
+		c.SetHeader(HeaderContentType, ContentTypeSSE)
+		c.SetHeader(HeaderCacheControl, "no-cache")
+		c.Writer.WriteHeader(http.StatusOK)
+		c.written = true
+		fmt.Fprintf(c.Writer, "retry: 300\nevent: build\ndata: %s\n\n", buildID)
+		flusher.Flush()
+
+		// Held open until the browser leaves or this process shuts down
+		select {
+		case <-c.Request.Context().Done():
+		case <-stop:
+		}
+		return nil
+	}).Hidden()
+	route.RouteProtocol = ProtocolSSE
+
+	s.TransformResponse(injectLiveReload(path))
+}
+
+// injectLiveReload adds the live-reload client before </body> of HTML responses
+func injectLiveReload(path string) ResponseTransform {
+	script := []byte(liveReloadScript(path))
+	return func(c *Context, res *Response) error {
+		if !strings.HasPrefix(res.Header.Get(HeaderContentType), "text/html") {
+			return nil
+		}
+		if i := bytes.LastIndex(res.Body, []byte("</body>")); i >= 0 {
+			res.Body = append(res.Body[:i:i], append(script, res.Body[i:]...)...)
+		} else {
+			res.Body = append(res.Body, script...)
+		}
+		return nil
+	}
+}
+
+// liveReloadScript is the client injected into HTML pages
+func liveReloadScript(path string) string {
+	return `<script>(function(){var id=null;var es=new EventSource(` + strconv.Quote(path) + `);` +
+		`es.addEventListener("build",function(e){if(id!==null&&id!==e.data){location.reload()}id=e.data})})();</script>`
+}
+
+// --- Supervisor process ---
+
+// devSupervisor owns the listener and manages the app process
+type devSupervisor struct {
+	server  *Server
+	config  *DevConfig
+	addr    string
+	file    *os.File // listener handed to children
+	binary  string   // latest successful build ("" until the first rebuild)
+	tmpDir  string
+	child   *exec.Cmd
+	exited  chan error
+	watched map[string]time.Time
+}
+
+// runDevSupervisor watches files and restarts the app until interrupted
+func (s *Server) runDevSupervisor(addr string, cfg *DevConfig) error {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	defer listener.Close()
+	tcp, ok := listener.(*net.TCPListener)
+	if !ok {
+		return errors.New("dev: listener is not TCP")
+	}
+	file, err := tcp.File()
+	if err != nil {
+		return fmt.Errorf("dev: listener file: %w", err)
+	}
+	defer file.Close()
+
+	tmpDir, err := os.MkdirTemp("", "poltergeist-dev-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmpDir)
+
+	sup := &devSupervisor{server: s, config: cfg, addr: addr, file: file, tmpDir: tmpDir}
+	sup.watched = sup.scan()
+
+	// The first process is this very binary; later ones are rebuilds
+	self, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	if err := sup.start(self); err != nil {
+		return err
+	}
+	s.Logger().Info("dev mode: watching for changes", "addr", addr, "dirs", cfg.Watch)
+
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(quit)
+	ticker := time.NewTicker(cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-quit:
+			sup.stop()
+			return nil
+		case err := <-sup.exited:
+			sup.child, sup.exited = nil, nil
+			s.Logger().Warn("dev mode: app exited, waiting for changes", "error", err)
+		case <-ticker.C:
+			changed := sup.changes()
+			if len(changed) == 0 {
+				continue
+			}
+			sup.reload(changed, self)
+		}
+	}
+}
+
+// reload rebuilds if Go sources changed and restarts the app
+func (sup *devSupervisor) reload(changed []string, self string) {
+	logger := sup.server.Logger()
+	logger.Info("dev mode: change detected", "files", changed)
+
+	binary := sup.binary
+	if binary == "" {
+		binary = self
+	}
+	if sup.needsRebuild(changed) {
+		next := filepath.Join(sup.tmpDir, "app-"+strconv.FormatInt(time.Now().UnixNano(), 36))
+		args := append(append([]string{"build"}, sup.config.BuildFlags...), "-o", next, sup.config.BuildTarget)
+		out, err := exec.Command("go", args...).CombinedOutput()
+		if err != nil {
+			logger.Error("dev mode: build failed, keeping the running version", "output", string(out))
+			return
+		}
+		if sup.binary != "" {
+			os.Remove(sup.binary)
+		}
+		sup.binary, binary = next, next
+	}
+
+	sup.stop()
+	if err := sup.start(binary); err != nil {
+		logger.Error("dev mode: restart failed", "error", err)
+	}
+}
+
+// needsRebuild reports whether any changed file is a build input
+func (sup *devSupervisor) needsRebuild(changed []string) bool {
+	for _, path := range changed {
+		if containsString(sup.config.RebuildExts, filepath.Ext(path)) {
+			return true
+		}
+	}
+	return false
+}
+
+// start runs binary with the shared listener as fd 3
+func (sup *devSupervisor) start(binary string) error {
+	cmd := exec.Command(binary, os.Args[1:]...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	cmd.ExtraFiles = []*os.File{sup.file}
+	cmd.Env = append(os.Environ(), envDevChild+"=1", envDevAddr+"="+sup.addr)
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+
+	exited := make(chan error, 1)
+	go func() { exited <- cmd.Wait() }()
+	sup.child, sup.exited = cmd, exited
+	return nil
+}
+
+// stop asks the app to shut down gracefully, killing it after StopTimeout
+func (sup *devSupervisor) stop() {
+	if sup.child == nil {
+		return
+	}
+	sup.child.Process.Signal(syscall.SIGTERM)
+	select {
+	case <-sup.exited:
+	case <-time.After(sup.config.StopTimeout):
+		sup.child.Process.Kill()
+		<-sup.exited
+	}
+	sup.child, sup.exited = nil, nil
+}
+
+// changes rescans the watched tree and returns the files that changed,
+// waiting for the tree to settle so editors' multi-step saves reload once
+func (sup *devSupervisor) changes() []string {
+	current := sup.scan()
+	changed := diffSnapshots(sup.watched, current)
+	for len(changed) > 0 {
+		time.Sleep(sup.config.Interval / 2)
+		settled := sup.scan()
+		if len(diffSnapshots(current, settled)) == 0 {
+			break
+		}
+		changed = diffSnapshots(sup.watched, settled)
+		current = settled
+	}
+	sup.watched = current
+	return changed
+}
+
+// scan records the modification times of watched files
+func (sup *devSupervisor) scan() map[string]time.Time {
+	files := make(map[string]time.Time)
+	for _, root := range sup.config.Watch {
+		filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return nil
+			}
+			if d.IsDir() {
+				name := d.Name()
+				if path != root && (containsString(sup.config.Ignore, name) || strings.HasPrefix(name, ".")) {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if !containsString(sup.config.Extensions, filepath.Ext(path)) {
+				return nil
+			}
+			if info, err := d.Info(); err == nil {
+				files[path] = info.ModTime()
+			}
+			return nil
+		})
+	}
+	return files
+}
+
+// diffSnapshots returns files added, removed or modified between snapshots
+func diffSnapshots(before, after map[string]time.Time) []string {
+	var changed []string
+	for path, mod := range after {
+		if prev, ok := before[path]; !ok || !prev.Equal(mod) {
+			changed = append(changed, path)
+		}
+	}
+	for path := range before {
+		if _, ok := after[path]; !ok {
+			changed = append(changed, path)
+		}
+	}
+	return changed
+}
+
+// getDevConfig fills unset fields with defaults
+func getDevConfig(config []*DevConfig) *DevConfig {
+	defaults := DefaultDevConfig()
+	if len(config) == 0 || config[0] == nil {
+		return defaults
+	}
+	cfg := *config[0]
+	if len(cfg.Watch) == 0 {
+		cfg.Watch = defaults.Watch
+	}
+	if len(cfg.Extensions) == 0 {
+		cfg.Extensions = defaults.Extensions
+	}
+	if cfg.Ignore == nil {
+		cfg.Ignore = defaults.Ignore
+	}
+	if cfg.BuildTarget == "" {
+		cfg.BuildTarget = defaults.BuildTarget
+	}
+	if cfg.Interval <= 0 {
+		cfg.Interval = defaults.Interval
+	}
+	if cfg.StopTimeout <= 0 {
+		cfg.StopTimeout = defaults.StopTimeout
+	}
+	if cfg.ReloadPath == "" {
+		cfg.ReloadPath = defaults.ReloadPath
+	}
+	if len(cfg.RebuildExts) == 0 {
+		cfg.RebuildExts = defaults.RebuildExts
+	}
+	return &cfg
+}
@@ -0,0 +1,76 @@
+package poltergeist
+
+import (
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+// =============================================================================
+// DEV MODE TESTS
+// =============================================================================
+
+func TestDiffSnapshots(t *testing.T) {
+	now := time.Now()
+	before := map[string]time.Time{"a.go": now, "b.html": now, "c.css": now}
+	after := map[string]time.Time{"a.go": now, "b.html": now.Add(time.Second), "d.js": now}
+
+	changed := diffSnapshots(before, after)
+	sort.Strings(changed)
+	if strings.Join(changed, ",") != "b.html,c.css,d.js" {
+		t.Errorf("changed = %v", changed)
+	}
+	if len(diffSnapshots(after, after)) != 0 {
+		t.Error("identical snapshots should not differ")
+	}
+}
+
+func TestDevSupervisor_NeedsRebuild(t *testing.T) {
+	sup := &devSupervisor{config: getDevConfig(nil)}
+	if sup.needsRebuild([]string{"templates/index.html", "static/app.css"}) {
+		t.Error("templates and assets should only restart")
+	}
+	if !sup.needsRebuild([]string{"static/app.css", "handlers/user.go"}) {
+		t.Error("Go sources should trigger a rebuild")
+	}
+}
+
+func TestInjectLiveReload(t *testing.T) {
+	app := New().UseLogger(NopLogger)
+	app.TransformResponse(injectLiveReload("/__reload"))
+	app.GET("/page", func(c *Context) error {
+		return c.HTML(200, "<html><body><h1>hi</h1></body></html>")
+	})
+	app.GET("/api", func(c *Context) error {
+		return c.JSON(200, H{"body": "</body>"})
+	})
+
+	w := httptest.NewRecorder()
+	app.Router().ServeHTTP(w, httptest.NewRequest("GET", "/page", nil))
+	body := w.Body.String()
+	if !strings.Contains(body, `<h1>hi</h1><script>`) || !strings.HasSuffix(body, "</script></body></html>") {
+		t.Errorf("script not injected before </body>: %q", body)
+	}
+	if !strings.Contains(body, `EventSource("/__reload")`) {
+		t.Errorf("script does not connect to the reload path: %q", body)
+	}
+
+	w = httptest.NewRecorder()
+	app.Router().ServeHTTP(w, httptest.NewRequest("GET", "/api", nil))
+	if strings.Contains(w.Body.String(), "<script>") {
+		t.Errorf("non-HTML response was modified: %q", w.Body.String())
+	}
+}
+
+func TestGetDevConfig_Partial(t *testing.T) {
+	config := &DevConfig{Watch: []string{"cmd"}}
+	cfg := getDevConfig([]*DevConfig{config})
+	if cfg.DisableLiveReload || cfg.ReloadPath != DefaultDevConfig().ReloadPath || cfg.Interval != DefaultDevConfig().Interval {
+		t.Errorf("config = %+v, want live reload on and defaults for unset fields", cfg)
+	}
+	if cfg.Watch[0] != "cmd" || config.Interval != 0 {
+		t.Errorf("Watch = %v, caller's Interval = %v; want the caller's Watch and config untouched", cfg.Watch, config.Interval)
+	}
+}
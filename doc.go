@@ -42,6 +42,16 @@
 //	api := app.Group("/api", middleware.Logger())
 //	api.GET("/users", listUsers)
 //
+// When patterns overlap, each path segment is matched most specific first,
+// whatever the registration order: a static segment beats a :param, which
+// beats a trailing *wildcard. A later segment never outranks an earlier
+// one, so "/static/health" beats "/static/*filepath" and "/users/new" beats
+// "/users/:id", while "/files/*path" beats "/:dir/readme" for
+// "/files/readme". If the preferred branch has no route for the method (or
+// its param constraints reject the value), matching falls back to the next
+// branch. Routes with the same method and pattern are tried in registration
+// order.
+//
 // # WebSocket
 //
 // Create WebSocket endpoints with hub support:
@@ -77,5 +87,29 @@
 //	    log.Printf("Request took: %v", duration)
 //	})
 //
+// AfterRequest hooks are skipped once the client has disconnected. Handlers
+// pass cancellation on with c.Context(), or c.WithTimeout(d) for one call.
+//
+// # Background Tasks
+//
+// Offload work from handlers to a worker pool that drains on shutdown:
+//
+//	app.POST("/signup", func(c *poltergeist.Context) error {
+//	    app.Tasks().Enqueue(func(ctx context.Context) error {
+//	        return mailer.SendWelcome(ctx, email)
+//	    })
+//	    return c.NoContent()
+//	})
+//
+// # Scheduled Jobs
+//
+// Run jobs on cron expressions; they start with Run and stop on shutdown:
+//
+//	app.Schedule("0 3 * * *", cleanupSessions, &poltergeist.JobConfig{
+//	    Name:     "cleanup",
+//	    Location: time.UTC,
+//	    Jitter:   time.Minute,
+//	})
+//
 // For more information, visit https://github.com/gofuckbiz/poltergeist
 package poltergeist
@@ -0,0 +1,239 @@
+package docs
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/gofuckbiz/poltergeist"
+)
+
+// =============================================================================
+// ASYNCAPI - Documentation for WebSocket and SSE endpoints
+// =============================================================================
+
+// AsyncAPI represents an AsyncAPI 3.0 document
+type AsyncAPI struct {
+	AsyncAPI   string                    `json:"asyncapi"`
+	Info       Info                      `json:"info"`
+	Servers    map[string]AsyncServer    `json:"servers,omitempty"`
+	Channels   map[string]*AsyncChannel  `json:"channels"`
+	Operations map[string]AsyncOperation `json:"operations"`
+	Components *AsyncComponents          `json:"components,omitempty"`
+}
+
+// AsyncServer represents a server the channels are reachable on
+type AsyncServer struct {
+	Host        string `json:"host"`
+	Protocol    string `json:"protocol"`
+	Pathname    string `json:"pathname,omitempty"`
+	Description string `json:"description,omitempty"`
+}
+
+// AsyncChannel represents a WebSocket or SSE endpoint
+type AsyncChannel struct {
+	Address     string                `json:"address"`
+	Description string                `json:"description,omitempty"`
+	Messages    map[string]AsyncRef   `json:"messages"`
+	Servers     []AsyncRef            `json:"servers,omitempty"`
+	Parameters  map[string]AsyncParam `json:"parameters,omitempty"`
+}
+
+// AsyncParam represents a channel address parameter
+type AsyncParam struct {
+	Description string `json:"description,omitempty"`
+}
+
+// AsyncOperation represents a send or receive operation on a channel
+type AsyncOperation struct {
+	Action   string     `json:"action"` // send (server -> client) or receive
+	Channel  AsyncRef   `json:"channel"`
+	Summary  string     `json:"summary,omitempty"`
+	Messages []AsyncRef `json:"messages"`
+}
+
+// AsyncMessage represents a message payload
+type AsyncMessage struct {
+	Name        string  `json:"name"`
+	Title       string  `json:"title,omitempty"`
+	ContentType string  `json:"contentType,omitempty"`
+	Payload     *Schema `json:"payload,omitempty"`
+}
+
+// AsyncComponents holds reusable messages and schemas
+type AsyncComponents struct {
+	Messages map[string]AsyncMessage `json:"messages,omitempty"`
+	Schemas  map[string]*Schema      `json:"schemas,omitempty"`
+}
+
+// AsyncRef is a JSON reference
+type AsyncRef struct {
+	Ref string `json:"$ref"`
+}
+
+// GenerateAsyncAPI generates an AsyncAPI document from the realtime routes
+// (WebSocket() and SSE() registrations) and the messages declared on them
+// with route.Sends and route.Receives
+func GenerateAsyncAPI(routes []*poltergeist.Route, config *SwaggerConfig) *AsyncAPI {
+	if config == nil {
+		config = DefaultSwaggerConfig()
+	}
+
+	doc := &AsyncAPI{
+		AsyncAPI:   "3.0.0",
+		Info:       config.info(),
+		Servers:    make(map[string]AsyncServer),
+		Channels:   make(map[string]*AsyncChannel),
+		Operations: make(map[string]AsyncOperation),
+		Components: &AsyncComponents{Messages: make(map[string]AsyncMessage)},
+	}
+
+	registry := NewSchemaRegistry()
+	protocols := make(map[string]bool)
+
+	for _, route := range routes {
+		if route.RouteProtocol == "" || !config.documents(route) {
+			continue
+		}
+		protocols[route.RouteProtocol] = true
+
+		channelID := "root"
+		if id := generateOperationID("", route.Path); id != "" {
+			channelID = strings.ToLower(id[:1]) + id[1:]
+		}
+		channel := &AsyncChannel{
+			Address:     convertPathToOpenAPI(route.Path),
+			Description: route.RouteDescription,
+			Messages:    make(map[string]AsyncRef),
+			Servers:     []AsyncRef{{Ref: "#/servers/" + route.RouteProtocol}},
+		}
+		for _, param := range extractParameters(route.Path) {
+			if channel.Parameters == nil {
+				channel.Parameters = make(map[string]AsyncParam)
+			}
+			channel.Parameters[param.Name] = AsyncParam{Description: param.Description}
+		}
+
+		for _, msg := range route.RouteMessages {
+			message := AsyncMessage{
+				Name:        msg.Event,
+				Title:       msg.Event,
+				ContentType: "application/json",
+				Payload:     registry.SchemaFor(msg.Payload),
+			}
+			messageID := asyncMessageID(doc.Components.Messages, channelID, message)
+			doc.Components.Messages[messageID] = message
+			channel.Messages[messageID] = AsyncRef{Ref: "#/components/messages/" + messageID}
+
+			action := "send"
+			if msg.Direction == poltergeist.MessageReceive {
+				action = "receive"
+			}
+			doc.Operations[channelID+"_"+action+"_"+messageID] = AsyncOperation{
+				Action:   action,
+				Channel:  AsyncRef{Ref: "#/channels/" + channelID},
+				Summary:  route.RouteName,
+				Messages: []AsyncRef{{Ref: "#/channels/" + channelID + "/messages/" + messageID}},
+			}
+		}
+		doc.Channels[channelID] = channel
+	}
+
+	for protocol := range protocols {
+		doc.Servers[protocol] = asyncServer(config.servers(), protocol)
+	}
+	doc.Components.Schemas = registry.Schemas()
+	return doc
+}
+
+// asyncMessageID picks the component ID for a message: the event name, shared by
+// identical messages. An event reused with another payload is qualified with
+// its channel.
+func asyncMessageID(messages map[string]AsyncMessage, channelID string, message AsyncMessage) string {
+	id := sanitizeName(message.Name)
+	if existing, taken := messages[id]; !taken || reflect.DeepEqual(existing, message) {
+		return id
+	}
+
+	qualified := channelID + "." + id
+	candidate := qualified
+	for i := 2; ; i++ {
+		if existing, taken := messages[candidate]; !taken || reflect.DeepEqual(existing, message) {
+			return candidate
+		}
+		candidate = qualified + strconv.Itoa(i)
+	}
+}
+
+// asyncServer derives a realtime server from the first HTTP server in the config
+func asyncServer(servers []Server, protocol string) AsyncServer {
+	server := AsyncServer{Host: "localhost:8080"}
+	secure := false
+	if len(servers) > 0 {
+		if u, err := url.Parse(servers[0].URL); err == nil && u.Host != "" {
+			server.Host = u.Host
+			server.Pathname = strings.TrimSuffix(u.Path, "/")
+			server.Description = servers[0].Description
+			secure = u.Scheme == "https"
+		}
+	}
+
+	switch {
+	case protocol == poltergeist.ProtocolSSE && secure:
+		server.Protocol = "https"
+	case protocol == poltergeist.ProtocolSSE:
+		server.Protocol = "http"
+	case secure:
+		server.Protocol = "wss"
+	default:
+		server.Protocol = "ws"
+	}
+	return server
+}
+
+// ExportAsyncAPI exports the AsyncAPI document to JSON
+func ExportAsyncAPI(routes []*poltergeist.Route, config *SwaggerConfig) ([]byte, error) {
+	return json.MarshalIndent(GenerateAsyncAPI(routes, config), "", "  ")
+}
+
+// mountAsyncAPI serves the AsyncAPI document and viewer next to the REST docs
+func mountAsyncAPI(server *poltergeist.Server, config *SwaggerConfig, path string) {
+	specURL := path + "/asyncapi.json"
+
+	server.GET(specURL, func(c *poltergeist.Context) error {
+		return c.JSON(http.StatusOK, GenerateAsyncAPI(server.Routes(), config))
+	}).Hidden()
+
+	page := asyncAPIHTML(config.Title, specURL)
+	server.GET(path+"/asyncapi", func(c *poltergeist.Context) error {
+		return c.HTML(http.StatusOK, page)
+	}).Hidden()
+}
+
+// asyncAPIHTML returns the AsyncAPI viewer HTML
+func asyncAPIHTML(title, specURL string) string {
+	return fmt.Sprintf(`<!DOCTYPE html>
+<html lang="en">
+<head>
+    <meta charset="UTF-8">
+    <meta name="viewport" content="width=device-width, initial-scale=1.0">
+    <title>%s - AsyncAPI</title>
+    <link rel="stylesheet" href="https://unpkg.com/@asyncapi/react-component@1/styles/default.min.css">
+</head>
+<body>
+    <div id="asyncapi"></div>
+    <script src="https://unpkg.com/@asyncapi/react-component@1/browser/standalone/index.js"></script>
+    <script>
+        AsyncApiStandalone.render({
+            schema: { url: %q, options: { method: "GET", mode: "cors" } },
+            config: { show: { sidebar: true } }
+        }, document.getElementById("asyncapi"));
+    </script>
+</body>
+</html>`, html.EscapeString(title), specURL)
+}
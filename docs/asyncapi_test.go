@@ -0,0 +1,87 @@
+package docs
+
+import (
+	"testing"
+
+	"github.com/gofuckbiz/poltergeist"
+)
+
+// =============================================================================
+// ASYNCAPI TESTS
+// =============================================================================
+
+type chatMessage struct {
+	Text string `json:"text"`
+}
+
+type chatTyping struct {
+	User string `json:"user"`
+}
+
+type tickerQuote struct {
+	Symbol string  `json:"symbol"`
+	Price  float64 `json:"price"`
+}
+
+func TestGenerateAsyncAPI_MessageIDs(t *testing.T) {
+	app := poltergeist.New()
+	noop := func(*poltergeist.WSConn, int, []byte) {}
+	app.WebSocket("/chat", noop).
+		Sends("message", chatMessage{}).
+		Receives("message", chatMessage{}).
+		Sends("typing", chatTyping{})
+	app.WebSocket("/rooms/:id", noop).
+		Sends("message", chatMessage{})
+	app.SSE("/ticker", func(*poltergeist.Context, *poltergeist.SSEWriter) {}).
+		Sends("message", tickerQuote{}).
+		Sends("typing", chatMessage{}).
+		Receives("typing", tickerQuote{})
+
+	doc := GenerateAsyncAPI(app.Routes(), nil)
+
+	payloads := map[string]string{
+		"message":        "chatMessage",
+		"typing":         "chatTyping",
+		"ticker.message": "tickerQuote",
+		"ticker.typing":  "chatMessage",
+		"ticker.typing2": "tickerQuote",
+	}
+	if len(doc.Components.Messages) != len(payloads) {
+		t.Errorf("messages = %v", doc.Components.Messages)
+	}
+	for id, schema := range payloads {
+		msg, ok := doc.Components.Messages[id]
+		if !ok {
+			t.Errorf("message %q missing", id)
+			continue
+		}
+		if msg.Payload == nil || msg.Payload.Ref != componentsPrefix+schema {
+			t.Errorf("%s payload = %+v, want %s", id, msg.Payload, schema)
+		}
+	}
+
+	channels := map[string][]string{
+		"chat":    {"message", "typing"},
+		"roomsId": {"message"},
+		"ticker":  {"ticker.message", "ticker.typing", "ticker.typing2"},
+	}
+	for channelID, ids := range channels {
+		channel, ok := doc.Channels[channelID]
+		if !ok {
+			t.Errorf("channel %q missing", channelID)
+			continue
+		}
+		if len(channel.Messages) != len(ids) {
+			t.Errorf("%s messages = %v", channelID, channel.Messages)
+		}
+		for _, id := range ids {
+			if ref := channel.Messages[id].Ref; ref != "#/components/messages/"+id {
+				t.Errorf("%s message %q ref = %q", channelID, id, ref)
+			}
+		}
+	}
+
+	if op, ok := doc.Operations["ticker_receive_ticker.typing2"]; !ok || op.Action != "receive" {
+		t.Errorf("operations = %v", doc.Operations)
+	}
+}
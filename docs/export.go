@@ -0,0 +1,144 @@
+package docs
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/gofuckbiz/poltergeist"
+	"gopkg.in/yaml.v3"
+)
+
+// =============================================================================
+// EXPORT - Write the spec to files for CI and code generation
+// =============================================================================
+
+// Spec dump triggers: a command-line argument or an environment variable
+const (
+	DumpSpecFlag = "openapi-out"             // -openapi-out=openapi.yaml
+	DumpSpecEnv  = "POLTERGEIST_OPENAPI_OUT" // POLTERGEIST_OPENAPI_OUT=openapi.yaml
+)
+
+// ExportYAML exports OpenAPI spec to YAML
+func ExportYAML(routes []*poltergeist.Route, config *SwaggerConfig) ([]byte, error) {
+	return marshalYAML(GenerateOpenAPI(routes, config))
+}
+
+// WriteSpec writes the OpenAPI spec of a server to a file.
+// The format follows the extension: .yaml/.yml for YAML, anything else for JSON.
+func WriteSpec(server *poltergeist.Server, path string, config ...*SwaggerConfig) error {
+	var cfg *SwaggerConfig
+	if len(config) > 0 {
+		cfg = config[0]
+	}
+
+	var data []byte
+	var err error
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		data, err = ExportYAML(server.Routes(), cfg)
+	default:
+		data, err = ExportJSON(server.Routes(), cfg)
+	}
+	if err != nil {
+		return fmt.Errorf("docs: generate spec: %w", err)
+	}
+
+	if dir := filepath.Dir(path); dir != "." {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return err
+		}
+	}
+	return os.WriteFile(path, data, 0o644)
+}
+
+// EnableSpecDump makes server.Run write the spec and return without serving
+// when the process is started with -openapi-out=<file> (or the
+// POLTERGEIST_OPENAPI_OUT environment variable). This lets CI and go generate
+// produce the contract from the real route table:
+//
+//	//go:generate go run . -openapi-out=openapi.yaml
+//
+// Swagger() enables this automatically.
+func EnableSpecDump(server *poltergeist.Server, config *SwaggerConfig) {
+	server.BeforeRun(func() error {
+		path := dumpTarget(os.Args[1:])
+		if path == "" {
+			return nil
+		}
+		if err := WriteSpec(server, path, config); err != nil {
+			return err
+		}
+		fmt.Printf("📄 OpenAPI spec written to %s\n", path)
+		return poltergeist.ErrSkipServe
+	})
+}
+
+// dumpTarget returns the requested dump path from arguments or environment
+func dumpTarget(args []string) string {
+	for i, arg := range args {
+		name := strings.TrimLeft(arg, "-")
+		if name == arg {
+			continue // not a flag
+		}
+		if value, ok := strings.CutPrefix(name, DumpSpecFlag+"="); ok {
+			return value
+		}
+		if name == DumpSpecFlag && i+1 < len(args) {
+			return args[i+1]
+		}
+	}
+	return os.Getenv(DumpSpecEnv)
+}
+
+// marshalYAML converts a JSON-tagged value to YAML, honoring json tags and
+// custom MarshalJSON implementations by round-tripping through JSON
+func marshalYAML(v any) ([]byte, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	// JSON is valid YAML: decoding into a node keeps key order
+	var node yaml.Node
+	if err := yaml.Unmarshal(data, &node); err != nil {
+		return nil, err
+	}
+	blockStyle(&node)
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(&node); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// blockStyle drops the JSON flow/quoting styles so the output reads as YAML.
+// Strings YAML 1.1 readers take for booleans stay quoted.
+func blockStyle(node *yaml.Node) {
+	node.Style = 0
+	if node.Kind == yaml.ScalarNode && node.Tag == "!!str" && yaml11Bool(node.Value) {
+		node.Style = yaml.DoubleQuotedStyle
+	}
+	for _, child := range node.Content {
+		blockStyle(child)
+	}
+}
+
+// yaml11Bool reports whether s is a YAML 1.1 boolean word (yes, No, ON, y, ...)
+// that yaml.v3 would emit unquoted
+func yaml11Bool(s string) bool {
+	switch s {
+	case "y", "Y", "yes", "Yes", "YES", "n", "N", "no", "No", "NO",
+		"on", "On", "ON", "off", "Off", "OFF":
+		return true
+	}
+	return false
+}
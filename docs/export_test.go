@@ -0,0 +1,45 @@
+package docs
+
+import (
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+// =============================================================================
+// EXPORT TESTS
+// =============================================================================
+
+func TestMarshalYAML_QuotesAmbiguousScalars(t *testing.T) {
+	values := []any{"yes", "No", "ON", "off", "y", "N", "true", "null", "~", "1.5", "042", "plain", true, 7}
+	data, err := marshalYAML(map[string]any{"enum": values})
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := string(data)
+	for _, word := range []string{"yes", "No", "ON", "off", "y", "N"} {
+		if !strings.Contains(out, `- "`+word+`"`) {
+			t.Errorf("%q not quoted:\n%s", word, out)
+		}
+	}
+	if !strings.Contains(out, "- plain\n") || !strings.Contains(out, "- true\n") || !strings.Contains(out, "- 7\n") {
+		t.Errorf("plain scalars quoted:\n%s", out)
+	}
+
+	// The output decodes back to the original values
+	var back struct {
+		Enum []any `yaml:"enum"`
+	}
+	if err := yaml.Unmarshal(data, &back); err != nil {
+		t.Fatal(err)
+	}
+	if len(back.Enum) != len(values) {
+		t.Fatalf("enum = %v", back.Enum)
+	}
+	for i, want := range values {
+		if back.Enum[i] != want {
+			t.Errorf("enum[%d] = %#v, want %#v", i, back.Enum[i], want)
+		}
+	}
+}
@@ -0,0 +1,125 @@
+package docs
+
+import (
+	"reflect"
+	"strings"
+
+	"github.com/gofuckbiz/poltergeist"
+)
+
+// =============================================================================
+// PARAMETERS - OpenAPI parameters from binding structs
+// =============================================================================
+
+// paramSources maps parameter locations to the struct tag read for each
+var paramSources = []struct {
+	in  string
+	tag string
+	get func(r *poltergeist.Route) any
+}{
+	{"path", "param", func(r *poltergeist.Route) any { return r.PathParams }},
+	{"query", "query", func(r *poltergeist.Route) any { return r.QueryParams }},
+	{"header", "header", func(r *poltergeist.Route) any { return r.HeaderParams }},
+}
+
+// routeParameters collects path parameters from the route pattern, refined by
+// the typed Query()/Headers()/Params() structs declared on the route
+func routeParameters(route *poltergeist.Route, registry *SchemaRegistry) []Parameter {
+	params := extractParameters(route.Path)
+	for i := range params {
+		if pattern, ok := route.ParamPatterns[params[i].Name]; ok {
+			params[i].Schema.Pattern = "^(?:" + pattern + ")$"
+		}
+	}
+
+	for _, source := range paramSources {
+		v := source.get(route)
+		if v == nil {
+			continue
+		}
+		for _, param := range structParameters(reflect.TypeOf(v), source.in, source.tag, registry) {
+			params = mergeParameter(params, param)
+		}
+	}
+	return params
+}
+
+// mergeParameter replaces a parameter with the same name and location, or appends it
+func mergeParameter(params []Parameter, param Parameter) []Parameter {
+	for i, existing := range params {
+		if existing.Name == param.Name && existing.In == param.In {
+			params[i] = param
+			return params
+		}
+	}
+	return append(params, param)
+}
+
+// structParameters reflects the fields of a binding struct into parameters
+func structParameters(t reflect.Type, in, tag string, registry *SchemaRegistry) []Parameter {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return nil
+	}
+
+	var params []Parameter
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+
+		// Flatten embedded structs
+		if field.Anonymous && field.Tag.Get(tag) == "" {
+			params = append(params, structParameters(field.Type, in, tag, registry)...)
+			continue
+		}
+		if !field.IsExported() {
+			continue
+		}
+
+		name := paramFieldName(field, tag)
+		if name == "" {
+			continue
+		}
+
+		schema := registry.TypeSchema(field.Type)
+		applyFieldTags(schema, field)
+
+		param := Parameter{
+			Name:        name,
+			In:          in,
+			Description: schema.Description,
+			Required:    in == "path" || isExplicitlyRequired(field),
+			Schema:      schema,
+		}
+		schema.Description = ""
+		params = append(params, param)
+	}
+	return params
+}
+
+// paramFieldName returns the parameter name for a struct field: the binding tag,
+// falling back to the json tag and then the field name. "-" skips the field.
+func paramFieldName(field reflect.StructField, tag string) string {
+	name := field.Tag.Get(tag)
+	if name == "" {
+		name, _, _ = strings.Cut(field.Tag.Get("json"), ",")
+	}
+	if name == "-" {
+		return ""
+	}
+	if name == "" {
+		name = field.Name
+	}
+	return name
+}
+
+// isExplicitlyRequired reports whether validation tags mark a field required
+func isExplicitlyRequired(field reflect.StructField) bool {
+	for _, tag := range []string{"validate", "binding"} {
+		if strings.Contains(field.Tag.Get(tag), "required") {
+			return true
+		}
+	}
+	return false
+}
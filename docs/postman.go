@@ -0,0 +1,387 @@
+package docs
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/gofuckbiz/poltergeist"
+)
+
+// =============================================================================
+// POSTMAN - Collection export (Postman v2.1, importable by Insomnia)
+// =============================================================================
+
+// postmanSchema is the collection format identifier
+const postmanSchema = "https://schema.getpostman.com/json/collection/v2.1.0/collection.json"
+
+// Collection variables referenced by the requests; username and password are
+// only declared when a route uses basic or digest auth
+const (
+	PostmanBaseURLVar   = "baseUrl"
+	PostmanAuthTokenVar = "authToken"
+	PostmanUsernameVar  = "username"
+	PostmanPasswordVar  = "password"
+)
+
+// PostmanCollection represents a Postman v2.1 collection
+type PostmanCollection struct {
+	Info     PostmanInfo       `json:"info"`
+	Item     []PostmanItem     `json:"item"`
+	Variable []PostmanVariable `json:"variable,omitempty"`
+}
+
+// PostmanInfo represents collection metadata
+type PostmanInfo struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Schema      string `json:"schema"`
+}
+
+// PostmanItem is a request, or a folder when Item is set
+type PostmanItem struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description,omitempty"`
+	Item        []PostmanItem   `json:"item,omitempty"`
+	Request     *PostmanRequest `json:"request,omitempty"`
+}
+
+// PostmanRequest represents a request
+type PostmanRequest struct {
+	Method      string            `json:"method"`
+	Header      []PostmanVariable `json:"header"`
+	URL         PostmanURL        `json:"url"`
+	Body        *PostmanBody      `json:"body,omitempty"`
+	Auth        *PostmanAuth      `json:"auth,omitempty"`
+	Description string            `json:"description,omitempty"`
+}
+
+// PostmanURL represents a request URL
+type PostmanURL struct {
+	Raw      string            `json:"raw"`
+	Host     []string          `json:"host"`
+	Path     []string          `json:"path"`
+	Query    []PostmanVariable `json:"query,omitempty"`
+	Variable []PostmanVariable `json:"variable,omitempty"`
+}
+
+// PostmanBody represents a raw request body
+type PostmanBody struct {
+	Mode    string `json:"mode"`
+	Raw     string `json:"raw"`
+	Options any    `json:"options,omitempty"`
+}
+
+// PostmanAuth represents request authentication; the attributes are set under
+// the key matching Type
+type PostmanAuth struct {
+	Type   string            `json:"type"` // bearer, apikey, basic, digest, oauth2 or noauth
+	Bearer []PostmanVariable `json:"bearer,omitempty"`
+	APIKey []PostmanVariable `json:"apikey,omitempty"`
+	Basic  []PostmanVariable `json:"basic,omitempty"`
+	Digest []PostmanVariable `json:"digest,omitempty"`
+	OAuth2 []PostmanVariable `json:"oauth2,omitempty"`
+}
+
+// PostmanVariable is a key/value pair (variables, headers, query params)
+type PostmanVariable struct {
+	Key         string `json:"key"`
+	Value       string `json:"value"`
+	Type        string `json:"type,omitempty"`
+	Description string `json:"description,omitempty"`
+	Disabled    bool   `json:"disabled,omitempty"`
+}
+
+// ExportPostman exports the server's routes as a Postman collection with a
+// folder per tag, example request bodies, and {{baseUrl}} / {{authToken}}
+// collection variables
+func ExportPostman(server *poltergeist.Server, config ...*SwaggerConfig) ([]byte, error) {
+	var cfg *SwaggerConfig
+	if len(config) > 0 {
+		cfg = config[0]
+	}
+	return json.MarshalIndent(GeneratePostman(server.Routes(), cfg), "", "  ")
+}
+
+// GeneratePostman builds a Postman collection from routes
+func GeneratePostman(routes []*poltergeist.Route, config *SwaggerConfig) *PostmanCollection {
+	if config == nil {
+		config = DefaultSwaggerConfig()
+	}
+
+	baseURL := "http://localhost:8080"
+	if servers := config.servers(); len(servers) > 0 {
+		baseURL = strings.TrimSuffix(servers[0].URL, "/")
+	}
+
+	collection := &PostmanCollection{
+		Info: PostmanInfo{
+			Name:        config.Title,
+			Description: config.Description,
+			Schema:      postmanSchema,
+		},
+		Variable: []PostmanVariable{
+			{Key: PostmanBaseURLVar, Value: baseURL, Type: "string"},
+			{Key: PostmanAuthTokenVar, Value: "", Type: "string"},
+		},
+	}
+
+	registry := NewSchemaRegistry()
+	folders := make(map[string]*PostmanItem)
+	var order []string
+	credentials := false
+
+	for _, route := range routes {
+		if route.RouteProtocol != "" || !config.documents(route) {
+			continue
+		}
+		item := postmanItem(route, config, registry)
+		if auth := item.Request.Auth; auth != nil && (auth.Type == "basic" || auth.Type == "digest") {
+			credentials = true
+		}
+
+		if len(route.RouteTags) == 0 {
+			collection.Item = append(collection.Item, item)
+			continue
+		}
+		tag := route.RouteTags[0]
+		folder, ok := folders[tag]
+		if !ok {
+			folder = &PostmanItem{Name: tag}
+			folders[tag] = folder
+			order = append(order, tag)
+		}
+		folder.Item = append(folder.Item, item)
+	}
+
+	// Folders follow the configured tag order, then first use
+	var ordered []PostmanItem
+	for _, tag := range config.tags(nil) {
+		if folder, ok := folders[tag.Name]; ok {
+			folder.Description = tag.Description
+			ordered = append(ordered, *folder)
+			delete(folders, tag.Name)
+		}
+	}
+	for _, tag := range order {
+		if folder, ok := folders[tag]; ok {
+			ordered = append(ordered, *folder)
+		}
+	}
+	collection.Item = append(ordered, collection.Item...)
+
+	if credentials {
+		collection.Variable = append(collection.Variable,
+			PostmanVariable{Key: PostmanUsernameVar, Value: "", Type: "string"},
+			PostmanVariable{Key: PostmanPasswordVar, Value: "", Type: "string"},
+		)
+	}
+	return collection
+}
+
+// postmanItem converts a route into a request item
+func postmanItem(route *poltergeist.Route, config *SwaggerConfig, registry *SchemaRegistry) PostmanItem {
+	name := route.RouteName
+	if name == "" {
+		name = route.Method + " " + route.Path
+	}
+
+	request := &PostmanRequest{
+		Method:      route.Method,
+		Header:      []PostmanVariable{},
+		URL:         postmanURL(route.Path),
+		Description: route.RouteDescription,
+		Auth:        postmanAuth(route, config),
+	}
+
+	for _, param := range routeParameters(route, registry) {
+		variable := PostmanVariable{
+			Key:         param.Name,
+			Value:       paramExample(param.Schema),
+			Description: param.Description,
+			Disabled:    !param.Required && param.In != "path",
+		}
+		switch param.In {
+		case "query":
+			request.URL.Query = append(request.URL.Query, variable)
+		case "header":
+			request.Header = append(request.Header, variable)
+		case "path":
+			for i, existing := range request.URL.Variable {
+				if existing.Key == param.Name {
+					request.URL.Variable[i] = variable
+				}
+			}
+		}
+	}
+	request.URL.Raw = postmanRawURL(request.URL)
+
+	if body := requestExample(route); body != nil {
+		data, err := json.MarshalIndent(body, "", "  ")
+		if err == nil {
+			request.Header = append(request.Header, PostmanVariable{Key: "Content-Type", Value: "application/json"})
+			request.Body = &PostmanBody{
+				Mode:    "raw",
+				Raw:     string(data),
+				Options: map[string]any{"raw": map[string]string{"language": "json"}},
+			}
+		}
+	}
+
+	return PostmanItem{Name: name, Request: request}
+}
+
+// postmanURL splits a route path into {{baseUrl}}-relative segments
+func postmanURL(path string) PostmanURL {
+	u := PostmanURL{Host: []string{"{{" + PostmanBaseURLVar + "}}"}, Path: []string{}}
+	for _, part := range strings.Split(path, "/") {
+		if part == "" {
+			continue
+		}
+		if strings.HasPrefix(part, "*") {
+			part = ":" + strings.TrimPrefix(part, "*")
+		}
+		part = strings.TrimSuffix(part, "?")
+		u.Path = append(u.Path, part)
+		if strings.HasPrefix(part, ":") {
+			u.Variable = append(u.Variable, PostmanVariable{Key: strings.TrimPrefix(part, ":")})
+		}
+	}
+	return u
+}
+
+// postmanRawURL renders the raw URL including enabled query parameters
+func postmanRawURL(u PostmanURL) string {
+	raw := u.Host[0] + "/" + strings.Join(u.Path, "/")
+	var query []string
+	for _, q := range u.Query {
+		if !q.Disabled {
+			query = append(query, q.Key+"="+q.Value)
+		}
+	}
+	if len(query) > 0 {
+		raw += "?" + strings.Join(query, "&")
+	}
+	return raw
+}
+
+// postmanAuth maps the route's first security scheme to Postman auth. Tokens
+// and API keys use {{authToken}}, basic and digest {{username}}/{{password}}.
+func postmanAuth(route *poltergeist.Route, config *SwaggerConfig) *PostmanAuth {
+	requirements := route.RouteSecurity
+	if len(requirements) == 0 {
+		for _, req := range config.Security {
+			requirements = append(requirements, req)
+		}
+	}
+
+	for _, requirement := range requirements {
+		names := make([]string, 0, len(requirement))
+		for name := range requirement {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		if len(names) > 0 {
+			return schemeAuth(config.SecuritySchemes[names[0]], requirement[names[0]])
+		}
+	}
+	if len(route.RouteSecurity) > 0 || len(config.Security) > 0 {
+		return &PostmanAuth{Type: "noauth"} // optional auth: Security() without schemes
+	}
+	return nil
+}
+
+// schemeAuth converts a security scheme to Postman auth; unknown schemes fall
+// back to a bearer token
+func schemeAuth(scheme SecurityScheme, scopes []string) *PostmanAuth {
+	token := "{{" + PostmanAuthTokenVar + "}}"
+	username := "{{" + PostmanUsernameVar + "}}"
+	password := "{{" + PostmanPasswordVar + "}}"
+
+	switch {
+	case scheme.Type == "apiKey":
+		return &PostmanAuth{Type: "apikey", APIKey: []PostmanVariable{
+			{Key: "key", Value: scheme.Name},
+			{Key: "value", Value: token},
+			{Key: "in", Value: scheme.In},
+		}}
+	case scheme.Type == "http" && strings.EqualFold(scheme.Scheme, "basic"):
+		return &PostmanAuth{Type: "basic", Basic: []PostmanVariable{
+			{Key: "username", Value: username, Type: "string"},
+			{Key: "password", Value: password, Type: "string"},
+		}}
+	case scheme.Type == "http" && strings.EqualFold(scheme.Scheme, "digest"):
+		return &PostmanAuth{Type: "digest", Digest: []PostmanVariable{
+			{Key: "username", Value: username, Type: "string"},
+			{Key: "password", Value: password, Type: "string"},
+		}}
+	case scheme.Type == "oauth2" || scheme.Type == "openIdConnect":
+		auth := &PostmanAuth{Type: "oauth2", OAuth2: []PostmanVariable{
+			{Key: "accessToken", Value: token, Type: "string"},
+			{Key: "addTokenTo", Value: "header", Type: "string"},
+		}}
+		if grant, flow := oauthFlow(scheme.Flows); flow != nil {
+			auth.OAuth2 = append(auth.OAuth2, PostmanVariable{Key: "grant_type", Value: grant, Type: "string"})
+			if flow.AuthorizationURL != "" {
+				auth.OAuth2 = append(auth.OAuth2, PostmanVariable{Key: "authUrl", Value: flow.AuthorizationURL, Type: "string"})
+			}
+			if flow.TokenURL != "" {
+				auth.OAuth2 = append(auth.OAuth2, PostmanVariable{Key: "accessTokenUrl", Value: flow.TokenURL, Type: "string"})
+			}
+		}
+		if len(scopes) > 0 {
+			auth.OAuth2 = append(auth.OAuth2, PostmanVariable{Key: "scope", Value: strings.Join(scopes, " "), Type: "string"})
+		}
+		return auth
+	default:
+		return &PostmanAuth{Type: "bearer", Bearer: []PostmanVariable{
+			{Key: "token", Value: token, Type: "string"},
+		}}
+	}
+}
+
+// oauthFlow picks the flow Postman should use and its grant type
+func oauthFlow(flows *OAuthFlows) (string, *OAuthFlow) {
+	switch {
+	case flows == nil:
+		return "", nil
+	case flows.AuthorizationCode != nil:
+		return "authorization_code", flows.AuthorizationCode
+	case flows.ClientCredentials != nil:
+		return "client_credentials", flows.ClientCredentials
+	case flows.Password != nil:
+		return "password_credentials", flows.Password
+	case flows.Implicit != nil:
+		return "implicit", flows.Implicit
+	}
+	return "", nil
+}
+
+// requestExample returns the declared request example or one generated from the type
+func requestExample(route *poltergeist.Route) any {
+	if route.RequestBodyExample != nil {
+		return route.RequestBodyExample
+	}
+	if route.RequestBody == nil {
+		return nil
+	}
+	return poltergeist.MockValue(reflect.TypeOf(route.RequestBody))
+}
+
+// paramExample picks a sample value for a parameter
+func paramExample(schema *Schema) string {
+	if schema == nil {
+		return ""
+	}
+	for _, v := range []any{schema.Example, schema.Default} {
+		if v != nil {
+			return fmt.Sprint(v)
+		}
+	}
+	if len(schema.Enum) > 0 {
+		return fmt.Sprint(schema.Enum[0])
+	}
+	return ""
+}
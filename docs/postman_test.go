@@ -0,0 +1,120 @@
+package docs
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/gofuckbiz/poltergeist"
+)
+
+// =============================================================================
+// POSTMAN EXPORT TESTS
+// =============================================================================
+
+// postmanAuths returns the auth of each request in the collection by name
+func postmanAuths(collection *PostmanCollection) map[string]*PostmanAuth {
+	auths := make(map[string]*PostmanAuth)
+	for _, item := range collection.Item {
+		if item.Request != nil {
+			auths[item.Name] = item.Request.Auth
+		}
+	}
+	return auths
+}
+
+// postmanValues flattens auth attributes into key/value pairs
+func postmanValues(vars []PostmanVariable) map[string]string {
+	values := make(map[string]string, len(vars))
+	for _, v := range vars {
+		values[v.Key] = v.Value
+	}
+	return values
+}
+
+func TestGeneratePostman_Auth(t *testing.T) {
+	handler := func(c *poltergeist.Context) error { return c.NoContent() }
+	app := poltergeist.New()
+	app.GET("/public", handler).Name("public")
+	app.GET("/bearer", handler).Name("bearer").Security("jwt")
+	app.GET("/basic", handler).Name("basic").Security("basic")
+	app.GET("/digest", handler).Name("digest").Security("digest")
+	app.GET("/key", handler).Name("key").Security("key")
+	app.GET("/oauth", handler).Name("oauth").SecurityScopes("oauth", "orders:read", "orders:write")
+	app.GET("/oidc", handler).Name("oidc").Security("oidc")
+	app.GET("/unknown", handler).Name("unknown").Security("missing")
+
+	config := DefaultSwaggerConfig()
+	config.SecuritySchemes = map[string]SecurityScheme{
+		"jwt":    BearerAuth("JWT"),
+		"basic":  BasicAuth(),
+		"digest": {Type: "http", Scheme: "Digest"},
+		"key":    APIKeyAuth("header", "X-API-Key"),
+		"oauth": OAuth2(OAuthFlows{ClientCredentials: &OAuthFlow{
+			TokenURL: "https://auth.example.com/token",
+			Scopes:   map[string]string{"orders:read": "Read orders"},
+		}}),
+		"oidc": OpenIDConnect("https://auth.example.com/.well-known/openid-configuration"),
+	}
+	collection := GeneratePostman(app.Routes(), config)
+	auths := postmanAuths(collection)
+
+	if auths["public"] != nil {
+		t.Errorf("public auth = %+v", auths["public"])
+	}
+
+	tests := []struct {
+		name, typ string
+		attrs     func(*PostmanAuth) []PostmanVariable
+		want      map[string]string
+	}{
+		{"bearer", "bearer", func(a *PostmanAuth) []PostmanVariable { return a.Bearer },
+			map[string]string{"token": "{{authToken}}"}},
+		{"unknown", "bearer", func(a *PostmanAuth) []PostmanVariable { return a.Bearer },
+			map[string]string{"token": "{{authToken}}"}},
+		{"basic", "basic", func(a *PostmanAuth) []PostmanVariable { return a.Basic },
+			map[string]string{"username": "{{username}}", "password": "{{password}}"}},
+		{"digest", "digest", func(a *PostmanAuth) []PostmanVariable { return a.Digest },
+			map[string]string{"username": "{{username}}", "password": "{{password}}"}},
+		{"key", "apikey", func(a *PostmanAuth) []PostmanVariable { return a.APIKey },
+			map[string]string{"key": "X-API-Key", "value": "{{authToken}}", "in": "header"}},
+		{"oauth", "oauth2", func(a *PostmanAuth) []PostmanVariable { return a.OAuth2 },
+			map[string]string{
+				"accessToken":    "{{authToken}}",
+				"addTokenTo":     "header",
+				"grant_type":     "client_credentials",
+				"accessTokenUrl": "https://auth.example.com/token",
+				"scope":          "orders:read orders:write",
+			}},
+		{"oidc", "oauth2", func(a *PostmanAuth) []PostmanVariable { return a.OAuth2 },
+			map[string]string{"accessToken": "{{authToken}}", "addTokenTo": "header"}},
+	}
+	for _, tt := range tests {
+		auth := auths[tt.name]
+		if auth == nil || auth.Type != tt.typ {
+			t.Errorf("%s auth = %+v, want type %s", tt.name, auth, tt.typ)
+			continue
+		}
+		if got := postmanValues(tt.attrs(auth)); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s attributes = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+
+	vars := postmanValues(collection.Variable)
+	for _, key := range []string{PostmanBaseURLVar, PostmanAuthTokenVar, PostmanUsernameVar, PostmanPasswordVar} {
+		if _, ok := vars[key]; !ok {
+			t.Errorf("collection variable %q missing", key)
+		}
+	}
+}
+
+func TestGeneratePostman_CredentialVariables(t *testing.T) {
+	app := poltergeist.New()
+	app.GET("/bearer", func(c *poltergeist.Context) error { return c.NoContent() }).Security("jwt")
+
+	config := DefaultSwaggerConfig()
+	config.SecuritySchemes = map[string]SecurityScheme{"jwt": BearerAuth("")}
+	vars := postmanValues(GeneratePostman(app.Routes(), config).Variable)
+	if _, ok := vars[PostmanUsernameVar]; ok {
+		t.Errorf("username declared without basic auth: %v", vars)
+	}
+}
@@ -0,0 +1,458 @@
+package docs
+
+import (
+	"encoding/json"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// =============================================================================
+// SCHEMA - JSON Schema (OpenAPI 3.1 dialect)
+// =============================================================================
+
+// Schema represents a schema
+type Schema struct {
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	Enum                 []any              `json:"enum,omitempty"`
+	Default              any                `json:"default,omitempty"`
+	Example              any                `json:"example,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+	Maximum              *float64           `json:"maximum,omitempty"`
+	MinLength            *int               `json:"minLength,omitempty"`
+	MaxLength            *int               `json:"maxLength,omitempty"`
+	Pattern              string             `json:"pattern,omitempty"`
+	Ref                  string             `json:"$ref,omitempty"`
+	AnyOf                []*Schema          `json:"anyOf,omitempty"`
+
+	// Nullable marks the value as accepting null. OpenAPI 3.1 has no nullable
+	// keyword, so it is rendered as a type array or an anyOf with null.
+	Nullable bool `json:"-"`
+}
+
+// MarshalJSON renders nullable schemas using OpenAPI 3.1 type arrays
+func (s *Schema) MarshalJSON() ([]byte, error) {
+	type plain Schema
+	if !s.Nullable {
+		return json.Marshal((*plain)(s))
+	}
+
+	// $ref siblings are ignored by many tools: wrap refs in anyOf
+	if s.Ref != "" || s.Type == "" {
+		inner := *s
+		inner.Nullable = false
+		inner.Description = ""
+		return json.Marshal(&Schema{
+			Description: s.Description,
+			AnyOf:       []*Schema{&inner, {Type: "null"}},
+		})
+	}
+
+	return json.Marshal(struct {
+		*plain
+		Type []string `json:"type"`
+	}{
+		plain: (*plain)(s),
+		Type:  []string{s.Type, "null"},
+	})
+}
+
+// Enumer can be implemented by types with a fixed set of values
+// to document them as an enum, e.g.
+//
+//	func (Status) Enum() []any { return []any{"active", "banned"} }
+type Enumer interface {
+	Enum() []any
+}
+
+// =============================================================================
+// SCHEMA REGISTRY - Reflection of Go types into component schemas
+// =============================================================================
+
+// componentsPrefix is the JSON pointer prefix for component schemas
+const componentsPrefix = "#/components/schemas/"
+
+var (
+	timeType       = reflect.TypeOf(time.Time{})
+	durationType   = reflect.TypeOf(time.Duration(0))
+	rawMessageType = reflect.TypeOf(json.RawMessage{})
+	enumerType     = reflect.TypeOf((*Enumer)(nil)).Elem()
+)
+
+// SchemaRegistry reflects Go types into schemas, registering named structs as
+// reusable components. Recursive types resolve to $ref cycles.
+type SchemaRegistry struct {
+	schemas map[string]*Schema
+	names   map[reflect.Type]string
+}
+
+// NewSchemaRegistry creates an empty registry
+func NewSchemaRegistry() *SchemaRegistry {
+	return &SchemaRegistry{
+		schemas: make(map[string]*Schema),
+		names:   make(map[reflect.Type]string),
+	}
+}
+
+// Schemas returns all registered component schemas
+func (r *SchemaRegistry) Schemas() map[string]*Schema {
+	return r.schemas
+}
+
+// Resolve returns the component schema a $ref points to (or the schema itself)
+func (r *SchemaRegistry) Resolve(s *Schema) *Schema {
+	for s != nil && s.Ref != "" {
+		next, ok := r.schemas[strings.TrimPrefix(s.Ref, componentsPrefix)]
+		if !ok {
+			return s
+		}
+		s = next
+	}
+	return s
+}
+
+// SchemaFor returns the schema for a value's type; named structs become $refs
+func (r *SchemaRegistry) SchemaFor(v any) *Schema {
+	if v == nil {
+		return &Schema{}
+	}
+	return r.TypeSchema(reflect.TypeOf(v))
+}
+
+// TypeSchema returns the schema for a Go type; named structs become $refs
+func (r *SchemaRegistry) TypeSchema(t reflect.Type) *Schema {
+	if t == nil {
+		return &Schema{}
+	}
+
+	// Pointers are nullable
+	if t.Kind() == reflect.Ptr {
+		s := r.TypeSchema(t.Elem())
+		s.Nullable = true
+		return s
+	}
+
+	if s := r.specialSchema(t); s != nil {
+		return s
+	}
+
+	if t.Kind() == reflect.Struct && t.Name() != "" {
+		return &Schema{Ref: componentsPrefix + r.register(t)}
+	}
+	return r.inlineSchema(t)
+}
+
+// register adds a named struct as a component, returning its name
+func (r *SchemaRegistry) register(t reflect.Type) string {
+	if name, ok := r.names[t]; ok {
+		return name
+	}
+
+	name := r.componentName(t)
+	r.names[t] = name
+
+	// Placeholder first so recursive fields resolve to the same $ref
+	placeholder := &Schema{}
+	r.schemas[name] = placeholder
+	*placeholder = *r.structSchema(t)
+	return name
+}
+
+// componentName picks a unique, URL-safe component name for a type
+func (r *SchemaRegistry) componentName(t reflect.Type) string {
+	name := sanitizeName(t.Name())
+	if _, taken := r.schemas[name]; !taken {
+		return name
+	}
+
+	// Same name from another package: qualify with the package name
+	pkg := t.PkgPath()
+	if idx := strings.LastIndex(pkg, "/"); idx != -1 {
+		pkg = pkg[idx+1:]
+	}
+	qualified := sanitizeName(pkg) + "." + name
+	candidate := qualified
+	for i := 2; ; i++ {
+		if _, taken := r.schemas[candidate]; !taken {
+			return candidate
+		}
+		candidate = qualified + strconv.Itoa(i)
+	}
+}
+
+// specialSchema handles well-known types and enums
+func (r *SchemaRegistry) specialSchema(t reflect.Type) *Schema {
+	switch t {
+	case timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case durationType:
+		return &Schema{Type: "integer", Format: "int64", Description: "Duration in nanoseconds"}
+	case rawMessageType:
+		return &Schema{}
+	}
+
+	// UUID-like types: [16]byte named UUID (google/uuid, gofrs/uuid, ...)
+	if t.Kind() == reflect.Array && t.Len() == 16 && t.Elem().Kind() == reflect.Uint8 && strings.EqualFold(t.Name(), "uuid") {
+		return &Schema{Type: "string", Format: "uuid"}
+	}
+
+	// Interfaces have no value to ask; a pointer to a zero value also covers
+	// Enum methods with pointer receivers
+	if t.Kind() != reflect.Interface && reflect.PointerTo(t).Implements(enumerType) {
+		values := reflect.New(t).Interface().(Enumer).Enum()
+		s := r.inlineSchema(t)
+		s.Enum = values
+		return s
+	}
+	return nil
+}
+
+// inlineSchema builds a schema for non-named-struct types
+func (r *SchemaRegistry) inlineSchema(t reflect.Type) *Schema {
+	switch t.Kind() {
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Uint, reflect.Uint8, reflect.Uint16:
+		return &Schema{Type: "integer"}
+	case reflect.Int32, reflect.Uint32:
+		return &Schema{Type: "integer", Format: "int32"}
+	case reflect.Int64, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Float32:
+		return &Schema{Type: "number", Format: "float"}
+	case reflect.Float64:
+		return &Schema{Type: "number", Format: "double"}
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return &Schema{Type: "array", Items: r.TypeSchema(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: r.TypeSchema(t.Elem())}
+	case reflect.Struct:
+		return r.structSchema(t)
+	case reflect.Interface:
+		return &Schema{}
+	default:
+		return &Schema{Type: "object"}
+	}
+}
+
+// structSchema reflects struct fields, flattening embedded structs
+func (r *SchemaRegistry) structSchema(t reflect.Type) *Schema {
+	s := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+	r.collectFields(t, s)
+	return s
+}
+
+// collectFields adds the JSON-visible fields of t to s
+func (r *SchemaRegistry) collectFields(t reflect.Type, s *Schema) {
+	for _, f := range jsonFields(t) {
+		prop := r.TypeSchema(f.field.Type)
+		applyFieldTags(prop, f.field)
+		s.Properties[f.name] = prop
+
+		if isRequired(f.field, f.opts) {
+			s.Required = append(s.Required, f.name)
+		}
+	}
+}
+
+// jsonField is a struct field as encoding/json sees it
+type jsonField struct {
+	name   string
+	opts   string // tag options, e.g. "omitempty"
+	tagged bool   // named by its json tag
+	index  []int  // path through embedded structs
+	field  reflect.StructField
+}
+
+// jsonFields lists the JSON-visible fields of a struct in field order.
+// Embedded structs without a JSON name are flattened the way encoding/json
+// does it: among fields sharing a name the shallowest wins, then the only
+// tagged one; any other tie hides the name altogether.
+func jsonFields(t reflect.Type) []jsonField {
+	type embedded struct {
+		typ   reflect.Type
+		index []int
+	}
+
+	var candidates []jsonField
+	visited := map[reflect.Type]bool{}
+	next := []embedded{{typ: t}}
+	for len(next) > 0 {
+		current := next
+		next = nil
+
+		// A type embedded twice at one depth yields each field twice, so
+		// the copies cancel out below
+		count := map[reflect.Type]int{}
+		for _, e := range current {
+			count[e.typ]++
+		}
+
+		for _, e := range current {
+			if visited[e.typ] {
+				continue
+			}
+			visited[e.typ] = true
+
+			for i := 0; i < e.typ.NumField(); i++ {
+				field := e.typ.Field(i)
+				jsonTag := field.Tag.Get("json")
+				if jsonTag == "-" {
+					continue
+				}
+				name, opts, _ := strings.Cut(jsonTag, ",")
+				index := append(append([]int{}, e.index...), i)
+
+				if field.Anonymous && name == "" {
+					ft := field.Type
+					if ft.Kind() == reflect.Ptr {
+						ft = ft.Elem()
+					}
+					if ft.Kind() == reflect.Struct {
+						next = append(next, embedded{typ: ft, index: index})
+						continue
+					}
+				}
+				if !field.IsExported() {
+					continue
+				}
+
+				f := jsonField{name: name, opts: opts, tagged: name != "", index: index, field: field}
+				if f.name == "" {
+					f.name = field.Name
+				}
+				candidates = append(candidates, f)
+				if count[e.typ] > 1 {
+					candidates = append(candidates, f)
+				}
+			}
+		}
+	}
+
+	byName := map[string][]jsonField{}
+	for _, f := range candidates {
+		byName[f.name] = append(byName[f.name], f)
+	}
+	var fields []jsonField
+	for _, named := range byName {
+		if f, ok := dominantField(named); ok {
+			fields = append(fields, f)
+		}
+	}
+	slices.SortFunc(fields, func(a, b jsonField) int {
+		return slices.Compare(a.index, b.index)
+	})
+	return fields
+}
+
+// dominantField picks the field a JSON name refers to, if any: the only
+// one at the shallowest depth, or the only tagged one there
+func dominantField(fields []jsonField) (jsonField, bool) {
+	depth := len(fields[0].index)
+	for _, f := range fields[1:] {
+		depth = min(depth, len(f.index))
+	}
+
+	var shallowest, tagged []jsonField
+	for _, f := range fields {
+		if len(f.index) != depth {
+			continue
+		}
+		shallowest = append(shallowest, f)
+		if f.tagged {
+			tagged = append(tagged, f)
+		}
+	}
+	switch {
+	case len(shallowest) == 1:
+		return shallowest[0], true
+	case len(tagged) == 1:
+		return tagged[0], true
+	}
+	return jsonField{}, false
+}
+
+// applyFieldTags applies documentation tags to a property schema:
+//
+//	format:"email"        format override (date-time, uuid, email, uri, ...)
+//	enum:"a,b,c"          allowed values
+//	doc:"..."             description
+//	example:"..."         example value
+//	default:"..."         default value
+func applyFieldTags(target *Schema, field reflect.StructField) {
+	if format := field.Tag.Get("format"); format != "" {
+		target.Format = format
+		if target.Type == "" {
+			target.Type = "string"
+		}
+	}
+	if desc := field.Tag.Get("doc"); desc != "" {
+		target.Description = desc
+	} else if desc := field.Tag.Get("description"); desc != "" {
+		target.Description = desc
+	}
+	if enum := field.Tag.Get("enum"); enum != "" {
+		target.Enum = nil
+		for _, v := range strings.Split(enum, ",") {
+			target.Enum = append(target.Enum, convertTagValue(strings.TrimSpace(v), target.Type))
+		}
+	}
+	if example, ok := field.Tag.Lookup("example"); ok {
+		target.Example = convertTagValue(example, target.Type)
+	}
+	if def, ok := field.Tag.Lookup("default"); ok {
+		target.Default = convertTagValue(def, target.Type)
+	}
+}
+
+// isRequired decides whether a field is required: explicit validation tags win,
+// otherwise non-pointer fields without omitempty are required
+func isRequired(field reflect.StructField, jsonOpts string) bool {
+	for _, tag := range []string{"validate", "binding"} {
+		if v := field.Tag.Get(tag); v != "" {
+			return strings.Contains(v, "required")
+		}
+	}
+	if field.Type.Kind() == reflect.Ptr {
+		return false
+	}
+	return !strings.Contains(jsonOpts, "omitempty")
+}
+
+// convertTagValue converts a tag string into a value matching the schema type
+func convertTagValue(value, schemaType string) any {
+	switch schemaType {
+	case "integer":
+		if n, err := strconv.ParseInt(value, 10, 64); err == nil {
+			return n
+		}
+	case "number":
+		if f, err := strconv.ParseFloat(value, 64); err == nil {
+			return f
+		}
+	case "boolean":
+		if b, err := strconv.ParseBool(value); err == nil {
+			return b
+		}
+	}
+	return value
+}
+
+// sanitizeName makes a type name safe for use in a JSON pointer
+func sanitizeName(name string) string {
+	replacer := strings.NewReplacer("[", "_", "]", "", "/", "_", "*", "", ",", "_", " ", "")
+	return replacer.Replace(name)
+}
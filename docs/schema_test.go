@@ -0,0 +1,161 @@
+package docs
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+// =============================================================================
+// SCHEMA REGISTRY TESTS
+// =============================================================================
+
+type valueStatus string
+
+func (valueStatus) Enum() []any { return []any{"active", "banned"} }
+
+type pointerStatus string
+
+func (*pointerStatus) Enum() []any { return []any{"open", "closed"} }
+
+type enumHolder struct {
+	Value   valueStatus    `json:"value"`
+	Pointer pointerStatus  `json:"pointer"`
+	Boxed   *pointerStatus `json:"boxed"`
+	Any     Enumer         `json:"any"`
+}
+
+func TestSchemaRegistry_Enums(t *testing.T) {
+	r := NewSchemaRegistry()
+	s := r.Resolve(r.SchemaFor(enumHolder{}))
+
+	tests := []struct {
+		property string
+		want     []any
+	}{
+		{"value", []any{"active", "banned"}},
+		{"pointer", []any{"open", "closed"}},
+		{"boxed", []any{"open", "closed"}},
+		{"any", nil},
+	}
+	for _, tt := range tests {
+		prop := s.Properties[tt.property]
+		if prop == nil || !reflect.DeepEqual(prop.Enum, tt.want) {
+			t.Errorf("%s = %+v, want enum %v", tt.property, prop, tt.want)
+		}
+	}
+}
+
+type selfEmbedding struct {
+	*selfEmbedding
+	Name     string          `json:"name"`
+	Children []selfEmbedding `json:"children"`
+}
+
+type embedsB struct {
+	*embedsA
+	B string `json:"b"`
+}
+
+type embedsA struct {
+	*embedsB
+	A string `json:"a"`
+}
+
+func TestSchemaRegistry_SelfEmbedding(t *testing.T) {
+	r := NewSchemaRegistry()
+	ref := r.SchemaFor(selfEmbedding{})
+	s := r.Resolve(ref)
+	if len(s.Properties) != 2 || s.Properties["name"] == nil {
+		t.Fatalf("properties = %v, want name and children", s.Properties)
+	}
+	if items := s.Properties["children"].Items; items == nil || items.Ref != ref.Ref {
+		t.Errorf("children items = %+v, want a $ref to %s", items, ref.Ref)
+	}
+
+	s = r.Resolve(r.SchemaFor(embedsA{}))
+	if len(s.Properties) != 2 || s.Properties["a"] == nil || s.Properties["b"] == nil {
+		t.Errorf("mutually embedding properties = %v, want a and b", s.Properties)
+	}
+}
+
+type Audit struct {
+	ID        string
+	CreatedBy string `json:"created_by"`
+	Version   int    `json:"version"`
+}
+
+type Owner struct {
+	ID   string
+	Team string `json:"Team"`
+}
+
+type Labels struct {
+	Team  string
+	Color string
+}
+
+type shadowing struct {
+	Audit                    // ID ties with Owner's: both are dropped
+	Owner                    // Team ties with Labels', but only this one is tagged
+	Labels                   // Color is flattened
+	CreatedBy string         `json:"created_by"` // shadows Audit's deeper field
+	Nested    struct{ Deep } `json:"nested"`
+	Shadow    string         `json:"-"`
+	internal  string
+}
+
+type Deep struct {
+	Level int `json:"level"`
+}
+
+type twice struct {
+	*Deep
+	Other
+}
+
+type Other struct {
+	Deep // same type as twice.Deep, one level deeper: twice.Deep wins
+}
+
+func TestSchemaRegistry_EmbeddedPrecedence(t *testing.T) {
+	for _, v := range []any{shadowing{}, twice{Deep: &Deep{}}} {
+		r := NewSchemaRegistry()
+		s := r.Resolve(r.SchemaFor(v))
+
+		data, err := json.Marshal(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var encoded map[string]any
+		json.Unmarshal(data, &encoded)
+
+		var want, got []string
+		for name := range encoded {
+			want = append(want, name)
+		}
+		for name := range s.Properties {
+			got = append(got, name)
+		}
+		sort.Strings(want)
+		sort.Strings(got)
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%T properties = %v, want encoding/json's %v", v, got, want)
+		}
+	}
+}
+
+type requiredTwice struct {
+	Audit
+	CreatedBy string `json:"created_by"`
+}
+
+func TestSchemaRegistry_RequiredOnce(t *testing.T) {
+	r := NewSchemaRegistry()
+	s := r.Resolve(r.SchemaFor(requiredTwice{}))
+	want := []string{"ID", "version", "created_by"}
+	if !reflect.DeepEqual(s.Required, want) {
+		t.Errorf("Required = %v, want %v", s.Required, want)
+	}
+}
@@ -2,27 +2,32 @@ package docs
 
 import (
 	"encoding/json"
-	"fmt"
+	"io/fs"
 	"net/http"
-	"reflect"
+	"os"
+	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/gofuckbiz/poltergeist"
 )
 
-// OpenAPI represents the OpenAPI 3.0 specification
+// OpenAPI represents the OpenAPI 3.1 specification
 type OpenAPI struct {
-	OpenAPI    string              `json:"openapi"`
-	Info       Info                `json:"info"`
-	Servers    []Server            `json:"servers,omitempty"`
-	Paths      map[string]PathItem `json:"paths"`
-	Components *Components         `json:"components,omitempty"`
-	Tags       []Tag               `json:"tags,omitempty"`
+	OpenAPI      string              `json:"openapi"`
+	Info         Info                `json:"info"`
+	Servers      []Server            `json:"servers,omitempty"`
+	Paths        map[string]PathItem `json:"paths"`
+	Components   *Components         `json:"components,omitempty"`
+	Security     []SecurityReq       `json:"security,omitempty"`
+	Tags         []Tag               `json:"tags,omitempty"`
+	ExternalDocs *ExternalDocs       `json:"externalDocs,omitempty"`
 }
 
 // Info represents API information
 type Info struct {
 	Title          string   `json:"title"`
+	Summary        string   `json:"summary,omitempty"`
 	Description    string   `json:"description,omitempty"`
 	TermsOfService string   `json:"termsOfService,omitempty"`
 	Contact        *Contact `json:"contact,omitempty"`
@@ -39,12 +44,28 @@ type Contact struct {
 
 // License represents license information
 type License struct {
-	Name string `json:"name"`
-	URL  string `json:"url,omitempty"`
+	Name       string `json:"name"`
+	Identifier string `json:"identifier,omitempty"` // SPDX expression, e.g. "MIT"
+	URL        string `json:"url,omitempty"`
 }
 
 // Server represents a server
 type Server struct {
+	URL         string                    `json:"url"`
+	Description string                    `json:"description,omitempty"`
+	Variables   map[string]ServerVariable `json:"variables,omitempty"`
+}
+
+// ServerVariable represents a substitution in a server URL template
+// such as "https://{region}.api.example.com"
+type ServerVariable struct {
+	Default     string   `json:"default"`
+	Enum        []string `json:"enum,omitempty"`
+	Description string   `json:"description,omitempty"`
+}
+
+// ExternalDocs links to additional documentation
+type ExternalDocs struct {
 	URL         string `json:"url"`
 	Description string `json:"description,omitempty"`
 }
@@ -97,17 +118,15 @@ type Response struct {
 
 // MediaType represents a media type
 type MediaType struct {
-	Schema *Schema `json:"schema,omitempty"`
+	Schema  *Schema `json:"schema,omitempty"`
+	Example any     `json:"example,omitempty"`
 }
 
-// Schema represents a schema
-type Schema struct {
-	Type       string             `json:"type,omitempty"`
-	Format     string             `json:"format,omitempty"`
-	Items      *Schema            `json:"items,omitempty"`
-	Properties map[string]*Schema `json:"properties,omitempty"`
-	Required   []string           `json:"required,omitempty"`
-	Ref        string             `json:"$ref,omitempty"`
+// ErrorResponse is the default error envelope, matching Context.Error and
+// the server's HTTPError rendering
+type ErrorResponse struct {
+	Error   string `json:"error" example:"Something went wrong"`
+	Details any    `json:"details,omitempty"`
 }
 
 // Components represents API components
@@ -118,12 +137,55 @@ type Components struct {
 
 // SecurityScheme represents a security scheme
 type SecurityScheme struct {
-	Type         string `json:"type"`
-	Scheme       string `json:"scheme,omitempty"`
-	BearerFormat string `json:"bearerFormat,omitempty"`
-	Name         string `json:"name,omitempty"`
-	In           string `json:"in,omitempty"`
-	Description  string `json:"description,omitempty"`
+	Type             string      `json:"type"` // http, apiKey, oauth2, openIdConnect
+	Scheme           string      `json:"scheme,omitempty"`
+	BearerFormat     string      `json:"bearerFormat,omitempty"`
+	Name             string      `json:"name,omitempty"`
+	In               string      `json:"in,omitempty"` // header, query, cookie
+	Description      string      `json:"description,omitempty"`
+	Flows            *OAuthFlows `json:"flows,omitempty"`
+	OpenIDConnectURL string      `json:"openIdConnectUrl,omitempty"`
+}
+
+// OAuthFlows represents the OAuth2 flows supported by a scheme
+type OAuthFlows struct {
+	Implicit          *OAuthFlow `json:"implicit,omitempty"`
+	Password          *OAuthFlow `json:"password,omitempty"`
+	ClientCredentials *OAuthFlow `json:"clientCredentials,omitempty"`
+	AuthorizationCode *OAuthFlow `json:"authorizationCode,omitempty"`
+}
+
+// OAuthFlow represents a single OAuth2 flow
+type OAuthFlow struct {
+	AuthorizationURL string            `json:"authorizationUrl,omitempty"`
+	TokenURL         string            `json:"tokenUrl,omitempty"`
+	RefreshURL       string            `json:"refreshUrl,omitempty"`
+	Scopes           map[string]string `json:"scopes"`
+}
+
+// BearerAuth returns an HTTP bearer scheme (format is informational, e.g. "JWT")
+func BearerAuth(format string) SecurityScheme {
+	return SecurityScheme{Type: "http", Scheme: "bearer", BearerFormat: format}
+}
+
+// BasicAuth returns an HTTP basic scheme
+func BasicAuth() SecurityScheme {
+	return SecurityScheme{Type: "http", Scheme: "basic"}
+}
+
+// APIKeyAuth returns an API key scheme; in is "header", "query", or "cookie"
+func APIKeyAuth(in, name string) SecurityScheme {
+	return SecurityScheme{Type: "apiKey", In: in, Name: name}
+}
+
+// OAuth2 returns an OAuth2 scheme with the given flows
+func OAuth2(flows OAuthFlows) SecurityScheme {
+	return SecurityScheme{Type: "oauth2", Flows: &flows}
+}
+
+// OpenIDConnect returns an OpenID Connect discovery scheme
+func OpenIDConnect(discoveryURL string) SecurityScheme {
+	return SecurityScheme{Type: "openIdConnect", OpenIDConnectURL: discoveryURL}
 }
 
 // SecurityReq represents a security requirement
@@ -131,19 +193,63 @@ type SecurityReq map[string][]string
 
 // Tag represents an API tag
 type Tag struct {
-	Name        string `json:"name"`
-	Description string `json:"description,omitempty"`
+	Name         string        `json:"name"`
+	Description  string        `json:"description,omitempty"`
+	ExternalDocs *ExternalDocs `json:"externalDocs,omitempty"`
 }
 
 // SwaggerConfig holds Swagger UI configuration
 type SwaggerConfig struct {
-	Title       string
-	Description string
-	Version     string
-	BasePath    string
-	Servers     []Server
-	Contact     *Contact
-	License     *License
+	Title          string
+	Summary        string
+	Description    string
+	Version        string
+	BasePath       string
+	TermsOfService string
+	Servers        []Server
+	Contact        *Contact
+	License        *License
+	ExternalDocs   *ExternalDocs
+
+	// Tags lists tags in display order with descriptions; tags used by routes
+	// but not listed here follow alphabetically
+	Tags []Tag
+
+	// Environments lists servers per deployment environment, e.g.
+	// {"production": {{URL: "https://api.example.com"}}, "staging": ...}.
+	// When Environment (or POLTERGEIST_ENV) names one of them only its servers
+	// are documented; otherwise all are, labelled with the environment name.
+	Environments map[string][]Server
+	Environment  string
+
+	// UI selects the documentation UI served at Path (default: UISwagger)
+	UI UIKind
+	// Path is where the UI and spec are served (default: "/swagger")
+	Path string
+	// Assets serves UI scripts and styles from this filesystem instead of the
+	// CDN (offline mode); see UIKind for the expected file names
+	Assets fs.FS
+
+	// SecuritySchemes declares schemes referenced by route.Security(name),
+	// e.g. {"bearerAuth": docs.BearerAuth("JWT")}
+	SecuritySchemes map[string]SecurityScheme
+	// Security is the default requirement for routes without their own
+	Security []SecurityReq
+
+	// APIVersion documents only routes of a Version() group (see SwaggerVersions)
+	APIVersion string
+
+	// Route filters: hidden routes are always skipped. When IncludeTags or
+	// IncludePaths are set, only matching routes are documented; ExcludeTags
+	// and ExcludePaths then remove routes by tag or path prefix.
+	IncludeTags  []string
+	ExcludeTags  []string
+	IncludePaths []string
+	ExcludePaths []string
+
+	// ErrorBody is the envelope documented for error responses
+	// (default: ErrorResponse, the shape written by Context.Error)
+	ErrorBody any
 }
 
 // DefaultSwaggerConfig returns default Swagger configuration
@@ -153,12 +259,52 @@ func DefaultSwaggerConfig() *SwaggerConfig {
 		Description: "API documentation generated by Poltergeist",
 		Version:     "1.0.0",
 		BasePath:    "/",
+		UI:          UISwagger,
+		Path:        "/swagger",
 		Servers: []Server{
 			{URL: "http://localhost:8080", Description: "Development server"},
 		},
 	}
 }
 
+// documents reports whether a route belongs in the spec
+func (config *SwaggerConfig) documents(route *poltergeist.Route) bool {
+	if route.RouteHidden {
+		return false
+	}
+	if config.APIVersion != "" && route.RouteVersion != config.APIVersion {
+		return false
+	}
+	if len(config.IncludeTags) > 0 || len(config.IncludePaths) > 0 {
+		if !hasAnyTag(route.RouteTags, config.IncludeTags) && !hasAnyPrefix(route.Path, config.IncludePaths) {
+			return false
+		}
+	}
+	return !hasAnyTag(route.RouteTags, config.ExcludeTags) && !hasAnyPrefix(route.Path, config.ExcludePaths)
+}
+
+// hasAnyTag reports whether tags and filter share an entry
+func hasAnyTag(tags, filter []string) bool {
+	for _, tag := range tags {
+		for _, f := range filter {
+			if tag == f {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// hasAnyPrefix reports whether path starts with one of the prefixes
+func hasAnyPrefix(path string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(path, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
 // GenerateOpenAPI generates OpenAPI spec from routes
 func GenerateOpenAPI(routes []*poltergeist.Route, config *SwaggerConfig) *OpenAPI {
 	if config == nil {
@@ -166,26 +312,34 @@ func GenerateOpenAPI(routes []*poltergeist.Route, config *SwaggerConfig) *OpenAP
 	}
 
 	spec := &OpenAPI{
-		OpenAPI: "3.0.3",
-		Info: Info{
-			Title:       config.Title,
-			Description: config.Description,
-			Version:     config.Version,
-			Contact:     config.Contact,
-			License:     config.License,
-		},
-		Servers: config.Servers,
-		Paths:   make(map[string]PathItem),
+		OpenAPI:      "3.1.0",
+		Info:         config.info(),
+		Servers:      config.servers(),
+		Paths:        make(map[string]PathItem),
+		Security:     config.Security,
+		ExternalDocs: config.ExternalDocs,
 		Components: &Components{
 			Schemas:         make(map[string]*Schema),
 			SecuritySchemes: make(map[string]SecurityScheme),
 		},
 	}
 
+	for name, scheme := range config.SecuritySchemes {
+		spec.Components.SecuritySchemes[name] = scheme
+	}
+
+	// Reflect request/response types into component schemas
+	registry := NewSchemaRegistry()
+
+	errorBody := config.errorBody()
+
 	// Track tags
 	tagsMap := make(map[string]bool)
 
 	for _, route := range routes {
+		if !config.documents(route) {
+			continue
+		}
 		path := convertPathToOpenAPI(route.Path)
 
 		// Get or create path item
@@ -194,52 +348,7 @@ func GenerateOpenAPI(routes []*poltergeist.Route, config *SwaggerConfig) *OpenAP
 			pathItem = PathItem{}
 		}
 
-		// Create operation
-		operation := &Operation{
-			Tags:        route.RouteTags,
-			Summary:     route.RouteName,
-			Description: route.RouteDescription,
-			OperationID: generateOperationID(route.Method, route.Path),
-			Parameters:  extractParameters(route.Path),
-			Responses: map[string]Response{
-				"200": {Description: "Successful response"},
-				"400": {Description: "Bad request"},
-				"500": {Description: "Internal server error"},
-			},
-		}
-
-		// Add request body if present
-		if route.RequestBody != nil {
-			schema := typeToSchema(reflect.TypeOf(route.RequestBody))
-			schemaName := reflect.TypeOf(route.RequestBody).Name()
-			if schemaName != "" {
-				spec.Components.Schemas[schemaName] = schema
-				operation.RequestBody = &RequestBody{
-					Content: map[string]MediaType{
-						"application/json": {
-							Schema: &Schema{Ref: "#/components/schemas/" + schemaName},
-						},
-					},
-				}
-			}
-		}
-
-		// Add response body if present
-		if route.ResponseBody != nil {
-			schema := typeToSchema(reflect.TypeOf(route.ResponseBody))
-			schemaName := reflect.TypeOf(route.ResponseBody).Name()
-			if schemaName != "" {
-				spec.Components.Schemas[schemaName] = schema
-				operation.Responses["200"] = Response{
-					Description: "Successful response",
-					Content: map[string]MediaType{
-						"application/json": {
-							Schema: &Schema{Ref: "#/components/schemas/" + schemaName},
-						},
-					},
-				}
-			}
-		}
+		operation := buildOperation(route, registry, errorBody)
 
 		// Track tags
 		for _, tag := range route.RouteTags {
@@ -267,20 +376,212 @@ func GenerateOpenAPI(routes []*poltergeist.Route, config *SwaggerConfig) *OpenAP
 		spec.Paths[path] = pathItem
 	}
 
-	// Add tags to spec
-	for tag := range tagsMap {
-		spec.Tags = append(spec.Tags, Tag{Name: tag})
-	}
+	spec.Components.Schemas = registry.Schemas()
+
+	spec.Tags = config.tags(tagsMap)
 
 	return spec
 }
 
+// info builds the info object
+func (config *SwaggerConfig) info() Info {
+	return Info{
+		Title:          config.Title,
+		Summary:        config.Summary,
+		Description:    config.Description,
+		TermsOfService: config.TermsOfService,
+		Version:        config.Version,
+		Contact:        config.Contact,
+		License:        config.License,
+	}
+}
+
+// servers returns the documented servers for the selected environment
+func (config *SwaggerConfig) servers() []Server {
+	if len(config.Environments) == 0 {
+		return config.Servers
+	}
+
+	env := config.Environment
+	if env == "" {
+		env = os.Getenv("POLTERGEIST_ENV")
+	}
+	if servers, ok := config.Environments[env]; ok {
+		return servers
+	}
+
+	names := make([]string, 0, len(config.Environments))
+	for name := range config.Environments {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var servers []Server
+	for _, name := range names {
+		for _, server := range config.Environments[name] {
+			if server.Description == "" {
+				server.Description = name
+			} else {
+				server.Description = name + ": " + server.Description
+			}
+			servers = append(servers, server)
+		}
+	}
+	return servers
+}
+
+// tags returns the configured tags in order, followed by the other used tags
+func (config *SwaggerConfig) tags(used map[string]bool) []Tag {
+	tags := append([]Tag{}, config.Tags...)
+	listed := make(map[string]bool, len(tags))
+	for _, tag := range tags {
+		listed[tag.Name] = true
+	}
+
+	var rest []string
+	for name := range used {
+		if !listed[name] {
+			rest = append(rest, name)
+		}
+	}
+	sort.Strings(rest)
+	for _, name := range rest {
+		tags = append(tags, Tag{Name: name})
+	}
+	return tags
+}
+
+// buildOperation documents a single route
+func buildOperation(route *poltergeist.Route, registry *SchemaRegistry, errorBody any) *Operation {
+	operation := &Operation{
+		Tags:        route.RouteTags,
+		Summary:     route.RouteName,
+		Description: route.RouteDescription,
+		OperationID: generateOperationID(route.Method, route.Path),
+		Parameters:  routeParameters(route, registry),
+		Responses:   make(map[string]Response),
+	}
+
+	// Add security requirements if present
+	for _, requirement := range route.RouteSecurity {
+		operation.Security = append(operation.Security, SecurityReq(requirement))
+	}
+
+	// Deprecation notice
+	if d := route.RouteDeprecation; d != nil {
+		operation.Deprecated = true
+		notice := "**Deprecated.**"
+		if d.Message != "" {
+			notice += " " + d.Message
+		}
+		if !d.Sunset.IsZero() {
+			notice += " Removal on " + d.Sunset.Format("2006-01-02") + "."
+		}
+		operation.Description = strings.TrimSpace(notice + "\n\n" + operation.Description)
+	}
+
+	// Add request body if present
+	if route.RequestBody != nil {
+		operation.RequestBody = &RequestBody{
+			Required: true,
+			Content: map[string]MediaType{
+				"application/json": {
+					Schema:  registry.SchemaFor(route.RequestBody),
+					Example: route.RequestBodyExample,
+				},
+			},
+		}
+	}
+
+	addResponses(operation, route, registry, errorBody)
+	return operation
+}
+
+// errorBody returns the documented error envelope
+func (config *SwaggerConfig) errorBody() any {
+	if config.ErrorBody == nil {
+		return ErrorResponse{}
+	}
+	return config.ErrorBody
+}
+
+// addResponses documents the success response, declared per-status responses,
+// and default error envelopes for an operation
+func addResponses(operation *Operation, route *poltergeist.Route, registry *SchemaRegistry, errorBody any) {
+	hasSuccess := false
+	for _, resp := range route.RouteResponses {
+		if resp.Status >= 200 && resp.Status < 300 {
+			hasSuccess = true
+		}
+	}
+
+	// Success response from Response()/Example(), unless declared per status
+	if route.ResponseBody != nil || !hasSuccess {
+		success := Response{Description: "Successful response"}
+		if route.ResponseBody != nil {
+			success.Content = jsonContent(registry.SchemaFor(route.ResponseBody), route.ResponseBodyExample)
+		}
+		operation.Responses["200"] = success
+	}
+
+	// Explicit per-status responses
+	for i, resp := range route.RouteResponses {
+		description := resp.Description
+		if description == "" {
+			description = http.StatusText(resp.Status)
+		}
+		documented := Response{Description: description}
+		if resp.Body != nil {
+			var example any
+			if route.ResponseBody == nil && i == firstSuccess(route.RouteResponses) {
+				example = route.ResponseBodyExample
+			}
+			documented.Content = jsonContent(registry.SchemaFor(resp.Body), example)
+		}
+		operation.Responses[strconv.Itoa(resp.Status)] = documented
+	}
+
+	// Default error envelopes
+	defaults := map[string]string{
+		"400": "Bad request",
+		"500": "Internal server error",
+	}
+	if len(operation.Security) > 0 {
+		defaults["401"] = "Unauthorized"
+	}
+	for status, description := range defaults {
+		if _, exists := operation.Responses[status]; !exists {
+			operation.Responses[status] = Response{
+				Description: description,
+				Content:     jsonContent(registry.SchemaFor(errorBody), nil),
+			}
+		}
+	}
+}
+
+// firstSuccess returns the index of the first declared 2xx response
+func firstSuccess(responses []poltergeist.RouteResponse) int {
+	for i, resp := range responses {
+		if resp.Status >= 200 && resp.Status < 300 {
+			return i
+		}
+	}
+	return -1
+}
+
+// jsonContent builds an application/json content map
+func jsonContent(schema *Schema, example any) map[string]MediaType {
+	return map[string]MediaType{
+		"application/json": {Schema: schema, Example: example},
+	}
+}
+
 // convertPathToOpenAPI converts route path to OpenAPI format
 func convertPathToOpenAPI(path string) string {
 	parts := strings.Split(path, "/")
 	for i, part := range parts {
 		if strings.HasPrefix(part, ":") {
-			parts[i] = "{" + strings.TrimPrefix(part, ":") + "}"
+			parts[i] = "{" + strings.TrimSuffix(strings.TrimPrefix(part, ":"), "?") + "}"
 		}
 		if strings.HasPrefix(part, "*") {
 			parts[i] = "{" + strings.TrimPrefix(part, "*") + "}"
@@ -301,6 +602,7 @@ func generateOperationID(method, path string) string {
 		}
 		part = strings.TrimPrefix(part, ":")
 		part = strings.TrimPrefix(part, "*")
+		part = strings.TrimSuffix(part, "?")
 		if len(part) > 0 {
 			result.WriteString(strings.ToUpper(part[:1]) + part[1:])
 		}
@@ -315,7 +617,7 @@ func extractParameters(path string) []Parameter {
 	for _, part := range parts {
 		if strings.HasPrefix(part, ":") {
 			params = append(params, Parameter{
-				Name:     strings.TrimPrefix(part, ":"),
+				Name:     strings.TrimSuffix(strings.TrimPrefix(part, ":"), "?"),
 				In:       "path",
 				Required: true,
 				Schema:   &Schema{Type: "string"},
@@ -325,132 +627,6 @@ func extractParameters(path string) []Parameter {
 	return params
 }
 
-// typeToSchema converts a Go type to OpenAPI schema
-func typeToSchema(t reflect.Type) *Schema {
-	if t == nil {
-		return &Schema{Type: "object"}
-	}
-
-	// Handle pointers
-	if t.Kind() == reflect.Ptr {
-		t = t.Elem()
-	}
-
-	switch t.Kind() {
-	case reflect.String:
-		return &Schema{Type: "string"}
-	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
-		return &Schema{Type: "integer"}
-	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
-		return &Schema{Type: "integer"}
-	case reflect.Float32, reflect.Float64:
-		return &Schema{Type: "number"}
-	case reflect.Bool:
-		return &Schema{Type: "boolean"}
-	case reflect.Slice, reflect.Array:
-		return &Schema{
-			Type:  "array",
-			Items: typeToSchema(t.Elem()),
-		}
-	case reflect.Struct:
-		props := make(map[string]*Schema)
-		var required []string
-		for i := 0; i < t.NumField(); i++ {
-			field := t.Field(i)
-			jsonTag := field.Tag.Get("json")
-			if jsonTag == "-" {
-				continue
-			}
-			name := strings.Split(jsonTag, ",")[0]
-			if name == "" {
-				name = field.Name
-			}
-			props[name] = typeToSchema(field.Type)
-
-			// Check if required
-			if !strings.Contains(jsonTag, "omitempty") {
-				required = append(required, name)
-			}
-		}
-		return &Schema{
-			Type:       "object",
-			Properties: props,
-			Required:   required,
-		}
-	case reflect.Map:
-		return &Schema{Type: "object"}
-	default:
-		return &Schema{Type: "object"}
-	}
-}
-
-// Swagger returns handlers for Swagger UI
-func Swagger(server *poltergeist.Server, config *SwaggerConfig) {
-	if config == nil {
-		config = DefaultSwaggerConfig()
-	}
-
-	// Serve OpenAPI JSON spec
-	server.GET("/swagger/doc.json", func(c *poltergeist.Context) error {
-		spec := GenerateOpenAPI(server.Routes(), config)
-		return c.JSON(http.StatusOK, spec)
-	})
-
-	// Serve Swagger UI
-	server.GET("/swagger", func(c *poltergeist.Context) error {
-		return c.HTML(http.StatusOK, swaggerUIHTML(config.Title))
-	})
-
-	server.GET("/swagger/", func(c *poltergeist.Context) error {
-		return c.HTML(http.StatusOK, swaggerUIHTML(config.Title))
-	})
-}
-
-// swaggerUIHTML returns Swagger UI HTML
-func swaggerUIHTML(title string) string {
-	return fmt.Sprintf(`<!DOCTYPE html>
-<html lang="en">
-<head>
-    <meta charset="UTF-8">
-    <meta name="viewport" content="width=device-width, initial-scale=1.0">
-    <title>%s - Swagger UI</title>
-    <link rel="stylesheet" type="text/css" href="https://cdn.jsdelivr.net/npm/swagger-ui-dist@5/swagger-ui.css">
-    <style>
-        html { box-sizing: border-box; overflow-y: scroll; }
-        *, *:before, *:after { box-sizing: inherit; }
-        body { margin: 0; background: #fafafa; }
-        .swagger-ui .topbar { display: none; }
-        .swagger-ui .info { margin: 20px 0; }
-        .swagger-ui .info .title { font-family: -apple-system, BlinkMacSystemFont, 'Segoe UI', Roboto, sans-serif; }
-    </style>
-</head>
-<body>
-    <div id="swagger-ui"></div>
-    <script src="https://cdn.jsdelivr.net/npm/swagger-ui-dist@5/swagger-ui-bundle.js"></script>
-    <script src="https://cdn.jsdelivr.net/npm/swagger-ui-dist@5/swagger-ui-standalone-preset.js"></script>
-    <script>
-        window.onload = function() {
-            window.ui = SwaggerUIBundle({
-                url: "/swagger/doc.json",
-                dom_id: '#swagger-ui',
-                deepLinking: true,
-                presets: [
-                    SwaggerUIBundle.presets.apis,
-                    SwaggerUIStandalonePreset
-                ],
-                plugins: [
-                    SwaggerUIBundle.plugins.DownloadUrl
-                ],
-                layout: "StandaloneLayout",
-                validatorUrl: null,
-                supportedSubmitMethods: ['get', 'post', 'put', 'delete', 'patch', 'options', 'head']
-            });
-        };
-    </script>
-</body>
-</html>`, title)
-}
-
 // ExportJSON exports OpenAPI spec to JSON
 func ExportJSON(routes []*poltergeist.Route, config *SwaggerConfig) ([]byte, error) {
 	spec := GenerateOpenAPI(routes, config)
@@ -0,0 +1,211 @@
+package docs
+
+import (
+	"fmt"
+	"html"
+	"net/http"
+	"strings"
+
+	"github.com/gofuckbiz/poltergeist"
+)
+
+// =============================================================================
+// DOCUMENTATION UI - Swagger UI, ReDoc and Scalar
+// =============================================================================
+
+// UIKind selects a documentation UI.
+//
+// In offline mode (SwaggerConfig.Assets set) each UI loads its files from the
+// assets filesystem instead of the CDN:
+//
+//	UISwagger: swagger-ui.css, swagger-ui-bundle.js, swagger-ui-standalone-preset.js
+//	UIReDoc:   redoc.standalone.js
+//	UIScalar:  scalar.js
+type UIKind string
+
+// Supported documentation UIs
+const (
+	UISwagger UIKind = "swagger"
+	UIReDoc   UIKind = "redoc"
+	UIScalar  UIKind = "scalar"
+)
+
+// CDN locations used when no offline assets are configured
+const (
+	swaggerCDN = "https://cdn.jsdelivr.net/npm/swagger-ui-dist@5"
+	redocCDN   = "https://cdn.jsdelivr.net/npm/redoc@2/bundles"
+	scalarCDN  = "https://cdn.jsdelivr.net/npm/@scalar/api-reference/dist/browser"
+)
+
+// Swagger returns handlers for Swagger UI
+func Swagger(server *poltergeist.Server, config *SwaggerConfig) {
+	if config == nil {
+		config = DefaultSwaggerConfig()
+	}
+
+	// Allow dumping the spec instead of serving (CI / go generate)
+	EnableSpecDump(server, config)
+	mountDocs(server, config)
+}
+
+// mountDocs serves the spec and the configured UI at config.Path
+func mountDocs(server *poltergeist.Server, config *SwaggerConfig) {
+	path := uiPath(config.Path)
+	specURL := path + "/doc.json"
+
+	// Serve OpenAPI JSON spec
+	server.GET(specURL, func(c *poltergeist.Context) error {
+		spec := GenerateOpenAPI(server.Routes(), config)
+		return c.JSON(http.StatusOK, spec)
+	}).Hidden()
+
+	ServeUI(server, config.UI, path, specURL, config)
+	mountAsyncAPI(server, config, path)
+}
+
+// ServeUI mounts a documentation UI at path, reading the spec from specURL.
+// Call it several times to offer more than one UI, e.g.
+//
+//	docs.Swagger(server, cfg)                                         // /swagger
+//	docs.ServeUI(server, docs.UIReDoc, "/redoc", "/swagger/doc.json", cfg)
+func ServeUI(server *poltergeist.Server, kind UIKind, path, specURL string, config *SwaggerConfig) {
+	if config == nil {
+		config = DefaultSwaggerConfig()
+	}
+	path = uiPath(path)
+
+	// Offline mode: serve scripts and styles from the configured filesystem
+	assetBase := ""
+	if config.Assets != nil {
+		assetBase = path + "/assets"
+		fileServer := http.FileServer(http.FS(config.Assets))
+		server.GET(assetBase+"/*filepath", func(c *poltergeist.Context) error {
+			req := c.Request.Clone(c.Request.Context())
+			req.URL.Path = "/" + strings.TrimPrefix(c.Param("filepath"), "/")
+			fileServer.ServeHTTP(c.Writer, req)
+			return nil
+		}).Hidden()
+	}
+
+	page := uiHTML(kind, config.Title, specURL, assetBase)
+	handler := func(c *poltergeist.Context) error {
+		return c.HTML(http.StatusOK, page)
+	}
+	server.GET(path, handler).Hidden()
+	server.GET(path+"/", handler).Hidden()
+}
+
+// uiPath normalizes a mount path, defaulting to /swagger
+func uiPath(path string) string {
+	if path == "" {
+		return "/swagger"
+	}
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	return strings.TrimSuffix(path, "/")
+}
+
+// uiHTML renders the page for a UI; an empty assetBase loads from the CDN
+func uiHTML(kind UIKind, title, specURL, assetBase string) string {
+	title = html.EscapeString(title)
+	switch kind {
+	case UIReDoc:
+		return redocHTML(title, specURL, assetURL(assetBase, "redoc.standalone.js", redocCDN+"/redoc.standalone.js"))
+	case UIScalar:
+		return scalarHTML(title, specURL, assetURL(assetBase, "scalar.js", scalarCDN+"/standalone.js"))
+	default:
+		return swaggerUIHTML(title, specURL, func(name string) string {
+			return assetURL(assetBase, name, swaggerCDN+"/"+name)
+		})
+	}
+}
+
+// assetURL resolves an asset against the offline base, falling back to the CDN
+func assetURL(assetBase, name, cdnURL string) string {
+	if assetBase != "" {
+		return assetBase + "/" + name
+	}
+	return cdnURL
+}
+
+// swaggerUIHTML returns Swagger UI HTML
+func swaggerUIHTML(title, specURL string, asset func(name string) string) string {
+	return fmt.Sprintf(`<!DOCTYPE html>
+<html lang="en">
+<head>
+    <meta charset="UTF-8">
+    <meta name="viewport" content="width=device-width, initial-scale=1.0">
+    <title>%s - Swagger UI</title>
+    <link rel="stylesheet" type="text/css" href="%s">
+    <style>
+        html { box-sizing: border-box; overflow-y: scroll; }
+        *, *:before, *:after { box-sizing: inherit; }
+        body { margin: 0; background: #fafafa; }
+        .swagger-ui .topbar { display: none; }
+        .swagger-ui .info { margin: 20px 0; }
+        .swagger-ui .info .title { font-family: -apple-system, BlinkMacSystemFont, 'Segoe UI', Roboto, sans-serif; }
+    </style>
+</head>
+<body>
+    <div id="swagger-ui"></div>
+    <script src="%s"></script>
+    <script src="%s"></script>
+    <script>
+        window.onload = function() {
+            window.ui = SwaggerUIBundle({
+                url: %q,
+                dom_id: '#swagger-ui',
+                deepLinking: true,
+                presets: [
+                    SwaggerUIBundle.presets.apis,
+                    SwaggerUIStandalonePreset
+                ],
+                plugins: [
+                    SwaggerUIBundle.plugins.DownloadUrl
+                ],
+                layout: "StandaloneLayout",
+                validatorUrl: null,
+                persistAuthorization: true,
+                supportedSubmitMethods: ['get', 'post', 'put', 'delete', 'patch', 'options', 'head']
+            });
+        };
+    </script>
+</body>
+</html>`, title, asset("swagger-ui.css"), asset("swagger-ui-bundle.js"), asset("swagger-ui-standalone-preset.js"), specURL)
+}
+
+// redocHTML returns ReDoc HTML
+func redocHTML(title, specURL, script string) string {
+	return fmt.Sprintf(`<!DOCTYPE html>
+<html lang="en">
+<head>
+    <meta charset="UTF-8">
+    <meta name="viewport" content="width=device-width, initial-scale=1.0">
+    <title>%s - ReDoc</title>
+    <style>
+        body { margin: 0; padding: 0; }
+    </style>
+</head>
+<body>
+    <redoc spec-url="%s"></redoc>
+    <script src="%s"></script>
+</body>
+</html>`, title, html.EscapeString(specURL), script)
+}
+
+// scalarHTML returns Scalar API reference HTML
+func scalarHTML(title, specURL, script string) string {
+	return fmt.Sprintf(`<!DOCTYPE html>
+<html lang="en">
+<head>
+    <meta charset="UTF-8">
+    <meta name="viewport" content="width=device-width, initial-scale=1.0">
+    <title>%s - API Reference</title>
+</head>
+<body>
+    <script id="api-reference" data-url="%s"></script>
+    <script src="%s"></script>
+</body>
+</html>`, title, html.EscapeString(specURL), script)
+}
@@ -0,0 +1,541 @@
+package docs
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gofuckbiz/poltergeist"
+)
+
+// =============================================================================
+// CONTRACT VALIDATION - Check traffic against the generated spec
+// =============================================================================
+
+// ValidatorConfig configures contract validation
+type ValidatorConfig struct {
+	// Swagger is the docs configuration the contract is generated from
+	Swagger *SwaggerConfig
+	// ValidateResponses checks handler responses too (always on in DevMode)
+	ValidateResponses bool
+	// OnResponseError is called when a response drifted from the contract and
+	// the response is sent unchanged. Without it the violations are logged and
+	// the response is replaced with a 500.
+	OnResponseError func(c *poltergeist.Context, violations []string)
+}
+
+// DefaultValidatorConfig returns default validation configuration
+func DefaultValidatorConfig() *ValidatorConfig {
+	return &ValidatorConfig{
+		Swagger: DefaultSwaggerConfig(),
+	}
+}
+
+// contractValidator validates requests and responses of documented routes
+type contractValidator struct {
+	config     *SwaggerConfig
+	mu         sync.Mutex
+	registry   *SchemaRegistry
+	operations map[*poltergeist.Route]*Operation
+	patterns   map[string]*regexp.Regexp
+}
+
+// Validator returns middleware that rejects requests whose parameters or body
+// do not match the documented schemas with 400. With ValidateResponses (or in
+// DevMode) responses are checked as well, so handlers drifting from the
+// documented contract fail loudly instead of silently.
+//
+//	app.Use(docs.Validator(app))
+func Validator(server *poltergeist.Server, config ...*ValidatorConfig) poltergeist.MiddlewareFunc {
+	cfg := DefaultValidatorConfig()
+	if len(config) > 0 && config[0] != nil {
+		cfg = config[0]
+	}
+	swagger := cfg.Swagger
+	if swagger == nil {
+		swagger = DefaultSwaggerConfig()
+	}
+	validateResponses := cfg.ValidateResponses || server.Config().DevMode
+
+	v := &contractValidator{
+		config:     swagger,
+		registry:   NewSchemaRegistry(),
+		operations: make(map[*poltergeist.Route]*Operation),
+		patterns:   make(map[string]*regexp.Regexp),
+	}
+
+	return func(next poltergeist.HandlerFunc) poltergeist.HandlerFunc {
+		return func(c *poltergeist.Context) error {
+			route := c.Route()
+			if route == nil || !swagger.documents(route) {
+				return next(c)
+			}
+			operation := v.operation(route)
+
+			violations, err := v.validateRequest(c, operation)
+			if err != nil {
+				return err
+			}
+			if len(violations) > 0 {
+				return c.JSON(http.StatusBadRequest, poltergeist.H{
+					"error":   "request does not match the API contract",
+					"details": violations,
+				})
+			}
+
+			if !validateResponses || isStreaming(c.Request) {
+				return next(c)
+			}
+			return v.checkResponse(c, next, route, operation, cfg.OnResponseError)
+		}
+	}
+}
+
+// operation returns the cached documentation of a route
+func (v *contractValidator) operation(route *poltergeist.Route) *Operation {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if op, ok := v.operations[route]; ok {
+		return op
+	}
+	op := buildOperation(route, v.registry, v.config.errorBody())
+	v.operations[route] = op
+	return op
+}
+
+// --- Requests ---
+
+// validateRequest checks parameters and the JSON body of a request; the
+// error is for bodies that can't be read, such as oversized ones
+func (v *contractValidator) validateRequest(c *poltergeist.Context, operation *Operation) ([]string, error) {
+	var violations []string
+
+	for _, param := range operation.Parameters {
+		raw, present := paramValue(c, param)
+		if !present {
+			if param.Required {
+				violations = append(violations, fmt.Sprintf("%s parameter %q is required", param.In, param.Name))
+			}
+			continue
+		}
+		v.validate(param.Schema, coerceParam(raw, param.Schema), param.In+"."+param.Name, &violations)
+	}
+
+	if operation.RequestBody == nil {
+		return violations, nil
+	}
+	media, ok := operation.RequestBody.Content["application/json"]
+	if !ok || c.Request.Body == nil {
+		return violations, nil
+	}
+
+	body, err := c.Body() // bounded, and rewound for the handler's Bind
+	if err != nil {
+		return nil, err
+	}
+	if len(bytes.TrimSpace(body)) == 0 {
+		if operation.RequestBody.Required {
+			violations = append(violations, "request body is required")
+		}
+		return violations, nil
+	}
+	value, err := decodeJSON(body)
+	if err != nil {
+		return append(violations, "request body is not valid JSON"), nil
+	}
+	v.validate(media.Schema, value, "body", &violations)
+	return violations, nil
+}
+
+// paramValue reads a parameter from the request
+func paramValue(c *poltergeist.Context, param Parameter) ([]string, bool) {
+	var values []string
+	switch param.In {
+	case "path":
+		if value := c.Param(param.Name); value != "" {
+			values = []string{value}
+		}
+	case "query":
+		values = c.Request.URL.Query()[param.Name]
+	case "header":
+		values = c.Request.Header.Values(param.Name)
+	}
+	return values, len(values) > 0 && values[0] != ""
+}
+
+// coerceParam converts raw parameter strings into JSON-like values for the schema
+func coerceParam(raw []string, schema *Schema) any {
+	if schema != nil && schema.Type == "array" {
+		var items []string
+		for _, value := range raw {
+			items = append(items, strings.Split(value, ",")...)
+		}
+		values := make([]any, len(items))
+		for i, item := range items {
+			values[i] = coerceScalar(item, schema.Items)
+		}
+		return values
+	}
+	return coerceScalar(raw[0], schema)
+}
+
+// coerceScalar converts a single parameter string, leaving it unchanged on mismatch
+func coerceScalar(raw string, schema *Schema) any {
+	if schema == nil {
+		return raw
+	}
+	switch schema.Type {
+	case "integer", "number":
+		if _, err := strconv.ParseFloat(raw, 64); err == nil {
+			return json.Number(raw)
+		}
+	case "boolean":
+		if b, err := strconv.ParseBool(raw); err == nil {
+			return b
+		}
+	}
+	return raw
+}
+
+// --- Responses ---
+
+// responseRecorder buffers a response so it can be checked before sending.
+// A flushed or hijacked response goes straight to the client unchecked.
+type responseRecorder struct {
+	http.ResponseWriter
+	status      int
+	body        bytes.Buffer
+	passthrough bool
+}
+
+func (r *responseRecorder) WriteHeader(code int) {
+	if r.passthrough {
+		r.ResponseWriter.WriteHeader(code)
+		return
+	}
+	if r.status == 0 {
+		r.status = code
+	}
+}
+
+func (r *responseRecorder) Write(data []byte) (int, error) {
+	if r.passthrough {
+		return r.ResponseWriter.Write(data)
+	}
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+	return r.body.Write(data)
+}
+
+// Flush sends what was buffered and streams the rest unchecked
+func (r *responseRecorder) Flush() {
+	if !r.passthrough {
+		r.passthrough = true
+		if r.status != 0 {
+			r.ResponseWriter.WriteHeader(r.status)
+			r.ResponseWriter.Write(r.body.Bytes())
+		}
+	}
+	if flusher, ok := r.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Hijack passes through to the underlying connection
+func (r *responseRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := r.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("docs: response writer does not support hijacking")
+	}
+	r.passthrough = true
+	return hijacker.Hijack()
+}
+
+// Unwrap exposes the underlying writer to http.ResponseController
+func (r *responseRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+// checkResponse runs the handler against a recorder and validates its output
+func (v *contractValidator) checkResponse(c *poltergeist.Context, next poltergeist.HandlerFunc, route *poltergeist.Route, operation *Operation, onError func(*poltergeist.Context, []string)) error {
+	original := c.Writer
+	recorder := &responseRecorder{ResponseWriter: original}
+	c.Writer = recorder
+	err := next(c)
+	c.Writer = original
+
+	if recorder.passthrough {
+		return err // already sent
+	}
+	if recorder.status == 0 {
+		return err // nothing written; the router handles the error
+	}
+
+	violations := v.validateResponse(route, operation, recorder)
+	if len(violations) > 0 {
+		if onError != nil {
+			onError(c, violations)
+		} else {
+			c.Logger().Warn("contract violation", "method", route.Method, "route", route.Path, "violations", strings.Join(violations, "; "))
+			original.Header().Del("Content-Length")
+			original.Header().Set("Content-Type", poltergeist.ContentTypeJSON)
+			original.WriteHeader(http.StatusInternalServerError)
+			return json.NewEncoder(original).Encode(poltergeist.H{
+				"error":   "response does not match the API contract",
+				"details": violations,
+			})
+		}
+	}
+
+	original.WriteHeader(recorder.status)
+	if _, writeErr := original.Write(recorder.body.Bytes()); writeErr != nil && err == nil {
+		err = writeErr
+	}
+	return err
+}
+
+// validateResponse checks a recorded response against the documented responses
+func (v *contractValidator) validateResponse(route *poltergeist.Route, operation *Operation, recorder *responseRecorder) []string {
+	documented, ok := operation.Responses[strconv.Itoa(recorder.status)]
+	if !ok {
+		// Undeclared error statuses are common (NotFound, Forbidden, ...);
+		// undeclared success statuses mean the contract is out of date
+		declared := route.ResponseBody != nil || len(route.RouteResponses) > 0
+		if declared && recorder.status < 400 {
+			return []string{fmt.Sprintf("status %d is not documented", recorder.status)}
+		}
+		return nil
+	}
+
+	media, ok := documented.Content["application/json"]
+	if !ok || media.Schema == nil {
+		return nil
+	}
+	if contentType := recorder.Header().Get("Content-Type"); !strings.Contains(contentType, "json") {
+		return []string{fmt.Sprintf("status %d should be JSON, got %q", recorder.status, contentType)}
+	}
+
+	value, err := decodeJSON(recorder.body.Bytes())
+	if err != nil {
+		return []string{"response body is not valid JSON"}
+	}
+	var violations []string
+	v.validate(media.Schema, value, "response", &violations)
+	return violations
+}
+
+// isStreaming reports whether a request upgrades to WebSocket or SSE
+func isStreaming(r *http.Request) bool {
+	return r.Header.Get("Upgrade") != "" || strings.Contains(r.Header.Get("Accept"), "text/event-stream")
+}
+
+// --- Schemas ---
+
+// decodeJSON decodes JSON keeping numbers exact
+func decodeJSON(data []byte) (any, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var value any
+	if err := dec.Decode(&value); err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+// validate checks a decoded JSON value against a schema, collecting violations
+func (v *contractValidator) validate(schema *Schema, value any, path string, violations *[]string) {
+	if schema == nil {
+		return
+	}
+	nullable := schema.Nullable
+	v.mu.Lock()
+	schema = v.registry.Resolve(schema)
+	v.mu.Unlock()
+
+	if value == nil {
+		if !nullable && !schema.Nullable && schema.Type != "" {
+			*violations = append(*violations, path+" must not be null")
+		}
+		return
+	}
+
+	if len(schema.AnyOf) > 0 {
+		for _, option := range schema.AnyOf {
+			var optionViolations []string
+			v.validate(option, value, path, &optionViolations)
+			if len(optionViolations) == 0 {
+				return
+			}
+		}
+		*violations = append(*violations, path+" does not match any allowed schema")
+		return
+	}
+
+	if msg := checkType(schema, value); msg != "" {
+		*violations = append(*violations, fmt.Sprintf("%s %s", path, msg))
+		return
+	}
+
+	if len(schema.Enum) > 0 && !inEnum(schema.Enum, value) {
+		*violations = append(*violations, fmt.Sprintf("%s must be one of %v", path, schema.Enum))
+	}
+
+	switch value := value.(type) {
+	case string:
+		v.validateString(schema, value, path, violations)
+	case json.Number:
+		validateNumber(schema, value, path, violations)
+	case []any:
+		for i, item := range value {
+			v.validate(schema.Items, item, fmt.Sprintf("%s[%d]", path, i), violations)
+		}
+	case map[string]any:
+		for _, name := range schema.Required {
+			if _, ok := value[name]; !ok {
+				*violations = append(*violations, fmt.Sprintf("%s.%s is required", path, name))
+			}
+		}
+		names := make([]string, 0, len(value))
+		for name := range value {
+			names = append(names, name)
+		}
+		sort.Strings(names) // stable violation order
+		for _, name := range names {
+			item := value[name]
+			if prop, ok := schema.Properties[name]; ok {
+				v.validate(prop, item, path+"."+name, violations)
+			} else if schema.AdditionalProperties != nil {
+				v.validate(schema.AdditionalProperties, item, path+"."+name, violations)
+			}
+		}
+	}
+}
+
+// checkType returns a violation message when value does not have the schema type
+func checkType(schema *Schema, value any) string {
+	ok := true
+	switch schema.Type {
+	case "string":
+		_, ok = value.(string)
+	case "integer":
+		n, isNumber := value.(json.Number)
+		ok = isNumber && isInteger(n)
+	case "number":
+		_, ok = value.(json.Number)
+	case "boolean":
+		_, ok = value.(bool)
+	case "array":
+		_, ok = value.([]any)
+	case "object":
+		_, ok = value.(map[string]any)
+	}
+	if !ok {
+		return "must be " + article(schema.Type)
+	}
+	return ""
+}
+
+// validateString checks string length, pattern and well-known formats
+func (v *contractValidator) validateString(schema *Schema, value, path string, violations *[]string) {
+	length := len([]rune(value))
+	if schema.MinLength != nil && length < *schema.MinLength {
+		*violations = append(*violations, fmt.Sprintf("%s must be at least %d characters", path, *schema.MinLength))
+	}
+	if schema.MaxLength != nil && length > *schema.MaxLength {
+		*violations = append(*violations, fmt.Sprintf("%s must be at most %d characters", path, *schema.MaxLength))
+	}
+	if schema.Pattern != "" {
+		if re := v.pattern(schema.Pattern); re != nil && !re.MatchString(value) {
+			*violations = append(*violations, fmt.Sprintf("%s must match %s", path, schema.Pattern))
+		}
+	}
+	if !validFormat(schema.Format, value) {
+		*violations = append(*violations, fmt.Sprintf("%s must be a valid %s", path, schema.Format))
+	}
+}
+
+// pattern compiles and caches a schema pattern (nil if invalid)
+func (v *contractValidator) pattern(expr string) *regexp.Regexp {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	re, ok := v.patterns[expr]
+	if !ok {
+		re, _ = regexp.Compile(expr)
+		v.patterns[expr] = re
+	}
+	return re
+}
+
+// validateNumber checks numeric bounds
+func validateNumber(schema *Schema, value json.Number, path string, violations *[]string) {
+	f, err := value.Float64()
+	if err != nil {
+		return
+	}
+	if schema.Minimum != nil && f < *schema.Minimum {
+		*violations = append(*violations, fmt.Sprintf("%s must be >= %v", path, *schema.Minimum))
+	}
+	if schema.Maximum != nil && f > *schema.Maximum {
+		*violations = append(*violations, fmt.Sprintf("%s must be <= %v", path, *schema.Maximum))
+	}
+}
+
+var uuidPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// validFormat checks the formats the schema reflection emits
+func validFormat(format, value string) bool {
+	switch format {
+	case "date-time":
+		_, err := time.Parse(time.RFC3339, value)
+		return err == nil
+	case "date":
+		_, err := time.Parse("2006-01-02", value)
+		return err == nil
+	case "uuid":
+		return uuidPattern.MatchString(value)
+	case "email":
+		at := strings.LastIndex(value, "@")
+		return at > 0 && at < len(value)-1
+	}
+	return true
+}
+
+// isInteger reports whether a JSON number has no fractional part
+func isInteger(n json.Number) bool {
+	if _, err := n.Int64(); err == nil {
+		return true
+	}
+	f, err := n.Float64()
+	return err == nil && f == math.Trunc(f)
+}
+
+// inEnum reports whether value equals one of the allowed values
+func inEnum(enum []any, value any) bool {
+	for _, allowed := range enum {
+		if fmt.Sprint(allowed) == fmt.Sprint(value) {
+			return true
+		}
+	}
+	return false
+}
+
+// article prefixes a schema type with "a" or "an"
+func article(schemaType string) string {
+	switch schemaType {
+	case "integer", "array", "object":
+		return "an " + schemaType
+	}
+	return "a " + schemaType
+}
@@ -0,0 +1,158 @@
+package docs
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofuckbiz/poltergeist"
+)
+
+// =============================================================================
+// CONTRACT VALIDATION TESTS
+// =============================================================================
+
+type validateUser struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+type validateCreate struct {
+	Name string `json:"name"`
+	Role string `json:"role" enum:"admin,member"`
+}
+
+type validateList struct {
+	Limit int `query:"limit" validate:"required"`
+}
+
+// validatorApp returns an app with documented routes behind the validator
+func validatorApp(config *poltergeist.Config, validator *ValidatorConfig) *poltergeist.Server {
+	app := poltergeist.NewWithConfig(config)
+	app.Use(Validator(app, validator))
+	app.GET("/users", func(c *poltergeist.Context) error {
+		return c.JSON(http.StatusOK, []validateUser{{ID: 1, Name: "ada"}})
+	}).Query(validateList{}).Response([]validateUser{})
+	app.POST("/users", func(c *poltergeist.Context) error {
+		var req validateCreate
+		if err := c.Bind(&req); err != nil {
+			return err
+		}
+		return c.JSON(http.StatusOK, validateUser{ID: 2, Name: req.Name})
+	}).Request(validateCreate{}).Response(validateUser{})
+	app.GET("/drift", func(c *poltergeist.Context) error {
+		return c.JSON(http.StatusOK, poltergeist.H{"id": "not a number", "name": "ada"})
+	}).Response(validateUser{})
+	return app
+}
+
+func TestValidator_Requests(t *testing.T) {
+	app := validatorApp(&poltergeist.Config{MaxBodyBuffer: 64}, nil)
+
+	tests := []struct {
+		name, method, target, body string
+		want                       int
+	}{
+		{"valid query", http.MethodGet, "/users?limit=10", "", http.StatusOK},
+		{"missing query", http.MethodGet, "/users", "", http.StatusBadRequest},
+		{"mistyped query", http.MethodGet, "/users?limit=ten", "", http.StatusBadRequest},
+		{"valid body", http.MethodPost, "/users", `{"name":"ada","role":"admin"}`, http.StatusOK},
+		{"mistyped body", http.MethodPost, "/users", `{"name":1,"role":"admin"}`, http.StatusBadRequest},
+		{"enum violation", http.MethodPost, "/users", `{"name":"ada","role":"owner"}`, http.StatusBadRequest},
+		{"missing field", http.MethodPost, "/users", `{"role":"admin"}`, http.StatusBadRequest},
+		{"invalid JSON", http.MethodPost, "/users", `{"name":`, http.StatusBadRequest},
+		{"oversized body", http.MethodPost, "/users", `{"name":"` + strings.Repeat("a", 100) + `","role":"admin"}`, http.StatusRequestEntityTooLarge},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.target, strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", poltergeist.ContentTypeJSON)
+			w := httptest.NewRecorder()
+			app.ServeHTTP(w, req)
+			if w.Code != tt.want {
+				t.Errorf("status = %d, want %d: %s", w.Code, tt.want, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestValidator_BodyStillBindable(t *testing.T) {
+	app := validatorApp(nil, nil)
+	req := httptest.NewRequest(http.MethodPost, "/users", strings.NewReader(`{"name":"ada","role":"member"}`))
+	req.Header.Set("Content-Type", poltergeist.ContentTypeJSON)
+	w := httptest.NewRecorder()
+	app.ServeHTTP(w, req)
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"name":"ada"`) {
+		t.Errorf("response = %d %s, want the handler to bind the validated body", w.Code, w.Body.String())
+	}
+}
+
+func TestValidator_Responses(t *testing.T) {
+	app := validatorApp(nil, &ValidatorConfig{ValidateResponses: true})
+	w := httptest.NewRecorder()
+	app.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/drift", nil))
+	if w.Code != http.StatusInternalServerError || !strings.Contains(w.Body.String(), "response does not match the API contract") {
+		t.Errorf("drifting response = %d %s, want 500", w.Code, w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	app.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/users?limit=1", nil))
+	if w.Code != http.StatusOK {
+		t.Errorf("matching response = %d %s, want 200", w.Code, w.Body.String())
+	}
+
+	var reported []string
+	app = validatorApp(nil, &ValidatorConfig{
+		ValidateResponses: true,
+		OnResponseError:   func(c *poltergeist.Context, violations []string) { reported = violations },
+	})
+	w = httptest.NewRecorder()
+	app.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/drift", nil))
+	if w.Code != http.StatusOK || len(reported) == 0 || !strings.Contains(reported[0], "id") {
+		t.Errorf("with OnResponseError: %d, violations %v; want the response unchanged and id reported", w.Code, reported)
+	}
+}
+
+func TestValidator_FlushAndHijack(t *testing.T) {
+	app := poltergeist.New()
+	app.Use(Validator(app, &ValidatorConfig{ValidateResponses: true}))
+	app.GET("/flush", func(c *poltergeist.Context) error {
+		flusher, ok := c.Writer.(http.Flusher)
+		if !ok {
+			return c.String(http.StatusInternalServerError, "no flusher")
+		}
+		c.Writer.Write([]byte("first,"))
+		flusher.Flush()
+		c.Writer.Write([]byte("second"))
+		return nil
+	}).Response("")
+	app.GET("/hijack", func(c *poltergeist.Context) error {
+		hijacker, ok := c.Writer.(http.Hijacker)
+		if !ok {
+			return c.String(http.StatusInternalServerError, "no hijacker")
+		}
+		conn, rw, err := hijacker.Hijack()
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+		rw.WriteString("HTTP/1.1 200 OK\r\nContent-Length: 8\r\nConnection: close\r\n\r\nhijacked")
+		return rw.Flush()
+	}).Response("")
+	server := httptest.NewServer(app)
+	defer server.Close()
+
+	for path, want := range map[string]string{"/flush": "first,second", "/hijack": "hijacked"} {
+		resp, err := http.Get(server.URL + path)
+		if err != nil {
+			t.Fatalf("%s: %v", path, err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK || string(body) != want {
+			t.Errorf("%s = %d %q, want 200 %q", path, resp.StatusCode, body, want)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package docs
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/gofuckbiz/poltergeist"
+)
+
+// =============================================================================
+// VERSIONED DOCS - One spec per API version
+// =============================================================================
+
+// SwaggerVersions serves a separate spec and UI per API version, for APIs in
+// the middle of a migration. Each config selects its routes with APIVersion
+// (routes of a Version() group) or IncludePaths (any route group), and
+// carries its own title and description. Path defaults to /swagger/<version>.
+//
+//	v1 := app.Version("v1")
+//	v2 := app.Version("v2")
+//	docs.SwaggerVersions(app,
+//	    &docs.SwaggerConfig{APIVersion: "v1", Title: "Shop API (deprecated)"},
+//	    &docs.SwaggerConfig{APIVersion: "v2", Title: "Shop API"},
+//	)
+//
+// In spec dump mode every version is written next to the requested file,
+// e.g. -openapi-out=openapi.yaml writes openapi.v1.yaml and openapi.v2.yaml.
+func SwaggerVersions(server *poltergeist.Server, configs ...*SwaggerConfig) {
+	versions := make([]*SwaggerConfig, 0, len(configs))
+	for i, config := range configs {
+		cfg := withVersionDefaults(config, i)
+		versions = append(versions, cfg)
+		mountDocs(server, cfg)
+	}
+
+	server.BeforeRun(func() error {
+		path := dumpTarget(os.Args[1:])
+		if path == "" {
+			return nil
+		}
+		for _, cfg := range versions {
+			target := versionedPath(path, versionName(cfg))
+			if err := WriteSpec(server, target, cfg); err != nil {
+				return err
+			}
+			fmt.Printf("📄 OpenAPI spec written to %s\n", target)
+		}
+		return poltergeist.ErrSkipServe
+	})
+}
+
+// withVersionDefaults fills unset fields of a version config from the defaults
+func withVersionDefaults(config *SwaggerConfig, index int) *SwaggerConfig {
+	defaults := DefaultSwaggerConfig()
+	if config == nil {
+		config = defaults
+	}
+	cfg := *config
+
+	if cfg.APIVersion == "" && len(cfg.IncludePaths) == 0 && len(cfg.IncludeTags) == 0 {
+		cfg.APIVersion = fmt.Sprintf("v%d", index+1)
+	}
+	if cfg.Title == "" {
+		cfg.Title = defaults.Title
+	}
+	if cfg.Version == "" {
+		cfg.Version = defaults.Version
+		if cfg.APIVersion != "" {
+			cfg.Version = cfg.APIVersion
+		}
+	}
+	if cfg.UI == "" {
+		cfg.UI = defaults.UI
+	}
+	if cfg.Servers == nil {
+		cfg.Servers = defaults.Servers
+	}
+	if cfg.Path == "" || cfg.Path == defaults.Path {
+		cfg.Path = defaults.Path + "/" + versionName(&cfg)
+	}
+	return &cfg
+}
+
+// versionName returns the name a version config is served and dumped under
+func versionName(config *SwaggerConfig) string {
+	if config.APIVersion != "" {
+		return config.APIVersion
+	}
+	if len(config.IncludePaths) > 0 {
+		return strings.Trim(strings.ReplaceAll(config.IncludePaths[0], "/", "-"), "-")
+	}
+	return config.Version
+}
+
+// versionedPath inserts a version before the file extension
+func versionedPath(path, version string) string {
+	ext := filepath.Ext(path)
+	return strings.TrimSuffix(path, ext) + "." + version + ext
+}
@@ -0,0 +1,338 @@
+package poltergeist
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"sync"
+)
+
+// =============================================================================
+// HTTP ERRORS - Typed errors handlers can return
+// =============================================================================
+
+// HTTPError is an error with a status code and a client-facing message.
+// Handlers return it (or wrap it) and the server renders the error envelope:
+//
+//	return poltergeist.ErrNotFound.Wrap(err)
+//	return poltergeist.NewHTTPError(409, "email already registered")
+type HTTPError struct {
+	Code    int    // HTTP status code
+	Message string // client-facing message
+	Details any    // optional extra payload (field errors, ...)
+	Err     error  // underlying cause; logged, never sent to clients
+}
+
+// NewHTTPError creates an HTTPError; the message defaults to the status text
+func NewHTTPError(code int, message ...string) *HTTPError {
+	msg := http.StatusText(code)
+	if len(message) > 0 && message[0] != "" {
+		msg = message[0]
+	}
+	return &HTTPError{Code: code, Message: msg}
+}
+
+// NewError creates an HTTPError with a client-facing message; chain
+// WithDetail to add structured details:
+//
+//	return poltergeist.NewError(409, "email already registered").WithDetail("field", "email")
+func NewError(code int, message string) *HTTPError {
+	return NewHTTPError(code, message)
+}
+
+// Error implements error
+func (e *HTTPError) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("%d %s: %v", e.Code, e.Message, e.Err)
+	}
+	return fmt.Sprintf("%d %s", e.Code, e.Message)
+}
+
+// Unwrap returns the underlying cause
+func (e *HTTPError) Unwrap() error {
+	return e.Err
+}
+
+// Is matches any HTTPError with the same status code, so
+// errors.Is(ErrNotFound.Wrap(err), ErrNotFound) holds
+func (e *HTTPError) Is(target error) bool {
+	t, ok := target.(*HTTPError)
+	return ok && t.Code == e.Code
+}
+
+// Wrap returns a copy carrying cause
+func (e *HTTPError) Wrap(cause error) *HTTPError {
+	copied := *e
+	copied.Err = cause
+	return &copied
+}
+
+// WithMessage returns a copy with a different client-facing message
+func (e *HTTPError) WithMessage(message string) *HTTPError {
+	copied := *e
+	copied.Message = message
+	return &copied
+}
+
+// WithDetails returns a copy with details for the response envelope
+func (e *HTTPError) WithDetails(details any) *HTTPError {
+	copied := *e
+	copied.Details = details
+	return &copied
+}
+
+// WithDetail returns a copy with key set in its details map (details that
+// aren't an H are replaced)
+func (e *HTTPError) WithDetail(key string, value any) *HTTPError {
+	details := H{}
+	if existing, ok := e.Details.(H); ok {
+		for k, v := range existing {
+			details[k] = v
+		}
+	}
+	details[key] = value
+	return e.WithDetails(details)
+}
+
+// Common HTTP errors
+var (
+	ErrBadRequest            = NewHTTPError(http.StatusBadRequest)
+	ErrUnauthorized          = NewHTTPError(http.StatusUnauthorized)
+	ErrForbidden             = NewHTTPError(http.StatusForbidden)
+	ErrNotFound              = NewHTTPError(http.StatusNotFound)
+	ErrMethodNotAllowed      = NewHTTPError(http.StatusMethodNotAllowed)
+	ErrConflict              = NewHTTPError(http.StatusConflict)
+	ErrRequestEntityTooLarge = NewHTTPError(http.StatusRequestEntityTooLarge)
+	ErrUnsupportedMediaType  = NewHTTPError(http.StatusUnsupportedMediaType)
+	ErrUnprocessableEntity   = NewHTTPError(http.StatusUnprocessableEntity)
+	ErrTooManyRequests       = NewHTTPError(http.StatusTooManyRequests)
+	ErrInternalServerError   = NewHTTPError(http.StatusInternalServerError)
+	ErrBadGateway            = NewHTTPError(http.StatusBadGateway)
+	ErrServiceUnavailable    = NewHTTPError(http.StatusServiceUnavailable)
+	ErrGatewayTimeout        = NewHTTPError(http.StatusGatewayTimeout)
+)
+
+// --- Bind and validation errors ---
+
+// BindError reports request data that could not be decoded
+type BindError struct {
+	Source string // BindSourceBody, BindSourceQuery or BindSourcePath
+	Field  string // Value that failed to convert ("" when the whole body is invalid)
+	Err    error
+}
+
+// Request data a BindError comes from
+const (
+	BindSourceBody  = ""
+	BindSourceQuery = "query"
+	BindSourcePath  = "path"
+)
+
+func (e *BindError) Error() string {
+	if e.Field != "" {
+		return "bind " + e.Field + ": " + e.Err.Error()
+	}
+	return "bind: " + e.Err.Error()
+}
+
+func (e *BindError) Unwrap() error { return e.Err }
+
+// FieldError describes one invalid field
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ValidationErrors is returned by validation code and rendered as 422 with
+// the field errors as details
+type ValidationErrors []FieldError
+
+func (v ValidationErrors) Error() string {
+	if len(v) == 0 {
+		return "validation failed"
+	}
+	return fmt.Sprintf("validation failed: %s %s (and %d more)", v[0].Field, v[0].Message, len(v)-1)
+}
+
+// =============================================================================
+// ERROR REGISTRY - Mapping handler errors to responses
+// =============================================================================
+
+// ErrorMapper converts a handler error into an HTTPError; returning nil
+// passes the error on to the next mapper
+type ErrorMapper func(c *Context, err error) *HTTPError
+
+// ErrorRenderer writes an HTTPError as the response
+type ErrorRenderer func(c *Context, err *HTTPError) error
+
+// errorMapping pairs a match target with its mapper
+type errorMapping struct {
+	target   error
+	typeOnly reflect.Type // set when target is a typed nil: match by type
+	mapper   ErrorMapper
+}
+
+// errorRegistry resolves handler errors into responses
+type errorRegistry struct {
+	mu       sync.RWMutex
+	mappings []errorMapping
+	render   ErrorRenderer
+}
+
+func newErrorRegistry() *errorRegistry {
+	return &errorRegistry{render: renderError}
+}
+
+// add registers a mapper for target
+func (r *errorRegistry) add(target error, mapper ErrorMapper) {
+	m := errorMapping{target: target, mapper: mapper}
+	if v := reflect.ValueOf(target); v.Kind() == reflect.Pointer && v.IsNil() {
+		m.typeOnly = v.Type()
+	}
+	r.mu.Lock()
+	r.mappings = append(r.mappings, m)
+	r.mu.Unlock()
+}
+
+// matches reports whether err matches the mapping target
+func (m errorMapping) matches(err error) bool {
+	if m.typeOnly != nil {
+		return errors.As(err, reflect.New(m.typeOnly).Interface())
+	}
+	return errors.Is(err, m.target)
+}
+
+// resolve maps err: registered mappers first, then HTTPError, bind and
+// validation errors; anything else becomes a 500
+func (r *errorRegistry) resolve(c *Context, err error) *HTTPError {
+	r.mu.RLock()
+	mappings := r.mappings
+	r.mu.RUnlock()
+
+	for _, m := range mappings {
+		if m.matches(err) {
+			if httpErr := m.mapper(c, err); httpErr != nil {
+				return httpErr
+			}
+		}
+	}
+
+	var httpErr *HTTPError
+	if errors.As(err, &httpErr) {
+		return httpErr
+	}
+	var validation ValidationErrors
+	if errors.As(err, &validation) {
+		return ErrUnprocessableEntity.WithMessage("Validation failed").WithDetails([]FieldError(validation))
+	}
+	var bind *BindError
+	if errors.As(err, &bind) {
+		httpErr := ErrBadRequest.WithMessage(bindErrorMessage(bind.Source)).Wrap(err)
+		if bind.Field != "" {
+			httpErr = httpErr.WithDetails([]FieldError{{Field: bind.Field, Message: bind.Err.Error()}})
+		}
+		return httpErr
+	}
+	return ErrInternalServerError.Wrap(err)
+}
+
+// bindErrorMessage is the client-facing message for a bind error source
+func bindErrorMessage(source string) string {
+	switch source {
+	case BindSourceQuery:
+		return "Invalid query parameters"
+	case BindSourcePath:
+		return "Invalid path parameters"
+	}
+	return "Invalid request body"
+}
+
+// respond renders an HTTPError unless the handler already wrote a response
+func (r *errorRegistry) respond(c *Context, httpErr *HTTPError) error {
+	if c.Written() {
+		return nil
+	}
+	r.mu.RLock()
+	render := r.render
+	r.mu.RUnlock()
+	return render(c, c.translateError(httpErr))
+}
+
+// renderError writes the default {"error": ..., "details": ...} envelope
+func renderError(c *Context, err *HTTPError) error {
+	body := H{"error": err.Message}
+	if err.Details != nil {
+		body["details"] = err.Details
+	}
+	return c.JSON(err.Code, body)
+}
+
+// ErrorEnvelope is the body written by StructuredErrorRenderer
+type ErrorEnvelope struct {
+	Code      int    `json:"code"`
+	Message   string `json:"message"`
+	RequestID string `json:"request_id,omitempty"`
+	Details   any    `json:"details,omitempty"`
+}
+
+// StructuredErrorRenderer writes errors as {"code", "message", "request_id",
+// "details"}, so clients can quote the request ID in support tickets:
+//
+//	app.Use(middleware.RequestID())
+//	app.ErrorRenderer(poltergeist.StructuredErrorRenderer)
+func StructuredErrorRenderer(c *Context, err *HTTPError) error {
+	return c.JSON(err.Code, ErrorEnvelope{
+		Code:      err.Code,
+		Message:   err.Message,
+		RequestID: c.RequestID(),
+		Details:   err.Details,
+	})
+}
+
+// =============================================================================
+// SERVER INTEGRATION
+// =============================================================================
+
+// OnErrorType maps errors matching target to a response. target is matched
+// with errors.Is, or by type when it is a typed nil pointer:
+//
+//	app.OnErrorType(sql.ErrNoRows, func(c *poltergeist.Context, err error) *poltergeist.HTTPError {
+//	    return poltergeist.ErrNotFound
+//	})
+//	app.OnErrorType((*json.SyntaxError)(nil), badJSON)
+func (s *Server) OnErrorType(target error, mapper ErrorMapper) *Server {
+	s.router.errors.add(target, mapper)
+	return s
+}
+
+// ErrorRenderer replaces the response envelope used for every error,
+// including 404 and 405 responses
+func (s *Server) ErrorRenderer(render ErrorRenderer) *Server {
+	s.router.errors.mu.Lock()
+	s.router.errors.render = render
+	s.router.errors.mu.Unlock()
+	return s
+}
+
+// ResolveError returns the HTTPError err will be rendered as, applying the
+// server's OnErrorType mappings. Middleware that reports status codes uses
+// it, since handler errors are rendered after the middleware chain returns.
+func (c *Context) ResolveError(err error) *HTTPError {
+	if c.router != nil {
+		return c.router.errors.resolve(c, err)
+	}
+	return defaultErrorRegistry.resolve(c, err)
+}
+
+// defaultErrorRegistry resolves errors for contexts outside a server
+var defaultErrorRegistry = newErrorRegistry()
+
+// HTTPError returns the error the server resolved for this request, if any
+func (c *Context) HTTPError() *HTTPError {
+	if v, ok := c.Get(ContextKeyHTTPError); ok {
+		httpErr, _ := v.(*HTTPError)
+		return httpErr
+	}
+	return nil
+}
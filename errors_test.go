@@ -0,0 +1,183 @@
+package poltergeist
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// =============================================================================
+// ERROR HANDLING TESTS
+// =============================================================================
+
+var errNoRows = errors.New("no rows in result set")
+
+type quotaError struct{ limit int }
+
+func (e *quotaError) Error() string { return "quota exceeded" }
+
+func TestErrors_Resolution(t *testing.T) {
+	app := New()
+	app.OnErrorType(errNoRows, func(c *Context, err error) *HTTPError {
+		return ErrNotFound.WithMessage("user not found")
+	})
+	app.OnErrorType((*quotaError)(nil), func(c *Context, err error) *HTTPError {
+		var q *quotaError
+		errors.As(err, &q)
+		return ErrTooManyRequests.WithDetails(H{"limit": q.limit})
+	})
+
+	var seen error
+	app.Pipeline().OnError(func(c *Context) {
+		if v, ok := c.Get(ContextKeyError); ok {
+			seen = v.(error)
+		}
+	})
+
+	routes := map[string]error{
+		"/http":     NewHTTPError(409, "email taken"),
+		"/wrapped":  ErrForbidden.Wrap(errors.New("role check failed")),
+		"/sentinel": errNoRows,
+		"/typed":    &quotaError{limit: 10},
+		"/validate": ValidationErrors{{Field: "email", Message: "is required"}},
+		"/plain":    errors.New("database password is hunter2"),
+	}
+	for path, err := range routes {
+		err := err
+		app.GET(path, func(c *Context) error { return err })
+	}
+	app.POST("/bind", func(c *Context) error {
+		var v struct{ Name string }
+		return c.Bind(&v)
+	})
+
+	tests := []struct {
+		method, path string
+		wantCode     int
+		wantBody     string
+	}{
+		{"GET", "/http", 409, `{"error":"email taken"}`},
+		{"GET", "/wrapped", 403, `{"error":"Forbidden"}`},
+		{"GET", "/sentinel", 404, `{"error":"user not found"}`},
+		{"GET", "/typed", 429, `{"details":{"limit":10},"error":"Too Many Requests"}`},
+		{"GET", "/validate", 422, `{"details":[{"field":"email","message":"is required"}],"error":"Validation failed"}`},
+		{"GET", "/plain", 500, `{"error":"Internal Server Error"}`},
+		{"POST", "/bind", 400, `{"error":"Invalid request body"}`},
+		{"GET", "/missing", 404, `{"error":"Not Found"}`},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(tt.method, tt.path, strings.NewReader("{bad"))
+		app.Router().ServeHTTP(w, req)
+
+		if w.Code != tt.wantCode {
+			t.Errorf("%s: code = %d, want %d", tt.path, w.Code, tt.wantCode)
+		}
+		if got := compactJSON(t, w.Body.Bytes()); got != tt.wantBody {
+			t.Errorf("%s: body = %s, want %s", tt.path, got, tt.wantBody)
+		}
+	}
+
+	if seen == nil || seen.Error() != "bind: invalid character 'b' looking for beginning of object key string" {
+		t.Errorf("pipeline saw %v, want the concrete bind error", seen)
+	}
+}
+
+func TestErrors_CustomRenderer(t *testing.T) {
+	app := New().ErrorRenderer(func(c *Context, err *HTTPError) error {
+		return c.JSON(err.Code, H{"code": err.Code, "message": err.Message})
+	})
+	app.GET("/fail", func(c *Context) error { return ErrConflict })
+
+	for path, want := range map[string]string{
+		"/fail":    `{"code":409,"message":"Conflict"}`,
+		"/missing": `{"code":404,"message":"Not Found"}`,
+	} {
+		w := httptest.NewRecorder()
+		app.Router().ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+		if got := compactJSON(t, w.Body.Bytes()); got != want {
+			t.Errorf("%s: body = %s, want %s", path, got, want)
+		}
+	}
+}
+
+func TestErrors_StructuredRenderer(t *testing.T) {
+	app := New()
+	app.ErrorRenderer(StructuredErrorRenderer)
+	app.Use(func(next HandlerFunc) HandlerFunc {
+		return func(c *Context) error {
+			c.Set(ContextKeyRequestID, "req-42")
+			return next(c)
+		}
+	})
+	app.GET("/conflict", func(c *Context) error {
+		return NewError(409, "email already registered").WithDetail("field", "email").WithDetail("hint", "log in")
+	})
+	app.GET("/plain", func(c *Context) error { return errors.New("boom") })
+
+	tests := []struct {
+		path     string
+		wantCode int
+		wantBody string
+	}{
+		{"/conflict", 409, `{"code":409,"details":{"field":"email","hint":"log in"},"message":"email already registered","request_id":"req-42"}`},
+		{"/plain", 500, `{"code":500,"message":"Internal Server Error","request_id":"req-42"}`},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		app.ServeHTTP(w, httptest.NewRequest("GET", tt.path, nil))
+		if w.Code != tt.wantCode {
+			t.Errorf("%s: code = %d, want %d", tt.path, w.Code, tt.wantCode)
+		}
+		if got := compactJSON(t, w.Body.Bytes()); got != tt.wantBody {
+			t.Errorf("%s: body = %s, want %s", tt.path, got, tt.wantBody)
+		}
+	}
+
+	// Unmatched requests have no middleware; the header still identifies them
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/missing", nil)
+	req.Header.Set(HeaderXRequestID, "edge-7")
+	app.ServeHTTP(w, req)
+	if got := compactJSON(t, w.Body.Bytes()); got != `{"code":404,"message":"Not Found","request_id":"edge-7"}` {
+		t.Errorf("404 body = %s", got)
+	}
+}
+
+func TestHTTPError_WithDetail(t *testing.T) {
+	base := ErrBadRequest.WithDetails([]string{"replaced"})
+	err := base.WithDetail("a", 1)
+	err2 := err.WithDetail("b", 2)
+	if d := err.Details.(H); len(d) != 1 || d["a"] != 1 {
+		t.Errorf("first detail = %v", err.Details)
+	}
+	if d := err2.Details.(H); len(d) != 2 || d["b"] != 2 {
+		t.Errorf("second detail = %v", err2.Details)
+	}
+	if ErrBadRequest.Details != nil {
+		t.Error("WithDetail mutated the shared error")
+	}
+}
+
+func TestHTTPError_Is(t *testing.T) {
+	err := ErrNotFound.Wrap(errNoRows)
+	if !errors.Is(err, ErrNotFound) || !errors.Is(err, errNoRows) {
+		t.Error("wrapped HTTPError should match both the status sentinel and its cause")
+	}
+	if errors.Is(err, ErrForbidden) {
+		t.Error("HTTPError matched a different status")
+	}
+}
+
+// compactJSON re-encodes JSON with sorted keys and no whitespace
+func compactJSON(t *testing.T, data []byte) string {
+	t.Helper()
+	var v any
+	if err := json.Unmarshal(data, &v); err != nil {
+		t.Fatalf("invalid JSON %q: %v", data, err)
+	}
+	out, _ := json.Marshal(v)
+	return string(out)
+}
@@ -1,6 +1,9 @@
 package poltergeist
 
-import "sync"
+import (
+	"log/slog"
+	"sync"
+)
 
 // =============================================================================
 // EVENT TYPES - Event-driven architecture constants
@@ -21,6 +24,10 @@ const (
 	EventWSMessage     EventType = "ws_message"     // WebSocket message received
 	EventSSEConnect    EventType = "sse_connect"    // SSE client connected
 	EventSSEDisconnect EventType = "sse_disconnect" // SSE client disconnected
+	EventJobStart      EventType = "job_start"      // Scheduled job started
+	EventJobFinish     EventType = "job_finish"     // Scheduled job finished successfully
+	EventJobError      EventType = "job_error"      // Scheduled job failed
+	EventDeprecated    EventType = "deprecated"     // Deprecated route called
 )
 
 // =============================================================================
@@ -30,20 +37,36 @@ const (
 // EventHandler represents an event handler function
 type EventHandler func(ctx *Context)
 
+// newEventContext creates a detached Context for events that are not tied to
+// an HTTP request (scheduled jobs, background work); details live in its store
+func newEventContext() *Context {
+	return NewContext(nil, nil)
+}
+
 // =============================================================================
 // EVENT PIPELINE - Event-driven request lifecycle
 // =============================================================================
 
 // EventPipeline manages event handlers for request lifecycle
 type EventPipeline struct {
-	handlers map[EventType][]EventHandler
-	mu       sync.RWMutex
+	handlers  map[EventType][]EventHandler
+	mu        sync.RWMutex
+	metrics   *metricsRegistry
+	tracing   *tracerSlot
+	reporting *reporterSlot
+	logs      *loggerSlot
+	caches    *cacheSlot
 }
 
 // NewEventPipeline creates a new event pipeline
 func NewEventPipeline() *EventPipeline {
 	return &EventPipeline{
-		handlers: make(map[EventType][]EventHandler),
+		handlers:  make(map[EventType][]EventHandler),
+		metrics:   newMetricsRegistry(),
+		tracing:   &tracerSlot{},
+		reporting: &reporterSlot{},
+		logs:      &loggerSlot{},
+		caches:    &cacheSlot{},
 	}
 }
 
@@ -67,30 +90,84 @@ func (p *EventPipeline) Off(event EventType) *EventPipeline {
 
 // Emit triggers an event with context
 func (p *EventPipeline) Emit(event EventType, ctx *Context) {
+	p.emitWhile(event, ctx, nil)
+}
+
+// emitWhile triggers an event, skipping the remaining handlers once keep
+// (if set) returns false for ctx
+func (p *EventPipeline) emitWhile(event EventType, ctx *Context, keep func(*Context) bool) {
+	p.metrics.get().pipelineEvents.Add(1, string(event))
+
 	p.mu.RLock()
 	handlers := p.handlers[event]
 	p.mu.RUnlock()
 
 	for _, handler := range handlers {
-		if ctx != nil {
-			handler(ctx)
+		if ctx == nil || (keep != nil && !keep(ctx)) {
+			return
 		}
+		handler(ctx)
 	}
 }
 
 // EmitAsync triggers an event asynchronously
 func (p *EventPipeline) EmitAsync(event EventType, ctx *Context) {
+	p.metrics.get().pipelineEvents.Add(1, string(event))
+
 	p.mu.RLock()
 	handlers := p.handlers[event]
 	p.mu.RUnlock()
 
 	for _, handler := range handlers {
 		if ctx != nil {
-			go handler(ctx)
+			go func(handler EventHandler) {
+				defer p.captureAsyncPanic(event)
+				handler(ctx)
+			}(handler)
 		}
 	}
 }
 
+// instruments returns the metrics the pipeline's owners report into
+func (p *EventPipeline) instruments() *instruments {
+	if p == nil {
+		return nopInstruments
+	}
+	return p.metrics.get()
+}
+
+// tracer returns the active tracer, or nil when tracing is off
+func (p *EventPipeline) tracer() Tracer {
+	if p == nil {
+		return nil
+	}
+	return p.tracing.get()
+}
+
+// logger returns the framework logger
+func (p *EventPipeline) logger() Logger {
+	if p == nil {
+		return slog.Default()
+	}
+	return p.logs.get()
+}
+
+// cache returns the server cache
+func (p *EventPipeline) cache() Cache {
+	if p == nil {
+		return NopCache
+	}
+	return p.caches.get()
+}
+
+// reporter returns the active error reporter, or nil when reporting is off
+func (p *EventPipeline) reporter() Reporter {
+	if p == nil {
+		return nil
+	}
+	return p.reporting.get()
+}
+
 // HasHandlers returns true if the event has registered handlers
 func (p *EventPipeline) HasHandlers(event EventType) bool {
 	p.mu.RLock()
@@ -162,3 +239,24 @@ func (p *EventPipeline) OnSSEConnect(handler EventHandler) *EventPipeline {
 func (p *EventPipeline) OnSSEDisconnect(handler EventHandler) *EventPipeline {
 	return p.On(EventSSEDisconnect, handler)
 }
+
+// OnJobStart registers a handler for scheduled job start events
+func (p *EventPipeline) OnJobStart(handler EventHandler) *EventPipeline {
+	return p.On(EventJobStart, handler)
+}
+
+// OnJobFinish registers a handler for scheduled job finish events
+func (p *EventPipeline) OnJobFinish(handler EventHandler) *EventPipeline {
+	return p.On(EventJobFinish, handler)
+}
+
+// OnJobError registers a handler for scheduled job error events
+func (p *EventPipeline) OnJobError(handler EventHandler) *EventPipeline {
+	return p.On(EventJobError, handler)
+}
+
+// OnDeprecated registers a handler called for each request to a deprecated
+// route (c.Route() identifies it), e.g. to count remaining usage before sunset
+func (p *EventPipeline) OnDeprecated(handler EventHandler) *EventPipeline {
+	return p.On(EventDeprecated, handler)
+}
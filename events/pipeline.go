@@ -17,6 +17,10 @@ const (
 	WSMessage     EventType = "ws_message"
 	SSEConnect    EventType = "sse_connect"
 	SSEDisconnect EventType = "sse_disconnect"
+	JobStart      EventType = "job_start"
+	JobFinish     EventType = "job_finish"
+	JobError      EventType = "job_error"
+	Deprecated    EventType = "deprecated"
 )
 
 // EventHandler represents an event handler function
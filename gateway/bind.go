@@ -0,0 +1,296 @@
+package gateway
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// =============================================================================
+// BINDING - Rule compiled against a method's request message
+// =============================================================================
+
+// binding is a Rule resolved against the request and response messages
+type binding struct {
+	path         string // Poltergeist route path
+	params       []pathParam
+	body         string                       // "*", a top-level field name or ""
+	bodyField    protoreflect.FieldDescriptor // set when body names a field
+	responseBody protoreflect.FieldDescriptor // set when the rule has a response_body
+	bound        map[string]bool              // field paths taken by the path or body
+}
+
+// pathParam is a route parameter bound to a request field path
+type pathParam struct {
+	name   string
+	fields []protoreflect.FieldDescriptor
+}
+
+// newBinding compiles rule for method
+func newBinding(method protoreflect.MethodDescriptor, rule Rule) (*binding, error) {
+	path, vars, err := parseTemplate(rule.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	b := &binding{path: path, body: rule.Body, bound: make(map[string]bool)}
+	input := method.Input()
+	for _, v := range vars {
+		fields, err := resolveFields(input, strings.Split(v, "."))
+		if err != nil {
+			return nil, fmt.Errorf("path variable %q: %w", v, err)
+		}
+		if last := fields[len(fields)-1]; last.IsList() || last.IsMap() {
+			return nil, fmt.Errorf("path variable %q: repeated fields cannot be bound to the path", v)
+		}
+		b.params = append(b.params, pathParam{name: v, fields: fields})
+		b.bound[fieldPath(fields)] = true
+	}
+
+	if rule.Body != "" && rule.Body != "*" {
+		fields, err := resolveFields(input, []string{rule.Body})
+		if err != nil {
+			return nil, fmt.Errorf("body %q: %w", rule.Body, err)
+		}
+		b.bodyField = fields[0]
+		b.bound[fieldPath(fields)] = true
+	}
+
+	if rule.ResponseBody != "" {
+		fields, err := resolveFields(method.Output(), []string{rule.ResponseBody})
+		if err != nil {
+			return nil, fmt.Errorf("response body %q: %w", rule.ResponseBody, err)
+		}
+		b.responseBody = fields[0]
+	}
+	return b, nil
+}
+
+// decodeBody unmarshals the request body into the message or its body field
+func (b *binding) decodeBody(msg protoreflect.Message, body []byte, opts protojson.UnmarshalOptions) error {
+	if len(strings.TrimSpace(string(body))) == 0 {
+		return nil
+	}
+	if b.bodyField == nil {
+		return opts.Unmarshal(body, msg.Interface())
+	}
+
+	// Decode {"<field>": body} into a scratch message so protojson handles
+	// every field kind, then merge the field over
+	wrapped, err := json.Marshal(map[string]json.RawMessage{b.bodyField.JSONName(): body})
+	if err != nil {
+		return err
+	}
+	scratch := msg.New()
+	if err := opts.Unmarshal(wrapped, scratch.Interface()); err != nil {
+		return err
+	}
+	proto.Merge(msg.Interface(), scratch.Interface())
+	return nil
+}
+
+// setQuery applies one query parameter; unknown parameters are ignored and
+// fields bound by the path or body are left alone
+func (b *binding) setQuery(msg protoreflect.Message, key string, values []string) error {
+	fields, err := resolveFields(msg.Descriptor(), strings.Split(key, "."))
+	if err != nil || b.bound[fieldPath(fields)] {
+		return nil
+	}
+	if b.bodyField != nil && fields[0] == b.bodyField {
+		return nil
+	}
+	return setField(msg, fields, values)
+}
+
+// =============================================================================
+// PATH TEMPLATES
+// =============================================================================
+
+// templateVar matches "{field.path}" and "{field.path=pattern}"
+var templateVar = regexp.MustCompile(`\{([A-Za-z_][A-Za-z0-9_.]*)(?:=([^}]*))?\}`)
+
+// parseTemplate converts an HttpRule path template into a Poltergeist route
+// path, returning the variables in order. "{id}" and "{id=*}" become ":id",
+// a trailing "{name=**}" becomes "*name"; routes already written with ":id"
+// segments are accepted as-is.
+func parseTemplate(template string) (string, []string, error) {
+	if !strings.HasPrefix(template, "/") {
+		return "", nil, fmt.Errorf("path template %q must start with /", template)
+	}
+
+	var out strings.Builder
+	var vars []string
+	last := 0
+	for _, m := range templateVar.FindAllStringSubmatchIndex(template, -1) {
+		start, end := m[0], m[1]
+		name := template[m[2]:m[3]]
+		pattern := "*"
+		if m[4] >= 0 {
+			pattern = template[m[4]:m[5]]
+		}
+		if template[start-1] != '/' || (end < len(template) && template[end] != '/') {
+			return "", nil, fmt.Errorf("path template %q: variable {%s} must span a whole segment", template, name)
+		}
+
+		out.WriteString(template[last:start])
+		switch pattern {
+		case "*":
+			out.WriteString(":" + name)
+		case "**":
+			if end != len(template) {
+				return "", nil, fmt.Errorf("path template %q: {%s=**} must be the last segment", template, name)
+			}
+			out.WriteString("*" + name)
+		default:
+			return "", nil, fmt.Errorf("path template %q: unsupported pattern %q for {%s}", template, pattern, name)
+		}
+		vars = append(vars, name)
+		last = end
+	}
+	out.WriteString(template[last:])
+
+	path := out.String()
+	if strings.ContainsAny(path, "{}") {
+		return "", nil, fmt.Errorf("path template %q is malformed", template)
+	}
+	if len(vars) > 0 {
+		return path, vars, nil
+	}
+
+	// Poltergeist-style template: parameters are the ":name" and "*name" segments
+	for _, segment := range strings.Split(path, "/") {
+		if strings.HasPrefix(segment, ":") || strings.HasPrefix(segment, "*") {
+			vars = append(vars, segment[1:])
+		}
+	}
+	return path, vars, nil
+}
+
+// =============================================================================
+// FIELDS - Setting message fields from strings
+// =============================================================================
+
+// resolveFields walks a dotted field path (proto or JSON names) through
+// singular message fields
+func resolveFields(desc protoreflect.MessageDescriptor, names []string) ([]protoreflect.FieldDescriptor, error) {
+	fields := make([]protoreflect.FieldDescriptor, 0, len(names))
+	for i, name := range names {
+		fd := desc.Fields().ByName(protoreflect.Name(name))
+		if fd == nil {
+			fd = desc.Fields().ByJSONName(name)
+		}
+		if fd == nil {
+			return nil, fmt.Errorf("no field %q in %s", name, desc.FullName())
+		}
+		fields = append(fields, fd)
+		if i < len(names)-1 {
+			if fd.Kind() != protoreflect.MessageKind || fd.IsList() || fd.IsMap() {
+				return nil, fmt.Errorf("field %q of %s is not a message", name, desc.FullName())
+			}
+			desc = fd.Message()
+		}
+	}
+	return fields, nil
+}
+
+// fieldPath renders a resolved field path with proto names
+func fieldPath(fields []protoreflect.FieldDescriptor) string {
+	names := make([]string, len(fields))
+	for i, fd := range fields {
+		names[i] = string(fd.Name())
+	}
+	return strings.Join(names, ".")
+}
+
+// setField sets the field at the end of fields; repeated fields take every
+// value, singular fields the last one
+func setField(msg protoreflect.Message, fields []protoreflect.FieldDescriptor, values []string) error {
+	for _, fd := range fields[:len(fields)-1] {
+		msg = msg.Mutable(fd).Message()
+	}
+	fd := fields[len(fields)-1]
+	if fd.IsMap() {
+		return fmt.Errorf("map field %q cannot be set from a parameter", fieldPath(fields))
+	}
+
+	if fd.IsList() {
+		list := msg.Mutable(fd).List()
+		for _, s := range values {
+			v, err := parseValue(fd, s, list.NewElement)
+			if err != nil {
+				return fmt.Errorf("invalid value %q for %s: %w", s, fieldPath(fields), err)
+			}
+			list.Append(v)
+		}
+		return nil
+	}
+
+	s := values[len(values)-1]
+	v, err := parseValue(fd, s, func() protoreflect.Value { return msg.NewField(fd) })
+	if err != nil {
+		return fmt.Errorf("invalid value %q for %s: %w", s, fieldPath(fields), err)
+	}
+	msg.Set(fd, v)
+	return nil
+}
+
+// parseValue parses a parameter for a field; messages (timestamps, durations,
+// wrappers, field masks) are parsed with protojson
+func parseValue(fd protoreflect.FieldDescriptor, s string, newValue func() protoreflect.Value) (protoreflect.Value, error) {
+	switch fd.Kind() {
+	case protoreflect.BoolKind:
+		v, err := strconv.ParseBool(s)
+		return protoreflect.ValueOfBool(v), err
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind:
+		v, err := strconv.ParseInt(s, 10, 32)
+		return protoreflect.ValueOfInt32(int32(v)), err
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		v, err := strconv.ParseInt(s, 10, 64)
+		return protoreflect.ValueOfInt64(v), err
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		v, err := strconv.ParseUint(s, 10, 32)
+		return protoreflect.ValueOfUint32(uint32(v)), err
+	case protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		v, err := strconv.ParseUint(s, 10, 64)
+		return protoreflect.ValueOfUint64(v), err
+	case protoreflect.FloatKind:
+		v, err := strconv.ParseFloat(s, 32)
+		return protoreflect.ValueOfFloat32(float32(v)), err
+	case protoreflect.DoubleKind:
+		v, err := strconv.ParseFloat(s, 64)
+		return protoreflect.ValueOfFloat64(v), err
+	case protoreflect.StringKind:
+		return protoreflect.ValueOfString(s), nil
+	case protoreflect.BytesKind:
+		v, err := base64.StdEncoding.DecodeString(s)
+		if err != nil {
+			v, err = base64.URLEncoding.DecodeString(s)
+		}
+		return protoreflect.ValueOfBytes(v), err
+	case protoreflect.EnumKind:
+		if ev := fd.Enum().Values().ByName(protoreflect.Name(s)); ev != nil {
+			return protoreflect.ValueOfEnum(ev.Number()), nil
+		}
+		n, err := strconv.ParseInt(s, 10, 32)
+		if err != nil {
+			return protoreflect.Value{}, fmt.Errorf("unknown %s value", fd.Enum().FullName())
+		}
+		return protoreflect.ValueOfEnum(protoreflect.EnumNumber(n)), nil
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		v := newValue()
+		if err := protojson.Unmarshal([]byte(s), v.Message().Interface()); err == nil {
+			return v, nil
+		}
+		quoted, _ := json.Marshal(s)
+		v = newValue()
+		return v, protojson.Unmarshal(quoted, v.Message().Interface())
+	}
+	return protoreflect.Value{}, fmt.Errorf("unsupported field kind %s", fd.Kind())
+}
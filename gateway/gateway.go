@@ -0,0 +1,495 @@
+// Package gateway exposes gRPC services as JSON/REST routes on a Poltergeist
+// server, in the style of grpc-gateway but without generated gateway code:
+//
+//	gw := gateway.New(app)
+//	if err := gw.RegisterService(&pb.Users_ServiceDesc, &usersServer{}); err != nil {
+//	    log.Fatal(err)
+//	}
+//
+// Routes come from the google.api.http annotations of the service's proto
+// file, or are declared explicitly when the proto has none:
+//
+//	gw.Handle(&pb.Users_ServiceDesc, srv, "GetUser", gateway.Rule{Method: "GET", Path: "/v1/users/{id}"})
+//
+// Path variables ("{id}", "{user.id}", "{name=**}") and query parameters
+// ("?page_size=10&filter.status=ACTIVE") are mapped onto request message
+// fields; the body is decoded with protojson into the whole message ("*") or
+// the field named by the rule. The routes are ordinary Poltergeist routes:
+// global, group and per-route middleware, metrics, tracing, error handling
+// and the docs pipeline apply to them.
+//
+// Request headers prefixed with Grpc-Metadata- and Authorization become
+// incoming gRPC metadata; headers and trailers set by the implementation
+// with grpc.SetHeader / grpc.SetTrailer are sent back as Grpc-Metadata-* and
+// Grpc-Trailer-* response headers. gRPC status errors are rendered with the
+// HTTP status grpc-gateway uses for their code. Only unary methods are
+// transcoded.
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"google.golang.org/genproto/googleapis/api/annotations"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+
+	"github.com/gofuckbiz/poltergeist"
+)
+
+// =============================================================================
+// CONFIGURATION
+// =============================================================================
+
+// Config holds gateway configuration
+type Config struct {
+	Marshal     protojson.MarshalOptions   // Response encoding (proto field names unless DisableProtoNames)
+	Unmarshal   protojson.UnmarshalOptions // Body decoding (default: unknown fields rejected)
+	MaxBodySize int64                      // Max request body size (default: 4MB)
+
+	// Render responses with lowerCamelCase JSON names instead of proto names
+	DisableProtoNames bool
+
+	// Interceptor wraps every transcoded call, like a gRPC server interceptor
+	Interceptor grpc.UnaryServerInterceptor
+
+	// IncomingHeader maps a request header to an incoming metadata key;
+	// returning false drops the header (default: DefaultIncomingHeader)
+	IncomingHeader func(name string) (string, bool)
+}
+
+// DefaultConfig returns default gateway configuration
+func DefaultConfig() *Config {
+	return &Config{
+		Marshal:        protojson.MarshalOptions{UseProtoNames: true},
+		MaxBodySize:    4 << 20,
+		IncomingHeader: DefaultIncomingHeader,
+	}
+}
+
+// Metadata header prefixes
+const (
+	MetadataHeaderPrefix = "Grpc-Metadata-"
+	TrailerHeaderPrefix  = "Grpc-Trailer-"
+)
+
+// DefaultIncomingHeader forwards Grpc-Metadata-* headers (without the
+// prefix) and Authorization
+func DefaultIncomingHeader(name string) (string, bool) {
+	name = http.CanonicalHeaderKey(name)
+	if strings.HasPrefix(name, MetadataHeaderPrefix) {
+		return strings.ToLower(strings.TrimPrefix(name, MetadataHeaderPrefix)), true
+	}
+	if name == poltergeist.HeaderAuthorization {
+		return "authorization", true
+	}
+	return "", false
+}
+
+// Rule binds a gRPC method to an HTTP route, mirroring google.api.HttpRule
+type Rule struct {
+	Method       string // HTTP method
+	Path         string // Path template, e.g. "/v1/users/{id}" ("/v1/users/:id" also works)
+	Body         string // "*" for the whole request message, a field name, or "" for none
+	ResponseBody string // Response field rendered instead of the whole message
+}
+
+// =============================================================================
+// GATEWAY
+// =============================================================================
+
+// Gateway registers transcoded gRPC methods on a router
+type Gateway struct {
+	router poltergeist.RouteRegistrar
+	config *Config
+}
+
+// New creates a gateway registering routes on router (a server or a group)
+func New(router poltergeist.RouteRegistrar, config ...*Config) *Gateway {
+	return &Gateway{router: router, config: getConfig(config)}
+}
+
+// getConfig copies the config and fills unset fields with defaults
+func getConfig(config []*Config) *Config {
+	defaults := DefaultConfig()
+	if len(config) == 0 || config[0] == nil {
+		return defaults
+	}
+	cfg := *config[0]
+	if cfg.MaxBodySize <= 0 {
+		cfg.MaxBodySize = defaults.MaxBodySize
+	}
+	if cfg.IncomingHeader == nil {
+		cfg.IncomingHeader = defaults.IncomingHeader
+	}
+	if !cfg.DisableProtoNames {
+		cfg.Marshal.UseProtoNames = true
+	}
+	return &cfg
+}
+
+// RegisterService mounts every unary method of the service that carries a
+// google.api.http annotation, including additional bindings. impl is the
+// service implementation passed to the generated Register...Server function.
+func (g *Gateway) RegisterService(desc *grpc.ServiceDesc, impl any, middlewares ...poltergeist.MiddlewareFunc) ([]*poltergeist.Route, error) {
+	service, err := serviceDescriptor(desc)
+	if err != nil {
+		return nil, err
+	}
+
+	var routes []*poltergeist.Route
+	for i := range desc.Methods {
+		method := service.Methods().ByName(protoreflect.Name(desc.Methods[i].MethodName))
+		if method == nil {
+			return nil, fmt.Errorf("gateway: method %s not found in %s", desc.Methods[i].MethodName, desc.ServiceName)
+		}
+		for _, rule := range httpRules(method) {
+			route, err := g.mount(desc, impl, &desc.Methods[i], method, rule, middlewares)
+			if err != nil {
+				return nil, err
+			}
+			routes = append(routes, route)
+		}
+	}
+	return routes, nil
+}
+
+// Handle mounts one unary method with an explicit rule, for services whose
+// protos carry no HTTP annotations
+func (g *Gateway) Handle(desc *grpc.ServiceDesc, impl any, methodName string, rule Rule, middlewares ...poltergeist.MiddlewareFunc) (*poltergeist.Route, error) {
+	service, err := serviceDescriptor(desc)
+	if err != nil {
+		return nil, err
+	}
+	for i := range desc.Methods {
+		if desc.Methods[i].MethodName != methodName {
+			continue
+		}
+		method := service.Methods().ByName(protoreflect.Name(methodName))
+		if method == nil {
+			break
+		}
+		return g.mount(desc, impl, &desc.Methods[i], method, rule, middlewares)
+	}
+	return nil, fmt.Errorf("gateway: unary method %s not found in %s", methodName, desc.ServiceName)
+}
+
+// mount registers the route for one rule
+func (g *Gateway) mount(desc *grpc.ServiceDesc, impl any, handler *grpc.MethodDesc, method protoreflect.MethodDescriptor, rule Rule, middlewares []poltergeist.MiddlewareFunc) (*poltergeist.Route, error) {
+	b, err := newBinding(method, rule)
+	if err != nil {
+		return nil, fmt.Errorf("gateway: %s %s (%s): %w", rule.Method, rule.Path, method.FullName(), err)
+	}
+
+	fullMethod := "/" + desc.ServiceName + "/" + handler.MethodName
+	call := func(c *poltergeist.Context) error {
+		return g.serve(c, impl, handler, fullMethod, b)
+	}
+
+	var route *poltergeist.Route
+	switch strings.ToUpper(rule.Method) {
+	case http.MethodGet:
+		route = g.router.GET(b.path, call, middlewares...)
+	case http.MethodPost:
+		route = g.router.POST(b.path, call, middlewares...)
+	case http.MethodPut:
+		route = g.router.PUT(b.path, call, middlewares...)
+	case http.MethodPatch:
+		route = g.router.PATCH(b.path, call, middlewares...)
+	case http.MethodDelete:
+		route = g.router.DELETE(b.path, call, middlewares...)
+	default:
+		return nil, fmt.Errorf("gateway: unsupported HTTP method %q for %s", rule.Method, method.FullName())
+	}
+
+	route.Name(string(method.Name())).Tag(string(method.Parent().Name()))
+	if doc := docMessage(method.Input()); doc != nil && rule.Body == "*" {
+		route.Request(doc)
+	}
+	if doc := docMessage(method.Output()); doc != nil && rule.ResponseBody == "" {
+		route.Response(doc)
+	}
+	return route, nil
+}
+
+// serve transcodes one request
+func (g *Gateway) serve(c *poltergeist.Context, impl any, handler *grpc.MethodDesc, fullMethod string, b *binding) error {
+	stream := &transportStream{method: fullMethod, header: metadata.MD{}, trailer: metadata.MD{}}
+	ctx := context.WithValue(c.Request.Context(), contextKey{}, c)
+	ctx = metadata.NewIncomingContext(ctx, g.incomingMetadata(c))
+	ctx = grpc.NewContextWithServerTransportStream(ctx, stream)
+
+	var decodeErr error
+	dec := func(v any) error {
+		msg, ok := v.(proto.Message)
+		if !ok {
+			decodeErr = fmt.Errorf("gateway: %T is not a proto message", v)
+			return decodeErr
+		}
+		decodeErr = g.decode(c, msg.ProtoReflect(), b)
+		return decodeErr
+	}
+
+	resp, err := handler.Handler(impl, ctx, dec, g.config.Interceptor)
+	stream.writeHeaders(c)
+	if err != nil {
+		if decodeErr != nil && errors.Is(err, decodeErr) {
+			return err
+		}
+		return httpError(err)
+	}
+
+	msg, ok := resp.(proto.Message)
+	if !ok {
+		return poltergeist.ErrInternalServerError.Wrap(fmt.Errorf("gateway: %s returned %T", fullMethod, resp))
+	}
+	data, err := g.encode(msg, b)
+	if err != nil {
+		return poltergeist.ErrInternalServerError.Wrap(err)
+	}
+	return c.Bytes(http.StatusOK, poltergeist.ContentTypeJSON, data)
+}
+
+// decode fills the request message from the body, query and path, in that
+// order, so path variables win
+func (g *Gateway) decode(c *poltergeist.Context, msg protoreflect.Message, b *binding) error {
+	if b.body != "" {
+		body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, g.config.MaxBodySize))
+		if err != nil {
+			return poltergeist.NewHTTPError(http.StatusRequestEntityTooLarge).Wrap(err)
+		}
+		if err := b.decodeBody(msg, body, g.config.Unmarshal); err != nil {
+			return poltergeist.ErrBadRequest.WithMessage("Invalid request body").Wrap(err)
+		}
+	}
+	if b.body != "*" {
+		for key, values := range c.Request.URL.Query() {
+			if err := b.setQuery(msg, key, values); err != nil {
+				return poltergeist.ErrBadRequest.WithMessage(err.Error()).Wrap(err)
+			}
+		}
+	}
+	for _, param := range b.params {
+		if err := setField(msg, param.fields, []string{c.Param(param.name)}); err != nil {
+			return poltergeist.ErrBadRequest.WithMessage(err.Error()).Wrap(err)
+		}
+	}
+	return nil
+}
+
+// encode renders the response message or its ResponseBody field
+func (g *Gateway) encode(msg proto.Message, b *binding) ([]byte, error) {
+	data, err := g.config.Marshal.Marshal(msg)
+	if err != nil || b.responseBody == nil {
+		return data, err
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	key := b.responseBody.JSONName()
+	if g.config.Marshal.UseProtoNames {
+		key = string(b.responseBody.Name())
+	}
+	if field, ok := fields[key]; ok {
+		return field, nil
+	}
+	return []byte("null"), nil
+}
+
+// incomingMetadata converts forwarded request headers to gRPC metadata
+func (g *Gateway) incomingMetadata(c *poltergeist.Context) metadata.MD {
+	md := metadata.MD{}
+	for name, values := range c.Request.Header {
+		if key, ok := g.config.IncomingHeader(name); ok {
+			md.Append(key, values...)
+		}
+	}
+	return md
+}
+
+// =============================================================================
+// CONTEXT PROPAGATION
+// =============================================================================
+
+type contextKey struct{}
+
+// FromContext returns the Poltergeist request a transcoded call serves, so
+// implementations can reach values set by middleware; nil for native gRPC calls
+func FromContext(ctx context.Context) *poltergeist.Context {
+	c, _ := ctx.Value(contextKey{}).(*poltergeist.Context)
+	return c
+}
+
+// transportStream collects headers and trailers set by the implementation
+type transportStream struct {
+	method  string
+	header  metadata.MD
+	trailer metadata.MD
+}
+
+func (s *transportStream) Method() string { return s.method }
+
+func (s *transportStream) SetHeader(md metadata.MD) error {
+	s.header = metadata.Join(s.header, md)
+	return nil
+}
+
+func (s *transportStream) SendHeader(md metadata.MD) error {
+	return s.SetHeader(md)
+}
+
+func (s *transportStream) SetTrailer(md metadata.MD) error {
+	s.trailer = metadata.Join(s.trailer, md)
+	return nil
+}
+
+// writeHeaders copies collected metadata to the response headers
+func (s *transportStream) writeHeaders(c *poltergeist.Context) {
+	header := c.Writer.Header()
+	for key, values := range s.header {
+		for _, v := range values {
+			header.Add(MetadataHeaderPrefix+key, v)
+		}
+	}
+	for key, values := range s.trailer {
+		for _, v := range values {
+			header.Add(TrailerHeaderPrefix+key, v)
+		}
+	}
+}
+
+// =============================================================================
+// ERRORS
+// =============================================================================
+
+// statusCodes maps gRPC codes to HTTP statuses, as grpc-gateway does
+var statusCodes = map[codes.Code]int{
+	codes.OK:                 http.StatusOK,
+	codes.Canceled:           499,
+	codes.Unknown:            http.StatusInternalServerError,
+	codes.InvalidArgument:    http.StatusBadRequest,
+	codes.DeadlineExceeded:   http.StatusGatewayTimeout,
+	codes.NotFound:           http.StatusNotFound,
+	codes.AlreadyExists:      http.StatusConflict,
+	codes.PermissionDenied:   http.StatusForbidden,
+	codes.Unauthenticated:    http.StatusUnauthorized,
+	codes.ResourceExhausted:  http.StatusTooManyRequests,
+	codes.FailedPrecondition: http.StatusBadRequest,
+	codes.Aborted:            http.StatusConflict,
+	codes.OutOfRange:         http.StatusBadRequest,
+	codes.Unimplemented:      http.StatusNotImplemented,
+	codes.Internal:           http.StatusInternalServerError,
+	codes.Unavailable:        http.StatusServiceUnavailable,
+	codes.DataLoss:           http.StatusInternalServerError,
+}
+
+// HTTPStatus returns the HTTP status for a gRPC code
+func HTTPStatus(code codes.Code) int {
+	if s, ok := statusCodes[code]; ok {
+		return s
+	}
+	return http.StatusInternalServerError
+}
+
+// httpError converts an implementation error into an HTTPError; HTTPErrors
+// pass through, status details become the envelope details
+func httpError(err error) error {
+	var httpErr *poltergeist.HTTPError
+	if errors.As(err, &httpErr) {
+		return err
+	}
+	st, ok := status.FromError(err)
+	if !ok {
+		st = status.FromContextError(err)
+	}
+	if st.Code() == codes.Unknown && !ok {
+		return poltergeist.ErrInternalServerError.Wrap(err)
+	}
+
+	out := poltergeist.NewHTTPError(HTTPStatus(st.Code()), st.Message()).Wrap(err)
+	var details []json.RawMessage
+	for _, d := range st.Proto().GetDetails() {
+		if data, err := protojson.Marshal(d); err == nil {
+			details = append(details, data)
+		}
+	}
+	if len(details) > 0 {
+		out = out.WithDetails(details)
+	}
+	return out
+}
+
+// =============================================================================
+// DESCRIPTORS
+// =============================================================================
+
+// serviceDescriptor finds the registered descriptor of a generated service
+func serviceDescriptor(desc *grpc.ServiceDesc) (protoreflect.ServiceDescriptor, error) {
+	d, err := protoregistry.GlobalFiles.FindDescriptorByName(protoreflect.FullName(desc.ServiceName))
+	if err != nil {
+		return nil, fmt.Errorf("gateway: service %s is not registered (import its generated package): %w", desc.ServiceName, err)
+	}
+	service, ok := d.(protoreflect.ServiceDescriptor)
+	if !ok {
+		return nil, fmt.Errorf("gateway: %s is not a service", desc.ServiceName)
+	}
+	return service, nil
+}
+
+// httpRules reads the google.api.http annotation of a method
+func httpRules(method protoreflect.MethodDescriptor) []Rule {
+	opts, ok := method.Options().(*descriptorpb.MethodOptions)
+	if !ok || opts == nil || !proto.HasExtension(opts, annotations.E_Http) {
+		return nil
+	}
+	annotation, _ := proto.GetExtension(opts, annotations.E_Http).(*annotations.HttpRule)
+	if annotation == nil {
+		return nil
+	}
+
+	var rules []Rule
+	for _, r := range append([]*annotations.HttpRule{annotation}, annotation.GetAdditionalBindings()...) {
+		rule := Rule{Body: r.GetBody(), ResponseBody: r.GetResponseBody()}
+		switch p := r.GetPattern().(type) {
+		case *annotations.HttpRule_Get:
+			rule.Method, rule.Path = http.MethodGet, p.Get
+		case *annotations.HttpRule_Post:
+			rule.Method, rule.Path = http.MethodPost, p.Post
+		case *annotations.HttpRule_Put:
+			rule.Method, rule.Path = http.MethodPut, p.Put
+		case *annotations.HttpRule_Patch:
+			rule.Method, rule.Path = http.MethodPatch, p.Patch
+		case *annotations.HttpRule_Delete:
+			rule.Method, rule.Path = http.MethodDelete, p.Delete
+		case *annotations.HttpRule_Custom:
+			rule.Method, rule.Path = p.Custom.GetKind(), p.Custom.GetPath()
+		default:
+			continue
+		}
+		rules = append(rules, rule)
+	}
+	return rules
+}
+
+// docMessage returns a zero value of the generated Go type of a message, for
+// documentation; nil when the type is not linked in
+func docMessage(desc protoreflect.MessageDescriptor) any {
+	mt, err := protoregistry.GlobalTypes.FindMessageByName(desc.FullName())
+	if err != nil {
+		return nil
+	}
+	return mt.New().Interface()
+}
@@ -0,0 +1,185 @@
+package gateway
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/gofuckbiz/poltergeist"
+)
+
+// =============================================================================
+// GATEWAY TESTS
+// =============================================================================
+
+// healthServer serves "users" and reports how it was reached
+type healthServer struct {
+	healthpb.UnimplementedHealthServer
+}
+
+func (healthServer) Check(ctx context.Context, req *healthpb.HealthCheckRequest) (*healthpb.HealthCheckResponse, error) {
+	if req.GetService() != "users" {
+		return nil, status.Errorf(codes.NotFound, "unknown service %q", req.GetService())
+	}
+	md, _ := metadata.FromIncomingContext(ctx)
+	grpc.SetHeader(ctx, metadata.Pairs("tenant", strings.Join(md.Get("tenant"), ",")))
+	if FromContext(ctx) != nil {
+		grpc.SetTrailer(ctx, metadata.Pairs("route", FromContext(ctx).Route().Path))
+	}
+	return &healthpb.HealthCheckResponse{Status: healthpb.HealthCheckResponse_SERVING}, nil
+}
+
+// healthApp mounts Check under each rule
+func healthApp(t *testing.T, rules ...Rule) *poltergeist.Server {
+	t.Helper()
+	app := poltergeist.New()
+	gw := New(app)
+	for _, rule := range rules {
+		if _, err := gw.Handle(&healthpb.Health_ServiceDesc, healthServer{}, "Check", rule); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return app
+}
+
+func TestGateway_Handle(t *testing.T) {
+	app := healthApp(t,
+		Rule{Method: http.MethodGet, Path: "/v1/health/{service}"},
+		Rule{Method: http.MethodGet, Path: "/v1/health"},
+		Rule{Method: http.MethodPost, Path: "/v1/health", Body: "*"},
+		Rule{Method: http.MethodGet, Path: "/v1/status/:service", ResponseBody: "status"},
+	)
+
+	tests := []struct {
+		name   string
+		method string
+		target string
+		body   string
+		code   int
+		want   string
+	}{
+		{"path variable", http.MethodGet, "/v1/health/users", "", http.StatusOK, `{"status":"SERVING"}`},
+		{"path wins over query", http.MethodGet, "/v1/health/users?service=other", "", http.StatusOK, `{"status":"SERVING"}`},
+		{"query", http.MethodGet, "/v1/health?service=users", "", http.StatusOK, `{"status":"SERVING"}`},
+		{"body", http.MethodPost, "/v1/health", `{"service":"users"}`, http.StatusOK, `{"status":"SERVING"}`},
+		{"unknown body field", http.MethodPost, "/v1/health", `{"nope":1}`, http.StatusBadRequest, ""},
+		{"response body", http.MethodGet, "/v1/status/users", "", http.StatusOK, `"SERVING"`},
+		{"status error", http.MethodGet, "/v1/health/orders", "", http.StatusNotFound, "orders"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.target, strings.NewReader(tt.body))
+			w := httptest.NewRecorder()
+			app.ServeHTTP(w, req)
+			// protojson varies its whitespace
+			if w.Code != tt.code || !strings.Contains(strings.ReplaceAll(w.Body.String(), " ", ""), tt.want) {
+				t.Errorf("%s %s = %d %s, want %d %s", tt.method, tt.target, w.Code, w.Body.String(), tt.code, tt.want)
+			}
+		})
+	}
+}
+
+func TestGateway_Metadata(t *testing.T) {
+	app := healthApp(t, Rule{Method: http.MethodGet, Path: "/v1/health/{service}"})
+	req := httptest.NewRequest(http.MethodGet, "/v1/health/users", nil)
+	req.Header.Set("Grpc-Metadata-Tenant", "t1")
+	req.Header.Set("X-Other", "dropped")
+	w := httptest.NewRecorder()
+	app.ServeHTTP(w, req)
+
+	if got := w.Header().Get(MetadataHeaderPrefix + "tenant"); got != "t1" {
+		t.Errorf("forwarded tenant = %q, want t1", got)
+	}
+	if got := w.Header().Get(TrailerHeaderPrefix + "route"); got != "/v1/health/:service" {
+		t.Errorf("route trailer = %q, want the transcoded route", got)
+	}
+}
+
+func TestGateway_HandleErrors(t *testing.T) {
+	gw := New(poltergeist.New())
+	tests := []struct {
+		name   string
+		method string
+		rule   Rule
+	}{
+		{"streaming method", "Watch", Rule{Method: http.MethodGet, Path: "/v1/watch"}},
+		{"unknown method", "Nope", Rule{Method: http.MethodGet, Path: "/v1/nope"}},
+		{"relative path", "Check", Rule{Method: http.MethodGet, Path: "v1/health"}},
+		{"unknown variable", "Check", Rule{Method: http.MethodGet, Path: "/v1/{name}"}},
+		{"partial segment", "Check", Rule{Method: http.MethodGet, Path: "/v1/health-{service}"}},
+		{"unknown body field", "Check", Rule{Method: http.MethodPost, Path: "/v1/health", Body: "nope"}},
+		{"unsupported HTTP method", "Check", Rule{Method: http.MethodTrace, Path: "/v1/health"}},
+	}
+	for _, tt := range tests {
+		if _, err := gw.Handle(&healthpb.Health_ServiceDesc, healthServer{}, tt.method, tt.rule); err == nil {
+			t.Errorf("%s: Handle succeeded, want an error", tt.name)
+		}
+	}
+
+	// The health proto has no google.api.http annotations
+	routes, err := gw.RegisterService(&healthpb.Health_ServiceDesc, healthServer{})
+	if err != nil || len(routes) != 0 {
+		t.Errorf("RegisterService = %v, %v; want no routes", routes, err)
+	}
+}
+
+func TestGetConfig(t *testing.T) {
+	custom := &Config{MaxBodySize: 1 << 10}
+	cfg := getConfig([]*Config{custom})
+	if !cfg.Marshal.UseProtoNames || cfg.IncomingHeader == nil || cfg.MaxBodySize != 1<<10 {
+		t.Errorf("custom config = %+v, want proto names and a header filter", cfg)
+	}
+	if custom.Marshal.UseProtoNames || custom.IncomingHeader != nil {
+		t.Error("getConfig modified the caller's config")
+	}
+	if cfg := getConfig([]*Config{{DisableProtoNames: true}}); cfg.Marshal.UseProtoNames {
+		t.Error("DisableProtoNames left UseProtoNames on")
+	}
+}
+
+func TestParseTemplate(t *testing.T) {
+	tests := []struct {
+		template string
+		path     string
+		vars     []string
+	}{
+		{"/v1/users/{id}", "/v1/users/:id", []string{"id"}},
+		{"/v1/users/{user.id=*}/posts", "/v1/users/:user.id/posts", []string{"user.id"}},
+		{"/v1/files/{name=**}", "/v1/files/*name", []string{"name"}},
+		{"/v1/users/:id", "/v1/users/:id", []string{"id"}},
+		{"/v1/users", "/v1/users", nil},
+	}
+	for _, tt := range tests {
+		path, vars, err := parseTemplate(tt.template)
+		if err != nil || path != tt.path || !reflect.DeepEqual(vars, tt.vars) {
+			t.Errorf("parseTemplate(%q) = %q, %v, %v; want %q, %v", tt.template, path, vars, err, tt.path, tt.vars)
+		}
+	}
+	for _, bad := range []string{"/v1/{name=**}/tail", "/v1/{name=a/*}", "/v1/{id"} {
+		if _, _, err := parseTemplate(bad); err == nil {
+			t.Errorf("parseTemplate(%q) succeeded, want an error", bad)
+		}
+	}
+}
+
+func TestHTTPStatus(t *testing.T) {
+	for code, want := range map[codes.Code]int{
+		codes.NotFound:         http.StatusNotFound,
+		codes.Unauthenticated:  http.StatusUnauthorized,
+		codes.DeadlineExceeded: http.StatusGatewayTimeout,
+		codes.Code(99):         http.StatusInternalServerError,
+	} {
+		if got := HTTPStatus(code); got != want {
+			t.Errorf("HTTPStatus(%s) = %d, want %d", code, got, want)
+		}
+	}
+}
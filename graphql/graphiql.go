@@ -0,0 +1,52 @@
+package graphql
+
+import (
+	"fmt"
+	"html"
+)
+
+// =============================================================================
+// GRAPHIQL - In-browser IDE
+// =============================================================================
+
+// CDN locations of GraphiQL and its dependencies
+const (
+	graphiQLCDN  = "https://unpkg.com/graphiql@3"
+	reactCDN     = "https://unpkg.com/react@18/umd/react.production.min.js"
+	reactDOMCDN  = "https://unpkg.com/react-dom@18/umd/react-dom.production.min.js"
+	graphqlWsCDN = "https://unpkg.com/graphql-ws@5/umd/graphql-ws.min.js"
+)
+
+// graphiQLHTML returns the GraphiQL page for an endpoint; subscriptions use
+// a graphql-transport-ws client on the same path
+func graphiQLHTML(path string, subscriptions bool) string {
+	path = html.EscapeString(path)
+	wsClient := "undefined"
+	if subscriptions {
+		wsClient = "graphqlWs.createClient({ url: (location.protocol === 'https:' ? 'wss://' : 'ws://') + location.host + endpoint })"
+	}
+
+	return fmt.Sprintf(`<!DOCTYPE html>
+<html lang="en">
+<head>
+    <meta charset="UTF-8">
+    <meta name="viewport" content="width=device-width, initial-scale=1.0">
+    <title>GraphiQL</title>
+    <link rel="stylesheet" href="%s/graphiql.min.css">
+    <style>body { margin: 0; height: 100vh; } #graphiql { height: 100vh; }</style>
+</head>
+<body>
+    <div id="graphiql">Loading...</div>
+    <script src="%s"></script>
+    <script src="%s"></script>
+    <script src="%s"></script>
+    <script src="%s/graphiql.min.js"></script>
+    <script>
+        const endpoint = '%s';
+        const fetcher = GraphiQL.createFetcher({ url: endpoint, wsClient: %s });
+        ReactDOM.createRoot(document.getElementById('graphiql'))
+            .render(React.createElement(GraphiQL, { fetcher: fetcher }));
+    </script>
+</body>
+</html>`, graphiQLCDN, reactCDN, reactDOMCDN, graphqlWsCDN, graphiQLCDN, path, wsClient)
+}
@@ -0,0 +1,266 @@
+// Package graphql serves a graphql-go schema on a Poltergeist server:
+//
+//	schema, _ := gql.NewSchema(gql.SchemaConfig{Query: queryType, Subscription: subType})
+//	graphql.Mount(app, schema)
+//
+// Queries and mutations are accepted as POST (JSON or application/graphql)
+// and GET (queries only). Browsers opening the endpoint get GraphiQL.
+// Subscriptions use the same path over the server's WebSocket
+// infrastructure, speaking both graphql-transport-ws and the legacy
+// graphql-ws protocol, so connection metrics, tracing and pipeline events
+// apply to them as to any other WebSocket route.
+//
+// Resolvers reach the Poltergeist request through their context, including
+// values set by auth middleware:
+//
+//	Resolve: func(p gql.ResolveParams) (any, error) {
+//	    user, _ := graphql.Value(p.Context, "username")
+//	    ...
+//	}
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	gql "github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/gqlerrors"
+	"github.com/graphql-go/graphql/language/ast"
+	"github.com/graphql-go/graphql/language/parser"
+
+	"github.com/gofuckbiz/poltergeist"
+)
+
+// =============================================================================
+// CONFIGURATION
+// =============================================================================
+
+// Config holds GraphQL endpoint configuration
+type Config struct {
+	Path                 string                // Mount path (default: "/graphql")
+	DisableGraphiQL      bool                  // Don't serve GraphiQL to browsers on GET
+	DisableSubscriptions bool                  // Reject WebSocket subscriptions on Path
+	MaxBodySize          int64                 // Max POST body size (default: 1MB)
+	InitTimeout          time.Duration         // Time a WebSocket client has to send connection_init (default: 10s)
+	KeepAlive            time.Duration         // Keep-alive interval of the legacy graphql-ws protocol (default: 15s)
+	WS                   *poltergeist.WSConfig // WebSocket options (default: poltergeist.DefaultWSConfig())
+
+	// Context adds values to the context resolvers receive
+	Context func(c *poltergeist.Context, ctx context.Context) context.Context
+
+	// OnConnect validates the connection_init payload of a WebSocket client
+	// (e.g. an auth token). Returning an error rejects the connection; values
+	// set on c are visible to the connection's resolvers.
+	OnConnect func(c *poltergeist.Context, payload map[string]any) error
+}
+
+// DefaultConfig returns default GraphQL configuration
+func DefaultConfig() *Config {
+	return &Config{
+		Path:        "/graphql",
+		MaxBodySize: 1 << 20,
+		InitTimeout: 10 * time.Second,
+		KeepAlive:   15 * time.Second,
+	}
+}
+
+// Request is a GraphQL request body
+type Request struct {
+	Query         string         `json:"query"`
+	Variables     map[string]any `json:"variables,omitempty"`
+	OperationName string         `json:"operationName,omitempty"`
+}
+
+// =============================================================================
+// MOUNTING
+// =============================================================================
+
+// endpoint serves one schema
+type endpoint struct {
+	server *poltergeist.Server
+	schema gql.Schema
+	config *Config
+	page   string
+}
+
+// Mount serves schema at config.Path and returns the POST route
+func Mount(server *poltergeist.Server, schema gql.Schema, config ...*Config) *poltergeist.Route {
+	cfg := getConfig(config)
+	e := &endpoint{
+		server: server,
+		schema: schema,
+		config: cfg,
+		page:   graphiQLHTML(cfg.Path, !cfg.DisableSubscriptions),
+	}
+
+	server.GET(cfg.Path, e.get).
+		Desc("GraphQL queries (GraphiQL in browsers, subscriptions over WebSocket)").
+		Tag("GraphQL").
+		Response(gql.Result{})
+
+	return server.POST(cfg.Path, e.post).
+		Desc("GraphQL queries and mutations").
+		Tag("GraphQL").
+		Request(Request{}).
+		Response(gql.Result{})
+}
+
+// getConfig copies the config and fills unset fields with defaults
+func getConfig(config []*Config) *Config {
+	defaults := DefaultConfig()
+	if len(config) == 0 || config[0] == nil {
+		return defaults
+	}
+
+	cfg := *config[0]
+	if cfg.Path == "" {
+		cfg.Path = defaults.Path
+	}
+	if cfg.MaxBodySize <= 0 {
+		cfg.MaxBodySize = defaults.MaxBodySize
+	}
+	if cfg.InitTimeout <= 0 {
+		cfg.InitTimeout = defaults.InitTimeout
+	}
+	if cfg.KeepAlive <= 0 {
+		cfg.KeepAlive = defaults.KeepAlive
+	}
+	return &cfg
+}
+
+// get serves GraphiQL, WebSocket subscriptions or a query in the URL
+func (e *endpoint) get(c *poltergeist.Context) error {
+	if c.IsWebSocket() {
+		if e.config.DisableSubscriptions {
+			return poltergeist.ErrBadRequest.WithMessage("GraphQL subscriptions are disabled")
+		}
+		return e.serveWS(c)
+	}
+
+	req := Request{Query: c.Query("query"), OperationName: c.Query("operationName")}
+	if req.Query == "" {
+		if !e.config.DisableGraphiQL && strings.Contains(c.Header("Accept"), "text/html") {
+			return c.HTML(http.StatusOK, e.page)
+		}
+		return requestError(c, "missing query")
+	}
+	if vars := c.Query("variables"); vars != "" {
+		if err := json.Unmarshal([]byte(vars), &req.Variables); err != nil {
+			return requestError(c, "variables must be a JSON object")
+		}
+	}
+
+	op, err := operationType(req)
+	if err == nil && op != ast.OperationTypeQuery {
+		c.SetHeader("Allow", http.MethodPost)
+		return c.JSON(http.StatusMethodNotAllowed, errorResult(op+" operations require POST"))
+	}
+	return c.JSON(http.StatusOK, e.execute(e.context(c, c.Request.Context()), req))
+}
+
+// post executes a query or mutation
+func (e *endpoint) post(c *poltergeist.Context) error {
+	body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, e.config.MaxBodySize))
+	if err != nil {
+		return requestError(c, "request body too large")
+	}
+
+	var req Request
+	if strings.HasPrefix(c.ContentType(), "application/graphql") {
+		req.Query = string(body)
+	} else if err := json.Unmarshal(body, &req); err != nil {
+		return requestError(c, "invalid JSON body")
+	}
+	if req.Query == "" {
+		return requestError(c, "missing query")
+	}
+
+	if op, err := operationType(req); err == nil && op == ast.OperationTypeSubscription {
+		return requestError(c, "subscriptions require a WebSocket connection")
+	}
+	return c.JSON(http.StatusOK, e.execute(e.context(c, c.Request.Context()), req))
+}
+
+// execute runs a query or mutation
+func (e *endpoint) execute(ctx context.Context, req Request) *gql.Result {
+	return gql.Do(gql.Params{
+		Schema:         e.schema,
+		RequestString:  req.Query,
+		VariableValues: req.Variables,
+		OperationName:  req.OperationName,
+		Context:        ctx,
+	})
+}
+
+// =============================================================================
+// CONTEXT PROPAGATION
+// =============================================================================
+
+type contextKey struct{}
+
+// context builds the resolver context for a request
+func (e *endpoint) context(c *poltergeist.Context, parent context.Context) context.Context {
+	ctx := context.WithValue(parent, contextKey{}, c)
+	if e.config.Context != nil {
+		ctx = e.config.Context(c, ctx)
+	}
+	return ctx
+}
+
+// FromContext returns the request a resolver runs for; for subscriptions it
+// is the WebSocket upgrade request
+func FromContext(ctx context.Context) *poltergeist.Context {
+	c, _ := ctx.Value(contextKey{}).(*poltergeist.Context)
+	return c
+}
+
+// Value returns a value stored on the request, e.g. by auth middleware
+// ("username", "token", "api_key") or OnConnect
+func Value(ctx context.Context, key string) (any, bool) {
+	if c := FromContext(ctx); c != nil {
+		return c.Get(key)
+	}
+	return nil, false
+}
+
+// =============================================================================
+// HELPERS
+// =============================================================================
+
+// errNoOperation is returned when the document has no matching operation
+var errNoOperation = errors.New("graphql: no matching operation")
+
+// operationType returns "query", "mutation" or "subscription" for the
+// operation req selects; documents that do not parse are left for graphql-go
+// to report
+func operationType(req Request) (string, error) {
+	doc, err := parser.Parse(parser.ParseParams{Source: req.Query})
+	if err != nil {
+		return "", err
+	}
+	for _, def := range doc.Definitions {
+		op, ok := def.(*ast.OperationDefinition)
+		if !ok {
+			continue
+		}
+		if req.OperationName == "" || (op.Name != nil && op.Name.Value == req.OperationName) {
+			return op.Operation, nil
+		}
+	}
+	return "", errNoOperation
+}
+
+// errorResult wraps a message in a GraphQL error response
+func errorResult(message string) *gql.Result {
+	return &gql.Result{Errors: []gqlerrors.FormattedError{{Message: message}}}
+}
+
+// requestError rejects a malformed request with a GraphQL-shaped 400
+func requestError(c *poltergeist.Context, message string) error {
+	return c.JSON(http.StatusBadRequest, errorResult(message))
+}
@@ -0,0 +1,214 @@
+package graphql
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	gql "github.com/graphql-go/graphql"
+
+	"github.com/gofuckbiz/poltergeist"
+	"github.com/gofuckbiz/poltergeist/poltergeisttest"
+)
+
+// =============================================================================
+// GRAPHQL TESTS
+// =============================================================================
+
+// testSchema has a query reading the request, a mutation and a subscription
+// counting to three
+func testSchema(t *testing.T) gql.Schema {
+	t.Helper()
+	schema, err := gql.NewSchema(gql.SchemaConfig{
+		Query: gql.NewObject(gql.ObjectConfig{Name: "Query", Fields: gql.Fields{
+			"hello": &gql.Field{
+				Type: gql.String,
+				Args: gql.FieldConfigArgument{"name": &gql.ArgumentConfig{Type: gql.String}},
+				Resolve: func(p gql.ResolveParams) (any, error) {
+					name, _ := p.Args["name"].(string)
+					if user, ok := Value(p.Context, "username"); ok {
+						name = user.(string)
+					}
+					return "hello " + name, nil
+				},
+			},
+		}}),
+		Mutation: gql.NewObject(gql.ObjectConfig{Name: "Mutation", Fields: gql.Fields{
+			"bump": &gql.Field{Type: gql.Int, Resolve: func(gql.ResolveParams) (any, error) { return 1, nil }},
+		}}),
+		Subscription: gql.NewObject(gql.ObjectConfig{Name: "Subscription", Fields: gql.Fields{
+			"count": &gql.Field{
+				Type:    gql.Int,
+				Resolve: func(p gql.ResolveParams) (any, error) { return p.Source, nil },
+				Subscribe: func(p gql.ResolveParams) (any, error) {
+					ch := make(chan any)
+					go func() {
+						defer close(ch)
+						for i := 1; i <= 3; i++ {
+							select {
+							case ch <- i:
+							case <-p.Context.Done():
+								return
+							}
+						}
+					}()
+					return ch, nil
+				},
+			},
+		}}),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return schema
+}
+
+func TestMount_HTTP(t *testing.T) {
+	app := poltergeist.New()
+	Mount(app, testSchema(t), &Config{MaxBodySize: 256})
+	pt := poltergeisttest.New(app)
+
+	pt.POST("/graphql").JSON(Request{Query: `query($n: String) { hello(name: $n) }`, Variables: map[string]any{"n": "ada"}}).
+		Expect(t).Status(http.StatusOK).JSONPath("$.data.hello", "hello ada")
+	pt.POST("/graphql").Body([]byte(`{ hello(name: "bob") }`), "application/graphql").
+		Expect(t).Status(http.StatusOK).JSONPath("$.data.hello", "hello bob")
+	pt.GET("/graphql").WithQuery("query", `{ hello(name: "cy") }`).
+		Expect(t).Status(http.StatusOK).JSONPath("$.data.hello", "hello cy")
+	pt.POST("/graphql").JSON(Request{Query: `mutation { bump }`}).
+		Expect(t).Status(http.StatusOK).JSONPath("$.data.bump", 1)
+
+	pt.GET("/graphql").WithQuery("query", `mutation { bump }`).
+		Expect(t).Status(http.StatusMethodNotAllowed).Header("Allow", http.MethodPost)
+	pt.POST("/graphql").JSON(Request{Query: `subscription { count }`}).
+		Expect(t).Status(http.StatusBadRequest)
+	pt.POST("/graphql").JSON(Request{Query: `{ hello(name: "` + strings.Repeat("x", 256) + `") }`}).
+		Expect(t).Status(http.StatusBadRequest).JSONPath("$.errors[0].message", "request body too large")
+	pt.POST("/graphql").JSON(Request{}).
+		Expect(t).Status(http.StatusBadRequest).JSONPath("$.errors[0].message", "missing query")
+}
+
+func TestMount_Config(t *testing.T) {
+	config := &Config{Path: "/gql"}
+	app := poltergeist.New()
+	Mount(app, testSchema(t), config)
+
+	if config.MaxBodySize != 0 || config.InitTimeout != 0 {
+		t.Errorf("caller's config modified: %+v", config)
+	}
+	// A partial config keeps GraphiQL and subscriptions on
+	poltergeisttest.New(app).GET("/gql").WithHeader("Accept", "text/html").
+		Expect(t).Status(http.StatusOK).BodyContains("graphiql")
+	url := "ws" + strings.TrimPrefix(poltergeisttest.New(app).URL(t), "http") + "/gql"
+	if conn, _, err := websocket.DefaultDialer.Dial(url, nil); err != nil {
+		t.Errorf("subscriptions refused: %v", err)
+	} else {
+		conn.Close()
+	}
+
+	app = poltergeist.New()
+	Mount(app, testSchema(t), &Config{DisableGraphiQL: true, DisableSubscriptions: true})
+	pt := poltergeisttest.New(app)
+	pt.GET("/graphql").WithHeader("Accept", "text/html").
+		Expect(t).Status(http.StatusBadRequest)
+	url = "ws" + strings.TrimPrefix(pt.URL(t), "http") + "/graphql"
+	if _, resp, err := websocket.DefaultDialer.Dial(url, nil); err == nil || resp == nil || resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("disabled subscriptions: dial error %v, want a 400", err)
+	}
+}
+
+// dialGraphQL opens a subscription connection speaking subprotocol
+func dialGraphQL(t *testing.T, app *poltergeist.Server, subprotocol string) *poltergeisttest.WSClient {
+	t.Helper()
+	return poltergeisttest.New(app).WebSocket(t, "/graphql", http.Header{"Sec-WebSocket-Protocol": {subprotocol}})
+}
+
+func TestMount_Subscriptions(t *testing.T) {
+	app := poltergeist.New().UseLogger(poltergeist.NopLogger)
+	Mount(app, testSchema(t), &Config{
+		OnConnect: func(c *poltergeist.Context, payload map[string]any) error {
+			if payload["token"] != "secret" {
+				return errors.New("bad token")
+			}
+			c.Set("username", "ada")
+			return nil
+		},
+	})
+
+	ws := dialGraphQL(t, app, ProtocolTransportWS)
+	var msg message
+	ws.SendJSON(message{Type: "connection_init", Payload: []byte(`{"token":"secret"}`)}).ExpectJSON(&msg)
+	if msg.Type != "connection_ack" {
+		t.Fatalf("init answered with %+v, want connection_ack", msg)
+	}
+
+	ws.SendJSON(message{ID: "q", Type: "subscribe", Payload: encode(Request{Query: `{ hello }`})})
+	ws.ExpectJSON(&msg)
+	if msg.Type != "next" || string(msg.Payload) != `{"data":{"hello":"hello ada"}}` {
+		t.Errorf("query result = %s %s, want hello ada from OnConnect", msg.Type, msg.Payload)
+	}
+	ws.ExpectJSON(&msg)
+	if msg.Type != "complete" || msg.ID != "q" {
+		t.Errorf("after the query: %+v, want complete", msg)
+	}
+
+	ws.SendJSON(message{ID: "s", Type: "subscribe", Payload: encode(Request{Query: `subscription { count }`})})
+	for i := 1; i <= 3; i++ {
+		ws.ExpectJSON(&msg)
+		if want := `{"data":{"count":` + string(rune('0'+i)) + `}}`; msg.Type != "next" || string(msg.Payload) != want {
+			t.Errorf("event %d = %s %s, want %s", i, msg.Type, msg.Payload, want)
+		}
+	}
+	ws.ExpectJSON(&msg)
+	if msg.Type != "complete" || msg.ID != "s" {
+		t.Errorf("after the stream: %+v, want complete", msg)
+	}
+
+	// Rejected init payloads close the connection with 4403
+	ws = dialGraphQL(t, app, ProtocolTransportWS)
+	ws.SendJSON(message{Type: "connection_init", Payload: []byte(`{"token":"guess"}`)})
+	expectClose(t, ws, closeForbidden)
+}
+
+func TestMount_LegacyProtocol(t *testing.T) {
+	app := poltergeist.New()
+	Mount(app, testSchema(t))
+
+	ws := dialGraphQL(t, app, ProtocolLegacyWS)
+	var msg message
+	ws.SendJSON(message{Type: "connection_init"})
+	for _, want := range []string{"connection_ack", "ka"} {
+		if ws.ExpectJSON(&msg); msg.Type != want {
+			t.Fatalf("got %+v, want %s", msg, want)
+		}
+	}
+	ws.SendJSON(message{ID: "1", Type: "start", Payload: encode(Request{Query: `{ hello(name: "old") }`})})
+	if ws.ExpectJSON(&msg); msg.Type != "data" || string(msg.Payload) != `{"data":{"hello":"hello old"}}` {
+		t.Errorf("legacy result = %s %s", msg.Type, msg.Payload)
+	}
+}
+
+func TestMount_InitTimeout(t *testing.T) {
+	app := poltergeist.New()
+	Mount(app, testSchema(t), &Config{InitTimeout: 20 * time.Millisecond})
+
+	expectClose(t, dialGraphQL(t, app, ProtocolTransportWS), closeInitTimeout)
+}
+
+// expectClose reads until the server closes the connection with code
+func expectClose(t *testing.T, ws *poltergeisttest.WSClient, code int) {
+	t.Helper()
+	ws.Conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	for {
+		_, _, err := ws.Conn.ReadMessage()
+		if err == nil {
+			continue
+		}
+		if !websocket.IsCloseError(err, code) {
+			t.Errorf("connection ended with %v, want close code %d", err, code)
+		}
+		return
+	}
+}
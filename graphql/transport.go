@@ -0,0 +1,304 @@
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	gql "github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/language/ast"
+
+	"github.com/gofuckbiz/poltergeist"
+)
+
+// =============================================================================
+// WEBSOCKET TRANSPORT - graphql-transport-ws and legacy graphql-ws
+// =============================================================================
+
+// Supported WebSocket subprotocols
+const (
+	ProtocolTransportWS = "graphql-transport-ws" // graphql-ws library
+	ProtocolLegacyWS    = "graphql-ws"           // subscriptions-transport-ws (Apollo)
+)
+
+// Close codes defined by graphql-transport-ws
+const (
+	closeInvalidMessage   = 4400
+	closeUnauthorized     = 4401
+	closeForbidden        = 4403
+	closeInitTimeout      = 4408
+	closeSubscriberExists = 4409
+	closeTooManyInits     = 4429
+)
+
+// message is a protocol frame in either protocol
+type message struct {
+	ID      string          `json:"id,omitempty"`
+	Type    string          `json:"type"`
+	Payload json.RawMessage `json:"payload,omitempty"`
+}
+
+// protocol names the message types that differ between the two protocols
+type protocol struct {
+	subscribe string // client starts an operation
+	stop      string // client stops an operation
+	next      string // server sends a result
+	legacy    bool
+}
+
+var (
+	transportWS = protocol{subscribe: "subscribe", stop: "complete", next: "next"}
+	legacyWS    = protocol{subscribe: "start", stop: "stop", next: "data", legacy: true}
+)
+
+// serveWS upgrades the request and runs a session until the client leaves
+func (e *endpoint) serveWS(c *poltergeist.Context) error {
+	cfg := poltergeist.DefaultWSConfig()
+	if e.config.WS != nil {
+		copied := *e.config.WS
+		cfg = &copied
+	}
+	cfg.Subprotocols = []string{ProtocolTransportWS, ProtocolLegacyWS}
+
+	s := &session{
+		endpoint:   e,
+		c:          c,
+		operations: make(map[string]context.CancelFunc),
+	}
+	onConnect := cfg.OnConnect
+	cfg.OnConnect = func(conn *poltergeist.WSConn) {
+		s.mu.Lock()
+		s.conn = conn
+		s.mu.Unlock()
+		if onConnect != nil {
+			onConnect(conn)
+		}
+	}
+	s.ctx, s.cancel = context.WithCancel(e.context(c, c.Request.Context()))
+	defer s.cancel()
+
+	timer := time.AfterFunc(e.config.InitTimeout, s.checkInit)
+	defer timer.Stop()
+
+	return e.server.WebSocketHandler(s.handle, cfg)(c)
+}
+
+// =============================================================================
+// SESSION - One subscription connection
+// =============================================================================
+
+// session tracks the operations of one WebSocket connection
+type session struct {
+	endpoint *endpoint
+	c        *poltergeist.Context
+	ctx      context.Context // cancelled when the connection closes
+	cancel   context.CancelFunc
+
+	mu         sync.Mutex
+	conn       *poltergeist.WSConn // set once the handshake completes
+	proto      protocol
+	acked      bool
+	operations map[string]context.CancelFunc
+}
+
+// checkInit closes connections that never sent connection_init
+func (s *session) checkInit() {
+	s.mu.Lock()
+	acked, conn := s.acked, s.conn
+	s.mu.Unlock()
+	if !acked && conn != nil {
+		conn.CloseWithReason(closeInitTimeout, "Connection initialisation timeout")
+	}
+}
+
+// handle processes one client frame
+func (s *session) handle(conn *poltergeist.WSConn, _ int, data []byte) {
+	var msg message
+	if err := json.Unmarshal(data, &msg); err != nil || msg.Type == "" {
+		conn.CloseWithReason(closeInvalidMessage, "Invalid message received")
+		return
+	}
+
+	s.mu.Lock()
+	if conn.Subprotocol() == ProtocolLegacyWS {
+		s.proto = legacyWS
+	} else {
+		s.proto = transportWS
+	}
+	proto, acked := s.proto, s.acked
+	s.mu.Unlock()
+
+	switch msg.Type {
+	case "connection_init":
+		s.init(conn, msg, proto, acked)
+	case "ping":
+		conn.SendJSON(message{Type: "pong", Payload: msg.Payload})
+	case "pong":
+	case "connection_terminate":
+		conn.Close()
+	case proto.subscribe:
+		if !acked {
+			conn.CloseWithReason(closeUnauthorized, "Unauthorized")
+			return
+		}
+		s.start(conn, msg, proto)
+	case proto.stop:
+		s.stop(msg.ID)
+	default:
+		conn.CloseWithReason(closeInvalidMessage, "Unknown message type "+msg.Type)
+	}
+}
+
+// init acknowledges the connection after OnConnect accepts its payload
+func (s *session) init(conn *poltergeist.WSConn, msg message, proto protocol, acked bool) {
+	if acked {
+		conn.CloseWithReason(closeTooManyInits, "Too many initialisation requests")
+		return
+	}
+
+	var payload map[string]any
+	if len(msg.Payload) > 0 {
+		json.Unmarshal(msg.Payload, &payload)
+	}
+	if onConnect := s.endpoint.config.OnConnect; onConnect != nil {
+		if err := onConnect(s.c, payload); err != nil {
+			if proto.legacy {
+				conn.SendJSON(message{Type: "connection_error", Payload: encode(map[string]string{"message": err.Error()})})
+				conn.Close()
+				return
+			}
+			conn.CloseWithReason(closeForbidden, "Forbidden")
+			return
+		}
+	}
+
+	s.mu.Lock()
+	s.acked = true
+	s.mu.Unlock()
+	conn.SendJSON(message{Type: "connection_ack"})
+
+	if proto.legacy {
+		conn.SendJSON(message{Type: "ka"})
+		go s.keepAlive(conn)
+	}
+}
+
+// keepAlive sends legacy "ka" frames until the connection closes
+func (s *session) keepAlive(conn *poltergeist.WSConn) {
+	ticker := time.NewTicker(s.endpoint.config.KeepAlive)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.ctx.Done():
+			return
+		case <-ticker.C:
+			if conn.SendJSON(message{Type: "ka"}) != nil {
+				return
+			}
+		}
+	}
+}
+
+// start runs an operation: subscriptions stream results, queries and
+// mutations send one
+func (s *session) start(conn *poltergeist.WSConn, msg message, proto protocol) {
+	var req Request
+	if msg.ID == "" || json.Unmarshal(msg.Payload, &req) != nil {
+		conn.CloseWithReason(closeInvalidMessage, "Invalid subscribe message")
+		return
+	}
+
+	s.mu.Lock()
+	if _, exists := s.operations[msg.ID]; exists {
+		s.mu.Unlock()
+		conn.CloseWithReason(closeSubscriberExists, "Subscriber for "+msg.ID+" already exists")
+		return
+	}
+	ctx, cancel := context.WithCancel(s.ctx)
+	s.operations[msg.ID] = cancel
+	s.mu.Unlock()
+
+	go s.run(ctx, conn, msg.ID, req, proto)
+}
+
+// run executes an operation and reports its results until it ends or is stopped
+func (s *session) run(ctx context.Context, conn *poltergeist.WSConn, id string, req Request, proto protocol) {
+	defer s.finish(id)
+
+	if op, err := operationType(req); err != nil || op != ast.OperationTypeSubscription {
+		s.send(conn, id, proto, s.endpoint.execute(ctx, req))
+		s.complete(ctx, conn, id)
+		return
+	}
+
+	results := gql.Subscribe(gql.Params{
+		Schema:         s.endpoint.schema,
+		RequestString:  req.Query,
+		VariableValues: req.Variables,
+		OperationName:  req.OperationName,
+		Context:        ctx,
+	})
+	for result := range results {
+		if ctx.Err() != nil {
+			continue // stopped: drain so the executor can exit
+		}
+		if !s.send(conn, id, proto, result) {
+			s.stop(id)
+		}
+	}
+	s.complete(ctx, conn, id)
+}
+
+// send delivers one result; results without data (parse or validation
+// failures) are sent as an error frame
+func (s *session) send(conn *poltergeist.WSConn, id string, proto protocol, result *gql.Result) bool {
+	msg := message{ID: id, Type: proto.next, Payload: encode(result)}
+	if result.Data == nil && len(result.Errors) > 0 {
+		msg.Type = "error"
+		if !proto.legacy {
+			msg.Payload = encode(result.Errors)
+		}
+	}
+	if err := conn.SendJSON(msg); err != nil {
+		s.c.Logger().Warn("graphql subscription send failed", "id", id, "error", err)
+		return false
+	}
+	return true
+}
+
+// complete tells the client an operation ended, unless the client stopped it
+func (s *session) complete(ctx context.Context, conn *poltergeist.WSConn, id string) {
+	if ctx.Err() == nil {
+		conn.SendJSON(message{ID: id, Type: "complete"})
+	}
+}
+
+// stop cancels an operation
+func (s *session) stop(id string) {
+	s.mu.Lock()
+	cancel := s.operations[id]
+	s.mu.Unlock()
+	if cancel != nil {
+		cancel()
+	}
+}
+
+// finish forgets an operation once its goroutine exits
+func (s *session) finish(id string) {
+	s.mu.Lock()
+	if cancel := s.operations[id]; cancel != nil {
+		cancel()
+		delete(s.operations, id)
+	}
+	s.mu.Unlock()
+}
+
+// encode marshals a payload, falling back to null
+func encode(v any) json.RawMessage {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return json.RawMessage("null")
+	}
+	return data
+}
@@ -3,6 +3,7 @@ package poltergeist
 import (
 	"context"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -16,8 +17,9 @@ type BaseHub struct {
 	mu       sync.RWMutex
 	rooms    map[string]map[string]bool // room -> set of client IDs
 	running  bool
-	shutdown chan struct{} // Graceful shutdown signal
-	done     chan struct{} // Shutdown complete signal
+	shutdown chan struct{}                 // Graceful shutdown signal
+	done     chan struct{}                 // Shutdown complete signal
+	events   atomic.Pointer[EventPipeline] // server pipeline (metrics, tracing)
 }
 
 // newBaseHub creates a new BaseHub
@@ -50,6 +52,16 @@ func (h *BaseHub) ShutdownWithTimeout(timeout time.Duration) error {
 	return h.Shutdown(ctx)
 }
 
+// attach binds the hub to the pipeline of the first server it is used with
+func (h *BaseHub) attach(p *EventPipeline) {
+	h.events.CompareAndSwap(nil, p)
+}
+
+// pipeline returns the attached pipeline (nil before first use)
+func (h *BaseHub) pipeline() *EventPipeline {
+	return h.events.Load()
+}
+
 // shutdownChan returns the shutdown channel for select statements
 func (h *BaseHub) shutdownChan() <-chan struct{} {
 	return h.shutdown
@@ -84,17 +96,24 @@ func (h *BaseHub) removeFromRoom(clientID, room string) {
 	}
 }
 
-// removeFromAllRooms removes a client from all rooms
-func (h *BaseHub) removeFromAllRooms(clientID string) {
+// removeFromAllRooms removes a client from all rooms, returning the rooms it
+// was in
+func (h *BaseHub) removeFromAllRooms(clientID string) []string {
 	h.mu.Lock()
 	defer h.mu.Unlock()
 
+	var left []string
 	for room, clients := range h.rooms {
+		if !clients[clientID] {
+			continue
+		}
+		left = append(left, room)
 		delete(clients, clientID)
 		if len(clients) == 0 {
 			delete(h.rooms, room)
 		}
 	}
+	return left
 }
 
 // getRoomClientIDs returns all client IDs in a room
@@ -114,6 +133,32 @@ func (h *BaseHub) getRoomClientIDs(room string) []string {
 	return ids
 }
 
+// clientRooms returns the rooms a client is in
+func (h *BaseHub) clientRooms(clientID string) []string {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	var rooms []string
+	for room, clients := range h.rooms {
+		if clients[clientID] {
+			rooms = append(rooms, room)
+		}
+	}
+	return rooms
+}
+
+// roomSizes returns the number of clients in each room
+func (h *BaseHub) roomSizes() map[string]int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	sizes := make(map[string]int, len(h.rooms))
+	for room, clients := range h.rooms {
+		sizes[room] = len(clients)
+	}
+	return sizes
+}
+
 // roomCount returns the number of clients in a room
 func (h *BaseHub) roomCount(room string) int {
 	h.mu.RLock()
@@ -0,0 +1,268 @@
+package poltergeist
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// =============================================================================
+// I18N - Message catalogs, locale negotiation and translation
+// =============================================================================
+
+// I18n holds message catalogs by language tag. Lookups fall back from a
+// regional tag to its base language ("pt-BR" to "pt") and then to the
+// fallback language; a missing key translates to itself.
+type I18n struct {
+	mu       sync.RWMutex
+	fallback string
+	tags     []string                     // supported tags, in the order added
+	catalogs map[string]map[string]string // lowercased tag -> key -> message
+}
+
+// NewI18n creates an empty catalog set with a fallback language
+//
+//	bundle := poltergeist.NewI18n("en").
+//	    Add("en", map[string]string{"greeting": "Hello, %s!"}).
+//	    Add("de", map[string]string{"greeting": "Hallo, %s!", "Not Found": "Nicht gefunden"})
+func NewI18n(fallback string) *I18n {
+	return &I18n{
+		fallback: fallback,
+		catalogs: make(map[string]map[string]string),
+	}
+}
+
+// Add merges messages into the catalog for lang (chainable)
+func (i *I18n) Add(lang string, messages map[string]string) *I18n {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	key := strings.ToLower(lang)
+	catalog, ok := i.catalogs[key]
+	if !ok {
+		catalog = make(map[string]string, len(messages))
+		i.catalogs[key] = catalog
+		i.tags = append(i.tags, lang)
+	}
+	for k, v := range messages {
+		catalog[k] = v
+	}
+	return i
+}
+
+// LoadFS loads the JSON catalogs in fsys matching pattern, one file per
+// language named after its tag (locales/de.json, locales/pt-BR.json).
+// Nested objects become dotted keys: {"errors": {"auth": "..."}} is
+// "errors.auth".
+//
+//	//go:embed locales/*.json
+//	var locales embed.FS
+//
+//	err := bundle.LoadFS(locales, "locales/*.json")
+func (i *I18n) LoadFS(fsys fs.FS, pattern string) error {
+	names, err := fs.Glob(fsys, pattern)
+	if err != nil {
+		return err
+	}
+	for _, name := range names {
+		data, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return err
+		}
+		var tree map[string]any
+		if err := json.Unmarshal(data, &tree); err != nil {
+			return fmt.Errorf("i18n: %s: %w", name, err)
+		}
+		messages := make(map[string]string)
+		flattenMessages("", tree, messages)
+		i.Add(strings.TrimSuffix(path.Base(name), path.Ext(name)), messages)
+	}
+	return nil
+}
+
+// flattenMessages turns nested catalog objects into dotted keys
+func flattenMessages(prefix string, tree map[string]any, out map[string]string) {
+	for key, value := range tree {
+		if prefix != "" {
+			key = prefix + "." + key
+		}
+		switch v := value.(type) {
+		case string:
+			out[key] = v
+		case map[string]any:
+			flattenMessages(key, v, out)
+		default:
+			out[key] = fmt.Sprint(v)
+		}
+	}
+}
+
+// Languages returns the supported language tags in the order added
+func (i *I18n) Languages() []string {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+	return append([]string(nil), i.tags...)
+}
+
+// Fallback returns the language used when nothing else matches
+func (i *I18n) Fallback() string {
+	return i.fallback
+}
+
+// Match returns the supported tag for lang: an exact match, its base
+// language ("en" for "en-US"), or a regional variant of it ("en-GB" for
+// "en"); "" when unsupported
+func (i *I18n) Match(lang string) string {
+	lang = strings.ToLower(strings.TrimSpace(lang))
+	if lang == "" {
+		return ""
+	}
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+	base, _, _ := strings.Cut(lang, "-")
+	var variant string
+	for _, tag := range i.tags {
+		lower := strings.ToLower(tag)
+		switch {
+		case lower == lang:
+			return tag
+		case lower == base:
+			variant = tag
+		case variant == "" && strings.HasPrefix(lower, base+"-"):
+			variant = tag
+		}
+	}
+	return variant
+}
+
+// Negotiate picks the best supported language for an Accept-Language
+// header by q-value, or the fallback
+func (i *I18n) Negotiate(acceptLanguage string) string {
+	type weighted struct {
+		tag string
+		q   float64
+	}
+	var ranges []weighted
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		q := 1.0
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+		if tag = strings.TrimSpace(tag); tag != "" && q > 0 {
+			ranges = append(ranges, weighted{tag, q})
+		}
+	}
+	sort.SliceStable(ranges, func(a, b int) bool { return ranges[a].q > ranges[b].q })
+
+	for _, r := range ranges {
+		if r.tag == "*" {
+			break
+		}
+		if tag := i.Match(r.tag); tag != "" {
+			return tag
+		}
+	}
+	return i.fallback
+}
+
+// Translate returns the message for key in lang, formatted with args
+// (fmt verbs) when given
+func (i *I18n) Translate(lang, key string, args ...any) string {
+	message, ok := i.lookup(lang, key)
+	if !ok {
+		message = key
+	}
+	return formatMessage(message, args)
+}
+
+// formatMessage applies args to a message. Taking a slice keeps vet from
+// treating T and Translate as printf wrappers, since keys aren't formats.
+func formatMessage(message string, args []any) string {
+	if len(args) == 0 {
+		return message
+	}
+	return fmt.Sprintf(message, args...)
+}
+
+// lookup finds key in lang, its base language, then the fallback
+func (i *I18n) lookup(lang, key string) (string, bool) {
+	lang = strings.ToLower(lang)
+	base, _, _ := strings.Cut(lang, "-")
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+	for _, tag := range [...]string{lang, base, strings.ToLower(i.fallback)} {
+		if message, ok := i.catalogs[tag][key]; ok {
+			return message, true
+		}
+	}
+	return "", false
+}
+
+// =============================================================================
+// CONTEXT INTEGRATION
+// =============================================================================
+
+// SetI18n makes bundle the request's catalog set and lang its locale;
+// middleware.I18n calls it after negotiating the language
+func (c *Context) SetI18n(bundle *I18n, lang string) {
+	c.Set(ContextKeyI18n, bundle)
+	c.Set(ContextKeyLocale, lang)
+}
+
+// Locale returns the request's language, or "" without middleware.I18n
+func (c *Context) Locale() string {
+	return c.GetString(ContextKeyLocale)
+}
+
+// T translates key into the request's language, formatting args with fmt
+// verbs; without a catalog it returns the key. Templates can use it as a
+// function:
+//
+//	c.T("greeting", user.Name)
+//	template.FuncMap{"t": c.T}
+func (c *Context) T(key string, args ...any) string {
+	bundle := c.i18n()
+	if bundle == nil {
+		return formatMessage(key, args)
+	}
+	return bundle.Translate(c.Locale(), key, args...)
+}
+
+// i18n returns the request's catalog set, if any
+func (c *Context) i18n() *I18n {
+	if v, ok := c.Get(ContextKeyI18n); ok {
+		bundle, _ := v.(*I18n)
+		return bundle
+	}
+	return nil
+}
+
+// translateError localizes an error's message and field messages, using
+// the English text as the catalog key ("Not Found", "is required")
+func (c *Context) translateError(err *HTTPError) *HTTPError {
+	bundle := c.i18n()
+	if bundle == nil {
+		return err
+	}
+	lang := c.Locale()
+	translated := *err
+	translated.Message = bundle.Translate(lang, err.Message)
+	if fields, ok := err.Details.([]FieldError); ok {
+		localized := make([]FieldError, len(fields))
+		for i, field := range fields {
+			field.Message = bundle.Translate(lang, field.Message)
+			localized[i] = field
+		}
+		translated.Details = localized
+	}
+	return &translated
+}
@@ -0,0 +1,112 @@
+package poltergeist
+
+import (
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+)
+
+// =============================================================================
+// I18N TESTS
+// =============================================================================
+
+func newTestI18n(t *testing.T) *I18n {
+	t.Helper()
+	bundle := NewI18n("en")
+	err := bundle.LoadFS(fstest.MapFS{
+		"locales/en.json":    {Data: []byte(`{"greeting": "Hello, %s!", "cart": {"empty": "Your cart is empty"}}`)},
+		"locales/de.json":    {Data: []byte(`{"greeting": "Hallo, %s!", "Not Found": "Nicht gefunden", "is required": "ist erforderlich"}`)},
+		"locales/pt-BR.json": {Data: []byte(`{"greeting": "Olá, %s!"}`)},
+	}, "locales/*.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return bundle
+}
+
+func TestI18n_Negotiate(t *testing.T) {
+	bundle := newTestI18n(t)
+
+	tests := []struct {
+		header, want string
+	}{
+		{"de-DE,de;q=0.9,en;q=0.8", "de"},
+		{"fr;q=0.9, pt;q=0.8", "pt-BR"},
+		{"en;q=0.5, de;q=0.7", "de"},
+		{"PT-br", "pt-BR"},
+		{"fr, *;q=0.1", "en"},
+		{"de;q=0", "en"},
+		{"", "en"},
+	}
+	for _, tt := range tests {
+		if got := bundle.Negotiate(tt.header); got != tt.want {
+			t.Errorf("Negotiate(%q) = %q, want %q", tt.header, got, tt.want)
+		}
+	}
+}
+
+func TestI18n_Translate(t *testing.T) {
+	bundle := newTestI18n(t)
+
+	tests := []struct {
+		lang, key string
+		args      []any
+		want      string
+	}{
+		{"de", "greeting", []any{"Ana"}, "Hallo, Ana!"},
+		{"pt-BR", "greeting", []any{"Ana"}, "Olá, Ana!"},
+		{"de-AT", "greeting", []any{"Ana"}, "Hallo, Ana!"}, // base language
+		{"de", "cart.empty", nil, "Your cart is empty"},    // fallback language
+		{"de", "missing.key", nil, "missing.key"},
+	}
+	for _, tt := range tests {
+		if got := bundle.Translate(tt.lang, tt.key, tt.args...); got != tt.want {
+			t.Errorf("Translate(%q, %q) = %q, want %q", tt.lang, tt.key, got, tt.want)
+		}
+	}
+}
+
+func TestContext_T(t *testing.T) {
+	bundle := newTestI18n(t)
+	app := New()
+	app.Use(func(next HandlerFunc) HandlerFunc {
+		return func(c *Context) error {
+			c.SetI18n(bundle, bundle.Negotiate(c.Header("Accept-Language")))
+			return next(c)
+		}
+	})
+	app.GET("/hello", func(c *Context) error {
+		return c.String(200, c.T("greeting", c.Locale()))
+	})
+	app.GET("/missing", func(c *Context) error { return ErrNotFound })
+	app.GET("/invalid", func(c *Context) error {
+		return ValidationErrors{{Field: "email", Message: "is required"}}
+	})
+
+	tests := []struct {
+		path, want string
+	}{
+		{"/hello", "Hallo, de!"},
+		{"/missing", `{"error":"Nicht gefunden"}`},
+		{"/invalid", `{"details":[{"field":"email","message":"ist erforderlich"}],"error":"Validation failed"}`},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", tt.path, nil)
+		req.Header.Set("Accept-Language", "de-CH, en;q=0.5")
+		app.ServeHTTP(w, req)
+		got := w.Body.String()
+		if tt.path != "/hello" {
+			got = compactJSON(t, w.Body.Bytes())
+		}
+		if got != tt.want {
+			t.Errorf("%s: body = %s, want %s", tt.path, got, tt.want)
+		}
+	}
+
+	// Without a catalog, T returns the key
+	c := &Context{}
+	if got := c.T("greeting"); got != "greeting" {
+		t.Errorf("T without catalog = %q, want the key", got)
+	}
+}
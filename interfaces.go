@@ -37,7 +37,8 @@ type RouteRegistrar interface {
 	PATCH(path string, handler HandlerFunc, middlewares ...MiddlewareFunc) *Route
 	OPTIONS(path string, handler HandlerFunc, middlewares ...MiddlewareFunc) *Route
 	HEAD(path string, handler HandlerFunc, middlewares ...MiddlewareFunc) *Route
-	Any(path string, handler HandlerFunc, middlewares ...MiddlewareFunc)
+	Any(path string, handler HandlerFunc, middlewares ...MiddlewareFunc) []*Route
+	Match(methods []string, path string, handler HandlerFunc, middlewares ...MiddlewareFunc) []*Route
 }
 
 // MiddlewareUser defines interface for adding middleware
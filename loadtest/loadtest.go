@@ -0,0 +1,325 @@
+// Package loadtest drives a Poltergeist app with concurrent scenarios and
+// reports latency percentiles per route:
+//
+//	runner := loadtest.New(app, &loadtest.Config{Concurrency: 50, Duration: 10 * time.Second})
+//	report := runner.Run(context.Background(), func(s *loadtest.Session) error {
+//	    _, err := s.GET("/users/42")
+//	    return err
+//	})
+//	fmt.Print(report)
+//	if err := report.Check(loadtest.Threshold{Route: "GET /users/:id", P99: 5 * time.Millisecond}); err != nil {
+//	    t.Fatal(err)
+//	}
+//
+// Requests run in-process through the router (New) or over the network
+// against a running server (NewHTTP).
+package loadtest
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gofuckbiz/poltergeist"
+)
+
+// =============================================================================
+// CONFIG - Load shape
+// =============================================================================
+
+// Config configures a load run
+type Config struct {
+	Concurrency int           // parallel workers (default: 10)
+	Duration    time.Duration // how long to run (default: 10s)
+	Iterations  int           // stop after this many scenario runs in total (0 = no limit)
+	Warmup      time.Duration // run without recording first (default: 0)
+	Timeout     time.Duration // per-request timeout for HTTP targets (default: 5s)
+}
+
+// DefaultConfig returns default load configuration
+func DefaultConfig() *Config {
+	return &Config{
+		Concurrency: 10,
+		Duration:    10 * time.Second,
+		Timeout:     5 * time.Second,
+	}
+}
+
+// withDefaults fills zero values from DefaultConfig
+func (c *Config) withDefaults() *Config {
+	defaults := DefaultConfig()
+	if c == nil {
+		return defaults
+	}
+	cfg := *c
+	if cfg.Concurrency <= 0 {
+		cfg.Concurrency = defaults.Concurrency
+	}
+	if cfg.Duration <= 0 {
+		cfg.Duration = defaults.Duration
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = defaults.Timeout
+	}
+	return &cfg
+}
+
+// =============================================================================
+// RUNNER - Workers executing scenarios
+// =============================================================================
+
+// Scenario is one virtual user iteration; returning an error counts it as failed
+type Scenario func(s *Session) error
+
+// Runner executes scenarios against a target
+type Runner struct {
+	config  *Config
+	handler http.Handler        // in-process target
+	baseURL string              // HTTP target
+	client  *http.Client        // HTTP target
+	router  *poltergeist.Router // resolves route patterns for grouping
+}
+
+// New creates a runner that serves requests in-process through the app's router
+func New(app *poltergeist.Server, config ...*Config) *Runner {
+	var cfg *Config
+	if len(config) > 0 {
+		cfg = config[0]
+	}
+	return &Runner{
+		config:  cfg.withDefaults(),
+		handler: app.Router(),
+		router:  app.Router(),
+	}
+}
+
+// NewHTTP creates a runner that sends requests to a running server
+func NewHTTP(baseURL string, config ...*Config) *Runner {
+	var cfg *Config
+	if len(config) > 0 {
+		cfg = config[0]
+	}
+	cfg = cfg.withDefaults()
+	return &Runner{
+		config:  cfg,
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		client: &http.Client{
+			Timeout: cfg.Timeout,
+			Transport: &http.Transport{
+				MaxIdleConns:        cfg.Concurrency,
+				MaxIdleConnsPerHost: cfg.Concurrency,
+			},
+		},
+	}
+}
+
+// Routes groups results by the app's route patterns ("GET /users/:id")
+// instead of raw paths; useful with NewHTTP when the app is at hand
+func (r *Runner) Routes(app *poltergeist.Server) *Runner {
+	r.router = app.Router()
+	return r
+}
+
+// Run executes the scenario on every worker until the duration elapses, the
+// iteration limit is reached or ctx is cancelled
+func (r *Runner) Run(ctx context.Context, scenario Scenario) *Report {
+	if r.config.Warmup > 0 {
+		warmCtx, cancel := context.WithTimeout(ctx, r.config.Warmup)
+		r.run(warmCtx, scenario, 0)
+		cancel()
+	}
+
+	runCtx, cancel := context.WithTimeout(ctx, r.config.Duration)
+	defer cancel()
+
+	start := time.Now()
+	recorders := r.run(runCtx, scenario, r.config.Iterations)
+	return buildReport(recorders, time.Since(start))
+}
+
+// run starts the workers and waits for them; each records into its own
+// recorder so the hot path takes no shared locks
+func (r *Runner) run(ctx context.Context, scenario Scenario, iterations int) []*recorder {
+	var remaining atomic.Int64
+	remaining.Store(int64(iterations))
+
+	recorders := make([]*recorder, r.config.Concurrency)
+	var wg sync.WaitGroup
+	for i := range recorders {
+		rec := newRecorder()
+		recorders[i] = rec
+		session := &Session{runner: r, ctx: ctx, rec: rec, Worker: i}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for ctx.Err() == nil {
+				if iterations > 0 && remaining.Add(-1) < 0 {
+					return
+				}
+				session.Iteration++
+				start := time.Now()
+				err := runScenario(scenario, session)
+				if ctx.Err() != nil && err != nil {
+					return // cut off by the deadline, not a real failure
+				}
+				rec.scenario(time.Since(start), err)
+			}
+		}()
+	}
+	wg.Wait()
+	return recorders
+}
+
+// runScenario calls the scenario, converting panics into errors
+func runScenario(scenario Scenario, s *Session) (err error) {
+	defer func() {
+		if rec := recover(); rec != nil {
+			err = &PanicError{Value: rec}
+		}
+	}()
+	return scenario(s)
+}
+
+// =============================================================================
+// SESSION - Per-worker request helpers
+// =============================================================================
+
+// Response is a completed request
+type Response struct {
+	Status int
+	Header http.Header
+	Body   []byte
+}
+
+// Session is a worker's handle for sending measured requests
+type Session struct {
+	Worker    int // worker index
+	Iteration int // scenario runs by this worker, starting at 1
+
+	runner *Runner
+	ctx    context.Context
+	rec    *recorder
+}
+
+// Context is cancelled when the run ends
+func (s *Session) Context() context.Context {
+	return s.ctx
+}
+
+// GET sends a GET request
+func (s *Session) GET(path string) (*Response, error) {
+	return s.Do(http.MethodGet, path, nil, nil)
+}
+
+// POST sends a POST request with a JSON body
+func (s *Session) POST(path string, body []byte) (*Response, error) {
+	return s.Do(http.MethodPost, path, body, http.Header{poltergeist.HeaderContentType: {"application/json"}})
+}
+
+// PUT sends a PUT request with a JSON body
+func (s *Session) PUT(path string, body []byte) (*Response, error) {
+	return s.Do(http.MethodPut, path, body, http.Header{poltergeist.HeaderContentType: {"application/json"}})
+}
+
+// DELETE sends a DELETE request
+func (s *Session) DELETE(path string) (*Response, error) {
+	return s.Do(http.MethodDelete, path, nil, nil)
+}
+
+// Do sends a request and records its latency under the route it matched.
+// Transport failures and 5xx responses are returned as errors.
+func (s *Session) Do(method, path string, body []byte, header http.Header) (*Response, error) {
+	var resp *Response
+	var err error
+
+	start := time.Now()
+	if s.runner.handler != nil {
+		resp = s.serveInProcess(method, path, body, header)
+	} else {
+		resp, err = s.sendHTTP(method, path, body, header)
+	}
+	elapsed := time.Since(start)
+
+	if err == nil && resp.Status >= 500 {
+		err = &StatusError{Method: method, Path: path, Status: resp.Status}
+	}
+	if s.ctx.Err() != nil && err != nil {
+		return resp, err // cancelled in flight; keep it out of the stats
+	}
+
+	status := 0
+	if resp != nil {
+		status = resp.Status
+	}
+	s.rec.request(s.runner.label(method, path), elapsed, status, err)
+	return resp, err
+}
+
+// Measure times an arbitrary operation (a hub broadcast, a WebSocket round
+// trip) and records it under name
+func (s *Session) Measure(name string, fn func() error) error {
+	start := time.Now()
+	err := fn()
+	s.rec.request(name, time.Since(start), 0, err)
+	return err
+}
+
+// serveInProcess runs the request through the handler
+func (s *Session) serveInProcess(method, path string, body []byte, header http.Header) *Response {
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+	req := httptest.NewRequest(method, path, reader).WithContext(s.ctx)
+	for key, values := range header {
+		req.Header[key] = values
+	}
+
+	w := httptest.NewRecorder()
+	s.runner.handler.ServeHTTP(w, req)
+	return &Response{Status: w.Code, Header: w.Header(), Body: w.Body.Bytes()}
+}
+
+// sendHTTP sends the request over the network
+func (s *Session) sendHTTP(method, path string, body []byte, header http.Header) (*Response, error) {
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(s.ctx, method, s.runner.baseURL+path, reader)
+	if err != nil {
+		return nil, err
+	}
+	for key, values := range header {
+		req.Header[key] = values
+	}
+
+	resp, err := s.runner.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	return &Response{Status: resp.StatusCode, Header: resp.Header, Body: data}, err
+}
+
+// label names a request by its route pattern when known
+func (r *Runner) label(method, path string) string {
+	if i := strings.IndexByte(path, '?'); i >= 0 {
+		path = path[:i]
+	}
+	if r.router != nil {
+		if route := r.router.Lookup(method, path); route != nil {
+			return route.Method + " " + route.Path
+		}
+	}
+	return method + " " + path
+}
@@ -0,0 +1,185 @@
+package loadtest
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gofuckbiz/poltergeist"
+)
+
+// =============================================================================
+// LOAD TEST TESTS
+// =============================================================================
+
+// testApp serves users, fails /boom with a 500 and counts requests
+func testApp(requests *atomic.Int64) *poltergeist.Server {
+	app := poltergeist.New().UseLogger(poltergeist.NopLogger)
+	app.GET("/users/:id", func(c *poltergeist.Context) error {
+		requests.Add(1)
+		return c.JSON(http.StatusOK, poltergeist.H{"id": c.Param("id")})
+	})
+	app.POST("/boom", func(c *poltergeist.Context) error {
+		requests.Add(1)
+		return poltergeist.ErrInternalServerError
+	})
+	return app
+}
+
+func TestRunner_InProcess(t *testing.T) {
+	var requests atomic.Int64
+	runner := New(testApp(&requests), &Config{Concurrency: 4, Duration: 10 * time.Second, Iterations: 100})
+	report := runner.Run(context.Background(), func(s *Session) error {
+		if s.Iteration < 1 || s.Worker < 0 || s.Worker >= 4 {
+			t.Errorf("session = worker %d, iteration %d", s.Worker, s.Iteration)
+		}
+		if _, err := s.GET("/users/42?expand=1"); err != nil {
+			return err
+		}
+		return s.Measure("noop", func() error { return nil })
+	})
+
+	if requests.Load() != 100 || report.Scenarios.Requests != 100 || report.Total.Requests != 200 {
+		t.Errorf("served %d, scenarios %d, total %d; want 100 iterations", requests.Load(), report.Scenarios.Requests, report.Total.Requests)
+	}
+	users := report.Route("GET /users/:id")
+	if users == nil || users.Requests != 100 || users.Status[http.StatusOK] != 100 || users.Errors != 0 {
+		t.Fatalf("route stats = %+v", users)
+	}
+	if users.Min > users.P50 || users.P50 > users.P99 || users.P99 > users.Max || users.RPS <= 0 {
+		t.Errorf("latencies out of order: %+v", users)
+	}
+	if report.Route("noop") == nil || report.Route("GET /users/42") != nil {
+		t.Errorf("routes = %v, want grouping by pattern plus measured ops", report.Routes)
+	}
+}
+
+func TestRunner_Errors(t *testing.T) {
+	var requests atomic.Int64
+	report := New(testApp(&requests), &Config{Concurrency: 2, Iterations: 10}).Run(context.Background(), func(s *Session) error {
+		if s.Iteration == 2 {
+			panic("scenario bug")
+		}
+		_, err := s.POST("/boom", []byte(`{}`))
+		return err
+	})
+
+	boom := report.Route("POST /boom")
+	if boom == nil || boom.Errors != boom.Requests || boom.ErrorRate() != 1 {
+		t.Fatalf("boom stats = %+v, want every request failed", boom)
+	}
+	if report.Scenarios.Requests != 10 || report.Scenarios.Errors != 10 {
+		t.Errorf("scenarios = %+v, want 10 failed", report.Scenarios)
+	}
+	var statusErr *StatusError
+	var panicErr *PanicError
+	if !errors.As(report.LastError, &statusErr) && !errors.As(report.LastError, &panicErr) {
+		t.Errorf("LastError = %v", report.LastError)
+	}
+}
+
+func TestRunner_HTTP(t *testing.T) {
+	var requests atomic.Int64
+	app := testApp(&requests)
+	server := httptest.NewServer(app.Router())
+	defer server.Close()
+
+	runner := NewHTTP(server.URL+"/", &Config{Concurrency: 2, Iterations: 20}).Routes(app)
+	report := runner.Run(context.Background(), func(s *Session) error {
+		resp, err := s.GET("/users/7")
+		if err == nil && !strings.Contains(string(resp.Body), `"7"`) {
+			t.Errorf("body = %s", resp.Body)
+		}
+		return err
+	})
+	if stats := report.Route("GET /users/:id"); stats == nil || stats.Requests != 20 || stats.Errors != 0 {
+		t.Errorf("stats = %+v, want 20 grouped requests", stats)
+	}
+}
+
+func TestRunner_DurationAndCancel(t *testing.T) {
+	var requests atomic.Int64
+	runner := New(testApp(&requests), &Config{Concurrency: 2, Duration: 50 * time.Millisecond, Warmup: 20 * time.Millisecond})
+	start := time.Now()
+	report := runner.Run(context.Background(), func(s *Session) error {
+		_, err := s.GET("/users/1")
+		return err
+	})
+	if elapsed := time.Since(start); elapsed > time.Second || report.Duration < 50*time.Millisecond {
+		t.Errorf("run took %s (reported %s), want about 70ms", elapsed, report.Duration)
+	}
+	if report.Total.Requests >= int(requests.Load()) || report.Total.Errors != 0 {
+		t.Errorf("recorded %d of %d requests with %d errors; warmup should not be recorded",
+			report.Total.Requests, requests.Load(), report.Total.Errors)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if report := runner.Run(ctx, func(*Session) error { return nil }); report.Scenarios.Requests != 0 {
+		t.Errorf("cancelled run recorded %d scenarios", report.Scenarios.Requests)
+	}
+}
+
+func TestReport_Check(t *testing.T) {
+	report := &Report{
+		Total: Stats{Name: "total", Requests: 10, Errors: 1, P50: time.Millisecond, P99: 5 * time.Millisecond, RPS: 100},
+		Routes: []Stats{
+			{Name: "GET /users/:id", Requests: 10, P50: time.Millisecond, P99: 5 * time.Millisecond, RPS: 100},
+		},
+	}
+
+	tests := []struct {
+		name      string
+		threshold Threshold
+		fails     bool
+	}{
+		{"nothing checked", Threshold{}, false},
+		{"p50", Threshold{P50: 500 * time.Microsecond}, true},
+		{"p99 met", Threshold{P99: 10 * time.Millisecond}, false},
+		{"p99", Threshold{Route: "GET /users/:id", P99: time.Millisecond}, true},
+		{"no errors allowed", Threshold{MaxErrorRate: Rate(0)}, true},
+		{"no errors on route", Threshold{Route: "GET /users/:id", MaxErrorRate: Rate(0)}, false},
+		{"error rate met", Threshold{MaxErrorRate: Rate(0.1)}, false},
+		{"throughput", Threshold{MinRPS: 1000}, true},
+		{"unknown route", Threshold{Route: "GET /nope"}, true},
+	}
+	for _, tt := range tests {
+		if err := report.Check(tt.threshold); (err != nil) != tt.fails {
+			t.Errorf("%s: Check = %v, want failure %v", tt.name, err, tt.fails)
+		}
+	}
+}
+
+func TestReport_String(t *testing.T) {
+	routes := make([]Stats, 1, 2)
+	routes[0] = Stats{Name: "GET /a"}
+	report := &Report{Routes: routes, Scenarios: Stats{Name: "scenario"}, LastError: errors.New("last")}
+
+	_ = report.String()
+	if spare := routes[:2][1]; spare.Name != "" {
+		t.Errorf("String wrote %q into the Routes backing array", spare.Name)
+	}
+	out := report.String()
+	for _, want := range []string{"GET /a", "scenario", "last error: last"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("report missing %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestPercentile(t *testing.T) {
+	sorted := make([]time.Duration, 100)
+	for i := range sorted {
+		sorted[i] = time.Duration(i+1) * time.Millisecond
+	}
+	for p, want := range map[float64]time.Duration{0: time.Millisecond, 0.5: 50 * time.Millisecond, 0.99: 99 * time.Millisecond, 1: 100 * time.Millisecond} {
+		if got := percentile(sorted, p); got != want {
+			t.Errorf("percentile(%v) = %s, want %s", p, got, want)
+		}
+	}
+}
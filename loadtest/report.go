@@ -0,0 +1,286 @@
+package loadtest
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+// =============================================================================
+// ERRORS
+// =============================================================================
+
+// StatusError reports a 5xx response
+type StatusError struct {
+	Method string
+	Path   string
+	Status int
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("%s %s: status %d", e.Method, e.Path, e.Status)
+}
+
+// PanicError reports a panicking scenario
+type PanicError struct {
+	Value any
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("scenario panic: %v", e.Value)
+}
+
+// =============================================================================
+// RECORDER - Per-worker samples
+// =============================================================================
+
+// samples holds raw measurements for one route
+type samples struct {
+	latencies []time.Duration
+	errors    int
+	status    map[int]int
+}
+
+// recorder collects one worker's measurements
+type recorder struct {
+	routes         map[string]*samples
+	scenarios      []time.Duration
+	scenarioErrors int
+	lastError      error
+}
+
+func newRecorder() *recorder {
+	return &recorder{routes: make(map[string]*samples)}
+}
+
+// request records a single request or measured operation
+func (r *recorder) request(name string, elapsed time.Duration, status int, err error) {
+	s, ok := r.routes[name]
+	if !ok {
+		s = &samples{status: make(map[int]int)}
+		r.routes[name] = s
+	}
+	s.latencies = append(s.latencies, elapsed)
+	if status != 0 {
+		s.status[status]++
+	}
+	if err != nil {
+		s.errors++
+	}
+}
+
+// scenario records a complete scenario iteration
+func (r *recorder) scenario(elapsed time.Duration, err error) {
+	r.scenarios = append(r.scenarios, elapsed)
+	if err != nil {
+		r.scenarioErrors++
+		r.lastError = err
+	}
+}
+
+// =============================================================================
+// REPORT - Aggregated results
+// =============================================================================
+
+// Stats summarizes latencies for a route (or all requests)
+type Stats struct {
+	Name     string
+	Requests int
+	Errors   int
+	RPS      float64
+	Min      time.Duration
+	Mean     time.Duration
+	P50      time.Duration
+	P90      time.Duration
+	P95      time.Duration
+	P99      time.Duration
+	Max      time.Duration
+	Status   map[int]int // responses per status code
+}
+
+// ErrorRate returns the failed share of requests (0..1)
+func (s *Stats) ErrorRate() float64 {
+	if s.Requests == 0 {
+		return 0
+	}
+	return float64(s.Errors) / float64(s.Requests)
+}
+
+// Report is the result of a load run
+type Report struct {
+	Duration  time.Duration
+	Total     Stats   // every recorded request
+	Scenarios Stats   // whole scenario iterations
+	Routes    []Stats // per route, sorted by name
+	LastError error   // most recent scenario error, for diagnosis
+}
+
+// Route returns the stats for a route name such as "GET /users/:id"
+func (r *Report) Route(name string) *Stats {
+	for i := range r.Routes {
+		if r.Routes[i].Name == name {
+			return &r.Routes[i]
+		}
+	}
+	return nil
+}
+
+// buildReport merges worker recorders into a report
+func buildReport(recorders []*recorder, elapsed time.Duration) *Report {
+	merged := make(map[string]*samples)
+	all := &samples{status: make(map[int]int)}
+	scenarios := &samples{status: make(map[int]int)}
+	report := &Report{Duration: elapsed}
+
+	for _, rec := range recorders {
+		for name, s := range rec.routes {
+			m, ok := merged[name]
+			if !ok {
+				m = &samples{status: make(map[int]int)}
+				merged[name] = m
+			}
+			for _, target := range []*samples{m, all} {
+				target.latencies = append(target.latencies, s.latencies...)
+				target.errors += s.errors
+				for code, n := range s.status {
+					target.status[code] += n
+				}
+			}
+		}
+		scenarios.latencies = append(scenarios.latencies, rec.scenarios...)
+		scenarios.errors += rec.scenarioErrors
+		if rec.lastError != nil {
+			report.LastError = rec.lastError
+		}
+	}
+
+	report.Total = summarize("total", all, elapsed)
+	report.Scenarios = summarize("scenario", scenarios, elapsed)
+
+	names := make([]string, 0, len(merged))
+	for name := range merged {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		report.Routes = append(report.Routes, summarize(name, merged[name], elapsed))
+	}
+	return report
+}
+
+// summarize computes stats over raw samples
+func summarize(name string, s *samples, elapsed time.Duration) Stats {
+	stats := Stats{Name: name, Requests: len(s.latencies), Errors: s.errors, Status: s.status}
+	if stats.Requests == 0 {
+		return stats
+	}
+
+	sort.Slice(s.latencies, func(i, j int) bool { return s.latencies[i] < s.latencies[j] })
+	var sum time.Duration
+	for _, d := range s.latencies {
+		sum += d
+	}
+
+	stats.Min = s.latencies[0]
+	stats.Max = s.latencies[len(s.latencies)-1]
+	stats.Mean = sum / time.Duration(len(s.latencies))
+	stats.P50 = percentile(s.latencies, 0.50)
+	stats.P90 = percentile(s.latencies, 0.90)
+	stats.P95 = percentile(s.latencies, 0.95)
+	stats.P99 = percentile(s.latencies, 0.99)
+	if elapsed > 0 {
+		stats.RPS = float64(stats.Requests) / elapsed.Seconds()
+	}
+	return stats
+}
+
+// percentile returns the nearest-rank percentile of sorted durations
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	rank := int(math.Ceil(p*float64(len(sorted)))) - 1
+	if rank < 0 {
+		rank = 0
+	}
+	return sorted[rank]
+}
+
+// String renders the report as a table
+func (r *Report) String() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Load test: %s, %d requests, %.0f req/s, %d errors\n",
+		r.Duration.Round(time.Millisecond), r.Total.Requests, r.Total.RPS, r.Total.Errors)
+	fmt.Fprintf(&sb, "  %-36s %9s %9s %10s %10s %10s %10s %10s\n",
+		"ROUTE", "REQS", "ERRS", "RPS", "P50", "P90", "P99", "MAX")
+	rows := make([]Stats, 0, len(r.Routes)+1)
+	rows = append(append(rows, r.Routes...), r.Scenarios)
+	for _, stats := range rows {
+		fmt.Fprintf(&sb, "  %-36s %9d %9d %10.0f %10s %10s %10s %10s\n",
+			stats.Name, stats.Requests, stats.Errors, stats.RPS,
+			formatDuration(stats.P50), formatDuration(stats.P90),
+			formatDuration(stats.P99), formatDuration(stats.Max))
+	}
+	if r.LastError != nil {
+		fmt.Fprintf(&sb, "  last error: %v\n", r.LastError)
+	}
+	return sb.String()
+}
+
+// formatDuration rounds durations for display
+func formatDuration(d time.Duration) string {
+	switch {
+	case d >= time.Second:
+		return d.Round(time.Millisecond).String()
+	case d >= time.Millisecond:
+		return d.Round(10 * time.Microsecond).String()
+	default:
+		return d.Round(100 * time.Nanosecond).String()
+	}
+}
+
+// =============================================================================
+// THRESHOLDS - Pass/fail criteria for CI
+// =============================================================================
+
+// Threshold is a limit a run must meet; zero fields are not checked
+type Threshold struct {
+	Route        string        // route name, or "" for all requests
+	P50          time.Duration // maximum median latency
+	P99          time.Duration // maximum 99th percentile latency
+	MaxErrorRate *float64      // maximum failed share (0..1); Rate(0) allows no errors
+	MinRPS       float64       // minimum throughput
+}
+
+// Rate returns a pointer to rate, for Threshold.MaxErrorRate
+func Rate(rate float64) *float64 {
+	return &rate
+}
+
+// Check returns an error describing every threshold the report misses
+func (r *Report) Check(thresholds ...Threshold) error {
+	var errs []error
+	for _, th := range thresholds {
+		stats := &r.Total
+		if th.Route != "" {
+			if stats = r.Route(th.Route); stats == nil {
+				errs = append(errs, fmt.Errorf("%s: no requests recorded", th.Route))
+				continue
+			}
+		}
+		if th.P50 > 0 && stats.P50 > th.P50 {
+			errs = append(errs, fmt.Errorf("%s: p50 %s exceeds %s", stats.Name, stats.P50, th.P50))
+		}
+		if th.P99 > 0 && stats.P99 > th.P99 {
+			errs = append(errs, fmt.Errorf("%s: p99 %s exceeds %s", stats.Name, stats.P99, th.P99))
+		}
+		if th.MaxErrorRate != nil && stats.ErrorRate() > *th.MaxErrorRate {
+			errs = append(errs, fmt.Errorf("%s: error rate %.2f%% exceeds %.2f%%",
+				stats.Name, stats.ErrorRate()*100, *th.MaxErrorRate*100))
+		}
+		if th.MinRPS > 0 && stats.RPS < th.MinRPS {
+			errs = append(errs, fmt.Errorf("%s: %.0f req/s is below %.0f", stats.Name, stats.RPS, th.MinRPS))
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,158 @@
+package poltergeist
+
+import (
+	"log/slog"
+	"sync/atomic"
+)
+
+// =============================================================================
+// LOGGER - Structured framework logs
+// =============================================================================
+
+// Logger receives the framework's own logs (WebSocket read errors, hub
+// delivery failures, failed tasks and jobs, lifecycle messages). args are
+// alternating key/value pairs, as with slog:
+//
+//	logger.Warn("websocket read error", "client_id", id, "error", err)
+//
+// *slog.Logger implements Logger; the logging package adapts zap and zerolog.
+type Logger interface {
+	Debug(msg string, args ...any)
+	Info(msg string, args ...any)
+	Warn(msg string, args ...any)
+	Error(msg string, args ...any)
+}
+
+// NopLogger discards all framework logs
+var NopLogger Logger = nopLogger{}
+
+type nopLogger struct{}
+
+func (nopLogger) Debug(string, ...any) {}
+func (nopLogger) Info(string, ...any)  {}
+func (nopLogger) Warn(string, ...any)  {}
+func (nopLogger) Error(string, ...any) {}
+
+// loggerBox lets atomic.Value hold any Logger implementation
+type loggerBox struct{ logger Logger }
+
+// loggerSlot holds the framework logger; unset means slog.Default()
+type loggerSlot struct {
+	current atomic.Value // loggerBox
+}
+
+// get returns the configured logger, falling back to slog.Default() so
+// slog.SetDefault keeps working for apps that never set one
+func (s *loggerSlot) get() Logger {
+	if s != nil {
+		if box, ok := s.current.Load().(loggerBox); ok && box.logger != nil {
+			return box.logger
+		}
+	}
+	return slog.Default()
+}
+
+// set replaces the logger; nil restores the default
+func (s *loggerSlot) set(logger Logger) {
+	s.current.Store(loggerBox{logger})
+}
+
+// LoggerWith returns a logger that adds args (key/value pairs) to every
+// entry. *slog.Logger values use slog's own With; other loggers are wrapped.
+func LoggerWith(logger Logger, args ...any) Logger {
+	if len(args) == 0 {
+		return logger
+	}
+	switch l := logger.(type) {
+	case *slog.Logger:
+		return l.With(args...)
+	case *fieldLogger:
+		return &fieldLogger{base: l.base, args: append(l.args[:len(l.args):len(l.args)], args...)}
+	}
+	return &fieldLogger{base: logger, args: args}
+}
+
+// fieldLogger prepends fixed fields to every entry
+type fieldLogger struct {
+	base Logger
+	args []any
+}
+
+func (l *fieldLogger) Debug(msg string, args ...any) { l.base.Debug(msg, l.with(args)...) }
+func (l *fieldLogger) Info(msg string, args ...any)  { l.base.Info(msg, l.with(args)...) }
+func (l *fieldLogger) Warn(msg string, args ...any)  { l.base.Warn(msg, l.with(args)...) }
+func (l *fieldLogger) Error(msg string, args ...any) { l.base.Error(msg, l.with(args)...) }
+
+func (l *fieldLogger) with(args []any) []any {
+	return append(l.args[:len(l.args):len(l.args)], args...)
+}
+
+// =============================================================================
+// REQUEST LOGGER - Context-scoped logger with request fields
+// =============================================================================
+
+// Logger returns a request-scoped logger that writes where the server's
+// logs go, with request_id (when set), method, route and route_name (for
+// named routes) already attached:
+//
+//	c.Logger().Info("order placed", "order_id", id)
+//
+// Middleware can replace it with SetLogger, e.g. to add the user ID.
+func (c *Context) Logger() Logger {
+	id := c.RequestID()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	// Rebuild when a request ID appears after first use
+	if c.logger == nil || (!c.loggerSet && c.loggerID != id) {
+		c.logger = LoggerWith(c.pipeline.logger(), c.logFields(id)...)
+		c.loggerID = id
+	}
+	return c.logger
+}
+
+// SetLogger replaces the request logger for the rest of the request; nil
+// restores the default. Build on the current one to keep its fields:
+//
+//	c.SetLogger(poltergeist.LoggerWith(c.Logger(), "user_id", user.ID))
+func (c *Context) SetLogger(logger Logger) {
+	c.mu.Lock()
+	c.logger = logger
+	c.loggerSet = logger != nil
+	c.mu.Unlock()
+}
+
+// logFields returns the request's default log fields
+func (c *Context) logFields(requestID string) []any {
+	var fields []any
+	if requestID != "" {
+		fields = append(fields, "request_id", requestID)
+	}
+	if c.Request != nil {
+		fields = append(fields, "method", c.Request.Method)
+	}
+	if c.route != nil {
+		fields = append(fields, "route", c.route.Path)
+		if c.route.RouteName != "" {
+			fields = append(fields, "route_name", c.route.RouteName)
+		}
+	}
+	return fields
+}
+
+// =============================================================================
+// SERVER INTEGRATION
+// =============================================================================
+
+// UseLogger replaces the framework logger (default: slog.Default()). Pass
+// NopLogger to silence framework logs.
+//
+//	app.UseLogger(slog.New(slog.NewJSONHandler(os.Stderr, nil)))
+func (s *Server) UseLogger(logger Logger) *Server {
+	s.router.pipeline.logs.set(logger)
+	return s
+}
+
+// Logger returns the framework logger
+func (s *Server) Logger() Logger {
+	return s.router.pipeline.logger()
+}
@@ -0,0 +1,139 @@
+package poltergeist
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// =============================================================================
+// LOGGER TESTS
+// =============================================================================
+
+type logEntry struct {
+	level, msg string
+	args       []any
+}
+
+// recordingLogger keeps every framework log line
+type recordingLogger struct {
+	mu      sync.Mutex
+	entries []logEntry
+}
+
+func (l *recordingLogger) record(level, msg string, args []any) {
+	l.mu.Lock()
+	l.entries = append(l.entries, logEntry{level, msg, args})
+	l.mu.Unlock()
+}
+
+func (l *recordingLogger) Debug(msg string, args ...any) { l.record("debug", msg, args) }
+func (l *recordingLogger) Info(msg string, args ...any)  { l.record("info", msg, args) }
+func (l *recordingLogger) Warn(msg string, args ...any)  { l.record("warn", msg, args) }
+func (l *recordingLogger) Error(msg string, args ...any) { l.record("error", msg, args) }
+
+func TestLogger_TaskFailure(t *testing.T) {
+	logger := &recordingLogger{}
+	app := NewWithConfig(&Config{
+		ShutdownTimeout: time.Second,
+		Tasks:           &TaskConfig{Workers: 1, QueueSize: 1, RetryBackoff: time.Millisecond},
+		Logger:          logger,
+	})
+	app.Tasks().EnqueueNamed("email", func(ctx context.Context) error {
+		return errors.New("smtp down")
+	})
+	if err := app.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	logger.mu.Lock()
+	defer logger.mu.Unlock()
+	if len(logger.entries) != 1 {
+		t.Fatalf("got %d log entries, want 1", len(logger.entries))
+	}
+	entry := logger.entries[0]
+	if entry.level != "error" || entry.msg != "task failed" || entry.args[1] != "email" {
+		t.Errorf("entry = %+v", entry)
+	}
+}
+
+func TestLogger_Default(t *testing.T) {
+	app := New()
+	if app.Logger() != slog.Default() {
+		t.Error("framework logger should default to slog.Default()")
+	}
+	app.UseLogger(NopLogger)
+	if app.Logger() != NopLogger {
+		t.Error("UseLogger did not replace the logger")
+	}
+	app.UseLogger(nil)
+	if app.Logger() != slog.Default() {
+		t.Error("UseLogger(nil) should restore the default")
+	}
+}
+
+func TestContext_RequestLogger(t *testing.T) {
+	logger := &recordingLogger{}
+	app := NewWithConfig(&Config{Logger: logger})
+	app.Use(func(next HandlerFunc) HandlerFunc {
+		return func(c *Context) error {
+			c.Logger().Debug("before request id") // built before the ID is known
+			c.Set(ContextKeyRequestID, "req-1")
+			return next(c)
+		}
+	})
+	app.GET("/orders/:id", func(c *Context) error {
+		c.Logger().Info("order read", "order_id", c.Param("id"))
+		return nil
+	}).Name("Get Order")
+	app.GET("/me", func(c *Context) error {
+		c.Logger().Info("profile read")
+		return nil
+	}, func(next HandlerFunc) HandlerFunc {
+		return func(c *Context) error {
+			c.SetLogger(LoggerWith(c.Logger(), "user_id", 7))
+			return next(c)
+		}
+	})
+
+	app.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/orders/9", nil))
+	app.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/me", nil))
+
+	want := []string{
+		"[method GET route /orders/:id route_name Get Order]",
+		"[request_id req-1 method GET route /orders/:id route_name Get Order order_id 9]",
+		"[method GET route /me]",
+		"[request_id req-1 method GET route /me user_id 7]",
+	}
+	if len(logger.entries) != len(want) {
+		t.Fatalf("got %d entries, want %d", len(logger.entries), len(want))
+	}
+	for i, entry := range logger.entries {
+		if got := fmt.Sprint(entry.args); got != want[i] {
+			t.Errorf("entry %d (%s) args = %s, want %s", i, entry.msg, got, want[i])
+		}
+	}
+}
+
+func TestLoggerWith(t *testing.T) {
+	if LoggerWith(NopLogger) != NopLogger {
+		t.Error("LoggerWith without fields should return the logger unchanged")
+	}
+	if _, ok := LoggerWith(slog.Default(), "k", "v").(*slog.Logger); !ok {
+		t.Error("LoggerWith should keep *slog.Logger values native")
+	}
+
+	logger := &recordingLogger{}
+	base := LoggerWith(logger, "a", 1)
+	left, right := LoggerWith(base, "b", 2), LoggerWith(base, "c", 3)
+	left.Warn("left")
+	right.Warn("right")
+	if got := fmt.Sprint(logger.entries[0].args, logger.entries[1].args); got != "[a 1 b 2] [a 1 c 3]" {
+		t.Errorf("derived loggers share fields: %s", got)
+	}
+}
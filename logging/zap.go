@@ -0,0 +1,34 @@
+// Package logging adapts popular structured loggers to poltergeist.Logger:
+//
+//	app.UseLogger(logging.NewZap(zapLogger))
+//	app.UseLogger(logging.NewZerolog(zerolog.New(os.Stderr)))
+//
+// *slog.Logger needs no adapter; pass it to app.UseLogger directly.
+package logging
+
+import (
+	"go.uber.org/zap"
+
+	"github.com/gofuckbiz/poltergeist"
+)
+
+// =============================================================================
+// ZAP
+// =============================================================================
+
+// Zap adapts a zap logger
+type Zap struct {
+	sugar *zap.SugaredLogger
+}
+
+// NewZap wraps logger; key/value args become zap fields
+func NewZap(logger *zap.Logger) *Zap {
+	return &Zap{sugar: logger.WithOptions(zap.AddCallerSkip(1)).Sugar()}
+}
+
+var _ poltergeist.Logger = (*Zap)(nil)
+
+func (z *Zap) Debug(msg string, args ...any) { z.sugar.Debugw(msg, args...) }
+func (z *Zap) Info(msg string, args ...any)  { z.sugar.Infow(msg, args...) }
+func (z *Zap) Warn(msg string, args ...any)  { z.sugar.Warnw(msg, args...) }
+func (z *Zap) Error(msg string, args ...any) { z.sugar.Errorw(msg, args...) }
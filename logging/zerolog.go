@@ -0,0 +1,40 @@
+package logging
+
+import (
+	"github.com/rs/zerolog"
+
+	"github.com/gofuckbiz/poltergeist"
+)
+
+// =============================================================================
+// ZEROLOG
+// =============================================================================
+
+// Zerolog adapts a zerolog logger
+type Zerolog struct {
+	logger zerolog.Logger
+}
+
+// NewZerolog wraps logger; key/value args become event fields
+func NewZerolog(logger zerolog.Logger) *Zerolog {
+	return &Zerolog{logger: logger}
+}
+
+var _ poltergeist.Logger = (*Zerolog)(nil)
+
+func (z *Zerolog) Debug(msg string, args ...any) { write(z.logger.Debug(), msg, args) }
+func (z *Zerolog) Info(msg string, args ...any)  { write(z.logger.Info(), msg, args) }
+func (z *Zerolog) Warn(msg string, args ...any)  { write(z.logger.Warn(), msg, args) }
+func (z *Zerolog) Error(msg string, args ...any) { write(z.logger.Error(), msg, args) }
+
+// write adds the key/value pairs and sends the event; a nil event means the
+// level is disabled
+func write(event *zerolog.Event, msg string, args []any) {
+	if event == nil {
+		return
+	}
+	if len(args) > 0 {
+		event = event.Fields(args)
+	}
+	event.Msg(msg)
+}
@@ -0,0 +1,232 @@
+package poltergeist
+
+import (
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+// =============================================================================
+// METRICS - Backend-agnostic instruments the framework reports into
+// =============================================================================
+
+// Metrics is a metrics backend (Prometheus, OpenTelemetry, ...). Label values
+// are passed positionally in the order the labels were declared.
+type Metrics interface {
+	Counter(name, help string, labels ...string) Counter
+	Gauge(name, help string, labels ...string) Gauge
+	Histogram(name, help string, buckets []float64, labels ...string) Histogram
+}
+
+// Counter is a monotonically increasing value
+type Counter interface {
+	Add(value float64, labelValues ...string)
+}
+
+// Gauge is a value that goes up and down
+type Gauge interface {
+	Set(value float64, labelValues ...string)
+	Add(delta float64, labelValues ...string)
+}
+
+// Histogram records a distribution of observations
+type Histogram interface {
+	Observe(value float64, labelValues ...string)
+}
+
+// Framework metric names (backends add their namespace, e.g. "poltergeist_")
+const (
+	MetricHTTPRequests   = "http_requests_total"           // method, route, status
+	MetricHTTPDuration   = "http_request_duration_seconds" // method, route
+	MetricHTTPInFlight   = "http_requests_in_flight"
+	MetricWSConnections  = "ws_connections"
+	MetricWSMessages     = "ws_messages_total" // direction: in, out
+	MetricSSEConnections = "sse_connections"
+	MetricSSEEvents      = "sse_events_total"
+	MetricPipelineEvents = "pipeline_events_total" // event
+	MetricTasks          = "tasks_total"           // status: enqueued, rejected, succeeded, failed
+	MetricTaskDuration   = "task_duration_seconds"
+	MetricTasksPending   = "tasks_pending"
+	MetricCacheRequests  = "cache_requests_total"           // operation, result
+	MetricCacheDuration  = "cache_request_duration_seconds" // operation
+)
+
+// Label values used by framework metrics
+const (
+	metricUnmatchedRoute = "unmatched"
+	metricDirectionIn    = "in"
+	metricDirectionOut   = "out"
+	metricTaskEnqueued   = "enqueued"
+	metricTaskRejected   = "rejected"
+	metricTaskSucceeded  = "succeeded"
+	metricTaskFailed     = "failed"
+	metricMethodOther    = "OTHER"
+	metricCacheHit       = "hit"
+	metricCacheMiss      = "miss"
+	metricCacheStored    = "stored"
+	metricCacheExists    = "exists"
+	metricCacheDeleted   = "deleted"
+	metricCacheError     = "error"
+)
+
+// DefaultDurationBuckets are latency histogram buckets in seconds
+var DefaultDurationBuckets = []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// --- No-op backend ---
+
+type nopMetrics struct{}
+
+func (nopMetrics) Counter(string, string, ...string) Counter                { return nopInstrument{} }
+func (nopMetrics) Gauge(string, string, ...string) Gauge                    { return nopInstrument{} }
+func (nopMetrics) Histogram(string, string, []float64, ...string) Histogram { return nopInstrument{} }
+
+type nopInstrument struct{}
+
+func (nopInstrument) Add(float64, ...string)     {}
+func (nopInstrument) Set(float64, ...string)     {}
+func (nopInstrument) Observe(float64, ...string) {}
+
+// =============================================================================
+// INSTRUMENTS - Framework instruments bound to the active backend
+// =============================================================================
+
+// instruments are the framework's instruments created on one backend
+type instruments struct {
+	backend        Metrics
+	httpRequests   Counter
+	httpDuration   Histogram
+	httpInFlight   Gauge
+	wsConnections  Gauge
+	wsMessages     Counter
+	sseConnections Gauge
+	sseEvents      Counter
+	pipelineEvents Counter
+	tasks          Counter
+	taskDuration   Histogram
+	tasksPending   Gauge
+	cacheRequests  Counter
+	cacheDuration  Histogram
+}
+
+func newInstruments(m Metrics) *instruments {
+	return &instruments{
+		backend:        m,
+		httpRequests:   m.Counter(MetricHTTPRequests, "HTTP requests served", "method", "route", "status"),
+		httpDuration:   m.Histogram(MetricHTTPDuration, "HTTP request latency in seconds", DefaultDurationBuckets, "method", "route"),
+		httpInFlight:   m.Gauge(MetricHTTPInFlight, "HTTP requests currently being served"),
+		wsConnections:  m.Gauge(MetricWSConnections, "Open WebSocket connections"),
+		wsMessages:     m.Counter(MetricWSMessages, "WebSocket messages", "direction"),
+		sseConnections: m.Gauge(MetricSSEConnections, "Open SSE streams"),
+		sseEvents:      m.Counter(MetricSSEEvents, "SSE events sent"),
+		pipelineEvents: m.Counter(MetricPipelineEvents, "Event pipeline emissions", "event"),
+		tasks:          m.Counter(MetricTasks, "Background tasks by outcome", "status"),
+		taskDuration:   m.Histogram(MetricTaskDuration, "Background task run time in seconds", DefaultDurationBuckets),
+		tasksPending:   m.Gauge(MetricTasksPending, "Background tasks waiting for a worker"),
+		cacheRequests:  m.Counter(MetricCacheRequests, "Server cache operations by result", "operation", "result"),
+		cacheDuration:  m.Histogram(MetricCacheDuration, "Server cache latency in seconds", DefaultDurationBuckets, "operation"),
+	}
+}
+
+// metricsRegistry holds the active instruments; components keep the registry
+// and always report into whichever backend is current
+type metricsRegistry struct {
+	current atomic.Pointer[instruments]
+}
+
+func newMetricsRegistry() *metricsRegistry {
+	r := &metricsRegistry{}
+	r.current.Store(newInstruments(nopMetrics{}))
+	return r
+}
+
+// use switches the backend
+func (r *metricsRegistry) use(m Metrics) {
+	if m == nil {
+		m = nopMetrics{}
+	}
+	r.current.Store(newInstruments(m))
+}
+
+// get returns the active instruments (no-op ones for a nil registry)
+func (r *metricsRegistry) get() *instruments {
+	if r == nil {
+		return nopInstruments
+	}
+	return r.current.Load()
+}
+
+var nopInstruments = newInstruments(nopMetrics{})
+
+// observeRequest records a served request
+func (m *instruments) observeRequest(c *Context, start time.Time) {
+	route := metricUnmatchedRoute
+	if c.route != nil {
+		route = c.route.Path
+	}
+	method := metricMethod(c.Request.Method)
+	m.httpRequests.Add(1, method, route, strconv.Itoa(c.statusCode))
+	m.httpDuration.Observe(time.Since(start).Seconds(), method, route)
+}
+
+// metricMethod bounds the method label: clients choose the method, so
+// anything non-standard is reported as OTHER
+func metricMethod(method string) string {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch,
+		http.MethodDelete, http.MethodOptions, http.MethodConnect, http.MethodTrace:
+		return method
+	}
+	return metricMethodOther
+}
+
+// observeCache records one cache operation
+func (m *instruments) observeCache(operation, result string, start time.Time) {
+	m.cacheRequests.Add(1, operation, result)
+	m.cacheDuration.Observe(time.Since(start).Seconds(), operation)
+}
+
+// =============================================================================
+// SERVER INTEGRATION
+// =============================================================================
+
+// UseMetrics makes the router, event pipeline, WebSocket/SSE hubs and task
+// queue report into m; call before Run
+//
+//	prom := metrics.NewPrometheus("poltergeist")
+//	app.UseMetrics(prom)
+//	app.MetricsEndpoint() // GET /metrics
+func (s *Server) UseMetrics(m Metrics) *Server {
+	s.router.pipeline.metrics.use(m)
+	return s
+}
+
+// Metrics returns the active backend so applications can register their own
+// instruments alongside the framework's
+func (s *Server) Metrics() Metrics {
+	return s.router.pipeline.metrics.get().backend
+}
+
+// Metrics returns the server's metrics backend, for middleware that
+// records its own instruments
+func (c *Context) Metrics() Metrics {
+	return c.pipeline.instruments().backend
+}
+
+// MetricsEndpoint serves the backend on path (default "/metrics") when it is
+// an http.Handler, such as the Prometheus backend. Push-based backends like
+// OpenTelemetry export through their own provider and return nil.
+func (s *Server) MetricsEndpoint(path ...string) *Route {
+	handler, ok := s.Metrics().(http.Handler)
+	if !ok {
+		return nil
+	}
+	p := "/metrics"
+	if len(path) > 0 && path[0] != "" {
+		p = path[0]
+	}
+	return s.GET(p, func(c *Context) error {
+		handler.ServeHTTP(c.Writer, c.Request)
+		return nil
+	}).Hidden()
+}
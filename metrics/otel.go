@@ -0,0 +1,132 @@
+package metrics
+
+import (
+	"context"
+	"strings"
+	"sync"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+
+	"github.com/gofuckbiz/poltergeist"
+)
+
+// =============================================================================
+// OPENTELEMETRY - Adapter onto an OTel Meter
+// =============================================================================
+
+// OTel reports into an OpenTelemetry Meter; export is handled by the
+// application's MeterProvider (OTLP, Prometheus exporter, ...)
+type OTel struct {
+	meter     metric.Meter
+	namespace string
+}
+
+// NewOTel creates a backend on meter; namespace prefixes every metric name the
+// same way as the Prometheus backend
+func NewOTel(meter metric.Meter, namespace string) *OTel {
+	return &OTel{meter: meter, namespace: namespace}
+}
+
+// name prefixes a metric name with the namespace
+func (o *OTel) name(name string) string {
+	if o.namespace == "" {
+		return name
+	}
+	return o.namespace + "_" + name
+}
+
+// Counter creates a Float64Counter
+func (o *OTel) Counter(name, help string, labels ...string) poltergeist.Counter {
+	counter, err := o.meter.Float64Counter(o.name(name), metric.WithDescription(help))
+	if err != nil {
+		otel.Handle(err)
+	}
+	return &otelCounter{counter: counter, labels: labels}
+}
+
+// Gauge creates a Float64UpDownCounter; Set is applied as the difference
+// from the last value set for the same labels
+func (o *OTel) Gauge(name, help string, labels ...string) poltergeist.Gauge {
+	gauge, err := o.meter.Float64UpDownCounter(o.name(name), metric.WithDescription(help))
+	if err != nil {
+		otel.Handle(err)
+	}
+	return &otelGauge{gauge: gauge, labels: labels, last: make(map[string]float64)}
+}
+
+// Histogram creates a Float64Histogram with explicit bucket boundaries
+func (o *OTel) Histogram(name, help string, buckets []float64, labels ...string) poltergeist.Histogram {
+	if len(buckets) == 0 {
+		buckets = poltergeist.DefaultDurationBuckets
+	}
+	opts := []metric.Float64HistogramOption{
+		metric.WithDescription(help),
+		metric.WithExplicitBucketBoundaries(buckets...),
+	}
+	if strings.HasSuffix(name, "_seconds") {
+		opts = append(opts, metric.WithUnit("s"))
+	}
+	histogram, err := o.meter.Float64Histogram(o.name(name), opts...)
+	if err != nil {
+		otel.Handle(err)
+	}
+	return &otelHistogram{histogram: histogram, labels: labels}
+}
+
+// --- Instruments ---
+
+type otelCounter struct {
+	counter metric.Float64Counter
+	labels  []string
+}
+
+func (c *otelCounter) Add(value float64, labelValues ...string) {
+	c.counter.Add(context.Background(), value, attributes(c.labels, labelValues))
+}
+
+type otelGauge struct {
+	gauge  metric.Float64UpDownCounter
+	labels []string
+	mu     sync.Mutex
+	last   map[string]float64
+}
+
+func (g *otelGauge) Add(delta float64, labelValues ...string) {
+	g.mu.Lock()
+	g.last[strings.Join(labelValues, "\xff")] += delta
+	g.mu.Unlock()
+	g.gauge.Add(context.Background(), delta, attributes(g.labels, labelValues))
+}
+
+func (g *otelGauge) Set(value float64, labelValues ...string) {
+	key := strings.Join(labelValues, "\xff")
+	g.mu.Lock()
+	delta := value - g.last[key]
+	g.last[key] = value
+	g.mu.Unlock()
+	g.gauge.Add(context.Background(), delta, attributes(g.labels, labelValues))
+}
+
+type otelHistogram struct {
+	histogram metric.Float64Histogram
+	labels    []string
+}
+
+func (h *otelHistogram) Observe(value float64, labelValues ...string) {
+	h.histogram.Record(context.Background(), value, attributes(h.labels, labelValues))
+}
+
+// attributes pairs label names with positional values
+func attributes(labels, values []string) metric.MeasurementOption {
+	kv := make([]attribute.KeyValue, 0, len(labels))
+	for i, label := range labels {
+		value := ""
+		if i < len(values) {
+			value = values[i]
+		}
+		kv = append(kv, attribute.String(label, value))
+	}
+	return metric.WithAttributes(kv...)
+}
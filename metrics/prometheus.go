@@ -0,0 +1,264 @@
+// Package metrics provides backends for poltergeist.Metrics:
+//
+//	prom := metrics.NewPrometheus("poltergeist")
+//	app.UseMetrics(prom)
+//	app.MetricsEndpoint() // GET /metrics in Prometheus text format
+//
+//	app.UseMetrics(metrics.NewOTel(otel.Meter("poltergeist"), "poltergeist"))
+//
+// Both backends use the same metric names, so dashboards work unchanged
+// whichever one is deployed.
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gofuckbiz/poltergeist"
+)
+
+// =============================================================================
+// PROMETHEUS - In-process registry with text exposition
+// =============================================================================
+
+// Metric kinds as written in # TYPE lines
+const (
+	kindCounter   = "counter"
+	kindGauge     = "gauge"
+	kindHistogram = "histogram"
+)
+
+// Prometheus is a self-contained registry served in the Prometheus text
+// format (version 0.0.4); it needs no client library
+type Prometheus struct {
+	namespace string
+	mu        sync.RWMutex
+	families  map[string]*family
+}
+
+// NewPrometheus creates a registry; namespace prefixes every metric name
+func NewPrometheus(namespace string) *Prometheus {
+	return &Prometheus{namespace: namespace, families: make(map[string]*family)}
+}
+
+// Counter registers (or returns the existing) counter
+func (p *Prometheus) Counter(name, help string, labels ...string) poltergeist.Counter {
+	return p.family(name, help, kindCounter, nil, labels)
+}
+
+// Gauge registers (or returns the existing) gauge
+func (p *Prometheus) Gauge(name, help string, labels ...string) poltergeist.Gauge {
+	return p.family(name, help, kindGauge, nil, labels)
+}
+
+// Histogram registers (or returns the existing) histogram; nil buckets use
+// poltergeist.DefaultDurationBuckets
+func (p *Prometheus) Histogram(name, help string, buckets []float64, labels ...string) poltergeist.Histogram {
+	if len(buckets) == 0 {
+		buckets = poltergeist.DefaultDurationBuckets
+	}
+	sorted := append([]float64(nil), buckets...)
+	sort.Float64s(sorted)
+	return p.family(name, help, kindHistogram, sorted, labels)
+}
+
+// family returns the named family, creating it on first use. Registering a
+// name again with a different kind or label set panics, as that is a bug.
+func (p *Prometheus) family(name, help, kind string, buckets []float64, labels []string) *family {
+	fullName := name
+	if p.namespace != "" {
+		fullName = p.namespace + "_" + name
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if f, ok := p.families[fullName]; ok {
+		if f.kind != kind || strings.Join(f.labels, ",") != strings.Join(labels, ",") {
+			panic(fmt.Sprintf("metrics: %s re-registered as %s%v (was %s%v)", fullName, kind, labels, f.kind, f.labels))
+		}
+		return f
+	}
+
+	f := &family{
+		name:    fullName,
+		help:    help,
+		kind:    kind,
+		labels:  labels,
+		buckets: buckets,
+		series:  make(map[string]*series),
+	}
+	p.families[fullName] = f
+	return f
+}
+
+// ServeHTTP writes every family in the text exposition format
+func (p *Prometheus) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set(poltergeist.HeaderContentType, "text/plain; version=0.0.4; charset=utf-8")
+	p.Expose(w)
+}
+
+// Expose writes the exposition to w
+func (p *Prometheus) Expose(w io.Writer) error {
+	p.mu.RLock()
+	names := make([]string, 0, len(p.families))
+	for name := range p.families {
+		names = append(names, name)
+	}
+	families := make([]*family, len(names))
+	sort.Strings(names)
+	for i, name := range names {
+		families[i] = p.families[name]
+	}
+	p.mu.RUnlock()
+
+	var sb strings.Builder
+	for _, f := range families {
+		f.write(&sb)
+	}
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// =============================================================================
+// FAMILIES - One metric name with its labelled series
+// =============================================================================
+
+// family is a metric and all of its label combinations
+type family struct {
+	name    string
+	help    string
+	kind    string
+	labels  []string
+	buckets []float64
+
+	mu     sync.Mutex
+	series map[string]*series
+}
+
+// series is one label combination
+type series struct {
+	labelValues []string
+	value       float64  // counter, gauge
+	counts      []uint64 // histogram: per bucket (non-cumulative)
+	sum         float64
+	count       uint64
+}
+
+// get returns the series for label values (mu held)
+func (f *family) get(labelValues []string) *series {
+	key := strings.Join(labelValues, "\xff")
+	s, ok := f.series[key]
+	if !ok {
+		values := make([]string, len(f.labels))
+		copy(values, labelValues)
+		s = &series{labelValues: values}
+		if f.kind == kindHistogram {
+			s.counts = make([]uint64, len(f.buckets))
+		}
+		f.series[key] = s
+	}
+	return s
+}
+
+// Add increments a counter or gauge
+func (f *family) Add(value float64, labelValues ...string) {
+	f.mu.Lock()
+	f.get(labelValues).value += value
+	f.mu.Unlock()
+}
+
+// Set sets a gauge
+func (f *family) Set(value float64, labelValues ...string) {
+	f.mu.Lock()
+	f.get(labelValues).value = value
+	f.mu.Unlock()
+}
+
+// Observe records a histogram observation
+func (f *family) Observe(value float64, labelValues ...string) {
+	f.mu.Lock()
+	s := f.get(labelValues)
+	if i := sort.SearchFloat64s(f.buckets, value); i < len(f.buckets) {
+		s.counts[i]++
+	}
+	s.sum += value
+	s.count++
+	f.mu.Unlock()
+}
+
+// write renders the family
+func (f *family) write(sb *strings.Builder) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	fmt.Fprintf(sb, "# HELP %s %s\n", f.name, escapeHelp(f.help))
+	fmt.Fprintf(sb, "# TYPE %s %s\n", f.name, f.kind)
+
+	keys := make([]string, 0, len(f.series))
+	for key := range f.series {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		s := f.series[key]
+		if f.kind != kindHistogram {
+			fmt.Fprintf(sb, "%s%s %s\n", f.name, f.labelString(s.labelValues, "", ""), formatValue(s.value))
+			continue
+		}
+
+		var cumulative uint64
+		for i, bound := range f.buckets {
+			cumulative += s.counts[i]
+			fmt.Fprintf(sb, "%s_bucket%s %d\n", f.name, f.labelString(s.labelValues, "le", formatValue(bound)), cumulative)
+		}
+		fmt.Fprintf(sb, "%s_bucket%s %d\n", f.name, f.labelString(s.labelValues, "le", "+Inf"), s.count)
+		fmt.Fprintf(sb, "%s_sum%s %s\n", f.name, f.labelString(s.labelValues, "", ""), formatValue(s.sum))
+		fmt.Fprintf(sb, "%s_count%s %d\n", f.name, f.labelString(s.labelValues, "", ""), s.count)
+	}
+}
+
+// labelString renders {a="x",b="y"} with an optional extra label
+func (f *family) labelString(values []string, extraName, extraValue string) string {
+	pairs := make([]string, 0, len(f.labels)+1)
+	for i, label := range f.labels {
+		pairs = append(pairs, label+`="`+escapeLabel(values[i])+`"`)
+	}
+	if extraName != "" {
+		pairs = append(pairs, extraName+`="`+extraValue+`"`)
+	}
+	if len(pairs) == 0 {
+		return ""
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+// --- Formatting helpers ---
+
+var (
+	helpEscaper  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+	labelEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+)
+
+func escapeHelp(s string) string  { return helpEscaper.Replace(s) }
+func escapeLabel(s string) string { return labelEscaper.Replace(s) }
+
+// formatValue renders a sample value as Prometheus expects
+func formatValue(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
@@ -0,0 +1,170 @@
+package poltergeist
+
+import (
+	"context"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// =============================================================================
+// METRICS TESTS
+// =============================================================================
+
+// recordingMetrics stores every sample by "name{label values}"
+type recordingMetrics struct {
+	mu      sync.Mutex
+	samples map[string]float64
+}
+
+type recordingInstrument struct {
+	m    *recordingMetrics
+	name string
+}
+
+func (m *recordingMetrics) Counter(name, _ string, _ ...string) Counter {
+	return &recordingInstrument{m, name}
+}
+func (m *recordingMetrics) Gauge(name, _ string, _ ...string) Gauge {
+	return &recordingInstrument{m, name}
+}
+func (m *recordingMetrics) Histogram(name, _ string, _ []float64, _ ...string) Histogram {
+	return &recordingInstrument{m, name}
+}
+
+func (i *recordingInstrument) key(values []string) string {
+	return i.name + "{" + strings.Join(values, ",") + "}"
+}
+func (i *recordingInstrument) Add(v float64, values ...string) {
+	i.m.mu.Lock()
+	i.m.samples[i.key(values)] += v
+	i.m.mu.Unlock()
+}
+func (i *recordingInstrument) Set(v float64, values ...string) {
+	i.m.mu.Lock()
+	i.m.samples[i.key(values)] = v
+	i.m.mu.Unlock()
+}
+func (i *recordingInstrument) Observe(_ float64, values ...string) { i.Add(1, values...) }
+
+func (m *recordingMetrics) get(key string) float64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.samples[key]
+}
+
+func TestMetrics_FrameworkInstruments(t *testing.T) {
+	backend := &recordingMetrics{samples: make(map[string]float64)}
+	app := New().UseMetrics(backend)
+	app.GET("/users/:id", func(c *Context) error {
+		return c.JSON(200, H{"id": c.Param("id")})
+	})
+
+	for _, path := range []string{"/users/1", "/users/2", "/missing"} {
+		app.Router().ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", path, nil))
+	}
+	for _, method := range []string{"PURGE", "X-RANDOM-1"} {
+		app.Router().ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(method, "/users/1", nil))
+	}
+
+	checks := map[string]float64{
+		MetricHTTPRequests + "{GET,/users/:id,200}":                  2,
+		MetricHTTPRequests + "{GET,unmatched,404}":                   1,
+		MetricHTTPDuration + "{GET,/users/:id}":                      2,
+		MetricHTTPRequests + "{OTHER,unmatched,405}":                 2,
+		MetricHTTPInFlight + "{}":                                    0,
+		MetricPipelineEvents + "{" + string(EventAfterRequest) + "}": 5,
+	}
+	for key, want := range checks {
+		if got := backend.get(key); got != want {
+			t.Errorf("%s = %v, want %v", key, got, want)
+		}
+	}
+
+	done := make(chan struct{})
+	if err := app.Tasks().Enqueue(func(ctx context.Context) error { close(done); return nil }); err != nil {
+		t.Fatal(err)
+	}
+	<-done
+	deadline := time.Now().Add(time.Second)
+	for backend.get(MetricTasks+"{succeeded}") != 1 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if got := backend.get(MetricTasks + "{enqueued}"); got != 1 {
+		t.Errorf("enqueued tasks = %v, want 1", got)
+	}
+	if got := backend.get(MetricTasks + "{succeeded}"); got != 1 {
+		t.Errorf("succeeded tasks = %v, want 1", got)
+	}
+	app.Tasks().Shutdown(context.Background())
+}
+
+func TestMetrics_Cache(t *testing.T) {
+	backend := &recordingMetrics{samples: make(map[string]float64)}
+	app := New().UseCache(&mapCache{items: map[string][]byte{}}).UseMetrics(backend)
+	ctx := context.Background()
+	store := app.Cache()
+
+	store.Get(ctx, "a")
+	store.Set(ctx, "a", []byte("1"), time.Minute)
+	store.Get(ctx, "a")
+	store.Add(ctx, "a", []byte("2"), time.Minute)
+	store.Add(ctx, "b", []byte("2"), time.Minute)
+	store.Delete(ctx, "a")
+
+	checks := map[string]float64{
+		MetricCacheRequests + "{get,miss}":       1,
+		MetricCacheRequests + "{get,hit}":        1,
+		MetricCacheRequests + "{set,stored}":     1,
+		MetricCacheRequests + "{add,exists}":     1,
+		MetricCacheRequests + "{add,stored}":     1,
+		MetricCacheRequests + "{delete,deleted}": 1,
+		MetricCacheDuration + "{get}":            2,
+	}
+	for key, want := range checks {
+		if got := backend.get(key); got != want {
+			t.Errorf("%s = %v, want %v", key, got, want)
+		}
+	}
+}
+
+func TestMetrics_Endpoint(t *testing.T) {
+	app := New()
+	if route := app.MetricsEndpoint(); route != nil {
+		t.Error("MetricsEndpoint should be nil for a backend that is not an http.Handler")
+	}
+	if _, ok := app.Metrics().(nopMetrics); !ok {
+		t.Errorf("default backend = %T, want nopMetrics", app.Metrics())
+	}
+}
+
+func TestMetrics_ContextAccessors(t *testing.T) {
+	backend := &recordingMetrics{samples: make(map[string]float64)}
+	app := New().UseMetrics(backend)
+	app.OnErrorType(errNoRows, func(c *Context, err error) *HTTPError {
+		return ErrNotFound
+	})
+
+	var gotBackend Metrics
+	var gotCode, gotStatus int
+	app.GET("/lookup", func(c *Context) error {
+		gotBackend = c.Metrics()
+		gotCode = c.ResolveError(errNoRows).Code
+		c.Status(202)
+		gotStatus = c.StatusCode()
+		return c.String(202, "accepted")
+	})
+	app.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/lookup", nil))
+
+	if gotBackend != backend {
+		t.Errorf("c.Metrics() = %T, want the server backend", gotBackend)
+	}
+	if gotCode != 404 {
+		t.Errorf("ResolveError code = %d, want 404 from OnErrorType", gotCode)
+	}
+	if gotStatus != 202 {
+		t.Errorf("StatusCode = %d, want 202", gotStatus)
+	}
+}
@@ -0,0 +1,389 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gofuckbiz/poltergeist"
+)
+
+// =============================================================================
+// AUDIT - Structured records of write operations
+// =============================================================================
+
+// Audit outcomes
+const (
+	AuditSuccess = "success" // 1xx-3xx
+	AuditDenied  = "denied"  // 401, 403
+	AuditFailure = "failure" // other 4xx and 5xx
+)
+
+// AuditRecord describes one audited request
+type AuditRecord struct {
+	Time      time.Time              `json:"time"`
+	Actor     string                 `json:"actor"`
+	Action    string                 `json:"action"`
+	Resource  string                 `json:"resource"`
+	Outcome   string                 `json:"outcome"`
+	Status    int                    `json:"status"`
+	RequestID string                 `json:"request_id,omitempty"`
+	IP        string                 `json:"ip"`
+	Duration  time.Duration          `json:"duration"`
+	Error     string                 `json:"error,omitempty"`
+	Changes   map[string]FieldChange `json:"changes,omitempty"`
+	Metadata  map[string]any         `json:"metadata,omitempty"`
+}
+
+// FieldChange is a field's value before and after a write
+type FieldChange struct {
+	From any `json:"from"`
+	To   any `json:"to"`
+}
+
+// AuditSink stores audit records. Write is called synchronously once the
+// handler returns, or panics; failures are logged and don't affect the
+// response.
+type AuditSink interface {
+	Write(ctx context.Context, record AuditRecord) error
+}
+
+// AuditSinkFunc adapts a function to AuditSink
+type AuditSinkFunc func(ctx context.Context, record AuditRecord) error
+
+// Write calls f
+func (f AuditSinkFunc) Write(ctx context.Context, record AuditRecord) error {
+	return f(ctx, record)
+}
+
+// errAuditPanic is recorded for handlers that panicked
+var errAuditPanic = errors.New("handler panicked")
+
+// AuditConfig holds audit middleware configuration
+type AuditConfig struct {
+	// Where records go (default: "audit" entries on the server logger)
+	Sink AuditSink
+	// Methods audited (default: POST, PUT, PATCH, DELETE)
+	Methods []string
+	// Who made the request (default: the principal set by auth middleware
+	// if it is a string, token claims (their "sub") or a fmt.Stringer, else
+	// the Basic auth username, else "anonymous")
+	ActorFunc func(c *poltergeist.Context) string
+	// What was done (default: route name, else "<METHOD> <route pattern>")
+	ActionFunc func(c *poltergeist.Context) string
+	// What it was done to (default: the request path)
+	ResourceFunc func(c *poltergeist.Context) string
+	// Computes field changes from AuditDiff's before and after values
+	// (default: JSONDiff)
+	DiffFunc func(before, after any) map[string]FieldChange
+	// Field names whose changed values are replaced by "[REDACTED]"
+	RedactFields []string
+	// Skip function
+	SkipFunc Skipper
+}
+
+// DefaultAuditConfig returns default audit configuration
+func DefaultAuditConfig() *AuditConfig {
+	return &AuditConfig{
+		Methods:      []string{http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete},
+		ActorFunc:    auditActor,
+		ActionFunc:   auditAction,
+		ResourceFunc: func(c *poltergeist.Context) string { return c.Path() },
+		DiffFunc:     JSONDiff,
+		RedactFields: []string{"password", "password_hash", "token", "secret", "api_key"},
+	}
+}
+
+// Audit returns a middleware writing an audit record for every write
+// request to sink
+func Audit(sink AuditSink) poltergeist.MiddlewareFunc {
+	return AuditWithConfig(&AuditConfig{Sink: sink})
+}
+
+// AuditWithConfig returns an audit middleware with custom config. Handlers
+// enrich the record with AuditDiff and AuditSet:
+//
+//	api.Use(middleware.Audit(middleware.NewAuditJSONSink(auditFile)))
+//
+//	api.PUT("/users/:id", func(c *poltergeist.Context) error {
+//	    before, _ := repo.Find(c.Param("id"))
+//	    after, err := repo.Update(c.Param("id"), input)
+//	    if err != nil {
+//	        return err
+//	    }
+//	    middleware.AuditDiff(c, before, after)
+//	    middleware.AuditSet(c, "tenant", tenantID)
+//	    return c.JSON(200, after)
+//	})
+func AuditWithConfig(config *AuditConfig) poltergeist.MiddlewareFunc {
+	cfg := getAuditConfig(config)
+	methods := make(map[string]bool, len(cfg.Methods))
+	for _, m := range cfg.Methods {
+		methods[strings.ToUpper(m)] = true
+	}
+	redact := make(map[string]bool, len(cfg.RedactFields))
+	for _, name := range cfg.RedactFields {
+		redact[strings.ToLower(name)] = true
+	}
+
+	writeRecord := func(c *poltergeist.Context, entry *auditEntry, start time.Time, status int, err error) {
+		record := AuditRecord{
+			Time:      start.UTC(),
+			Actor:     cfg.ActorFunc(c),
+			Action:    cfg.ActionFunc(c),
+			Resource:  cfg.ResourceFunc(c),
+			Outcome:   auditOutcome(status),
+			Status:    status,
+			RequestID: c.RequestID(),
+			IP:        c.ClientIP(),
+			Duration:  time.Since(start),
+			Metadata:  entry.metadata,
+		}
+		if err != nil {
+			record.Error = err.Error()
+		}
+		if entry.diffed {
+			record.Changes = cfg.DiffFunc(entry.before, entry.after)
+			for field := range record.Changes {
+				if redact[strings.ToLower(field)] {
+					record.Changes[field] = FieldChange{From: "[REDACTED]", To: "[REDACTED]"}
+				}
+			}
+		}
+
+		if cfg.Sink == nil {
+			logAuditRecord(c.Logger(), record)
+		} else if writeErr := cfg.Sink.Write(context.WithoutCancel(c.Context()), record); writeErr != nil {
+			c.Logger().Error("audit sink failed", "action", record.Action, "error", writeErr)
+		}
+	}
+
+	return func(next poltergeist.HandlerFunc) poltergeist.HandlerFunc {
+		return func(c *poltergeist.Context) error {
+			// Skip if configured
+			if (cfg.SkipFunc != nil && cfg.SkipFunc(c)) || !methods[c.Method()] {
+				return next(c)
+			}
+
+			start := time.Now()
+			entry := &auditEntry{}
+			c.Set(contextKeyAudit, entry)
+
+			mw := &metricsWriter{ResponseWriter: c.Writer}
+			c.Writer = mw
+			finished := false
+			defer func() {
+				if !finished {
+					// The handler panicked: record the attempt, and let the
+					// panic carry on to Recover
+					c.Writer = mw.ResponseWriter
+					writeRecord(c, entry, start, http.StatusInternalServerError, errAuditPanic)
+				}
+			}()
+			err := next(c)
+			finished = true
+			c.Writer = mw.ResponseWriter
+
+			writeRecord(c, entry, start, mw.statusFor(c, err), err)
+			return err
+		}
+	}
+}
+
+// getAuditConfig fills unset fields with defaults
+func getAuditConfig(config *AuditConfig) *AuditConfig {
+	defaults := DefaultAuditConfig()
+	if config == nil {
+		config = defaults
+	}
+	cfg := *config
+	if len(cfg.Methods) == 0 {
+		cfg.Methods = defaults.Methods
+	}
+	if cfg.ActorFunc == nil {
+		cfg.ActorFunc = defaults.ActorFunc
+	}
+	if cfg.ActionFunc == nil {
+		cfg.ActionFunc = defaults.ActionFunc
+	}
+	if cfg.ResourceFunc == nil {
+		cfg.ResourceFunc = defaults.ResourceFunc
+	}
+	if cfg.DiffFunc == nil {
+		cfg.DiffFunc = defaults.DiffFunc
+	}
+	if cfg.RedactFields == nil {
+		cfg.RedactFields = defaults.RedactFields
+	}
+	return &cfg
+}
+
+// contextKeyAudit holds the request's *auditEntry
+const contextKeyAudit = "audit"
+
+// auditEntry collects what handlers add to the record
+type auditEntry struct {
+	mu       sync.Mutex
+	before   any
+	after    any
+	diffed   bool
+	metadata map[string]any
+}
+
+// AuditDiff records the state of the written resource before and after
+// the change; the record lists the fields that differ. A no-op without
+// the Audit middleware.
+func AuditDiff(c *poltergeist.Context, before, after any) {
+	if entry := auditEntryFrom(c); entry != nil {
+		entry.mu.Lock()
+		entry.before, entry.after, entry.diffed = before, after, true
+		entry.mu.Unlock()
+	}
+}
+
+// AuditSet adds a metadata value to the request's audit record. A no-op
+// without the Audit middleware.
+func AuditSet(c *poltergeist.Context, key string, value any) {
+	if entry := auditEntryFrom(c); entry != nil {
+		entry.mu.Lock()
+		if entry.metadata == nil {
+			entry.metadata = make(map[string]any)
+		}
+		entry.metadata[key] = value
+		entry.mu.Unlock()
+	}
+}
+
+// auditEntryFrom returns the request's audit entry, if audited
+func auditEntryFrom(c *poltergeist.Context) *auditEntry {
+	v, ok := c.Get(contextKeyAudit)
+	if !ok {
+		return nil
+	}
+	entry, _ := v.(*auditEntry)
+	return entry
+}
+
+// JSONDiff compares before and after by their JSON encoding, field by
+// field for objects; nil stands for a created or deleted resource
+func JSONDiff(before, after any) map[string]FieldChange {
+	from, to := jsonFields(before), jsonFields(after)
+	changes := make(map[string]FieldChange)
+	for field, value := range from {
+		if other, ok := to[field]; !ok || !reflect.DeepEqual(value, other) {
+			changes[field] = FieldChange{From: value, To: to[field]}
+		}
+	}
+	for field, value := range to {
+		if _, ok := from[field]; !ok {
+			changes[field] = FieldChange{To: value}
+		}
+	}
+	return changes
+}
+
+// jsonFields decodes v's JSON object form; non-objects become a single
+// "value" field
+func jsonFields(v any) map[string]any {
+	if v == nil {
+		return nil
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return map[string]any{"value": fmt.Sprint(v)}
+	}
+	var fields map[string]any
+	if json.Unmarshal(data, &fields) != nil {
+		var value any
+		json.Unmarshal(data, &value)
+		return map[string]any{"value": value}
+	}
+	return fields
+}
+
+// auditActor names the caller from what auth middleware stored, as
+// Authorize does
+func auditActor(c *poltergeist.Context) string {
+	if subject := principalSubject(c); subject != "" {
+		return subject
+	}
+	return "anonymous"
+}
+
+// auditAction names the operation by route
+func auditAction(c *poltergeist.Context) string {
+	if route := c.Route(); route != nil {
+		if route.RouteName != "" {
+			return route.RouteName
+		}
+		return c.Method() + " " + route.Path
+	}
+	return c.Method() + " " + c.Path()
+}
+
+// auditOutcome classifies a response status
+func auditOutcome(status int) string {
+	switch {
+	case status == http.StatusUnauthorized || status == http.StatusForbidden:
+		return AuditDenied
+	case status >= 400:
+		return AuditFailure
+	}
+	return AuditSuccess
+}
+
+// =============================================================================
+// AUDIT SINKS
+// =============================================================================
+
+// NewAuditLogSink writes records as structured "audit" entries to logger
+func NewAuditLogSink(logger poltergeist.Logger) AuditSink {
+	return AuditSinkFunc(func(_ context.Context, r AuditRecord) error {
+		logAuditRecord(logger, r)
+		return nil
+	})
+}
+
+// logAuditRecord logs a record as an "audit" entry
+func logAuditRecord(l poltergeist.Logger, r AuditRecord) {
+	args := []any{
+		"actor", r.Actor, "action", r.Action, "resource", r.Resource,
+		"outcome", r.Outcome, "status", r.Status, "ip", r.IP, "duration", r.Duration,
+	}
+	if r.RequestID != "" {
+		args = append(args, "request_id", r.RequestID)
+	}
+	if r.Error != "" {
+		args = append(args, "error", r.Error)
+	}
+	if len(r.Changes) > 0 {
+		args = append(args, "changes", r.Changes)
+	}
+	if len(r.Metadata) > 0 {
+		args = append(args, "metadata", r.Metadata)
+	}
+	l.Info("audit", args...)
+}
+
+// NewAuditJSONSink writes records as JSON lines to w, e.g. an append-only
+// file shipped to a SIEM
+func NewAuditJSONSink(w io.Writer) AuditSink {
+	var mu sync.Mutex
+	return AuditSinkFunc(func(_ context.Context, r AuditRecord) error {
+		data, err := json.Marshal(r)
+		if err != nil {
+			return err
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		_, err = w.Write(append(data, '\n'))
+		return err
+	})
+}
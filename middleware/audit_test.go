@@ -0,0 +1,132 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/gofuckbiz/poltergeist"
+)
+
+// =============================================================================
+// AUDIT TESTS
+// =============================================================================
+
+// auditRecorder is a sink keeping the records it gets
+type auditRecorder struct {
+	mu      sync.Mutex
+	records []AuditRecord
+}
+
+func (r *auditRecorder) Write(_ context.Context, record AuditRecord) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.records = append(r.records, record)
+	return nil
+}
+
+// last returns the latest record, failing without one
+func (r *auditRecorder) last(t *testing.T) AuditRecord {
+	t.Helper()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.records) == 0 {
+		t.Fatal("no audit record written")
+	}
+	return r.records[len(r.records)-1]
+}
+
+func TestAudit_Actor(t *testing.T) {
+	tests := []struct {
+		name      string
+		principal any
+		username  string
+		want      string
+	}{
+		{"string", "svc-1", "", "svc-1"},
+		{"stringer", &testUser{id: "u1"}, "", "u1"},
+		{"token claims", testClaims{"sub": "oidc-user"}, "", "oidc-user"},
+		{"basic auth", nil, "ada", "ada"},
+		{"nobody", nil, "", "anonymous"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sink := &auditRecorder{}
+			app := poltergeist.New()
+			app.Use(withPrincipal(tt.principal), func(next poltergeist.HandlerFunc) poltergeist.HandlerFunc {
+				return func(c *poltergeist.Context) error {
+					if tt.username != "" {
+						c.Set("username", tt.username)
+					}
+					return next(c)
+				}
+			}, Audit(sink))
+			app.POST("/users", func(c *poltergeist.Context) error { return c.NoContent() })
+
+			app.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/users", nil))
+			if got := sink.last(t).Actor; got != tt.want {
+				t.Errorf("Actor = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAudit_Record(t *testing.T) {
+	sink := &auditRecorder{}
+	app := poltergeist.New()
+	app.Use(Audit(sink))
+	app.PUT("/users/:id", func(c *poltergeist.Context) error {
+		AuditDiff(c,
+			map[string]any{"name": "ada", "password": "old", "role": "user"},
+			map[string]any{"name": "ada", "password": "new", "role": "admin"})
+		AuditSet(c, "reason", "promotion")
+		return c.NoContent()
+	})
+	app.GET("/users/:id", func(c *poltergeist.Context) error { return c.NoContent() })
+	app.DELETE("/users/:id", func(c *poltergeist.Context) error { return poltergeist.ErrForbidden })
+
+	app.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPut, "/users/7", nil))
+	record := sink.last(t)
+	if record.Action != "PUT /users/:id" || record.Resource != "/users/7" || record.Outcome != AuditSuccess || record.Status != http.StatusNoContent {
+		t.Errorf("record = %+v", record)
+	}
+	if len(record.Changes) != 2 || record.Changes["role"] != (FieldChange{From: "user", To: "admin"}) {
+		t.Errorf("Changes = %v, want role and password", record.Changes)
+	}
+	if change := record.Changes["password"]; change.From != "[REDACTED]" || change.To != "[REDACTED]" {
+		t.Errorf("password change = %v, want it redacted", change)
+	}
+	if record.Metadata["reason"] != "promotion" {
+		t.Errorf("Metadata = %v", record.Metadata)
+	}
+
+	app.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/users/7", nil))
+	if len(sink.records) != 1 {
+		t.Errorf("GET was audited: %d records", len(sink.records))
+	}
+
+	app.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodDelete, "/users/7", nil))
+	if record := sink.last(t); record.Outcome != AuditDenied || record.Status != http.StatusForbidden || record.Error == "" {
+		t.Errorf("denied record = %+v", record)
+	}
+}
+
+func TestAudit_Panic(t *testing.T) {
+	sink := &auditRecorder{}
+	app := poltergeist.New().UseLogger(poltergeist.NopLogger)
+	app.Use(Recovery(), Audit(sink))
+	app.POST("/boom", func(c *poltergeist.Context) error { panic("boom") })
+
+	w := httptest.NewRecorder()
+	app.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/boom", nil))
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("status = %d, want Recovery's 500", w.Code)
+	}
+	record := sink.last(t)
+	if record.Outcome != AuditFailure || record.Status != http.StatusInternalServerError || !strings.Contains(record.Error, "panic") {
+		t.Errorf("record = %+v, want a failure for the panic", record)
+	}
+}
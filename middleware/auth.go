@@ -3,7 +3,9 @@ package middleware
 import (
 	"crypto/subtle"
 	"encoding/base64"
+	"errors"
 	"net/http"
+	"reflect"
 	"strings"
 
 	"github.com/gofuckbiz/poltergeist"
@@ -16,7 +18,7 @@ type BasicAuthConfig struct {
 	// Realm name
 	Realm string
 	// Skip function
-	SkipFunc func(c *poltergeist.Context) bool
+	SkipFunc Skipper
 }
 
 // BasicAuth returns a Basic Auth middleware
@@ -100,7 +102,7 @@ type BearerAuthConfig struct {
 	// Token validator function
 	Validator func(token string, c *poltergeist.Context) bool
 	// Skip function
-	SkipFunc func(c *poltergeist.Context) bool
+	SkipFunc Skipper
 	// Error message
 	ErrorMessage string
 }
@@ -159,10 +161,18 @@ type APIKeyConfig struct {
 	HeaderName string
 	// Query parameter name (default: api_key)
 	QueryName string
-	// Validator function
+	// Cookie name (default: "", cookies are not read). Cookies are sent by
+	// browsers automatically, so pair this with CSRF protection.
+	CookieName string
+	// Validator function (used when Lookup is nil)
 	Validator func(key string, c *poltergeist.Context) bool
+	// Lookup resolves a key to its principal (user, service account, ...),
+	// stored under poltergeist.ContextKeyPrincipal. A nil principal (unknown
+	// key) sends 401. Returning an *poltergeist.HTTPError sends that status;
+	// other errors send 401.
+	Lookup func(key string) (any, error)
 	// Skip function
-	SkipFunc func(c *poltergeist.Context) bool
+	SkipFunc Skipper
 }
 
 // APIKeyAuth returns an API key auth middleware
@@ -174,8 +184,24 @@ func APIKeyAuth(validator func(key string, c *poltergeist.Context) bool) polterg
 	})
 }
 
-// APIKeyAuthWithConfig returns an API key auth middleware with custom config
+// APIKey returns an API key middleware that resolves the key (from the
+// X-API-Key header or api_key query parameter) to a principal:
+//
+//	app.Use(middleware.APIKey(func(key string) (any, error) {
+//	    return store.ClientByKey(key)
+//	}))
+//	client := poltergeist.MustGetAs[*Client](c, poltergeist.ContextKeyPrincipal)
+func APIKey(lookup func(key string) (any, error)) poltergeist.MiddlewareFunc {
+	return APIKeyAuthWithConfig(&APIKeyConfig{Lookup: lookup})
+}
+
+// APIKeyAuthWithConfig returns an API key auth middleware with custom config.
+// The key is read from the header, then the query parameter, then the cookie.
+// It panics if neither Lookup nor Validator is set.
 func APIKeyAuthWithConfig(config *APIKeyConfig) poltergeist.MiddlewareFunc {
+	if config.Lookup == nil && config.Validator == nil {
+		panic("middleware: APIKeyAuth requires a Lookup or Validator")
+	}
 	headerName := config.HeaderName
 	if headerName == "" {
 		headerName = "X-API-Key"
@@ -198,6 +224,11 @@ func APIKeyAuthWithConfig(config *APIKeyConfig) poltergeist.MiddlewareFunc {
 				// Try query parameter
 				key = c.Query(queryName)
 			}
+			if key == "" && config.CookieName != "" {
+				if cookie, err := c.Request.Cookie(config.CookieName); err == nil {
+					key = cookie.Value
+				}
+			}
 
 			if key == "" {
 				return c.JSON(http.StatusUnauthorized, map[string]string{
@@ -205,8 +236,22 @@ func APIKeyAuthWithConfig(config *APIKeyConfig) poltergeist.MiddlewareFunc {
 				})
 			}
 
-			// Validate key
-			if !config.Validator(key, c) {
+			// Resolve or validate key
+			if config.Lookup != nil {
+				principal, err := config.Lookup(key)
+				if err != nil {
+					var httpErr *poltergeist.HTTPError
+					if errors.As(err, &httpErr) {
+						return httpErr
+					}
+				}
+				if err != nil || isNilPrincipal(principal) {
+					return c.JSON(http.StatusUnauthorized, map[string]string{
+						"error": "Invalid API key",
+					})
+				}
+				c.Set(poltergeist.ContextKeyPrincipal, principal)
+			} else if !config.Validator(key, c) {
 				return c.JSON(http.StatusUnauthorized, map[string]string{
 					"error": "Invalid API key",
 				})
@@ -220,6 +265,19 @@ func APIKeyAuthWithConfig(config *APIKeyConfig) poltergeist.MiddlewareFunc {
 	}
 }
 
+// isNilPrincipal reports whether a Lookup found nothing, including typed
+// nil pointers such as a (*Client)(nil) from store.ClientByKey
+func isNilPrincipal(principal any) bool {
+	if principal == nil {
+		return true
+	}
+	switch v := reflect.ValueOf(principal); v.Kind() {
+	case reflect.Pointer, reflect.Map, reflect.Slice, reflect.Interface:
+		return v.IsNil()
+	}
+	return false
+}
+
 // StaticAPIKey returns an API key middleware with a static key
 func StaticAPIKey(validKey string) poltergeist.MiddlewareFunc {
 	return APIKeyAuth(func(key string, c *poltergeist.Context) bool {
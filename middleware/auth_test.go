@@ -0,0 +1,132 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofuckbiz/poltergeist"
+)
+
+// =============================================================================
+// API KEY TESTS
+// =============================================================================
+
+type apiClient struct {
+	Name string
+}
+
+// lookupClient knows the key "good", rejects "banned" with 403 and fails
+// on "broken"; other keys are unknown
+func lookupClient(key string) (any, error) {
+	switch key {
+	case "good":
+		return &apiClient{Name: "svc"}, nil
+	case "banned":
+		return nil, poltergeist.ErrForbidden.WithMessage("key revoked")
+	case "broken":
+		return nil, errors.New("store down")
+	}
+	var missing *apiClient // the typed nil a store lookup returns
+	return missing, nil
+}
+
+func TestAPIKey_Locations(t *testing.T) {
+	app := poltergeist.New()
+	app.Use(APIKeyAuthWithConfig(&APIKeyConfig{Lookup: lookupClient, CookieName: "api_key"}))
+	app.GET("/", func(c *poltergeist.Context) error {
+		client := poltergeist.MustGetAs[*apiClient](c, poltergeist.ContextKeyPrincipal)
+		return c.String(http.StatusOK, client.Name)
+	})
+
+	tests := []struct {
+		name   string
+		target string
+		header string
+		cookie string
+		want   int
+	}{
+		{"header", "/", "good", "", http.StatusOK},
+		{"query", "/?api_key=good", "", "", http.StatusOK},
+		{"cookie", "/", "", "good", http.StatusOK},
+		{"header wins", "/?api_key=good", "nope", "", http.StatusUnauthorized},
+		{"missing", "/", "", "", http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.target, nil)
+			if tt.header != "" {
+				req.Header.Set("X-API-Key", tt.header)
+			}
+			if tt.cookie != "" {
+				req.AddCookie(&http.Cookie{Name: "api_key", Value: tt.cookie})
+			}
+			w := httptest.NewRecorder()
+			app.ServeHTTP(w, req)
+			if w.Code != tt.want {
+				t.Errorf("status = %d, want %d: %s", w.Code, tt.want, w.Body.String())
+			}
+			if tt.want == http.StatusOK && w.Body.String() != "svc" {
+				t.Errorf("principal = %q, want svc", w.Body.String())
+			}
+		})
+	}
+}
+
+func TestAPIKey_LookupErrors(t *testing.T) {
+	var reached bool
+	app := poltergeist.New()
+	app.Use(APIKey(lookupClient))
+	app.GET("/", func(c *poltergeist.Context) error {
+		reached = true
+		return c.NoContent()
+	})
+
+	tests := []struct {
+		key  string
+		want int
+	}{
+		{"good", http.StatusNoContent},
+		{"unknown", http.StatusUnauthorized},
+		{"banned", http.StatusForbidden},
+		{"broken", http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		reached = false
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("X-API-Key", tt.key)
+		w := httptest.NewRecorder()
+		app.ServeHTTP(w, req)
+		if w.Code != tt.want || reached != (tt.want == http.StatusNoContent) {
+			t.Errorf("key %q: status %d, handler reached %v; want %d", tt.key, w.Code, reached, tt.want)
+		}
+	}
+}
+
+func TestAPIKey_Validator(t *testing.T) {
+	app := poltergeist.New()
+	app.Use(StaticAPIKey("secret"))
+	app.GET("/", func(c *poltergeist.Context) error {
+		return c.String(http.StatusOK, c.MustGet("api_key").(string))
+	})
+
+	for key, want := range map[string]int{"secret": http.StatusOK, "guess": http.StatusUnauthorized} {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("X-API-Key", key)
+		w := httptest.NewRecorder()
+		app.ServeHTTP(w, req)
+		if w.Code != want {
+			t.Errorf("key %q: status %d, want %d", key, w.Code, want)
+		}
+	}
+}
+
+func TestAPIKey_RequiresLookupOrValidator(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("APIKeyAuthWithConfig accepted a config without Lookup or Validator")
+		}
+	}()
+	APIKeyAuthWithConfig(&APIKeyConfig{HeaderName: "X-Key"})
+}
@@ -0,0 +1,358 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/gofuckbiz/poltergeist"
+)
+
+// =============================================================================
+// AUTHORIZATION - Policy-based access control (RBAC / ABAC)
+// =============================================================================
+
+// AuthzRequest is the question put to a PolicyProvider: may Subject do
+// Permission on Resource?
+type AuthzRequest struct {
+	// The principal set by auth middleware (poltergeist.ContextKeyPrincipal)
+	Principal any
+	// The principal's name, e.g. a user ID
+	Subject string
+	// The principal's roles
+	Roles []string
+	// The permission checked, e.g. "users:write"
+	Permission string
+	// What is accessed (default: the request path)
+	Resource string
+	// Request method
+	Method string
+	// Attributes for attribute-based rules: route params, AuthzConfig's
+	// AttributesFunc and the attributes passed to Can
+	Attributes map[string]any
+}
+
+// PolicyProvider decides authorization requests. Errors deny access and
+// are answered with 500.
+type PolicyProvider interface {
+	Authorize(ctx context.Context, req *AuthzRequest) (bool, error)
+}
+
+// PolicyFunc adapts a function to PolicyProvider, e.g. for ABAC rules
+//
+//	middleware.PolicyFunc(func(_ context.Context, r *middleware.AuthzRequest) (bool, error) {
+//	    return r.Attributes["owner_id"] == r.Subject || slices.Contains(r.Roles, "admin"), nil
+//	})
+type PolicyFunc func(ctx context.Context, req *AuthzRequest) (bool, error)
+
+// Authorize calls f
+func (f PolicyFunc) Authorize(ctx context.Context, req *AuthzRequest) (bool, error) {
+	return f(ctx, req)
+}
+
+// AuthzConfig holds authorization configuration
+type AuthzConfig struct {
+	// Policy deciding requests (default: the one installed by Policy)
+	Policy PolicyProvider
+	// Permissions required, all of them
+	Permissions []string
+	// Names the principal (default: a string or fmt.Stringer principal, an
+	// OIDC-style "sub" claim, else the Basic auth username)
+	SubjectFunc func(c *poltergeist.Context) string
+	// Lists the principal's roles (default: a principal's Roles() method,
+	// else its "roles" claim)
+	RolesFunc func(c *poltergeist.Context) []string
+	// What is accessed (default: the request path)
+	ResourceFunc func(c *poltergeist.Context) string
+	// Extra attributes for ABAC rules, added to the route params
+	AttributesFunc func(c *poltergeist.Context) map[string]any
+	// Custom response when access is denied (default: 403, or 401 without
+	// a principal)
+	DeniedHandler func(c *poltergeist.Context, req *AuthzRequest) error
+	// Skip function
+	SkipFunc Skipper
+}
+
+// contextKeyPolicy holds the PolicyProvider installed by Policy
+const contextKeyPolicy = "authz_policy"
+
+// errNoPolicy is reported when Authorize runs without a policy
+var errNoPolicy = errors.New("authorization: no policy provider, install middleware.Policy")
+
+// Policy returns a middleware installing provider for Authorize and Can
+// further down the chain
+func Policy(provider PolicyProvider) poltergeist.MiddlewareFunc {
+	if provider == nil {
+		panic("middleware: Policy requires a provider")
+	}
+	return func(next poltergeist.HandlerFunc) poltergeist.HandlerFunc {
+		return func(c *poltergeist.Context) error {
+			c.Set(contextKeyPolicy, provider)
+			return next(c)
+		}
+	}
+}
+
+// Authorize returns a middleware requiring every permission of the
+// principal, as decided by the policy installed with Policy. Register it
+// after the auth middleware that sets the principal.
+//
+//	rbac := middleware.NewRBAC(map[string][]string{
+//	    "admin":  {"*"},
+//	    "editor": {"posts:*", "users:read"},
+//	})
+//	app.Use(middleware.APIKey(lookupUser), middleware.Policy(rbac))
+//	app.PUT("/users/:id", updateUser, middleware.Authorize("users:write"))
+func Authorize(permissions ...string) poltergeist.MiddlewareFunc {
+	return AuthorizeWithConfig(&AuthzConfig{Permissions: permissions})
+}
+
+// AuthorizeWithConfig returns an authorization middleware with custom
+// config. It panics without Permissions, which would allow every request.
+func AuthorizeWithConfig(config *AuthzConfig) poltergeist.MiddlewareFunc {
+	cfg := getAuthzConfig(config)
+	if len(cfg.Permissions) == 0 {
+		panic("middleware: Authorize requires at least one permission")
+	}
+
+	return func(next poltergeist.HandlerFunc) poltergeist.HandlerFunc {
+		return func(c *poltergeist.Context) error {
+			// Skip if configured
+			if cfg.SkipFunc != nil && cfg.SkipFunc(c) {
+				return next(c)
+			}
+
+			policy := cfg.Policy
+			if policy == nil {
+				policy = policyFrom(c)
+			}
+			if policy == nil {
+				c.Logger().Error("authorization failed", "error", errNoPolicy)
+				return poltergeist.ErrInternalServerError.Wrap(errNoPolicy)
+			}
+
+			req := cfg.request(c, nil)
+			for _, permission := range cfg.Permissions {
+				req.Permission = permission
+				allowed, err := policy.Authorize(c.Context(), req)
+				if err != nil {
+					c.Logger().Error("authorization failed", "permission", permission, "subject", req.Subject, "error", err)
+					return poltergeist.ErrInternalServerError.Wrap(err)
+				}
+				if !allowed {
+					if cfg.DeniedHandler != nil {
+						return cfg.DeniedHandler(c, req)
+					}
+					if req.Principal == nil && req.Subject == "" {
+						return poltergeist.ErrUnauthorized
+					}
+					return poltergeist.ErrForbidden
+				}
+			}
+			return next(c)
+		}
+	}
+}
+
+// getAuthzConfig fills unset fields with defaults
+func getAuthzConfig(config *AuthzConfig) *AuthzConfig {
+	if config == nil {
+		config = &AuthzConfig{}
+	}
+	cfg := *config
+	if cfg.SubjectFunc == nil {
+		cfg.SubjectFunc = principalSubject
+	}
+	if cfg.RolesFunc == nil {
+		cfg.RolesFunc = principalRoles
+	}
+	if cfg.ResourceFunc == nil {
+		cfg.ResourceFunc = func(c *poltergeist.Context) string { return c.Path() }
+	}
+	return &cfg
+}
+
+// request builds the authorization request for c, without its permission
+func (cfg *AuthzConfig) request(c *poltergeist.Context, attributes map[string]any) *AuthzRequest {
+	principal, _ := c.Get(poltergeist.ContextKeyPrincipal)
+	req := &AuthzRequest{
+		Principal:  principal,
+		Subject:    cfg.SubjectFunc(c),
+		Roles:      cfg.RolesFunc(c),
+		Resource:   cfg.ResourceFunc(c),
+		Method:     c.Method(),
+		Attributes: make(map[string]any),
+	}
+	for name, value := range c.Params {
+		req.Attributes[name] = value
+	}
+	if cfg.AttributesFunc != nil {
+		for name, value := range cfg.AttributesFunc(c) {
+			req.Attributes[name] = value
+		}
+	}
+	for name, value := range attributes {
+		req.Attributes[name] = value
+	}
+	return req
+}
+
+// Can reports whether the principal has permission under the installed
+// policy, for checks that need the loaded resource:
+//
+//	post, _ := repo.Find(c.Param("id"))
+//	if ok, err := middleware.Can(c, "posts:edit", map[string]any{"owner_id": post.AuthorID}); err != nil || !ok {
+//	    return poltergeist.ErrForbidden
+//	}
+func Can(c *poltergeist.Context, permission string, attributes map[string]any) (bool, error) {
+	policy := policyFrom(c)
+	if policy == nil {
+		return false, errNoPolicy
+	}
+	req := getAuthzConfig(nil).request(c, attributes)
+	req.Permission = permission
+	return policy.Authorize(c.Context(), req)
+}
+
+// policyFrom returns the policy installed by Policy, or nil
+func policyFrom(c *poltergeist.Context) PolicyProvider {
+	if v, ok := c.Get(contextKeyPolicy); ok {
+		policy, _ := v.(PolicyProvider)
+		return policy
+	}
+	return nil
+}
+
+// claimsPrincipal is satisfied by token claims such as *oidc.Claims
+type claimsPrincipal interface {
+	String(name string) string
+	Strings(name string) []string
+}
+
+// principalSubject names the principal set by auth middleware
+func principalSubject(c *poltergeist.Context) string {
+	if v, ok := c.Get(poltergeist.ContextKeyPrincipal); ok {
+		switch p := v.(type) {
+		case string:
+			return p
+		case claimsPrincipal:
+			return p.String("sub")
+		case fmt.Stringer:
+			return p.String()
+		}
+	}
+	return c.GetString("username")
+}
+
+// principalRoles lists the roles of the principal set by auth middleware
+func principalRoles(c *poltergeist.Context) []string {
+	if v, ok := c.Get(poltergeist.ContextKeyPrincipal); ok {
+		switch p := v.(type) {
+		case interface{ Roles() []string }:
+			return p.Roles()
+		case claimsPrincipal:
+			return p.Strings("roles")
+		}
+	}
+	return nil
+}
+
+// =============================================================================
+// RBAC - Role-based policy
+// =============================================================================
+
+// RBAC is a role-based PolicyProvider: a request is allowed when one of
+// the principal's roles, or a role it inherits, grants the permission.
+// Grants may end in a wildcard segment ("posts:*"), and "*" grants
+// everything.
+type RBAC struct {
+	mu       sync.RWMutex
+	grants   map[string][]string
+	inherits map[string][]string
+}
+
+// NewRBAC creates a role-based policy from permissions per role
+func NewRBAC(roles map[string][]string) *RBAC {
+	r := &RBAC{grants: make(map[string][]string), inherits: make(map[string][]string)}
+	for role, permissions := range roles {
+		r.Grant(role, permissions...)
+	}
+	return r
+}
+
+// Grant gives role permissions
+func (r *RBAC) Grant(role string, permissions ...string) *RBAC {
+	r.mu.Lock()
+	r.grants[role] = append(r.grants[role], permissions...)
+	r.mu.Unlock()
+	return r
+}
+
+// Inherit gives role every permission of parents
+func (r *RBAC) Inherit(role string, parents ...string) *RBAC {
+	r.mu.Lock()
+	r.inherits[role] = append(r.inherits[role], parents...)
+	r.mu.Unlock()
+	return r
+}
+
+// Authorize checks req.Permission against req.Roles
+func (r *RBAC) Authorize(_ context.Context, req *AuthzRequest) (bool, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	seen := make(map[string]bool)
+	pending := append([]string(nil), req.Roles...)
+	for len(pending) > 0 {
+		role := pending[len(pending)-1]
+		pending = pending[:len(pending)-1]
+		if seen[role] {
+			continue
+		}
+		seen[role] = true
+		for _, grant := range r.grants[role] {
+			if permissionMatches(grant, req.Permission) {
+				return true, nil
+			}
+		}
+		pending = append(pending, r.inherits[role]...)
+	}
+	return false, nil
+}
+
+// permissionMatches reports whether grant covers permission
+func permissionMatches(grant, permission string) bool {
+	if grant == "*" || grant == permission {
+		return true
+	}
+	prefix, ok := strings.CutSuffix(grant, "*")
+	return ok && strings.HasSuffix(prefix, ":") && strings.HasPrefix(permission, prefix)
+}
+
+// =============================================================================
+// CASBIN - Adapter for github.com/casbin/casbin
+// =============================================================================
+
+// CasbinEnforcer is the part of *casbin.Enforcer (and SyncedEnforcer,
+// CachedEnforcer) the adapter uses, so poltergeist doesn't depend on casbin
+type CasbinEnforcer interface {
+	Enforce(rvals ...any) (bool, error)
+}
+
+// CasbinPolicy adapts a Casbin enforcer with a (sub, obj, act) request
+// definition. A permission "users:write" is enforced as object "users" and
+// action "write" (split at the last colon); one without a colon as the
+// request path and the permission. Roles are left to the model's role definitions.
+//
+//	e, _ := casbin.NewEnforcer("model.conf", "policy.csv")
+//	app.Use(middleware.Policy(middleware.CasbinPolicy(e)))
+func CasbinPolicy(e CasbinEnforcer) PolicyProvider {
+	return PolicyFunc(func(_ context.Context, req *AuthzRequest) (bool, error) {
+		obj, act := req.Resource, req.Permission
+		if i := strings.LastIndexByte(req.Permission, ':'); i >= 0 {
+			obj, act = req.Permission[:i], req.Permission[i+1:]
+		}
+		return e.Enforce(req.Subject, obj, act)
+	})
+}
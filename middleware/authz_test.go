@@ -0,0 +1,250 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/gofuckbiz/poltergeist"
+)
+
+// =============================================================================
+// AUTHORIZATION TESTS
+// =============================================================================
+
+// testUser is a principal with roles
+type testUser struct {
+	id    string
+	roles []string
+}
+
+func (u *testUser) String() string  { return u.id }
+func (u *testUser) Roles() []string { return u.roles }
+
+// testClaims stands in for token claims such as *oidc.Claims
+type testClaims map[string]any
+
+func (c testClaims) String(name string) string {
+	s, _ := c[name].(string)
+	return s
+}
+
+func (c testClaims) Strings(name string) []string {
+	s, _ := c[name].([]string)
+	return s
+}
+
+// withPrincipal returns a middleware setting principal (unless nil)
+func withPrincipal(principal any) poltergeist.MiddlewareFunc {
+	return func(next poltergeist.HandlerFunc) poltergeist.HandlerFunc {
+		return func(c *poltergeist.Context) error {
+			if principal != nil {
+				c.Set(poltergeist.ContextKeyPrincipal, principal)
+			}
+			return next(c)
+		}
+	}
+}
+
+// authzStatus serves one request through the middlewares and returns its status
+func authzStatus(t *testing.T, method, target string, middlewares ...poltergeist.MiddlewareFunc) int {
+	t.Helper()
+	app := poltergeist.New()
+	app.Use(middlewares...)
+	app.Match([]string{method}, "/posts/:id", func(c *poltergeist.Context) error { return c.NoContent() })
+	w := httptest.NewRecorder()
+	app.ServeHTTP(w, httptest.NewRequest(method, target, nil))
+	return w.Code
+}
+
+func TestRBAC_Authorize(t *testing.T) {
+	rbac := NewRBAC(map[string][]string{
+		"admin":  {"*"},
+		"editor": {"posts:*", "users:read"},
+		"viewer": {"posts:read"},
+	})
+	rbac.Inherit("lead", "editor").Inherit("editor", "viewer", "lead") // cycles are fine
+	rbac.Grant("lead", "reports:read")
+
+	tests := []struct {
+		roles      []string
+		permission string
+		want       bool
+	}{
+		{[]string{"admin"}, "anything:at-all", true},
+		{[]string{"editor"}, "posts:delete", true},
+		{[]string{"editor"}, "users:read", true},
+		{[]string{"editor"}, "users:write", false},
+		{[]string{"editor"}, "postsx:read", false},
+		{[]string{"editor"}, "reports:read", true}, // inherited from lead
+		{[]string{"lead"}, "posts:write", true},    // inherited from editor
+		{[]string{"viewer"}, "posts:write", false},
+		{[]string{"viewer", "editor"}, "posts:write", true},
+		{nil, "posts:read", false},
+		{[]string{"ghost"}, "posts:read", false},
+	}
+	for _, tt := range tests {
+		got, err := rbac.Authorize(context.Background(), &AuthzRequest{Roles: tt.roles, Permission: tt.permission})
+		if err != nil || got != tt.want {
+			t.Errorf("roles %v, %s = %v, %v; want %v", tt.roles, tt.permission, got, err, tt.want)
+		}
+	}
+}
+
+func TestPermissionMatches(t *testing.T) {
+	tests := []struct {
+		grant, permission string
+		want              bool
+	}{
+		{"*", "posts:read", true},
+		{"posts:read", "posts:read", true},
+		{"posts:*", "posts:read", true},
+		{"posts:*", "posts:comments:read", true},
+		{"posts:*", "posts", false},
+		{"posts*", "postsecret:read", false},
+		{"posts:read", "posts:write", false},
+	}
+	for _, tt := range tests {
+		if got := permissionMatches(tt.grant, tt.permission); got != tt.want {
+			t.Errorf("permissionMatches(%q, %q) = %v, want %v", tt.grant, tt.permission, got, tt.want)
+		}
+	}
+}
+
+func TestAuthorize_Middleware(t *testing.T) {
+	rbac := NewRBAC(map[string][]string{"editor": {"posts:*"}, "viewer": {"posts:read"}})
+	policy := Policy(rbac)
+	failing := Policy(PolicyFunc(func(context.Context, *AuthzRequest) (bool, error) {
+		return false, errors.New("policy store down")
+	}))
+
+	tests := []struct {
+		name        string
+		middlewares []poltergeist.MiddlewareFunc
+		want        int
+	}{
+		{"allowed", []poltergeist.MiddlewareFunc{withPrincipal(&testUser{"u1", []string{"editor"}}), policy, Authorize("posts:write")}, http.StatusNoContent},
+		{"all permissions required", []poltergeist.MiddlewareFunc{withPrincipal(&testUser{"u1", []string{"viewer"}}), policy, Authorize("posts:read", "posts:write")}, http.StatusForbidden},
+		{"claims roles", []poltergeist.MiddlewareFunc{withPrincipal(testClaims{"sub": "u2", "roles": []string{"editor"}}), policy, Authorize("posts:write")}, http.StatusNoContent},
+		{"no principal", []poltergeist.MiddlewareFunc{policy, Authorize("posts:read")}, http.StatusUnauthorized},
+		{"no policy", []poltergeist.MiddlewareFunc{withPrincipal("u1"), Authorize("posts:read")}, http.StatusInternalServerError},
+		{"policy error", []poltergeist.MiddlewareFunc{withPrincipal("u1"), failing, Authorize("posts:read")}, http.StatusInternalServerError},
+		{"config policy", []poltergeist.MiddlewareFunc{
+			withPrincipal(&testUser{"u1", []string{"viewer"}}),
+			AuthorizeWithConfig(&AuthzConfig{Policy: rbac, Permissions: []string{"posts:read"}}),
+		}, http.StatusNoContent},
+		{"denied handler", []poltergeist.MiddlewareFunc{withPrincipal("u1"), policy, AuthorizeWithConfig(&AuthzConfig{
+			Permissions: []string{"posts:read"},
+			DeniedHandler: func(c *poltergeist.Context, req *AuthzRequest) error {
+				return c.String(http.StatusNotFound, "hidden")
+			},
+		})}, http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := authzStatus(t, http.MethodPut, "/posts/7", tt.middlewares...); got != tt.want {
+				t.Errorf("status = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAuthorize_RequiresPermissions(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("Authorize with no permissions should panic instead of allowing everything")
+		}
+	}()
+	Authorize()
+}
+
+func TestAuthorize_ABAC(t *testing.T) {
+	var got *AuthzRequest
+	owner := Policy(PolicyFunc(func(_ context.Context, req *AuthzRequest) (bool, error) {
+		got = req
+		return req.Attributes["id"] == req.Subject, nil
+	}))
+	authorize := AuthorizeWithConfig(&AuthzConfig{
+		Permissions:    []string{"posts:edit"},
+		AttributesFunc: func(c *poltergeist.Context) map[string]any { return map[string]any{"tenant": "t1"} },
+	})
+
+	if status := authzStatus(t, http.MethodPatch, "/posts/u1", withPrincipal(&testUser{id: "u1"}), owner, authorize); status != http.StatusNoContent {
+		t.Errorf("owner = %d, want allowed", status)
+	}
+	want := &AuthzRequest{
+		Principal:  got.Principal,
+		Subject:    "u1",
+		Permission: "posts:edit",
+		Resource:   "/posts/u1",
+		Method:     http.MethodPatch,
+		Attributes: map[string]any{"id": "u1", "tenant": "t1"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("request = %+v, want %+v", got, want)
+	}
+	if status := authzStatus(t, http.MethodPatch, "/posts/u2", withPrincipal(&testUser{id: "u1"}), owner, authorize); status != http.StatusForbidden {
+		t.Errorf("other owner = %d, want 403", status)
+	}
+}
+
+func TestCan(t *testing.T) {
+	app := poltergeist.New()
+	app.Use(withPrincipal(testClaims{"sub": "u1"}), Policy(PolicyFunc(func(_ context.Context, req *AuthzRequest) (bool, error) {
+		return req.Permission == "posts:edit" && req.Attributes["owner_id"] == req.Subject, nil
+	})))
+	app.GET("/posts/:id", func(c *poltergeist.Context) error {
+		ok, err := Can(c, "posts:edit", map[string]any{"owner_id": c.Param("id")})
+		if err != nil || !ok {
+			return poltergeist.ErrForbidden
+		}
+		return c.NoContent()
+	})
+
+	for target, want := range map[string]int{"/posts/u1": http.StatusNoContent, "/posts/u2": http.StatusForbidden} {
+		w := httptest.NewRecorder()
+		app.ServeHTTP(w, httptest.NewRequest(http.MethodGet, target, nil))
+		if w.Code != want {
+			t.Errorf("%s = %d, want %d", target, w.Code, want)
+		}
+	}
+
+	c := poltergeist.NewContext(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	if _, err := Can(c, "posts:edit", nil); err == nil {
+		t.Error("Can without a policy should fail")
+	}
+}
+
+// fakeEnforcer records what Casbin would be asked
+type fakeEnforcer struct {
+	got []any
+}
+
+func (e *fakeEnforcer) Enforce(rvals ...any) (bool, error) {
+	e.got = rvals
+	return true, nil
+}
+
+func TestCasbinPolicy(t *testing.T) {
+	tests := []struct {
+		permission string
+		want       []any
+	}{
+		{"users:write", []any{"alice", "users", "write"}},
+		{"admin:users:delete", []any{"alice", "admin:users", "delete"}},
+		{"read", []any{"alice", "/reports", "read"}},
+	}
+	for _, tt := range tests {
+		e := &fakeEnforcer{}
+		req := &AuthzRequest{Subject: "alice", Resource: "/reports", Permission: tt.permission}
+		if ok, err := CasbinPolicy(e).Authorize(context.Background(), req); !ok || err != nil {
+			t.Fatalf("Authorize = %v, %v", ok, err)
+		}
+		if !reflect.DeepEqual(e.got, tt.want) {
+			t.Errorf("%s enforced as %v, want %v", tt.permission, e.got, tt.want)
+		}
+	}
+}
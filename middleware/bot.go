@@ -0,0 +1,222 @@
+package middleware
+
+import (
+	"regexp"
+	"time"
+
+	"github.com/gofuckbiz/poltergeist"
+)
+
+// =============================================================================
+// BOT FILTER - User-agent classification, blocking and tarpitting
+// =============================================================================
+
+// BotAction is what happens to a request from a matched bot
+type BotAction string
+
+// Bot actions
+const (
+	BotTag    BotAction = "tag"    // let through, classified on the Context
+	BotBlock  BotAction = "block"  // reject with 403
+	BotTarpit BotAction = "tarpit" // delay by TarpitDelay, then let through
+)
+
+// Bot categories used by DefaultBotRules
+const (
+	BotCategorySearch  = "search"  // search engine crawlers
+	BotCategoryAI      = "ai"      // AI training and answer-engine crawlers
+	BotCategorySocial  = "social"  // link preview fetchers
+	BotCategoryMonitor = "monitor" // uptime and SEO tools
+	BotCategoryTool    = "tool"    // HTTP libraries and command-line clients
+	BotCategoryUnknown = "unknown" // self-declared bots matching no other rule
+)
+
+// BotRule classifies user agents matching Pattern (a case-insensitive
+// regular expression)
+type BotRule struct {
+	Name     string
+	Pattern  string
+	Category string
+	// Action for this rule (default: the category's action in
+	// BotFilterConfig.Actions, else BotTag)
+	Action BotAction
+}
+
+// BotInfo is the classification of a request's client
+type BotInfo struct {
+	Name     string
+	Category string
+	Action   BotAction
+}
+
+// DefaultBotRules returns rules for well-known crawlers and clients, most
+// specific first
+func DefaultBotRules() []BotRule {
+	return []BotRule{
+		{Name: "Googlebot", Pattern: `googlebot|google-inspectiontool`, Category: BotCategorySearch},
+		{Name: "Bingbot", Pattern: `bingbot|msnbot`, Category: BotCategorySearch},
+		{Name: "DuckDuckBot", Pattern: `duckduckbot`, Category: BotCategorySearch},
+		{Name: "YandexBot", Pattern: `yandex(bot|images)`, Category: BotCategorySearch},
+		{Name: "Baiduspider", Pattern: `baiduspider`, Category: BotCategorySearch},
+		{Name: "Applebot", Pattern: `applebot`, Category: BotCategorySearch},
+		{Name: "GPTBot", Pattern: `gptbot|chatgpt-user|oai-searchbot`, Category: BotCategoryAI},
+		{Name: "ClaudeBot", Pattern: `claudebot|claude-web|anthropic-ai`, Category: BotCategoryAI},
+		{Name: "CCBot", Pattern: `ccbot`, Category: BotCategoryAI},
+		{Name: "PerplexityBot", Pattern: `perplexitybot`, Category: BotCategoryAI},
+		{Name: "Bytespider", Pattern: `bytespider`, Category: BotCategoryAI},
+		{Name: "Google-Extended", Pattern: `google-extended`, Category: BotCategoryAI},
+		{Name: "facebookexternalhit", Pattern: `facebookexternalhit|facebookcatalog`, Category: BotCategorySocial},
+		{Name: "Twitterbot", Pattern: `twitterbot`, Category: BotCategorySocial},
+		{Name: "Slackbot", Pattern: `slackbot`, Category: BotCategorySocial},
+		{Name: "Discordbot", Pattern: `discordbot`, Category: BotCategorySocial},
+		{Name: "LinkedInBot", Pattern: `linkedinbot`, Category: BotCategorySocial},
+		{Name: "AhrefsBot", Pattern: `ahrefsbot`, Category: BotCategoryMonitor},
+		{Name: "SemrushBot", Pattern: `semrushbot`, Category: BotCategoryMonitor},
+		{Name: "UptimeRobot", Pattern: `uptimerobot|pingdom|statuscake`, Category: BotCategoryMonitor},
+		{Name: "curl", Pattern: `^(curl|wget|httpie)/`, Category: BotCategoryTool},
+		{Name: "HTTP library", Pattern: `python-requests|python-urllib|aiohttp|go-http-client|java/|okhttp|libwww-perl|scrapy|headlesschrome|phantomjs`, Category: BotCategoryTool},
+		// "somebot/1.0" or a standalone "bot", but not device names like CUBOT
+		{Name: "bot", Pattern: `[a-z0-9]bot[/;]|\bbot\b|crawl|spider|scrape|slurp`, Category: BotCategoryUnknown},
+	}
+}
+
+// BotFilterConfig holds bot filter configuration
+type BotFilterConfig struct {
+	// Rules checked in order, the first match wins (default: DefaultBotRules())
+	Rules []BotRule
+	// Actions by category for rules without their own, e.g.
+	// {BotCategoryAI: BotBlock}
+	Actions map[string]BotAction
+	// Action for requests without a User-Agent, classified as category
+	// "unknown" (default: Actions[BotCategoryUnknown], else BotTag)
+	EmptyAction BotAction
+	// Delay applied by BotTarpit (default: 10s, cut short if the client
+	// disconnects)
+	TarpitDelay time.Duration
+	// Skip function
+	SkipFunc Skipper
+}
+
+// DefaultBotFilterConfig returns default bot filter configuration
+func DefaultBotFilterConfig() *BotFilterConfig {
+	return &BotFilterConfig{
+		Rules:       DefaultBotRules(),
+		TarpitDelay: 10 * time.Second,
+	}
+}
+
+// BotFilter returns a middleware classifying bots by user agent with the
+// default rules, blocking none of them
+func BotFilter() poltergeist.MiddlewareFunc {
+	return BotFilterWithConfig(DefaultBotFilterConfig())
+}
+
+// BotFilterWithConfig returns a bot filter with custom config. Matched
+// clients are blocked (403), tarpitted or tagged per their rule, and the
+// classification is available to handlers through BotFrom.
+//
+//	app.Use(middleware.BotFilterWithConfig(&middleware.BotFilterConfig{
+//	    Actions: map[string]middleware.BotAction{
+//	        middleware.BotCategoryAI:      middleware.BotBlock,
+//	        middleware.BotCategoryUnknown: middleware.BotTarpit,
+//	    },
+//	}))
+//
+//	if bot := middleware.BotFrom(c); bot != nil && bot.Category == middleware.BotCategorySearch {
+//	    return c.HTML(200, prerendered)
+//	}
+func BotFilterWithConfig(config *BotFilterConfig) poltergeist.MiddlewareFunc {
+	cfg := getBotFilterConfig(config)
+	type compiledRule struct {
+		re   *regexp.Regexp
+		info *BotInfo
+	}
+	rules := make([]compiledRule, len(cfg.Rules))
+	for i, rule := range cfg.Rules {
+		action := rule.Action
+		if action == "" {
+			action = cfg.Actions[rule.Category]
+		}
+		if action == "" {
+			action = BotTag
+		}
+		rules[i] = compiledRule{
+			re:   regexp.MustCompile("(?i)" + rule.Pattern),
+			info: &BotInfo{Name: rule.Name, Category: rule.Category, Action: action},
+		}
+	}
+	emptyAction := cfg.EmptyAction
+	if emptyAction == "" {
+		emptyAction = cfg.Actions[BotCategoryUnknown]
+	}
+	if emptyAction == "" {
+		emptyAction = BotTag
+	}
+	empty := &BotInfo{Name: "empty user agent", Category: BotCategoryUnknown, Action: emptyAction}
+
+	return func(next poltergeist.HandlerFunc) poltergeist.HandlerFunc {
+		return func(c *poltergeist.Context) error {
+			// Skip if configured
+			if cfg.SkipFunc != nil && cfg.SkipFunc(c) {
+				return next(c)
+			}
+
+			var bot *BotInfo
+			if ua := c.Header("User-Agent"); ua == "" {
+				bot = empty
+			} else {
+				for _, rule := range rules {
+					if rule.re.MatchString(ua) {
+						bot = rule.info
+						break
+					}
+				}
+			}
+			if bot == nil {
+				return next(c)
+			}
+			c.Set(poltergeist.ContextKeyBot, bot)
+
+			switch bot.Action {
+			case BotBlock:
+				return poltergeist.ErrForbidden
+			case BotTarpit:
+				timer := time.NewTimer(cfg.TarpitDelay)
+				defer timer.Stop()
+				select {
+				case <-timer.C:
+				case <-c.Context().Done():
+					return nil
+				}
+			}
+			return next(c)
+		}
+	}
+}
+
+// getBotFilterConfig fills unset fields with defaults
+func getBotFilterConfig(config *BotFilterConfig) *BotFilterConfig {
+	defaults := DefaultBotFilterConfig()
+	if config == nil {
+		return defaults
+	}
+	cfg := *config
+	if cfg.Rules == nil {
+		cfg.Rules = defaults.Rules
+	}
+	if cfg.TarpitDelay <= 0 {
+		cfg.TarpitDelay = defaults.TarpitDelay
+	}
+	return &cfg
+}
+
+// BotFrom returns the bot classification of the request's client, or nil
+// when it isn't a recognized bot (or BotFilter isn't installed). Handlers
+// must not modify it.
+func BotFrom(c *poltergeist.Context) *BotInfo {
+	if v, ok := c.Get(poltergeist.ContextKeyBot); ok {
+		bot, _ := v.(*BotInfo)
+		return bot
+	}
+	return nil
+}
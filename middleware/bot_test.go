@@ -0,0 +1,133 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gofuckbiz/poltergeist"
+)
+
+// =============================================================================
+// BOT FILTER TESTS
+// =============================================================================
+
+// botApp serves / through the config, answering with the bot's name
+func botApp(config *BotFilterConfig) *poltergeist.Server {
+	app := poltergeist.New()
+	app.Use(BotFilterWithConfig(config))
+	app.GET("/", func(c *poltergeist.Context) error {
+		if bot := BotFrom(c); bot != nil {
+			return c.String(http.StatusOK, bot.Name)
+		}
+		return c.String(http.StatusOK, "human")
+	})
+	return app
+}
+
+// botRequest sends a GET with the user agent
+func botRequest(app *poltergeist.Server, userAgent string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("User-Agent", userAgent)
+	w := httptest.NewRecorder()
+	app.ServeHTTP(w, req)
+	return w
+}
+
+func TestBotFilter_DefaultRules(t *testing.T) {
+	app := botApp(nil)
+	tests := []struct {
+		userAgent string
+		want      string
+	}{
+		{"Mozilla/5.0 (compatible; Googlebot/2.1; +http://www.google.com/bot.html)", "Googlebot"},
+		{"Mozilla/5.0 AppleWebKit/537.36 (KHTML, like Gecko; compatible; GPTBot/1.1; +https://openai.com/gptbot)", "GPTBot"},
+		{"facebookexternalhit/1.1 (+http://www.facebook.com/externalhit_uatext.php)", "facebookexternalhit"},
+		{"Mozilla/5.0 (compatible; AhrefsBot/7.0; +http://ahrefs.com/robot/)", "AhrefsBot"},
+		{"curl/8.4.0", "curl"},
+		{"python-requests/2.31.0", "HTTP library"},
+		{"Mozilla/5.0 (compatible; MJ12bot/v1.4.8; http://mj12bot.com/)", "bot"},
+		{"Mozilla/5.0 (compatible; SeznamBot/4.0; +https://o-seznam.cz/)", "bot"},
+		{"Some bot", "bot"},
+		{"Mozilla/5.0 (compatible; ExampleCrawler)", "bot"},
+		{"Mozilla/5.0 (Macintosh; Intel Mac OS X 14_0) AppleWebKit/605.1.15 Safari/605.1.15", "human"},
+		{"Mozilla/5.0 (Linux; Android 10; CUBOT X30) AppleWebKit/537.36 Chrome/120.0 Mobile Safari/537.36", "human"},
+		{"Mozilla/5.0 (Linux; Android 9; CUBOT_P30 Build/PPR1) Chrome/119.0 Mobile Safari/537.36", "human"},
+		{"Mozilla/5.0 (X11; Linux x86_64; rv:120.0) Gecko/20100101 Firefox/120.0", "human"},
+	}
+	for _, tt := range tests {
+		if got := botRequest(app, tt.userAgent).Body.String(); got != tt.want {
+			t.Errorf("%q classified as %q, want %q", tt.userAgent, got, tt.want)
+		}
+	}
+}
+
+func TestBotFilter_Actions(t *testing.T) {
+	app := botApp(&BotFilterConfig{
+		Rules: append([]BotRule{{Name: "Friendly", Pattern: `friendlybot`, Category: BotCategoryAI, Action: BotTag}}, DefaultBotRules()...),
+		Actions: map[string]BotAction{
+			BotCategoryAI:      BotBlock,
+			BotCategoryUnknown: BotBlock,
+		},
+	})
+	tests := []struct {
+		userAgent string
+		want      int
+	}{
+		{"GPTBot/1.0", http.StatusForbidden},
+		{"FriendlyBot/1.0", http.StatusOK}, // the rule's own action wins
+		{"Some bot", http.StatusForbidden},
+		{"", http.StatusForbidden}, // empty user agents are unknown bots
+		{"Googlebot/2.1", http.StatusOK},
+	}
+	for _, tt := range tests {
+		if got := botRequest(app, tt.userAgent).Code; got != tt.want {
+			t.Errorf("%q = %d, want %d", tt.userAgent, got, tt.want)
+		}
+	}
+
+	app = botApp(&BotFilterConfig{Actions: map[string]BotAction{BotCategoryUnknown: BotBlock}, EmptyAction: BotTag})
+	if w := botRequest(app, ""); w.Code != http.StatusOK || w.Body.String() != "empty user agent" {
+		t.Errorf("EmptyAction BotTag = %d %q, want tagged", w.Code, w.Body.String())
+	}
+	if w := botRequest(botApp(nil), ""); w.Code != http.StatusOK || w.Body.String() != "empty user agent" {
+		t.Errorf("default empty user agent = %d %q, want tagged", w.Code, w.Body.String())
+	}
+}
+
+func TestBotFilter_Tarpit(t *testing.T) {
+	app := botApp(&BotFilterConfig{
+		Actions:     map[string]BotAction{BotCategoryTool: BotTarpit},
+		TarpitDelay: 30 * time.Millisecond,
+		SkipFunc:    func(c *poltergeist.Context) bool { return c.Header("X-Internal") != "" },
+	})
+
+	start := time.Now()
+	if w := botRequest(app, "curl/8.0"); w.Code != http.StatusOK || time.Since(start) < 30*time.Millisecond {
+		t.Errorf("tarpitted request = %d after %s, want 200 after the delay", w.Code, time.Since(start))
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("User-Agent", "curl/8.0")
+	req.Header.Set("X-Internal", "1")
+	start = time.Now()
+	w := httptest.NewRecorder()
+	app.ServeHTTP(w, req)
+	if time.Since(start) >= 30*time.Millisecond || w.Body.String() != "human" {
+		t.Errorf("skipped request = %q after %s, want no classification or delay", w.Body.String(), time.Since(start))
+	}
+
+	slow := botApp(&BotFilterConfig{Actions: map[string]BotAction{BotCategoryTool: BotTarpit}, TarpitDelay: time.Minute})
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	req = httptest.NewRequest(http.MethodGet, "/", nil).WithContext(ctx)
+	req.Header.Set("User-Agent", "curl/8.0")
+	start = time.Now()
+	w = httptest.NewRecorder()
+	slow.ServeHTTP(w, req)
+	if time.Since(start) > time.Second || w.Body.String() == "curl" {
+		t.Errorf("disconnected client held %s and got %q", time.Since(start), w.Body.String())
+	}
+}
@@ -0,0 +1,338 @@
+package middleware
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofuckbiz/poltergeist"
+)
+
+// =============================================================================
+// RESPONSE CACHE - Shared GET response cache on a poltergeist.Cache store
+// =============================================================================
+
+// cacheKeyPrefix namespaces response cache entries in the store
+const cacheKeyPrefix = "httpcache:"
+
+// CacheConfig holds response cache configuration
+type CacheConfig struct {
+	// Store for cached responses (default: the server cache, see app.UseCache)
+	Store poltergeist.Cache
+	// Time to live when the response has no max-age/s-maxage (default: 1 minute)
+	TTL time.Duration
+	// Request headers whose values select separate cache entries
+	// (default: Accept, Accept-Encoding)
+	VaryHeaders []string
+	// Largest body that is cached; bigger and streamed responses are not
+	// (default: 1MB)
+	MaxBodySize int
+	// Cache requests that carry cookies, for apps whose cookies never change
+	// the response (default: they bypass the cache, as the response may
+	// depend on the session)
+	IgnoreCookies bool
+	// Skip function
+	SkipFunc Skipper
+}
+
+// DefaultCacheConfig returns default response cache configuration
+func DefaultCacheConfig() *CacheConfig {
+	return &CacheConfig{
+		TTL:         time.Minute,
+		VaryHeaders: []string{poltergeist.HeaderAccept, poltergeist.HeaderAcceptEncoding},
+		MaxBodySize: 1 << 20,
+	}
+}
+
+// Cache returns a middleware that caches 200 GET responses in store for
+// ttl, keyed by path, query and the Accept/Accept-Encoding headers. A nil
+// store uses the server cache.
+//
+//	api.GET("/products", listProducts, middleware.Cache(cache.NewMemory(nil), time.Minute))
+func Cache(store poltergeist.Cache, ttl time.Duration) poltergeist.MiddlewareFunc {
+	return CacheWithConfig(&CacheConfig{Store: store, TTL: ttl})
+}
+
+// CacheWithConfig returns a response cache middleware with custom config.
+// It follows shared-cache rules: requests with Authorization, cookies or
+// Cache-Control: no-store bypass it, no-cache/max-age=0 requests refresh
+// the entry, and responses marked no-store, no-cache or private, that set
+// cookies, or that Vary on headers outside VaryHeaders are never stored.
+// Responses carry X-Cache: HIT or MISS.
+func CacheWithConfig(config *CacheConfig) poltergeist.MiddlewareFunc {
+	cfg := getCacheConfig(config)
+
+	return func(next poltergeist.HandlerFunc) poltergeist.HandlerFunc {
+		return func(c *poltergeist.Context) error {
+			// Skip if configured
+			if cfg.SkipFunc != nil && cfg.SkipFunc(c) {
+				return next(c)
+			}
+			method := c.Request.Method
+			if method != http.MethodGet && method != http.MethodHead {
+				return next(c)
+			}
+			requestCC := c.Header(poltergeist.HeaderCacheControl)
+			if c.Header(poltergeist.HeaderAuthorization) != "" || hasDirective(requestCC, "no-store") {
+				return next(c)
+			}
+			if !cfg.IgnoreCookies && c.Header("Cookie") != "" {
+				return next(c)
+			}
+
+			store := cfg.Store
+			if store == nil {
+				store = c.Cache()
+			}
+			ctx := c.Context()
+			key, err := cacheKey(ctx, store, c, cfg.VaryHeaders)
+			if err != nil {
+				c.Logger().Warn("response cache lookup failed", "error", err)
+				return next(c)
+			}
+
+			refresh := hasDirective(requestCC, "no-cache") || directiveValue(requestCC, "max-age") == "0"
+			if !refresh {
+				if data, ok, err := store.Get(ctx, key); err != nil {
+					c.Logger().Warn("response cache lookup failed", "key", key, "error", err)
+				} else if ok {
+					var entry cachedResponse
+					if json.Unmarshal(data, &entry) == nil {
+						return entry.write(c)
+					}
+				}
+			}
+			if method == http.MethodHead {
+				return next(c)
+			}
+
+			c.SetHeader("X-Cache", "MISS")
+			rec := &cacheRecorder{
+				ResponseWriter: c.Writer,
+				before:         c.Writer.Header().Clone(),
+				limit:          cfg.MaxBodySize,
+			}
+			c.Writer = rec
+			err = next(c)
+			c.Writer = rec.ResponseWriter
+
+			ttl, ok := rec.cacheable(cfg.TTL, cfg.VaryHeaders)
+			if err != nil || !ok {
+				return err
+			}
+			data, _ := json.Marshal(cachedResponse{
+				Status:   rec.status,
+				Header:   rec.header,
+				Body:     rec.body,
+				StoredAt: time.Now().Unix(),
+			})
+			if err := store.Set(ctx, key, data, ttl); err != nil {
+				c.Logger().Warn("response cache store failed", "key", key, "error", err)
+			}
+			return nil
+		}
+	}
+}
+
+// InvalidateCache drops every cached response for the given paths, across
+// query strings and vary headers. Pass the store given to Cache, or
+// app.Cache() when the middleware uses the server cache.
+//
+//	middleware.InvalidateCache(c.Context(), store, "/products", "/products/"+id)
+func InvalidateCache(ctx context.Context, store poltergeist.Cache, paths ...string) error {
+	// Entries are keyed by a per-path version; bumping it orphans them
+	// until their TTL expires
+	version := strconv.FormatInt(time.Now().UnixNano(), 36)
+	for _, path := range paths {
+		if err := store.Set(ctx, cacheKeyPrefix+"v:"+path, []byte(version), 0); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// getCacheConfig fills unset fields with defaults
+func getCacheConfig(config *CacheConfig) *CacheConfig {
+	defaults := DefaultCacheConfig()
+	if config == nil {
+		return defaults
+	}
+	cfg := *config
+	if cfg.TTL <= 0 {
+		cfg.TTL = defaults.TTL
+	}
+	if cfg.VaryHeaders == nil {
+		cfg.VaryHeaders = defaults.VaryHeaders
+	}
+	if cfg.MaxBodySize <= 0 {
+		cfg.MaxBodySize = defaults.MaxBodySize
+	}
+	return &cfg
+}
+
+// cacheKey builds the entry key from the path version, path, query and
+// vary header values
+func cacheKey(ctx context.Context, store poltergeist.Cache, c *poltergeist.Context, vary []string) (string, error) {
+	path := c.Path()
+	version, _, err := store.Get(ctx, cacheKeyPrefix+"v:"+path)
+	if err != nil {
+		return "", err
+	}
+	h := sha256.New()
+	h.Write([]byte(c.Request.URL.RawQuery))
+	for _, name := range vary {
+		h.Write([]byte{0})
+		h.Write([]byte(c.Header(name)))
+	}
+	return cacheKeyPrefix + path + "@" + string(version) + "#" + hex.EncodeToString(h.Sum(nil)[:16]), nil
+}
+
+// containsHeader reports whether names holds the header name
+func containsHeader(names []string, name string) bool {
+	for _, n := range names {
+		if strings.EqualFold(n, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// hasDirective reports whether a Cache-Control header contains directive
+func hasDirective(header, directive string) bool {
+	for _, part := range strings.Split(header, ",") {
+		name, _, _ := strings.Cut(strings.TrimSpace(part), "=")
+		if strings.EqualFold(name, directive) {
+			return true
+		}
+	}
+	return false
+}
+
+// directiveValue returns the value of a Cache-Control directive such as max-age
+func directiveValue(header, directive string) string {
+	for _, part := range strings.Split(header, ",") {
+		name, value, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if ok && strings.EqualFold(name, directive) {
+			return strings.Trim(value, `"`)
+		}
+	}
+	return ""
+}
+
+// =============================================================================
+// CACHED RESPONSES
+// =============================================================================
+
+// cachedResponse is the stored form of a response
+type cachedResponse struct {
+	Status   int         `json:"status"`
+	Header   http.Header `json:"header"`
+	Body     []byte      `json:"body"`
+	StoredAt int64       `json:"stored_at"`
+}
+
+// write replays the cached response
+func (e *cachedResponse) write(c *poltergeist.Context) error {
+	header := c.Writer.Header()
+	for name, values := range e.Header {
+		header[name] = values
+	}
+	header.Set("X-Cache", "HIT")
+	header.Set("Age", strconv.FormatInt(max(time.Now().Unix()-e.StoredAt, 0), 10))
+	return c.Bytes(e.Status, header.Get(poltergeist.HeaderContentType), e.Body)
+}
+
+// cacheRecorder passes the response through while keeping a copy
+type cacheRecorder struct {
+	http.ResponseWriter
+	before   http.Header // headers set outside the cached handler
+	status   int
+	header   http.Header
+	body     []byte
+	limit    int
+	overflow bool // body exceeded limit
+	streamed bool // handler flushed mid-response
+}
+
+func (r *cacheRecorder) WriteHeader(code int) {
+	if r.status == 0 {
+		r.status = code
+		r.header = r.handlerHeader()
+	}
+	r.ResponseWriter.WriteHeader(code)
+}
+
+func (r *cacheRecorder) Write(p []byte) (int, error) {
+	if r.status == 0 {
+		r.WriteHeader(http.StatusOK)
+	}
+	if !r.overflow {
+		if len(r.body)+len(p) > r.limit {
+			r.overflow, r.body = true, nil
+		} else {
+			r.body = append(r.body, p...)
+		}
+	}
+	return r.ResponseWriter.Write(p)
+}
+
+// handlerHeader returns the headers the cached handler set, leaving out
+// per-request ones from outer middleware such as X-Request-ID
+func (r *cacheRecorder) handlerHeader() http.Header {
+	header := make(http.Header)
+	for name, values := range r.Header() {
+		if previous, ok := r.before[name]; !ok || strings.Join(previous, "\n") != strings.Join(values, "\n") {
+			header[name] = values
+		}
+	}
+	return header
+}
+
+// Flush marks the response as streamed (not cached) and flushes
+func (r *cacheRecorder) Flush() {
+	r.streamed = true
+	if flusher, ok := r.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Unwrap exposes the underlying writer to http.ResponseController
+func (r *cacheRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+// cacheable reports whether the recorded response may be stored and for
+// how long, preferring the response's s-maxage or max-age over fallback.
+// Responses varying on a header outside vary would be served to requests
+// they don't fit, so they aren't stored.
+func (r *cacheRecorder) cacheable(fallback time.Duration, vary []string) (time.Duration, bool) {
+	if r.status != http.StatusOK || r.overflow || r.streamed || r.header.Get("Set-Cookie") != "" {
+		return 0, false
+	}
+	for _, value := range r.Header().Values("Vary") {
+		for _, name := range strings.Split(value, ",") {
+			if name = strings.TrimSpace(name); name != "" && !containsHeader(vary, name) {
+				return 0, false // includes Vary: *
+			}
+		}
+	}
+	cc := r.header.Get(poltergeist.HeaderCacheControl)
+	if hasDirective(cc, "no-store") || hasDirective(cc, "no-cache") || hasDirective(cc, "private") {
+		return 0, false
+	}
+	for _, directive := range []string{"s-maxage", "max-age"} {
+		if value := directiveValue(cc, directive); value != "" {
+			seconds, err := strconv.Atoi(value)
+			if err != nil || seconds <= 0 {
+				return 0, false
+			}
+			return time.Duration(seconds) * time.Second, true
+		}
+	}
+	return fallback, true
+}
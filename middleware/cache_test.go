@@ -0,0 +1,141 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gofuckbiz/poltergeist"
+	"github.com/gofuckbiz/poltergeist/cache"
+)
+
+// =============================================================================
+// RESPONSE CACHE TESTS
+// =============================================================================
+
+// cacheRequest sends a GET to path with headers given as name, value pairs
+func cacheRequest(app *poltergeist.Server, path string, headers ...string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	for i := 0; i+1 < len(headers); i += 2 {
+		req.Header.Set(headers[i], headers[i+1])
+	}
+	w := httptest.NewRecorder()
+	app.ServeHTTP(w, req)
+	return w
+}
+
+// countingApp serves path with a handler counting its calls; respond may
+// set response headers first
+func countingApp(config *CacheConfig, respond func(c *poltergeist.Context)) (*poltergeist.Server, *atomic.Int32) {
+	var calls atomic.Int32
+	app := poltergeist.New()
+	app.Use(CacheWithConfig(config))
+	app.GET("/items", func(c *poltergeist.Context) error {
+		n := calls.Add(1)
+		if respond != nil {
+			respond(c)
+		}
+		return c.String(http.StatusOK, "call "+strconv.Itoa(int(n)))
+	})
+	return app, &calls
+}
+
+func TestCache_HitAndMiss(t *testing.T) {
+	app, calls := countingApp(&CacheConfig{Store: cache.NewMemory(nil)}, nil)
+
+	first := cacheRequest(app, "/items")
+	second := cacheRequest(app, "/items")
+	if first.Header().Get("X-Cache") != "MISS" || second.Header().Get("X-Cache") != "HIT" || second.Body.String() != "call 1" {
+		t.Fatalf("responses = %s %q, %s %q; want a MISS then a HIT",
+			first.Header().Get("X-Cache"), first.Body.String(), second.Header().Get("X-Cache"), second.Body.String())
+	}
+
+	tests := []struct {
+		name    string
+		path    string
+		headers []string
+		runs    bool
+	}{
+		{"same request", "/items", nil, false},
+		{"other query", "/items?page=2", nil, true},
+		{"other Accept", "/items", []string{"Accept", "application/xml"}, true},
+		{"Authorization", "/items", []string{"Authorization", "Bearer t"}, true},
+		{"Cookie", "/items", []string{"Cookie", "session=abc"}, true},
+		{"no-store", "/items", []string{"Cache-Control", "no-store"}, true},
+		{"refresh", "/items", []string{"Cache-Control", "no-cache"}, true},
+	}
+	for _, tt := range tests {
+		before := calls.Load()
+		cacheRequest(app, tt.path, tt.headers...)
+		if ran := calls.Load() > before; ran != tt.runs {
+			t.Errorf("%s: handler ran = %v, want %v", tt.name, ran, tt.runs)
+		}
+	}
+}
+
+func TestCache_IgnoreCookies(t *testing.T) {
+	app, calls := countingApp(&CacheConfig{Store: cache.NewMemory(nil), IgnoreCookies: true}, nil)
+	cacheRequest(app, "/items", "Cookie", "theme=dark")
+	if w := cacheRequest(app, "/items", "Cookie", "theme=light"); w.Header().Get("X-Cache") != "HIT" || calls.Load() != 1 {
+		t.Errorf("with IgnoreCookies: %s after %d calls, want a HIT", w.Header().Get("X-Cache"), calls.Load())
+	}
+}
+
+func TestCache_ResponsesNotStored(t *testing.T) {
+	tests := []struct {
+		name   string
+		header string
+		value  string
+	}{
+		{"Vary on a cookie", "Vary", "Cookie"},
+		{"Vary *", "Vary", "*"},
+		{"Vary outside VaryHeaders", "Vary", "Accept, X-Tenant"},
+		{"private", "Cache-Control", "private"},
+		{"no-store", "Cache-Control", "no-store"},
+		{"sets a cookie", "Set-Cookie", "session=abc"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app, calls := countingApp(&CacheConfig{Store: cache.NewMemory(nil)}, func(c *poltergeist.Context) {
+				c.Writer.Header().Set(tt.header, tt.value)
+			})
+			cacheRequest(app, "/items")
+			if w := cacheRequest(app, "/items"); calls.Load() != 2 || w.Header().Get("X-Cache") == "HIT" {
+				t.Errorf("second request: %s after %d calls, want the handler to run again", w.Header().Get("X-Cache"), calls.Load())
+			}
+		})
+	}
+
+	// Vary on headers that are part of the key is fine
+	app, calls := countingApp(&CacheConfig{Store: cache.NewMemory(nil)}, func(c *poltergeist.Context) {
+		c.Writer.Header().Set("Vary", "Accept, accept-encoding")
+	})
+	cacheRequest(app, "/items")
+	if cacheRequest(app, "/items"); calls.Load() != 1 {
+		t.Errorf("Vary within VaryHeaders: handler ran %d times, want once", calls.Load())
+	}
+}
+
+func TestCache_MaxAgeAndInvalidate(t *testing.T) {
+	store := cache.NewMemory(nil)
+	app, calls := countingApp(&CacheConfig{Store: store, TTL: time.Hour}, func(c *poltergeist.Context) {
+		c.Writer.Header().Set("Cache-Control", "max-age=0")
+	})
+	cacheRequest(app, "/items")
+	if cacheRequest(app, "/items"); calls.Load() != 2 {
+		t.Errorf("max-age=0 response was cached")
+	}
+
+	app, calls = countingApp(&CacheConfig{Store: store}, nil)
+	cacheRequest(app, "/items")
+	if err := InvalidateCache(context.Background(), store, "/items"); err != nil {
+		t.Fatal(err)
+	}
+	if w := cacheRequest(app, "/items"); calls.Load() != 2 || w.Header().Get("X-Cache") != "MISS" {
+		t.Errorf("after InvalidateCache: %s after %d calls, want a MISS", w.Header().Get("X-Cache"), calls.Load())
+	}
+}
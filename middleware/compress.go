@@ -0,0 +1,389 @@
+package middleware
+
+import (
+	"bufio"
+	"compress/flate"
+	"compress/gzip"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/andybalholm/brotli"
+
+	"github.com/gofuckbiz/poltergeist"
+)
+
+// =============================================================================
+// COMPRESSION - Negotiated gzip, deflate and brotli responses
+// =============================================================================
+
+// Supported content encodings
+const (
+	EncodingBrotli  = "br"
+	EncodingGzip    = "gzip"
+	EncodingDeflate = "deflate"
+)
+
+// CompressConfig holds response compression configuration
+type CompressConfig struct {
+	// Encodings offered, in server preference order (default: br, gzip, deflate)
+	Encodings []string
+	// Compression level passed to every encoder (default: each encoder's default)
+	Level int
+	// Responses smaller than this are sent uncompressed (default: 1024 bytes)
+	MinLength int
+	// Compressible Content-Type prefixes (default: text, JSON, JavaScript,
+	// XML, YAML, SVG and NDJSON types)
+	ContentTypes []string
+	// Skip function
+	SkipFunc Skipper
+}
+
+// DefaultCompressConfig returns default compression configuration
+func DefaultCompressConfig() *CompressConfig {
+	return &CompressConfig{
+		Encodings: []string{EncodingBrotli, EncodingGzip, EncodingDeflate},
+		Level:     -1,
+		MinLength: 1024,
+		ContentTypes: []string{
+			"text/",
+			"application/json",
+			"application/problem+json",
+			"application/javascript",
+			"application/xml",
+			"application/yaml",
+			"application/x-ndjson",
+			"image/svg+xml",
+		},
+	}
+}
+
+// Compress returns a middleware that compresses responses with the best
+// encoding the client accepts
+func Compress() poltergeist.MiddlewareFunc {
+	return CompressWithConfig(nil)
+}
+
+// Gzip returns a gzip compression middleware
+func Gzip() poltergeist.MiddlewareFunc {
+	return CompressWithConfig(&CompressConfig{Encodings: []string{EncodingGzip}})
+}
+
+// CompressWithConfig returns a compression middleware with custom config.
+// WebSocket upgrades and SSE streams pass through untouched, as do HEAD
+// requests, partial content and responses that already set
+// Content-Encoding.
+func CompressWithConfig(config *CompressConfig) poltergeist.MiddlewareFunc {
+	cfg := getCompressConfig(config)
+	pools := make(map[string]*sync.Pool, len(cfg.Encodings))
+	for _, encoding := range cfg.Encodings {
+		pools[encoding] = newEncoderPool(encoding, cfg.Level)
+	}
+
+	return func(next poltergeist.HandlerFunc) poltergeist.HandlerFunc {
+		return func(c *poltergeist.Context) error {
+			// Skip if configured
+			if cfg.SkipFunc != nil && cfg.SkipFunc(c) {
+				return next(c)
+			}
+			if c.Request.Method == http.MethodHead || isRealtime(c) {
+				return next(c)
+			}
+
+			c.Writer.Header().Add("Vary", "Accept-Encoding")
+			encoding := negotiateEncoding(c.Header("Accept-Encoding"), cfg.Encodings)
+			if encoding == "" {
+				return next(c)
+			}
+
+			original := c.Writer
+			cw := &compressWriter{
+				ResponseWriter: original,
+				config:         cfg,
+				encoding:       encoding,
+				pool:           pools[encoding],
+			}
+			c.Writer = cw
+
+			err := next(c)
+			if err != nil && !cw.wroteHeader && len(cw.buf) == 0 {
+				// Nothing was sent; let the router render the error unencoded
+				c.Writer = original
+				return err
+			}
+			closeErr := cw.Close()
+			c.Writer = original
+			if err == nil {
+				err = closeErr
+			}
+			return err
+		}
+	}
+}
+
+// getCompressConfig fills unset fields with defaults
+func getCompressConfig(config *CompressConfig) *CompressConfig {
+	defaults := DefaultCompressConfig()
+	if config == nil {
+		return defaults
+	}
+	cfg := *config
+	if len(cfg.Encodings) == 0 {
+		cfg.Encodings = defaults.Encodings
+	}
+	if cfg.Level == 0 {
+		cfg.Level = defaults.Level
+	}
+	if cfg.MinLength == 0 {
+		cfg.MinLength = defaults.MinLength
+	}
+	if len(cfg.ContentTypes) == 0 {
+		cfg.ContentTypes = defaults.ContentTypes
+	}
+	return &cfg
+}
+
+// isRealtime reports whether the request is a WebSocket upgrade or an SSE
+// stream, which must never be buffered or compressed
+func isRealtime(c *poltergeist.Context) bool {
+	if route := c.Route(); route != nil && route.RouteProtocol != "" {
+		return true
+	}
+	return c.IsWebSocket() || strings.Contains(c.Header("Accept"), poltergeist.ContentTypeSSE)
+}
+
+// negotiateEncoding picks the offered encoding with the highest q-value in
+// Accept-Encoding, breaking ties by server preference; "" means identity
+func negotiateEncoding(header string, offered []string) string {
+	if header == "" {
+		return ""
+	}
+	weights := make(map[string]float64)
+	wildcard := -1.0
+	for _, part := range strings.Split(header, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		name = strings.ToLower(strings.TrimSpace(name))
+		q := 1.0
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+		if name == "*" {
+			wildcard = q
+		} else if name != "" {
+			weights[name] = q
+		}
+	}
+
+	best, bestQ := "", 0.0
+	for _, encoding := range offered {
+		q, ok := weights[encoding]
+		if !ok {
+			q = wildcard
+		}
+		if q > bestQ {
+			best, bestQ = encoding, q
+		}
+	}
+	return best
+}
+
+// =============================================================================
+// ENCODER POOLS
+// =============================================================================
+
+// encoder is the common surface of the gzip, flate and brotli writers
+type encoder interface {
+	io.WriteCloser
+	Flush() error
+	Reset(w io.Writer)
+}
+
+// newEncoderPool returns a pool of reusable encoders for encoding
+func newEncoderPool(encoding string, level int) *sync.Pool {
+	return &sync.Pool{New: func() any {
+		switch encoding {
+		case EncodingBrotli:
+			brLevel := level
+			if brLevel < brotli.BestSpeed || brLevel > brotli.BestCompression {
+				brLevel = brotli.DefaultCompression
+			}
+			return brotli.NewWriterLevel(io.Discard, brLevel)
+		case EncodingDeflate:
+			w, err := flate.NewWriter(io.Discard, level)
+			if err != nil {
+				w, _ = flate.NewWriter(io.Discard, flate.DefaultCompression)
+			}
+			return w
+		default:
+			w, err := gzip.NewWriterLevel(io.Discard, level)
+			if err != nil {
+				w = gzip.NewWriter(io.Discard)
+			}
+			return w
+		}
+	}}
+}
+
+// =============================================================================
+// COMPRESS WRITER
+// =============================================================================
+
+// compressWriter buffers the start of the response until it knows whether
+// to compress: once MinLength bytes arrive, on Flush, or when the handler
+// returns
+type compressWriter struct {
+	http.ResponseWriter
+	config   *CompressConfig
+	encoding string
+	pool     *sync.Pool
+
+	buf         []byte
+	status      int
+	wroteHeader bool    // status line sent to the client
+	enc         encoder // nil once decided against compression
+}
+
+func (w *compressWriter) WriteHeader(code int) {
+	if code < http.StatusOK {
+		w.ResponseWriter.WriteHeader(code) // informational, e.g. 103 Early Hints
+		return
+	}
+	if w.wroteHeader || w.status != 0 {
+		return
+	}
+	w.status = code
+	// Bodiless responses have nothing to compress
+	if code == http.StatusNoContent || code == http.StatusNotModified {
+		w.start(false)
+	}
+}
+
+func (w *compressWriter) Write(p []byte) (int, error) {
+	if w.wroteHeader {
+		if w.enc != nil {
+			return w.enc.Write(p)
+		}
+		return w.ResponseWriter.Write(p)
+	}
+	w.buf = append(w.buf, p...)
+	if len(w.buf) >= w.config.MinLength {
+		if err := w.decide(true); err != nil {
+			return 0, err
+		}
+	}
+	return len(p), nil
+}
+
+// Flush decides immediately (streamed responses can't wait for MinLength)
+// and pushes compressed output to the client
+func (w *compressWriter) Flush() {
+	if !w.wroteHeader {
+		if err := w.decide(true); err != nil {
+			return
+		}
+	}
+	if w.enc != nil {
+		w.enc.Flush()
+	}
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Hijack passes through to the underlying connection
+func (w *compressWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	if hijacker, ok := w.ResponseWriter.(http.Hijacker); ok {
+		return hijacker.Hijack()
+	}
+	return nil, nil, errors.New("compress: response writer does not support hijacking")
+}
+
+// Unwrap exposes the underlying writer to http.ResponseController
+func (w *compressWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// Close sends anything still buffered and returns the encoder to its pool
+func (w *compressWriter) Close() error {
+	if !w.wroteHeader {
+		if err := w.decide(len(w.buf) >= w.config.MinLength); err != nil {
+			return err
+		}
+	}
+	if w.enc == nil {
+		return nil
+	}
+	err := w.enc.Close()
+	w.enc.Reset(io.Discard)
+	w.pool.Put(w.enc)
+	w.enc = nil
+	return err
+}
+
+// decide sends the headers, compressing when large enough and the response
+// is eligible, then writes the buffered bytes
+func (w *compressWriter) decide(largeEnough bool) error {
+	header := w.Header()
+	if header.Get("Content-Type") == "" && len(w.buf) > 0 {
+		header.Set("Content-Type", http.DetectContentType(w.buf))
+	}
+	compress := largeEnough &&
+		header.Get("Content-Encoding") == "" &&
+		header.Get("Content-Range") == "" &&
+		w.status != http.StatusPartialContent &&
+		w.compressible(header.Get("Content-Type"))
+	w.start(compress)
+
+	if len(w.buf) == 0 {
+		return nil
+	}
+	buf := w.buf
+	w.buf = nil
+	if w.enc != nil {
+		_, err := w.enc.Write(buf)
+		return err
+	}
+	_, err := w.ResponseWriter.Write(buf)
+	return err
+}
+
+// start writes the status line, switching on compression if asked
+func (w *compressWriter) start(compress bool) {
+	if compress {
+		header := w.Header()
+		header.Set("Content-Encoding", w.encoding)
+		header.Del("Content-Length")
+		if etag := header.Get("ETag"); etag != "" && !strings.HasPrefix(etag, "W/") {
+			header.Set("ETag", "W/"+etag) // the bytes differ from the identity response
+		}
+		w.enc = w.pool.Get().(encoder)
+		w.enc.Reset(w.ResponseWriter)
+	}
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	w.wroteHeader = true
+	w.ResponseWriter.WriteHeader(w.status)
+}
+
+// compressible reports whether the content type is in the allow list
+func (w *compressWriter) compressible(contentType string) bool {
+	contentType = strings.ToLower(contentType)
+	if strings.HasPrefix(contentType, poltergeist.ContentTypeSSE) {
+		return false
+	}
+	for _, prefix := range w.config.ContentTypes {
+		if strings.HasPrefix(contentType, prefix) {
+			return true
+		}
+	}
+	return false
+}
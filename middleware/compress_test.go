@@ -0,0 +1,119 @@
+package middleware
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofuckbiz/poltergeist"
+)
+
+// =============================================================================
+// COMPRESSION TESTS
+// =============================================================================
+
+// compressRequest sends a GET to path accepting encoding
+func compressRequest(app *poltergeist.Server, path, encoding string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	if encoding != "" {
+		req.Header.Set("Accept-Encoding", encoding)
+	}
+	w := httptest.NewRecorder()
+	app.ServeHTTP(w, req)
+	return w
+}
+
+func TestCompress(t *testing.T) {
+	large := strings.Repeat("poltergeist ", 200)
+	app := poltergeist.New()
+	app.Use(Compress())
+	app.GET("/large", func(c *poltergeist.Context) error { return c.String(http.StatusOK, large) })
+	app.GET("/small", func(c *poltergeist.Context) error { return c.String(http.StatusOK, "tiny") })
+	app.GET("/png", func(c *poltergeist.Context) error {
+		return c.Bytes(http.StatusOK, "image/png", []byte(large))
+	})
+	app.GET("/fail", func(c *poltergeist.Context) error { return poltergeist.ErrNotFound })
+
+	tests := []struct {
+		name, path, accept, encoding string
+	}{
+		{"gzip", "/large", "gzip", EncodingGzip},
+		{"preferred", "/large", "gzip, br", EncodingBrotli},
+		{"q-values", "/large", "br;q=0.1, deflate", EncodingDeflate},
+		{"identity", "/large", "", ""},
+		{"refused", "/large", "gzip;q=0", ""},
+		{"below MinLength", "/small", "gzip", ""},
+		{"incompressible type", "/png", "gzip", ""},
+		{"error", "/fail", "gzip", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := compressRequest(app, tt.path, tt.accept)
+			if got := w.Header().Get("Content-Encoding"); got != tt.encoding {
+				t.Errorf("Content-Encoding = %q, want %q", got, tt.encoding)
+			}
+			if w.Header().Get("Vary") != "Accept-Encoding" {
+				t.Errorf("Vary = %q", w.Header().Get("Vary"))
+			}
+		})
+	}
+
+	w := compressRequest(app, "/large", "gzip")
+	reader, err := gzip.NewReader(w.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if body, _ := io.ReadAll(reader); string(body) != large {
+		t.Errorf("decompressed body = %d bytes, want %d", len(body), len(large))
+	}
+}
+
+func TestCompress_RestoresWriter(t *testing.T) {
+	var inner, after http.ResponseWriter
+	app := poltergeist.New()
+	app.Use(func(next poltergeist.HandlerFunc) poltergeist.HandlerFunc {
+		return func(c *poltergeist.Context) error {
+			inner = c.Writer
+			err := next(c)
+			after = c.Writer
+			return err
+		}
+	})
+	app.Use(Compress())
+	app.GET("/", func(c *poltergeist.Context) error {
+		return c.String(http.StatusOK, strings.Repeat("a", 2048))
+	})
+
+	w := compressRequest(app, "/", "gzip")
+	if w.Header().Get("Content-Encoding") != EncodingGzip || w.Body.Len() == 0 {
+		t.Fatalf("response = %v, %d bytes; want gzip", w.Header(), w.Body.Len())
+	}
+	if after != inner {
+		t.Errorf("writer after Compress = %T, want the original %T back", after, inner)
+	}
+}
+
+func TestCompress_WithResponseTransforms(t *testing.T) {
+	app := poltergeist.New()
+	app.TransformResponse(poltergeist.Envelope())
+	app.Use(Compress())
+	app.GET("/items", func(c *poltergeist.Context) error {
+		return c.JSON(http.StatusOK, []string{strings.Repeat("item", 500)})
+	})
+
+	w := compressRequest(app, "/items", "gzip")
+	if w.Code != http.StatusOK || w.Body.Len() == 0 {
+		t.Fatalf("response = %d with %d bytes, want the compressed body", w.Code, w.Body.Len())
+	}
+	reader, err := gzip.NewReader(w.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(reader)
+	if !strings.HasPrefix(string(body), `["item`) {
+		t.Errorf("body = %.40q, want the handler's JSON", body)
+	}
+}
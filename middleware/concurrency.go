@@ -0,0 +1,201 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gofuckbiz/poltergeist"
+)
+
+// =============================================================================
+// CONCURRENCY LIMIT - Bounded in-flight requests with a short queue
+// =============================================================================
+
+// ConcurrencyConfig holds concurrency limiter configuration
+type ConcurrencyConfig struct {
+	// Requests handled at once per key (default: 100)
+	Limit int
+	// Requests allowed to wait for a free slot per key (default: 0, reject
+	// as soon as the limit is reached)
+	QueueSize int
+	// How long a queued request waits before it is rejected (default: 1s)
+	QueueTimeout time.Duration
+	// Key function; nil shares one limit across all requests, KeyByRoute(nil)
+	// gives every route its own, KeyByIP() every client
+	KeyFunc func(c *poltergeist.Context) string
+	// Status for rejected requests (default: 503; 429 suits per-client keys)
+	StatusCode int
+	// Retry-After sent with rejections (default: 1s)
+	RetryAfter time.Duration
+	// Custom response when saturated
+	LimitHandler func(c *poltergeist.Context) error
+	// Skip function to bypass the limiter; WebSocket and SSE requests,
+	// which hold their connection open, are never limited
+	SkipFunc Skipper
+}
+
+// DefaultConcurrencyConfig returns default concurrency limiter configuration
+func DefaultConcurrencyConfig() *ConcurrencyConfig {
+	return &ConcurrencyConfig{
+		Limit:        100,
+		QueueTimeout: time.Second,
+		StatusCode:   http.StatusServiceUnavailable,
+		RetryAfter:   time.Second,
+	}
+}
+
+// MaxConcurrency returns a middleware handling at most n requests at once,
+// queuing up to queueLen more for at most wait; the rest get 503 with
+// Retry-After. Registered on a route or group, it bounds only that route
+// or group.
+//
+//	app.POST("/reports", buildReport, middleware.MaxConcurrency(4, 16, 2*time.Second))
+func MaxConcurrency(n, queueLen int, wait time.Duration) poltergeist.MiddlewareFunc {
+	return MaxConcurrencyWithConfig(&ConcurrencyConfig{Limit: n, QueueSize: queueLen, QueueTimeout: wait})
+}
+
+// MaxConcurrencyWithConfig returns a concurrency limiter with custom config
+//
+//	app.Use(middleware.MaxConcurrencyWithConfig(&middleware.ConcurrencyConfig{
+//	    Limit:     20,
+//	    QueueSize: 50,
+//	    KeyFunc:   middleware.KeyByRoute(nil),
+//	}))
+func MaxConcurrencyWithConfig(config *ConcurrencyConfig) poltergeist.MiddlewareFunc {
+	cfg := getConcurrencyConfig(config)
+	limiters := &concurrencyLimiters{limit: cfg.Limit, queue: cfg.QueueSize, limiters: make(map[string]*concurrencyLimiter)}
+	retryAfter := strconv.Itoa(ceilSeconds(cfg.RetryAfter))
+
+	return func(next poltergeist.HandlerFunc) poltergeist.HandlerFunc {
+		return func(c *poltergeist.Context) error {
+			// Skip if configured
+			if (cfg.SkipFunc != nil && cfg.SkipFunc(c)) || isRealtime(c) {
+				return next(c)
+			}
+
+			var key string
+			if cfg.KeyFunc != nil {
+				key = cfg.KeyFunc(c)
+			}
+			limiter := limiters.get(key)
+			defer limiters.put(key, limiter)
+
+			if !limiter.acquire(c, cfg.QueueTimeout) {
+				c.SetHeader("Retry-After", retryAfter)
+				if cfg.LimitHandler != nil {
+					return cfg.LimitHandler(c)
+				}
+				return poltergeist.NewHTTPError(cfg.StatusCode)
+			}
+			defer limiter.release()
+			return next(c)
+		}
+	}
+}
+
+// getConcurrencyConfig fills unset fields with defaults
+func getConcurrencyConfig(config *ConcurrencyConfig) *ConcurrencyConfig {
+	defaults := DefaultConcurrencyConfig()
+	if config == nil {
+		return defaults
+	}
+	cfg := *config
+	if cfg.Limit <= 0 {
+		cfg.Limit = defaults.Limit
+	}
+	if cfg.QueueTimeout <= 0 {
+		cfg.QueueTimeout = defaults.QueueTimeout
+	}
+	if cfg.StatusCode == 0 {
+		cfg.StatusCode = defaults.StatusCode
+	}
+	if cfg.RetryAfter <= 0 {
+		cfg.RetryAfter = defaults.RetryAfter
+	}
+	return &cfg
+}
+
+// concurrencyLimiters holds a limiter per key, dropping limiters no
+// request is using so per-client keys don't accumulate
+type concurrencyLimiters struct {
+	limit    int
+	queue    int
+	mu       sync.Mutex
+	limiters map[string]*concurrencyLimiter
+}
+
+// get returns the key's limiter, registering the caller as a user
+func (l *concurrencyLimiters) get(key string) *concurrencyLimiter {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	limiter, ok := l.limiters[key]
+	if !ok {
+		limiter = &concurrencyLimiter{slots: make(chan struct{}, l.limit), queue: l.queue}
+		l.limiters[key] = limiter
+	}
+	limiter.users++
+	return limiter
+}
+
+// put unregisters a user, dropping the limiter once it is unused
+func (l *concurrencyLimiters) put(key string, limiter *concurrencyLimiter) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	limiter.users--
+	if limiter.users == 0 {
+		delete(l.limiters, key)
+	}
+}
+
+// concurrencyLimiter bounds the requests of one key
+type concurrencyLimiter struct {
+	slots chan struct{} // one token per running request
+	queue int
+
+	// Guarded by concurrencyLimiters.mu
+	users int
+
+	mu      sync.Mutex
+	waiting int
+}
+
+// acquire takes a slot, waiting up to timeout in the queue when there is
+// room; false when saturated, timed out or the client went away
+func (l *concurrencyLimiter) acquire(c *poltergeist.Context, timeout time.Duration) bool {
+	select {
+	case l.slots <- struct{}{}:
+		return true
+	default:
+	}
+
+	l.mu.Lock()
+	if l.waiting >= l.queue {
+		l.mu.Unlock()
+		return false
+	}
+	l.waiting++
+	l.mu.Unlock()
+	defer func() {
+		l.mu.Lock()
+		l.waiting--
+		l.mu.Unlock()
+	}()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case l.slots <- struct{}{}:
+		return true
+	case <-timer.C:
+		return false
+	case <-c.Context().Done():
+		return false
+	}
+}
+
+// release frees a slot
+func (l *concurrencyLimiter) release() {
+	<-l.slots
+}
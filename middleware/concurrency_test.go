@@ -0,0 +1,178 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gofuckbiz/poltergeist"
+)
+
+// =============================================================================
+// CONCURRENCY LIMIT TESTS
+// =============================================================================
+
+// heldApp serves /work and /other through the config; each request signals
+// started and waits until release is closed
+func heldApp(config *ConcurrencyConfig) (app *poltergeist.Server, started chan string, release chan struct{}) {
+	started = make(chan string, 16)
+	release = make(chan struct{})
+	app = poltergeist.New()
+	app.Use(MaxConcurrencyWithConfig(config))
+	handler := func(c *poltergeist.Context) error {
+		started <- c.Path()
+		<-release
+		return c.NoContent()
+	}
+	app.GET("/work", handler)
+	app.GET("/other", handler)
+	return app, started, release
+}
+
+// serveAsync serves a request in the background; its recorder is complete
+// after wg.Wait
+func serveAsync(app *poltergeist.Server, req *http.Request, wg *sync.WaitGroup) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		app.ServeHTTP(w, req)
+	}()
+	return w
+}
+
+// waitStarted waits for a held request to reach its handler
+func waitStarted(t *testing.T, started chan string) {
+	t.Helper()
+	select {
+	case <-started:
+	case <-time.After(time.Second):
+		t.Fatal("request never reached the handler")
+	}
+}
+
+func TestMaxConcurrency_Saturated(t *testing.T) {
+	app, started, release := heldApp(&ConcurrencyConfig{Limit: 1, RetryAfter: 1500 * time.Millisecond})
+	var wg sync.WaitGroup
+	first := serveAsync(app, httptest.NewRequest(http.MethodGet, "/work", nil), &wg)
+	waitStarted(t, started)
+
+	w := httptest.NewRecorder()
+	app.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/other", nil))
+	if w.Code != http.StatusServiceUnavailable || w.Header().Get("Retry-After") != "2" {
+		t.Errorf("saturated = %d, Retry-After %q; want 503 and 2", w.Code, w.Header().Get("Retry-After"))
+	}
+
+	close(release)
+	wg.Wait()
+	if first.Code != http.StatusNoContent {
+		t.Errorf("first request = %d", first.Code)
+	}
+	w = httptest.NewRecorder()
+	app.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/work", nil))
+	if w.Code != http.StatusNoContent {
+		t.Errorf("after release = %d, want the slot back", w.Code)
+	}
+}
+
+func TestMaxConcurrency_Queue(t *testing.T) {
+	app, started, release := heldApp(&ConcurrencyConfig{Limit: 1, QueueSize: 1, QueueTimeout: time.Second})
+	var wg sync.WaitGroup
+	first := serveAsync(app, httptest.NewRequest(http.MethodGet, "/work", nil), &wg)
+	waitStarted(t, started)
+	queued := serveAsync(app, httptest.NewRequest(http.MethodGet, "/work", nil), &wg)
+	time.Sleep(20 * time.Millisecond) // let it join the queue
+
+	w := httptest.NewRecorder()
+	app.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/work", nil))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("full queue = %d, want 503", w.Code)
+	}
+
+	close(release)
+	wg.Wait()
+	if first.Code != http.StatusNoContent || queued.Code != http.StatusNoContent {
+		t.Errorf("first = %d, queued = %d; want both served", first.Code, queued.Code)
+	}
+}
+
+func TestMaxConcurrency_QueueTimeout(t *testing.T) {
+	app, started, release := heldApp(&ConcurrencyConfig{
+		Limit: 1, QueueSize: 1, QueueTimeout: 30 * time.Millisecond,
+		LimitHandler: func(c *poltergeist.Context) error { return c.String(http.StatusTooManyRequests, "busy") },
+	})
+	var wg sync.WaitGroup
+	serveAsync(app, httptest.NewRequest(http.MethodGet, "/work", nil), &wg)
+	waitStarted(t, started)
+
+	start := time.Now()
+	w := httptest.NewRecorder()
+	app.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/work", nil))
+	if elapsed := time.Since(start); w.Code != http.StatusTooManyRequests || w.Body.String() != "busy" || elapsed < 30*time.Millisecond {
+		t.Errorf("timed out request = %d %q after %s, want LimitHandler's 429 after the queue timeout", w.Code, w.Body.String(), elapsed)
+	}
+	close(release)
+	wg.Wait()
+}
+
+func TestMaxConcurrency_ClientGone(t *testing.T) {
+	app, started, release := heldApp(&ConcurrencyConfig{Limit: 1, QueueSize: 1, QueueTimeout: 5 * time.Second})
+	var wg sync.WaitGroup
+	serveAsync(app, httptest.NewRequest(http.MethodGet, "/work", nil), &wg)
+	waitStarted(t, started)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	req := httptest.NewRequest(http.MethodGet, "/work", nil).WithContext(ctx)
+	done := make(chan struct{})
+	go func() {
+		app.ServeHTTP(httptest.NewRecorder(), req)
+		close(done)
+	}()
+	time.Sleep(20 * time.Millisecond)
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("queued request kept waiting after its client went away")
+	}
+
+	close(release)
+	wg.Wait()
+	select {
+	case path := <-started:
+		t.Errorf("cancelled request reached the handler (%s)", path)
+	default:
+	}
+}
+
+func TestMaxConcurrency_Keys(t *testing.T) {
+	app, started, release := heldApp(&ConcurrencyConfig{Limit: 1, KeyFunc: KeyByRoute(nil)})
+	var wg sync.WaitGroup
+	work := serveAsync(app, httptest.NewRequest(http.MethodGet, "/work", nil), &wg)
+	other := serveAsync(app, httptest.NewRequest(http.MethodGet, "/other", nil), &wg)
+	waitStarted(t, started)
+	waitStarted(t, started) // per-route limits: both run at once
+
+	close(release)
+	wg.Wait()
+	if work.Code != http.StatusNoContent || other.Code != http.StatusNoContent {
+		t.Errorf("work = %d, other = %d", work.Code, other.Code)
+	}
+}
+
+func TestConcurrencyLimiters_DropUnused(t *testing.T) {
+	limiters := &concurrencyLimiters{limit: 1, limiters: make(map[string]*concurrencyLimiter)}
+	a := limiters.get("a")
+	b := limiters.get("a")
+	if a != b {
+		t.Fatal("one key got two limiters")
+	}
+	limiters.put("a", a)
+	limiters.put("a", b)
+	if len(limiters.limiters) != 0 {
+		t.Errorf("limiters = %v, want unused ones dropped", limiters.limiters)
+	}
+}
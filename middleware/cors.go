@@ -2,26 +2,56 @@ package middleware
 
 import (
 	"net/http"
+	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 
 	"github.com/gofuckbiz/poltergeist"
 )
 
+// =============================================================================
+// CORS - Cross-origin resource sharing
+// =============================================================================
+
+// CORS request and response headers
+const (
+	headerAllowOrigin      = "Access-Control-Allow-Origin"
+	headerAllowMethods     = "Access-Control-Allow-Methods"
+	headerAllowHeaders     = "Access-Control-Allow-Headers"
+	headerAllowCredentials = "Access-Control-Allow-Credentials"
+	headerExposeHeaders    = "Access-Control-Expose-Headers"
+	headerMaxAge           = "Access-Control-Max-Age"
+	headerRequestMethod    = "Access-Control-Request-Method"
+	headerRequestHeaders   = "Access-Control-Request-Headers"
+)
+
 // CORSConfig holds CORS middleware configuration
 type CORSConfig struct {
-	// Allowed origins ("*" for all)
+	// Allowed origins: exact ("https://app.example.com"), "*" for all, or
+	// wildcard patterns ("https://*.example.com", "http://localhost:*")
 	AllowOrigins []string
+	// Allowed origins as regular expressions matching the whole origin,
+	// compiled once (invalid ones panic)
+	AllowOriginRegex []string
+	// Custom origin check, consulted after AllowOrigins and AllowOriginRegex
+	AllowOriginFunc func(origin string) bool
 	// Allowed HTTP methods
 	AllowMethods []string
-	// Allowed headers
+	// Allowed headers ("*" allows whatever the preflight asks for)
 	AllowHeaders []string
 	// Exposed headers
 	ExposeHeaders []string
-	// Allow credentials
+	// Allow credentials. Requires explicit origins or patterns: combining it
+	// with "*" would hand every site the user's cookies, so it panics.
 	AllowCredentials bool
-	// Max age for preflight cache (seconds)
+	// Max age for preflight cache in seconds (0 omits the header, negative disables caching)
 	MaxAge int
+	// Configs for path prefixes ("/public", "/admin"), typically one per
+	// route group; the longest matching prefix replaces this config
+	Overrides map[string]*CORSConfig
+	// Skip CORS handling for some requests
+	SkipFunc Skipper
 }
 
 // DefaultCORSConfig returns default CORS configuration
@@ -55,57 +85,49 @@ func CORS() poltergeist.MiddlewareFunc {
 	return CORSWithConfig(DefaultCORSConfig())
 }
 
-// CORSWithConfig returns a CORS middleware with custom config
+// CORSWithConfig returns a CORS middleware with custom config. Preflight
+// requests (OPTIONS with Access-Control-Request-Method) are answered with
+// 204 without reaching the handler; the router routes them to this
+// middleware even when the path has no OPTIONS route. Disallowed origins
+// get no CORS headers, so the browser blocks the response.
+//
+//	app.Use(middleware.CORSWithConfig(&middleware.CORSConfig{
+//	    AllowOrigins:     []string{"https://app.example.com", "https://*.example.dev"},
+//	    AllowCredentials: true,
+//	    ExposeHeaders:    []string{"X-Request-ID"},
+//	    Overrides: map[string]*middleware.CORSConfig{
+//	        "/public": {AllowOrigins: []string{"*"}},
+//	    },
+//	}))
 func CORSWithConfig(config *CORSConfig) poltergeist.MiddlewareFunc {
-	if config == nil {
-		config = DefaultCORSConfig()
+	base := newCORSPolicy(config)
+	overrides := make([]corsOverride, 0, len(base.config.Overrides))
+	for prefix, override := range base.config.Overrides {
+		overrides = append(overrides, corsOverride{
+			prefix: strings.TrimSuffix(prefix, "/"),
+			policy: newCORSPolicy(override),
+		})
 	}
-
-	allowMethods := strings.Join(config.AllowMethods, ", ")
-	allowHeaders := strings.Join(config.AllowHeaders, ", ")
-	exposeHeaders := strings.Join(config.ExposeHeaders, ", ")
-	maxAge := strconv.Itoa(config.MaxAge)
+	sort.Slice(overrides, func(i, j int) bool {
+		return len(overrides[i].prefix) > len(overrides[j].prefix)
+	})
 
 	return func(next poltergeist.HandlerFunc) poltergeist.HandlerFunc {
 		return func(c *poltergeist.Context) error {
-			origin := c.Header("Origin")
-
-			// Check if origin is allowed
-			allowed := false
-			for _, o := range config.AllowOrigins {
-				if o == "*" || o == origin {
-					allowed = true
-					break
-				}
+			// Skip if configured
+			if base.config.SkipFunc != nil && base.config.SkipFunc(c) {
+				return next(c)
 			}
 
-			if allowed {
-				// Set CORS headers
-				if len(config.AllowOrigins) == 1 && config.AllowOrigins[0] == "*" {
-					c.SetHeader("Access-Control-Allow-Origin", "*")
-				} else {
-					c.SetHeader("Access-Control-Allow-Origin", origin)
-					c.SetHeader("Vary", "Origin")
-				}
-
-				if config.AllowCredentials {
-					c.SetHeader("Access-Control-Allow-Credentials", "true")
-				}
-
-				if len(config.ExposeHeaders) > 0 {
-					c.SetHeader("Access-Control-Expose-Headers", exposeHeaders)
-				}
-
-				// Handle preflight request
-				if c.Method() == http.MethodOptions {
-					c.SetHeader("Access-Control-Allow-Methods", allowMethods)
-					c.SetHeader("Access-Control-Allow-Headers", allowHeaders)
-					c.SetHeader("Access-Control-Max-Age", maxAge)
-					return c.NoContent()
+			policy := base
+			reqPath := c.Path()
+			for _, o := range overrides {
+				if reqPath == o.prefix || strings.HasPrefix(reqPath, o.prefix+"/") {
+					policy = o.policy
+					break
 				}
 			}
-
-			return next(c)
+			return policy.handle(c, next)
 		}
 	}
 }
@@ -121,3 +143,177 @@ func AllowAllCORS() poltergeist.MiddlewareFunc {
 		MaxAge:           86400,
 	})
 }
+
+// corsOverride is a policy for a path prefix
+type corsOverride struct {
+	prefix string
+	policy *corsPolicy
+}
+
+// corsPolicy is a CORS config with its origin matchers compiled and its
+// header values joined
+type corsPolicy struct {
+	config        *CORSConfig
+	anyOrigin     bool
+	origins       map[string]bool
+	patterns      []*regexp.Regexp
+	anyHeader     bool
+	allowMethods  string
+	allowHeaders  string
+	exposeHeaders string
+	maxAge        string
+}
+
+// newCORSPolicy fills defaults and compiles a config
+func newCORSPolicy(config *CORSConfig) *corsPolicy {
+	cfg := getCORSConfig(config)
+	p := &corsPolicy{
+		config:        cfg,
+		origins:       make(map[string]bool),
+		allowMethods:  strings.Join(cfg.AllowMethods, ", "),
+		allowHeaders:  strings.Join(cfg.AllowHeaders, ", "),
+		exposeHeaders: strings.Join(cfg.ExposeHeaders, ", "),
+	}
+	for _, origin := range cfg.AllowOrigins {
+		origin = strings.ToLower(origin)
+		switch {
+		case origin == "*":
+			if cfg.AllowCredentials {
+				panic(`middleware: CORS AllowCredentials requires explicit origins, not "*"`)
+			}
+			p.anyOrigin = true
+		case strings.Contains(origin, "*"):
+			p.patterns = append(p.patterns, wildcardOrigin(origin))
+		default:
+			p.origins[origin] = true
+		}
+	}
+	for _, expr := range cfg.AllowOriginRegex {
+		p.patterns = append(p.patterns, regexp.MustCompile("^(?:"+expr+")$"))
+	}
+	for _, h := range cfg.AllowHeaders {
+		if h == "*" {
+			p.anyHeader = true
+		}
+	}
+	switch {
+	case cfg.MaxAge > 0:
+		p.maxAge = strconv.Itoa(cfg.MaxAge)
+	case cfg.MaxAge < 0:
+		p.maxAge = "0"
+	}
+	return p
+}
+
+// getCORSConfig fills unset fields with defaults. Origins are not filled:
+// a config naming no origins allows none.
+func getCORSConfig(config *CORSConfig) *CORSConfig {
+	defaults := DefaultCORSConfig()
+	if config == nil {
+		return defaults
+	}
+	cfg := *config
+	if len(cfg.AllowMethods) == 0 {
+		cfg.AllowMethods = defaults.AllowMethods
+	}
+	if len(cfg.AllowHeaders) == 0 {
+		cfg.AllowHeaders = defaults.AllowHeaders
+	}
+	return &cfg
+}
+
+// wildcardOrigin compiles an origin pattern; each "*" matches one or more
+// host labels or a port
+func wildcardOrigin(pattern string) *regexp.Regexp {
+	parts := strings.Split(pattern, "*")
+	for i, part := range parts {
+		parts[i] = regexp.QuoteMeta(part)
+	}
+	return regexp.MustCompile("^" + strings.Join(parts, "[a-z0-9-]+(?:\\.[a-z0-9-]+)*") + "$")
+}
+
+// allows reports whether origin may access the resource
+func (p *corsPolicy) allows(origin string) bool {
+	if p.anyOrigin {
+		return true
+	}
+	lower := strings.ToLower(origin)
+	if p.origins[lower] {
+		return true
+	}
+	for _, re := range p.patterns {
+		if re.MatchString(lower) {
+			return true
+		}
+	}
+	return p.config.AllowOriginFunc != nil && p.config.AllowOriginFunc(origin)
+}
+
+// handle applies the policy to a request
+func (p *corsPolicy) handle(c *poltergeist.Context, next poltergeist.HandlerFunc) error {
+	header := c.Writer.Header()
+	origin := c.Header("Origin")
+	preflight := c.Method() == http.MethodOptions && origin != "" && c.Header(headerRequestMethod) != ""
+
+	// The response depends on Origin unless every origin gets "*"
+	if !p.anyOrigin {
+		addVary(header, "Origin")
+	}
+	if preflight {
+		addVary(header, headerRequestMethod, headerRequestHeaders)
+	}
+
+	if origin == "" || !p.allows(origin) {
+		if preflight {
+			return c.NoContent()
+		}
+		return next(c)
+	}
+
+	if p.anyOrigin {
+		header.Set(headerAllowOrigin, "*")
+	} else {
+		header.Set(headerAllowOrigin, origin)
+	}
+	if p.config.AllowCredentials {
+		header.Set(headerAllowCredentials, "true")
+	}
+
+	if !preflight {
+		if p.exposeHeaders != "" {
+			header.Set(headerExposeHeaders, p.exposeHeaders)
+		}
+		return next(c)
+	}
+
+	header.Set(headerAllowMethods, p.allowMethods)
+	allowHeaders := p.allowHeaders
+	if p.anyHeader && p.config.AllowCredentials {
+		// "*" is literal on credentialed requests: echo what was asked for
+		allowHeaders = c.Header(headerRequestHeaders)
+	}
+	if allowHeaders != "" {
+		header.Set(headerAllowHeaders, allowHeaders)
+	}
+	if p.maxAge != "" {
+		header.Set(headerMaxAge, p.maxAge)
+	}
+	return c.NoContent()
+}
+
+// addVary adds values to the Vary header unless already listed
+func addVary(header http.Header, values ...string) {
+	existing := strings.ToLower(strings.Join(header.Values("Vary"), ","))
+	for _, v := range values {
+		listed := false
+		for _, field := range strings.Split(existing, ",") {
+			if strings.TrimSpace(field) == strings.ToLower(v) {
+				listed = true
+				break
+			}
+		}
+		if !listed {
+			header.Add("Vary", v)
+		}
+	}
+}
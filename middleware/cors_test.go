@@ -0,0 +1,134 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofuckbiz/poltergeist"
+)
+
+// =============================================================================
+// CORS TESTS
+// =============================================================================
+
+// corsRequest sends a request from origin and returns the response; a
+// non-empty preflight method makes it a preflight
+func corsRequest(app *poltergeist.Server, path, origin, preflight string) *httptest.ResponseRecorder {
+	method := http.MethodGet
+	if preflight != "" {
+		method = http.MethodOptions
+	}
+	req := httptest.NewRequest(method, path, nil)
+	req.Header.Set("Origin", origin)
+	if preflight != "" {
+		req.Header.Set(headerRequestMethod, preflight)
+		req.Header.Set(headerRequestHeaders, "X-Custom")
+	}
+	w := httptest.NewRecorder()
+	app.ServeHTTP(w, req)
+	return w
+}
+
+// corsApp serves GET /items and /public/items through the config
+func corsApp(config *CORSConfig) *poltergeist.Server {
+	app := poltergeist.New()
+	app.Use(CORSWithConfig(config))
+	handler := func(c *poltergeist.Context) error { return c.String(http.StatusOK, "ok") }
+	app.GET("/items", handler)
+	app.GET("/public/items", handler)
+	return app
+}
+
+func TestCORS_Origins(t *testing.T) {
+	app := corsApp(&CORSConfig{
+		AllowOrigins:     []string{"https://app.example.com", "https://*.example.dev", "http://localhost:*"},
+		AllowOriginRegex: []string{`https://pr-\d+\.preview\.io`},
+		AllowCredentials: true,
+	})
+
+	tests := []struct {
+		origin string
+		want   bool
+	}{
+		{"https://app.example.com", true},
+		{"https://APP.example.com", true},
+		{"https://a.b.example.dev", true},
+		{"https://example.dev", false},
+		{"http://localhost:3000", true},
+		{"https://pr-12.preview.io", true},
+		{"https://pr-12.preview.io.evil.com", false},
+		{"https://evil.com/https://pr-12.preview.io", false},
+		{"https://evil.com", false},
+	}
+	for _, tt := range tests {
+		w := corsRequest(app, "/items", tt.origin, "")
+		got := w.Header().Get(headerAllowOrigin)
+		if (got == tt.origin) != tt.want || (!tt.want && got != "") {
+			t.Errorf("%s: Allow-Origin = %q, want allowed %v", tt.origin, got, tt.want)
+		}
+		if tt.want && w.Header().Get(headerAllowCredentials) != "true" {
+			t.Errorf("%s: credentials not allowed", tt.origin)
+		}
+		if w.Code != http.StatusOK || w.Header().Get("Vary") != "Origin" {
+			t.Errorf("%s: status %d, Vary %q", tt.origin, w.Code, w.Header().Get("Vary"))
+		}
+	}
+}
+
+func TestCORS_Preflight(t *testing.T) {
+	app := corsApp(&CORSConfig{
+		AllowOrigins:     []string{"https://app.example.com"},
+		AllowHeaders:     []string{"*"},
+		AllowCredentials: true,
+		MaxAge:           600,
+		Overrides: map[string]*CORSConfig{
+			"/public/": {AllowOrigins: []string{"*"}, MaxAge: -1},
+		},
+	})
+
+	w := corsRequest(app, "/items", "https://app.example.com", http.MethodPut)
+	if w.Code != http.StatusNoContent || w.Body.Len() != 0 {
+		t.Errorf("preflight = %d %q, want an empty 204", w.Code, w.Body.String())
+	}
+	checks := map[string]string{
+		headerAllowOrigin:  "https://app.example.com",
+		headerAllowHeaders: "X-Custom", // "*" is echoed on credentialed requests
+		headerMaxAge:       "600",
+	}
+	for name, want := range checks {
+		if got := w.Header().Get(name); got != want {
+			t.Errorf("%s = %q, want %q", name, got, want)
+		}
+	}
+	if vary := w.Header().Values("Vary"); len(vary) != 3 {
+		t.Errorf("Vary = %v, want Origin and the preflight headers", vary)
+	}
+
+	if w := corsRequest(app, "/items", "https://evil.com", http.MethodPut); w.Code != http.StatusNoContent || w.Header().Get(headerAllowOrigin) != "" {
+		t.Errorf("disallowed preflight = %d with Allow-Origin %q", w.Code, w.Header().Get(headerAllowOrigin))
+	}
+
+	w = corsRequest(app, "/public/items", "https://evil.com", http.MethodGet)
+	if w.Header().Get(headerAllowOrigin) != "*" || w.Header().Get(headerMaxAge) != "0" || w.Header().Get(headerAllowCredentials) != "" {
+		t.Errorf("override headers = %v, want * without credentials", w.Header())
+	}
+}
+
+func TestCORS_CredentialsWithAnyOrigin(t *testing.T) {
+	for _, config := range []*CORSConfig{
+		{AllowOrigins: []string{"*"}, AllowCredentials: true},
+		{AllowOrigins: []string{"https://app.example.com"}, Overrides: map[string]*CORSConfig{
+			"/public": {AllowOrigins: []string{"*"}, AllowCredentials: true},
+		}},
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("%+v: AllowCredentials with a * origin should panic", config)
+				}
+			}()
+			CORSWithConfig(config)
+		}()
+	}
+}
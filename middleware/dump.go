@@ -0,0 +1,328 @@
+package middleware
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gofuckbiz/poltergeist"
+)
+
+// =============================================================================
+// DUMP - Pretty-printed request/response capture for debugging
+// =============================================================================
+
+// dumpRedacted replaces redacted header and field values
+const dumpRedacted = "[REDACTED]"
+
+// DumpConfig holds request/response dump configuration
+type DumpConfig struct {
+	// Destination for dumps (default: os.Stderr)
+	Output io.Writer
+	// Headers whose values are hidden, case-insensitive (default:
+	// Authorization, Proxy-Authorization, Cookie, Set-Cookie, X-API-Key)
+	RedactHeaders []string
+	// JSON and form fields whose values are hidden at any depth,
+	// case-insensitive (default: password, token, secret, access_token,
+	// refresh_token, client_secret, api_key)
+	RedactFields []string
+	// Largest body printed per direction; longer ones are cut (default: 64KB)
+	MaxBodySize int
+	// Skip function
+	SkipFunc Skipper
+}
+
+// DefaultDumpConfig returns default dump configuration
+func DefaultDumpConfig() *DumpConfig {
+	return &DumpConfig{
+		Output:        os.Stderr,
+		RedactHeaders: []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie", "X-API-Key"},
+		RedactFields:  []string{"password", "token", "secret", "access_token", "refresh_token", "client_secret", "api_key"},
+		MaxBodySize:   64 << 10,
+	}
+}
+
+// Dump returns a development middleware that prints each request and
+// response with headers and bodies, JSON indented and secrets redacted.
+// Not for production: it buffers bodies and logs what clients send.
+//
+//	if os.Getenv("APP_ENV") == "dev" {
+//	    app.Use(middleware.Dump())
+//	}
+func Dump() poltergeist.MiddlewareFunc {
+	return DumpWithConfig(nil)
+}
+
+// DumpWithConfig returns a dump middleware with custom config
+func DumpWithConfig(config *DumpConfig) poltergeist.MiddlewareFunc {
+	cfg := getDumpConfig(config)
+	redact := newDumpRedactor(cfg)
+	var mu sync.Mutex // keeps concurrent dumps from interleaving
+
+	return func(next poltergeist.HandlerFunc) poltergeist.HandlerFunc {
+		return func(c *poltergeist.Context) error {
+			// Skip if configured
+			if cfg.SkipFunc != nil && cfg.SkipFunc(c) {
+				return next(c)
+			}
+			if isRealtime(c) {
+				return next(c)
+			}
+
+			req := c.Request
+			var out bytes.Buffer
+			fmt.Fprintf(&out, "--> %s %s %s\n", req.Method, req.URL.RequestURI(), req.Proto)
+			fmt.Fprintf(&out, "Host: %s\n", req.Host)
+			writeDumpHeaders(&out, req.Header, redact)
+
+			contentType := req.Header.Get(poltergeist.HeaderContentType)
+			switch {
+			case req.ContentLength == 0 || req.Body == nil || req.Body == http.NoBody:
+			case strings.HasPrefix(contentType, poltergeist.ContentTypeMultipart):
+				fmt.Fprintf(&out, "\n[multipart body, %d bytes]\n", req.ContentLength)
+			case req.ContentLength > int64(cfg.MaxBodySize):
+				fmt.Fprintf(&out, "\n[%d bytes, not captured]\n", req.ContentLength)
+			default:
+				body, err := c.Body()
+				if err != nil {
+					return err
+				}
+				writeDumpBody(&out, body, contentType, redact, cfg.MaxBodySize)
+			}
+
+			start := time.Now()
+			dw := &dumpWriter{ResponseWriter: c.Writer, limit: cfg.MaxBodySize}
+			c.Writer = dw
+			err := next(c)
+			c.Writer = dw.ResponseWriter
+
+			status := dw.status
+			if status == 0 {
+				if err != nil && !c.Written() {
+					status = c.ResolveError(err).Code
+				} else {
+					status = c.StatusCode()
+				}
+			}
+			fmt.Fprintf(&out, "\n<-- %d %s (%s)\n", status, http.StatusText(status), time.Since(start))
+			if err != nil && dw.status == 0 {
+				// The router renders the error after the chain returns
+				fmt.Fprintf(&out, "error: %v\n", err)
+			} else {
+				writeDumpHeaders(&out, dw.Header(), redact)
+				writeDumpBody(&out, dw.body, dw.Header().Get(poltergeist.HeaderContentType), redact, cfg.MaxBodySize)
+				if dw.truncated {
+					out.WriteString("[truncated]\n")
+				}
+			}
+			out.WriteString("\n")
+
+			mu.Lock()
+			cfg.Output.Write(out.Bytes())
+			mu.Unlock()
+			return err
+		}
+	}
+}
+
+// getDumpConfig fills unset fields with defaults
+func getDumpConfig(config *DumpConfig) *DumpConfig {
+	defaults := DefaultDumpConfig()
+	if config == nil {
+		return defaults
+	}
+	cfg := *config
+	if cfg.Output == nil {
+		cfg.Output = defaults.Output
+	}
+	if cfg.RedactHeaders == nil {
+		cfg.RedactHeaders = defaults.RedactHeaders
+	}
+	if cfg.RedactFields == nil {
+		cfg.RedactFields = defaults.RedactFields
+	}
+	if cfg.MaxBodySize <= 0 {
+		cfg.MaxBodySize = defaults.MaxBodySize
+	}
+	return &cfg
+}
+
+// dumpRedactor decides which header and field values are hidden
+type dumpRedactor struct {
+	headers map[string]bool
+	fields  map[string]bool
+	// Matches "field": "value" pairs in JSON that does not parse, such as
+	// truncated or malformed bodies
+	rawFields *regexp.Regexp
+}
+
+func newDumpRedactor(cfg *DumpConfig) *dumpRedactor {
+	r := &dumpRedactor{headers: make(map[string]bool), fields: make(map[string]bool)}
+	for _, name := range cfg.RedactHeaders {
+		r.headers[strings.ToLower(name)] = true
+	}
+	quoted := make([]string, 0, len(cfg.RedactFields))
+	for _, name := range cfg.RedactFields {
+		r.fields[strings.ToLower(name)] = true
+		quoted = append(quoted, regexp.QuoteMeta(name))
+	}
+	if len(quoted) > 0 {
+		r.rawFields = regexp.MustCompile(`(?i)("(?:` + strings.Join(quoted, "|") + `)"\s*:\s*)("(?:[^"\\]|\\.)*"?|[^,}\]\s]+)`)
+	}
+	return r
+}
+
+// raw redacts field values in unparsed JSON text
+func (r *dumpRedactor) raw(body []byte) []byte {
+	if r.rawFields == nil {
+		return body
+	}
+	return r.rawFields.ReplaceAll(body, []byte(`${1}"`+dumpRedacted+`"`))
+}
+
+// writeDumpHeaders prints headers sorted by name, hiding redacted values
+func writeDumpHeaders(out *bytes.Buffer, header http.Header, redact *dumpRedactor) {
+	names := make([]string, 0, len(header))
+	for name := range header {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		for _, value := range header[name] {
+			if redact.headers[strings.ToLower(name)] {
+				value = dumpRedacted
+			}
+			fmt.Fprintf(out, "%s: %s\n", name, value)
+		}
+	}
+}
+
+// writeDumpBody prints a body: JSON indented and form data one field per
+// line, both redacted; other text as is, binary as a byte count
+func writeDumpBody(out *bytes.Buffer, body []byte, contentType string, redact *dumpRedactor, limit int) {
+	if len(body) == 0 {
+		return
+	}
+	out.WriteString("\n")
+	switch {
+	case strings.Contains(contentType, "json"):
+		var v any
+		if json.Unmarshal(body, &v) == nil {
+			enc := json.NewEncoder(out)
+			enc.SetEscapeHTML(false)
+			enc.SetIndent("", "  ")
+			enc.Encode(redactJSON(v, redact.fields))
+			return
+		}
+		body = redact.raw(body)
+	case strings.HasPrefix(contentType, poltergeist.ContentTypeForm):
+		if values, err := url.ParseQuery(string(body)); err == nil {
+			names := make([]string, 0, len(values))
+			for name := range values {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			for _, name := range names {
+				for _, value := range values[name] {
+					if redact.fields[strings.ToLower(name)] {
+						value = dumpRedacted
+					}
+					fmt.Fprintf(out, "%s=%s\n", name, value)
+				}
+			}
+			return
+		}
+	}
+	if !utf8.Valid(body) {
+		fmt.Fprintf(out, "[%d bytes binary]\n", len(body))
+		return
+	}
+	if len(body) > limit {
+		body = body[:limit]
+	}
+	out.Write(body)
+	if body[len(body)-1] != '\n' {
+		out.WriteString("\n")
+	}
+}
+
+// redactJSON hides the values of redacted keys in decoded JSON
+func redactJSON(v any, redact map[string]bool) any {
+	switch v := v.(type) {
+	case map[string]any:
+		for key, value := range v {
+			if redact[strings.ToLower(key)] {
+				v[key] = dumpRedacted
+			} else {
+				v[key] = redactJSON(value, redact)
+			}
+		}
+	case []any:
+		for i, value := range v {
+			v[i] = redactJSON(value, redact)
+		}
+	}
+	return v
+}
+
+// dumpWriter passes the response through while keeping up to limit bytes
+type dumpWriter struct {
+	http.ResponseWriter
+	status    int
+	body      []byte
+	limit     int
+	truncated bool
+}
+
+func (w *dumpWriter) WriteHeader(code int) {
+	if w.status == 0 && code >= http.StatusOK {
+		w.status = code
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *dumpWriter) Write(p []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	if room := w.limit - len(w.body); room < len(p) {
+		w.body = append(w.body, p[:max(room, 0)]...)
+		w.truncated = true
+	} else {
+		w.body = append(w.body, p...)
+	}
+	return w.ResponseWriter.Write(p)
+}
+
+// Flush passes through to the underlying writer
+func (w *dumpWriter) Flush() {
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Hijack passes through to the underlying connection
+func (w *dumpWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	if hijacker, ok := w.ResponseWriter.(http.Hijacker); ok {
+		return hijacker.Hijack()
+	}
+	return nil, nil, errors.New("dump: response writer does not support hijacking")
+}
+
+// Unwrap exposes the underlying writer to http.ResponseController
+func (w *dumpWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,123 @@
+package middleware
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofuckbiz/poltergeist"
+)
+
+// =============================================================================
+// DUMP TESTS
+// =============================================================================
+
+// dumpRequest serves req through a dump of config into a buffer, with a
+// handler echoing the body back as respondType, and returns the dump and
+// what the client got
+func dumpRequest(config *DumpConfig, req *http.Request, respondType string) (string, *httptest.ResponseRecorder) {
+	var out bytes.Buffer
+	cfg := *config
+	cfg.Output = &out
+	app := poltergeist.New()
+	app.Use(DumpWithConfig(&cfg))
+	app.Any("/echo", func(c *poltergeist.Context) error {
+		body, _ := c.Body()
+		http.SetCookie(c.Writer, &http.Cookie{Name: "session", Value: "s3cr3t-cookie"})
+		return c.Bytes(http.StatusOK, respondType, body)
+	})
+	app.GET("/fail", func(c *poltergeist.Context) error { return poltergeist.ErrConflict })
+
+	w := httptest.NewRecorder()
+	app.ServeHTTP(w, req)
+	return out.String(), w
+}
+
+func TestDump_RedactsJSON(t *testing.T) {
+	body := `{"user":"ada","password":"hunter2","nested":{"Token":"t0k3n","items":[{"api_key":"k3y"}]},"note":"token"}`
+	req := httptest.NewRequest(http.MethodPost, "/echo?page=1", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer abc.def")
+	req.Header.Set("x-api-key", "plain-key")
+	req.Header.Set("X-Request-ID", "req-1")
+
+	dump, w := dumpRequest(&DumpConfig{}, req, "application/json")
+	if w.Body.String() != body {
+		t.Errorf("handler got %q, want the untouched body", w.Body.String())
+	}
+	for _, secret := range []string{"hunter2", "t0k3n", "k3y", "abc.def", "plain-key", "s3cr3t-cookie"} {
+		if strings.Contains(dump, secret) {
+			t.Errorf("dump leaks %q:\n%s", secret, dump)
+		}
+	}
+	for _, want := range []string{
+		"--> POST /echo?page=1 HTTP/1.1",
+		"Authorization: [REDACTED]",
+		"X-Api-Key: [REDACTED]",
+		"X-Request-Id: req-1",
+		`"user": "ada"`,
+		`"password": "[REDACTED]"`,
+		`"Token": "[REDACTED]"`,
+		`"note": "token"`,
+		"<-- 200 OK",
+		"Set-Cookie: [REDACTED]",
+	} {
+		if !strings.Contains(dump, want) {
+			t.Errorf("dump missing %q:\n%s", want, dump)
+		}
+	}
+}
+
+func TestDump_RedactsFormAndRawJSON(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/echo", strings.NewReader("user=ada&PASSWORD=hunter2&client_secret=cs"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	dump, _ := dumpRequest(&DumpConfig{}, req, "application/x-www-form-urlencoded")
+	if strings.Contains(dump, "hunter2") || !strings.Contains(dump, "PASSWORD=[REDACTED]") || !strings.Contains(dump, "user=ada") {
+		t.Errorf("form dump:\n%s", dump)
+	}
+
+	// Cut off mid-document, the response JSON no longer parses
+	body := `{"access_token": "at-123", "refresh_token":"rt-456", "secret": 42, "padding": "` + strings.Repeat("x", 64) + `"}`
+	req = httptest.NewRequest(http.MethodPost, "/echo", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	dump, _ = dumpRequest(&DumpConfig{MaxBodySize: 80}, req, "application/json")
+	for _, secret := range []string{"at-123", "rt-456", "42"} {
+		if strings.Contains(dump, secret) {
+			t.Errorf("truncated dump leaks %q:\n%s", secret, dump)
+		}
+	}
+	if !strings.Contains(dump, "[truncated]") {
+		t.Errorf("dump does not mark the cut body:\n%s", dump)
+	}
+}
+
+func TestDump_Config(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/echo", strings.NewReader(`{"password":"hunter2","pin":"1234"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer visible")
+	req.Header.Set("X-Tenant", "acme")
+	dump, _ := dumpRequest(&DumpConfig{RedactHeaders: []string{"x-tenant"}, RedactFields: []string{"PIN"}}, req, "application/json")
+	for _, want := range []string{"Authorization: Bearer visible", "X-Tenant: [REDACTED]", `"password": "hunter2"`, `"pin": "[REDACTED]"`} {
+		if !strings.Contains(dump, want) {
+			t.Errorf("dump missing %q:\n%s", want, dump)
+		}
+	}
+
+	binary := httptest.NewRequest(http.MethodPost, "/echo", bytes.NewReader([]byte{0xff, 0xfe, 0x00}))
+	binary.Header.Set("Content-Type", "application/octet-stream")
+	if dump, _ := dumpRequest(&DumpConfig{}, binary, "application/octet-stream"); !strings.Contains(dump, "[3 bytes binary]") {
+		t.Errorf("binary dump:\n%s", dump)
+	}
+
+	dump, w := dumpRequest(&DumpConfig{}, httptest.NewRequest(http.MethodGet, "/fail", nil), "")
+	if w.Code != http.StatusConflict || !strings.Contains(dump, "<-- 409 Conflict") || !strings.Contains(dump, "error: ") {
+		t.Errorf("error dump (%d):\n%s", w.Code, dump)
+	}
+
+	dump, _ = dumpRequest(&DumpConfig{SkipFunc: SkipPaths("/fail")}, httptest.NewRequest(http.MethodGet, "/fail", nil), "")
+	if dump != "" {
+		t.Errorf("skipped request was dumped:\n%s", dump)
+	}
+}
@@ -0,0 +1,224 @@
+package middleware
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gofuckbiz/poltergeist"
+)
+
+// =============================================================================
+// ETAG - Body-hash entity tags and 304 Not Modified
+// =============================================================================
+
+// ETagConfig holds ETag middleware configuration
+type ETagConfig struct {
+	// Generate weak validators (W/"...") instead of strong ones (default: false)
+	Weak bool
+	// Largest body that is buffered and hashed; bigger and streamed
+	// responses pass through untagged (default: 1MB)
+	MaxSize int
+	// Skip function
+	SkipFunc Skipper
+}
+
+// DefaultETagConfig returns default ETag configuration
+func DefaultETagConfig() *ETagConfig {
+	return &ETagConfig{
+		MaxSize: 1 << 20,
+	}
+}
+
+// ETag returns a middleware that tags 200 GET/HEAD responses with a hash of
+// their body and answers a matching If-None-Match with 304 Not Modified.
+// HEAD requests reach the handler as GET, so they get GET's tag; their body
+// is dropped.
+func ETag() poltergeist.MiddlewareFunc {
+	return ETagWithConfig(nil)
+}
+
+// ETagWithConfig returns an ETag middleware with custom config. Handlers
+// that set their own ETag keep it and still get the 304 check.
+func ETagWithConfig(config *ETagConfig) poltergeist.MiddlewareFunc {
+	cfg := getETagConfig(config)
+
+	return func(next poltergeist.HandlerFunc) poltergeist.HandlerFunc {
+		return func(c *poltergeist.Context) error {
+			// Skip if configured
+			if cfg.SkipFunc != nil && cfg.SkipFunc(c) {
+				return next(c)
+			}
+			method := c.Request.Method
+			if (method != http.MethodGet && method != http.MethodHead) || isRealtime(c) {
+				return next(c)
+			}
+
+			head := method == http.MethodHead
+			if head {
+				c.Request.Method = http.MethodGet
+			}
+			original := c.Writer
+			ew := &etagWriter{ResponseWriter: original, config: cfg, head: head}
+			c.Writer = ew
+			err := next(c)
+			c.Writer = original
+			c.Request.Method = method
+			if ew.passthrough {
+				return err
+			}
+			if err != nil && ew.status == 0 && len(ew.buf) == 0 {
+				// Nothing was sent; let the router render the error
+				return err
+			}
+			if writeErr := ew.finish(c); err == nil {
+				err = writeErr
+			}
+			return err
+		}
+	}
+}
+
+// getETagConfig fills unset fields with defaults
+func getETagConfig(config *ETagConfig) *ETagConfig {
+	defaults := DefaultETagConfig()
+	if config == nil {
+		return defaults
+	}
+	cfg := *config
+	if cfg.MaxSize <= 0 {
+		cfg.MaxSize = defaults.MaxSize
+	}
+	return &cfg
+}
+
+// etagWriter buffers the response so it can be hashed, falling back to
+// passing it through once it outgrows MaxSize or is flushed
+type etagWriter struct {
+	http.ResponseWriter
+	config      *ETagConfig
+	status      int
+	buf         []byte
+	head        bool // drop the body, keeping its length
+	passthrough bool
+}
+
+func (w *etagWriter) WriteHeader(code int) {
+	if w.passthrough {
+		w.ResponseWriter.WriteHeader(code)
+		return
+	}
+	if code < http.StatusOK {
+		w.ResponseWriter.WriteHeader(code) // informational, e.g. 103 Early Hints
+		return
+	}
+	if w.status == 0 {
+		w.status = code
+	}
+}
+
+func (w *etagWriter) Write(p []byte) (int, error) {
+	if w.passthrough {
+		return w.ResponseWriter.Write(p)
+	}
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	if len(w.buf)+len(p) > w.config.MaxSize {
+		if err := w.release(); err != nil {
+			return 0, err
+		}
+		return w.ResponseWriter.Write(p)
+	}
+	w.buf = append(w.buf, p...)
+	return len(p), nil
+}
+
+// Flush gives up on tagging: streamed responses go out as they are written
+func (w *etagWriter) Flush() {
+	if !w.passthrough {
+		if err := w.release(); err != nil {
+			return
+		}
+	}
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Unwrap exposes the underlying writer to http.ResponseController
+func (w *etagWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// release sends what is buffered untagged and switches to passthrough
+func (w *etagWriter) release() error {
+	w.passthrough = true
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	w.ResponseWriter.WriteHeader(w.status)
+	buf := w.buf
+	w.buf = nil
+	if len(buf) == 0 {
+		return nil
+	}
+	_, err := w.ResponseWriter.Write(buf)
+	return err
+}
+
+// finish tags a buffered 200 response and sends it, or a 304 when the
+// client's copy is current
+func (w *etagWriter) finish(c *poltergeist.Context) error {
+	header := w.Header()
+	if w.status == http.StatusOK {
+		etag := header.Get("ETag")
+		if etag == "" {
+			etag = w.hash()
+			header.Set("ETag", etag)
+		}
+		if etagMatches(c.Header("If-None-Match"), etag) {
+			for _, name := range []string{poltergeist.HeaderContentType, "Content-Length", poltergeist.HeaderContentEncoding} {
+				header.Del(name)
+			}
+			c.Status(http.StatusNotModified)
+			w.ResponseWriter.WriteHeader(http.StatusNotModified)
+			return nil
+		}
+	}
+	if w.head {
+		if header.Get("Content-Length") == "" {
+			header.Set("Content-Length", strconv.Itoa(len(w.buf)))
+		}
+		w.buf = nil
+	}
+	return w.release()
+}
+
+// hash returns the entity tag for the buffered body
+func (w *etagWriter) hash() string {
+	sum := sha256.Sum256(w.buf)
+	etag := `"` + base64.RawURLEncoding.EncodeToString(sum[:16]) + `"`
+	if w.config.Weak {
+		return "W/" + etag
+	}
+	return etag
+}
+
+// etagMatches applies If-None-Match's weak comparison: "*" matches
+// anything, and W/ prefixes are ignored on both sides
+func etagMatches(ifNoneMatch, etag string) bool {
+	if ifNoneMatch == "" {
+		return false
+	}
+	etag = strings.TrimPrefix(etag, "W/")
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,124 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/gofuckbiz/poltergeist"
+)
+
+// =============================================================================
+// ETAG TESTS
+// =============================================================================
+
+// etagApp serves /doc (GET and HEAD), a large /big, a streamed /stream and
+// a self-tagged /own through the config
+func etagApp(config *ETagConfig) *poltergeist.Server {
+	app := poltergeist.New()
+	app.Use(ETagWithConfig(config))
+	doc := func(c *poltergeist.Context) error { return c.String(http.StatusOK, "hello "+c.Method()) }
+	app.GET("/doc", doc)
+	app.HEAD("/doc", doc)
+	app.POST("/doc", doc)
+	app.GET("/big", func(c *poltergeist.Context) error { return c.String(http.StatusOK, strings.Repeat("x", 64)) })
+	app.GET("/stream", func(c *poltergeist.Context) error {
+		c.Writer.Write([]byte("part"))
+		c.Writer.(http.Flusher).Flush()
+		return nil
+	})
+	app.GET("/own", func(c *poltergeist.Context) error {
+		c.SetHeader("ETag", `"v1"`)
+		return c.String(http.StatusOK, "mine")
+	})
+	app.GET("/missing", func(c *poltergeist.Context) error { return poltergeist.ErrNotFound })
+	app.StaticFS("/files", fstest.MapFS{"a.txt": {Data: []byte("file body")}})
+	return app
+}
+
+// etagRequest sends method to path with an optional If-None-Match
+func etagRequest(app *poltergeist.Server, method, path, ifNoneMatch string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, nil)
+	if ifNoneMatch != "" {
+		req.Header.Set("If-None-Match", ifNoneMatch)
+	}
+	w := httptest.NewRecorder()
+	app.ServeHTTP(w, req)
+	return w
+}
+
+func TestETag_NotModified(t *testing.T) {
+	app := etagApp(nil)
+	first := etagRequest(app, http.MethodGet, "/doc", "")
+	etag := first.Header().Get("ETag")
+	if first.Code != http.StatusOK || !strings.HasPrefix(etag, `"`) || first.Body.String() != "hello GET" {
+		t.Fatalf("GET = %d %q with ETag %q", first.Code, first.Body.String(), etag)
+	}
+
+	for _, ifNoneMatch := range []string{etag, `"other", ` + etag, "W/" + etag, "*"} {
+		w := etagRequest(app, http.MethodGet, "/doc", ifNoneMatch)
+		if w.Code != http.StatusNotModified || w.Body.Len() != 0 || w.Header().Get("Content-Type") != "" {
+			t.Errorf("If-None-Match %s = %d %q, want an empty 304", ifNoneMatch, w.Code, w.Body.String())
+		}
+	}
+	if w := etagRequest(app, http.MethodGet, "/doc", `"stale"`); w.Code != http.StatusOK {
+		t.Errorf("stale tag = %d, want 200", w.Code)
+	}
+	if w := etagRequest(app, http.MethodGet, "/own", `"v1"`); w.Code != http.StatusNotModified || w.Header().Get("ETag") != `"v1"` {
+		t.Errorf("handler ETag = %d %q, want it kept and matched", w.Code, w.Header().Get("ETag"))
+	}
+}
+
+func TestETag_Head(t *testing.T) {
+	app := etagApp(nil)
+	for _, path := range []string{"/doc", "/files/a.txt"} {
+		get := etagRequest(app, http.MethodGet, path, "")
+		head := etagRequest(app, http.MethodHead, path, "")
+		etag := get.Header().Get("ETag")
+		if etag == "" || head.Header().Get("ETag") != etag {
+			t.Errorf("%s: HEAD ETag = %q, GET ETag = %q; want them equal", path, head.Header().Get("ETag"), etag)
+		}
+		if head.Code != http.StatusOK || head.Body.Len() != 0 || head.Header().Get("Content-Length") != strconv.Itoa(get.Body.Len()) {
+			t.Errorf("%s: HEAD = %d %q, Content-Length %q", path, head.Code, head.Body.String(), head.Header().Get("Content-Length"))
+		}
+		if w := etagRequest(app, http.MethodHead, path, etag); w.Code != http.StatusNotModified {
+			t.Errorf("%s: conditional HEAD = %d, want 304", path, w.Code)
+		}
+	}
+}
+
+func TestETag_Untagged(t *testing.T) {
+	app := etagApp(&ETagConfig{MaxSize: 16})
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		code   int
+		body   string
+	}{
+		{"POST", http.MethodPost, "/doc", http.StatusOK, "hello POST"},
+		{"over MaxSize", http.MethodGet, "/big", http.StatusOK, strings.Repeat("x", 64)},
+		{"flushed", http.MethodGet, "/stream", http.StatusOK, "part"},
+		{"error", http.MethodGet, "/missing", http.StatusNotFound, ""},
+	}
+	for _, tt := range tests {
+		w := etagRequest(app, tt.method, tt.path, "*")
+		if w.Code != tt.code || w.Header().Get("ETag") != "" || !strings.Contains(w.Body.String(), tt.body) {
+			t.Errorf("%s: %d %q with ETag %q, want %d untagged", tt.name, w.Code, w.Body.String(), w.Header().Get("ETag"), tt.code)
+		}
+	}
+}
+
+func TestETag_Weak(t *testing.T) {
+	app := etagApp(&ETagConfig{Weak: true})
+	etag := etagRequest(app, http.MethodGet, "/doc", "").Header().Get("ETag")
+	if !strings.HasPrefix(etag, `W/"`) {
+		t.Fatalf("ETag = %q, want a weak validator", etag)
+	}
+	if w := etagRequest(app, http.MethodGet, "/doc", strings.TrimPrefix(etag, "W/")); w.Code != http.StatusNotModified {
+		t.Errorf("strong form of a weak tag = %d, want 304", w.Code)
+	}
+}
@@ -0,0 +1,87 @@
+package middleware
+
+import (
+	"github.com/gofuckbiz/poltergeist"
+)
+
+// =============================================================================
+// I18N - Locale negotiation for c.T
+// =============================================================================
+
+// I18nConfig holds locale negotiation configuration
+type I18nConfig struct {
+	// Message catalogs (required)
+	Bundle *poltergeist.I18n
+	// Query parameter overriding the language, e.g. ?lang=de ("" disables;
+	// default: "lang")
+	QueryParam string
+	// Cookie overriding Accept-Language, e.g. set by a language picker (""
+	// disables; default: "lang")
+	CookieName string
+	// Skip function
+	SkipFunc Skipper
+}
+
+// DefaultI18nConfig returns default locale negotiation configuration
+func DefaultI18nConfig() *I18nConfig {
+	return &I18nConfig{
+		QueryParam: "lang",
+		CookieName: "lang",
+	}
+}
+
+// I18n returns a middleware that picks each request's language from the
+// lang query parameter, the lang cookie or Accept-Language, in that order,
+// and enables c.T and translated error messages. Responses carry
+// Content-Language.
+//
+//	bundle := poltergeist.NewI18n("en")
+//	if err := bundle.LoadFS(locales, "locales/*.json"); err != nil {
+//	    log.Fatal(err)
+//	}
+//	app.Use(middleware.I18n(bundle))
+//
+//	app.GET("/hello", func(c *poltergeist.Context) error {
+//	    return c.String(200, c.T("greeting", c.Query("name")))
+//	})
+func I18n(bundle *poltergeist.I18n) poltergeist.MiddlewareFunc {
+	config := DefaultI18nConfig()
+	config.Bundle = bundle
+	return I18nWithConfig(config)
+}
+
+// I18nWithConfig returns a locale negotiation middleware with custom
+// config. Unset QueryParam and CookieName disable those overrides.
+func I18nWithConfig(config *I18nConfig) poltergeist.MiddlewareFunc {
+	if config == nil || config.Bundle == nil {
+		panic("middleware: I18n requires a Bundle")
+	}
+	bundle := config.Bundle
+
+	return func(next poltergeist.HandlerFunc) poltergeist.HandlerFunc {
+		return func(c *poltergeist.Context) error {
+			// Skip if configured
+			if config.SkipFunc != nil && config.SkipFunc(c) {
+				return next(c)
+			}
+
+			lang := ""
+			if config.QueryParam != "" {
+				lang = bundle.Match(c.Query(config.QueryParam))
+			}
+			if lang == "" && config.CookieName != "" {
+				if cookie, err := c.Request.Cookie(config.CookieName); err == nil {
+					lang = bundle.Match(cookie.Value)
+				}
+			}
+			if lang == "" {
+				lang = bundle.Negotiate(c.Header("Accept-Language"))
+			}
+
+			c.SetI18n(bundle, lang)
+			c.SetHeader("Content-Language", lang)
+			c.Writer.Header().Add("Vary", "Accept-Language")
+			return next(c)
+		}
+	}
+}
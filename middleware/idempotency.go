@@ -0,0 +1,226 @@
+package middleware
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gofuckbiz/poltergeist"
+)
+
+// =============================================================================
+// IDEMPOTENCY - Replaying responses for retried Idempotency-Key requests
+// =============================================================================
+
+// idempotencyKeyPrefix namespaces idempotency entries in the store
+const idempotencyKeyPrefix = "idempotency:"
+
+// HeaderIdempotencyKey is the request header carrying the client's key
+const HeaderIdempotencyKey = "Idempotency-Key"
+
+// IdempotencyConfig holds idempotency middleware configuration
+type IdempotencyConfig struct {
+	// Store for keys and responses; use a shared store such as cache.NewRedis
+	// when several instances serve the API (default: the server cache, see
+	// app.UseCache)
+	Store poltergeist.Cache
+	// Request header carrying the key (default: Idempotency-Key)
+	Header string
+	// Methods the keys apply to (default: POST, PUT, PATCH, DELETE)
+	Methods []string
+	// How long responses are replayed (default: 24 hours)
+	TTL time.Duration
+	// How long a key is held while its first request runs; a crashed
+	// instance releases it after that (default: 1 minute)
+	LockTTL time.Duration
+	// Reject requests without a key with 400
+	Required bool
+	// Largest body that is stored; requests with bigger or streamed
+	// responses run again when retried (default: 1MB)
+	MaxBodySize int
+	// Skip function
+	SkipFunc Skipper
+}
+
+// DefaultIdempotencyConfig returns default idempotency configuration
+func DefaultIdempotencyConfig() *IdempotencyConfig {
+	return &IdempotencyConfig{
+		Header:      HeaderIdempotencyKey,
+		Methods:     []string{http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete},
+		TTL:         24 * time.Hour,
+		LockTTL:     time.Minute,
+		MaxBodySize: 1 << 20,
+	}
+}
+
+// Idempotency returns a middleware that makes requests carrying an
+// Idempotency-Key safe to retry: the first response for a key is stored in
+// store and replayed to retries. A nil store uses the server cache; with
+// none configured, requests run unprotected and a warning is logged.
+//
+//	api.POST("/payments", createPayment, middleware.Idempotency(cache.NewRedis(rdb, "api:")))
+func Idempotency(store poltergeist.Cache) poltergeist.MiddlewareFunc {
+	return IdempotencyWithConfig(&IdempotencyConfig{Store: store})
+}
+
+// IdempotencyWithConfig returns an idempotency middleware with custom config.
+// Keys are scoped to the method, path and Authorization header. A retry
+// gets the stored status, headers and body with Idempotent-Replayed: true;
+// one arriving while the first request runs gets 409, and one reusing a
+// key with a different body gets 422. Failed requests (errors and 5xx)
+// are not stored, so they can be retried.
+func IdempotencyWithConfig(config *IdempotencyConfig) poltergeist.MiddlewareFunc {
+	cfg := getIdempotencyConfig(config)
+
+	return func(next poltergeist.HandlerFunc) poltergeist.HandlerFunc {
+		return func(c *poltergeist.Context) error {
+			// Skip if configured
+			if cfg.SkipFunc != nil && cfg.SkipFunc(c) {
+				return next(c)
+			}
+			if !containsMethod(cfg.Methods, c.Request.Method) {
+				return next(c)
+			}
+			idempotencyKey := c.Header(cfg.Header)
+			if idempotencyKey == "" {
+				if cfg.Required {
+					return poltergeist.ErrBadRequest.WithMessage(cfg.Header + " header is required")
+				}
+				return next(c)
+			}
+
+			body, err := c.Body()
+			if err != nil {
+				return err
+			}
+			sum := sha256.Sum256(body)
+			fingerprint := hex.EncodeToString(sum[:])
+			store := cfg.Store
+			if store == nil {
+				store = c.Cache()
+			}
+			ctx := c.Context()
+			key := idempotencyEntryKey(c, idempotencyKey)
+			pending, _ := json.Marshal(idempotentResponse{Fingerprint: fingerprint})
+
+			// Claim the key atomically, so concurrent retries run the handler once
+			claimed, err := store.Add(ctx, key, pending, cfg.LockTTL)
+			if err != nil {
+				c.Logger().Warn("idempotency store failed", "key", key, "error", err)
+				return next(c)
+			}
+			if !claimed {
+				return replayIdempotent(c, store, key, fingerprint)
+			}
+
+			rec := &cacheRecorder{
+				ResponseWriter: c.Writer,
+				before:         c.Writer.Header().Clone(),
+				limit:          cfg.MaxBodySize,
+			}
+			c.Writer = rec
+			err = next(c)
+			c.Writer = rec.ResponseWriter
+
+			if err != nil || rec.status == 0 || rec.status >= 500 || rec.overflow || rec.streamed {
+				store.Delete(ctx, key)
+				return err
+			}
+			data, _ := json.Marshal(idempotentResponse{
+				Fingerprint: fingerprint,
+				Status:      rec.status,
+				Header:      rec.header,
+				Body:        rec.body,
+			})
+			if err := store.Set(ctx, key, data, cfg.TTL); err != nil {
+				c.Logger().Warn("idempotency store failed", "key", key, "error", err)
+				store.Delete(ctx, key)
+			}
+			return nil
+		}
+	}
+}
+
+// getIdempotencyConfig fills unset fields with defaults
+func getIdempotencyConfig(config *IdempotencyConfig) *IdempotencyConfig {
+	defaults := DefaultIdempotencyConfig()
+	if config == nil {
+		return defaults
+	}
+	cfg := *config
+	if cfg.Header == "" {
+		cfg.Header = defaults.Header
+	}
+	if len(cfg.Methods) == 0 {
+		cfg.Methods = defaults.Methods
+	}
+	if cfg.TTL <= 0 {
+		cfg.TTL = defaults.TTL
+	}
+	if cfg.LockTTL <= 0 {
+		cfg.LockTTL = defaults.LockTTL
+	}
+	if cfg.MaxBodySize <= 0 {
+		cfg.MaxBodySize = defaults.MaxBodySize
+	}
+	return &cfg
+}
+
+// idempotencyEntryKey scopes a client key to the method, path and
+// credentials, so clients can't read each other's responses
+func idempotencyEntryKey(c *poltergeist.Context, idempotencyKey string) string {
+	h := sha256.New()
+	h.Write([]byte(c.Header(poltergeist.HeaderAuthorization)))
+	h.Write([]byte{0})
+	h.Write([]byte(idempotencyKey))
+	return idempotencyKeyPrefix + c.Request.Method + " " + c.Path() + "#" + hex.EncodeToString(h.Sum(nil)[:16])
+}
+
+// containsMethod reports whether methods holds method
+func containsMethod(methods []string, method string) bool {
+	for _, m := range methods {
+		if strings.EqualFold(m, method) {
+			return true
+		}
+	}
+	return false
+}
+
+// =============================================================================
+// STORED RESPONSES
+// =============================================================================
+
+// idempotentResponse is the stored state of a key: without a status while
+// its first request runs, then the response
+type idempotentResponse struct {
+	Fingerprint string      `json:"fingerprint"` // SHA-256 of the request body
+	Status      int         `json:"status,omitempty"`
+	Header      http.Header `json:"header,omitempty"`
+	Body        []byte      `json:"body,omitempty"`
+}
+
+// replayIdempotent answers a request whose key was already claimed
+func replayIdempotent(c *poltergeist.Context, store poltergeist.Cache, key, fingerprint string) error {
+	data, ok, err := store.Get(c.Context(), key)
+	if err != nil {
+		return poltergeist.ErrServiceUnavailable.Wrap(err)
+	}
+	var entry idempotentResponse
+	if ok && json.Unmarshal(data, &entry) == nil && entry.Fingerprint != fingerprint {
+		return poltergeist.ErrUnprocessableEntity.WithMessage("Idempotency key was used with a different request body")
+	}
+	if entry.Status == 0 {
+		// Still running, or released since the claim failed
+		return poltergeist.ErrConflict.WithMessage("A request with this idempotency key is in progress")
+	}
+
+	header := c.Writer.Header()
+	for name, values := range entry.Header {
+		header[name] = values
+	}
+	header.Set("Idempotent-Replayed", "true")
+	return c.Bytes(entry.Status, header.Get(poltergeist.HeaderContentType), entry.Body)
+}
@@ -0,0 +1,149 @@
+package middleware
+
+import (
+	"bytes"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/gofuckbiz/poltergeist"
+	"github.com/gofuckbiz/poltergeist/cache"
+)
+
+// =============================================================================
+// IDEMPOTENCY TESTS
+// =============================================================================
+
+// idempotencyRequest sends a POST with an optional key, body and headers
+func idempotencyRequest(app *poltergeist.Server, key, body string, headers ...string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/orders", strings.NewReader(body))
+	if key != "" {
+		req.Header.Set(HeaderIdempotencyKey, key)
+	}
+	for i := 0; i+1 < len(headers); i += 2 {
+		req.Header.Set(headers[i], headers[i+1])
+	}
+	w := httptest.NewRecorder()
+	app.ServeHTTP(w, req)
+	return w
+}
+
+func TestIdempotency_Replay(t *testing.T) {
+	var calls atomic.Int32
+	app := poltergeist.New()
+	app.POST("/orders", func(c *poltergeist.Context) error {
+		n := calls.Add(1)
+		c.SetHeader("X-Order", "o-1")
+		return c.JSON(http.StatusCreated, poltergeist.H{"call": n})
+	}, Idempotency(cache.NewMemory(nil)))
+
+	first := idempotencyRequest(app, "k1", `{"sku":"a"}`)
+	retry := idempotencyRequest(app, "k1", `{"sku":"a"}`)
+	if calls.Load() != 1 {
+		t.Fatalf("handler ran %d times, want once", calls.Load())
+	}
+	if retry.Code != http.StatusCreated || retry.Body.String() != first.Body.String() || retry.Header().Get("X-Order") != "o-1" {
+		t.Errorf("replay = %d %s %v, want %d %s", retry.Code, retry.Body.String(), retry.Header(), first.Code, first.Body.String())
+	}
+	if retry.Header().Get("Idempotent-Replayed") != "true" || first.Header().Get("Idempotent-Replayed") != "" {
+		t.Error("Idempotent-Replayed should mark only the replay")
+	}
+
+	tests := []struct {
+		name    string
+		key     string
+		body    string
+		headers []string
+		want    int
+		runs    bool
+	}{
+		{"different body", "k1", `{"sku":"b"}`, nil, http.StatusUnprocessableEntity, false},
+		{"other credentials", "k1", `{"sku":"a"}`, []string{"Authorization", "Bearer other"}, http.StatusCreated, true},
+		{"new key", "k2", `{"sku":"a"}`, nil, http.StatusCreated, true},
+		{"no key", "", `{"sku":"a"}`, nil, http.StatusCreated, true},
+	}
+	for _, tt := range tests {
+		before := calls.Load()
+		w := idempotencyRequest(app, tt.key, tt.body, tt.headers...)
+		if w.Code != tt.want || (calls.Load() > before) != tt.runs {
+			t.Errorf("%s: status = %d, handler ran = %v; want %d, %v", tt.name, w.Code, calls.Load() > before, tt.want, tt.runs)
+		}
+	}
+}
+
+func TestIdempotency_FailuresAreRetried(t *testing.T) {
+	var calls atomic.Int32
+	app := poltergeist.New()
+	app.POST("/orders", func(c *poltergeist.Context) error {
+		if calls.Add(1) == 1 {
+			return poltergeist.ErrServiceUnavailable
+		}
+		return c.NoContent()
+	}, Idempotency(cache.NewMemory(nil)))
+
+	if w := idempotencyRequest(app, "k", "{}"); w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("first attempt = %d", w.Code)
+	}
+	if w := idempotencyRequest(app, "k", "{}"); w.Code != http.StatusNoContent || calls.Load() != 2 {
+		t.Errorf("retry = %d after %d calls, want the handler to run again", w.Code, calls.Load())
+	}
+}
+
+func TestIdempotency_InProgress(t *testing.T) {
+	started, release := make(chan struct{}), make(chan struct{})
+	app := poltergeist.New()
+	app.POST("/orders", func(c *poltergeist.Context) error {
+		close(started)
+		<-release
+		return c.NoContent()
+	}, Idempotency(cache.NewMemory(nil)))
+
+	done := make(chan int)
+	go func() { done <- idempotencyRequest(app, "k", "{}").Code }()
+	<-started
+	if w := idempotencyRequest(app, "k", "{}"); w.Code != http.StatusConflict {
+		t.Errorf("concurrent retry = %d, want 409", w.Code)
+	}
+	close(release)
+	if code := <-done; code != http.StatusNoContent {
+		t.Errorf("first request = %d", code)
+	}
+}
+
+func TestIdempotency_Required(t *testing.T) {
+	app := poltergeist.New()
+	app.Use(IdempotencyWithConfig(&IdempotencyConfig{Store: cache.NewMemory(nil), Required: true}))
+	app.POST("/orders", func(c *poltergeist.Context) error { return c.NoContent() })
+	app.GET("/orders", func(c *poltergeist.Context) error { return c.NoContent() })
+
+	if w := idempotencyRequest(app, "", "{}"); w.Code != http.StatusBadRequest {
+		t.Errorf("POST without a key = %d, want 400", w.Code)
+	}
+	w := httptest.NewRecorder()
+	app.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/orders", nil))
+	if w.Code != http.StatusNoContent {
+		t.Errorf("GET without a key = %d, want it untouched", w.Code)
+	}
+}
+
+func TestIdempotency_WithoutCache(t *testing.T) {
+	var logs bytes.Buffer
+	var calls atomic.Int32
+	app := poltergeist.New().UseLogger(slog.New(slog.NewTextHandler(&logs, nil)))
+	app.POST("/orders", func(c *poltergeist.Context) error {
+		calls.Add(1)
+		return c.NoContent()
+	}, Idempotency(nil))
+
+	idempotencyRequest(app, "k1", `{}`)
+	idempotencyRequest(app, "k1", `{}`)
+	if calls.Load() != 2 {
+		t.Errorf("handler ran %d times, want every request served without a cache", calls.Load())
+	}
+	if !strings.Contains(logs.String(), "idempotency store failed") || !strings.Contains(logs.String(), "no cache configured") {
+		t.Errorf("logs = %q, want a warning about the missing cache", logs.String())
+	}
+}
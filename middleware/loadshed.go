@@ -0,0 +1,222 @@
+package middleware
+
+import (
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gofuckbiz/poltergeist"
+)
+
+// =============================================================================
+// LOAD SHEDDING - Drop low-priority work when the server is overloaded
+// =============================================================================
+
+// ShedPriority ranks requests for load shedding; lower priorities are
+// dropped first and ShedCritical never is. The zero value is ShedNormal.
+type ShedPriority int
+
+// Shedding priorities
+const (
+	ShedLow ShedPriority = iota - 1
+	ShedNormal
+	ShedHigh
+	ShedCritical
+)
+
+// LoadShedConfig holds load shedding configuration
+type LoadShedConfig struct {
+	// Latency above which the server counts as overloaded, compared with a
+	// moving average of handled requests (default: 500ms)
+	TargetLatency time.Duration
+	// In-flight requests above which the server counts as overloaded
+	// (default: 0, not considered)
+	MaxInFlight int
+	// How often the shedding level is raised or lowered (default: 1s)
+	Interval time.Duration
+	// Priority of routes by tag (Route.Tag); a route with several tagged
+	// priorities gets the highest
+	Priorities map[string]ShedPriority
+	// Priority of untagged routes (default: ShedNormal)
+	DefaultPriority ShedPriority
+	// Priority chosen per request; ok=false falls back to route tags
+	PriorityFunc func(c *poltergeist.Context) (priority ShedPriority, ok bool)
+	// Retry-After sent with shed requests (default: 5s)
+	RetryAfter time.Duration
+	// Skip function; WebSocket and SSE requests are never shed
+	SkipFunc Skipper
+}
+
+// DefaultLoadShedConfig returns default load shedding configuration
+func DefaultLoadShedConfig() *LoadShedConfig {
+	return &LoadShedConfig{
+		TargetLatency:   500 * time.Millisecond,
+		Interval:        time.Second,
+		DefaultPriority: ShedNormal,
+		RetryAfter:      5 * time.Second,
+	}
+}
+
+// LoadShed returns a load shedding middleware with default config
+func LoadShed() poltergeist.MiddlewareFunc {
+	return LoadShedWithConfig(DefaultLoadShedConfig())
+}
+
+// LoadShedWithConfig returns a load shedding middleware with custom config.
+// While latency or in-flight requests exceed their targets the shedding
+// level rises by one priority per interval, rejecting the lowest-priority
+// requests with 503 and Retry-After so critical endpoints keep their tail
+// latency; it falls again once the server has recovered.
+//
+//	app.Use(middleware.LoadShedWithConfig(&middleware.LoadShedConfig{
+//	    TargetLatency: 200 * time.Millisecond,
+//	    Priorities: map[string]middleware.ShedPriority{
+//	        "checkout": middleware.ShedCritical,
+//	        "reports":  middleware.ShedLow,
+//	    },
+//	}))
+//	app.POST("/orders", createOrder).Tag("checkout")
+func LoadShedWithConfig(config *LoadShedConfig) poltergeist.MiddlewareFunc {
+	cfg := getLoadShedConfig(config)
+	shedder := newLoadShedder(cfg)
+	retryAfter := strconv.Itoa(ceilSeconds(cfg.RetryAfter))
+
+	return func(next poltergeist.HandlerFunc) poltergeist.HandlerFunc {
+		return func(c *poltergeist.Context) error {
+			// Skip if configured
+			if (cfg.SkipFunc != nil && cfg.SkipFunc(c)) || isRealtime(c) {
+				return next(c)
+			}
+
+			start := time.Now()
+			if level, changed := shedder.adjust(start); changed {
+				c.Logger().Warn("load shedding level changed", "level", level, "latency", shedder.averageLatency(), "in_flight", shedder.inFlight.Load())
+			}
+			if cfg.priority(c) < ShedPriority(shedder.level.Load()) {
+				c.SetHeader("Retry-After", retryAfter)
+				return poltergeist.ErrServiceUnavailable.WithMessage("Server overloaded")
+			}
+
+			shedder.inFlight.Add(1)
+			defer func() {
+				shedder.inFlight.Add(-1)
+				shedder.observe(time.Since(start))
+			}()
+			return next(c)
+		}
+	}
+}
+
+// getLoadShedConfig fills unset fields with defaults
+func getLoadShedConfig(config *LoadShedConfig) *LoadShedConfig {
+	defaults := DefaultLoadShedConfig()
+	if config == nil {
+		return defaults
+	}
+	cfg := *config
+	if cfg.TargetLatency <= 0 {
+		cfg.TargetLatency = defaults.TargetLatency
+	}
+	if cfg.Interval <= 0 {
+		cfg.Interval = defaults.Interval
+	}
+	if cfg.RetryAfter <= 0 {
+		cfg.RetryAfter = defaults.RetryAfter
+	}
+	return &cfg
+}
+
+// priority ranks a request: PriorityFunc, then route tags, then the default
+func (cfg *LoadShedConfig) priority(c *poltergeist.Context) ShedPriority {
+	if cfg.PriorityFunc != nil {
+		if p, ok := cfg.PriorityFunc(c); ok {
+			return p
+		}
+	}
+	priority, tagged := cfg.DefaultPriority, false
+	if route := c.Route(); route != nil {
+		for _, tag := range route.RouteTags {
+			if p, ok := cfg.Priorities[tag]; ok && (!tagged || p > priority) {
+				priority, tagged = p, true
+			}
+		}
+	}
+	return priority
+}
+
+// latencyWeight is the weight of each request in the latency average
+const latencyWeight = 0.1
+
+// loadShedder tracks load and the current shedding level: requests with a
+// priority below the level are rejected
+type loadShedder struct {
+	config   *LoadShedConfig
+	level    atomic.Int32
+	inFlight atomic.Int64
+
+	mu         sync.Mutex
+	latency    float64 // moving average, in nanoseconds
+	observed   int     // requests handled since the last adjustment
+	lastAdjust time.Time
+}
+
+// newLoadShedder creates a shedder that sheds nothing yet
+func newLoadShedder(config *LoadShedConfig) *loadShedder {
+	s := &loadShedder{config: config, lastAdjust: time.Now()}
+	s.level.Store(int32(ShedLow))
+	return s
+}
+
+// observe adds a handled request's latency to the moving average
+func (s *loadShedder) observe(d time.Duration) {
+	s.mu.Lock()
+	if s.latency == 0 {
+		s.latency = float64(d)
+	} else {
+		s.latency += latencyWeight * (float64(d) - s.latency)
+	}
+	s.observed++
+	s.mu.Unlock()
+}
+
+// averageLatency returns the moving average latency
+func (s *loadShedder) averageLatency() time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return time.Duration(s.latency)
+}
+
+// adjust raises the level one step per interval while overloaded and
+// lowers it once load is comfortably below the targets, returning the
+// level and whether it changed
+func (s *loadShedder) adjust(now time.Time) (int32, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	level := s.level.Load()
+	if now.Sub(s.lastAdjust) < s.config.Interval {
+		return level, false
+	}
+	s.lastAdjust = now
+	// With everything shed nothing refreshes the average: let it decay so
+	// the level can come down and probe again
+	if s.observed == 0 {
+		s.latency /= 2
+	}
+	s.observed = 0
+
+	pressure := s.latency / float64(s.config.TargetLatency)
+	if limit := s.config.MaxInFlight; limit > 0 {
+		pressure = max(pressure, float64(s.inFlight.Load())/float64(limit))
+	}
+	switch {
+	case pressure > 1 && level < int32(ShedCritical):
+		level++
+	case pressure < 0.8 && level > int32(ShedLow):
+		level--
+	default:
+		return level, false
+	}
+	s.level.Store(level)
+	return level, true
+}
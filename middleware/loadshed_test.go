@@ -0,0 +1,129 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gofuckbiz/poltergeist"
+)
+
+// =============================================================================
+// LOAD SHEDDING TESTS
+// =============================================================================
+
+func TestLoadShed_Priority(t *testing.T) {
+	cfg := getLoadShedConfig(&LoadShedConfig{
+		Priorities: map[string]ShedPriority{"checkout": ShedCritical, "reports": ShedLow, "admin": ShedHigh},
+		PriorityFunc: func(c *poltergeist.Context) (ShedPriority, bool) {
+			if c.Header("X-Priority") == "high" {
+				return ShedHigh, true
+			}
+			return 0, false
+		},
+	})
+
+	tests := []struct {
+		name   string
+		tags   []string
+		header string
+		want   ShedPriority
+	}{
+		{"untagged", nil, "", ShedNormal},
+		{"unknown tag", []string{"users"}, "", ShedNormal},
+		{"low tag", []string{"reports"}, "", ShedLow},
+		{"highest tag wins", []string{"reports", "checkout", "admin"}, "", ShedCritical},
+		{"PriorityFunc", []string{"reports"}, "high", ShedHigh},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got ShedPriority
+			app := poltergeist.New()
+			app.GET("/", func(c *poltergeist.Context) error {
+				got = cfg.priority(c)
+				return c.NoContent()
+			}).Tag(tt.tags...)
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.header != "" {
+				req.Header.Set("X-Priority", tt.header)
+			}
+			app.ServeHTTP(httptest.NewRecorder(), req)
+			if got != tt.want {
+				t.Errorf("priority = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadShed_Levels(t *testing.T) {
+	s := newLoadShedder(getLoadShedConfig(&LoadShedConfig{TargetLatency: 10 * time.Millisecond, Interval: time.Second}))
+	now := time.Now()
+	step := func(latency time.Duration) int32 {
+		s.observe(latency)
+		s.mu.Lock()
+		s.latency = float64(latency)
+		s.mu.Unlock()
+		now = now.Add(time.Second)
+		level, _ := s.adjust(now)
+		return level
+	}
+
+	if level := s.level.Load(); level != int32(ShedLow) {
+		t.Fatalf("initial level = %d, want nothing shed", level)
+	}
+	for _, want := range []ShedPriority{ShedNormal, ShedHigh, ShedCritical, ShedCritical} {
+		if level := step(50 * time.Millisecond); level != int32(want) {
+			t.Errorf("overloaded: level = %d, want %d", level, want)
+		}
+	}
+	if level, changed := s.adjust(now.Add(time.Millisecond)); changed || level != int32(ShedCritical) {
+		t.Errorf("adjusted within the interval: %d, %v", level, changed)
+	}
+	for _, want := range []ShedPriority{ShedHigh, ShedNormal, ShedLow, ShedLow} {
+		if level := step(time.Millisecond); level != int32(want) {
+			t.Errorf("recovering: level = %d, want %d", level, want)
+		}
+	}
+
+	// MaxInFlight alone counts as overload
+	s = newLoadShedder(getLoadShedConfig(&LoadShedConfig{MaxInFlight: 2, Interval: time.Second}))
+	s.inFlight.Store(3)
+	if level, changed := s.adjust(time.Now().Add(time.Second)); !changed || level != int32(ShedNormal) {
+		t.Errorf("over MaxInFlight: level = %d, want %d", level, ShedNormal)
+	}
+}
+
+func TestLoadShed_Middleware(t *testing.T) {
+	app := poltergeist.New().UseLogger(poltergeist.NopLogger)
+	app.Use(LoadShedWithConfig(&LoadShedConfig{
+		TargetLatency: time.Millisecond,
+		Interval:      50 * time.Millisecond,
+		Priorities:    map[string]ShedPriority{"reports": ShedLow, "checkout": ShedCritical},
+	}))
+	app.GET("/slow", func(c *poltergeist.Context) error {
+		time.Sleep(10 * time.Millisecond)
+		return c.NoContent()
+	})
+	app.GET("/reports", func(c *poltergeist.Context) error { return c.NoContent() }).Tag("reports")
+	app.GET("/users", func(c *poltergeist.Context) error { return c.NoContent() })
+	app.GET("/checkout", func(c *poltergeist.Context) error { return c.NoContent() }).Tag("checkout")
+
+	serve := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		app.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		return w
+	}
+	serve("/slow")
+	time.Sleep(60 * time.Millisecond)
+
+	w := serve("/reports")
+	if w.Code != http.StatusServiceUnavailable || w.Header().Get("Retry-After") != "5" {
+		t.Errorf("low priority under load = %d, Retry-After %q; want 503 after 5s", w.Code, w.Header().Get("Retry-After"))
+	}
+	for _, path := range []string{"/users", "/checkout"} {
+		if w := serve(path); w.Code != http.StatusNoContent {
+			t.Errorf("%s under load = %d, want it served", path, w.Code)
+		}
+	}
+}
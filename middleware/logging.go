@@ -20,11 +20,16 @@ const (
 
 // LogConfig holds logging middleware configuration
 type LogConfig struct {
-	// Output format (text or JSON)
+	// Output format for Logger (text or JSON); structured entries ignore it
 	Format LogFormat
 	// Skip certain paths from logging
 	SkipPaths []string
-	// Custom logger
+	// Skip function, checked in addition to SkipPaths
+	SkipFunc Skipper
+	// Printf-style output. Leave nil to log structured entries through the
+	// request logger (c.Logger(), i.e. app.UseLogger's logger).
+	//
+	// Deprecated: use app.UseLogger with slog or a logging adapter
 	Logger *log.Logger
 	// Include request body in logs
 	IncludeBody bool
@@ -37,13 +42,18 @@ func DefaultLogConfig() *LogConfig {
 	return &LogConfig{
 		Format:         LogFormatText,
 		SkipPaths:      []string{"/health", "/healthz", "/ping"},
-		Logger:         log.Default(),
 		IncludeBody:    false,
 		IncludeHeaders: false,
 	}
 }
 
-// Logger returns a logging middleware with default config
+// Logger returns a logging middleware with default config. Each request is
+// logged through the server logger as a structured "request" entry with
+// method, path, route, status, latency, ip, bytes and request_id fields, at
+// Error level for 5xx responses, Warn for 4xx and Info otherwise:
+//
+//	app.UseLogger(logging.NewZerolog(zerolog.New(os.Stdout)))
+//	app.Use(middleware.RequestID(), middleware.Logger())
 func Logger() poltergeist.MiddlewareFunc {
 	return LoggerWithConfig(DefaultLogConfig())
 }
@@ -62,7 +72,7 @@ func LoggerWithConfig(config *LogConfig) poltergeist.MiddlewareFunc {
 	return func(next poltergeist.HandlerFunc) poltergeist.HandlerFunc {
 		return func(c *poltergeist.Context) error {
 			// Skip logging for certain paths
-			if skipPaths[c.Path()] {
+			if skipPaths[c.Path()] || (config.SkipFunc != nil && config.SkipFunc(c)) {
 				return next(c)
 			}
 
@@ -72,15 +82,17 @@ func LoggerWithConfig(config *LogConfig) poltergeist.MiddlewareFunc {
 			clientIP := c.ClientIP()
 
 			// Execute handler
+			mw := &metricsWriter{ResponseWriter: c.Writer}
+			c.Writer = mw
 			err := next(c)
+			c.Writer = mw.ResponseWriter
 
-			// Calculate latency
 			latency := time.Since(start)
+			statusCode := mw.statusFor(c, err)
 
-			// Get status code
-			statusCode := 200
-			if err != nil {
-				statusCode = 500
+			if config.Logger == nil {
+				logRequest(c, path, statusCode, latency, clientIP, mw.size, err)
+				return err
 			}
 
 			// Format and log
@@ -110,6 +122,31 @@ func LoggerWithConfig(config *LogConfig) poltergeist.MiddlewareFunc {
 	}
 }
 
+// logRequest writes the structured access log entry; the request logger
+// already carries request_id, method and route
+func logRequest(c *poltergeist.Context, path string, status int, latency time.Duration, ip string, size int, err error) {
+	fields := []any{
+		"path", path,
+		"status", status,
+		"latency", latency,
+		"ip", ip,
+		"bytes", size,
+	}
+	if err != nil {
+		fields = append(fields, "error", err)
+	}
+
+	logger := c.Logger()
+	switch {
+	case status >= 500:
+		logger.Error("request", fields...)
+	case status >= 400:
+		logger.Warn("request", fields...)
+	default:
+		logger.Info("request", fields...)
+	}
+}
+
 // ANSI color codes
 const (
 	colorReset   = "\033[0m"
@@ -2,10 +2,7 @@
 package middleware
 
 import (
-	"compress/gzip"
-	"io"
 	"net/http"
-	"strings"
 	"time"
 
 	"github.com/gofuckbiz/poltergeist"
@@ -34,63 +31,18 @@ func Timeout(timeout time.Duration) poltergeist.MiddlewareFunc {
 	}
 }
 
-// Secure adds security headers
-func Secure() poltergeist.MiddlewareFunc {
-	return func(next poltergeist.HandlerFunc) poltergeist.HandlerFunc {
-		return func(c *poltergeist.Context) error {
-			c.SetHeader("X-Content-Type-Options", "nosniff")
-			c.SetHeader("X-Frame-Options", "DENY")
-			c.SetHeader("X-XSS-Protection", "1; mode=block")
-			c.SetHeader("Referrer-Policy", "strict-origin-when-cross-origin")
-			c.SetHeader("Content-Security-Policy", "default-src 'self'")
-			return next(c)
-		}
-	}
-}
-
-// gzipWriter wraps response writer for gzip compression
-type gzipWriter struct {
-	io.Writer
-	http.ResponseWriter
-}
-
-func (w gzipWriter) Write(b []byte) (int, error) {
-	return w.Writer.Write(b)
-}
-
-// Gzip returns a gzip compression middleware
-func Gzip() poltergeist.MiddlewareFunc {
-	return func(next poltergeist.HandlerFunc) poltergeist.HandlerFunc {
-		return func(c *poltergeist.Context) error {
-			// Check if client accepts gzip
-			if !strings.Contains(c.Header("Accept-Encoding"), "gzip") {
-				return next(c)
-			}
-
-			// Create gzip writer
-			gz := gzip.NewWriter(c.Writer)
-			defer gz.Close()
-
-			c.SetHeader("Content-Encoding", "gzip")
-			c.Writer = gzipWriter{Writer: gz, ResponseWriter: c.Writer}
-
-			return next(c)
-		}
-	}
-}
-
 // RequestID adds a unique request ID to each request
 func RequestID() poltergeist.MiddlewareFunc {
 	return func(next poltergeist.HandlerFunc) poltergeist.HandlerFunc {
 		return func(c *poltergeist.Context) error {
 			// Check if request already has an ID
-			id := c.Header("X-Request-ID")
+			id := c.Header(poltergeist.HeaderXRequestID)
 			if id == "" {
 				id = generateRequestID()
 			}
 
-			c.SetHeader("X-Request-ID", id)
-			c.Set("request_id", id)
+			c.SetHeader(poltergeist.HeaderXRequestID, id)
+			c.Set(poltergeist.ContextKeyRequestID, id)
 
 			return next(c)
 		}
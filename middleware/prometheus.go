@@ -0,0 +1,215 @@
+package middleware
+
+import (
+	"bufio"
+	"errors"
+	"net"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/gofuckbiz/poltergeist"
+)
+
+// =============================================================================
+// PROMETHEUS - Per-route request metrics
+// =============================================================================
+
+// Metric names recorded by Prometheus() (backends add their namespace)
+const (
+	MetricHandlerRequests = "http_handler_requests_total"   // method, handler, status
+	MetricHandlerDuration = "http_handler_duration_seconds" // method, handler, status
+	MetricRequestSize     = "http_request_size_bytes"       // method, handler
+	MetricResponseSize    = "http_response_size_bytes"      // method, handler, status
+)
+
+// DefaultSizeBuckets are body size histogram buckets in bytes (64B to 16MB)
+var DefaultSizeBuckets = []float64{64, 256, 1 << 10, 4 << 10, 16 << 10, 64 << 10, 256 << 10, 1 << 20, 4 << 20, 16 << 20}
+
+// PrometheusConfig holds request metrics configuration
+type PrometheusConfig struct {
+	// Backend to record into (default: the server's, see app.UseMetrics)
+	Metrics poltergeist.Metrics
+	// Latency buckets in seconds (default: poltergeist.DefaultDurationBuckets)
+	DurationBuckets []float64
+	// Body size buckets in bytes (default: DefaultSizeBuckets)
+	SizeBuckets []float64
+	// Skip function
+	SkipFunc Skipper
+}
+
+// DefaultPrometheusConfig returns default request metrics configuration
+func DefaultPrometheusConfig() *PrometheusConfig {
+	return &PrometheusConfig{
+		DurationBuckets: poltergeist.DefaultDurationBuckets,
+		SizeBuckets:     DefaultSizeBuckets,
+	}
+}
+
+// Prometheus returns a middleware recording request count, latency and
+// request/response body sizes per route, labeled by the route's name (its
+// path pattern when unnamed), method and status. Expose them with the
+// Prometheus backend and app.MetricsEndpoint (app.Metrics returns the
+// backend itself, for registering custom instruments):
+//
+//	app.UseMetrics(metrics.NewPrometheus("myapp"))
+//	app.Use(middleware.Prometheus())
+//	app.MetricsEndpoint() // GET /metrics
+func Prometheus() poltergeist.MiddlewareFunc {
+	return PrometheusWithConfig(nil)
+}
+
+// PrometheusWithConfig returns a request metrics middleware with custom config
+func PrometheusWithConfig(config *PrometheusConfig) poltergeist.MiddlewareFunc {
+	cfg := getPrometheusConfig(config)
+	var current atomic.Pointer[requestInstruments]
+
+	return func(next poltergeist.HandlerFunc) poltergeist.HandlerFunc {
+		return func(c *poltergeist.Context) error {
+			// Skip if configured
+			if cfg.SkipFunc != nil && cfg.SkipFunc(c) {
+				return next(c)
+			}
+
+			backend := cfg.Metrics
+			if backend == nil {
+				backend = c.Metrics()
+			}
+			inst := current.Load()
+			if inst == nil || inst.backend != backend {
+				// Created once per backend; app.UseMetrics may swap it
+				inst = newRequestInstruments(backend, cfg)
+				current.Store(inst)
+			}
+
+			start := time.Now()
+			mw := &metricsWriter{ResponseWriter: c.Writer}
+			c.Writer = mw
+			err := next(c)
+			c.Writer = mw.ResponseWriter
+
+			method, handler, code := methodLabel(c.Method()), handlerLabel(c), strconv.Itoa(mw.statusFor(c, err))
+			inst.requests.Add(1, method, handler, code)
+			inst.duration.Observe(time.Since(start).Seconds(), method, handler, code)
+			inst.requestSize.Observe(float64(max(c.Request.ContentLength, 0)), method, handler)
+			inst.responseSize.Observe(float64(mw.size), method, handler, code)
+			return err
+		}
+	}
+}
+
+// getPrometheusConfig fills unset fields with defaults
+func getPrometheusConfig(config *PrometheusConfig) *PrometheusConfig {
+	defaults := DefaultPrometheusConfig()
+	if config == nil {
+		return defaults
+	}
+	cfg := *config
+	if len(cfg.DurationBuckets) == 0 {
+		cfg.DurationBuckets = defaults.DurationBuckets
+	}
+	if len(cfg.SizeBuckets) == 0 {
+		cfg.SizeBuckets = defaults.SizeBuckets
+	}
+	return &cfg
+}
+
+// methodLabel passes standard methods through and reports any other as
+// OTHER, since clients choose the method and could grow the series without
+// bound
+func methodLabel(method string) string {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch,
+		http.MethodDelete, http.MethodOptions, http.MethodConnect, http.MethodTrace:
+		return method
+	}
+	return "OTHER"
+}
+
+// handlerLabel names the matched route: its name, else its pattern
+func handlerLabel(c *poltergeist.Context) string {
+	route := c.Route()
+	switch {
+	case route == nil:
+		return "unmatched"
+	case route.RouteName != "":
+		return route.RouteName
+	}
+	return route.Path
+}
+
+// requestInstruments are the middleware's instruments on one backend
+type requestInstruments struct {
+	backend      poltergeist.Metrics
+	requests     poltergeist.Counter
+	duration     poltergeist.Histogram
+	requestSize  poltergeist.Histogram
+	responseSize poltergeist.Histogram
+}
+
+func newRequestInstruments(m poltergeist.Metrics, cfg *PrometheusConfig) *requestInstruments {
+	return &requestInstruments{
+		backend:      m,
+		requests:     m.Counter(MetricHandlerRequests, "HTTP requests by route", "method", "handler", "status"),
+		duration:     m.Histogram(MetricHandlerDuration, "HTTP request latency by route in seconds", cfg.DurationBuckets, "method", "handler", "status"),
+		requestSize:  m.Histogram(MetricRequestSize, "HTTP request body size in bytes", cfg.SizeBuckets, "method", "handler"),
+		responseSize: m.Histogram(MetricResponseSize, "HTTP response body size in bytes", cfg.SizeBuckets, "method", "handler", "status"),
+	}
+}
+
+// metricsWriter records the status and body size of a response
+type metricsWriter struct {
+	http.ResponseWriter
+	status int
+	size   int
+}
+
+func (w *metricsWriter) WriteHeader(code int) {
+	if w.status == 0 && code >= http.StatusOK {
+		w.status = code
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *metricsWriter) Write(p []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	n, err := w.ResponseWriter.Write(p)
+	w.size += n
+	return n, err
+}
+
+// statusFor returns the status the client gets: what was written, else
+// what the router will render err as, else the context's status
+func (w *metricsWriter) statusFor(c *poltergeist.Context, err error) int {
+	switch {
+	case w.status != 0:
+		return w.status
+	case err != nil && !c.Written():
+		return c.ResolveError(err).Code
+	}
+	return c.StatusCode()
+}
+
+// Flush passes through to the underlying writer
+func (w *metricsWriter) Flush() {
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Hijack passes through to the underlying connection (WebSocket upgrades)
+func (w *metricsWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	if hijacker, ok := w.ResponseWriter.(http.Hijacker); ok {
+		w.status = http.StatusSwitchingProtocols
+		return hijacker.Hijack()
+	}
+	return nil, nil, errors.New("prometheus: response writer does not support hijacking")
+}
+
+// Unwrap exposes the underlying writer to http.ResponseController
+func (w *metricsWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,55 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofuckbiz/poltergeist"
+	"github.com/gofuckbiz/poltergeist/metrics"
+)
+
+// =============================================================================
+// PROMETHEUS TESTS
+// =============================================================================
+
+func TestPrometheus_Labels(t *testing.T) {
+	app := poltergeist.New().UseMetrics(metrics.NewPrometheus("test"))
+	app.Use(Prometheus())
+	app.GET("/users/:id", func(c *poltergeist.Context) error {
+		return c.String(http.StatusOK, "ada")
+	}).Name("user")
+	app.POST("/posts", func(c *poltergeist.Context) error { return poltergeist.ErrConflict })
+	app.Match([]string{"PURGE", "X-RANDOM-1"}, "/any", func(c *poltergeist.Context) error { return c.NoContent() })
+	app.MetricsEndpoint()
+
+	requests := []struct{ method, target string }{
+		{http.MethodGet, "/users/1"},
+		{http.MethodGet, "/users/2"},
+		{http.MethodPost, "/posts"},
+		{"PURGE", "/any"},
+		{"X-RANDOM-1", "/any"},
+	}
+	for _, r := range requests {
+		app.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(r.method, r.target, strings.NewReader("body")))
+	}
+
+	w := httptest.NewRecorder()
+	app.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	body := w.Body.String()
+	for _, want := range []string{
+		`test_http_handler_requests_total{method="GET",handler="user",status="200"} 2`,
+		`test_http_handler_requests_total{method="POST",handler="/posts",status="409"} 1`,
+		`test_http_handler_requests_total{method="OTHER",handler="/any",status="204"} 2`,
+		`test_http_response_size_bytes_sum{method="GET",handler="user",status="200"} 6`,
+		`test_http_request_size_bytes_sum{method="POST",handler="/posts"} 4`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("metrics missing %s\n%s", want, body)
+		}
+	}
+	if strings.Contains(body, "PURGE") || strings.Contains(body, "X-RANDOM-1") {
+		t.Errorf("non-standard methods leaked into labels:\n%s", body)
+	}
+}
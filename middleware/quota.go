@@ -0,0 +1,237 @@
+package middleware
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gofuckbiz/poltergeist"
+)
+
+// =============================================================================
+// QUOTA - Requests per calendar day or month
+// =============================================================================
+
+// QuotaPeriod is the calendar period a quota resets on
+type QuotaPeriod string
+
+// Quota periods
+const (
+	QuotaDay   QuotaPeriod = "day"
+	QuotaMonth QuotaPeriod = "month"
+)
+
+// Quota is an allowance of Limit requests per calendar Period. Unlike a
+// Rate it doesn't refill gradually: the count starts over when the period
+// does.
+type Quota struct {
+	Limit  int64
+	Period QuotaPeriod
+}
+
+// DailyQuota returns a quota of n requests per day
+func DailyQuota(n int64) Quota { return Quota{Limit: n, Period: QuotaDay} }
+
+// MonthlyQuota returns a quota of n requests per month
+func MonthlyQuota(n int64) Quota { return Quota{Limit: n, Period: QuotaMonth} }
+
+// valid reports whether the quota is usable
+func (q Quota) valid() bool {
+	return q.Limit > 0 && (q.Period == QuotaDay || q.Period == QuotaMonth)
+}
+
+// window returns the period containing now, as an ID and its end
+func (q Quota) window(now time.Time) (string, time.Time) {
+	y, m, d := now.Date()
+	if q.Period == QuotaMonth {
+		return now.Format("2006-01"), time.Date(y, m+1, 1, 0, 0, 0, 0, now.Location())
+	}
+	return now.Format("2006-01-02"), time.Date(y, m, d+1, 0, 0, 0, 0, now.Location())
+}
+
+// QuotaStore counts requests per key and period. Implementations shared
+// between instances (such as ratelimit.Redis) make quotas hold across
+// replicas.
+type QuotaStore interface {
+	// Increment adds one request to key and returns the new count; the
+	// counter may be dropped after expiresAt
+	Increment(ctx context.Context, key string, expiresAt time.Time) (int64, error)
+}
+
+// QuotaConfig holds quota configuration
+type QuotaConfig struct {
+	// Allowance per key, e.g. DailyQuota(10000)
+	Quota Quota
+	// Allowances for specific keys (as returned by KeyFunc)
+	Overrides map[string]Quota
+	// Allowance chosen per request, e.g. by the caller's plan; ok=false
+	// falls back to Overrides and Quota
+	QuotaFunc func(c *poltergeist.Context) (quota Quota, ok bool)
+	// Store that counts requests (default: in-memory, per instance)
+	Store QuotaStore
+	// Key function (default: KeyByAPIKey(""))
+	KeyFunc func(c *poltergeist.Context) string
+	// Time zone periods start in (default: UTC)
+	Location *time.Location
+	// Let requests through when the store fails (default: false, reject
+	// with 503)
+	FailOpen bool
+	// Skip function to bypass the quota
+	SkipFunc Skipper
+	// Custom response when the quota is used up (default: 429)
+	LimitHandler func(c *poltergeist.Context) error
+}
+
+// DefaultQuotaConfig returns default quota configuration
+func DefaultQuotaConfig() *QuotaConfig {
+	return &QuotaConfig{
+		Quota:    DailyQuota(1000),
+		KeyFunc:  KeyByAPIKey(""),
+		Location: time.UTC,
+	}
+}
+
+// QuotaLimit returns a middleware allowing each API key quota requests
+// per period
+//
+//	api.Use(middleware.QuotaLimit(middleware.MonthlyQuota(50_000)))
+func QuotaLimit(quota Quota) poltergeist.MiddlewareFunc {
+	return QuotaLimitWithConfig(&QuotaConfig{Quota: quota})
+}
+
+// QuotaLimitWithConfig returns a quota middleware with custom config.
+// Responses carry X-Quota-Limit, X-Quota-Remaining and X-Quota-Reset
+// (seconds until the period ends); requests over the quota get 429 with
+// Retry-After.
+//
+//	api.Use(middleware.QuotaLimitWithConfig(&middleware.QuotaConfig{
+//	    Quota: middleware.DailyQuota(1000),
+//	    QuotaFunc: func(c *poltergeist.Context) (middleware.Quota, bool) {
+//	        if plan := c.GetString("plan"); plan == "pro" {
+//	            return middleware.MonthlyQuota(1_000_000), true
+//	        }
+//	        return middleware.Quota{}, false
+//	    },
+//	    Store: ratelimit.NewRedis(redisClient, "myapp:quota:"),
+//	}))
+func QuotaLimitWithConfig(config *QuotaConfig) poltergeist.MiddlewareFunc {
+	cfg := getQuotaConfig(config)
+
+	return func(next poltergeist.HandlerFunc) poltergeist.HandlerFunc {
+		return func(c *poltergeist.Context) error {
+			// Skip if configured
+			if cfg.SkipFunc != nil && cfg.SkipFunc(c) {
+				return next(c)
+			}
+
+			key := cfg.KeyFunc(c)
+			quota := cfg.quotaFor(c, key)
+			now := time.Now().In(cfg.Location)
+			window, resetAt := quota.window(now)
+
+			used, err := cfg.Store.Increment(c.Context(), key+"|"+string(quota.Period)+":"+window, resetAt)
+			if err != nil {
+				c.Logger().Error("quota store failed", "error", err)
+				if cfg.FailOpen {
+					return next(c)
+				}
+				return poltergeist.ErrServiceUnavailable
+			}
+
+			reset := strconv.Itoa(ceilSeconds(resetAt.Sub(now)))
+			c.SetHeader("X-Quota-Limit", strconv.FormatInt(quota.Limit, 10))
+			c.SetHeader("X-Quota-Remaining", strconv.FormatInt(max(quota.Limit-used, 0), 10))
+			c.SetHeader("X-Quota-Reset", reset)
+			if used > quota.Limit {
+				c.SetHeader("Retry-After", reset)
+				if cfg.LimitHandler != nil {
+					return cfg.LimitHandler(c)
+				}
+				return poltergeist.ErrTooManyRequests.WithMessage("Quota exceeded")
+			}
+
+			return next(c)
+		}
+	}
+}
+
+// getQuotaConfig fills unset fields with defaults
+func getQuotaConfig(config *QuotaConfig) *QuotaConfig {
+	defaults := DefaultQuotaConfig()
+	if config == nil {
+		config = defaults
+	}
+	cfg := *config
+	if !cfg.Quota.valid() {
+		cfg.Quota = defaults.Quota
+	}
+	if cfg.KeyFunc == nil {
+		cfg.KeyFunc = defaults.KeyFunc
+	}
+	if cfg.Location == nil {
+		cfg.Location = defaults.Location
+	}
+	if cfg.Store == nil {
+		cfg.Store = NewMemoryQuotaStore()
+	}
+	return &cfg
+}
+
+// quotaFor picks the allowance for a request: QuotaFunc, then Overrides,
+// then Quota
+func (cfg *QuotaConfig) quotaFor(c *poltergeist.Context, key string) Quota {
+	if cfg.QuotaFunc != nil {
+		if q, ok := cfg.QuotaFunc(c); ok && q.valid() {
+			return q
+		}
+	}
+	if q, ok := cfg.Overrides[key]; ok && q.valid() {
+		return q
+	}
+	return cfg.Quota
+}
+
+// memoryQuotaStore counts requests in process
+type memoryQuotaStore struct {
+	mu          sync.Mutex
+	counters    map[string]quotaCounter
+	lastCleanup time.Time
+}
+
+// quotaCounter is a key's count for one period
+type quotaCounter struct {
+	count   int64
+	expires time.Time
+}
+
+// NewMemoryQuotaStore creates an in-process quota store; counters of ended
+// periods are dropped hourly
+func NewMemoryQuotaStore() QuotaStore {
+	return &memoryQuotaStore{
+		counters:    make(map[string]quotaCounter),
+		lastCleanup: time.Now(),
+	}
+}
+
+// Increment counts a request for key
+func (s *memoryQuotaStore) Increment(_ context.Context, key string, expiresAt time.Time) (int64, error) {
+	now := time.Now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if now.Sub(s.lastCleanup) >= time.Hour {
+		for k, counter := range s.counters {
+			if !counter.expires.After(now) {
+				delete(s.counters, k)
+			}
+		}
+		s.lastCleanup = now
+	}
+
+	counter := s.counters[key]
+	counter.count++
+	counter.expires = expiresAt
+	s.counters[key] = counter
+	return counter.count, nil
+}
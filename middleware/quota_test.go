@@ -0,0 +1,193 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gofuckbiz/poltergeist"
+)
+
+// =============================================================================
+// QUOTA TESTS
+// =============================================================================
+
+// keyRecorder is a quota store remembering the keys and expiries it saw
+type keyRecorder struct {
+	QuotaStore
+	mu      sync.Mutex
+	keys    []string
+	expires []time.Time
+	err     error
+}
+
+func (s *keyRecorder) Increment(ctx context.Context, key string, expiresAt time.Time) (int64, error) {
+	s.mu.Lock()
+	s.keys = append(s.keys, key)
+	s.expires = append(s.expires, expiresAt)
+	s.mu.Unlock()
+	if s.err != nil {
+		return 0, s.err
+	}
+	return s.QuotaStore.Increment(ctx, key, expiresAt)
+}
+
+// quotaRequest sends a GET with an API key and returns the response
+func quotaRequest(app *poltergeist.Server, apiKey string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	if apiKey != "" {
+		req.Header.Set("X-API-Key", apiKey)
+	}
+	w := httptest.NewRecorder()
+	app.ServeHTTP(w, req)
+	return w
+}
+
+// quotaApp serves / through the quota config
+func quotaApp(config *QuotaConfig) *poltergeist.Server {
+	app := poltergeist.New().UseLogger(poltergeist.NopLogger)
+	app.Use(QuotaLimitWithConfig(config))
+	app.GET("/", func(c *poltergeist.Context) error { return c.NoContent() })
+	return app
+}
+
+func TestQuota_Window(t *testing.T) {
+	kolkata, err := time.LoadLocation("Asia/Kolkata")
+	if err != nil {
+		t.Skip(err)
+	}
+	tests := []struct {
+		quota  Quota
+		now    time.Time
+		id     string
+		resets time.Time
+	}{
+		{DailyQuota(1), time.Date(2026, 3, 14, 15, 4, 5, 0, time.UTC), "2026-03-14", time.Date(2026, 3, 15, 0, 0, 0, 0, time.UTC)},
+		{DailyQuota(1), time.Date(2026, 12, 31, 23, 59, 0, 0, time.UTC), "2026-12-31", time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{MonthlyQuota(1), time.Date(2026, 1, 31, 12, 0, 0, 0, time.UTC), "2026-01", time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC)},
+		{MonthlyQuota(1), time.Date(2026, 12, 1, 0, 0, 0, 0, time.UTC), "2026-12", time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC)},
+		// 20:00 UTC is already the next day in Kolkata (UTC+5:30)
+		{DailyQuota(1), time.Date(2026, 3, 14, 20, 0, 0, 0, time.UTC).In(kolkata), "2026-03-15", time.Date(2026, 3, 16, 0, 0, 0, 0, kolkata)},
+	}
+	for _, tt := range tests {
+		id, resets := tt.quota.window(tt.now)
+		if id != tt.id || !resets.Equal(tt.resets) {
+			t.Errorf("%s window at %s = %s until %s, want %s until %s", tt.quota.Period, tt.now, id, resets, tt.id, tt.resets)
+		}
+	}
+}
+
+func TestQuota_Valid(t *testing.T) {
+	for quota, want := range map[Quota]bool{
+		DailyQuota(10):             true,
+		MonthlyQuota(1):            true,
+		DailyQuota(0):              false,
+		{Limit: 5, Period: "week"}: false,
+	} {
+		if got := quota.valid(); got != want {
+			t.Errorf("%+v valid = %v, want %v", quota, got, want)
+		}
+	}
+}
+
+func TestQuota_Headers(t *testing.T) {
+	app := quotaApp(&QuotaConfig{Quota: DailyQuota(2)})
+	for i, want := range []struct {
+		code      int
+		remaining string
+	}{
+		{http.StatusNoContent, "1"},
+		{http.StatusNoContent, "0"},
+		{http.StatusTooManyRequests, "0"},
+	} {
+		w := quotaRequest(app, "key-a")
+		if w.Code != want.code || w.Header().Get("X-Quota-Limit") != "2" || w.Header().Get("X-Quota-Remaining") != want.remaining {
+			t.Errorf("request %d = %d, limit %q, remaining %q; want %d, 2, %s", i+1, w.Code,
+				w.Header().Get("X-Quota-Limit"), w.Header().Get("X-Quota-Remaining"), want.code, want.remaining)
+		}
+		reset, err := strconv.Atoi(w.Header().Get("X-Quota-Reset"))
+		if err != nil || reset < 1 || reset > 24*60*60 {
+			t.Errorf("request %d: X-Quota-Reset = %q, want seconds until midnight", i+1, w.Header().Get("X-Quota-Reset"))
+		}
+		if (w.Code == http.StatusTooManyRequests) != (w.Header().Get("Retry-After") != "") {
+			t.Errorf("request %d: Retry-After = %q", i+1, w.Header().Get("Retry-After"))
+		}
+	}
+
+	if w := quotaRequest(app, "key-b"); w.Code != http.StatusNoContent {
+		t.Errorf("another key = %d, want its own quota", w.Code)
+	}
+}
+
+func TestQuota_Overrides(t *testing.T) {
+	app := quotaApp(&QuotaConfig{
+		Quota:   DailyQuota(1),
+		KeyFunc: func(c *poltergeist.Context) string { return c.Header("X-API-Key") },
+		Overrides: map[string]Quota{
+			"partner": MonthlyQuota(3),
+			"broken":  {Limit: 10}, // invalid: falls back to Quota
+		},
+		QuotaFunc: func(c *poltergeist.Context) (Quota, bool) {
+			if c.Header("X-API-Key") == "pro" {
+				return DailyQuota(5), true
+			}
+			return Quota{}, false
+		},
+	})
+
+	for key, want := range map[string]string{"basic": "1", "partner": "3", "broken": "1", "pro": "5"} {
+		if got := quotaRequest(app, key).Header().Get("X-Quota-Limit"); got != want {
+			t.Errorf("%s limit = %s, want %s", key, got, want)
+		}
+	}
+}
+
+func TestQuota_StoreKeys(t *testing.T) {
+	tokyo, err := time.LoadLocation("Asia/Tokyo")
+	if err != nil {
+		t.Skip(err)
+	}
+	store := &keyRecorder{QuotaStore: NewMemoryQuotaStore()}
+	app := quotaApp(&QuotaConfig{Quota: MonthlyQuota(5), Store: store, Location: tokyo})
+	quotaRequest(app, "secret")
+
+	now := time.Now().In(tokyo)
+	wantSuffix := "|month:" + now.Format("2006-01")
+	if len(store.keys) != 1 || !strings.HasPrefix(store.keys[0], "apikey:") || !strings.HasSuffix(store.keys[0], wantSuffix) {
+		t.Fatalf("store keys = %v, want an API key hash ending %s", store.keys, wantSuffix)
+	}
+	if strings.Contains(store.keys[0], "secret") {
+		t.Errorf("store key %q leaks the API key", store.keys[0])
+	}
+	y, m, _ := now.Date()
+	if want := time.Date(y, m+1, 1, 0, 0, 0, 0, tokyo); !store.expires[0].Equal(want) {
+		t.Errorf("expiry = %s, want the start of next month in Tokyo (%s)", store.expires[0], want)
+	}
+}
+
+func TestQuota_StoreFailure(t *testing.T) {
+	failing := &keyRecorder{QuotaStore: NewMemoryQuotaStore(), err: errors.New("redis down")}
+	if w := quotaRequest(quotaApp(&QuotaConfig{Store: failing}), "k"); w.Code != http.StatusServiceUnavailable {
+		t.Errorf("fail closed = %d, want 503", w.Code)
+	}
+	if w := quotaRequest(quotaApp(&QuotaConfig{Store: failing, FailOpen: true}), "k"); w.Code != http.StatusNoContent {
+		t.Errorf("fail open = %d, want the request through", w.Code)
+	}
+}
+
+func TestQuota_LimitHandler(t *testing.T) {
+	app := quotaApp(&QuotaConfig{
+		Quota:        DailyQuota(1),
+		LimitHandler: func(c *poltergeist.Context) error { return c.String(http.StatusPaymentRequired, "upgrade") },
+	})
+	quotaRequest(app, "k")
+	if w := quotaRequest(app, "k"); w.Code != http.StatusPaymentRequired || w.Body.String() != "upgrade" {
+		t.Errorf("over quota = %d %q, want the LimitHandler response", w.Code, w.Body.String())
+	}
+}
@@ -1,7 +1,12 @@
 package middleware
 
 import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"math"
 	"net/http"
+	"strconv"
 	"sync"
 	"time"
 
@@ -11,19 +16,34 @@ import (
 
 // RateLimitConfig holds rate limiter configuration
 type RateLimitConfig struct {
-	// Requests per second
+	// Requests per second (used when Rate is zero)
 	RPS float64
-	// Burst size (max requests in a burst)
+	// Burst size (max requests in a burst, used when Rate is zero)
 	Burst int
-	// Key function to identify clients (default: IP-based)
+	// Quota per key, e.g. PerMinute(100); takes precedence over RPS/Burst
+	Rate Rate
+	// Quotas for specific keys (as returned by KeyFunc), e.g. a higher
+	// limit for a partner's API key
+	Overrides map[string]Rate
+	// Quota chosen per request, e.g. by the caller's plan; ok=false falls
+	// back to Overrides and Rate
+	RateFunc func(c *poltergeist.Context) (rate Rate, ok bool)
+	// Store that tracks quotas (default: in-memory, per instance). Use a
+	// shared store such as ratelimit.NewRedis to limit across replicas.
+	Store RateLimitStore
+	// Let requests through when the store fails (default: false, reject
+	// with 503)
+	FailOpen bool
+	// Key function to identify clients (default: IP-based); see KeyByIP,
+	// KeyByUser, KeyByAPIKey and KeyByRoute
 	KeyFunc func(c *poltergeist.Context) string
 	// Skip function to bypass rate limiting
-	SkipFunc func(c *poltergeist.Context) bool
+	SkipFunc Skipper
 	// Custom response when rate limited
 	LimitHandler func(c *poltergeist.Context) error
 	// Cleanup interval for expired limiters
 	CleanupInterval time.Duration
-	// Expiration time for unused limiters
+	// Deprecated: idle keys are dropped once their quota has fully refilled
 	ExpirationTime time.Duration
 }
 
@@ -46,99 +66,401 @@ func DefaultRateLimitConfig() *RateLimitConfig {
 	}
 }
 
-// visitor holds rate limiter and last seen time
-type visitor struct {
-	limiter  *rate.Limiter
-	lastSeen time.Time
+// RateLimit returns a rate limiting middleware with default config
+func RateLimit() poltergeist.MiddlewareFunc {
+	return RateLimitWithConfig(DefaultRateLimitConfig())
 }
 
-// rateLimiterStore stores rate limiters per key
-type rateLimiterStore struct {
-	visitors map[string]*visitor
-	mu       sync.RWMutex
-	config   *RateLimitConfig
+// RateLimitWithConfig returns a rate limiting middleware with custom config.
+// Responses carry the RateLimit-Limit, RateLimit-Remaining and
+// RateLimit-Reset headers, plus Retry-After when limited.
+//
+//	app.Use(middleware.RateLimitWithConfig(&middleware.RateLimitConfig{
+//	    Rate:  middleware.PerMinute(100),
+//	    Store: ratelimit.NewRedis(redisClient, "myapp:rl:"),
+//	}))
+func RateLimitWithConfig(config *RateLimitConfig) poltergeist.MiddlewareFunc {
+	cfg := getRateLimitConfig(config)
+
+	return func(next poltergeist.HandlerFunc) poltergeist.HandlerFunc {
+		return func(c *poltergeist.Context) error {
+			// Skip if configured
+			if cfg.SkipFunc != nil && cfg.SkipFunc(c) {
+				return next(c)
+			}
+
+			// Take one request from this key's quota
+			key := cfg.KeyFunc(c)
+			result, err := cfg.Store.Take(c.Context(), key, cfg.rateFor(c, key))
+			if err != nil {
+				c.Logger().Error("rate limit store failed", "error", err)
+				if cfg.FailOpen {
+					return next(c)
+				}
+				return poltergeist.ErrServiceUnavailable
+			}
+
+			writeRateLimitHeaders(c, result)
+			if !result.Allowed {
+				c.SetHeader("Retry-After", strconv.Itoa(ceilSeconds(result.RetryAfter)))
+				return cfg.LimitHandler(c)
+			}
+
+			return next(c)
+		}
+	}
 }
 
-// newRateLimiterStore creates a new rate limiter store
-func newRateLimiterStore(config *RateLimitConfig) *rateLimiterStore {
-	store := &rateLimiterStore{
-		visitors: make(map[string]*visitor),
-		config:   config,
+// getRateLimitConfig fills unset fields with defaults
+func getRateLimitConfig(config *RateLimitConfig) *RateLimitConfig {
+	defaults := DefaultRateLimitConfig()
+	if config == nil {
+		config = defaults
+	}
+	cfg := *config
+	if !cfg.Rate.valid() {
+		cfg.Rate = rpsRate(cfg.RPS, cfg.Burst)
+	}
+	if cfg.KeyFunc == nil {
+		cfg.KeyFunc = defaults.KeyFunc
+	}
+	if cfg.LimitHandler == nil {
+		cfg.LimitHandler = defaults.LimitHandler
+	}
+	if cfg.Store == nil {
+		cfg.Store = NewMemoryRateLimitStore(cfg.CleanupInterval)
+	}
+	return &cfg
+}
+
+// rateFor picks the quota for a request: RateFunc, then Overrides, then Rate
+func (cfg *RateLimitConfig) rateFor(c *poltergeist.Context, key string) Rate {
+	if cfg.RateFunc != nil {
+		if r, ok := cfg.RateFunc(c); ok && r.valid() {
+			return r
+		}
 	}
+	if r, ok := cfg.Overrides[key]; ok && r.valid() {
+		return r
+	}
+	return cfg.Rate
+}
 
-	// Start cleanup goroutine
-	go store.cleanup()
+// writeRateLimitHeaders sets the RateLimit-* headers for result
+func writeRateLimitHeaders(c *poltergeist.Context, result RateLimitResult) {
+	c.SetHeader("RateLimit-Limit", strconv.Itoa(result.Limit))
+	c.SetHeader("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+	c.SetHeader("RateLimit-Reset", strconv.Itoa(ceilSeconds(result.ResetAfter)))
+}
 
-	return store
+// ceilSeconds rounds d up to whole seconds
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
 }
 
-// getVisitor returns or creates a rate limiter for a key
-func (s *rateLimiterStore) getVisitor(key string) *rate.Limiter {
-	s.mu.Lock()
-	defer s.mu.Unlock()
+// =============================================================================
+// RATE LIMIT KEYS - Who a quota belongs to
+// =============================================================================
+
+// KeyByIP limits each client IP separately (see RealIP and
+// Config.TrustedProxies for clients behind proxies)
+func KeyByIP() func(c *poltergeist.Context) string {
+	return func(c *poltergeist.Context) string {
+		return "ip:" + c.ClientIP()
+	}
+}
 
-	v, exists := s.visitors[key]
-	if !exists {
-		limiter := rate.NewLimiter(rate.Limit(s.config.RPS), s.config.Burst)
-		s.visitors[key] = &visitor{
-			limiter:  limiter,
-			lastSeen: time.Now(),
+// KeyByUser limits each authenticated user separately; requests where
+// userID returns "" fall back to their IP
+//
+//	middleware.KeyByUser(func(c *poltergeist.Context) string { return c.GetString("user_id") })
+func KeyByUser(userID func(c *poltergeist.Context) string) func(c *poltergeist.Context) string {
+	return func(c *poltergeist.Context) string {
+		if id := userID(c); id != "" {
+			return "user:" + id
 		}
-		return limiter
+		return "ip:" + c.ClientIP()
 	}
+}
 
-	v.lastSeen = time.Now()
-	return v.limiter
+// KeyByAPIKey limits each API key separately, using the key stored by the
+// API key middleware or else the header (default: X-API-Key); requests
+// without one fall back to their IP. Keys are hashed so stores never hold
+// them.
+func KeyByAPIKey(header string) func(c *poltergeist.Context) string {
+	if header == "" {
+		header = "X-API-Key"
+	}
+	return func(c *poltergeist.Context) string {
+		key := c.GetString("api_key")
+		if key == "" {
+			key = c.Header(header)
+		}
+		if key == "" {
+			return "ip:" + c.ClientIP()
+		}
+		sum := sha256.Sum256([]byte(key))
+		return "apikey:" + hex.EncodeToString(sum[:16])
+	}
 }
 
-// cleanup removes expired visitors
-func (s *rateLimiterStore) cleanup() {
-	for {
-		time.Sleep(s.config.CleanupInterval)
-		s.mu.Lock()
-		for key, v := range s.visitors {
-			if time.Since(v.lastSeen) > s.config.ExpirationTime {
-				delete(s.visitors, key)
-			}
+// KeyByRoute gives every route its own quota, per key when key is set
+// (e.g. KeyByIP()) or shared by all clients of the route when it is nil
+func KeyByRoute(key func(c *poltergeist.Context) string) func(c *poltergeist.Context) string {
+	return func(c *poltergeist.Context) string {
+		route := c.Method() + " " + c.Path()
+		if r := c.Route(); r != nil {
+			route = r.Method + " " + r.Path
+		}
+		if key == nil {
+			return "route:" + route
 		}
-		s.mu.Unlock()
+		return "route:" + route + "|" + key(c)
 	}
 }
 
-// RateLimit returns a rate limiting middleware with default config
-func RateLimit() poltergeist.MiddlewareFunc {
-	return RateLimitWithConfig(DefaultRateLimitConfig())
+// =============================================================================
+// RATE LIMIT STORES - Quota tracking (GCRA and sliding window)
+// =============================================================================
+
+// RateLimitStrategy selects how a quota is enforced
+type RateLimitStrategy string
+
+// Rate limiting strategies
+const (
+	// StrategyTokenBucket refills one request every Period/Limit and lets up
+	// to Burst through at once (GCRA); the default
+	StrategyTokenBucket RateLimitStrategy = "token_bucket"
+	// StrategySlidingWindow allows Limit requests in any Period-long window,
+	// weighting the previous fixed window by its overlap; Burst is ignored
+	StrategySlidingWindow RateLimitStrategy = "sliding_window"
+)
+
+// Rate is a request quota: Limit requests per Period, of which up to Burst
+// may arrive at once
+type Rate struct {
+	Limit    int
+	Period   time.Duration
+	Burst    int               // default: Limit
+	Strategy RateLimitStrategy // default: StrategyTokenBucket
 }
 
-// RateLimitWithConfig returns a rate limiting middleware with custom config
-func RateLimitWithConfig(config *RateLimitConfig) poltergeist.MiddlewareFunc {
-	if config == nil {
-		config = DefaultRateLimitConfig()
+// PerSecond returns a quota of n requests per second
+func PerSecond(n int) Rate { return Rate{Limit: n, Period: time.Second} }
+
+// PerMinute returns a quota of n requests per minute
+func PerMinute(n int) Rate { return Rate{Limit: n, Period: time.Minute} }
+
+// PerHour returns a quota of n requests per hour
+func PerHour(n int) Rate { return Rate{Limit: n, Period: time.Hour} }
+
+// WithBurst returns the quota allowing burst requests at once
+func (r Rate) WithBurst(burst int) Rate {
+	r.Burst = burst
+	return r
+}
+
+// SlidingWindow returns the quota enforced with StrategySlidingWindow
+func (r Rate) SlidingWindow() Rate {
+	r.Strategy = StrategySlidingWindow
+	return r
+}
+
+// Interval is the time it takes to earn back one request; at least 1ns, so
+// huge limits don't make GCRA divide by zero
+func (r Rate) Interval() time.Duration {
+	return max(r.Period/time.Duration(r.Limit), time.Nanosecond)
+}
+
+// Capacity is the number of requests allowed at once
+func (r Rate) Capacity() int {
+	if r.Strategy == StrategySlidingWindow {
+		return r.Limit
 	}
+	if r.Burst > 0 {
+		return r.Burst
+	}
+	return r.Limit
+}
 
-	store := newRateLimiterStore(config)
+// valid reports whether the quota is usable
+func (r Rate) valid() bool {
+	return r.Limit > 0 && r.Period > 0
+}
 
-	return func(next poltergeist.HandlerFunc) poltergeist.HandlerFunc {
-		return func(c *poltergeist.Context) error {
-			// Skip if configured
-			if config.SkipFunc != nil && config.SkipFunc(c) {
-				return next(c)
-			}
+// rpsRate converts the legacy RPS/Burst settings to a Rate
+func rpsRate(rps float64, burst int) Rate {
+	if rps <= 0 {
+		rps = DefaultRateLimitConfig().RPS
+	}
+	period := max(time.Duration(float64(time.Second)/rps), time.Nanosecond)
+	return Rate{Limit: 1, Period: period, Burst: max(burst, 1)}
+}
 
-			// Get key for this request
-			key := config.KeyFunc(c)
+// RateLimitResult is the outcome of taking a request from a quota
+type RateLimitResult struct {
+	Allowed    bool
+	Limit      int           // requests allowed at once (the quota's capacity)
+	Remaining  int           // requests left right now
+	ResetAfter time.Duration // until the full capacity is available again
+	RetryAfter time.Duration // until the next request is allowed (when denied)
+}
 
-			// Get rate limiter for this key
-			limiter := store.getVisitor(key)
+// RateLimitStore tracks quotas per key. Implementations shared between
+// instances (such as ratelimit.Redis) make limits hold across replicas.
+type RateLimitStore interface {
+	Take(ctx context.Context, key string, rate Rate) (RateLimitResult, error)
+}
 
-			// Check if allowed
-			if !limiter.Allow() {
-				return config.LimitHandler(c)
-			}
+// GCRA applies the generic cell rate algorithm: given the theoretical
+// arrival time (tat) stored for a key, it decides a request arriving at
+// now and returns the new tat to store. Stores share it so every backend
+// limits identically.
+func GCRA(now, tat time.Time, rate Rate) (RateLimitResult, time.Time) {
+	interval := rate.Interval()
+	capacity := rate.Capacity()
+	if tat.Before(now) {
+		tat = now
+	}
+	newTAT := tat.Add(interval)
+	allowAt := newTAT.Add(-interval * time.Duration(capacity))
+	if now.Before(allowAt) {
+		return RateLimitResult{
+			Limit:      capacity,
+			ResetAfter: tat.Sub(now),
+			RetryAfter: allowAt.Sub(now),
+		}, tat
+	}
+	return RateLimitResult{
+		Allowed:    true,
+		Limit:      capacity,
+		Remaining:  int(now.Sub(allowAt) / interval),
+		ResetAfter: newTAT.Sub(now),
+	}, newTAT
+}
 
-			return next(c)
+// WindowState is the sliding window counters stored for a key
+type WindowState struct {
+	Window   int64 // index of the current fixed window (Unix time / Period)
+	Current  int   // requests counted in the current window
+	Previous int   // requests counted in the previous window
+}
+
+// SlidingWindow decides a request arriving at now under the sliding window
+// counter algorithm and returns the state to store. The previous window's
+// count is weighted by how much of it the sliding window still covers.
+func SlidingWindow(now time.Time, state WindowState, rate Rate) (RateLimitResult, WindowState) {
+	period := rate.Period
+	window := now.UnixNano() / int64(period)
+	switch state.Window {
+	case window:
+	case window - 1:
+		state = WindowState{Window: window, Previous: state.Current}
+	default:
+		state = WindowState{Window: window}
+	}
+
+	elapsed := time.Duration(now.UnixNano() - window*int64(period))
+	remainingInWindow := period - elapsed
+	limit := float64(rate.Limit)
+	used := float64(state.Previous)*float64(remainingInWindow)/float64(period) + float64(state.Current)
+
+	result := RateLimitResult{Limit: rate.Limit}
+	if used+1 > limit {
+		result.RetryAfter = slidingRetryAfter(state, rate, elapsed)
+	} else {
+		state.Current++
+		result.Allowed = true
+		result.Remaining = int(limit - used - 1)
+	}
+	// Counts in this window weigh on the next one until it ends
+	result.ResetAfter = remainingInWindow
+	if state.Current > 0 {
+		result.ResetAfter += period
+	}
+	return result, state
+}
+
+// slidingRetryAfter returns how long until the weighted count leaves room
+// for one more request
+func slidingRetryAfter(state WindowState, rate Rate, elapsed time.Duration) time.Duration {
+	period := float64(rate.Period)
+	limit := float64(rate.Limit)
+	if state.Current+1 > rate.Limit {
+		// This window alone is full: wait for it to become the previous
+		// window and decay enough
+		into := period * (1 - (limit-1)/float64(state.Current))
+		return rate.Period - elapsed + time.Duration(math.Ceil(into))
+	}
+	// The previous window's weight has to shrink
+	at := period * (1 - (limit-float64(state.Current)-1)/float64(state.Previous))
+	return time.Duration(math.Ceil(at)) - elapsed
+}
+
+// memoryRateLimitStore keeps quota state in process
+type memoryRateLimitStore struct {
+	mu              sync.Mutex
+	tats            map[string]time.Time   // token bucket state
+	windows         map[string]windowEntry // sliding window state
+	cleanupInterval time.Duration
+	lastCleanup     time.Time
+}
+
+// windowEntry is a key's sliding window state and when it stops mattering
+type windowEntry struct {
+	state   WindowState
+	expires time.Time
+}
+
+// NewMemoryRateLimitStore creates an in-process store; keys whose quota has
+// fully refilled are dropped every cleanupInterval (default: 1 minute)
+func NewMemoryRateLimitStore(cleanupInterval time.Duration) RateLimitStore {
+	if cleanupInterval <= 0 {
+		cleanupInterval = time.Minute
+	}
+	return &memoryRateLimitStore{
+		tats:            make(map[string]time.Time),
+		windows:         make(map[string]windowEntry),
+		cleanupInterval: cleanupInterval,
+		lastCleanup:     time.Now(),
+	}
+}
+
+// Take decides a request for key
+func (s *memoryRateLimitStore) Take(_ context.Context, key string, rate Rate) (RateLimitResult, error) {
+	now := time.Now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if now.Sub(s.lastCleanup) >= s.cleanupInterval {
+		s.cleanup(now)
+	}
+
+	if rate.Strategy == StrategySlidingWindow {
+		result, state := SlidingWindow(now, s.windows[key].state, rate)
+		// The counters stop mattering once the next window has passed
+		expires := time.Unix(0, (state.Window+2)*int64(rate.Period))
+		s.windows[key] = windowEntry{state: state, expires: expires}
+		return result, nil
+	}
+	result, tat := GCRA(now, s.tats[key], rate)
+	s.tats[key] = tat
+	return result, nil
+}
+
+// cleanup drops keys whose state no longer limits anything
+func (s *memoryRateLimitStore) cleanup(now time.Time) {
+	for k, tat := range s.tats {
+		if !tat.After(now) {
+			delete(s.tats, k)
+		}
+	}
+	for k, entry := range s.windows {
+		if !entry.expires.After(now) {
+			delete(s.windows, k)
 		}
 	}
+	s.lastCleanup = now
 }
 
 // RateLimitPerRoute returns a rate limiter specific to a single route
@@ -165,6 +487,8 @@ type SlidingWindowConfig struct {
 	MaxRequests int
 	// Key function
 	KeyFunc func(c *poltergeist.Context) string
+	// Skip function to bypass rate limiting
+	SkipFunc Skipper
 }
 
 // slidingWindowStore stores request timestamps
@@ -206,6 +530,11 @@ func SlidingWindowRateLimit(config *SlidingWindowConfig) poltergeist.MiddlewareF
 
 	return func(next poltergeist.HandlerFunc) poltergeist.HandlerFunc {
 		return func(c *poltergeist.Context) error {
+			// Skip if configured
+			if config.SkipFunc != nil && config.SkipFunc(c) {
+				return next(c)
+			}
+
 			key := config.KeyFunc(c)
 			now := time.Now()
 
@@ -0,0 +1,154 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofuckbiz/poltergeist"
+)
+
+// =============================================================================
+// RATE LIMIT TESTS
+// =============================================================================
+
+// rateLimitRequest sends a GET from remoteAddr
+func rateLimitRequest(app *poltergeist.Server, remoteAddr string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.RemoteAddr = remoteAddr
+	w := httptest.NewRecorder()
+	app.ServeHTTP(w, req)
+	return w
+}
+
+func rateLimitApp(config *RateLimitConfig) *poltergeist.Server {
+	app := poltergeist.New()
+	app.Use(RateLimitWithConfig(config))
+	app.GET("/", func(c *poltergeist.Context) error { return c.NoContent() })
+	return app
+}
+
+func TestRateLimit_Headers(t *testing.T) {
+	for _, rate := range []Rate{PerMinute(2), PerMinute(2).SlidingWindow()} {
+		app := rateLimitApp(&RateLimitConfig{Rate: rate})
+		// Sliding window counts weigh on the next window too
+		maxWait := 60
+		if rate.Strategy == StrategySlidingWindow {
+			maxWait = 120
+		}
+
+		tests := []struct {
+			status    int
+			remaining string
+		}{
+			{http.StatusNoContent, "1"},
+			{http.StatusNoContent, "0"},
+			{http.StatusTooManyRequests, "0"},
+		}
+		for i, tt := range tests {
+			w := rateLimitRequest(app, "203.0.113.7:1234")
+			if w.Code != tt.status {
+				t.Fatalf("%q request %d = %d, want %d", rate.Strategy, i+1, w.Code, tt.status)
+			}
+			if w.Header().Get("RateLimit-Limit") != "2" || w.Header().Get("RateLimit-Remaining") != tt.remaining {
+				t.Errorf("%q request %d: RateLimit-Limit %q, RateLimit-Remaining %q; want 2, %s",
+					rate.Strategy, i+1, w.Header().Get("RateLimit-Limit"), w.Header().Get("RateLimit-Remaining"), tt.remaining)
+			}
+			if reset, err := strconv.Atoi(w.Header().Get("RateLimit-Reset")); err != nil || reset < 1 || reset > maxWait {
+				t.Errorf("%q request %d: RateLimit-Reset = %q, want 1-%d seconds", rate.Strategy, i+1, w.Header().Get("RateLimit-Reset"), maxWait)
+			}
+
+			retryAfter := w.Header().Get("Retry-After")
+			if tt.status != http.StatusTooManyRequests {
+				if retryAfter != "" {
+					t.Errorf("%q request %d: Retry-After %q on an allowed request", rate.Strategy, i+1, retryAfter)
+				}
+				continue
+			}
+			if seconds, err := strconv.Atoi(retryAfter); err != nil || seconds < 1 || seconds > maxWait {
+				t.Errorf("%q: Retry-After = %q, want 1-%d seconds", rate.Strategy, retryAfter, maxWait)
+			}
+			if !strings.Contains(w.Body.String(), "Too Many Requests") {
+				t.Errorf("%q: 429 body = %q", rate.Strategy, w.Body.String())
+			}
+		}
+
+		// Other clients have their own quota
+		if w := rateLimitRequest(app, "198.51.100.9:1234"); w.Code != http.StatusNoContent {
+			t.Errorf("%q: another client = %d, want its own quota", rate.Strategy, w.Code)
+		}
+	}
+}
+
+func TestRateLimit_LimitHandler(t *testing.T) {
+	app := rateLimitApp(&RateLimitConfig{
+		Rate: PerHour(1),
+		LimitHandler: func(c *poltergeist.Context) error {
+			return c.String(http.StatusServiceUnavailable, "slow down")
+		},
+	})
+	rateLimitRequest(app, "203.0.113.7:1234")
+	w := rateLimitRequest(app, "203.0.113.7:1234")
+	if w.Code != http.StatusServiceUnavailable || w.Body.String() != "slow down" || w.Header().Get("Retry-After") == "" {
+		t.Errorf("limited = %d %q, Retry-After %q; want the custom handler after Retry-After is set",
+			w.Code, w.Body.String(), w.Header().Get("Retry-After"))
+	}
+}
+
+// failingRateLimitStore fails every Take
+type failingRateLimitStore struct{}
+
+func (failingRateLimitStore) Take(context.Context, string, Rate) (RateLimitResult, error) {
+	return RateLimitResult{}, errors.New("store down")
+}
+
+func TestRateLimit_StoreFailure(t *testing.T) {
+	closed := rateLimitApp(&RateLimitConfig{Rate: PerMinute(1), Store: failingRateLimitStore{}})
+	if w := rateLimitRequest(closed, "203.0.113.7:1234"); w.Code != http.StatusServiceUnavailable {
+		t.Errorf("store failure = %d, want 503", w.Code)
+	}
+	open := rateLimitApp(&RateLimitConfig{Rate: PerMinute(1), Store: failingRateLimitStore{}, FailOpen: true})
+	if w := rateLimitRequest(open, "203.0.113.7:1234"); w.Code != http.StatusNoContent {
+		t.Errorf("store failure with FailOpen = %d, want the request through", w.Code)
+	}
+}
+
+func TestRate_HugeLimits(t *testing.T) {
+	huge := Rate{Limit: math.MaxInt, Period: time.Second}
+	if huge.Interval() != time.Nanosecond {
+		t.Errorf("Interval = %v, want it clamped to 1ns", huge.Interval())
+	}
+	result, _ := GCRA(time.Now(), time.Time{}, huge)
+	if !result.Allowed {
+		t.Errorf("GCRA with a huge limit denied a request: %+v", result)
+	}
+
+	app := rateLimitApp(&RateLimitConfig{RPS: 1e12, Burst: 1})
+	if w := rateLimitRequest(app, "203.0.113.7:1234"); w.Code != http.StatusNoContent {
+		t.Errorf("huge RPS = %d, want the request through", w.Code)
+	}
+}
+
+func TestGCRA(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	rate := PerSecond(10).WithBurst(2) // one request per 100ms, two at once
+
+	first, tat := GCRA(now, time.Time{}, rate)
+	second, tat := GCRA(now, tat, rate)
+	third, _ := GCRA(now, tat, rate)
+	if !first.Allowed || first.Remaining != 1 || !second.Allowed || second.Remaining != 0 {
+		t.Fatalf("burst = %+v, %+v; want two allowed", first, second)
+	}
+	if third.Allowed || third.RetryAfter != 100*time.Millisecond || third.ResetAfter != 200*time.Millisecond {
+		t.Errorf("over the burst = %+v, want denied with RetryAfter 100ms and ResetAfter 200ms", third)
+	}
+	if later, _ := GCRA(now.Add(100*time.Millisecond), tat, rate); !later.Allowed {
+		t.Errorf("after one interval = %+v, want allowed", later)
+	}
+}
@@ -0,0 +1,41 @@
+package middleware
+
+import (
+	"net"
+
+	"github.com/gofuckbiz/poltergeist"
+)
+
+// =============================================================================
+// REAL IP - Client address from trusted proxies
+// =============================================================================
+
+// RealIP returns a middleware that replaces Request.RemoteAddr with the
+// client address forwarded by trusted proxies, so logging, rate limiting and
+// anything else reading RemoteAddr or c.ClientIP() see the real client.
+// Forwarding headers are believed only when the connecting peer is in
+// trustedCIDRs (CIDRs or single addresses), and X-Forwarded-For is walked
+// from the right, so clients can't spoof their address. RemoteAddr keeps its
+// host:port form; a forwarded client gets port 0, as its port is unknown.
+// Panics if an entry is invalid.
+//
+//	app.Use(middleware.RealIP("10.0.0.0/8", "fd00::/8"))
+//
+// Use it instead of Config.TrustedProxies, not alongside it: once RealIP has
+// run, c.ClientIP() returns the rewritten address.
+func RealIP(trustedCIDRs ...string) poltergeist.MiddlewareFunc {
+	proxies, err := poltergeist.ParseTrustedProxies(trustedCIDRs...)
+	if err != nil {
+		panic(err)
+	}
+
+	return func(next poltergeist.HandlerFunc) poltergeist.HandlerFunc {
+		return func(c *poltergeist.Context) error {
+			ip := proxies.ClientIP(c.Request)
+			if host, _, err := net.SplitHostPort(c.Request.RemoteAddr); err != nil || host != ip {
+				c.Request.RemoteAddr = net.JoinHostPort(ip, "0")
+			}
+			return next(c)
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package middleware
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofuckbiz/poltergeist"
+)
+
+// =============================================================================
+// REAL IP TESTS
+// =============================================================================
+
+func TestRealIP(t *testing.T) {
+	var remoteAddr, clientIP string
+	app := poltergeist.New()
+	app.Use(RealIP("10.0.0.0/8", "192.0.2.1", "fd00::/8"))
+	app.GET("/", func(c *poltergeist.Context) error {
+		remoteAddr, clientIP = c.Request.RemoteAddr, c.ClientIP()
+		return c.NoContent()
+	})
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		forwarded  string
+		realIP     string
+		want       string
+	}{
+		{"direct client", "203.0.113.7:51000", "", "", "203.0.113.7:51000"},
+		{"untrusted peer spoofing", "203.0.113.7:51000", "1.2.3.4", "5.6.7.8", "203.0.113.7:51000"},
+		{"trusted proxy", "10.0.0.2:443", "198.51.100.9", "", "198.51.100.9:0"},
+		{"trusted single address", "192.0.2.1:443", "198.51.100.9", "", "198.51.100.9:0"},
+		{"client-supplied hop before the real one", "10.0.0.2:443", "1.2.3.4, 198.51.100.9", "", "198.51.100.9:0"},
+		{"trusted hops skipped", "10.0.0.2:443", "1.2.3.4, 198.51.100.9, 10.0.0.5, 10.1.1.1", "", "198.51.100.9:0"},
+		{"spoofed trusted hop", "10.0.0.2:443", "198.51.100.9, 10.0.0.5, 203.0.113.50", "", "203.0.113.50:0"},
+		{"only trusted hops", "10.0.0.2:443", "10.0.0.9, 10.0.0.5", "", "10.0.0.9:0"},
+		{"malformed hop", "10.0.0.2:443", "198.51.100.9, junk", "", "10.0.0.2:443"},
+		{"X-Real-IP from trusted proxy", "10.0.0.2:443", "", "198.51.100.9", "198.51.100.9:0"},
+		{"IPv6", "[fd00::1]:443", "2001:db8::7", "", "[2001:db8::7]:0"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.RemoteAddr = tt.remoteAddr
+			if tt.forwarded != "" {
+				req.Header.Set("X-Forwarded-For", tt.forwarded)
+			}
+			if tt.realIP != "" {
+				req.Header.Set("X-Real-IP", tt.realIP)
+			}
+			app.ServeHTTP(httptest.NewRecorder(), req)
+			if remoteAddr != tt.want {
+				t.Errorf("RemoteAddr = %q, want %q", remoteAddr, tt.want)
+			}
+			if want, _, _ := net.SplitHostPort(tt.want); clientIP != want {
+				t.Errorf("ClientIP = %q, want %q", clientIP, want)
+			}
+		})
+	}
+}
+
+func TestRealIP_InvalidCIDR(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("RealIP accepted an invalid CIDR")
+		}
+	}()
+	RealIP("10.0.0.0/33")
+}
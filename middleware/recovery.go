@@ -1,11 +1,15 @@
 package middleware
 
 import (
+	"errors"
 	"fmt"
 	"log"
+	"net"
 	"net/http"
+	"os"
 	"runtime"
 	"strings"
+	"syscall"
 
 	"github.com/gofuckbiz/poltergeist"
 )
@@ -16,20 +20,56 @@ type RecoveryConfig struct {
 	PrintStack bool
 	// Stack trace size (default: 4096)
 	StackSize int
-	// Custom logger
+	// Printf-style output. Leave nil to log structured entries through the
+	// request logger (c.Logger()).
+	//
+	// Deprecated: use app.UseLogger with slog or a logging adapter
 	Logger *log.Logger
-	// Custom recovery handler
+	// Custom response for a recovered panic; a returned error is rendered
+	// like any handler error
+	PanicHandler func(c *poltergeist.Context, p *poltergeist.PanicError) error
+	// Deprecated: use PanicHandler
 	RecoveryHandler func(c *poltergeist.Context, err interface{})
+	// Called for every recovered panic before the response is written, e.g.
+	// ReportTo(rollbarReporter); the server reporter (app.UseReporter) is
+	// always notified
+	Hooks []RecoveryHook
 	// Enable HTML error page in development
 	EnableDevPage bool
 }
 
+// RecoveryHook is notified of recovered panics
+type RecoveryHook interface {
+	OnPanic(c *poltergeist.Context, p *poltergeist.PanicError)
+}
+
+// RecoveryHookFunc adapts a function to RecoveryHook
+type RecoveryHookFunc func(c *poltergeist.Context, p *poltergeist.PanicError)
+
+// OnPanic calls f
+func (f RecoveryHookFunc) OnPanic(c *poltergeist.Context, p *poltergeist.PanicError) {
+	f(c, p)
+}
+
+// ReportTo returns a hook sending panics to reporter, for reporters other
+// than the server's (which always receives them)
+func ReportTo(reporter poltergeist.Reporter) RecoveryHook {
+	return RecoveryHookFunc(func(c *poltergeist.Context, p *poltergeist.PanicError) {
+		reporter.CaptureError(p, &poltergeist.ReportDetails{
+			Level:   poltergeist.ReportLevelFatal,
+			Source:  poltergeist.ReportSourcePanic,
+			Request: c.Request,
+			Route:   handlerLabel(c),
+			Stack:   p.Stack,
+		})
+	})
+}
+
 // DefaultRecoveryConfig returns default recovery configuration
 func DefaultRecoveryConfig() *RecoveryConfig {
 	return &RecoveryConfig{
 		PrintStack:    true,
 		StackSize:     4096,
-		Logger:        log.Default(),
 		EnableDevPage: false,
 	}
 }
@@ -39,45 +79,96 @@ func Recovery() poltergeist.MiddlewareFunc {
 	return RecoveryWithConfig(DefaultRecoveryConfig())
 }
 
-// RecoveryWithConfig returns a recovery middleware with custom config
+// RecoveryWithConfig returns a recovery middleware with custom config. The
+// recovered panic, with its stack, is stored on the Context (c.Panic()).
+// Panics caused by the client hanging up (broken pipe, connection reset)
+// are logged as warnings without a response, report or hooks, and
+// http.ErrAbortHandler is re-panicked so net/http aborts the response.
+//
+//	app.Use(middleware.RecoveryWithConfig(&middleware.RecoveryConfig{
+//	    PanicHandler: func(c *poltergeist.Context, p *poltergeist.PanicError) error {
+//	        return poltergeist.ErrInternalServerError.WithDetails(poltergeist.H{"request_id": c.RequestID()})
+//	    },
+//	    Hooks: []middleware.RecoveryHook{middleware.ReportTo(rollbar)},
+//	}))
 func RecoveryWithConfig(config *RecoveryConfig) poltergeist.MiddlewareFunc {
 	if config == nil {
 		config = DefaultRecoveryConfig()
 	}
+	stackSize := config.StackSize
+	if stackSize <= 0 {
+		stackSize = DefaultRecoveryConfig().StackSize
+	}
 
 	return func(next poltergeist.HandlerFunc) poltergeist.HandlerFunc {
-		return func(c *poltergeist.Context) error {
+		return func(c *poltergeist.Context) (err error) {
 			defer func() {
-				if r := recover(); r != nil {
-					// Get stack trace
-					stack := make([]byte, config.StackSize)
-					length := runtime.Stack(stack, false)
-					stackStr := string(stack[:length])
-
-					// Log the panic
-					if config.PrintStack {
-						config.Logger.Printf("[PANIC RECOVERED] %v\n%s", r, stackStr)
-					} else {
-						config.Logger.Printf("[PANIC RECOVERED] %v", r)
-					}
+				r := recover()
+				if r == nil {
+					return
+				}
+				if r == http.ErrAbortHandler {
+					panic(r)
+				}
 
-					// Custom recovery handler
-					if config.RecoveryHandler != nil {
-						config.RecoveryHandler(c, r)
-						return
-					}
+				// Get stack trace
+				stack := make([]byte, stackSize)
+				length := runtime.Stack(stack, false)
+				p := &poltergeist.PanicError{Value: r, Stack: string(stack[:length])}
+				c.Set(poltergeist.ContextKeyPanic, p)
 
-					// Default error response
-					if config.EnableDevPage {
-						// Development error page
-						html := formatDevErrorPage(r, stackStr)
-						c.HTML(http.StatusInternalServerError, html)
+				// The client is gone: nothing to answer or alert on
+				if isBrokenPipe(r) {
+					if config.Logger != nil {
+						config.Logger.Printf("[CONNECTION BROKEN] %v", r)
 					} else {
-						// Production error response
-						c.JSON(http.StatusInternalServerError, map[string]string{
-							"error": "Internal Server Error",
-						})
+						c.Logger().Warn("client connection broken", "path", c.Path(), "error", r)
 					}
+					return
+				}
+
+				// Log the panic
+				switch {
+				case config.Logger != nil && config.PrintStack:
+					config.Logger.Printf("[PANIC RECOVERED] %v\n%s", r, p.Stack)
+				case config.Logger != nil:
+					config.Logger.Printf("[PANIC RECOVERED] %v", r)
+				case config.PrintStack:
+					c.Logger().Error("panic recovered", "path", c.Path(), "panic", r, "stack", p.Stack)
+				default:
+					c.Logger().Error("panic recovered", "path", c.Path(), "panic", r)
+				}
+
+				// Report to the server's error reporter, if any, then the hooks
+				c.CaptureError(p, &poltergeist.ReportDetails{
+					Level:  poltergeist.ReportLevelFatal,
+					Source: poltergeist.ReportSourcePanic,
+					Stack:  p.Stack,
+				})
+				for _, hook := range config.Hooks {
+					hook.OnPanic(c, p)
+				}
+
+				// Custom recovery handler
+				if config.PanicHandler != nil {
+					err = config.PanicHandler(c, p)
+					return
+				}
+				if config.RecoveryHandler != nil {
+					config.RecoveryHandler(c, r)
+					return
+				}
+
+				// Default error response
+				if config.EnableDevPage {
+					// Development error page
+					html := formatDevErrorPage(r, p.Stack)
+					c.HTML(http.StatusInternalServerError, html)
+				} else {
+					// Production error response
+					c.JSON(http.StatusInternalServerError, map[string]string{
+						"error": "Internal Server Error",
+					})
 				}
 			}()
 
@@ -86,6 +177,27 @@ func RecoveryWithConfig(config *RecoveryConfig) poltergeist.MiddlewareFunc {
 	}
 }
 
+// isBrokenPipe reports whether a panic came from writing to a client that
+// closed the connection
+func isBrokenPipe(r any) bool {
+	err, ok := r.(error)
+	if !ok {
+		return false
+	}
+	if errors.Is(err, syscall.EPIPE) || errors.Is(err, syscall.ECONNRESET) {
+		return true
+	}
+	var opErr *net.OpError
+	if errors.As(err, &opErr) {
+		var syscallErr *os.SyscallError
+		if errors.As(opErr.Err, &syscallErr) {
+			msg := strings.ToLower(syscallErr.Error())
+			return strings.Contains(msg, "broken pipe") || strings.Contains(msg, "connection reset by peer")
+		}
+	}
+	return false
+}
+
 // formatDevErrorPage formats a development error page
 func formatDevErrorPage(err interface{}, stack string) string {
 	// Format stack trace for HTML
@@ -0,0 +1,75 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gofuckbiz/poltergeist"
+)
+
+// =============================================================================
+// RECOVERY TESTS
+// =============================================================================
+
+// captureReporter records what reaches the error reporter
+type captureReporter struct {
+	mu      sync.Mutex
+	sources []string
+}
+
+func (r *captureReporter) CaptureError(err error, details *poltergeist.ReportDetails) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.sources = append(r.sources, details.Source)
+}
+
+func (r *captureReporter) CaptureMessage(string, *poltergeist.ReportDetails) {}
+
+func (r *captureReporter) Flush(time.Duration) bool { return true }
+
+func TestRecovery_ReportsPanicOnce(t *testing.T) {
+	tests := []struct {
+		name   string
+		config *RecoveryConfig
+		want   int
+	}{
+		{"default response", nil, http.StatusInternalServerError},
+		{"PanicHandler returning a 5xx", &RecoveryConfig{
+			PanicHandler: func(c *poltergeist.Context, p *poltergeist.PanicError) error {
+				return poltergeist.ErrInternalServerError.WithDetails(poltergeist.H{"request_id": c.RequestID()})
+			},
+		}, http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reporter := &captureReporter{}
+			app := poltergeist.New().UseLogger(poltergeist.NopLogger)
+			app.UseReporter(reporter)
+			app.Use(RecoveryWithConfig(tt.config))
+			app.GET("/boom", func(c *poltergeist.Context) error { panic("boom") })
+
+			w := httptest.NewRecorder()
+			app.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/boom", nil))
+			if w.Code != tt.want {
+				t.Errorf("status = %d, want %d", w.Code, tt.want)
+			}
+			if len(reporter.sources) != 1 || reporter.sources[0] != poltergeist.ReportSourcePanic {
+				t.Errorf("reports = %v, want the panic once", reporter.sources)
+			}
+		})
+	}
+
+	// Handler errors are still reported
+	reporter := &captureReporter{}
+	app := poltergeist.New().UseLogger(poltergeist.NopLogger)
+	app.UseReporter(reporter)
+	app.Use(Recovery())
+	app.GET("/fail", func(c *poltergeist.Context) error { return poltergeist.ErrInternalServerError })
+	app.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/fail", nil))
+	if len(reporter.sources) != 1 || reporter.sources[0] != poltergeist.ReportSourceHandler {
+		t.Errorf("reports = %v, want the handler error", reporter.sources)
+	}
+}
@@ -0,0 +1,203 @@
+package middleware
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/gofuckbiz/poltergeist"
+)
+
+// =============================================================================
+// SECURITY HEADERS - HSTS, framing, sniffing, referrer and CSP ("helmet")
+// =============================================================================
+
+// SecureConfig holds security header configuration. Empty fields send no
+// header, so start from DefaultSecureConfig() to change a single value.
+type SecureConfig struct {
+	// Strict-Transport-Security max-age in seconds, sent on HTTPS requests
+	// only (0 disables HSTS)
+	HSTSMaxAge int
+	// Add includeSubDomains to HSTS
+	HSTSIncludeSubdomains bool
+	// Add preload to HSTS (see hstspreload.org before enabling)
+	HSTSPreload bool
+	// X-Content-Type-Options value
+	ContentTypeNosniff string
+	// X-Frame-Options value (DENY or SAMEORIGIN)
+	XFrameOptions string
+	// X-XSS-Protection value
+	XSSProtection string
+	// Referrer-Policy value
+	ReferrerPolicy string
+	// Content-Security-Policy value; build one with NewCSP()
+	ContentSecurityPolicy string
+	// Send the policy as Content-Security-Policy-Report-Only
+	CSPReportOnly bool
+	// Skip function
+	SkipFunc Skipper
+}
+
+// DefaultSecureConfig returns default security header configuration
+func DefaultSecureConfig() *SecureConfig {
+	return &SecureConfig{
+		HSTSMaxAge:            31536000, // 1 year
+		HSTSIncludeSubdomains: true,
+		ContentTypeNosniff:    "nosniff",
+		XFrameOptions:         "DENY",
+		XSSProtection:         "1; mode=block",
+		ReferrerPolicy:        "strict-origin-when-cross-origin",
+		ContentSecurityPolicy: NewCSP().DefaultSrc(CSPSelf).String(),
+	}
+}
+
+// Secure adds security headers
+func Secure() poltergeist.MiddlewareFunc {
+	return SecureWithConfig(nil)
+}
+
+// SecureWithConfig adds security headers with custom config
+//
+//	app.Use(middleware.SecureWithConfig(&middleware.SecureConfig{
+//	    HSTSMaxAge:     63072000,
+//	    XFrameOptions:  "SAMEORIGIN",
+//	    ReferrerPolicy: "no-referrer",
+//	    ContentSecurityPolicy: middleware.NewCSP().
+//	        DefaultSrc(middleware.CSPSelf).
+//	        ImgSrc(middleware.CSPSelf, "data:", "https://cdn.example.com").
+//	        FrameAncestors(middleware.CSPNone).
+//	        String(),
+//	}))
+func SecureWithConfig(config *SecureConfig) poltergeist.MiddlewareFunc {
+	if config == nil {
+		config = DefaultSecureConfig()
+	}
+	hsts := hstsValue(config)
+	cspHeader := "Content-Security-Policy"
+	if config.CSPReportOnly {
+		cspHeader = "Content-Security-Policy-Report-Only"
+	}
+	headers := [][2]string{
+		{"X-Content-Type-Options", config.ContentTypeNosniff},
+		{"X-Frame-Options", config.XFrameOptions},
+		{"X-XSS-Protection", config.XSSProtection},
+		{"Referrer-Policy", config.ReferrerPolicy},
+		{cspHeader, config.ContentSecurityPolicy},
+	}
+
+	return func(next poltergeist.HandlerFunc) poltergeist.HandlerFunc {
+		return func(c *poltergeist.Context) error {
+			// Skip if configured
+			if config.SkipFunc != nil && config.SkipFunc(c) {
+				return next(c)
+			}
+			for _, header := range headers {
+				if header[1] != "" {
+					c.SetHeader(header[0], header[1])
+				}
+			}
+			// Browsers ignore HSTS over plain HTTP
+			if hsts != "" && (c.Request.TLS != nil || c.Header("X-Forwarded-Proto") == "https") {
+				c.SetHeader("Strict-Transport-Security", hsts)
+			}
+			return next(c)
+		}
+	}
+}
+
+// hstsValue builds the Strict-Transport-Security header value
+func hstsValue(config *SecureConfig) string {
+	if config.HSTSMaxAge <= 0 {
+		return ""
+	}
+	value := "max-age=" + strconv.Itoa(config.HSTSMaxAge)
+	if config.HSTSIncludeSubdomains {
+		value += "; includeSubDomains"
+	}
+	if config.HSTSPreload {
+		value += "; preload"
+	}
+	return value
+}
+
+// =============================================================================
+// CSP BUILDER
+// =============================================================================
+
+// Common Content-Security-Policy source keywords
+const (
+	CSPSelf          = "'self'"
+	CSPNone          = "'none'"
+	CSPUnsafeInline  = "'unsafe-inline'"
+	CSPUnsafeEval    = "'unsafe-eval'"
+	CSPStrictDynamic = "'strict-dynamic'"
+)
+
+// CSP builds a Content-Security-Policy header value. Directives keep the
+// order they were first added in; adding to one again appends sources.
+type CSP struct {
+	names   []string
+	sources map[string][]string
+}
+
+// NewCSP creates an empty policy
+func NewCSP() *CSP {
+	return &CSP{sources: make(map[string][]string)}
+}
+
+// Add appends sources to a directive, creating it if needed
+func (p *CSP) Add(directive string, sources ...string) *CSP {
+	if _, ok := p.sources[directive]; !ok {
+		p.names = append(p.names, directive)
+	}
+	p.sources[directive] = append(p.sources[directive], sources...)
+	return p
+}
+
+// DefaultSrc sets fallback sources for every fetch directive
+func (p *CSP) DefaultSrc(sources ...string) *CSP { return p.Add("default-src", sources...) }
+
+// ScriptSrc sets allowed script sources
+func (p *CSP) ScriptSrc(sources ...string) *CSP { return p.Add("script-src", sources...) }
+
+// StyleSrc sets allowed stylesheet sources
+func (p *CSP) StyleSrc(sources ...string) *CSP { return p.Add("style-src", sources...) }
+
+// ImgSrc sets allowed image sources
+func (p *CSP) ImgSrc(sources ...string) *CSP { return p.Add("img-src", sources...) }
+
+// FontSrc sets allowed font sources
+func (p *CSP) FontSrc(sources ...string) *CSP { return p.Add("font-src", sources...) }
+
+// ConnectSrc sets allowed fetch/XHR/WebSocket/EventSource targets
+func (p *CSP) ConnectSrc(sources ...string) *CSP { return p.Add("connect-src", sources...) }
+
+// FrameAncestors sets who may embed the page (supersedes X-Frame-Options)
+func (p *CSP) FrameAncestors(sources ...string) *CSP { return p.Add("frame-ancestors", sources...) }
+
+// ObjectSrc sets allowed plugin sources (usually CSPNone)
+func (p *CSP) ObjectSrc(sources ...string) *CSP { return p.Add("object-src", sources...) }
+
+// BaseURI restricts <base> URLs
+func (p *CSP) BaseURI(sources ...string) *CSP { return p.Add("base-uri", sources...) }
+
+// FormAction restricts form submission targets
+func (p *CSP) FormAction(sources ...string) *CSP { return p.Add("form-action", sources...) }
+
+// UpgradeInsecureRequests makes browsers load http:// subresources over HTTPS
+func (p *CSP) UpgradeInsecureRequests() *CSP { return p.Add("upgrade-insecure-requests") }
+
+// ReportURI sets where violation reports are sent
+func (p *CSP) ReportURI(uri string) *CSP { return p.Add("report-uri", uri) }
+
+// String returns the header value, e.g. "default-src 'self'; img-src 'self' data:"
+func (p *CSP) String() string {
+	directives := make([]string, 0, len(p.names))
+	for _, name := range p.names {
+		if sources := p.sources[name]; len(sources) > 0 {
+			directives = append(directives, name+" "+strings.Join(sources, " "))
+		} else {
+			directives = append(directives, name)
+		}
+	}
+	return strings.Join(directives, "; ")
+}
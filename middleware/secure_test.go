@@ -0,0 +1,122 @@
+package middleware
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofuckbiz/poltergeist"
+)
+
+// =============================================================================
+// SECURITY HEADER TESTS
+// =============================================================================
+
+// secureHeaders serves one request through the config and returns the
+// response headers; tweak may adjust the request
+func secureHeaders(config *SecureConfig, tweak func(req *http.Request)) http.Header {
+	app := poltergeist.New()
+	app.Use(SecureWithConfig(config))
+	app.GET("/", func(c *poltergeist.Context) error { return c.NoContent() })
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	if tweak != nil {
+		tweak(req)
+	}
+	w := httptest.NewRecorder()
+	app.ServeHTTP(w, req)
+	return w.Header()
+}
+
+func TestSecure_Defaults(t *testing.T) {
+	header := secureHeaders(nil, nil)
+	want := map[string]string{
+		"X-Content-Type-Options":    "nosniff",
+		"X-Frame-Options":           "DENY",
+		"X-XSS-Protection":          "1; mode=block",
+		"Referrer-Policy":           "strict-origin-when-cross-origin",
+		"Content-Security-Policy":   "default-src 'self'",
+		"Strict-Transport-Security": "", // plain HTTP
+	}
+	for name, value := range want {
+		if got := header.Get(name); got != value {
+			t.Errorf("%s = %q, want %q", name, got, value)
+		}
+	}
+}
+
+func TestSecure_HSTS(t *testing.T) {
+	tests := []struct {
+		name   string
+		config *SecureConfig
+		tweak  func(req *http.Request)
+		want   string
+	}{
+		{"TLS", nil, func(req *http.Request) { req.TLS = &tls.ConnectionState{} }, "max-age=31536000; includeSubDomains"},
+		{"behind a proxy", nil, func(req *http.Request) { req.Header.Set("X-Forwarded-Proto", "https") }, "max-age=31536000; includeSubDomains"},
+		{"forwarded http", nil, func(req *http.Request) { req.Header.Set("X-Forwarded-Proto", "http") }, ""},
+		{"preload", &SecureConfig{HSTSMaxAge: 63072000, HSTSIncludeSubdomains: true, HSTSPreload: true},
+			func(req *http.Request) { req.TLS = &tls.ConnectionState{} }, "max-age=63072000; includeSubDomains; preload"},
+		{"disabled", &SecureConfig{HSTSIncludeSubdomains: true}, func(req *http.Request) { req.TLS = &tls.ConnectionState{} }, ""},
+	}
+	for _, tt := range tests {
+		if got := secureHeaders(tt.config, tt.tweak).Get("Strict-Transport-Security"); got != tt.want {
+			t.Errorf("%s: HSTS = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestSecure_Custom(t *testing.T) {
+	header := secureHeaders(&SecureConfig{
+		XFrameOptions:         "SAMEORIGIN",
+		ContentSecurityPolicy: "default-src 'none'",
+		CSPReportOnly:         true,
+	}, nil)
+	if header.Get("X-Frame-Options") != "SAMEORIGIN" || header.Get("Content-Security-Policy-Report-Only") != "default-src 'none'" {
+		t.Errorf("headers = %v", header)
+	}
+	for _, name := range []string{"Content-Security-Policy", "X-Content-Type-Options", "Referrer-Policy", "X-XSS-Protection"} {
+		if header.Get(name) != "" {
+			t.Errorf("%s = %q, want empty fields to send no header", name, header.Get(name))
+		}
+	}
+
+	skipped := secureHeaders(&SecureConfig{XFrameOptions: "DENY", SkipFunc: SkipPaths("/")}, nil)
+	if skipped.Get("X-Frame-Options") != "" {
+		t.Error("SkipFunc did not skip the headers")
+	}
+}
+
+func TestCSP(t *testing.T) {
+	tests := []struct {
+		csp  *CSP
+		want string
+	}{
+		{NewCSP(), ""},
+		{NewCSP().DefaultSrc(CSPSelf), "default-src 'self'"},
+		{
+			NewCSP().
+				DefaultSrc(CSPSelf).
+				ScriptSrc(CSPSelf, CSPStrictDynamic).
+				ImgSrc(CSPSelf, "data:").
+				DefaultSrc("https://cdn.example.com"). // appends, keeping the first position
+				ObjectSrc(CSPNone).
+				FrameAncestors(CSPNone).
+				UpgradeInsecureRequests().
+				ReportURI("/csp-report"),
+			"default-src 'self' https://cdn.example.com; script-src 'self' 'strict-dynamic'; img-src 'self' data:; " +
+				"object-src 'none'; frame-ancestors 'none'; upgrade-insecure-requests; report-uri /csp-report",
+		},
+		{
+			NewCSP().StyleSrc(CSPSelf, CSPUnsafeInline).FontSrc("https://fonts.gstatic.com").ConnectSrc(CSPSelf, "wss:").
+				BaseURI(CSPSelf).FormAction(CSPSelf).Add("worker-src", "blob:").ScriptSrc(CSPUnsafeEval),
+			"style-src 'self' 'unsafe-inline'; font-src https://fonts.gstatic.com; connect-src 'self' wss:; " +
+				"base-uri 'self'; form-action 'self'; worker-src blob:; script-src 'unsafe-eval'",
+		},
+	}
+	for _, tt := range tests {
+		if got := tt.csp.String(); got != tt.want {
+			t.Errorf("CSP = %q\nwant  %q", got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package middleware
+
+import (
+	"strings"
+
+	"github.com/gofuckbiz/poltergeist"
+)
+
+// =============================================================================
+// SKIPPER - Conditional middleware execution
+// =============================================================================
+
+// Skipper reports whether a middleware should let a request through
+// untouched. Every config's SkipFunc is a Skipper.
+type Skipper func(c *poltergeist.Context) bool
+
+// SkipPaths returns a Skipper matching request paths exactly, or by prefix
+// for patterns ending in "*" ("/internal/*")
+//
+//	app.Use(middleware.CompressWithConfig(&middleware.CompressConfig{
+//	    SkipFunc: middleware.SkipPaths("/health", "/metrics"),
+//	}))
+func SkipPaths(paths ...string) Skipper {
+	exact := make(map[string]bool, len(paths))
+	var prefixes []string
+	for _, p := range paths {
+		if prefix, ok := strings.CutSuffix(p, "*"); ok {
+			prefixes = append(prefixes, prefix)
+		} else {
+			exact[p] = true
+		}
+	}
+	return func(c *poltergeist.Context) bool {
+		path := c.Path()
+		if exact[path] {
+			return true
+		}
+		for _, prefix := range prefixes {
+			if strings.HasPrefix(path, prefix) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// Unless runs middleware for every request except those to paths (as
+// matched by SkipPaths), for middleware without a SkipFunc of its own or
+// to exclude several at once
+//
+//	app.Use(middleware.Unless(middleware.Logger(), "/health", "/metrics"))
+func Unless(middleware poltergeist.MiddlewareFunc, paths ...string) poltergeist.MiddlewareFunc {
+	return UnlessFunc(SkipPaths(paths...), middleware)
+}
+
+// UnlessFunc runs middleware for every request skip doesn't match; the
+// inverse of If
+func UnlessFunc(skip Skipper, middleware poltergeist.MiddlewareFunc) poltergeist.MiddlewareFunc {
+	return func(next poltergeist.HandlerFunc) poltergeist.HandlerFunc {
+		wrapped := middleware(next)
+		return func(c *poltergeist.Context) error {
+			if skip(c) {
+				return next(c)
+			}
+			return wrapped(c)
+		}
+	}
+}
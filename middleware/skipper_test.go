@@ -0,0 +1,95 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofuckbiz/poltergeist"
+)
+
+// =============================================================================
+// SKIPPER TESTS
+// =============================================================================
+
+func TestSkipPaths(t *testing.T) {
+	skip := SkipPaths("/health", "/internal/*", "/static*")
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{"/health", true},
+		{"/health/deep", false},
+		{"/healthz", false},
+		{"/internal/metrics", true},
+		{"/internal/", true},
+		{"/internal", false},
+		{"/static", true},
+		{"/static-assets/app.js", true},
+		{"/users", false},
+		{"/", false},
+	}
+	for _, tt := range tests {
+		c := poltergeist.NewContext(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, tt.path+"?q=1", nil))
+		if got := skip(c); got != tt.want {
+			t.Errorf("SkipPaths(%s) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+
+	c := poltergeist.NewContext(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/health", nil))
+	if SkipPaths()(c) {
+		t.Error("SkipPaths() with no paths skipped a request")
+	}
+}
+
+// markHeader returns a middleware setting X-Ran: name
+func markHeader(name string) poltergeist.MiddlewareFunc {
+	return func(next poltergeist.HandlerFunc) poltergeist.HandlerFunc {
+		return func(c *poltergeist.Context) error {
+			c.Writer.Header().Add("X-Ran", name)
+			return next(c)
+		}
+	}
+}
+
+func TestUnless(t *testing.T) {
+	app := poltergeist.New()
+	app.Use(
+		Unless(markHeader("unless"), "/health", "/internal/*"),
+		UnlessFunc(func(c *poltergeist.Context) bool { return c.Header("X-Skip") != "" }, markHeader("func")),
+	)
+	handler := func(c *poltergeist.Context) error { return c.NoContent() }
+	app.GET("/health", handler)
+	app.GET("/internal/stats", handler)
+	app.GET("/users", handler)
+
+	tests := []struct {
+		path   string
+		header bool
+		want   []string
+	}{
+		{"/users", false, []string{"unless", "func"}},
+		{"/health", false, []string{"func"}},
+		{"/internal/stats", false, []string{"func"}},
+		{"/users", true, []string{"unless"}},
+		{"/health", true, nil},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+		if tt.header {
+			req.Header.Set("X-Skip", "1")
+		}
+		w := httptest.NewRecorder()
+		app.ServeHTTP(w, req)
+		got := w.Header().Values("X-Ran")
+		if w.Code != http.StatusNoContent || len(got) != len(tt.want) {
+			t.Errorf("%s (X-Skip %v) ran %v with %d, want %v", tt.path, tt.header, got, w.Code, tt.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("%s (X-Skip %v) ran %v, want %v", tt.path, tt.header, got, tt.want)
+			}
+		}
+	}
+}
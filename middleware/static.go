@@ -0,0 +1,264 @@
+package middleware
+
+import (
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofuckbiz/poltergeist"
+)
+
+// =============================================================================
+// STATIC CACHE - Cache-Control/Expires rules for static assets
+// =============================================================================
+
+// CacheRule sets the caching of static paths it matches. A rule matches
+// when every criterion it sets does.
+type CacheRule struct {
+	// File extensions, e.g. ".css"; "" matches extensionless paths such as
+	// SPA pages and directories
+	Extensions []string
+	// URL path prefix, e.g. "/assets/"
+	Prefix string
+	// Cache lifetime; also sets Expires
+	MaxAge time.Duration
+	// Add immutable: browsers skip revalidation until MaxAge passes
+	Immutable bool
+	// Allow browser caching only, not shared caches
+	Private bool
+	// Revalidate on every use (Cache-Control: no-cache), ignoring MaxAge
+	NoCache bool
+}
+
+// matches reports whether the rule applies to urlPath
+func (r *CacheRule) matches(urlPath string) bool {
+	if r.Prefix != "" && !strings.HasPrefix(urlPath, r.Prefix) {
+		return false
+	}
+	if len(r.Extensions) == 0 {
+		return true
+	}
+	ext := strings.ToLower(path.Ext(urlPath))
+	for _, candidate := range r.Extensions {
+		if strings.ToLower(candidate) == ext {
+			return true
+		}
+	}
+	return false
+}
+
+// cacheControl returns the rule's Cache-Control value
+func (r *CacheRule) cacheControl() string {
+	if r.NoCache {
+		return "no-cache"
+	}
+	value := "public"
+	if r.Private {
+		value = "private"
+	}
+	value += ", max-age=" + strconv.Itoa(int(r.MaxAge.Seconds()))
+	if r.Immutable {
+		value += ", immutable"
+	}
+	return value
+}
+
+// StaticCacheConfig holds static cache header configuration
+type StaticCacheConfig struct {
+	// Rules tried in order; the first match wins (default: pages no-cache,
+	// CSS/JS 1 hour, images, fonts and media 7 days)
+	Rules []CacheRule
+	// Rule for fingerprinted assets, checked before Rules (default: 1 year,
+	// immutable)
+	Fingerprinted *CacheRule
+	// Detects fingerprinted file names (default: IsFingerprinted)
+	IsFingerprinted func(urlPath string) bool
+	// Skip function
+	SkipFunc Skipper
+}
+
+// DefaultStaticCacheConfig returns default static cache header configuration
+func DefaultStaticCacheConfig() *StaticCacheConfig {
+	return &StaticCacheConfig{
+		Rules: []CacheRule{
+			{Extensions: []string{"", ".html", ".htm"}, NoCache: true},
+			{Extensions: []string{".css", ".js", ".mjs", ".map", ".json", ".webmanifest"}, MaxAge: time.Hour},
+			{
+				Extensions: []string{
+					".png", ".jpg", ".jpeg", ".gif", ".webp", ".avif", ".svg", ".ico",
+					".woff", ".woff2", ".ttf", ".otf", ".eot",
+					".mp4", ".webm", ".mp3", ".ogg", ".wav",
+				},
+				MaxAge: 7 * 24 * time.Hour,
+			},
+		},
+		Fingerprinted:   &CacheRule{MaxAge: 365 * 24 * time.Hour, Immutable: true},
+		IsFingerprinted: IsFingerprinted,
+	}
+}
+
+// StaticCache returns a middleware that sets Cache-Control and Expires on
+// successful static file responses by extension or path rule. Fingerprinted
+// assets (app.3f9a2b1c.js, index-BxYz12ab.css) are cached for a year as
+// immutable. Headers a handler sets itself are kept, so leave
+// StaticConfig.MaxAge unset.
+//
+//	for _, route := range app.StaticFS("/", dist, &poltergeist.StaticConfig{SPA: true}) {
+//	    route.Use(middleware.StaticCache())
+//	}
+func StaticCache() poltergeist.MiddlewareFunc {
+	return StaticCacheWithConfig(nil)
+}
+
+// StaticCacheWithConfig returns a static cache header middleware with
+// custom config
+//
+//	middleware.StaticCacheWithConfig(&middleware.StaticCacheConfig{
+//	    Rules: []middleware.CacheRule{
+//	        {Prefix: "/downloads/", MaxAge: 10 * time.Minute, Private: true},
+//	        {Extensions: []string{".png", ".svg"}, MaxAge: 30 * 24 * time.Hour},
+//	    },
+//	})
+func StaticCacheWithConfig(config *StaticCacheConfig) poltergeist.MiddlewareFunc {
+	cfg := getStaticCacheConfig(config)
+
+	return func(next poltergeist.HandlerFunc) poltergeist.HandlerFunc {
+		return func(c *poltergeist.Context) error {
+			// Skip if configured
+			if cfg.SkipFunc != nil && cfg.SkipFunc(c) {
+				return next(c)
+			}
+			method := c.Request.Method
+			if method != http.MethodGet && method != http.MethodHead {
+				return next(c)
+			}
+			rule := cfg.ruleFor(c.Request.URL.Path)
+			if rule == nil {
+				return next(c)
+			}
+
+			original := c.Writer
+			c.Writer = &staticCacheWriter{ResponseWriter: original, rule: rule}
+			err := next(c)
+			c.Writer = original
+			return err
+		}
+	}
+}
+
+// getStaticCacheConfig fills unset fields with defaults
+func getStaticCacheConfig(config *StaticCacheConfig) *StaticCacheConfig {
+	defaults := DefaultStaticCacheConfig()
+	if config == nil {
+		return defaults
+	}
+	cfg := *config
+	if cfg.Rules == nil {
+		cfg.Rules = defaults.Rules
+	}
+	if cfg.Fingerprinted == nil {
+		cfg.Fingerprinted = defaults.Fingerprinted
+	}
+	if cfg.IsFingerprinted == nil {
+		cfg.IsFingerprinted = defaults.IsFingerprinted
+	}
+	return &cfg
+}
+
+// ruleFor returns the rule for urlPath, or nil to leave it alone
+func (cfg *StaticCacheConfig) ruleFor(urlPath string) *CacheRule {
+	if cfg.IsFingerprinted(urlPath) {
+		return cfg.Fingerprinted
+	}
+	for i := range cfg.Rules {
+		if cfg.Rules[i].matches(urlPath) {
+			return &cfg.Rules[i]
+		}
+	}
+	return nil
+}
+
+// IsFingerprinted reports whether a file name carries a content hash
+// between its name and extension, as bundlers emit: a segment after the
+// last "." or "-" of at least 8 letters, digits or underscores, mixing
+// letters and digits (app.3f9a2b1c.js, chunk-BxYz12ab.css). Version numbers
+// and dates don't qualify.
+func IsFingerprinted(urlPath string) bool {
+	base := path.Base(urlPath)
+	ext := path.Ext(base)
+	if ext == "" {
+		return false
+	}
+	stem := strings.TrimSuffix(base, ext)
+	i := strings.LastIndexAny(stem, ".-")
+	if i <= 0 {
+		return false
+	}
+	hash := stem[i+1:]
+	if len(hash) < 8 {
+		return false
+	}
+	var letters, digits bool
+	for _, ch := range hash {
+		switch {
+		case ch >= '0' && ch <= '9':
+			digits = true
+		case ch >= 'a' && ch <= 'z', ch >= 'A' && ch <= 'Z':
+			letters = true
+		case ch == '_':
+		default:
+			return false
+		}
+	}
+	return letters && digits
+}
+
+// staticCacheWriter adds the rule's headers when a successful response starts
+type staticCacheWriter struct {
+	http.ResponseWriter
+	rule        *CacheRule
+	wroteHeader bool
+}
+
+func (w *staticCacheWriter) WriteHeader(code int) {
+	if !w.wroteHeader && code >= http.StatusOK {
+		w.wroteHeader = true
+		if code == http.StatusOK || code == http.StatusPartialContent || code == http.StatusNotModified {
+			w.setHeaders()
+		}
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *staticCacheWriter) Write(p []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	return w.ResponseWriter.Write(p)
+}
+
+// setHeaders applies the rule unless the handler chose its own caching
+func (w *staticCacheWriter) setHeaders() {
+	header := w.Header()
+	if header.Get(poltergeist.HeaderCacheControl) != "" {
+		return
+	}
+	header.Set(poltergeist.HeaderCacheControl, w.rule.cacheControl())
+	if !w.rule.NoCache {
+		header.Set("Expires", time.Now().Add(w.rule.MaxAge).UTC().Format(http.TimeFormat))
+	}
+}
+
+// Flush passes through to the underlying writer
+func (w *staticCacheWriter) Flush() {
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Unwrap exposes the underlying writer to http.ResponseController
+func (w *staticCacheWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
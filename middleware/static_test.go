@@ -0,0 +1,172 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/gofuckbiz/poltergeist"
+)
+
+// =============================================================================
+// STATIC CACHE TESTS
+// =============================================================================
+
+// staticFiles is a built SPA with plain and fingerprinted assets
+var staticFiles = fstest.MapFS{
+	"index.html":              {Data: []byte("<html></html>")},
+	"app.css":                 {Data: []byte("body{}")},
+	"logo.png":                {Data: []byte("png")},
+	"app.3f9a2b1c.js":         {Data: []byte("js")},
+	"downloads/report.pdf":    {Data: []byte("pdf")},
+	"downloads/chunk-v1.2.js": {Data: []byte("js")},
+}
+
+// staticRequest serves method urlPath from staticFiles through the config
+func staticRequest(config *StaticCacheConfig, method, urlPath string) *httptest.ResponseRecorder {
+	app := poltergeist.New()
+	for _, route := range app.StaticFS("/", staticFiles, &poltergeist.StaticConfig{SPA: true}) {
+		route.Use(StaticCacheWithConfig(config))
+	}
+	w := httptest.NewRecorder()
+	app.ServeHTTP(w, httptest.NewRequest(method, urlPath, nil))
+	return w
+}
+
+func TestStaticCache_Defaults(t *testing.T) {
+	tests := []struct {
+		path    string
+		want    string
+		expires bool
+	}{
+		{"/", "no-cache", false},
+		{"/index.html", "no-cache", false},
+		{"/settings/profile", "no-cache", false}, // SPA fallback
+		{"/app.css", "public, max-age=3600", true},
+		{"/logo.png", "public, max-age=604800", true},
+		{"/app.3f9a2b1c.js", "public, max-age=31536000, immutable", true},
+		{"/downloads/report.pdf", "", false},
+	}
+	for _, tt := range tests {
+		w := staticRequest(nil, http.MethodGet, tt.path)
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s: status = %d, want 200", tt.path, w.Code)
+		}
+		if got := w.Header().Get("Cache-Control"); got != tt.want {
+			t.Errorf("%s: Cache-Control = %q, want %q", tt.path, got, tt.want)
+		}
+		if got := w.Header().Get("Expires") != ""; got != tt.expires {
+			t.Errorf("%s: Expires set = %v, want %v", tt.path, got, tt.expires)
+		}
+	}
+
+	w := staticRequest(nil, http.MethodGet, "/logo.png")
+	expires, err := http.ParseTime(w.Header().Get("Expires"))
+	if err != nil {
+		t.Fatalf("Expires: %v", err)
+	}
+	if until := time.Until(expires); until < 7*24*time.Hour-time.Minute || until > 7*24*time.Hour+time.Minute {
+		t.Errorf("Expires in %v, want about 7 days", until)
+	}
+
+	if got := staticRequest(nil, http.MethodHead, "/app.css").Header().Get("Cache-Control"); got != "public, max-age=3600" {
+		t.Errorf("HEAD Cache-Control = %q", got)
+	}
+}
+
+func TestStaticCache_CustomRules(t *testing.T) {
+	config := &StaticCacheConfig{
+		Rules: []CacheRule{
+			{Prefix: "/downloads/", MaxAge: 10 * time.Minute, Private: true},
+			{Extensions: []string{".PNG"}, MaxAge: time.Hour},
+		},
+	}
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"/downloads/report.pdf", "private, max-age=600"},
+		{"/downloads/chunk-v1.2.js", "private, max-age=600"}, // not fingerprinted
+		{"/logo.png", "public, max-age=3600"},                // extensions ignore case
+		{"/app.3f9a2b1c.js", "public, max-age=31536000, immutable"},
+		{"/app.css", ""}, // custom rules replace the defaults
+	}
+	for _, tt := range tests {
+		if got := staticRequest(config, http.MethodGet, tt.path).Header().Get("Cache-Control"); got != tt.want {
+			t.Errorf("%s: Cache-Control = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+
+	never := &StaticCacheConfig{IsFingerprinted: func(string) bool { return false }}
+	if got := staticRequest(never, http.MethodGet, "/app.3f9a2b1c.js").Header().Get("Cache-Control"); got != "public, max-age=3600" {
+		t.Errorf("custom IsFingerprinted: Cache-Control = %q", got)
+	}
+
+	skip := &StaticCacheConfig{SkipFunc: SkipPaths("/app.css")}
+	if got := staticRequest(skip, http.MethodGet, "/app.css").Header().Get("Cache-Control"); got != "" {
+		t.Errorf("skipped: Cache-Control = %q", got)
+	}
+}
+
+func TestStaticCache_HandlerResponses(t *testing.T) {
+	app := poltergeist.New()
+	app.Use(StaticCache())
+	app.GET("/own.css", func(c *poltergeist.Context) error {
+		c.SetHeader("Cache-Control", "no-store")
+		return c.String(http.StatusOK, "body{}")
+	})
+	app.GET("/missing.css", func(c *poltergeist.Context) error {
+		return c.String(http.StatusNotFound, "missing")
+	})
+	app.POST("/upload.css", func(c *poltergeist.Context) error {
+		return c.String(http.StatusOK, "ok")
+	})
+
+	tests := []struct {
+		method, path string
+		want         string
+	}{
+		{http.MethodGet, "/own.css", "no-store"},
+		{http.MethodGet, "/missing.css", ""},
+		{http.MethodPost, "/upload.css", ""},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		app.ServeHTTP(w, httptest.NewRequest(tt.method, tt.path, nil))
+		if got := w.Header().Get("Cache-Control"); got != tt.want {
+			t.Errorf("%s %s: Cache-Control = %q, want %q", tt.method, tt.path, got, tt.want)
+		}
+		if tt.want == "" && w.Header().Get("Expires") != "" {
+			t.Errorf("%s %s: Expires set", tt.method, tt.path)
+		}
+	}
+}
+
+func TestIsFingerprinted(t *testing.T) {
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{"/assets/app.3f9a2b1c.js", true},
+		{"/assets/index-BxYz12ab.css", true},
+		{"/assets/vendor.a1b2c3d4e5f6.chunk.js", false}, // hash is not the last segment
+		{"/img/logo_2x.12345678abc.png", true},
+		{"/assets/app.js", false},
+		{"/assets/jquery-3.7.1.js", false},     // version
+		{"/assets/report-20240115.pdf", false}, // date: digits only
+		{"/assets/app.abcdefgh.js", false},     // letters only
+		{"/assets/app.3f9a2b1.js", false},      // too short
+		{"/assets/app.3f9a-2b1c.js", false},    // "-" splits the hash
+		{"/assets/3f9a2b1c5d.js", false},       // no name before the hash
+		{"/assets/app.3f9a2b1c", false},        // no extension
+		{"/assets/app.3f9a2b1c%20.js", false},  // stray characters
+		{"/assets/chunk-Ab_12cD34.js", true},
+	}
+	for _, tt := range tests {
+		if got := IsFingerprinted(tt.path); got != tt.want {
+			t.Errorf("IsFingerprinted(%q) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+}
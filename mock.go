@@ -0,0 +1,244 @@
+package poltergeist
+
+import (
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// =============================================================================
+// MOCK MODE - Serve example payloads from route declarations
+// =============================================================================
+
+// mockMaxDepth bounds example generation for recursive types
+const mockMaxDepth = 5
+
+// mockTime is the fixed timestamp used in generated examples
+var mockTime = time.Date(2024, time.January, 1, 12, 0, 0, 0, time.UTC)
+
+// RunMock starts the server in mock mode: routes with a declared Response(),
+// Example() or ResponseStatus() body answer with a generated example payload
+// without invoking their handlers, so clients can be built against the
+// contract before the backend exists. Middleware still runs, and routes
+// without declarations (health checks, docs) are served normally.
+//
+// Clients can pick a documented status with the "Prefer: code=404" header.
+func (s *Server) RunMock(addr ...string) error {
+	s.router.mock = true
+	return s.Run(addr...)
+}
+
+// hasMockResponse reports whether a route declares a response to mock
+func (r *Route) hasMockResponse() bool {
+	return r.ResponseBody != nil || r.ResponseBodyExample != nil || len(r.RouteResponses) > 0
+}
+
+// mockHandler returns a handler serving the route's declared example response
+func mockHandler(route *Route) HandlerFunc {
+	return func(c *Context) error {
+		status, body := route.mockResponse(preferredStatus(c.Header("Prefer")))
+		c.SetHeader("X-Poltergeist-Mock", "true")
+		if body == nil {
+			c.Writer.WriteHeader(status)
+			c.statusCode = status
+			c.written = true
+			return nil
+		}
+		return c.JSON(status, body)
+	}
+}
+
+// preferredStatus parses "Prefer: code=<status>" (0 if absent)
+func preferredStatus(prefer string) int {
+	for _, part := range strings.Split(prefer, ",") {
+		if value, ok := strings.CutPrefix(strings.TrimSpace(part), "code="); ok {
+			if code, err := strconv.Atoi(value); err == nil {
+				return code
+			}
+		}
+	}
+	return 0
+}
+
+// mockResponse picks the status and example body to serve
+func (r *Route) mockResponse(preferred int) (int, any) {
+	if preferred != 0 {
+		for _, resp := range r.RouteResponses {
+			if resp.Status == preferred {
+				return resp.Status, mockBody(resp.Body, nil)
+			}
+		}
+	}
+
+	if r.ResponseBody != nil || r.ResponseBodyExample != nil {
+		return http.StatusOK, mockBody(r.ResponseBody, r.ResponseBodyExample)
+	}
+	for _, resp := range r.RouteResponses {
+		if resp.Status >= 200 && resp.Status < 300 {
+			return resp.Status, mockBody(resp.Body, r.ResponseBodyExample)
+		}
+	}
+	resp := r.RouteResponses[0]
+	return resp.Status, mockBody(resp.Body, nil)
+}
+
+// mockBody returns the declared example, or one generated from the body type
+func mockBody(body, example any) any {
+	if example != nil {
+		return example
+	}
+	if body == nil {
+		return nil
+	}
+	return MockValue(reflect.TypeOf(body))
+}
+
+// MockValue generates an example value of type t, honoring the example, enum
+// and format struct tags used by the docs package
+func MockValue(t reflect.Type) any {
+	return mockValue(t, reflect.StructTag(""), 0).Interface()
+}
+
+// mockValue builds an example reflect.Value for a type and field tag
+func mockValue(t reflect.Type, tag reflect.StructTag, depth int) reflect.Value {
+	v := reflect.New(t).Elem()
+	if depth > mockMaxDepth {
+		return v
+	}
+
+	if t == reflect.TypeOf(time.Time{}) {
+		v.Set(reflect.ValueOf(mockTime))
+		return v
+	}
+	if values := enumValues(t); len(values) > 0 {
+		if first := reflect.ValueOf(values[0]); first.Type().ConvertibleTo(t) {
+			v.Set(first.Convert(t))
+			return v
+		}
+	}
+	if setFromTag(v, tag) {
+		return v
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		v.Set(mockValue(t.Elem(), tag, depth+1).Addr())
+	case reflect.String:
+		v.SetString(mockString(tag.Get("format")))
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if t != reflect.TypeOf(time.Duration(0)) {
+			v.SetInt(1)
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		v.SetUint(1)
+	case reflect.Float32, reflect.Float64:
+		v.SetFloat(1.5)
+	case reflect.Bool:
+		v.SetBool(true)
+	case reflect.Slice:
+		if t.Elem().Kind() == reflect.Uint8 {
+			v.SetBytes([]byte("example"))
+			break
+		}
+		v.Set(reflect.Append(v, mockValue(t.Elem(), "", depth+1)))
+	case reflect.Array:
+		if v.Len() > 0 {
+			v.Index(0).Set(mockValue(t.Elem(), "", depth+1))
+		}
+	case reflect.Map:
+		v.Set(reflect.MakeMap(t))
+		key := mockValue(t.Key(), "", depth+1)
+		if t.Key().Kind() == reflect.String {
+			key = reflect.ValueOf("key").Convert(t.Key())
+		}
+		v.SetMapIndex(key, mockValue(t.Elem(), "", depth+1))
+	case reflect.Struct:
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if !field.IsExported() || field.Tag.Get("json") == "-" {
+				continue
+			}
+			v.Field(i).Set(mockValue(field.Type, field.Tag, depth+1))
+		}
+	}
+	return v
+}
+
+// enumValues returns the values of a type implementing Enum() []any
+func enumValues(t reflect.Type) []any {
+	enumer, ok := reflect.Zero(t).Interface().(interface{ Enum() []any })
+	if !ok || t.Kind() == reflect.Ptr || t.Kind() == reflect.Interface {
+		return nil
+	}
+	return enumer.Enum()
+}
+
+// setFromTag applies the example, default or first enum tag value to a scalar
+func setFromTag(v reflect.Value, tag reflect.StructTag) bool {
+	value, ok := tag.Lookup("example")
+	if !ok {
+		value, ok = tag.Lookup("default")
+	}
+	if !ok {
+		if enum := tag.Get("enum"); enum != "" {
+			value, _, _ = strings.Cut(enum, ",")
+			value, ok = strings.TrimSpace(value), true
+		}
+	}
+	if !ok {
+		return false
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(value)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return false
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			return false
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return false
+		}
+		v.SetFloat(f)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return false
+		}
+		v.SetBool(b)
+	default:
+		return false
+	}
+	return true
+}
+
+// mockString returns an example string for a format
+func mockString(format string) string {
+	switch format {
+	case "email":
+		return "user@example.com"
+	case "uuid":
+		return "3fa85f64-5717-4562-b3fc-2c963f66afa6"
+	case "date-time":
+		return mockTime.Format(time.RFC3339)
+	case "date":
+		return mockTime.Format("2006-01-02")
+	case "uri", "url":
+		return "https://example.com"
+	case "ipv4":
+		return "192.0.2.1"
+	}
+	return "string"
+}
@@ -0,0 +1,67 @@
+package poltergeist
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+)
+
+// =============================================================================
+// MOCK MODE TESTS
+// =============================================================================
+
+type mockUser struct {
+	ID     int       `json:"id" example:"42"`
+	Email  string    `json:"email" format:"email"`
+	Role   string    `json:"role" enum:"admin,member"`
+	Tags   []string  `json:"tags"`
+	Parent *mockUser `json:"parent,omitempty"`
+}
+
+func TestRouter_MockMode(t *testing.T) {
+	router := NewRouter()
+	router.mock = true
+
+	called := false
+	router.GET("/users/:id", func(c *Context) error {
+		called = true
+		return nil
+	}).Response(mockUser{}).ResponseStatus(404, H{}, "not found")
+
+	router.GET("/health", func(c *Context) error {
+		return c.String(200, "ok")
+	})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/users/1", nil))
+
+	if called {
+		t.Error("handler was invoked in mock mode")
+	}
+	if w.Code != 200 {
+		t.Fatalf("Status = %d, want 200", w.Code)
+	}
+	var user mockUser
+	if err := json.Unmarshal(w.Body.Bytes(), &user); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if user.ID != 42 || user.Email != "user@example.com" || user.Role != "admin" || len(user.Tags) != 1 {
+		t.Errorf("mock body = %+v", user)
+	}
+
+	// Prefer header selects a documented status
+	req := httptest.NewRequest("GET", "/users/1", nil)
+	req.Header.Set("Prefer", "code=404")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != 404 {
+		t.Errorf("Prefer status = %d, want 404", w.Code)
+	}
+
+	// Undeclared routes run their handlers
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/health", nil))
+	if w.Body.String() != "ok" {
+		t.Errorf("health body = %q, want ok", w.Body.String())
+	}
+}
@@ -0,0 +1,86 @@
+package poltergeist
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// =============================================================================
+// MOUNT - Compose net/http handlers and sub-applications under a prefix
+// =============================================================================
+
+// mountParam is the wildcard capturing the path below a mount prefix
+const mountParam = "path"
+
+// WrapHandler adapts an http.Handler to a HandlerFunc
+//
+//	app.GET("/debug/pprof/*name", poltergeist.WrapHandler(http.DefaultServeMux))
+func WrapHandler(handler http.Handler) HandlerFunc {
+	return func(c *Context) error {
+		handler.ServeHTTP(c.Writer, c.Request)
+		return nil
+	}
+}
+
+// Mount serves every method under prefix with handler, which sees request
+// paths relative to the prefix (as with http.StripPrefix). Global and given
+// middleware run first. Another *Server can be mounted as a sub-application:
+//
+//	app.Mount("/admin", adminApp, auth.RequireRole("admin"))
+//	app.Mount("/legacy", legacyMux)
+//
+// Handlers that route on the full path, such as net/http/pprof, should be
+// registered with WrapHandler instead. Mounted routes are hidden from docs.
+func (r *Router) Mount(prefix string, handler http.Handler, middlewares ...MiddlewareFunc) []*Route {
+	return mount(r.addRoute, prefix, handler, middlewares)
+}
+
+// Mount serves handler under the group prefix (see Router.Mount)
+func (g *RouteGroup) Mount(prefix string, handler http.Handler, middlewares ...MiddlewareFunc) []*Route {
+	return mount(g.addRoute, prefix, handler, middlewares)
+}
+
+// Mount serves handler under prefix (see Router.Mount)
+func (s *Server) Mount(prefix string, handler http.Handler, middlewares ...MiddlewareFunc) []*Route {
+	return s.router.Mount(prefix, handler, middlewares...)
+}
+
+// ServeHTTP makes the server an http.Handler, so it can be mounted in
+// another app or used with httptest and third-party servers
+func (s *Server) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	s.router.ServeHTTP(w, req)
+}
+
+// mount registers a stripped handler for all methods through add
+func mount(add func(method, path string, handler HandlerFunc, middlewares ...MiddlewareFunc) *Route,
+	prefix string, handler http.Handler, middlewares []MiddlewareFunc) []*Route {
+	pattern := strings.TrimSuffix(prefix, "/") + "/*" + mountParam
+	serve := func(c *Context) error {
+		handler.ServeHTTP(c.Writer, stripMountPrefix(c.Request, c.Param(mountParam)))
+		return nil
+	}
+
+	routes := addRoutes(add, AllHTTPMethods, pattern, serve, middlewares)
+	for _, route := range routes {
+		route.Hidden()
+	}
+	return routes
+}
+
+// stripMountPrefix returns a shallow copy of req whose path is rest, keeping
+// a trailing slash of the original path
+func stripMountPrefix(req *http.Request, rest string) *http.Request {
+	p := "/" + rest
+	if rest != "" && strings.HasSuffix(req.URL.Path, "/") {
+		p += "/"
+	}
+
+	r2 := new(http.Request)
+	*r2 = *req
+	r2.URL = new(url.URL)
+	*r2.URL = *req.URL
+	r2.URL.Path = p
+	r2.URL.RawPath = ""
+	return r2
+}
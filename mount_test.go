@@ -0,0 +1,63 @@
+package poltergeist
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// =============================================================================
+// MOUNT TESTS
+// =============================================================================
+
+func TestServer_Mount(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("legacy " + r.URL.Path))
+	})
+
+	admin := New()
+	admin.GET("/users/:id", func(c *Context) error {
+		return c.String(200, "admin user "+c.Param("id"))
+	})
+
+	app := New()
+	app.Use(func(next HandlerFunc) HandlerFunc {
+		return func(c *Context) error {
+			c.SetHeader("X-Outer", "1")
+			return next(c)
+		}
+	})
+	app.Mount("/legacy/", mux)
+	app.Group("/internal").Mount("/admin", admin)
+
+	tests := []struct {
+		method   string
+		path     string
+		wantCode int
+		wantBody string
+	}{
+		{"GET", "/legacy", 200, "legacy /"},
+		{"POST", "/legacy/a/b/", 200, "legacy /a/b/"},
+		{"GET", "/internal/admin/users/7", 200, "admin user 7"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.path, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			app.ServeHTTP(w, httptest.NewRequest(tt.method, tt.path, nil))
+			if w.Code != tt.wantCode || w.Body.String() != tt.wantBody {
+				t.Errorf("got %d %q, want %d %q", w.Code, w.Body.String(), tt.wantCode, tt.wantBody)
+			}
+			if w.Header().Get("X-Outer") != "1" {
+				t.Error("outer middleware did not run")
+			}
+		})
+	}
+
+	// The sub-application keeps its own 404s
+	w := httptest.NewRecorder()
+	app.ServeHTTP(w, httptest.NewRequest("GET", "/internal/admin/missing", nil))
+	if w.Code != 404 {
+		t.Errorf("sub-app miss: status %d", w.Code)
+	}
+}
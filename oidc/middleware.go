@@ -0,0 +1,359 @@
+package oidc
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gofuckbiz/poltergeist"
+)
+
+// =============================================================================
+// MIDDLEWARE - Bearer tokens and browser sessions
+// =============================================================================
+
+// ContextKeyClaims holds the request's verified *Claims
+const ContextKeyClaims = "oidc_claims"
+
+// flowCookieName holds the pending login's state, nonce and PKCE verifier
+const flowCookieName = "oidc_flow"
+
+// flowTTL is how long a user has to complete a login at the provider
+const flowTTL = 10 * time.Minute
+
+// ClaimsFrom returns the claims verified for this request, or nil
+func ClaimsFrom(c *poltergeist.Context) *Claims {
+	if v, ok := c.Get(ContextKeyClaims); ok {
+		claims, _ := v.(*Claims)
+		return claims
+	}
+	return nil
+}
+
+// setClaims stores claims as the request's claims and principal
+func setClaims(c *poltergeist.Context, claims *Claims) {
+	c.Set(ContextKeyClaims, claims)
+	c.Set(poltergeist.ContextKeyPrincipal, claims)
+}
+
+// Middleware returns a middleware requiring a valid bearer token in the
+// Authorization header, issued to one of Config.Audience (the ClientID by
+// default, which accepts this client's ID tokens). Failures answer 401 with
+// a WWW-Authenticate challenge.
+func (p *Provider) Middleware() poltergeist.MiddlewareFunc {
+	return func(next poltergeist.HandlerFunc) poltergeist.HandlerFunc {
+		return func(c *poltergeist.Context) error {
+			token, ok := strings.CutPrefix(c.Header(poltergeist.HeaderAuthorization), "Bearer ")
+			if !ok || token == "" {
+				c.SetHeader("WWW-Authenticate", `Bearer realm="`+p.metadata.Issuer+`"`)
+				return poltergeist.ErrUnauthorized
+			}
+			claims, err := p.Verify(c.Context(), token)
+			if err != nil {
+				c.SetHeader("WWW-Authenticate", `Bearer error="invalid_token"`)
+				return poltergeist.ErrUnauthorized.Wrap(err)
+			}
+			setClaims(c, claims)
+			return next(c)
+		}
+	}
+}
+
+// RequireLogin returns a middleware requiring a browser session from the
+// login flow. Page requests without one are redirected to Config.LoginPath
+// and returned to afterwards; other requests get 401.
+func (p *Provider) RequireLogin() poltergeist.MiddlewareFunc {
+	return func(next poltergeist.HandlerFunc) poltergeist.HandlerFunc {
+		return func(c *poltergeist.Context) error {
+			if claims := p.session(c); claims != nil {
+				setClaims(c, claims)
+				return next(c)
+			}
+			if c.Request.Method == http.MethodGet && strings.Contains(c.Header(poltergeist.HeaderAccept), "text/html") {
+				login := p.config.LoginPath + "?return_to=" + url.QueryEscape(c.Request.URL.RequestURI())
+				return c.Redirect(http.StatusFound, login)
+			}
+			return poltergeist.ErrUnauthorized
+		}
+	}
+}
+
+// =============================================================================
+// LOGIN FLOW - Authorization code with PKCE
+// =============================================================================
+
+// routeAdder is satisfied by *poltergeist.Server, *Router and *RouteGroup
+type routeAdder interface {
+	GET(path string, handler poltergeist.HandlerFunc, middlewares ...poltergeist.MiddlewareFunc) *poltergeist.Route
+}
+
+// Routes registers the login, callback and logout handlers under prefix
+// (prefix+"/login", "/callback" and "/logout"). Register the callback URL
+// with the provider as Config.RedirectURL.
+func (p *Provider) Routes(r routeAdder, prefix string) {
+	prefix = strings.TrimSuffix(prefix, "/")
+	r.GET(prefix+"/login", p.LoginHandler()).Name("oidc.login")
+	r.GET(prefix+"/callback", p.CallbackHandler()).Name("oidc.callback")
+	r.GET(prefix+"/logout", p.LogoutHandler()).Name("oidc.logout")
+}
+
+// loginFlow is the state kept in a signed cookie during a login
+type loginFlow struct {
+	State    string `json:"s"`
+	Nonce    string `json:"n"`
+	Verifier string `json:"v"`
+	ReturnTo string `json:"r"`
+}
+
+// LoginHandler redirects to the provider's authorization endpoint. A local
+// return_to query parameter is where the user lands after the callback.
+func (p *Provider) LoginHandler() poltergeist.HandlerFunc {
+	return func(c *poltergeist.Context) error {
+		if err := p.requireBrowserFlow(); err != nil {
+			return err
+		}
+		flow := loginFlow{
+			State:    randomToken(),
+			Nonce:    randomToken(),
+			Verifier: randomToken() + randomToken(),
+			ReturnTo: localPath(c.Query("return_to"), p.config.AfterLoginURL),
+		}
+		if err := p.setCookie(c, flowCookieName, flow, flowTTL); err != nil {
+			return err
+		}
+
+		challenge := sha256.Sum256([]byte(flow.Verifier))
+		query := url.Values{
+			"response_type":         {"code"},
+			"client_id":             {p.config.ClientID},
+			"redirect_uri":          {p.config.RedirectURL},
+			"scope":                 {strings.Join(p.config.Scopes, " ")},
+			"state":                 {flow.State},
+			"nonce":                 {flow.Nonce},
+			"code_challenge":        {base64.RawURLEncoding.EncodeToString(challenge[:])},
+			"code_challenge_method": {"S256"},
+		}
+		return c.Redirect(http.StatusFound, withQuery(p.metadata.AuthorizationEndpoint, query))
+	}
+}
+
+// CallbackHandler completes the login: it checks the state, exchanges the
+// code, verifies the ID token and starts the session
+func (p *Provider) CallbackHandler() poltergeist.HandlerFunc {
+	return func(c *poltergeist.Context) error {
+		if err := p.requireBrowserFlow(); err != nil {
+			return err
+		}
+		var flow loginFlow
+		if !p.readCookie(c, flowCookieName, &flow) || flow.State == "" || c.Query("state") != flow.State {
+			return poltergeist.ErrBadRequest.WithMessage("invalid login state")
+		}
+		p.clearCookie(c, flowCookieName)
+		if reason := c.Query("error"); reason != "" {
+			return poltergeist.ErrUnauthorized.WithMessage("login failed: " + reason)
+		}
+
+		tokens, err := p.exchange(c, c.Query("code"), flow.Verifier)
+		if err != nil {
+			return poltergeist.ErrBadGateway.WithMessage("token exchange failed").Wrap(err)
+		}
+		claims, err := p.VerifyIDToken(c.Context(), tokens.IDToken, flow.Nonce)
+		if err != nil {
+			return poltergeist.ErrUnauthorized.Wrap(err)
+		}
+
+		ttl := p.config.SessionTTL
+		if ttl <= 0 {
+			ttl = time.Until(claims.ExpiresAt)
+		}
+		if err := p.setCookie(c, p.config.CookieName, claims.Raw, ttl); err != nil {
+			return err
+		}
+		return c.Redirect(http.StatusFound, flow.ReturnTo)
+	}
+}
+
+// LogoutHandler ends the session and, when the provider supports it, the
+// provider's session too
+func (p *Provider) LogoutHandler() poltergeist.HandlerFunc {
+	return func(c *poltergeist.Context) error {
+		p.clearCookie(c, p.config.CookieName)
+		if p.metadata.EndSessionEndpoint == "" {
+			return c.Redirect(http.StatusFound, p.config.AfterLoginURL)
+		}
+		query := url.Values{"client_id": {p.config.ClientID}}
+		if target, err := url.Parse(p.config.RedirectURL); err == nil && target.IsAbs() {
+			// Come back to this app's AfterLoginURL
+			after, _ := url.Parse(p.config.AfterLoginURL)
+			query.Set("post_logout_redirect_uri", target.ResolveReference(after).String())
+		}
+		return c.Redirect(http.StatusFound, withQuery(p.metadata.EndSessionEndpoint, query))
+	}
+}
+
+// session returns the claims of a valid session cookie, or nil
+func (p *Provider) session(c *poltergeist.Context) *Claims {
+	if p.config.CookieSecret == nil {
+		return nil
+	}
+	var raw map[string]any
+	if !p.readCookie(c, p.config.CookieName, &raw) {
+		return nil
+	}
+	return newClaims(raw)
+}
+
+// requireBrowserFlow reports misconfiguration of the login flow
+func (p *Provider) requireBrowserFlow() error {
+	if p.config.CookieSecret == nil || p.config.RedirectURL == "" {
+		return poltergeist.ErrInternalServerError.Wrap(errors.New("oidc: login flow needs CookieSecret and RedirectURL"))
+	}
+	return nil
+}
+
+// tokenResponse is the token endpoint's reply
+type tokenResponse struct {
+	AccessToken      string `json:"access_token"`
+	IDToken          string `json:"id_token"`
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+// exchange trades an authorization code for tokens
+func (p *Provider) exchange(c *poltergeist.Context, code, verifier string) (*tokenResponse, error) {
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {p.config.RedirectURL},
+		"code_verifier": {verifier},
+		"client_id":     {p.config.ClientID},
+	}
+	req, err := http.NewRequestWithContext(c.Context(), http.MethodPost, p.metadata.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set(poltergeist.HeaderContentType, poltergeist.ContentTypeForm)
+	req.Header.Set("Accept", "application/json")
+	if p.config.ClientSecret != "" {
+		req.SetBasicAuth(url.QueryEscape(p.config.ClientID), url.QueryEscape(p.config.ClientSecret))
+	}
+
+	resp, err := p.config.HTTPClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	var tokens tokenResponse
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&tokens); err != nil {
+		return nil, fmt.Errorf("token endpoint: %s", resp.Status)
+	}
+	if tokens.Error != "" {
+		return nil, fmt.Errorf("token endpoint: %s: %s", tokens.Error, tokens.ErrorDescription)
+	}
+	if tokens.IDToken == "" {
+		return nil, errors.New("token endpoint: no id_token in response")
+	}
+	return &tokens, nil
+}
+
+// =============================================================================
+// SIGNED COOKIES
+// =============================================================================
+
+// signedCookie is the payload of a cookie signed with Config.CookieSecret
+type signedCookie struct {
+	Value   json.RawMessage `json:"v"`
+	Expires int64           `json:"e"`
+}
+
+// setCookie stores v as JSON, signed and expiring after ttl
+func (p *Provider) setCookie(c *poltergeist.Context, name string, v any, ttl time.Duration) error {
+	value, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	expires := time.Now().Add(ttl)
+	payload, _ := json.Marshal(signedCookie{Value: value, Expires: expires.Unix()})
+	encoded := base64.RawURLEncoding.EncodeToString(payload)
+	http.SetCookie(c.Writer, &http.Cookie{
+		Name:     name,
+		Value:    encoded + "." + p.sign(name, encoded),
+		Path:     "/",
+		Expires:  expires,
+		HttpOnly: true,
+		Secure:   strings.HasPrefix(p.config.RedirectURL, "https://"),
+		SameSite: http.SameSiteLaxMode,
+	})
+	return nil
+}
+
+// readCookie decodes a signed, unexpired cookie into v
+func (p *Provider) readCookie(c *poltergeist.Context, name string, v any) bool {
+	cookie, err := c.Request.Cookie(name)
+	if err != nil {
+		return false
+	}
+	encoded, mac, ok := strings.Cut(cookie.Value, ".")
+	if !ok || !hmac.Equal([]byte(mac), []byte(p.sign(name, encoded))) {
+		return false
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return false
+	}
+	var sc signedCookie
+	if json.Unmarshal(payload, &sc) != nil || time.Now().Unix() >= sc.Expires {
+		return false
+	}
+	decoder := json.NewDecoder(strings.NewReader(string(sc.Value)))
+	decoder.UseNumber()
+	return decoder.Decode(v) == nil
+}
+
+// clearCookie deletes a cookie set by setCookie
+func (p *Provider) clearCookie(c *poltergeist.Context, name string) {
+	http.SetCookie(c.Writer, &http.Cookie{Name: name, Value: "", Path: "/", MaxAge: -1, HttpOnly: true})
+}
+
+// sign returns the cookie MAC, bound to the cookie name so values can't
+// be swapped between cookies
+func (p *Provider) sign(name, value string) string {
+	mac := hmac.New(sha256.New, p.config.CookieSecret)
+	mac.Write([]byte(name + "=" + value))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// randomToken returns 32 random bytes, base64url encoded
+func randomToken() string {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		panic("oidc: crypto/rand failed: " + err.Error())
+	}
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// localPath returns target when it is a path on this site, else fallback,
+// so return_to can't redirect off-site
+func localPath(target, fallback string) string {
+	if !strings.HasPrefix(target, "/") || strings.HasPrefix(target, "//") || strings.HasPrefix(target, "/\\") {
+		return fallback
+	}
+	return target
+}
+
+// withQuery appends query to endpoint, keeping any query it already has
+func withQuery(endpoint string, query url.Values) string {
+	if strings.Contains(endpoint, "?") {
+		return endpoint + "&" + query.Encode()
+	}
+	return endpoint + "?" + query.Encode()
+}
@@ -0,0 +1,318 @@
+// Package oidc authenticates requests with an OpenID Connect provider:
+// bearer access/ID tokens for APIs and the authorization code flow (with
+// PKCE) for browser apps. Verified claims are put on the Context.
+//
+//	provider, err := oidc.New(ctx, &oidc.Config{
+//	    Issuer:       "https://accounts.example.com",
+//	    ClientID:     os.Getenv("OIDC_CLIENT_ID"),
+//	    ClientSecret: os.Getenv("OIDC_CLIENT_SECRET"),
+//	    RedirectURL:  "https://app.example.com/auth/callback",
+//	    CookieSecret: []byte(os.Getenv("SESSION_SECRET")),
+//	})
+//	provider.Routes(app, "/auth")                    // login, callback, logout
+//	app.Group("/app", provider.RequireLogin())       // browser pages
+//	app.Group("/api", provider.Middleware())         // bearer tokens
+package oidc
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// =============================================================================
+// PROVIDER - Discovery and signing keys
+// =============================================================================
+
+// Config holds OpenID Connect configuration
+type Config struct {
+	// Issuer URL; discovery reads <Issuer>/.well-known/openid-configuration
+	Issuer string
+	// Client ID registered with the provider; ID tokens must be issued to it
+	ClientID string
+	// Client secret for the code exchange ("" for public clients using PKCE only)
+	ClientSecret string
+	// Callback URL registered with the provider (browser flow)
+	RedirectURL string
+	// Scopes requested at login (default: openid, profile, email)
+	Scopes []string
+	// Audiences accepted on bearer access tokens (default: ClientID)
+	Audience []string
+	// Key for signing login state and session cookies, at least 32 bytes
+	// (required for the browser flow)
+	CookieSecret []byte
+	// Session cookie name (default: "oidc_session")
+	CookieName string
+	// Session lifetime (default: the ID token's expiry)
+	SessionTTL time.Duration
+	// Login route RequireLogin redirects to (default: "/auth/login")
+	LoginPath string
+	// Where to go after login without a return path, and after logout (default: "/")
+	AfterLoginURL string
+	// Clock skew tolerated on exp, nbf and iat (default: 1 minute)
+	Leeway time.Duration
+	// HTTP client for discovery, keys and token exchange (default: 10s timeout)
+	HTTPClient *http.Client
+}
+
+// DefaultConfig returns default OpenID Connect configuration
+func DefaultConfig() *Config {
+	return &Config{
+		Scopes:        []string{"openid", "profile", "email"},
+		CookieName:    "oidc_session",
+		LoginPath:     "/auth/login",
+		AfterLoginURL: "/",
+		Leeway:        time.Minute,
+		HTTPClient:    &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// getConfig fills unset fields with defaults
+func getConfig(config *Config) *Config {
+	defaults := DefaultConfig()
+	cfg := *config
+	if len(cfg.Scopes) == 0 {
+		cfg.Scopes = defaults.Scopes
+	}
+	if len(cfg.Audience) == 0 {
+		cfg.Audience = []string{cfg.ClientID}
+	}
+	if cfg.CookieName == "" {
+		cfg.CookieName = defaults.CookieName
+	}
+	if cfg.LoginPath == "" {
+		cfg.LoginPath = defaults.LoginPath
+	}
+	if cfg.AfterLoginURL == "" {
+		cfg.AfterLoginURL = defaults.AfterLoginURL
+	}
+	if cfg.Leeway == 0 {
+		cfg.Leeway = defaults.Leeway
+	}
+	if cfg.HTTPClient == nil {
+		cfg.HTTPClient = defaults.HTTPClient
+	}
+	return &cfg
+}
+
+// Metadata is the provider's discovery document
+type Metadata struct {
+	Issuer                string   `json:"issuer"`
+	AuthorizationEndpoint string   `json:"authorization_endpoint"`
+	TokenEndpoint         string   `json:"token_endpoint"`
+	UserinfoEndpoint      string   `json:"userinfo_endpoint,omitempty"`
+	JWKSURI               string   `json:"jwks_uri"`
+	EndSessionEndpoint    string   `json:"end_session_endpoint,omitempty"`
+	SigningAlgorithms     []string `json:"id_token_signing_alg_values_supported,omitempty"`
+}
+
+// Provider verifies tokens from one issuer and runs its login flow
+type Provider struct {
+	config   *Config
+	metadata Metadata
+	keys     *keySet
+}
+
+// New discovers the provider's endpoints and returns a Provider. Signing
+// keys are fetched on first use and refetched when a token names an
+// unknown key, so provider key rotation needs no restart.
+func New(ctx context.Context, config *Config) (*Provider, error) {
+	if config == nil || config.Issuer == "" || config.ClientID == "" {
+		return nil, errors.New("oidc: Issuer and ClientID are required")
+	}
+	if config.CookieSecret != nil && len(config.CookieSecret) < 32 {
+		return nil, errors.New("oidc: CookieSecret must be at least 32 bytes")
+	}
+	cfg := getConfig(config)
+
+	var metadata Metadata
+	wellKnown := strings.TrimSuffix(cfg.Issuer, "/") + "/.well-known/openid-configuration"
+	if err := getJSON(ctx, cfg.HTTPClient, wellKnown, &metadata); err != nil {
+		return nil, fmt.Errorf("oidc: discovery: %w", err)
+	}
+	if metadata.Issuer != cfg.Issuer {
+		return nil, fmt.Errorf("oidc: discovery issuer %q does not match %q", metadata.Issuer, cfg.Issuer)
+	}
+	if metadata.JWKSURI == "" {
+		return nil, errors.New("oidc: discovery document has no jwks_uri")
+	}
+
+	return &Provider{
+		config:   cfg,
+		metadata: metadata,
+		keys:     &keySet{client: cfg.HTTPClient, uri: metadata.JWKSURI},
+	}, nil
+}
+
+// Metadata returns the provider's discovery document
+func (p *Provider) Metadata() Metadata {
+	return p.metadata
+}
+
+// getJSON fetches url and decodes its JSON body into v
+func getJSON(ctx context.Context, client *http.Client, url string, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s: %s", url, resp.Status)
+	}
+	return json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(v)
+}
+
+// =============================================================================
+// KEY SET - Cached JWKS
+// =============================================================================
+
+// keyRefetchInterval limits JWKS refetches triggered by unknown key IDs
+const keyRefetchInterval = time.Minute
+
+// keySet caches the provider's public keys by key ID
+type keySet struct {
+	client *http.Client
+	uri    string
+
+	mu      sync.Mutex
+	keys    map[string]crypto.PublicKey
+	fetched time.Time
+}
+
+// get returns the key for kid, fetching the set when it is unknown
+func (s *keySet) get(ctx context.Context, kid string) (crypto.PublicKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if key, ok := s.lookup(kid); ok {
+		return key, nil
+	}
+	if time.Since(s.fetched) < keyRefetchInterval {
+		return nil, fmt.Errorf("oidc: unknown signing key %q", kid)
+	}
+	if err := s.fetch(ctx); err != nil {
+		return nil, err
+	}
+	if key, ok := s.lookup(kid); ok {
+		return key, nil
+	}
+	return nil, fmt.Errorf("oidc: unknown signing key %q", kid)
+}
+
+// lookup finds kid; an empty kid matches a set holding a single key
+func (s *keySet) lookup(kid string) (crypto.PublicKey, bool) {
+	if kid == "" && len(s.keys) == 1 {
+		for _, key := range s.keys {
+			return key, true
+		}
+	}
+	key, ok := s.keys[kid]
+	return key, ok
+}
+
+// fetch replaces the cached keys with the provider's current set
+func (s *keySet) fetch(ctx context.Context) error {
+	var set struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	s.fetched = time.Now()
+	if err := getJSON(ctx, s.client, s.uri, &set); err != nil {
+		return fmt.Errorf("oidc: fetching keys: %w", err)
+	}
+	keys := make(map[string]crypto.PublicKey, len(set.Keys))
+	for _, jwk := range set.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		if key, err := jwk.publicKey(); err == nil {
+			keys[jwk.Kid] = key
+		}
+	}
+	s.keys = keys
+	return nil
+}
+
+// jsonWebKey is one entry of a JWKS document
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// publicKey decodes an RSA, EC or Ed25519 public key
+func (k *jsonWebKey) publicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeBigInt(k.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("oidc: unsupported curve %q", k.Crv)
+		}
+		x, err := decodeBigInt(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeBigInt(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	case "OKP":
+		if k.Crv != "Ed25519" {
+			return nil, fmt.Errorf("oidc: unsupported curve %q", k.Crv)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil || len(x) != ed25519.PublicKeySize {
+			return nil, errors.New("oidc: invalid Ed25519 key")
+		}
+		return ed25519.PublicKey(x), nil
+	}
+	return nil, fmt.Errorf("oidc: unsupported key type %q", k.Kty)
+}
+
+// decodeBigInt decodes a base64url big-endian integer
+func decodeBigInt(s string) (*big.Int, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil || len(data) == 0 {
+		return nil, errors.New("oidc: invalid key parameter")
+	}
+	return new(big.Int).SetBytes(data), nil
+}
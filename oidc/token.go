@@ -0,0 +1,248 @@
+package oidc
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"slices"
+	"strings"
+	"time"
+)
+
+// =============================================================================
+// TOKENS - JWT signature and claim verification
+// =============================================================================
+
+// Token verification errors
+var (
+	ErrMalformedToken = errors.New("oidc: malformed token")
+	ErrInvalidToken   = errors.New("oidc: invalid token signature")
+	ErrTokenExpired   = errors.New("oidc: token expired")
+	ErrInvalidClaims  = errors.New("oidc: invalid token claims")
+)
+
+// Claims are the verified claims of an ID or access token
+type Claims struct {
+	Issuer        string
+	Subject       string
+	Audience      []string
+	ExpiresAt     time.Time
+	IssuedAt      time.Time
+	Email         string
+	EmailVerified bool
+	Name          string
+	// Every claim as decoded from the token (numbers are json.Number)
+	Raw map[string]any
+}
+
+// String returns a string claim, or ""
+func (c *Claims) String(name string) string {
+	s, _ := c.Raw[name].(string)
+	return s
+}
+
+// Strings returns a claim holding a string or a list of strings, such as
+// groups or roles; a space-separated "scope" is split
+func (c *Claims) Strings(name string) []string {
+	switch v := c.Raw[name].(type) {
+	case string:
+		if name == "scope" {
+			return strings.Fields(v)
+		}
+		return []string{v}
+	case []any:
+		values := make([]string, 0, len(v))
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				values = append(values, s)
+			}
+		}
+		return values
+	}
+	return nil
+}
+
+// HasScope reports whether an access token was granted scope
+func (c *Claims) HasScope(scope string) bool {
+	return slices.Contains(c.Strings("scope"), scope) || slices.Contains(c.Strings("scp"), scope)
+}
+
+// Verify checks a bearer token's signature, issuer, expiry and audience
+// (one of Config.Audience), and returns its claims
+func (p *Provider) Verify(ctx context.Context, token string) (*Claims, error) {
+	claims, err := p.verify(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+	for _, aud := range p.config.Audience {
+		if slices.Contains(claims.Audience, aud) {
+			return claims, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: audience %v not accepted", ErrInvalidClaims, claims.Audience)
+}
+
+// VerifyIDToken checks an ID token issued to this client; a non-empty nonce
+// must match the token's
+func (p *Provider) VerifyIDToken(ctx context.Context, token, nonce string) (*Claims, error) {
+	claims, err := p.verify(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+	if !slices.Contains(claims.Audience, p.config.ClientID) {
+		return nil, fmt.Errorf("%w: not issued to this client", ErrInvalidClaims)
+	}
+	if azp := claims.String("azp"); len(claims.Audience) > 1 && azp != "" && azp != p.config.ClientID {
+		return nil, fmt.Errorf("%w: authorized party %q", ErrInvalidClaims, azp)
+	}
+	if nonce != "" && claims.String("nonce") != nonce {
+		return nil, fmt.Errorf("%w: nonce mismatch", ErrInvalidClaims)
+	}
+	return claims, nil
+}
+
+// verify checks the signature and time-based claims
+func (p *Provider) verify(ctx context.Context, token string) (*Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, ErrMalformedToken
+	}
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, ErrMalformedToken
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, ErrMalformedToken
+	}
+	key, err := p.keys.get(ctx, header.Kid)
+	if err != nil {
+		return nil, err
+	}
+	if err := verifySignature(header.Alg, key, []byte(parts[0]+"."+parts[1]), signature); err != nil {
+		return nil, err
+	}
+
+	var raw map[string]any
+	if err := decodeSegment(parts[1], &raw); err != nil {
+		return nil, ErrMalformedToken
+	}
+	claims := newClaims(raw)
+
+	now := time.Now()
+	leeway := p.config.Leeway
+	switch {
+	case claims.Issuer != p.metadata.Issuer:
+		return nil, fmt.Errorf("%w: issuer %q", ErrInvalidClaims, claims.Issuer)
+	case claims.ExpiresAt.IsZero():
+		return nil, fmt.Errorf("%w: no expiry", ErrInvalidClaims)
+	case now.After(claims.ExpiresAt.Add(leeway)):
+		return nil, ErrTokenExpired
+	}
+	if nbf, ok := numericDate(raw["nbf"]); ok && now.Add(leeway).Before(nbf) {
+		return nil, fmt.Errorf("%w: not valid yet", ErrInvalidClaims)
+	}
+	return claims, nil
+}
+
+// newClaims fills the standard fields from decoded claims
+func newClaims(raw map[string]any) *Claims {
+	claims := &Claims{Raw: raw}
+	claims.Issuer, _ = raw["iss"].(string)
+	claims.Subject, _ = raw["sub"].(string)
+	claims.Email, _ = raw["email"].(string)
+	claims.EmailVerified, _ = raw["email_verified"].(bool)
+	claims.Name, _ = raw["name"].(string)
+	claims.Audience = claims.Strings("aud")
+	claims.ExpiresAt, _ = numericDate(raw["exp"])
+	claims.IssuedAt, _ = numericDate(raw["iat"])
+	return claims
+}
+
+// numericDate converts a JWT NumericDate (seconds since the epoch)
+func numericDate(v any) (time.Time, bool) {
+	n, ok := v.(json.Number)
+	if !ok {
+		return time.Time{}, false
+	}
+	seconds, err := n.Float64()
+	if err != nil {
+		return time.Time{}, false
+	}
+	return time.Unix(0, int64(seconds*float64(time.Second))), true
+}
+
+// decodeSegment decodes a base64url JSON token segment
+func decodeSegment(segment string, v any) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	return decoder.Decode(v)
+}
+
+// verifySignature checks signed against signature for an asymmetric
+// algorithm; "none" and HMAC algorithms are rejected
+func verifySignature(alg string, key crypto.PublicKey, signed, signature []byte) error {
+	var hash crypto.Hash
+	switch alg {
+	case "RS256", "PS256", "ES256":
+		hash = crypto.SHA256
+	case "RS384", "PS384", "ES384":
+		hash = crypto.SHA384
+	case "RS512", "PS512", "ES512":
+		hash = crypto.SHA512
+	case "EdDSA":
+		if k, ok := key.(ed25519.PublicKey); ok && ed25519.Verify(k, signed, signature) {
+			return nil
+		}
+		return ErrInvalidToken
+	default:
+		return fmt.Errorf("%w: unsupported algorithm %q", ErrInvalidToken, alg)
+	}
+	h := hash.New()
+	h.Write(signed)
+	digest := h.Sum(nil)
+
+	switch k := key.(type) {
+	case *rsa.PublicKey:
+		var err error
+		switch alg[0] {
+		case 'R':
+			err = rsa.VerifyPKCS1v15(k, hash, digest, signature)
+		case 'P':
+			err = rsa.VerifyPSS(k, hash, digest, signature, nil)
+		default:
+			err = ErrInvalidToken
+		}
+		if err != nil {
+			return ErrInvalidToken
+		}
+		return nil
+	case *ecdsa.PublicKey:
+		size := (k.Curve.Params().BitSize + 7) / 8
+		if alg[0] != 'E' || len(signature) != 2*size {
+			return ErrInvalidToken
+		}
+		r := new(big.Int).SetBytes(signature[:size])
+		s := new(big.Int).SetBytes(signature[size:])
+		if !ecdsa.Verify(k, digest, r, s) {
+			return ErrInvalidToken
+		}
+		return nil
+	}
+	return ErrInvalidToken
+}
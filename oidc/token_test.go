@@ -0,0 +1,278 @@
+package oidc
+
+import (
+	"context"
+	"crypto"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gofuckbiz/poltergeist"
+)
+
+// =============================================================================
+// TOKEN VERIFICATION TESTS
+// =============================================================================
+
+// testIssuer serves discovery and a JWKS holding the keys set on it
+type testIssuer struct {
+	server  *httptest.Server
+	mu      sync.Mutex
+	keys    map[string]*rsa.PrivateKey
+	fetches atomic.Int32
+}
+
+func newTestIssuer(t *testing.T) *testIssuer {
+	t.Helper()
+	issuer := &testIssuer{keys: map[string]*rsa.PrivateKey{"k1": testKey(0)}}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(Metadata{
+			Issuer:                issuer.server.URL,
+			AuthorizationEndpoint: issuer.server.URL + "/authorize",
+			TokenEndpoint:         issuer.server.URL + "/token",
+			JWKSURI:               issuer.server.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		issuer.fetches.Add(1)
+		issuer.mu.Lock()
+		defer issuer.mu.Unlock()
+		var keys []jsonWebKey
+		for kid, key := range issuer.keys {
+			keys = append(keys, jsonWebKey{
+				Kty: "RSA",
+				Kid: kid,
+				Use: "sig",
+				N:   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+				E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+			})
+		}
+		json.NewEncoder(w).Encode(map[string]any{"keys": keys})
+	})
+	issuer.server = httptest.NewServer(mux)
+	t.Cleanup(issuer.server.Close)
+	return issuer
+}
+
+// provider returns a Provider for the issuer with client ID "client"
+func (i *testIssuer) provider(t *testing.T) *Provider {
+	t.Helper()
+	p, err := New(context.Background(), &Config{Issuer: i.server.URL, ClientID: "client"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+// key returns the issuer's signing key kid
+func (i *testIssuer) key(kid string) *rsa.PrivateKey {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	return i.keys[kid]
+}
+
+// claims returns valid claims for "client", with overrides applied
+func (i *testIssuer) claims(overrides map[string]any) map[string]any {
+	claims := map[string]any{
+		"iss": i.server.URL,
+		"sub": "user-1",
+		"aud": "client",
+		"exp": time.Now().Add(time.Hour).Unix(),
+		"iat": time.Now().Unix(),
+	}
+	for name, value := range overrides {
+		if value == nil {
+			delete(claims, name)
+			continue
+		}
+		claims[name] = value
+	}
+	return claims
+}
+
+var (
+	keyOnce sync.Once
+	keyPool []*rsa.PrivateKey
+)
+
+// testKey returns one of three RSA keys shared by the tests
+func testKey(i int) *rsa.PrivateKey {
+	keyOnce.Do(func() {
+		for i := 0; i < 3; i++ {
+			key, err := rsa.GenerateKey(rand.Reader, 2048)
+			if err != nil {
+				panic(err)
+			}
+			keyPool = append(keyPool, key)
+		}
+	})
+	return keyPool[i]
+}
+
+// signToken builds a JWT with alg and kid; RS256 tokens are signed with key
+func signToken(t *testing.T, alg, kid string, key *rsa.PrivateKey, claims map[string]any) string {
+	t.Helper()
+	header, _ := json.Marshal(map[string]string{"alg": alg, "kid": kid, "typ": "JWT"})
+	payload, _ := json.Marshal(claims)
+	signed := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+
+	var signature []byte
+	switch alg {
+	case "RS256":
+		digest := sha256.Sum256([]byte(signed))
+		var err error
+		signature, err = rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+		if err != nil {
+			t.Fatal(err)
+		}
+	case "HS256":
+		// The classic confusion attack: the public key as the HMAC secret
+		mac := hmac.New(sha256.New, key.PublicKey.N.Bytes())
+		mac.Write([]byte(signed))
+		signature = mac.Sum(nil)
+	}
+	return signed + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func TestProvider_Verify(t *testing.T) {
+	issuer := newTestIssuer(t)
+	p := issuer.provider(t)
+	k1 := issuer.key("k1")
+	other := testKey(1)
+	now := time.Now()
+
+	tests := []struct {
+		name  string
+		token string
+		want  error
+	}{
+		{"valid", signToken(t, "RS256", "k1", k1, issuer.claims(nil)), nil},
+		{"audience list", signToken(t, "RS256", "k1", k1, issuer.claims(map[string]any{"aud": []string{"other", "client"}})), nil},
+		{"expired within leeway", signToken(t, "RS256", "k1", k1, issuer.claims(map[string]any{"exp": now.Add(-30 * time.Second).Unix()})), nil},
+		{"alg none", signToken(t, "none", "k1", nil, issuer.claims(nil)), ErrInvalidToken},
+		{"alg HS256", signToken(t, "HS256", "k1", k1, issuer.claims(nil)), ErrInvalidToken},
+		{"bad signature", signToken(t, "RS256", "k1", other, issuer.claims(nil)), ErrInvalidToken},
+		{"wrong issuer", signToken(t, "RS256", "k1", k1, issuer.claims(map[string]any{"iss": "https://evil.example.com"})), ErrInvalidClaims},
+		{"expired", signToken(t, "RS256", "k1", k1, issuer.claims(map[string]any{"exp": now.Add(-time.Hour).Unix()})), ErrTokenExpired},
+		{"no expiry", signToken(t, "RS256", "k1", k1, issuer.claims(map[string]any{"exp": nil})), ErrInvalidClaims},
+		{"nbf in the future", signToken(t, "RS256", "k1", k1, issuer.claims(map[string]any{"nbf": now.Add(time.Hour).Unix()})), ErrInvalidClaims},
+		{"audience mismatch", signToken(t, "RS256", "k1", k1, issuer.claims(map[string]any{"aud": "someone-else"})), ErrInvalidClaims},
+		{"malformed", "not.a-token", ErrMalformedToken},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims, err := p.Verify(context.Background(), tt.token)
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("Verify = %v, want %v", err, tt.want)
+			}
+			if err == nil && claims.Subject != "user-1" {
+				t.Errorf("subject = %q", claims.Subject)
+			}
+		})
+	}
+}
+
+func TestProvider_VerifyIDToken(t *testing.T) {
+	issuer := newTestIssuer(t)
+	p := issuer.provider(t)
+	k1 := issuer.key("k1")
+
+	tests := []struct {
+		name   string
+		claims map[string]any
+		nonce  string
+		want   error
+	}{
+		{"valid", map[string]any{"nonce": "n1"}, "n1", nil},
+		{"nonce not checked", nil, "", nil},
+		{"nonce mismatch", map[string]any{"nonce": "n2"}, "n1", ErrInvalidClaims},
+		{"nonce missing", nil, "n1", ErrInvalidClaims},
+		{"other client", map[string]any{"aud": "other"}, "", ErrInvalidClaims},
+		{"azp is this client", map[string]any{"aud": []string{"client", "api"}, "azp": "client"}, "", nil},
+		{"azp mismatch", map[string]any{"aud": []string{"client", "api"}, "azp": "api"}, "", ErrInvalidClaims},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token := signToken(t, "RS256", "k1", k1, issuer.claims(tt.claims))
+			if _, err := p.VerifyIDToken(context.Background(), token, tt.nonce); !errors.Is(err, tt.want) {
+				t.Errorf("VerifyIDToken = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestProvider_KeyRotation(t *testing.T) {
+	issuer := newTestIssuer(t)
+	p := issuer.provider(t)
+	ctx := context.Background()
+
+	if _, err := p.Verify(ctx, signToken(t, "RS256", "k1", issuer.key("k1"), issuer.claims(nil))); err != nil {
+		t.Fatal(err)
+	}
+	issuer.mu.Lock()
+	issuer.keys["k2"] = testKey(2)
+	issuer.mu.Unlock()
+	rotated := signToken(t, "RS256", "k2", testKey(2), issuer.claims(nil))
+
+	// Unknown key IDs refetch the set at most once per keyRefetchInterval
+	if _, err := p.Verify(ctx, rotated); err == nil || issuer.fetches.Load() != 1 {
+		t.Fatalf("Verify right after a fetch = %v with %d fetches, want an unknown key and 1 fetch", err, issuer.fetches.Load())
+	}
+	p.keys.mu.Lock()
+	p.keys.fetched = time.Now().Add(-keyRefetchInterval)
+	p.keys.mu.Unlock()
+	if _, err := p.Verify(ctx, rotated); err != nil || issuer.fetches.Load() != 2 {
+		t.Errorf("Verify with a rotated key = %v after %d fetches, want a refetch", err, issuer.fetches.Load())
+	}
+	if _, err := p.Verify(ctx, signToken(t, "RS256", "k3", testKey(2), issuer.claims(nil))); err == nil {
+		t.Error("Verify accepted a token naming a key the provider doesn't publish")
+	}
+}
+
+func TestProvider_Middleware(t *testing.T) {
+	issuer := newTestIssuer(t)
+	p := issuer.provider(t)
+	app := poltergeist.New()
+	app.GET("/me", func(c *poltergeist.Context) error {
+		return c.String(http.StatusOK, ClaimsFrom(c).Subject)
+	}, p.Middleware())
+
+	tests := []struct {
+		name, authorization string
+		want                int
+		challenge           string
+	}{
+		{"valid", "Bearer " + signToken(t, "RS256", "k1", issuer.key("k1"), issuer.claims(nil)), http.StatusOK, ""},
+		{"missing", "", http.StatusUnauthorized, `Bearer realm="` + issuer.server.URL + `"`},
+		{"not bearer", "Basic dXNlcjpwYXNz", http.StatusUnauthorized, `Bearer realm="` + issuer.server.URL + `"`},
+		{"invalid", "Bearer " + signToken(t, "none", "k1", nil, issuer.claims(nil)), http.StatusUnauthorized, `Bearer error="invalid_token"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/me", nil)
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+			w := httptest.NewRecorder()
+			app.ServeHTTP(w, req)
+			if w.Code != tt.want || w.Header().Get("WWW-Authenticate") != tt.challenge {
+				t.Errorf("response = %d, WWW-Authenticate %q; want %d, %q", w.Code, w.Header().Get("WWW-Authenticate"), tt.want, tt.challenge)
+			}
+			if tt.want == http.StatusOK && w.Body.String() != "user-1" {
+				t.Errorf("claims subject = %q", w.Body.String())
+			}
+		})
+	}
+}
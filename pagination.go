@@ -0,0 +1,551 @@
+package poltergeist
+
+import (
+	"fmt"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// =============================================================================
+// PAGINATION - page/limit/cursor parsing
+// =============================================================================
+
+// PaginationConfig holds pagination limits
+type PaginationConfig struct {
+	DefaultLimit int // Limit when none is requested (default: 20)
+	MaxLimit     int // Upper bound for requested limits (default: 100)
+}
+
+// DefaultPaginationConfig returns default pagination limits
+func DefaultPaginationConfig() PaginationConfig {
+	return PaginationConfig{
+		DefaultLimit: DefaultPageLimit,
+		MaxLimit:     DefaultMaxPageLimit,
+	}
+}
+
+// Pagination is the page requested by a client
+type Pagination struct {
+	Page   int    // 1-based page number
+	Limit  int    // Items per page, capped at MaxLimit
+	Offset int    // (Page-1) * Limit
+	Cursor string // Opaque cursor for cursor-based pagination ("" for the first page)
+}
+
+// Pagination parses ?page=, ?limit= (or ?per_page=) and ?cursor=. Invalid
+// values fall back to the defaults and limits are capped, so handlers can
+// pass the result straight to a query.
+func (c *Context) Pagination(config ...PaginationConfig) Pagination {
+	cfg := DefaultPaginationConfig()
+	if len(config) > 0 {
+		if config[0].DefaultLimit > 0 {
+			cfg.DefaultLimit = config[0].DefaultLimit
+		}
+		if config[0].MaxLimit > 0 {
+			cfg.MaxLimit = config[0].MaxLimit
+		}
+	}
+
+	p := Pagination{
+		Page:   c.QueryIntDefault(QueryPage, 1),
+		Limit:  c.QueryIntDefault(QueryLimit, c.QueryIntDefault(QueryPerPage, cfg.DefaultLimit)),
+		Cursor: c.Query(QueryCursor),
+	}
+	if p.Page < 1 {
+		p.Page = 1
+	}
+	if p.Limit < 1 {
+		p.Limit = cfg.DefaultLimit
+	}
+	if p.Limit > cfg.MaxLimit {
+		p.Limit = cfg.MaxLimit
+	}
+	p.Offset = (p.Page - 1) * p.Limit
+	c.Set(ContextKeyPagination, p)
+	return p
+}
+
+// pagination returns the pagination parsed for this request, parsing the
+// defaults if the handler never called Pagination
+func (c *Context) pagination() Pagination {
+	if v, ok := c.Get(ContextKeyPagination); ok {
+		if p, ok := v.(Pagination); ok {
+			return p
+		}
+	}
+	return c.Pagination()
+}
+
+// =============================================================================
+// LIST RESPONSES - data + meta with Link headers
+// =============================================================================
+
+// PageMeta is the meta object of a paginated response
+type PageMeta struct {
+	Page       int    `json:"page,omitempty"`
+	Limit      int    `json:"limit"`
+	Total      *int64 `json:"total,omitempty"`
+	TotalPages int    `json:"total_pages,omitempty"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// ListResponse is the body written by Paginate and PaginateCursor
+type ListResponse struct {
+	Data any      `json:"data"`
+	Meta PageMeta `json:"meta"`
+}
+
+// Paginate sends a page of items with page/limit/total meta, an
+// X-Total-Count header and first/prev/next/last Link headers. A negative
+// total means unknown: next is then offered while the page is full.
+func (c *Context) Paginate(items any, total int64) error {
+	p := c.pagination()
+	meta := PageMeta{Page: p.Page, Limit: p.Limit}
+
+	hasNext := sliceLen(items) == p.Limit
+	if total >= 0 {
+		meta.Total = &total
+		meta.TotalPages = int((total + int64(p.Limit) - 1) / int64(p.Limit))
+		hasNext = p.Page < meta.TotalPages
+		c.SetHeader(HeaderXTotalCount, strconv.FormatInt(total, 10))
+	}
+
+	c.addPageLink("first", QueryPage, "1")
+	if p.Page > 1 {
+		c.addPageLink("prev", QueryPage, strconv.Itoa(p.Page-1))
+	}
+	if hasNext {
+		c.addPageLink("next", QueryPage, strconv.Itoa(p.Page+1))
+	}
+	if meta.TotalPages > 0 {
+		c.addPageLink("last", QueryPage, strconv.Itoa(meta.TotalPages))
+	}
+	return c.JSON(StatusOK, ListResponse{Data: emptyList(items), Meta: meta})
+}
+
+// PaginateCursor sends a page of items for cursor-based pagination with a
+// next_cursor meta field and a next Link header ("" ends the list)
+func (c *Context) PaginateCursor(items any, nextCursor string) error {
+	p := c.pagination()
+	if nextCursor != "" {
+		c.addPageLink("next", QueryCursor, nextCursor)
+	}
+	return c.JSON(StatusOK, ListResponse{Data: emptyList(items), Meta: PageMeta{Limit: p.Limit, NextCursor: nextCursor}})
+}
+
+// addPageLink adds a Link header pointing at this request with one query
+// parameter replaced
+func (c *Context) addPageLink(rel, key, value string) {
+	query := c.Request.URL.Query()
+	query.Set(key, value)
+	if key == QueryCursor {
+		query.Del(QueryPage)
+	}
+	link := url.URL{Path: c.Request.URL.Path, RawQuery: query.Encode()}
+	c.Writer.Header().Add(HeaderLink, "<"+link.String()+`>; rel="`+rel+`"`)
+}
+
+// =============================================================================
+// SORTING
+// =============================================================================
+
+// SortField is one sort key
+type SortField struct {
+	Field string
+	Desc  bool
+}
+
+// Sort parses ?sort=-created_at,name into sort keys ("-" for descending,
+// "+" or nothing for ascending). Fields outside allowed are rejected with
+// a 400 so clients cannot sort on unindexed or private columns; with no
+// allowed fields every field is accepted.
+func (c *Context) Sort(allowed ...string) ([]SortField, error) {
+	var fields []SortField
+	for _, value := range c.Request.URL.Query()[QuerySort] {
+		for _, part := range strings.Split(value, ",") {
+			part = strings.TrimSpace(part)
+			if part == "" {
+				continue
+			}
+			field := SortField{Field: strings.TrimLeft(part, "+-"), Desc: strings.HasPrefix(part, "-")}
+			if len(allowed) > 0 && !containsString(allowed, field.Field) {
+				return nil, ErrBadRequest.WithMessage(fmt.Sprintf("cannot sort by %q", field.Field)).
+					WithDetails(H{"allowed": allowed})
+			}
+			fields = append(fields, field)
+		}
+	}
+	return fields, nil
+}
+
+// =============================================================================
+// FILTERING - Filter expressions
+// =============================================================================
+
+// FilterOp is a filter comparison operator
+type FilterOp string
+
+// Filter operators
+const (
+	FilterEq       FilterOp = "eq"
+	FilterNe       FilterOp = "ne"
+	FilterGt       FilterOp = "gt"
+	FilterGe       FilterOp = "ge"
+	FilterLt       FilterOp = "lt"
+	FilterLe       FilterOp = "le"
+	FilterIn       FilterOp = "in"
+	FilterContains FilterOp = "contains"
+	FilterPrefix   FilterOp = "startswith"
+)
+
+// Filter is a parsed filter expression: either a condition (Field Op Value)
+// or a group (Logic "and"/"or" over Filters). Values are string, int64,
+// float64, bool, nil, or []any for "in".
+//
+//	status eq 'active' and (age ge 18 or role in ('admin', 'owner'))
+type Filter struct {
+	Logic   string // "and" or "or" for groups, "" for conditions
+	Filters []*Filter
+	Not     bool // negated by a leading "not"
+
+	Field string
+	Op    FilterOp
+	Value any
+}
+
+// IsGroup reports whether the filter combines other filters
+func (f *Filter) IsGroup() bool {
+	return f.Logic != ""
+}
+
+// Fields returns the fields the filter references
+func (f *Filter) Fields() []string {
+	if !f.IsGroup() {
+		return []string{f.Field}
+	}
+	var fields []string
+	for _, child := range f.Filters {
+		for _, field := range child.Fields() {
+			if !containsString(fields, field) {
+				fields = append(fields, field)
+			}
+		}
+	}
+	return fields
+}
+
+// Filter parses the ?filter= expression (nil when absent). Syntax errors and
+// fields outside allowed are rejected with a 400; with no allowed fields
+// every field is accepted.
+func (c *Context) Filter(allowed ...string) (*Filter, error) {
+	expr := strings.TrimSpace(c.Query(QueryFilter))
+	if expr == "" {
+		return nil, nil
+	}
+	f, err := ParseFilter(expr)
+	if err != nil {
+		return nil, ErrBadRequest.WithMessage(err.Error()).Wrap(err)
+	}
+	if len(allowed) > 0 {
+		for _, field := range f.Fields() {
+			if !containsString(allowed, field) {
+				return nil, ErrBadRequest.WithMessage(fmt.Sprintf("cannot filter by %q", field)).
+					WithDetails(H{"allowed": allowed})
+			}
+		}
+	}
+	return f, nil
+}
+
+// ParseFilter parses a filter expression
+func ParseFilter(expr string) (*Filter, error) {
+	tokens, err := tokenizeFilter(expr)
+	if err != nil {
+		return nil, err
+	}
+	p := &filterParser{tokens: tokens}
+	f, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind != tokenEOF {
+		return nil, fmt.Errorf("filter: unexpected %q at position %d", tok.text, tok.pos)
+	}
+	return f, nil
+}
+
+// --- Tokenizer ---
+
+type filterTokenKind int
+
+const (
+	tokenEOF filterTokenKind = iota
+	tokenIdent
+	tokenString
+	tokenNumber
+	tokenLParen
+	tokenRParen
+	tokenComma
+)
+
+type filterToken struct {
+	kind filterTokenKind
+	text string
+	pos  int
+}
+
+// tokenizeFilter splits an expression into tokens; strings are single- or
+// double-quoted with the quote doubled to escape it
+func tokenizeFilter(expr string) ([]filterToken, error) {
+	var tokens []filterToken
+	runes := []rune(expr)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '(':
+			tokens = append(tokens, filterToken{tokenLParen, "(", i})
+			i++
+		case r == ')':
+			tokens = append(tokens, filterToken{tokenRParen, ")", i})
+			i++
+		case r == ',':
+			tokens = append(tokens, filterToken{tokenComma, ",", i})
+			i++
+		case r == '\'' || r == '"':
+			start := i
+			var sb strings.Builder
+			for i++; ; i++ {
+				if i >= len(runes) {
+					return nil, fmt.Errorf("filter: unterminated string at position %d", start)
+				}
+				if runes[i] == r {
+					if i+1 < len(runes) && runes[i+1] == r {
+						sb.WriteRune(r)
+						i++
+						continue
+					}
+					i++
+					break
+				}
+				sb.WriteRune(runes[i])
+			}
+			tokens = append(tokens, filterToken{tokenString, sb.String(), start})
+		case r == '-' || unicode.IsDigit(r):
+			start := i
+			for i++; i < len(runes) && (unicode.IsDigit(runes[i]) || strings.ContainsRune(".eE+-", runes[i])); i++ {
+			}
+			tokens = append(tokens, filterToken{tokenNumber, string(runes[start:i]), start})
+		case unicode.IsLetter(r) || r == '_':
+			start := i
+			for i++; i < len(runes) && (unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i]) || runes[i] == '_' || runes[i] == '.'); i++ {
+			}
+			tokens = append(tokens, filterToken{tokenIdent, string(runes[start:i]), start})
+		default:
+			return nil, fmt.Errorf("filter: unexpected %q at position %d", r, i)
+		}
+	}
+	return append(tokens, filterToken{kind: tokenEOF, pos: len(runes)}), nil
+}
+
+// --- Parser ---
+
+// filterParser is a recursive descent parser:
+//
+//	or        = and { "or" and }
+//	and       = unary { "and" unary }
+//	unary     = [ "not" ] primary
+//	primary   = "(" or ")" | condition
+//	condition = field op value | field "in" "(" value { "," value } ")"
+type filterParser struct {
+	tokens []filterToken
+	pos    int
+}
+
+func (p *filterParser) peek() filterToken { return p.tokens[p.pos] }
+
+func (p *filterParser) next() filterToken {
+	tok := p.tokens[p.pos]
+	if tok.kind != tokenEOF {
+		p.pos++
+	}
+	return tok
+}
+
+// keyword reports whether the next token is the given keyword
+func (p *filterParser) keyword(word string) bool {
+	tok := p.peek()
+	return tok.kind == tokenIdent && strings.EqualFold(tok.text, word)
+}
+
+func (p *filterParser) parseOr() (*Filter, error) {
+	return p.parseLogic("or", p.parseAnd)
+}
+
+func (p *filterParser) parseAnd() (*Filter, error) {
+	return p.parseLogic("and", p.parseUnary)
+}
+
+// parseLogic parses operands joined by a logic keyword into a group
+func (p *filterParser) parseLogic(logic string, operand func() (*Filter, error)) (*Filter, error) {
+	first, err := operand()
+	if err != nil {
+		return nil, err
+	}
+	if !p.keyword(logic) {
+		return first, nil
+	}
+	group := &Filter{Logic: logic, Filters: []*Filter{first}}
+	for p.keyword(logic) {
+		p.next()
+		f, err := operand()
+		if err != nil {
+			return nil, err
+		}
+		group.Filters = append(group.Filters, f)
+	}
+	return group, nil
+}
+
+func (p *filterParser) parseUnary() (*Filter, error) {
+	if p.keyword("not") {
+		p.next()
+		f, err := p.parsePrimary()
+		if err != nil {
+			return nil, err
+		}
+		f.Not = !f.Not
+		return f, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *filterParser) parsePrimary() (*Filter, error) {
+	if p.peek().kind == tokenLParen {
+		p.next()
+		f, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if tok := p.next(); tok.kind != tokenRParen {
+			return nil, fmt.Errorf("filter: expected ) at position %d", tok.pos)
+		}
+		return f, nil
+	}
+	return p.parseCondition()
+}
+
+func (p *filterParser) parseCondition() (*Filter, error) {
+	field := p.next()
+	if field.kind != tokenIdent {
+		return nil, fmt.Errorf("filter: expected field at position %d", field.pos)
+	}
+	opTok := p.next()
+	op := FilterOp(strings.ToLower(opTok.text))
+	if opTok.kind != tokenIdent || !validFilterOp(op) {
+		return nil, fmt.Errorf("filter: unknown operator %q at position %d", opTok.text, opTok.pos)
+	}
+
+	f := &Filter{Field: field.text, Op: op}
+	if op != FilterIn {
+		value, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		f.Value = value
+		return f, nil
+	}
+
+	if tok := p.next(); tok.kind != tokenLParen {
+		return nil, fmt.Errorf("filter: expected ( after in at position %d", tok.pos)
+	}
+	var values []any
+	for {
+		value, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+		tok := p.next()
+		if tok.kind == tokenRParen {
+			break
+		}
+		if tok.kind != tokenComma {
+			return nil, fmt.Errorf("filter: expected , or ) at position %d", tok.pos)
+		}
+	}
+	f.Value = values
+	return f, nil
+}
+
+// parseValue parses a literal: string, number, true, false or null
+func (p *filterParser) parseValue() (any, error) {
+	tok := p.next()
+	switch tok.kind {
+	case tokenString:
+		return tok.text, nil
+	case tokenNumber:
+		if n, err := strconv.ParseInt(tok.text, 10, 64); err == nil {
+			return n, nil
+		}
+		if f, err := strconv.ParseFloat(tok.text, 64); err == nil {
+			return f, nil
+		}
+		return nil, fmt.Errorf("filter: invalid number %q at position %d", tok.text, tok.pos)
+	case tokenIdent:
+		switch strings.ToLower(tok.text) {
+		case "true":
+			return true, nil
+		case "false":
+			return false, nil
+		case "null":
+			return nil, nil
+		}
+	}
+	return nil, fmt.Errorf("filter: expected value at position %d", tok.pos)
+}
+
+// validFilterOp reports whether op is a known operator
+func validFilterOp(op FilterOp) bool {
+	switch op {
+	case FilterEq, FilterNe, FilterGt, FilterGe, FilterLt, FilterLe, FilterIn, FilterContains, FilterPrefix:
+		return true
+	}
+	return false
+}
+
+// =============================================================================
+// HELPERS
+// =============================================================================
+
+// containsString reports whether values contains v
+func containsString(values []string, v string) bool {
+	for _, s := range values {
+		if s == v {
+			return true
+		}
+	}
+	return false
+}
+
+// sliceLen returns the length of a slice or array (0 for anything else)
+func sliceLen(items any) int {
+	v := reflect.ValueOf(items)
+	if v.Kind() == reflect.Slice || v.Kind() == reflect.Array {
+		return v.Len()
+	}
+	return 0
+}
+
+// emptyList turns a nil slice into an empty one so lists encode as []
+func emptyList(items any) any {
+	v := reflect.ValueOf(items)
+	if v.Kind() == reflect.Slice && v.IsNil() {
+		return reflect.MakeSlice(v.Type(), 0, 0).Interface()
+	}
+	return items
+}
@@ -0,0 +1,154 @@
+package poltergeist
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// =============================================================================
+// PAGINATION, SORTING AND FILTERING TESTS
+// =============================================================================
+
+func listContext(target string) (*Context, *httptest.ResponseRecorder) {
+	w := httptest.NewRecorder()
+	return NewContext(w, httptest.NewRequest("GET", target, nil)), w
+}
+
+func TestContext_Pagination(t *testing.T) {
+	tests := []struct {
+		query string
+		want  Pagination
+	}{
+		{"", Pagination{Page: 1, Limit: 20, Offset: 0}},
+		{"?page=3&limit=10", Pagination{Page: 3, Limit: 10, Offset: 20}},
+		{"?page=2&per_page=5", Pagination{Page: 2, Limit: 5, Offset: 5}},
+		{"?page=-1&limit=1000", Pagination{Page: 1, Limit: 100, Offset: 0}},
+		{"?limit=abc&cursor=xyz", Pagination{Page: 1, Limit: 20, Cursor: "xyz"}},
+	}
+	for _, tt := range tests {
+		c, _ := listContext("/items" + tt.query)
+		if got := c.Pagination(); got != tt.want {
+			t.Errorf("Pagination(%q) = %+v, want %+v", tt.query, got, tt.want)
+		}
+	}
+
+	c, _ := listContext("/items?limit=80")
+	if got := c.Pagination(PaginationConfig{MaxLimit: 50}); got.Limit != 50 {
+		t.Errorf("custom MaxLimit: Limit = %d, want 50", got.Limit)
+	}
+}
+
+func TestContext_Paginate(t *testing.T) {
+	c, w := listContext("/items?page=2&limit=2&q=ghost")
+	c.Pagination()
+	if err := c.Paginate([]string{"c", "d"}, 5); err != nil {
+		t.Fatalf("Paginate: %v", err)
+	}
+
+	var body struct {
+		Data []string `json:"data"`
+		Meta PageMeta `json:"meta"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(body.Data) != 2 || body.Meta.Page != 2 || *body.Meta.Total != 5 || body.Meta.TotalPages != 3 {
+		t.Errorf("body = %s", w.Body.String())
+	}
+	if got := w.Header().Get(HeaderXTotalCount); got != "5" {
+		t.Errorf("X-Total-Count = %q, want 5", got)
+	}
+
+	links := strings.Join(w.Header().Values(HeaderLink), ", ")
+	for _, want := range []string{
+		`</items?limit=2&page=1&q=ghost>; rel="first"`,
+		`</items?limit=2&page=1&q=ghost>; rel="prev"`,
+		`</items?limit=2&page=3&q=ghost>; rel="next"`,
+		`</items?limit=2&page=3&q=ghost>; rel="last"`,
+	} {
+		if !strings.Contains(links, want) {
+			t.Errorf("Link headers %q missing %q", links, want)
+		}
+	}
+
+	// Cursor pagination and empty lists
+	c, w = listContext("/items?cursor=abc&page=4")
+	var none []string
+	c.PaginateCursor(none, "def")
+	if !strings.Contains(w.Body.String(), `"data":[]`) || !strings.Contains(w.Body.String(), `"next_cursor":"def"`) {
+		t.Errorf("cursor body = %s", w.Body.String())
+	}
+	if got := w.Header().Get(HeaderLink); got != `</items?cursor=def>; rel="next"` {
+		t.Errorf("cursor Link = %q", got)
+	}
+}
+
+func TestContext_Sort(t *testing.T) {
+	c, _ := listContext("/items?sort=-created_at,+name&sort=id")
+	fields, err := c.Sort("created_at", "name", "id")
+	if err != nil {
+		t.Fatalf("Sort: %v", err)
+	}
+	want := []SortField{{"created_at", true}, {"name", false}, {"id", false}}
+	if !reflect.DeepEqual(fields, want) {
+		t.Errorf("Sort = %+v, want %+v", fields, want)
+	}
+
+	c, _ = listContext("/items?sort=password")
+	_, err = c.Sort("name")
+	if !errors.Is(err, ErrBadRequest) {
+		t.Errorf("disallowed sort error = %v", err)
+	}
+}
+
+func TestParseFilter(t *testing.T) {
+	f, err := ParseFilter(`status eq 'active' and (age ge 18 or role in ('admin', "owner")) and not deleted eq true`)
+	if err != nil {
+		t.Fatalf("ParseFilter: %v", err)
+	}
+	want := &Filter{Logic: "and", Filters: []*Filter{
+		{Field: "status", Op: FilterEq, Value: "active"},
+		{Logic: "or", Filters: []*Filter{
+			{Field: "age", Op: FilterGe, Value: int64(18)},
+			{Field: "role", Op: FilterIn, Value: []any{"admin", "owner"}},
+		}},
+		{Field: "deleted", Op: FilterEq, Value: true, Not: true},
+	}}
+	if !reflect.DeepEqual(f, want) {
+		t.Errorf("ParseFilter mismatch:\n got %+v\nwant %+v", f, want)
+	}
+	if got := f.Fields(); !reflect.DeepEqual(got, []string{"status", "age", "role", "deleted"}) {
+		t.Errorf("Fields = %v", got)
+	}
+
+	for _, bad := range []string{"status", "status is 'x'", "name eq 'open", "(a eq 1", "a eq 1 b", "a in (1,", "a eq @"} {
+		if _, err := ParseFilter(bad); err == nil {
+			t.Errorf("ParseFilter(%q) succeeded, want error", bad)
+		}
+	}
+}
+
+func TestContext_Filter(t *testing.T) {
+	c, _ := listContext("/items?filter=" + "price%20lt%209.5%20and%20name%20contains%20'it''s'")
+	f, err := c.Filter("price", "name")
+	if err != nil {
+		t.Fatalf("Filter: %v", err)
+	}
+	if f.Filters[0].Value != 9.5 || f.Filters[1].Value != "it's" {
+		t.Errorf("Filter values = %v, %v", f.Filters[0].Value, f.Filters[1].Value)
+	}
+
+	c, _ = listContext("/items?filter=secret%20eq%201")
+	if _, err := c.Filter("price"); !errors.Is(err, ErrBadRequest) {
+		t.Errorf("disallowed filter error = %v", err)
+	}
+
+	c, _ = listContext("/items")
+	if f, err := c.Filter(); f != nil || err != nil {
+		t.Errorf("absent filter = %v, %v", f, err)
+	}
+}
@@ -0,0 +1,223 @@
+// Package poltergeisttest provides a fluent in-process test client for
+// Poltergeist applications:
+//
+//	pt := poltergeisttest.New(app)
+//	pt.GET("/users/1").
+//	    WithHeader("Authorization", "Bearer token").
+//	    Expect(t).
+//	    Status(200).
+//	    JSONPath("$.name", "John")
+//
+// Requests are served directly by the app's router without opening a socket.
+// Assertions report failures with t.Errorf and keep the chain going, so one
+// run shows every mismatch.
+package poltergeisttest
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/gofuckbiz/poltergeist"
+)
+
+// =============================================================================
+// CLIENT - In-process request builder
+// =============================================================================
+
+// Client sends requests to an http.Handler in-process
+type Client struct {
+	handler http.Handler
+	header  http.Header
+
+	mu     sync.Mutex
+	server *httptest.Server // started on demand for WebSocket and SSE tests
+}
+
+// New creates a client for a Poltergeist server
+func New(app *poltergeist.Server) *Client {
+	return NewHandler(app.Router())
+}
+
+// NewHandler creates a client for any http.Handler
+func NewHandler(handler http.Handler) *Client {
+	return &Client{handler: handler, header: make(http.Header)}
+}
+
+// URL starts a real HTTP server for the handler (once per test) and returns
+// its base URL; realtime helpers need a network connection
+func (c *Client) URL(t testing.TB) string {
+	t.Helper()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.server == nil {
+		server := httptest.NewServer(c.handler)
+		c.server = server
+		t.Cleanup(func() {
+			c.mu.Lock()
+			if c.server == server {
+				c.server = nil
+			}
+			c.mu.Unlock()
+			server.Close()
+		})
+	}
+	return c.server.URL
+}
+
+// WithHeader sets a header sent with every request
+func (c *Client) WithHeader(key, value string) *Client {
+	c.header.Set(key, value)
+	return c
+}
+
+// WithBearer sends "Authorization: Bearer <token>" with every request
+func (c *Client) WithBearer(token string) *Client {
+	return c.WithHeader("Authorization", "Bearer "+token)
+}
+
+// Request starts a request with any method
+func (c *Client) Request(method, path string) *Request {
+	return &Request{
+		client: c,
+		method: method,
+		path:   path,
+		header: c.header.Clone(),
+		query:  make(url.Values),
+	}
+}
+
+// GET starts a GET request
+func (c *Client) GET(path string) *Request { return c.Request(http.MethodGet, path) }
+
+// POST starts a POST request
+func (c *Client) POST(path string) *Request { return c.Request(http.MethodPost, path) }
+
+// PUT starts a PUT request
+func (c *Client) PUT(path string) *Request { return c.Request(http.MethodPut, path) }
+
+// PATCH starts a PATCH request
+func (c *Client) PATCH(path string) *Request { return c.Request(http.MethodPatch, path) }
+
+// DELETE starts a DELETE request
+func (c *Client) DELETE(path string) *Request { return c.Request(http.MethodDelete, path) }
+
+// HEAD starts a HEAD request
+func (c *Client) HEAD(path string) *Request { return c.Request(http.MethodHead, path) }
+
+// OPTIONS starts an OPTIONS request
+func (c *Client) OPTIONS(path string) *Request { return c.Request(http.MethodOptions, path) }
+
+// =============================================================================
+// REQUEST - Fluent request options
+// =============================================================================
+
+// Request is a request being built
+type Request struct {
+	client *Client
+	method string
+	path   string
+	header http.Header
+	query  url.Values
+	body   []byte
+	err    error
+}
+
+// WithHeader sets a request header
+func (r *Request) WithHeader(key, value string) *Request {
+	r.header.Set(key, value)
+	return r
+}
+
+// WithBearer sets "Authorization: Bearer <token>"
+func (r *Request) WithBearer(token string) *Request {
+	return r.WithHeader("Authorization", "Bearer "+token)
+}
+
+// WithQuery adds a query parameter
+func (r *Request) WithQuery(key, value string) *Request {
+	r.query.Add(key, value)
+	return r
+}
+
+// WithCookie adds a cookie
+func (r *Request) WithCookie(name, value string) *Request {
+	r.header.Add("Cookie", (&http.Cookie{Name: name, Value: value}).String())
+	return r
+}
+
+// JSON sets a JSON body, marshaling v (strings and []byte are sent as-is)
+func (r *Request) JSON(v any) *Request {
+	switch body := v.(type) {
+	case string:
+		r.body = []byte(body)
+	case []byte:
+		r.body = body
+	default:
+		r.body, r.err = json.Marshal(v)
+	}
+	r.header.Set(poltergeist.HeaderContentType, "application/json")
+	return r
+}
+
+// Form sets a URL-encoded form body
+func (r *Request) Form(values url.Values) *Request {
+	r.body = []byte(values.Encode())
+	r.header.Set(poltergeist.HeaderContentType, "application/x-www-form-urlencoded")
+	return r
+}
+
+// Body sets a raw body with a content type
+func (r *Request) Body(body []byte, contentType string) *Request {
+	r.body = body
+	if contentType != "" {
+		r.header.Set(poltergeist.HeaderContentType, contentType)
+	}
+	return r
+}
+
+// HTTPRequest builds the *http.Request that will be sent
+func (r *Request) HTTPRequest() *http.Request {
+	target := r.path
+	if len(r.query) > 0 {
+		sep := "?"
+		if strings.Contains(target, "?") {
+			sep = "&"
+		}
+		target += sep + r.query.Encode()
+	}
+
+	var body io.Reader
+	if r.body != nil {
+		body = bytes.NewReader(r.body)
+	}
+	req := httptest.NewRequest(r.method, target, body)
+	req.Header = r.header.Clone()
+	return req
+}
+
+// Do sends the request and returns the recorded response
+func (r *Request) Do() *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	r.client.handler.ServeHTTP(w, r.HTTPRequest())
+	return w
+}
+
+// Expect sends the request and starts assertions on the response
+func (r *Request) Expect(t testing.TB) *Response {
+	t.Helper()
+	if r.err != nil {
+		t.Fatalf("%s %s: encode request body: %v", r.method, r.path, r.err)
+	}
+	return &Response{
+		t:        t,
+		label:    r.method + " " + r.path,
+		Recorder: r.Do(),
+	}
+}
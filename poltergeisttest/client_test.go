@@ -0,0 +1,125 @@
+package poltergeisttest
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/gofuckbiz/poltergeist"
+)
+
+// =============================================================================
+// CLIENT AND ASSERTION TESTS
+// =============================================================================
+
+// recordingT collects the failures assertions report
+type recordingT struct {
+	testing.TB
+	failures []string
+}
+
+func (r *recordingT) Errorf(format string, args ...any) {
+	r.failures = append(r.failures, fmt.Sprintf(format, args...))
+}
+
+// echoApp echoes what it received as JSON
+func echoApp() *poltergeist.Server {
+	app := poltergeist.New()
+	app.Any("/echo", func(c *poltergeist.Context) error {
+		body, _ := c.Body()
+		c.Writer.Header().Set("X-Method", c.Method())
+		cookie, _ := c.Request.Cookie("session")
+		var session string
+		if cookie != nil {
+			session = cookie.Value
+		}
+		return c.JSON(http.StatusOK, poltergeist.H{
+			"auth":    c.Header("Authorization"),
+			"tenant":  c.Header("X-Tenant"),
+			"query":   c.Request.URL.Query(),
+			"type":    c.Header("Content-Type"),
+			"body":    string(body),
+			"session": session,
+			"tags":    []string{"a", "b"},
+		})
+	})
+	return app
+}
+
+func TestClient_Request(t *testing.T) {
+	pt := New(echoApp()).WithBearer("token").WithHeader("X-Tenant", "t1")
+
+	pt.POST("/echo?page=1").
+		WithQuery("sort", "name").
+		WithCookie("session", "abc").
+		JSON(map[string]int{"n": 1}).
+		Expect(t).
+		Status(http.StatusOK).
+		Header("X-Method", http.MethodPost).
+		HeaderContains("Content-Type", "json").
+		JSONPath("$.auth", "Bearer token").
+		JSONPath("$.tenant", "t1").
+		JSONPath("$.query.page[0]", "1").
+		JSONPath("$.query['sort'][0]", "name").
+		JSONPath("$.session", "abc").
+		JSONPath("$.type", "application/json").
+		JSONPath("$.body", `{"n":1}`).
+		JSONPath("$.tags[-1]", "b").
+		JSONPathExists("$.query")
+
+	pt.PUT("/echo").Form(url.Values{"a": {"1"}}).Expect(t).
+		JSONPath("$.type", "application/x-www-form-urlencoded").
+		JSONPath("$.body", "a=1")
+
+	pt.PATCH("/echo").WithBearer("other").Body([]byte("raw"), "text/plain").Expect(t).
+		JSONPath("$.auth", "Bearer other").
+		JSONPath("$.body", "raw")
+
+	var decoded struct{ Tags []string }
+	pt.DELETE("/echo").Expect(t).Decode(&decoded)
+	if strings.Join(decoded.Tags, ",") != "a,b" {
+		t.Errorf("decoded tags = %v", decoded.Tags)
+	}
+
+	pt.GET("/missing").Expect(t).Status(http.StatusNotFound)
+}
+
+func TestResponse_Failures(t *testing.T) {
+	pt := New(echoApp())
+	tests := []struct {
+		name   string
+		assert func(r *Response)
+		want   string
+	}{
+		{"status", func(r *Response) { r.Status(http.StatusCreated) }, "status = 200, want 201"},
+		{"header", func(r *Response) { r.Header("X-Method", "PUT") }, `header X-Method = "GET", want "PUT"`},
+		{"body", func(r *Response) { r.Body("nope") }, "body mismatch"},
+		{"body contains", func(r *Response) { r.BodyContains("nope") }, `body does not contain "nope"`},
+		{"JSON", func(r *Response) { r.JSON(`{"tags":["a"]}`) }, "JSON body mismatch"},
+		{"path value", func(r *Response) { r.JSONPath("$.tags[0]", "z") }, "$.tags[0] mismatch"},
+		{"missing key", func(r *Response) { r.JSONPath("$.nope", 1) }, `key "nope" not found`},
+		{"out of range", func(r *Response) { r.JSONPathExists("$.tags[5]") }, "index 5 out of range"},
+		{"not an array", func(r *Response) { r.JSONPath("$.auth[0]", 1) }, "not an array"},
+		{"decode", func(r *Response) { var n int; r.Decode(&n) }, "decode body"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rt := &recordingT{TB: t}
+			tt.assert(pt.GET("/echo").Expect(rt))
+			if len(rt.failures) != 1 || !strings.Contains(rt.failures[0], tt.want) {
+				t.Errorf("failures = %q, want one containing %q", rt.failures, tt.want)
+			}
+		})
+	}
+
+	// Passing assertions report nothing and JSON ignores key order
+	rt := &recordingT{TB: t}
+	pt.GET("/echo").Expect(rt).
+		Status(http.StatusOK).
+		JSON(map[string]any{"tags": []string{"a", "b"}, "body": "", "session": "", "type": "", "tenant": "", "auth": "", "query": map[string]any{}})
+	if len(rt.failures) != 0 {
+		t.Errorf("failures = %q, want none", rt.failures)
+	}
+}
@@ -0,0 +1,172 @@
+package poltergeisttest
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/gofuckbiz/poltergeist"
+)
+
+// =============================================================================
+// ROUTE COVERAGE - Which registered routes the tests exercised
+// =============================================================================
+
+// RouteCoverage records requests per route through an outermost middleware
+type RouteCoverage struct {
+	app     *poltergeist.Server
+	mu      sync.Mutex
+	hits    map[*poltergeist.Route]int
+	ignored []string
+}
+
+// TrackCoverage starts recording which routes are exercised. A hit is counted
+// before the route's middleware and handler run, so rejected requests and
+// streams the client abandons count too. Hidden routes (docs, internal
+// endpoints) are not counted. Typical use is suite-wide:
+//
+//	func TestMain(m *testing.M) {
+//	    cov := poltergeisttest.TrackCoverage(app)
+//	    code := m.Run()
+//	    fmt.Print(cov.Report())
+//	    if err := cov.Check(80); err != nil && code == 0 {
+//	        fmt.Println(err)
+//	        code = 1
+//	    }
+//	    os.Exit(code)
+//	}
+func TrackCoverage(app *poltergeist.Server) *RouteCoverage {
+	cov := &RouteCoverage{app: app, hits: make(map[*poltergeist.Route]int)}
+	app.Router().UseNamed(poltergeist.Middleware{
+		Name:     "poltergeisttest.coverage",
+		Priority: math.MinInt,
+		Func:     cov.record,
+	})
+	return cov
+}
+
+// record counts the request's route before passing it on
+func (cov *RouteCoverage) record(next poltergeist.HandlerFunc) poltergeist.HandlerFunc {
+	return func(c *poltergeist.Context) error {
+		if route := c.Route(); route != nil {
+			cov.mu.Lock()
+			cov.hits[route]++
+			cov.mu.Unlock()
+		}
+		return next(c)
+	}
+}
+
+// Ignore excludes routes whose path starts with one of the prefixes
+func (cov *RouteCoverage) Ignore(prefixes ...string) *RouteCoverage {
+	cov.mu.Lock()
+	defer cov.mu.Unlock()
+	cov.ignored = append(cov.ignored, prefixes...)
+	return cov
+}
+
+// Hits returns how many requests a route served
+func (cov *RouteCoverage) Hits(route *poltergeist.Route) int {
+	cov.mu.Lock()
+	defer cov.mu.Unlock()
+	return cov.hits[route]
+}
+
+// Routes returns the routes coverage is measured against
+func (cov *RouteCoverage) Routes() []*poltergeist.Route {
+	cov.mu.Lock()
+	defer cov.mu.Unlock()
+
+	var routes []*poltergeist.Route
+	for _, route := range cov.app.Routes() {
+		if route.RouteHidden || cov.isIgnored(route.Path) {
+			continue
+		}
+		routes = append(routes, route)
+	}
+	return routes
+}
+
+// Uncovered returns the routes no test requested
+func (cov *RouteCoverage) Uncovered() []*poltergeist.Route {
+	var uncovered []*poltergeist.Route
+	for _, route := range cov.Routes() {
+		if cov.Hits(route) == 0 {
+			uncovered = append(uncovered, route)
+		}
+	}
+	return uncovered
+}
+
+// Percent returns the share of routes exercised (100 when there are none)
+func (cov *RouteCoverage) Percent() float64 {
+	routes := cov.Routes()
+	if len(routes) == 0 {
+		return 100
+	}
+	covered := len(routes) - len(cov.Uncovered())
+	return float64(covered) * 100 / float64(len(routes))
+}
+
+// Check returns an error listing uncovered routes when coverage is below min percent
+func (cov *RouteCoverage) Check(min float64) error {
+	percent := cov.Percent()
+	if percent >= min {
+		return nil
+	}
+	return fmt.Errorf("route coverage %.1f%% is below %.1f%%; untested routes:\n%s",
+		percent, min, formatRoutes(cov.Uncovered()))
+}
+
+// Require fails the test when coverage is below min percent
+func (cov *RouteCoverage) Require(t testing.TB, min float64) {
+	t.Helper()
+	if err := cov.Check(min); err != nil {
+		t.Error(err)
+	}
+}
+
+// Report renders a per-route hit table
+func (cov *RouteCoverage) Report() string {
+	routes := cov.Routes()
+	sort.SliceStable(routes, func(i, j int) bool {
+		if routes[i].Path != routes[j].Path {
+			return routes[i].Path < routes[j].Path
+		}
+		return routes[i].Method < routes[j].Method
+	})
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Route coverage: %.1f%%\n", cov.Percent())
+	for _, route := range routes {
+		hits := cov.Hits(route)
+		mark := "✓"
+		if hits == 0 {
+			mark = "✗"
+		}
+		fmt.Fprintf(&sb, "  %s %-7s %-40s %d\n", mark, route.Method, route.Path, hits)
+	}
+	return sb.String()
+}
+
+// isIgnored reports whether a path matches an ignored prefix (mu held)
+func (cov *RouteCoverage) isIgnored(path string) bool {
+	for _, prefix := range cov.ignored {
+		if strings.HasPrefix(path, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// formatRoutes lists routes one per line
+func formatRoutes(routes []*poltergeist.Route) string {
+	lines := make([]string, len(routes))
+	for i, route := range routes {
+		lines[i] = "  " + route.Method + " " + route.Path
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,60 @@
+package poltergeisttest
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofuckbiz/poltergeist"
+)
+
+// =============================================================================
+// ROUTE COVERAGE TESTS
+// =============================================================================
+
+func TestTrackCoverage(t *testing.T) {
+	app := poltergeist.New().UseLogger(poltergeist.NopLogger)
+	users := app.GET("/users", func(c *poltergeist.Context) error { return c.NoContent() })
+	admin := app.DELETE("/admin", func(c *poltergeist.Context) error { return c.NoContent() },
+		func(next poltergeist.HandlerFunc) poltergeist.HandlerFunc {
+			return func(c *poltergeist.Context) error { return poltergeist.ErrForbidden }
+		})
+	events := app.SSE("/events", func(c *poltergeist.Context, sse *poltergeist.SSEWriter) {
+		sse.SendEvent("hello", "world")
+	})
+	app.GET("/untested", func(c *poltergeist.Context) error { return c.NoContent() })
+	app.GET("/internal/health", func(c *poltergeist.Context) error { return c.NoContent() })
+	app.GET("/docs", func(c *poltergeist.Context) error { return c.NoContent() }).Hidden()
+
+	cov := TrackCoverage(app).Ignore("/internal")
+
+	New(app).GET("/users").Do()
+	New(app).GET("/users").Do()
+	New(app).DELETE("/admin").Expect(t).Status(http.StatusForbidden)
+
+	// An SSE client that has gone away by the time the stream ends
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	req := httptest.NewRequest(http.MethodGet, "/events", nil).WithContext(ctx)
+	app.ServeHTTP(httptest.NewRecorder(), req)
+
+	for route, want := range map[*poltergeist.Route]int{users: 2, admin: 1, events: 1} {
+		if got := cov.Hits(route); got != want {
+			t.Errorf("%s %s hits = %d, want %d", route.Method, route.Path, got, want)
+		}
+	}
+	if got := len(cov.Routes()); got != 4 {
+		t.Errorf("measured %d routes, want 4 without hidden and ignored ones", got)
+	}
+	if uncovered := cov.Uncovered(); len(uncovered) != 1 || uncovered[0].Path != "/untested" {
+		t.Errorf("uncovered = %v, want /untested", formatRoutes(uncovered))
+	}
+	if cov.Percent() != 75 || cov.Check(75) != nil {
+		t.Errorf("Percent = %.1f, want 75", cov.Percent())
+	}
+	if err := cov.Check(80); err == nil || !strings.Contains(err.Error(), "/untested") {
+		t.Errorf("Check(80) = %v, want it to list /untested", err)
+	}
+}
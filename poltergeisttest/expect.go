@@ -0,0 +1,301 @@
+package poltergeisttest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// =============================================================================
+// RESPONSE - Chained assertions
+// =============================================================================
+
+// Response holds a recorded response and the test it reports to
+type Response struct {
+	Recorder *httptest.ResponseRecorder
+
+	t     testing.TB
+	label string
+}
+
+// Status asserts the status code
+func (r *Response) Status(code int) *Response {
+	r.t.Helper()
+	if r.Recorder.Code != code {
+		r.t.Errorf("%s: status = %d, want %d\nbody: %s", r.label, r.Recorder.Code, code, r.bodyPreview())
+	}
+	return r
+}
+
+// Header asserts a response header value
+func (r *Response) Header(key, want string) *Response {
+	r.t.Helper()
+	if got := r.Recorder.Header().Get(key); got != want {
+		r.t.Errorf("%s: header %s = %q, want %q", r.label, key, got, want)
+	}
+	return r
+}
+
+// HeaderContains asserts a response header contains a substring
+func (r *Response) HeaderContains(key, substr string) *Response {
+	r.t.Helper()
+	if got := r.Recorder.Header().Get(key); !strings.Contains(got, substr) {
+		r.t.Errorf("%s: header %s = %q, want it to contain %q", r.label, key, got, substr)
+	}
+	return r
+}
+
+// Body asserts the exact body
+func (r *Response) Body(want string) *Response {
+	r.t.Helper()
+	if got := r.Recorder.Body.String(); got != want {
+		r.t.Errorf("%s: body mismatch\n%s", r.label, diffLines(want, got))
+	}
+	return r
+}
+
+// BodyContains asserts the body contains a substring
+func (r *Response) BodyContains(substr string) *Response {
+	r.t.Helper()
+	if !strings.Contains(r.Recorder.Body.String(), substr) {
+		r.t.Errorf("%s: body does not contain %q\nbody: %s", r.label, substr, r.bodyPreview())
+	}
+	return r
+}
+
+// JSON asserts the body is JSON equal to want (a value, or a JSON document as
+// string or []byte); key order and number formatting are ignored
+func (r *Response) JSON(want any) *Response {
+	r.t.Helper()
+	got, ok := r.decode()
+	if !ok {
+		return r
+	}
+	expected, err := normalize(want, true)
+	if err != nil {
+		r.t.Errorf("%s: invalid expected JSON: %v", r.label, err)
+		return r
+	}
+	if !reflect.DeepEqual(got, expected) {
+		r.t.Errorf("%s: JSON body mismatch\n%s", r.label, diffLines(pretty(expected), pretty(got)))
+	}
+	return r
+}
+
+// JSONPath asserts the value at a path such as "$.user.tags[0]" equals want
+func (r *Response) JSONPath(path string, want any) *Response {
+	r.t.Helper()
+	doc, ok := r.decode()
+	if !ok {
+		return r
+	}
+	got, err := lookup(doc, path)
+	if err != nil {
+		r.t.Errorf("%s: %s: %v\nbody: %s", r.label, path, err, r.bodyPreview())
+		return r
+	}
+	expected, err := normalize(want, false)
+	if err != nil {
+		r.t.Errorf("%s: invalid expected value for %s: %v", r.label, path, err)
+		return r
+	}
+	if !reflect.DeepEqual(got, expected) {
+		r.t.Errorf("%s: %s mismatch\n%s", r.label, path, diffLines(pretty(expected), pretty(got)))
+	}
+	return r
+}
+
+// JSONPathExists asserts a path is present in the JSON body
+func (r *Response) JSONPathExists(path string) *Response {
+	r.t.Helper()
+	if doc, ok := r.decode(); ok {
+		if _, err := lookup(doc, path); err != nil {
+			r.t.Errorf("%s: %s: %v", r.label, path, err)
+		}
+	}
+	return r
+}
+
+// Decode unmarshals the JSON body into v
+func (r *Response) Decode(v any) *Response {
+	r.t.Helper()
+	if err := json.Unmarshal(r.Recorder.Body.Bytes(), v); err != nil {
+		r.t.Errorf("%s: decode body: %v\nbody: %s", r.label, err, r.bodyPreview())
+	}
+	return r
+}
+
+// decode parses the body as generic JSON
+func (r *Response) decode() (any, bool) {
+	r.t.Helper()
+	var doc any
+	if err := json.Unmarshal(r.Recorder.Body.Bytes(), &doc); err != nil {
+		r.t.Errorf("%s: body is not JSON: %v\nbody: %s", r.label, err, r.bodyPreview())
+		return nil, false
+	}
+	return doc, true
+}
+
+// bodyPreview returns the body truncated for failure messages
+func (r *Response) bodyPreview() string {
+	const limit = 2048
+	body := r.Recorder.Body.String()
+	if len(body) > limit {
+		return body[:limit] + "…"
+	}
+	return body
+}
+
+// =============================================================================
+// HELPERS - JSON normalization, paths and diffs
+// =============================================================================
+
+// normalize round-trips a value through JSON so it compares like decoded data.
+// []byte and json.RawMessage are JSON documents; so are strings when rawStrings is set.
+func normalize(v any, rawStrings bool) (any, error) {
+	var data []byte
+	switch value := v.(type) {
+	case json.RawMessage:
+		data = value
+	case []byte:
+		data = value
+	case string:
+		if !rawStrings {
+			return value, nil
+		}
+		data = []byte(value)
+	default:
+		var err error
+		if data, err = json.Marshal(v); err != nil {
+			return nil, err
+		}
+	}
+
+	var out any
+	if err := json.Unmarshal(data, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// lookup resolves a simple JSONPath ($.a.b[0]['c']) in decoded JSON
+func lookup(doc any, path string) (any, error) {
+	rest := strings.TrimPrefix(path, "$")
+	current := doc
+	for rest != "" {
+		switch {
+		case strings.HasPrefix(rest, "['"):
+			end := strings.Index(rest, "']")
+			if end == -1 {
+				return nil, fmt.Errorf("unterminated key in path")
+			}
+			key := rest[2:end]
+			rest = rest[end+2:]
+			obj, ok := current.(map[string]any)
+			if !ok {
+				return nil, fmt.Errorf("%q: not an object", key)
+			}
+			if current, ok = obj[key]; !ok {
+				return nil, fmt.Errorf("key %q not found", key)
+			}
+		case strings.HasPrefix(rest, "["):
+			end := strings.Index(rest, "]")
+			if end == -1 {
+				return nil, fmt.Errorf("unterminated index in path")
+			}
+			index, err := strconv.Atoi(rest[1:end])
+			if err != nil {
+				return nil, fmt.Errorf("invalid index %q", rest[1:end])
+			}
+			rest = rest[end+1:]
+			arr, ok := current.([]any)
+			if !ok {
+				return nil, fmt.Errorf("[%d]: not an array", index)
+			}
+			if index < 0 {
+				index += len(arr)
+			}
+			if index < 0 || index >= len(arr) {
+				return nil, fmt.Errorf("index %d out of range (len %d)", index, len(arr))
+			}
+			current = arr[index]
+		case strings.HasPrefix(rest, "."):
+			rest = rest[1:]
+			end := strings.IndexAny(rest, ".[")
+			if end == -1 {
+				end = len(rest)
+			}
+			key := rest[:end]
+			rest = rest[end:]
+			obj, ok := current.(map[string]any)
+			if !ok {
+				return nil, fmt.Errorf("%q: not an object", key)
+			}
+			if current, ok = obj[key]; !ok {
+				return nil, fmt.Errorf("key %q not found", key)
+			}
+		default:
+			return nil, fmt.Errorf("invalid path segment %q", rest)
+		}
+	}
+	return current, nil
+}
+
+// pretty renders decoded JSON with indentation
+func pretty(v any) string {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetIndent("", "  ")
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return fmt.Sprint(v)
+	}
+	return strings.TrimSuffix(buf.String(), "\n")
+}
+
+// diffLines renders a line diff of want and got ("-" want, "+" got)
+func diffLines(want, got string) string {
+	a := strings.Split(want, "\n")
+	b := strings.Split(got, "\n")
+
+	// Longest common subsequence table
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	var sb strings.Builder
+	sb.WriteString("--- want\n+++ got\n")
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			sb.WriteString("  " + a[i] + "\n")
+			i++
+			j++
+		case i < len(a) && (j == len(b) || lcs[i+1][j] >= lcs[i][j+1]):
+			sb.WriteString("- " + a[i] + "\n")
+			i++
+		default:
+			sb.WriteString("+ " + b[j] + "\n")
+			j++
+		}
+	}
+	return strings.TrimSuffix(sb.String(), "\n")
+}
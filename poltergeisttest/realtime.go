@@ -0,0 +1,358 @@
+package poltergeisttest
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// DefaultTimeout bounds how long realtime expectations wait for a message
+const DefaultTimeout = 2 * time.Second
+
+// =============================================================================
+// WEBSOCKET CLIENT - Send and expect messages
+// =============================================================================
+
+// Event is the {"event": ..., "data": ..., "id": ..., "trace": ...}
+// envelope used by event-based WebSocket messages
+type Event struct {
+	Event   string            `json:"event"`
+	Data    json.RawMessage   `json:"data,omitempty"`
+	ID      string            `json:"id,omitempty"`
+	Trace   map[string]string `json:"trace,omitempty"`
+	EventID string            `json:"event_id,omitempty"` // set on room events recorded in a hub's history
+}
+
+// WSClient is a WebSocket connection under test
+type WSClient struct {
+	Conn    *websocket.Conn
+	Timeout time.Duration // per-expectation wait (default: DefaultTimeout)
+
+	t    testing.TB
+	path string
+}
+
+// WebSocket dials a WebSocket route; the connection is closed when the test ends
+//
+//	ws := pt.WebSocket(t, "/ws")
+//	ws.SendEvent("join", Room{Name: "general"})
+//	ws.ExpectEvent("joined", &ack)
+func (c *Client) WebSocket(t testing.TB, path string, header ...http.Header) *WSClient {
+	t.Helper()
+	target := "ws" + strings.TrimPrefix(c.URL(t), "http") + path
+
+	requestHeader := c.header.Clone()
+	for _, h := range header {
+		for key, values := range h {
+			requestHeader[key] = values
+		}
+	}
+
+	conn, resp, err := websocket.DefaultDialer.Dial(target, requestHeader)
+	if err != nil {
+		status := 0
+		if resp != nil {
+			status = resp.StatusCode
+		}
+		t.Fatalf("WS %s: dial failed (status %d): %v", path, status, err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	return &WSClient{Conn: conn, Timeout: DefaultTimeout, t: t, path: path}
+}
+
+// Send sends a text message
+func (ws *WSClient) Send(message string) *WSClient {
+	ws.t.Helper()
+	if err := ws.Conn.WriteMessage(websocket.TextMessage, []byte(message)); err != nil {
+		ws.t.Fatalf("WS %s: send: %v", ws.path, err)
+	}
+	return ws
+}
+
+// SendJSON sends a JSON message
+func (ws *WSClient) SendJSON(v any) *WSClient {
+	ws.t.Helper()
+	if err := ws.Conn.WriteJSON(v); err != nil {
+		ws.t.Fatalf("WS %s: send JSON: %v", ws.path, err)
+	}
+	return ws
+}
+
+// SendEvent sends an {"event": event, "data": data} message
+func (ws *WSClient) SendEvent(event string, data any) *WSClient {
+	ws.t.Helper()
+	raw, err := json.Marshal(data)
+	if err != nil {
+		ws.t.Fatalf("WS %s: encode %q: %v", ws.path, event, err)
+	}
+	return ws.SendJSON(Event{Event: event, Data: raw})
+}
+
+// Read waits for the next message and returns it
+func (ws *WSClient) Read() []byte {
+	ws.t.Helper()
+	ws.Conn.SetReadDeadline(time.Now().Add(ws.Timeout))
+	_, message, err := ws.Conn.ReadMessage()
+	if err != nil {
+		ws.t.Fatalf("WS %s: no message within %s: %v", ws.path, ws.Timeout, err)
+	}
+	return message
+}
+
+// ExpectText asserts the next message equals want
+func (ws *WSClient) ExpectText(want string) *WSClient {
+	ws.t.Helper()
+	if got := string(ws.Read()); got != want {
+		ws.t.Errorf("WS %s: message mismatch\n%s", ws.path, diffLines(want, got))
+	}
+	return ws
+}
+
+// ExpectJSON decodes the next message into v
+func (ws *WSClient) ExpectJSON(v any) *WSClient {
+	ws.t.Helper()
+	message := ws.Read()
+	if err := json.Unmarshal(message, v); err != nil {
+		ws.t.Errorf("WS %s: message is not JSON: %v\nmessage: %s", ws.path, err, message)
+	}
+	return ws
+}
+
+// ExpectEvent asserts the next message is the named event and decodes its
+// data into v (nil to skip decoding)
+func (ws *WSClient) ExpectEvent(event string, v any) *WSClient {
+	ws.t.Helper()
+	message := ws.Read()
+
+	var envelope Event
+	if err := json.Unmarshal(message, &envelope); err != nil {
+		ws.t.Errorf("WS %s: expected event %q, got non-JSON message: %s", ws.path, event, message)
+		return ws
+	}
+	if envelope.Event != event {
+		ws.t.Errorf("WS %s: event = %q, want %q\nmessage: %s", ws.path, envelope.Event, event, message)
+		return ws
+	}
+	if v != nil {
+		if err := json.Unmarshal(envelope.Data, v); err != nil {
+			ws.t.Errorf("WS %s: decode %q data: %v\ndata: %s", ws.path, event, err, envelope.Data)
+		}
+	}
+	return ws
+}
+
+// ExpectNoMessage asserts nothing arrives within d
+func (ws *WSClient) ExpectNoMessage(d time.Duration) *WSClient {
+	ws.t.Helper()
+	ws.Conn.SetReadDeadline(time.Now().Add(d))
+	if _, message, err := ws.Conn.ReadMessage(); err == nil {
+		ws.t.Errorf("WS %s: unexpected message: %s", ws.path, message)
+	}
+	return ws
+}
+
+// Close closes the connection with a normal closure frame
+func (ws *WSClient) Close() {
+	ws.Conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+	ws.Conn.Close()
+}
+
+// =============================================================================
+// SSE CLIENT - Consume and assert event streams
+// =============================================================================
+
+// SSEMessage is a parsed Server-Sent Event
+type SSEMessage struct {
+	ID    string
+	Event string
+	Data  string
+	Retry int
+	Trace map[string]string // traceparent / tracestate fields
+}
+
+// SSEClient is an open event stream under test
+type SSEClient struct {
+	Response *http.Response
+	Timeout  time.Duration // per-expectation wait (default: DefaultTimeout)
+
+	t      testing.TB
+	path   string
+	events chan SSEMessage
+	done   chan struct{}
+	once   sync.Once
+}
+
+// SSE opens an event stream; it is closed when the test ends
+//
+//	stream := pt.SSE(t, "/events")
+//	stream.ExpectSequence("connected", "tick", "tick")
+func (c *Client) SSE(t testing.TB, path string, header ...http.Header) *SSEClient {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodGet, c.URL(t)+path, nil)
+	if err != nil {
+		t.Fatalf("SSE %s: %v", path, err)
+	}
+	req.Header = c.header.Clone()
+	for _, h := range header {
+		for key, values := range h {
+			req.Header[key] = values
+		}
+	}
+	req.Header.Set("Accept", "text/event-stream")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("SSE %s: %v", path, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		t.Fatalf("SSE %s: status = %d, want 200", path, resp.StatusCode)
+	}
+
+	stream := &SSEClient{
+		Response: resp,
+		Timeout:  DefaultTimeout,
+		t:        t,
+		path:     path,
+		events:   make(chan SSEMessage, 64),
+		done:     make(chan struct{}),
+	}
+	go stream.readLoop()
+	t.Cleanup(stream.Close)
+	return stream
+}
+
+// readLoop parses the stream into events until it ends or the client is
+// closed
+func (s *SSEClient) readLoop() {
+	defer close(s.events)
+
+	scanner := bufio.NewScanner(s.Response.Body)
+	var msg SSEMessage
+	var data []string
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			if len(data) > 0 || msg.Event != "" {
+				msg.Data = strings.Join(data, "\n")
+				select {
+				case s.events <- msg:
+				case <-s.done:
+					return
+				}
+			}
+			msg, data = SSEMessage{}, nil
+			continue
+		}
+		if strings.HasPrefix(line, ":") {
+			continue // comment / keep-alive
+		}
+		field, value, _ := strings.Cut(line, ":")
+		value = strings.TrimPrefix(value, " ")
+		switch field {
+		case "event":
+			msg.Event = value
+		case "data":
+			data = append(data, value)
+		case "id":
+			msg.ID = value
+		case "retry":
+			msg.Retry, _ = strconv.Atoi(value)
+		case "traceparent", "tracestate":
+			if msg.Trace == nil {
+				msg.Trace = make(map[string]string)
+			}
+			msg.Trace[field] = value
+		}
+	}
+}
+
+// Next waits for the next event
+func (s *SSEClient) Next() SSEMessage {
+	s.t.Helper()
+	select {
+	case msg, ok := <-s.events:
+		if !ok {
+			s.t.Fatalf("SSE %s: stream closed", s.path)
+		}
+		return msg
+	case <-time.After(s.Timeout):
+		s.t.Fatalf("SSE %s: no event within %s", s.path, s.Timeout)
+	}
+	return SSEMessage{}
+}
+
+// ExpectEvent asserts the next event has the given type and decodes its JSON
+// data into v (nil to skip decoding)
+func (s *SSEClient) ExpectEvent(event string, v any) *SSEClient {
+	s.t.Helper()
+	msg := s.Next()
+	if msg.Event != event {
+		s.t.Errorf("SSE %s: event = %q, want %q\ndata: %s", s.path, msg.Event, event, msg.Data)
+		return s
+	}
+	if v != nil {
+		if err := json.Unmarshal([]byte(msg.Data), v); err != nil {
+			s.t.Errorf("SSE %s: decode %q data: %v\ndata: %s", s.path, event, err, msg.Data)
+		}
+	}
+	return s
+}
+
+// ExpectData asserts the next event's data equals want (JSON-compared unless
+// want is a string)
+func (s *SSEClient) ExpectData(want any) *SSEClient {
+	s.t.Helper()
+	msg := s.Next()
+	if text, ok := want.(string); ok {
+		if msg.Data != text {
+			s.t.Errorf("SSE %s: data mismatch\n%s", s.path, diffLines(text, msg.Data))
+		}
+		return s
+	}
+
+	var got any
+	if err := json.Unmarshal([]byte(msg.Data), &got); err != nil {
+		s.t.Errorf("SSE %s: data is not JSON: %v\ndata: %s", s.path, err, msg.Data)
+		return s
+	}
+	expected, err := normalize(want, false)
+	if err != nil {
+		s.t.Errorf("SSE %s: invalid expected data: %v", s.path, err)
+		return s
+	}
+	if !reflect.DeepEqual(got, expected) {
+		s.t.Errorf("SSE %s: data mismatch\n%s", s.path, diffLines(pretty(expected), pretty(got)))
+	}
+	return s
+}
+
+// ExpectSequence asserts the next events have these types, in order
+func (s *SSEClient) ExpectSequence(events ...string) *SSEClient {
+	s.t.Helper()
+	got := make([]string, 0, len(events))
+	for range events {
+		got = append(got, s.Next().Event)
+	}
+	if strings.Join(got, "\n") != strings.Join(events, "\n") {
+		s.t.Errorf("SSE %s: event sequence mismatch\n%s", s.path, diffLines(strings.Join(events, "\n"), strings.Join(got, "\n")))
+	}
+	return s
+}
+
+// Close closes the stream
+func (s *SSEClient) Close() {
+	s.once.Do(func() {
+		close(s.done)
+		s.Response.Body.Close()
+	})
+}
@@ -0,0 +1,98 @@
+package poltergeisttest
+
+import (
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofuckbiz/poltergeist"
+)
+
+// =============================================================================
+// REALTIME CLIENT TESTS
+// =============================================================================
+
+func TestWebSocket(t *testing.T) {
+	app := poltergeist.New().UseLogger(poltergeist.NopLogger)
+	app.WebSocket("/ws", func(conn *poltergeist.WSConn, _ int, message []byte) {
+		switch string(message) {
+		case "ping":
+			conn.SendText("pong")
+		case "quiet":
+		default:
+			conn.SendJSON(map[string]any{"event": "echo", "data": map[string]string{"got": string(message)}})
+		}
+	})
+
+	ws := New(app).WebSocket(t, "/ws")
+	ws.Send("ping").ExpectText("pong")
+
+	var data struct{ Got string }
+	ws.SendEvent("join", "general").ExpectEvent("echo", &data)
+	if !strings.Contains(data.Got, `"event":"join"`) || !strings.Contains(data.Got, `"general"`) {
+		t.Errorf("echoed %q, want the join event", data.Got)
+	}
+
+	var raw map[string]any
+	ws.SendJSON(map[string]int{"n": 1}).ExpectJSON(&raw)
+	if raw["event"] != "echo" {
+		t.Errorf("JSON message = %v", raw)
+	}
+
+	ws.Send("quiet").ExpectNoMessage(50 * time.Millisecond)
+	ws.Close()
+}
+
+func TestSSE(t *testing.T) {
+	app := poltergeist.New().UseLogger(poltergeist.NopLogger)
+	app.SSE("/events", func(c *poltergeist.Context, sse *poltergeist.SSEWriter) {
+		sse.SendComment("keep-alive")
+		sse.Send(&poltergeist.SSEEvent{ID: "1", Event: "connected", Data: "hello", Retry: 1000})
+		sse.SendEvent("user", map[string]string{"name": "ada"})
+		sse.SendData("plain text")
+		sse.SendEvent("tick", 1)
+		sse.SendEvent("tick", 2)
+	})
+
+	stream := New(app).SSE(t, "/events")
+	if msg := stream.Next(); msg.ID != "1" || msg.Event != "connected" || msg.Data != "hello" || msg.Retry != 1000 {
+		t.Errorf("first event = %+v", msg)
+	}
+
+	var user struct{ Name string }
+	stream.ExpectEvent("user", &user)
+	if user.Name != "ada" {
+		t.Errorf("user = %+v", user)
+	}
+	stream.ExpectData("plain text").ExpectSequence("tick", "tick")
+}
+
+func TestSSE_CloseStopsReader(t *testing.T) {
+	app := poltergeist.New().UseLogger(poltergeist.NopLogger)
+	app.SSE("/flood", func(c *poltergeist.Context, sse *poltergeist.SSEWriter) {
+		for i := 0; i < 200; i++ {
+			sse.SendEvent("n", i)
+		}
+	})
+
+	stream := New(app).SSE(t, "/flood")
+	stream.ExpectData(strconv.Itoa(0))
+	time.Sleep(50 * time.Millisecond) // let the buffer fill up
+	stream.Close()
+
+	deadline := time.Now().Add(time.Second)
+	for readLoopRunning() {
+		if time.Now().After(deadline) {
+			t.Fatal("SSE reader still blocked after Close")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// readLoopRunning reports whether an SSE reader goroutine is alive
+func readLoopRunning() bool {
+	buf := make([]byte, 1<<20)
+	return strings.Contains(string(buf[:runtime.Stack(buf, true)]), "(*SSEClient).readLoop")
+}
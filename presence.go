@@ -0,0 +1,246 @@
+package poltergeist
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// =============================================================================
+// PRESENCE - Who is online in which WSHub room
+// =============================================================================
+
+// PresenceAction is what a PresenceEvent reports
+type PresenceAction string
+
+// Presence actions
+const (
+	PresenceJoin  PresenceAction = "join"  // first connection of an identity joined the room
+	PresenceLeave PresenceAction = "leave" // last connection of an identity left the room
+)
+
+// PresenceEvent reports an identity coming online in, or leaving, a room.
+// Several connections of one identity (tabs, devices) produce one join and
+// one leave.
+type PresenceEvent struct {
+	Action   PresenceAction `json:"action"`
+	Room     string         `json:"room"`
+	Identity string         `json:"identity"`
+	At       time.Time      `json:"at"`
+}
+
+// PresenceEntry describes an identity online in a room
+type PresenceEntry struct {
+	Identity    string    `json:"identity"`
+	Connections int       `json:"connections"`
+	JoinedAt    time.Time `json:"joined_at"`
+	LastSeen    time.Time `json:"last_seen"` // last message from any of its connections
+}
+
+// presenceTracker holds the identities online per room
+type presenceTracker struct {
+	mu        sync.RWMutex
+	rooms     map[string]map[string]*roomPresence // room -> identity -> presence
+	joined    map[*WSConn]map[string]string       // conn -> room -> identity it joined as
+	lastSeen  map[string]time.Time                // identity -> when it last went offline
+	hooks     []func(PresenceEvent)
+	broadcast bool
+}
+
+// roomPresence is one identity's presence in a room
+type roomPresence struct {
+	conns    map[*WSConn]bool
+	joinedAt time.Time
+}
+
+// newPresenceTracker creates an empty tracker
+func newPresenceTracker() *presenceTracker {
+	return &presenceTracker{
+		rooms:    make(map[string]map[string]*roomPresence),
+		joined:   make(map[*WSConn]map[string]string),
+		lastSeen: make(map[string]time.Time),
+	}
+}
+
+// join records conn in room, returning the event if its identity is new there
+func (p *presenceTracker) join(conn *WSConn, room string) *PresenceEvent {
+	identity := conn.Identity()
+	now := time.Now()
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.joined[conn] == nil {
+		p.joined[conn] = make(map[string]string)
+	}
+	if _, ok := p.joined[conn][room]; ok {
+		return nil
+	}
+	p.joined[conn][room] = identity
+
+	if p.rooms[room] == nil {
+		p.rooms[room] = make(map[string]*roomPresence)
+	}
+	presence, ok := p.rooms[room][identity]
+	if !ok {
+		presence = &roomPresence{conns: make(map[*WSConn]bool), joinedAt: now}
+		p.rooms[room][identity] = presence
+	}
+	presence.conns[conn] = true
+	if ok {
+		return nil
+	}
+	return &PresenceEvent{Action: PresenceJoin, Room: room, Identity: identity, At: now}
+}
+
+// leave removes conn from room, returning the event if its identity has no
+// connection left there
+func (p *presenceTracker) leave(conn *WSConn, room string) *PresenceEvent {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.leaveLocked(conn, room, time.Now())
+}
+
+// disconnect removes conn from every room it joined
+func (p *presenceTracker) disconnect(conn *WSConn) []PresenceEvent {
+	now := time.Now()
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var events []PresenceEvent
+	for room := range p.joined[conn] {
+		if event := p.leaveLocked(conn, room, now); event != nil {
+			events = append(events, *event)
+		}
+	}
+	delete(p.joined, conn)
+	return events
+}
+
+// leaveLocked removes conn from room; p.mu must be held
+func (p *presenceTracker) leaveLocked(conn *WSConn, room string, now time.Time) *PresenceEvent {
+	identity, ok := p.joined[conn][room]
+	if !ok {
+		return nil
+	}
+	delete(p.joined[conn], room)
+	if len(p.joined[conn]) == 0 {
+		delete(p.joined, conn)
+	}
+
+	presence := p.rooms[room][identity]
+	delete(presence.conns, conn)
+	if len(presence.conns) > 0 {
+		return nil
+	}
+	delete(p.rooms[room], identity)
+	if len(p.rooms[room]) == 0 {
+		delete(p.rooms, room)
+	}
+	p.lastSeen[identity] = now
+	return &PresenceEvent{Action: PresenceLeave, Room: room, Identity: identity, At: now}
+}
+
+// entries lists the identities online in room, sorted by identity
+func (p *presenceTracker) entries(room string) []PresenceEntry {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	entries := make([]PresenceEntry, 0, len(p.rooms[room]))
+	for identity, presence := range p.rooms[room] {
+		entry := PresenceEntry{Identity: identity, Connections: len(presence.conns), JoinedAt: presence.joinedAt}
+		for conn := range presence.conns {
+			if seen := conn.LastSeen(); seen.After(entry.LastSeen) {
+				entry.LastSeen = seen
+			}
+		}
+		entries = append(entries, entry)
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Identity < entries[j].Identity })
+	return entries
+}
+
+// seen returns when identity was last active, and whether it is online
+func (p *presenceTracker) seen(identity string) (time.Time, bool) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	var last time.Time
+	online := false
+	for conn, rooms := range p.joined {
+		for _, id := range rooms {
+			if id == identity {
+				online = true
+				if seen := conn.LastSeen(); seen.After(last) {
+					last = seen
+				}
+				break
+			}
+		}
+	}
+	if !online {
+		last = p.lastSeen[identity]
+	}
+	return last, online
+}
+
+// --- WSHub API ---
+
+// Presence returns the identities online in room, one entry per identity
+// however many connections it has there:
+//
+//	cfg := poltergeist.DefaultWSConfig()
+//	cfg.OnConnect = func(conn *poltergeist.WSConn) {
+//	    user, _ := conn.Get(poltergeist.ContextKeyPrincipal)
+//	    conn.SetIdentity(user.(*User).ID)
+//	    hub.JoinRoom(conn, "lobby")
+//	}
+//	app.WebSocketWithHub("/ws", hub, nil, cfg)
+//	app.GET("/rooms/:room/online", func(c *poltergeist.Context) error {
+//	    return c.JSON(200, hub.Presence(c.Param("room")))
+//	})
+func (h *WSHub) Presence(room string) []PresenceEntry {
+	return h.presence.entries(room)
+}
+
+// LastSeen returns when identity was last active: its latest message while
+// online, or when its last connection left a room. online reports whether
+// it is in any room now; a zero time means it was never seen.
+func (h *WSHub) LastSeen(identity string) (at time.Time, online bool) {
+	return h.presence.seen(identity)
+}
+
+// OnPresence registers a callback for presence joins and leaves; callbacks
+// run synchronously on the goroutine that joined, left or unregistered
+func (h *WSHub) OnPresence(callback func(event PresenceEvent)) {
+	h.presence.mu.Lock()
+	h.presence.hooks = append(h.presence.hooks, callback)
+	h.presence.mu.Unlock()
+}
+
+// EnablePresenceEvents makes the hub send {"event": "presence:join" or
+// "presence:leave", "data": PresenceEvent} to a room's connections when an
+// identity joins or leaves it
+func (h *WSHub) EnablePresenceEvents() {
+	h.presence.mu.Lock()
+	h.presence.broadcast = true
+	h.presence.mu.Unlock()
+}
+
+// emitPresence runs the presence callbacks and broadcasts for events
+func (h *WSHub) emitPresence(events ...PresenceEvent) {
+	if len(events) == 0 {
+		return
+	}
+	h.presence.mu.RLock()
+	hooks, broadcast := h.presence.hooks, h.presence.broadcast
+	h.presence.mu.RUnlock()
+
+	for _, event := range events {
+		for _, hook := range hooks {
+			hook(event)
+		}
+		if broadcast {
+			h.BroadcastJSONToRoom(event.Room, wsEnvelope{Event: "presence:" + string(event.Action), Data: event})
+		}
+	}
+}
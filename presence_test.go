@@ -0,0 +1,89 @@
+package poltergeist
+
+import (
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// =============================================================================
+// PRESENCE TESTS
+// =============================================================================
+
+func TestWSHub_Presence(t *testing.T) {
+	hub := NewWSHub()
+	go hub.Run()
+	defer hub.ShutdownWithTimeout(time.Second)
+
+	var mu sync.Mutex
+	var events []string
+	hub.OnPresence(func(e PresenceEvent) {
+		mu.Lock()
+		events = append(events, string(e.Action)+":"+e.Room+":"+e.Identity)
+		mu.Unlock()
+	})
+
+	app := New()
+	cfg := DefaultWSConfig()
+	cfg.OnConnect = func(conn *WSConn) {
+		conn.SetIdentity(conn.ctx.Query("user"))
+		hub.JoinRoom(conn, "lobby")
+	}
+	app.WebSocketWithHub("/ws", hub, nil, cfg)
+	server := httptest.NewServer(app.Router())
+	defer server.Close()
+	url := "ws" + strings.TrimPrefix(server.URL, "http") + "/ws?user="
+
+	dial := func(user string) *websocket.Conn {
+		conn, _, err := websocket.DefaultDialer.Dial(url+user, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return conn
+	}
+	alice1, alice2, bob := dial("alice"), dial("alice"), dial("bob")
+	defer alice2.Close()
+	defer bob.Close()
+
+	waitFor(t, func() bool { return len(hub.Presence("lobby")) == 2 })
+	entries := hub.Presence("lobby")
+	if entries[0].Identity != "alice" || entries[0].Connections != 2 || entries[1].Identity != "bob" {
+		t.Errorf("presence = %+v", entries)
+	}
+
+	// One of alice's connections leaving keeps her online
+	alice1.Close()
+	waitFor(t, func() bool { return hub.Presence("lobby")[0].Connections == 1 })
+	if _, online := hub.LastSeen("alice"); !online {
+		t.Error("alice should still be online")
+	}
+
+	bob.Close()
+	waitFor(t, func() bool { return len(hub.Presence("lobby")) == 1 })
+	if at, online := hub.LastSeen("bob"); online || at.IsZero() {
+		t.Errorf("bob last seen = %v, online %v", at, online)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	want := "join:lobby:alice join:lobby:bob leave:lobby:bob"
+	if got := strings.Join(events, " "); got != want {
+		t.Errorf("events = %q, want %q", got, want)
+	}
+}
+
+// waitFor polls cond for up to two seconds
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met in time")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
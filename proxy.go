@@ -0,0 +1,143 @@
+package poltergeist
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// =============================================================================
+// TRUSTED PROXIES - Client IP resolution behind load balancers
+// =============================================================================
+
+// TrustedProxies is a set of proxy networks whose forwarding headers
+// (X-Forwarded-For, X-Real-IP) are believed. A nil set trusts nobody.
+type TrustedProxies struct {
+	nets []*net.IPNet
+}
+
+// ParseTrustedProxies parses CIDRs ("10.0.0.0/8") and single addresses
+// ("192.168.1.10", "::1")
+func ParseTrustedProxies(cidrs ...string) (*TrustedProxies, error) {
+	t := &TrustedProxies{}
+	for _, cidr := range cidrs {
+		cidr = strings.TrimSpace(cidr)
+		if !strings.Contains(cidr, "/") {
+			ip := net.ParseIP(cidr)
+			if ip == nil {
+				return nil, fmt.Errorf("poltergeist: invalid trusted proxy %q", cidr)
+			}
+			bits := 8 * net.IPv6len
+			if ip4 := ip.To4(); ip4 != nil {
+				ip, bits = ip4, 8*net.IPv4len
+			}
+			t.nets = append(t.nets, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return nil, fmt.Errorf("poltergeist: invalid trusted proxy %q: %w", cidr, err)
+		}
+		t.nets = append(t.nets, network)
+	}
+	return t, nil
+}
+
+// Contains reports whether ip belongs to a trusted proxy
+func (t *TrustedProxies) Contains(ip net.IP) bool {
+	if t == nil || ip == nil {
+		return false
+	}
+	for _, network := range t.nets {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// ClientIP resolves the client address of req. Forwarding headers are only
+// read when the connecting peer is trusted; X-Forwarded-For is walked from
+// the right, skipping trusted hops, so clients can't spoof the result by
+// sending the header themselves. X-Real-IP is used when X-Forwarded-For is
+// absent.
+func (t *TrustedProxies) ClientIP(req *http.Request) string {
+	peer := remoteIP(req.RemoteAddr)
+	if !t.Contains(net.ParseIP(peer)) {
+		return peer
+	}
+
+	if forwarded := req.Header.Values(HeaderXForwardedFor); len(forwarded) > 0 {
+		hops := strings.Split(strings.Join(forwarded, ","), ",")
+		client := peer
+		for i := len(hops) - 1; i >= 0; i-- {
+			ip := parseForwardedIP(hops[i])
+			if ip == nil {
+				break // malformed hop: keep the last address we could verify
+			}
+			client = ip.String()
+			if !t.Contains(ip) {
+				break
+			}
+		}
+		return client
+	}
+	if ip := parseForwardedIP(req.Header.Get(HeaderXRealIP)); ip != nil {
+		return ip.String()
+	}
+	return peer
+}
+
+// remoteIP strips the port from a RemoteAddr
+func remoteIP(addr string) string {
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		return host
+	}
+	return addr
+}
+
+// parseForwardedIP parses a forwarding header entry, tolerating ports and
+// IPv6 brackets
+func parseForwardedIP(s string) net.IP {
+	s = strings.TrimSpace(s)
+	if ip := net.ParseIP(s); ip != nil {
+		return ip
+	}
+	if host, _, err := net.SplitHostPort(s); err == nil {
+		return net.ParseIP(host)
+	}
+	return net.ParseIP(strings.Trim(s, "[]"))
+}
+
+// SetTrustedProxies sets the proxies whose forwarding headers ClientIP
+// believes, replacing any previous list
+func (r *Router) SetTrustedProxies(cidrs ...string) error {
+	proxies, err := ParseTrustedProxies(cidrs...)
+	if err != nil {
+		return err
+	}
+	r.trustedProxies = proxies
+	return nil
+}
+
+// =============================================================================
+// SERVER & CONTEXT INTEGRATION
+// =============================================================================
+
+// SetTrustedProxies sets the proxies whose forwarding headers ClientIP
+// believes (see Config.TrustedProxies)
+func (s *Server) SetTrustedProxies(cidrs ...string) error {
+	return s.router.SetTrustedProxies(cidrs...)
+}
+
+// ClientIP returns the client's IP address. Behind a load balancer, list it
+// in Config.TrustedProxies; otherwise forwarding headers are ignored and the
+// connecting peer's address is returned.
+func (c *Context) ClientIP() string {
+	var proxies *TrustedProxies
+	if c.router != nil {
+		proxies = c.router.trustedProxies
+	}
+	return proxies.ClientIP(c.Request)
+}
@@ -0,0 +1,69 @@
+package poltergeist
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+// =============================================================================
+// TRUSTED PROXY TESTS
+// =============================================================================
+
+func TestContext_ClientIP(t *testing.T) {
+	tests := []struct {
+		name    string
+		trusted []string
+		remote  string
+		xff     []string
+		realIP  string
+		want    string
+	}{
+		{"no proxies trusted ignores headers", nil, "203.0.113.9:4000", []string{"1.1.1.1"}, "2.2.2.2", "203.0.113.9"},
+		{"untrusted peer ignores headers", []string{"10.0.0.0/8"}, "203.0.113.9:4000", []string{"1.1.1.1"}, "", "203.0.113.9"},
+		{"trusted peer uses forwarded client", []string{"10.0.0.0/8"}, "10.0.0.2:4000", []string{"198.51.100.7"}, "", "198.51.100.7"},
+		{"spoofed leftmost entry is skipped", []string{"10.0.0.0/8"}, "10.0.0.2:4000", []string{"6.6.6.6, 198.51.100.7, 10.0.0.5"}, "", "198.51.100.7"},
+		{"multiple header lines", []string{"10.0.0.0/8"}, "10.0.0.2:4000", []string{"6.6.6.6", "198.51.100.7"}, "", "198.51.100.7"},
+		{"all hops trusted", []string{"10.0.0.0/8"}, "10.0.0.2:4000", []string{"10.1.1.1, 10.0.0.5"}, "", "10.1.1.1"},
+		{"malformed hop stops the walk", []string{"10.0.0.0/8"}, "10.0.0.2:4000", []string{"198.51.100.7, junk, 10.0.0.5"}, "", "10.0.0.5"},
+		{"entries with ports", []string{"10.0.0.1"}, "10.0.0.1:4000", []string{"198.51.100.7:5555"}, "", "198.51.100.7"},
+		{"x-real-ip fallback", []string{"10.0.0.0/8"}, "10.0.0.2:4000", nil, "198.51.100.8", "198.51.100.8"},
+		{"ipv6 peer and client", []string{"::1"}, "[::1]:4000", []string{"[2001:db8::1]:443"}, "", "2001:db8::1"},
+		{"trusted peer without headers", []string{"10.0.0.0/8"}, "10.0.0.2:4000", nil, "", "10.0.0.2"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := NewWithConfig(&Config{TrustedProxies: tt.trusted})
+			var got string
+			app.GET("/", func(c *Context) error {
+				got = c.ClientIP()
+				return nil
+			})
+			req := httptest.NewRequest("GET", "/", nil)
+			req.RemoteAddr = tt.remote
+			for _, v := range tt.xff {
+				req.Header.Add(HeaderXForwardedFor, v)
+			}
+			if tt.realIP != "" {
+				req.Header.Set(HeaderXRealIP, tt.realIP)
+			}
+			app.ServeHTTP(httptest.NewRecorder(), req)
+			if got != tt.want {
+				t.Errorf("ClientIP() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseTrustedProxies(t *testing.T) {
+	if _, err := ParseTrustedProxies("10.0.0.0/8", "192.168.1.1", "fd00::/8"); err != nil {
+		t.Errorf("valid list error = %v", err)
+	}
+	for _, bad := range []string{"10.0.0.0/33", "proxy.local"} {
+		if _, err := ParseTrustedProxies(bad); err == nil {
+			t.Errorf("ParseTrustedProxies(%q) succeeded", bad)
+		}
+	}
+	if err := New().SetTrustedProxies("nope"); err == nil {
+		t.Error("SetTrustedProxies accepted an invalid entry")
+	}
+}
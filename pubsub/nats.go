@@ -0,0 +1,176 @@
+// Package pubsub provides poltergeist.HubBackend implementations, so WSHub
+// broadcasts reach the connections of every replica of an app:
+//
+//	nc, _ := nats.Connect(nats.DefaultURL)
+//	hub := poltergeist.NewWSHub()
+//	hub.UseBackend(pubsub.NewNATS(nc, "myapp.ws"))
+package pubsub
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nats-io/nats.go"
+
+	"github.com/gofuckbiz/poltergeist"
+)
+
+// =============================================================================
+// NATS BACKEND - Core pub/sub, one subject per room
+// =============================================================================
+
+// DefaultNATSPrefix is the subject prefix used when none is given
+const DefaultNATSPrefix = "poltergeist.ws"
+
+// NATS relays hub broadcasts through NATS core pub/sub. Hub-wide broadcasts
+// go to "<prefix>.all" and room broadcasts to "<prefix>.room.<room>", so an
+// instance only receives the rooms its connections are in. Room names are
+// escaped into a single subject token ("%2E" for ".", "%2A" for "*", etc.).
+//
+// Reconnects are handled by the NATS client: subscriptions are restored
+// and messages published during an outage are buffered up to
+// nats.ReconnectBufSize. Messages published to other instances while this
+// one is disconnected are missed, as with any core NATS subscriber.
+type NATS struct {
+	conn   *nats.Conn
+	prefix string
+	owned  bool // conn was opened by ConnectNATS and is closed with the backend
+
+	mu   sync.Mutex
+	subs map[string]*nats.Subscription // channel -> subscription
+}
+
+// NewNATS creates a NATS backend on an existing connection; prefix
+// namespaces the subjects, e.g. "myapp.ws" (default DefaultNATSPrefix).
+// The connection's reconnect options apply and it stays open when the
+// backend is closed.
+func NewNATS(conn *nats.Conn, prefix string) *NATS {
+	if prefix == "" {
+		prefix = DefaultNATSPrefix
+	}
+	return &NATS{conn: conn, prefix: strings.TrimSuffix(prefix, "."), subs: make(map[string]*nats.Subscription)}
+}
+
+// ConnectNATS connects to the NATS servers at url (comma-separated) and
+// creates a backend owning the connection. It reconnects forever, every
+// second, and starts even while the servers are unreachable, so a NATS
+// outage degrades the hub to local delivery instead of failing it; options
+// are applied after these defaults and may override them.
+//
+//	backend, err := pubsub.ConnectNATS("nats://nats-1:4222,nats://nats-2:4222", "myapp.ws",
+//	    nats.UserCredentials("hub.creds"))
+func ConnectNATS(url, prefix string, options ...nats.Option) (*NATS, error) {
+	defaults := []nats.Option{
+		nats.Name("poltergeist hub"),
+		nats.MaxReconnects(-1),
+		nats.ReconnectWait(time.Second),
+		nats.RetryOnFailedConnect(true),
+	}
+	conn, err := nats.Connect(url, append(defaults, options...)...)
+	if err != nil {
+		return nil, err
+	}
+	n := NewNATS(conn, prefix)
+	n.owned = true
+	return n, nil
+}
+
+// Publish sends payload to the channel's subject
+func (n *NATS) Publish(ctx context.Context, channel string, payload []byte) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return n.conn.Publish(n.subject(channel), payload)
+}
+
+// Subscribe delivers the messages of the channel's subject to handler,
+// replacing any previous subscription to channel
+func (n *NATS) Subscribe(channel string, handler func(payload []byte)) error {
+	sub, err := n.conn.Subscribe(n.subject(channel), func(msg *nats.Msg) {
+		handler(msg.Data)
+	})
+	if err != nil {
+		return err
+	}
+	n.mu.Lock()
+	previous := n.subs[channel]
+	n.subs[channel] = sub
+	n.mu.Unlock()
+	if previous != nil {
+		return previous.Unsubscribe()
+	}
+	return nil
+}
+
+// Unsubscribe stops the deliveries of channel
+func (n *NATS) Unsubscribe(channel string) error {
+	n.mu.Lock()
+	sub, ok := n.subs[channel]
+	delete(n.subs, channel)
+	n.mu.Unlock()
+	if !ok {
+		return nil
+	}
+	return sub.Unsubscribe()
+}
+
+// Close removes every subscription, and closes the connection if it was
+// opened by ConnectNATS
+func (n *NATS) Close() error {
+	n.mu.Lock()
+	subs := n.subs
+	n.subs = make(map[string]*nats.Subscription)
+	n.mu.Unlock()
+
+	var firstErr error
+	for _, sub := range subs {
+		if err := sub.Unsubscribe(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	if n.owned {
+		n.conn.Close()
+	}
+	return firstErr
+}
+
+// Conn returns the underlying NATS connection
+func (n *NATS) Conn() *nats.Conn {
+	return n.conn
+}
+
+// subject maps a hub channel to its NATS subject
+func (n *NATS) subject(channel string) string {
+	if room, ok := strings.CutPrefix(channel, poltergeist.HubChannelRoomPrefix); ok {
+		return n.prefix + ".room." + escapeToken(room)
+	}
+	if channel == poltergeist.HubChannelAll {
+		return n.prefix + ".all"
+	}
+	return n.prefix + ".channel." + escapeToken(channel)
+}
+
+// escapeToken percent-encodes the bytes a NATS subject token can't hold,
+// and '%' itself; an empty name becomes "%"
+func escapeToken(name string) string {
+	if name == "" {
+		return "%"
+	}
+	const hex = "0123456789ABCDEF"
+	var b strings.Builder
+	for i := 0; i < len(name); i++ {
+		c := name[i]
+		if c <= ' ' || c == 0x7f || c == '.' || c == '*' || c == '>' || c == '%' {
+			b.WriteByte('%')
+			b.WriteByte(hex[c>>4])
+			b.WriteByte(hex[c&0xf])
+			continue
+		}
+		b.WriteByte(c)
+	}
+	return b.String()
+}
+
+var _ poltergeist.HubBackend = (*NATS)(nil)
@@ -0,0 +1,212 @@
+package pubsub
+
+import (
+	"context"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/nats-io/nats-server/v2/server"
+	"github.com/nats-io/nats.go"
+
+	"github.com/gofuckbiz/poltergeist"
+)
+
+// =============================================================================
+// NATS BACKEND TESTS
+// =============================================================================
+
+// runServer starts an in-process NATS server on a random port
+func runServer(t *testing.T) string {
+	t.Helper()
+	srv, err := server.NewServer(&server.Options{Host: "127.0.0.1", Port: -1, NoLog: true, NoSigs: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	go srv.Start()
+	if !srv.ReadyForConnections(5 * time.Second) {
+		t.Fatal("nats server not ready")
+	}
+	t.Cleanup(srv.Shutdown)
+	return srv.ClientURL()
+}
+
+func TestEscapeToken(t *testing.T) {
+	tests := []struct {
+		name, want string
+	}{
+		{"lobby", "lobby"},
+		{"", "%"},
+		{"a.b", "a%2Eb"},
+		{"*", "%2A"},
+		{">", "%3E"},
+		{"50%", "50%25"},
+		{"a b\tc", "a%20b%09c"},
+		{"é", "é"},
+	}
+	for _, tt := range tests {
+		if got := escapeToken(tt.name); got != tt.want {
+			t.Errorf("escapeToken(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestNATS_Subject(t *testing.T) {
+	n := NewNATS(nil, "app.ws.")
+	tests := []struct {
+		channel, want string
+	}{
+		{poltergeist.HubChannelAll, "app.ws.all"},
+		{poltergeist.HubChannelRoomPrefix + "lobby", "app.ws.room.lobby"},
+		{poltergeist.HubChannelRoomPrefix + "*", "app.ws.room.%2A"},
+		{poltergeist.HubChannelRoomPrefix + "all", "app.ws.room.all"},
+		{poltergeist.HubChannelRoomPrefix + "a.>", "app.ws.room.a%2E%3E"},
+	}
+	for _, tt := range tests {
+		if got := n.subject(tt.channel); got != tt.want {
+			t.Errorf("subject(%q) = %q, want %q", tt.channel, got, tt.want)
+		}
+	}
+}
+
+func TestNATS_PublishSubscribe(t *testing.T) {
+	url := runServer(t)
+	a, err := ConnectNATS(url, "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer a.Close()
+	b, err := ConnectNATS(url, "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer b.Close()
+
+	received := make(chan string, 10)
+	for _, channel := range []string{poltergeist.HubChannelAll, poltergeist.HubChannelRoomPrefix + "*"} {
+		channel := channel
+		if err := b.Subscribe(channel, func(payload []byte) { received <- channel + "=" + string(payload) }); err != nil {
+			t.Fatal(err)
+		}
+	}
+	b.Conn().Flush()
+
+	ctx := context.Background()
+	a.Publish(ctx, poltergeist.HubChannelRoomPrefix+"*", []byte("room"))
+	a.Publish(ctx, poltergeist.HubChannelRoomPrefix+"lobby", []byte("other room"))
+	a.Publish(ctx, poltergeist.HubChannelAll, []byte("all"))
+	var got []string
+	for len(got) < 2 {
+		select {
+		case msg := <-received:
+			got = append(got, msg)
+		case <-time.After(2 * time.Second):
+			t.Fatalf("received %v, timed out waiting for more", got)
+		}
+	}
+	// Subjects are delivered independently, so only the set is known
+	sort.Strings(got)
+	if strings.Join(got, ",") != "*=all,room:*=room" {
+		t.Errorf("received %v, want *=all and room:*=room", got)
+	}
+
+	b.Unsubscribe(poltergeist.HubChannelAll)
+	b.Conn().Flush()
+	a.Publish(ctx, poltergeist.HubChannelAll, []byte("late"))
+	a.Conn().Flush()
+	select {
+	case got := <-received:
+		t.Errorf("received %s after Unsubscribe", got)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestNATS_HubRelay(t *testing.T) {
+	url := runServer(t)
+	type instance struct {
+		hub  *poltergeist.WSHub
+		conn *nats.Conn
+		url  string
+	}
+	instances := make([]instance, 2)
+	for i := range instances {
+		nc, err := nats.Connect(url)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer nc.Close()
+		hub := poltergeist.NewWSHub()
+		go hub.Run()
+		defer hub.ShutdownWithTimeout(time.Second)
+		if err := hub.UseBackend(NewNATS(nc, "relay")); err != nil {
+			t.Fatal(err)
+		}
+
+		app := poltergeist.New()
+		for path, room := range map[string]string{"/lobby": "lobby", "/star": "*"} {
+			room := room
+			cfg := poltergeist.DefaultWSConfig()
+			cfg.OnConnect = func(conn *poltergeist.WSConn) { hub.JoinRoom(conn, room) }
+			app.WebSocketWithHub(path, hub, nil, cfg)
+		}
+		server := httptest.NewServer(app.Router())
+		defer server.Close()
+		instances[i] = instance{hub, nc, "ws" + strings.TrimPrefix(server.URL, "http")}
+	}
+
+	dial := func(url string) *websocket.Conn {
+		conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+		return conn
+	}
+	// receive reads the next two messages, in any order, then checks none
+	// follows
+	receive := func(conn *websocket.Conn) string {
+		var got []string
+		for len(got) < 2 {
+			_, msg, err := conn.ReadMessage()
+			if err != nil {
+				t.Fatal(err)
+			}
+			got = append(got, string(msg))
+		}
+		sort.Strings(got)
+		conn.SetReadDeadline(time.Now().Add(100 * time.Millisecond))
+		if _, extra, err := conn.ReadMessage(); err == nil {
+			got = append(got, string(extra))
+		}
+		return strings.Join(got, ",")
+	}
+
+	remote := instances[1]
+	lobby := dial(remote.url + "/lobby")
+	defer lobby.Close()
+	star := dial(remote.url + "/star")
+	defer star.Close()
+	deadline := time.Now().Add(2 * time.Second)
+	for remote.hub.RoomCount("lobby") != 1 || remote.hub.RoomCount("*") != 1 {
+		if time.Now().After(deadline) {
+			t.Fatal("connections didn't join their rooms")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	remote.conn.Flush()
+
+	// A room named "*" is relayed as a room, not hub-wide
+	sender := instances[0].hub
+	sender.BroadcastToRoom("*", []byte("star"))
+	sender.BroadcastToRoom("lobby", []byte("lobby"))
+	sender.Broadcast([]byte("all"))
+	if got := receive(star); got != "all,star" {
+		t.Errorf("room * received %s, want all,star", got)
+	}
+	if got := receive(lobby); got != "all,lobby" {
+		t.Errorf("lobby received %s, want all,lobby", got)
+	}
+}
@@ -0,0 +1,158 @@
+// Package ratelimit provides shared middleware.RateLimitStore and
+// middleware.QuotaStore implementations, so rate limits and quotas hold
+// across every replica of an app:
+//
+//	app.Use(middleware.RateLimitWithConfig(&middleware.RateLimitConfig{
+//	    Rate:  middleware.PerMinute(100),
+//	    Store: ratelimit.NewRedis(redisClient, "myapp:rl:"),
+//	}))
+package ratelimit
+
+import (
+	"context"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/gofuckbiz/poltergeist/middleware"
+)
+
+// =============================================================================
+// REDIS STORE - GCRA in a Lua script
+// =============================================================================
+
+// gcraScript runs the generic cell rate algorithm atomically on the Redis
+// clock, so replicas with skewed clocks still agree. Times are in
+// microseconds; the key holds the theoretical arrival time and expires once
+// the quota has fully refilled.
+//
+// KEYS[1] = key, ARGV[1] = emission interval, ARGV[2] = capacity
+// Returns {allowed, remaining, reset after, retry after}
+var gcraScript = redis.NewScript(`
+local now = redis.call("TIME")
+now = tonumber(now[1]) * 1000000 + tonumber(now[2])
+local interval = tonumber(ARGV[1])
+local capacity = tonumber(ARGV[2])
+
+local tat = tonumber(redis.call("GET", KEYS[1]) or now)
+if tat < now then
+	tat = now
+end
+local new_tat = tat + interval
+local allow_at = new_tat - interval * capacity
+
+if now < allow_at then
+	return {0, 0, tat - now, allow_at - now}
+end
+-- %.0f keeps the microsecond timestamp out of scientific notation
+redis.call("SET", KEYS[1], string.format("%.0f", new_tat), "PX", math.max(1, math.ceil((new_tat - now) / 1000)))
+return {1, math.floor((now - allow_at) / interval), new_tat - now, 0}
+`)
+
+// slidingWindowScript runs middleware.SlidingWindow atomically on the
+// Redis clock, keeping the window index and both counters in a hash.
+//
+// KEYS[1] = key, ARGV[1] = period, ARGV[2] = limit
+// Returns {allowed, remaining, reset after, retry after}
+var slidingWindowScript = redis.NewScript(`
+local now = redis.call("TIME")
+now = tonumber(now[1]) * 1000000 + tonumber(now[2])
+local period = tonumber(ARGV[1])
+local limit = tonumber(ARGV[2])
+local window = math.floor(now / period)
+
+local state = redis.call("HMGET", KEYS[1], "w", "c", "p")
+local w = tonumber(state[1]) or -1
+local cur = tonumber(state[2]) or 0
+local prev = tonumber(state[3]) or 0
+if w == window - 1 then
+	prev = cur
+	cur = 0
+elseif w ~= window then
+	prev = 0
+	cur = 0
+end
+
+local elapsed = now - window * period
+local remaining_in_window = period - elapsed
+local used = prev * remaining_in_window / period + cur
+local allowed, remaining, retry = 0, 0, 0
+if used + 1 > limit then
+	if cur + 1 > limit then
+		retry = remaining_in_window + math.ceil(period * (1 - (limit - 1) / cur))
+	else
+		retry = math.ceil(period * (1 - (limit - cur - 1) / prev)) - elapsed
+	end
+else
+	cur = cur + 1
+	allowed = 1
+	remaining = math.floor(limit - used - 1)
+end
+
+local reset = remaining_in_window
+if cur > 0 then
+	reset = reset + period
+end
+redis.call("HSET", KEYS[1], "w", string.format("%.0f", window), "c", cur, "p", prev)
+redis.call("PEXPIRE", KEYS[1], math.max(1, math.ceil((remaining_in_window + period) / 1000)))
+return {allowed, remaining, reset, retry}
+`)
+
+// Redis is a rate limit store shared through Redis
+type Redis struct {
+	client redis.UniversalClient
+	prefix string
+}
+
+// NewRedis creates a Redis rate limit store on an existing client (single
+// node, cluster or sentinel); prefix namespaces the keys, e.g. "myapp:rl:"
+func NewRedis(client redis.UniversalClient, prefix string) *Redis {
+	return &Redis{client: client, prefix: prefix}
+}
+
+// Take decides a request for key with the rate's strategy
+func (r *Redis) Take(ctx context.Context, key string, rate middleware.Rate) (middleware.RateLimitResult, error) {
+	var cmd *redis.Cmd
+	if rate.Strategy == middleware.StrategySlidingWindow {
+		// Separate keys: the two strategies store different types
+		cmd = slidingWindowScript.Run(ctx, r.client, []string{r.prefix + "sw:" + key}, max(rate.Period.Microseconds(), 1), rate.Limit)
+	} else {
+		cmd = gcraScript.Run(ctx, r.client, []string{r.prefix + key}, max(rate.Interval().Microseconds(), 1), rate.Capacity())
+	}
+	values, err := cmd.Int64Slice()
+	if err != nil {
+		return middleware.RateLimitResult{}, err
+	}
+	return middleware.RateLimitResult{
+		Allowed:    values[0] == 1,
+		Limit:      rate.Capacity(),
+		Remaining:  int(values[1]),
+		ResetAfter: time.Duration(values[2]) * time.Microsecond,
+		RetryAfter: time.Duration(values[3]) * time.Microsecond,
+	}, nil
+}
+
+// Increment counts a request toward a middleware.QuotaLimit quota; the
+// counter expires when its period ends
+func (r *Redis) Increment(ctx context.Context, key string, expiresAt time.Time) (int64, error) {
+	var incr *redis.IntCmd
+	_, err := r.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		incr = pipe.Incr(ctx, r.prefix+"quota:"+key)
+		pipe.ExpireAt(ctx, r.prefix+"quota:"+key, expiresAt)
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return incr.Val(), nil
+}
+
+// Client returns the underlying Redis client
+func (r *Redis) Client() redis.UniversalClient {
+	return r.client
+}
+
+var (
+	_ middleware.RateLimitStore = (*Redis)(nil)
+	_ middleware.QuotaStore     = (*Redis)(nil)
+)
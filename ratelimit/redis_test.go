@@ -0,0 +1,136 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+
+	"github.com/gofuckbiz/poltergeist/middleware"
+)
+
+// =============================================================================
+// REDIS STORE TESTS
+// =============================================================================
+
+// newTestRedis returns a store on an in-process Redis whose clock the test sets
+func newTestRedis(t *testing.T) (*Redis, *miniredis.Miniredis) {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { client.Close() })
+	return NewRedis(client, "test:"), mr
+}
+
+// The scripts must decide exactly like the Go implementations the memory
+// store uses, so limits don't change when an app moves to Redis
+func TestRedis_MatchesGoAlgorithms(t *testing.T) {
+	// A whole minute, so windows line up with the offsets below
+	base := time.Unix(1_700_000_040, 0)
+
+	tests := []struct {
+		name    string
+		rate    middleware.Rate
+		offsets []time.Duration
+	}{
+		{
+			name: "gcra",
+			rate: middleware.PerSecond(10).WithBurst(3),
+			offsets: []time.Duration{
+				0, 0, 0, 0, 50 * time.Millisecond, 100 * time.Millisecond, 100 * time.Millisecond,
+				150 * time.Millisecond, 175 * time.Millisecond, time.Second, 5 * time.Second,
+			},
+		},
+		{
+			name: "sliding window",
+			rate: middleware.PerMinute(5).SlidingWindow(),
+			offsets: []time.Duration{
+				0, time.Second, 2 * time.Second, 3 * time.Second, 4 * time.Second, 5 * time.Second,
+				30 * time.Second, 59 * time.Second, 61 * time.Second, 75 * time.Second, 90 * time.Second,
+				105 * time.Second, 150 * time.Second, 5 * time.Minute,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store, mr := newTestRedis(t)
+			var tat time.Time
+			var window middleware.WindowState
+			var denied int
+
+			for i, offset := range tt.offsets {
+				now := base.Add(offset)
+				mr.SetTime(now)
+				got, err := store.Take(context.Background(), "client", tt.rate)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				var want middleware.RateLimitResult
+				if tt.rate.Strategy == middleware.StrategySlidingWindow {
+					want, window = middleware.SlidingWindow(now, window, tt.rate)
+				} else {
+					want, tat = middleware.GCRA(now, tat, tt.rate)
+				}
+				if got.Allowed != want.Allowed || got.Limit != want.Limit || got.Remaining != want.Remaining ||
+					!closeTo(got.ResetAfter, want.ResetAfter) || !closeTo(got.RetryAfter, want.RetryAfter) {
+					t.Errorf("request %d at +%v: Redis %+v, Go %+v", i+1, offset, got, want)
+				}
+				if !got.Allowed {
+					denied++
+				}
+			}
+			if denied == 0 {
+				t.Error("no request was denied; the offsets don't exercise the limit")
+			}
+		})
+	}
+}
+
+// closeTo allows for the scripts rounding to microseconds
+func closeTo(a, b time.Duration) bool {
+	d := a - b
+	return d > -time.Microsecond && d < time.Microsecond
+}
+
+func TestRedis_KeysExpire(t *testing.T) {
+	store, mr := newTestRedis(t)
+	ctx := context.Background()
+	mr.SetTime(time.Unix(1_700_000_040, 0))
+
+	store.Take(ctx, "a", middleware.PerSecond(10))
+	store.Take(ctx, "a", middleware.PerMinute(10).SlidingWindow())
+	if !mr.Exists("test:a") || !mr.Exists("test:sw:a") {
+		t.Fatalf("keys = %v, want test:a and test:sw:a", mr.Keys())
+	}
+	if ttl := mr.TTL("test:a"); ttl <= 0 || ttl > time.Second {
+		t.Errorf("GCRA key TTL = %v, want until the quota refills", ttl)
+	}
+	if ttl := mr.TTL("test:sw:a"); ttl <= time.Minute || ttl > 2*time.Minute {
+		t.Errorf("sliding window key TTL = %v, want until the next window ends", ttl)
+	}
+
+	mr.FastForward(2 * time.Minute)
+	if len(mr.Keys()) != 0 {
+		t.Errorf("keys = %v after the quotas refilled, want none", mr.Keys())
+	}
+}
+
+func TestRedis_Increment(t *testing.T) {
+	store, mr := newTestRedis(t)
+	ctx := context.Background()
+	now := time.Unix(1_700_000_040, 0)
+	mr.SetTime(now)
+
+	for want := int64(1); want <= 3; want++ {
+		count, err := store.Increment(ctx, "user", now.Add(time.Hour))
+		if err != nil || count != want {
+			t.Fatalf("Increment = %d, %v; want %d", count, err, want)
+		}
+	}
+	if ttl := mr.TTL("test:quota:user"); ttl != time.Hour {
+		t.Errorf("quota TTL = %v, want the end of the period", ttl)
+	}
+}
@@ -0,0 +1,123 @@
+package poltergeist
+
+import (
+	"bytes"
+	"encoding/json"
+	"regexp"
+)
+
+// =============================================================================
+// JSON RENDERING - Pretty-printed, secure-prefixed and JSONP variants
+// =============================================================================
+
+// JSONConfig holds server-wide JSON rendering options
+type JSONConfig struct {
+	Indent       string // Indent every c.JSON response with this string, e.g. "  " (default: "", compact)
+	Secure       bool   // Prefix every c.JSON response with SecurePrefix (default: false)
+	SecurePrefix string // Anti-hijacking prefix written by SecureJSON (default: "while(1);")
+}
+
+// DefaultJSONConfig returns default JSON rendering configuration
+func DefaultJSONConfig() *JSONConfig {
+	return &JSONConfig{
+		SecurePrefix: "while(1);",
+	}
+}
+
+// jsonpCallback matches safe JSONP callback names such as "cb" or "app.onData"
+var jsonpCallback = regexp.MustCompile(`^[A-Za-z_$][\w$]*(\.[A-Za-z_$][\w$]*)*$`)
+
+// IndentedJSON sends a pretty-printed JSON response, e.g. for debugging
+// endpoints
+func (c *Context) IndentedJSON(code int, v any) error {
+	return c.renderJSON(code, v, "  ", "")
+}
+
+// SecureJSON sends a JSON response prefixed with the configured
+// anti-hijacking prefix ("while(1);" by default), which clients strip before
+// parsing. It guards legacy browsers against JSON array hijacking.
+func (c *Context) SecureJSON(code int, v any) error {
+	cfg := c.jsonConfig()
+	return c.renderJSON(code, v, cfg.Indent, cfg.SecurePrefix)
+}
+
+// JSONP sends v wrapped in a call to callback as JavaScript, for cross-origin
+// script-tag clients:
+//
+//	return c.JSONP(200, c.Query("callback"), data)
+//
+// An empty callback sends plain JSON; names that aren't JavaScript
+// identifiers (optionally dotted) are rejected with 400 to prevent injection.
+func (c *Context) JSONP(code int, callback string, v any) error {
+	if callback == "" {
+		return c.JSON(code, v)
+	}
+	if !jsonpCallback.MatchString(callback) {
+		return ErrBadRequest.WithMessage("invalid JSONP callback")
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	// The leading comment keeps the response from being read as a Flash file
+	var buf bytes.Buffer
+	buf.Grow(len(callback) + len(data) + 8)
+	buf.WriteString("/**/")
+	buf.WriteString(callback)
+	buf.WriteByte('(')
+	buf.Write(data)
+	buf.WriteString(");")
+	return c.writeResponse(code, ContentTypeJavaScript, buf.Bytes())
+}
+
+// renderJSON encodes v with indent and prefix before writing, so encoding
+// errors leave the response untouched
+func (c *Context) renderJSON(code int, v any, indent, prefix string) error {
+	var buf bytes.Buffer
+	buf.WriteString(prefix)
+	enc := json.NewEncoder(&buf)
+	enc.SetIndent("", indent)
+	if err := enc.Encode(v); err != nil {
+		return err
+	}
+	return c.writeResponse(code, ContentTypeJSON, buf.Bytes())
+}
+
+// jsonConfig returns the router's JSON options, or the defaults
+func (c *Context) jsonConfig() *JSONConfig {
+	if c.router != nil && c.router.jsonConfig != nil {
+		return c.router.jsonConfig
+	}
+	return defaultJSONConfig
+}
+
+// defaultJSONConfig is shared by contexts without configured options
+var defaultJSONConfig = DefaultJSONConfig()
+
+// SetJSONConfig sets server-wide JSON rendering options
+func (r *Router) SetJSONConfig(config *JSONConfig) *Router {
+	r.jsonConfig = getJSONConfig(config)
+	return r
+}
+
+// getJSONConfig fills unset fields with defaults
+func getJSONConfig(config *JSONConfig) *JSONConfig {
+	if config == nil {
+		return DefaultJSONConfig()
+	}
+	cfg := *config
+	if cfg.SecurePrefix == "" {
+		cfg.SecurePrefix = DefaultJSONConfig().SecurePrefix
+	}
+	return &cfg
+}
+
+// =============================================================================
+// SERVER INTEGRATION
+// =============================================================================
+
+// SetJSONConfig sets server-wide JSON rendering options (see Config.JSON)
+func (s *Server) SetJSONConfig(config *JSONConfig) *Server {
+	s.router.SetJSONConfig(config)
+	return s
+}
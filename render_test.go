@@ -0,0 +1,43 @@
+package poltergeist
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// =============================================================================
+// JSON RENDERING TESTS
+// =============================================================================
+
+func TestContext_JSONVariants(t *testing.T) {
+	data := H{"a": 1}
+	tests := []struct {
+		name   string
+		config *JSONConfig
+		render func(c *Context) error
+		code   int
+		ctype  string
+		body   string
+	}{
+		{"indented", nil, func(c *Context) error { return c.IndentedJSON(200, data) }, 200, ContentTypeJSON, "{\n  \"a\": 1\n}\n"},
+		{"secure default prefix", nil, func(c *Context) error { return c.SecureJSON(200, []int{1}) }, 200, ContentTypeJSON, "while(1);[1]\n"},
+		{"secure custom prefix", &JSONConfig{SecurePrefix: ")]}',\n"}, func(c *Context) error { return c.SecureJSON(200, []int{1}) }, 200, ContentTypeJSON, ")]}',\n[1]\n"},
+		{"jsonp", nil, func(c *Context) error { return c.JSONP(200, "app.onData", data) }, 200, ContentTypeJavaScript, `/**/app.onData({"a":1});`},
+		{"jsonp without callback", nil, func(c *Context) error { return c.JSONP(200, "", data) }, 200, ContentTypeJSON, "{\"a\":1}\n"},
+		{"jsonp injection", nil, func(c *Context) error { return c.JSONP(200, "alert(1);cb", data) }, 400, ContentTypeJSON, "{\"error\":\"invalid JSONP callback\"}\n"},
+		{"global indent", &JSONConfig{Indent: "\t"}, func(c *Context) error { return c.JSON(201, data) }, 201, ContentTypeJSON, "{\n\t\"a\": 1\n}\n"},
+		{"global secure", &JSONConfig{Secure: true}, func(c *Context) error { return c.JSON(200, data) }, 200, ContentTypeJSON, "while(1);{\"a\":1}\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := NewWithConfig(&Config{JSON: tt.config})
+			app.GET("/", tt.render)
+			w := httptest.NewRecorder()
+			app.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+			if w.Code != tt.code || w.Header().Get(HeaderContentType) != tt.ctype || w.Body.String() != tt.body {
+				t.Errorf("got %d %q %q, want %d %q %q", w.Code, w.Header().Get(HeaderContentType), w.Body.String(), tt.code, tt.ctype, tt.body)
+			}
+		})
+	}
+}
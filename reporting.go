@@ -0,0 +1,221 @@
+package poltergeist
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"runtime"
+	"sync/atomic"
+	"syscall"
+	"time"
+)
+
+// =============================================================================
+// REPORTING - Forwarding panics and server errors to alerting
+// =============================================================================
+
+// ReportLevel is the severity of a captured error or message
+type ReportLevel string
+
+// Report levels
+const (
+	ReportLevelFatal   ReportLevel = "fatal"
+	ReportLevelError   ReportLevel = "error"
+	ReportLevelWarning ReportLevel = "warning"
+	ReportLevelInfo    ReportLevel = "info"
+)
+
+// Report sources: where in the framework an error was captured
+const (
+	ReportSourcePanic   = "panic"   // recovered handler panic (Recovery middleware)
+	ReportSourceHandler = "handler" // handler error resolved to a 5xx
+	ReportSourceEvent   = "event"   // panicking EmitAsync handler
+	ReportSourceHub     = "hub"     // failed WebSocket/SSE hub delivery
+)
+
+// ReportDetails is context attached to a captured error or message
+type ReportDetails struct {
+	Level   ReportLevel       // default: error
+	Source  string            // one of the ReportSource constants, or your own
+	Request *http.Request     // request being served, if any
+	Route   string            // matched route pattern
+	Tags    map[string]string // indexed key/values
+	Extra   map[string]any    // unindexed data
+	Stack   string            // stack trace of a recovered panic
+}
+
+// Reporter sends errors and messages to an alerting service (see the
+// reporting package for Sentry)
+type Reporter interface {
+	CaptureError(err error, details *ReportDetails)
+	CaptureMessage(message string, details *ReportDetails)
+
+	// Flush waits until queued reports are sent, returning false on timeout
+	Flush(timeout time.Duration) bool
+}
+
+// ErrWSSendBufferFull is reported when a hub drops a WebSocket connection
+// that is not keeping up with broadcasts
+var ErrWSSendBufferFull = errors.New("websocket send buffer full")
+
+// PanicError wraps a recovered panic value
+type PanicError struct {
+	Value any
+	Stack string
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("panic: %v", e.Value)
+}
+
+// Unwrap returns the panic value when it is an error
+func (e *PanicError) Unwrap() error {
+	err, _ := e.Value.(error)
+	return err
+}
+
+// Panic returns the panic recovered by middleware.Recovery for this
+// request, with its stack trace, or nil
+func (c *Context) Panic() *PanicError {
+	if v, ok := c.Get(ContextKeyPanic); ok {
+		p, _ := v.(*PanicError)
+		return p
+	}
+	return nil
+}
+
+// =============================================================================
+// SERVER INTEGRATION
+// =============================================================================
+
+// reporterBox lets atomic.Value hold any Reporter implementation
+type reporterBox struct{ reporter Reporter }
+
+// reporterSlot holds the active reporter; nil means reporting is off
+type reporterSlot struct {
+	current atomic.Value // reporterBox
+}
+
+// get returns the active reporter or nil
+func (s *reporterSlot) get() Reporter {
+	if s == nil {
+		return nil
+	}
+	box, _ := s.current.Load().(reporterBox)
+	return box.reporter
+}
+
+// UseReporter sends recovered panics, 5xx handler errors, panicking async
+// event handlers and hub delivery failures to r. Queued reports are flushed
+// on shutdown.
+//
+//	sentry, err := reporting.NewSentry(&reporting.SentryConfig{DSN: os.Getenv("SENTRY_DSN")})
+//	app.UseReporter(sentry)
+func (s *Server) UseReporter(r Reporter) *Server {
+	if s.router.pipeline.reporter() == nil {
+		s.OnShutdown(func(ctx context.Context) error {
+			if r := s.Reporter(); r != nil {
+				timeout := s.config.ShutdownTimeout
+				if deadline, ok := ctx.Deadline(); ok {
+					timeout = time.Until(deadline)
+				}
+				if !r.Flush(timeout) {
+					return fmt.Errorf("reporter: flush timed out")
+				}
+			}
+			return nil
+		})
+	}
+	s.router.pipeline.reporting.current.Store(reporterBox{r})
+	return s
+}
+
+// Reporter returns the active reporter (nil when reporting is off)
+func (s *Server) Reporter() Reporter {
+	return s.router.pipeline.reporter()
+}
+
+// CaptureError reports err with the request and route attached; a no-op
+// when no reporter is configured
+func (c *Context) CaptureError(err error, details ...*ReportDetails) {
+	reporter := c.pipeline.reporter()
+	if reporter == nil || err == nil {
+		return
+	}
+	reporter.CaptureError(err, c.reportDetails(details))
+}
+
+// CaptureMessage reports a message with the request and route attached
+func (c *Context) CaptureMessage(message string, details ...*ReportDetails) {
+	reporter := c.pipeline.reporter()
+	if reporter == nil {
+		return
+	}
+	reporter.CaptureMessage(message, c.reportDetails(details))
+}
+
+// reportDetails fills request information into (a copy of) details
+func (c *Context) reportDetails(details []*ReportDetails) *ReportDetails {
+	d := &ReportDetails{}
+	if len(details) > 0 && details[0] != nil {
+		*d = *details[0]
+	}
+	if d.Level == "" {
+		d.Level = ReportLevelError
+	}
+	if d.Request == nil {
+		d.Request = c.Request
+	}
+	if d.Route == "" && c.route != nil {
+		d.Route = c.route.Path
+	}
+	return d
+}
+
+// --- Framework capture points ---
+
+// captureAsyncPanic recovers a panicking async event handler and reports it
+func (p *EventPipeline) captureAsyncPanic(event EventType) {
+	rec := recover()
+	if rec == nil {
+		return
+	}
+	stack := make([]byte, 4096)
+	stack = stack[:runtime.Stack(stack, false)]
+	p.logger().Error("async event handler panicked", "event", string(event), "panic", rec)
+
+	if reporter := p.reporter(); reporter != nil {
+		reporter.CaptureError(&PanicError{Value: rec, Stack: string(stack)}, &ReportDetails{
+			Level:  ReportLevelError,
+			Source: ReportSourceEvent,
+			Tags:   map[string]string{"event": string(event)},
+			Stack:  string(stack),
+		})
+	}
+}
+
+// clientGone reports whether a delivery failed because the client closed
+// its connection
+func clientGone(err error) bool {
+	return errors.Is(err, ErrWSClosed) || errors.Is(err, ErrSSEClosed) || errors.Is(err, net.ErrClosed) ||
+		errors.Is(err, syscall.EPIPE) || errors.Is(err, syscall.ECONNRESET)
+}
+
+// captureHubFailure logs and reports a failed hub delivery. Clients that
+// simply went away are expected and only logged at debug level.
+func captureHubFailure(p *EventPipeline, protocol, clientID string, err error) {
+	if clientGone(err) {
+		p.logger().Debug("hub client disconnected", "protocol", protocol, "client_id", clientID, "error", err)
+		return
+	}
+	p.logger().Warn("hub delivery failed", "protocol", protocol, "client_id", clientID, "error", err)
+	if reporter := p.reporter(); reporter != nil {
+		reporter.CaptureError(err, &ReportDetails{
+			Level:  ReportLevelWarning,
+			Source: ReportSourceHub,
+			Tags:   map[string]string{"protocol": protocol, "client_id": clientID},
+		})
+	}
+}
@@ -0,0 +1,472 @@
+// Package reporting provides a Sentry implementation of poltergeist.Reporter:
+//
+//	sentry, err := reporting.NewSentry(&reporting.SentryConfig{
+//	    DSN:         os.Getenv("SENTRY_DSN"),
+//	    Environment: "production",
+//	})
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	app.UseReporter(sentry)
+//
+// Events are queued and sent in the background over Sentry's envelope
+// endpoint, so capturing never blocks a request. Queued events are flushed
+// when the server shuts down.
+package reporting
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"reflect"
+	"runtime"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/gofuckbiz/poltergeist"
+)
+
+// =============================================================================
+// SENTRY CONFIGURATION
+// =============================================================================
+
+// SentryConfig holds Sentry reporter configuration
+type SentryConfig struct {
+	DSN         string        // https://<key>@<host>/<project> (required)
+	Environment string        // e.g. "production"
+	Release     string        // e.g. "api@1.4.2"
+	ServerName  string        // default: hostname
+	Timeout     time.Duration // HTTP timeout per event (default: 5s)
+	QueueSize   int           // events buffered before dropping (default: 100)
+	SampleRate  float64       // fraction of error events sent (default: 1)
+
+	// BeforeSend can scrub or drop (by returning nil) an event
+	BeforeSend func(event *SentryEvent) *SentryEvent
+
+	// Client overrides the HTTP client
+	Client *http.Client
+
+	// Logger receives send failures (default: slog.Default())
+	Logger poltergeist.Logger
+}
+
+// DefaultSentryConfig returns default Sentry configuration (DSN still required)
+func DefaultSentryConfig() *SentryConfig {
+	return &SentryConfig{
+		Timeout:    5 * time.Second,
+		QueueSize:  100,
+		SampleRate: 1,
+	}
+}
+
+// filteredHeaders are never sent to Sentry
+var filteredHeaders = map[string]bool{
+	"Authorization":       true,
+	"Cookie":              true,
+	"Proxy-Authorization": true,
+	"X-Api-Key":           true,
+	"X-Csrf-Token":        true,
+}
+
+// =============================================================================
+// SENTRY EVENT
+// =============================================================================
+
+// SentryEvent is the event payload sent to Sentry
+type SentryEvent struct {
+	EventID     string            `json:"event_id"`
+	Timestamp   time.Time         `json:"timestamp"`
+	Level       string            `json:"level"`
+	Platform    string            `json:"platform"`
+	Logger      string            `json:"logger,omitempty"`
+	ServerName  string            `json:"server_name,omitempty"`
+	Release     string            `json:"release,omitempty"`
+	Environment string            `json:"environment,omitempty"`
+	Transaction string            `json:"transaction,omitempty"`
+	Message     *SentryMessage    `json:"message,omitempty"`
+	Exception   *SentryExceptions `json:"exception,omitempty"`
+	Request     *SentryRequest    `json:"request,omitempty"`
+	Tags        map[string]string `json:"tags,omitempty"`
+	Extra       map[string]any    `json:"extra,omitempty"`
+	SDK         SentrySDK         `json:"sdk"`
+}
+
+// SentryMessage is a plain message event body
+type SentryMessage struct {
+	Formatted string `json:"formatted"`
+}
+
+// SentryExceptions lists an error chain, root cause first
+type SentryExceptions struct {
+	Values []SentryException `json:"values"`
+}
+
+// SentryException is one error in a chain
+type SentryException struct {
+	Type       string            `json:"type"`
+	Value      string            `json:"value"`
+	Stacktrace *SentryStacktrace `json:"stacktrace,omitempty"`
+}
+
+// SentryStacktrace lists frames, outermost call first
+type SentryStacktrace struct {
+	Frames []SentryFrame `json:"frames"`
+}
+
+// SentryFrame is one stack frame
+type SentryFrame struct {
+	Function string `json:"function"`
+	Module   string `json:"module,omitempty"`
+	AbsPath  string `json:"abs_path"`
+	Lineno   int    `json:"lineno"`
+	InApp    bool   `json:"in_app"`
+}
+
+// SentryRequest describes the HTTP request being served
+type SentryRequest struct {
+	URL         string            `json:"url"`
+	Method      string            `json:"method"`
+	QueryString string            `json:"query_string,omitempty"`
+	Headers     map[string]string `json:"headers,omitempty"`
+}
+
+// SentrySDK identifies the client
+type SentrySDK struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+// =============================================================================
+// SENTRY REPORTER
+// =============================================================================
+
+// Sentry reports errors to Sentry
+type Sentry struct {
+	config   *SentryConfig
+	endpoint string
+	auth     string
+	client   *http.Client
+
+	queue   chan *SentryEvent
+	pending atomic.Int64
+}
+
+// NewSentry creates a Sentry reporter and starts its sender
+func NewSentry(config ...*SentryConfig) (*Sentry, error) {
+	cfg := DefaultSentryConfig()
+	if len(config) > 0 && config[0] != nil {
+		cfg = config[0]
+		defaults := DefaultSentryConfig()
+		if cfg.Timeout <= 0 {
+			cfg.Timeout = defaults.Timeout
+		}
+		if cfg.QueueSize <= 0 {
+			cfg.QueueSize = defaults.QueueSize
+		}
+		if cfg.SampleRate <= 0 {
+			cfg.SampleRate = defaults.SampleRate
+		}
+	}
+	if cfg.Logger == nil {
+		cfg.Logger = slog.Default()
+	}
+	if cfg.ServerName == "" {
+		cfg.ServerName, _ = os.Hostname()
+	}
+
+	endpoint, auth, err := parseDSN(cfg.DSN)
+	if err != nil {
+		return nil, err
+	}
+
+	client := cfg.Client
+	if client == nil {
+		client = &http.Client{Timeout: cfg.Timeout}
+	}
+
+	s := &Sentry{
+		config:   cfg,
+		endpoint: endpoint,
+		auth:     auth,
+		client:   client,
+		queue:    make(chan *SentryEvent, cfg.QueueSize),
+	}
+	go s.run()
+	return s, nil
+}
+
+// parseDSN turns a DSN into the envelope endpoint and auth header
+func parseDSN(dsn string) (endpoint, auth string, err error) {
+	if dsn == "" {
+		return "", "", errors.New("sentry: DSN is required")
+	}
+	u, err := url.Parse(dsn)
+	if err != nil {
+		return "", "", fmt.Errorf("sentry: invalid DSN: %w", err)
+	}
+	key := u.User.Username()
+	path := strings.TrimSuffix(u.Path, "/")
+	slash := strings.LastIndex(path, "/")
+	project := path[slash+1:]
+	if key == "" || project == "" || u.Host == "" {
+		return "", "", errors.New("sentry: DSN must look like https://<key>@<host>/<project>")
+	}
+
+	endpoint = fmt.Sprintf("%s://%s%s/api/%s/envelope/", u.Scheme, u.Host, path[:slash], project)
+	auth = fmt.Sprintf("Sentry sentry_version=7, sentry_client=%s/%s, sentry_key=%s", sdkName, sdkVersion, key)
+	return endpoint, auth, nil
+}
+
+const (
+	sdkName    = "poltergeist.sentry"
+	sdkVersion = "1.0.0"
+)
+
+// CaptureError queues an error event with its unwrapped chain and the
+// caller's stack
+func (s *Sentry) CaptureError(err error, details *poltergeist.ReportDetails) {
+	if err == nil || !s.sampled() {
+		return
+	}
+	event := s.newEvent(details)
+	event.Exception = &SentryExceptions{Values: exceptionChain(err, callerStack(3))}
+	s.enqueue(event)
+}
+
+// CaptureMessage queues a message event
+func (s *Sentry) CaptureMessage(message string, details *poltergeist.ReportDetails) {
+	event := s.newEvent(details)
+	if details == nil || details.Level == "" {
+		event.Level = string(poltergeist.ReportLevelInfo)
+	}
+	event.Message = &SentryMessage{Formatted: message}
+	s.enqueue(event)
+}
+
+// Flush waits until every queued event has been sent
+func (s *Sentry) Flush(timeout time.Duration) bool {
+	deadline := time.Now().Add(timeout)
+	for s.pending.Load() > 0 {
+		if time.Now().After(deadline) {
+			return false
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	return true
+}
+
+// sampled applies the sample rate to error events
+func (s *Sentry) sampled() bool {
+	if s.config.SampleRate >= 1 {
+		return true
+	}
+	var b [2]byte
+	rand.Read(b[:])
+	return float64(uint16(b[0])<<8|uint16(b[1]))/65536 < s.config.SampleRate
+}
+
+// newEvent builds an event carrying the report details
+func (s *Sentry) newEvent(details *poltergeist.ReportDetails) *SentryEvent {
+	if details == nil {
+		details = &poltergeist.ReportDetails{}
+	}
+
+	event := &SentryEvent{
+		EventID:     newEventID(),
+		Timestamp:   time.Now().UTC(),
+		Level:       string(details.Level),
+		Platform:    "go",
+		Logger:      "poltergeist",
+		ServerName:  s.config.ServerName,
+		Release:     s.config.Release,
+		Environment: s.config.Environment,
+		Tags:        make(map[string]string, len(details.Tags)+2),
+		Extra:       make(map[string]any, len(details.Extra)+1),
+		SDK:         SentrySDK{Name: sdkName, Version: sdkVersion},
+	}
+	if event.Level == "" {
+		event.Level = string(poltergeist.ReportLevelError)
+	}
+
+	for k, v := range details.Tags {
+		event.Tags[k] = v
+	}
+	for k, v := range details.Extra {
+		event.Extra[k] = v
+	}
+	if details.Source != "" {
+		event.Tags["source"] = details.Source
+	}
+	if details.Stack != "" {
+		event.Extra["stack"] = details.Stack
+	}
+
+	if r := details.Request; r != nil {
+		event.Request = sentryRequest(r)
+		event.Transaction = r.Method + " " + r.URL.Path
+	}
+	if details.Route != "" {
+		event.Tags["route"] = details.Route
+		if details.Request != nil {
+			event.Transaction = details.Request.Method + " " + details.Route
+		}
+	}
+	return event
+}
+
+// enqueue hands an event to the sender, dropping it when the queue is full
+func (s *Sentry) enqueue(event *SentryEvent) {
+	if s.config.BeforeSend != nil {
+		if event = s.config.BeforeSend(event); event == nil {
+			return
+		}
+	}
+
+	s.pending.Add(1)
+	select {
+	case s.queue <- event:
+	default:
+		s.pending.Add(-1)
+		s.config.Logger.Warn("sentry queue full, dropping event", "event_id", event.EventID)
+	}
+}
+
+// run sends queued events
+func (s *Sentry) run() {
+	for event := range s.queue {
+		if err := s.send(event); err != nil {
+			s.config.Logger.Error("sentry send failed", "event_id", event.EventID, "error", err)
+		}
+		s.pending.Add(-1)
+	}
+}
+
+// send posts one event as an envelope
+func (s *Sentry) send(event *SentryEvent) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("encode event: %w", err)
+	}
+
+	var body bytes.Buffer
+	fmt.Fprintf(&body, `{"event_id":%q,"sent_at":%q}`+"\n", event.EventID, time.Now().UTC().Format(time.RFC3339Nano))
+	fmt.Fprintf(&body, `{"type":"event","length":%d}`+"\n", len(payload))
+	body.Write(payload)
+	body.WriteByte('\n')
+
+	req, err := http.NewRequest(http.MethodPost, s.endpoint, &body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-sentry-envelope")
+	req.Header.Set("X-Sentry-Auth", s.auth)
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("send event: %w", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("send event: %s", resp.Status)
+	}
+	return nil
+}
+
+// =============================================================================
+// HELPERS
+// =============================================================================
+
+// newEventID returns a random 32-char hex id
+func newEventID() string {
+	var b [16]byte
+	rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
+
+// sentryRequest describes r without credentials
+func sentryRequest(r *http.Request) *SentryRequest {
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	req := &SentryRequest{
+		URL:         scheme + "://" + r.Host + r.URL.Path,
+		Method:      r.Method,
+		QueryString: r.URL.RawQuery,
+		Headers:     make(map[string]string, len(r.Header)),
+	}
+	for key, values := range r.Header {
+		if filteredHeaders[key] {
+			req.Headers[key] = "[Filtered]"
+			continue
+		}
+		req.Headers[key] = strings.Join(values, ", ")
+	}
+	return req
+}
+
+// exceptionChain unwraps err into Sentry exceptions, root cause first; the
+// stack is attached to the outermost error
+func exceptionChain(err error, stack *SentryStacktrace) []SentryException {
+	var chain []SentryException
+	for err != nil && len(chain) < 10 {
+		chain = append(chain, SentryException{
+			Type:  reflect.TypeOf(err).String(),
+			Value: err.Error(),
+		})
+		err = errors.Unwrap(err)
+	}
+	chain[0].Stacktrace = stack
+
+	for i, j := 0, len(chain)-1; i < j; i, j = i+1, j-1 {
+		chain[i], chain[j] = chain[j], chain[i]
+	}
+	return chain
+}
+
+// callerStack captures the stack above the capture call, outermost first
+func callerStack(skip int) *SentryStacktrace {
+	pcs := make([]uintptr, 64)
+	n := runtime.Callers(skip, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+
+	var out []SentryFrame
+	for {
+		frame, more := frames.Next()
+		module, function := splitFunction(frame.Function)
+		out = append(out, SentryFrame{
+			Function: function,
+			Module:   module,
+			AbsPath:  frame.File,
+			Lineno:   frame.Line,
+			InApp:    !strings.HasPrefix(module, "runtime") && !strings.Contains(frame.File, "/go/src/"),
+		})
+		if !more {
+			break
+		}
+	}
+
+	for i, j := 0, len(out)-1; i < j; i, j = i+1, j-1 {
+		out[i], out[j] = out[j], out[i]
+	}
+	return &SentryStacktrace{Frames: out}
+}
+
+// splitFunction splits "github.com/a/b.(*T).Method" into package and function
+func splitFunction(name string) (module, function string) {
+	slash := strings.LastIndex(name, "/")
+	dot := strings.Index(name[slash+1:], ".")
+	if dot < 0 {
+		return "", name
+	}
+	dot += slash + 1
+	return name[:dot], name[dot+1:]
+}
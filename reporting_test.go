@@ -0,0 +1,176 @@
+package poltergeist
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http/httptest"
+	"os"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// =============================================================================
+// REPORTING TESTS
+// =============================================================================
+
+type capturedReport struct {
+	err     error
+	message string
+	details *ReportDetails
+}
+
+// recordingReporter keeps everything it is asked to capture
+type recordingReporter struct {
+	mu      sync.Mutex
+	reports []capturedReport
+	flushed bool
+}
+
+func (r *recordingReporter) CaptureError(err error, details *ReportDetails) {
+	r.mu.Lock()
+	r.reports = append(r.reports, capturedReport{err: err, details: details})
+	r.mu.Unlock()
+}
+
+func (r *recordingReporter) CaptureMessage(message string, details *ReportDetails) {
+	r.mu.Lock()
+	r.reports = append(r.reports, capturedReport{message: message, details: details})
+	r.mu.Unlock()
+}
+
+func (r *recordingReporter) Flush(time.Duration) bool {
+	r.mu.Lock()
+	r.flushed = true
+	r.mu.Unlock()
+	return true
+}
+
+func (r *recordingReporter) snapshot() []capturedReport {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]capturedReport(nil), r.reports...)
+}
+
+func TestReporting_HandlerErrors(t *testing.T) {
+	reporter := &recordingReporter{}
+	app := New().UseReporter(reporter)
+
+	dbErr := errors.New("connection refused")
+	app.GET("/users/:id", func(c *Context) error { return dbErr })
+	app.GET("/missing", func(c *Context) error { return ErrNotFound })
+	app.GET("/unavailable", func(c *Context) error { return ErrServiceUnavailable })
+
+	for _, path := range []string{"/users/1", "/missing", "/unavailable", "/nowhere"} {
+		app.Router().ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", path, nil))
+	}
+
+	reports := reporter.snapshot()
+	if len(reports) != 2 {
+		t.Fatalf("got %d reports, want only the two 5xx errors", len(reports))
+	}
+	first := reports[0]
+	if first.err != dbErr || first.details.Source != ReportSourceHandler {
+		t.Errorf("report = %v from %q", first.err, first.details.Source)
+	}
+	if first.details.Route != "/users/:id" || first.details.Request == nil || first.details.Level != ReportLevelError {
+		t.Errorf("details = %+v, want route, request and error level", first.details)
+	}
+}
+
+func TestReporting_AsyncEventPanic(t *testing.T) {
+	reporter := &recordingReporter{}
+	app := New().UseReporter(reporter)
+
+	done := make(chan struct{})
+	app.Pipeline().On(EventAfterRequest, func(c *Context) {
+		defer close(done)
+		panic("boom")
+	})
+	app.Pipeline().EmitAsync(EventAfterRequest, NewContext(nil, nil))
+	<-done
+
+	deadline := time.Now().Add(2 * time.Second)
+	for len(reporter.snapshot()) == 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	reports := reporter.snapshot()
+	if len(reports) != 1 {
+		t.Fatalf("got %d reports, want 1", len(reports))
+	}
+	var panicErr *PanicError
+	if !errors.As(reports[0].err, &panicErr) || panicErr.Value != "boom" || panicErr.Stack == "" {
+		t.Errorf("report = %v, want the recovered panic with its stack", reports[0].err)
+	}
+	if reports[0].details.Tags["event"] != string(EventAfterRequest) {
+		t.Errorf("tags = %v", reports[0].details.Tags)
+	}
+}
+
+func TestReporting_FlushOnShutdown(t *testing.T) {
+	reporter := &recordingReporter{}
+	app := New().UseReporter(reporter).UseReporter(reporter)
+
+	if len(app.shutdownHooks) != 1 {
+		t.Errorf("registered %d flush hooks, want 1", len(app.shutdownHooks))
+	}
+	if err := app.Shutdown(context.Background()); err != nil || !reporter.flushed {
+		t.Errorf("shutdown = %v, flushed = %v", err, reporter.flushed)
+	}
+}
+
+func TestContext_Panic(t *testing.T) {
+	c := NewContext(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	if c.Panic() != nil {
+		t.Error("Panic() without a recovered panic should be nil")
+	}
+	p := &PanicError{Value: "boom", Stack: "goroutine 1"}
+	c.Set(ContextKeyPanic, p)
+	if c.Panic() != p {
+		t.Errorf("Panic() = %v, want the stored panic", c.Panic())
+	}
+}
+
+// failingStream is a streaming response whose writes fail with err
+type failingStream struct {
+	*httptest.ResponseRecorder
+	err error
+}
+
+func (w *failingStream) Write([]byte) (int, error) { return 0, w.err }
+
+func TestReporting_HubFailures(t *testing.T) {
+	reporter := &recordingReporter{}
+	app := New().UseReporter(reporter).UseLogger(NopLogger)
+	hub := NewSSEHub()
+	hub.attach(app.Pipeline())
+	go hub.Run()
+	defer hub.ShutdownWithTimeout(time.Second)
+
+	stream := func(err error) *SSEWriter {
+		w, e := newSSEWriter(&failingStream{httptest.NewRecorder(), err}, &SSEConfig{}, app.Pipeline(), nil)
+		if e != nil {
+			t.Fatal(e)
+		}
+		return w
+	}
+	closed := stream(nil)
+	closed.Close()
+	disconnected := stream(&net.OpError{Op: "write", Net: "tcp", Err: os.NewSyscallError("write", syscall.EPIPE)})
+	broken := stream(errors.New("disk on fire"))
+
+	hub.deliver(&SSEEvent{Data: "tick"}, []*SSEWriter{closed, disconnected, broken})
+
+	reports := reporter.snapshot()
+	if len(reports) != 1 || reports[0].err.Error() != "disk on fire" || reports[0].details.Source != ReportSourceHub {
+		t.Errorf("reports = %+v, want only the unexpected write error", reports)
+	}
+
+	captureHubFailure(app.Pipeline(), "websocket", "c1", ErrWSClosed)
+	captureHubFailure(app.Pipeline(), "websocket", "c2", ErrWSSendBufferFull)
+	if reports := reporter.snapshot(); len(reports) != 2 || reports[1].err != ErrWSSendBufferFull {
+		t.Errorf("reports = %+v, want the full send buffer reported", reports)
+	}
+}
@@ -0,0 +1,45 @@
+package poltergeist
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// =============================================================================
+// REQUEST CONTEXT - Cancellation and deadlines for downstream calls
+// =============================================================================
+
+// Context returns the request's context.Context, cancelled when the client
+// disconnects or the route timeout expires; pass it to database and HTTP
+// calls. Detached contexts (events, jobs) get context.Background().
+//
+//	rows, err := db.QueryContext(c.Context(), "SELECT ...")
+func (c *Context) Context() context.Context {
+	if c.Request != nil {
+		return c.Request.Context()
+	}
+	return context.Background()
+}
+
+// WithTimeout derives a context from the request's that also expires after
+// timeout, for bounding a single downstream call. Always call cancel.
+//
+//	ctx, cancel := c.WithTimeout(500 * time.Millisecond)
+//	defer cancel()
+//	user, err := users.Get(ctx, id)
+func (c *Context) WithTimeout(timeout time.Duration) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(c.Context(), timeout)
+}
+
+// Deadline returns when the request's context expires, e.g. a route Timeout;
+// ok is false when there is no deadline
+func (c *Context) Deadline() (deadline time.Time, ok bool) {
+	return c.Context().Deadline()
+}
+
+// clientConnected reports whether the client is still waiting for the
+// response (false once it disconnected)
+func clientConnected(c *Context) bool {
+	return c.Request == nil || !errors.Is(c.Request.Context().Err(), context.Canceled)
+}
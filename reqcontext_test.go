@@ -0,0 +1,79 @@
+package poltergeist
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// =============================================================================
+// REQUEST CONTEXT TESTS
+// =============================================================================
+
+func TestContext_RequestContext(t *testing.T) {
+	type key struct{}
+	req := httptest.NewRequest("GET", "/", nil)
+	req = req.WithContext(context.WithValue(req.Context(), key{}, "v"))
+	c := NewContext(httptest.NewRecorder(), req)
+
+	if c.Context().Value(key{}) != "v" {
+		t.Error("Context() is not the request context")
+	}
+	if _, ok := c.Deadline(); ok {
+		t.Error("Deadline() reported a deadline for a plain request")
+	}
+
+	ctx, cancel := c.WithTimeout(time.Minute)
+	defer cancel()
+	if deadline, ok := ctx.Deadline(); !ok || time.Until(deadline) > time.Minute {
+		t.Errorf("WithTimeout deadline = %v, %v", deadline, ok)
+	}
+	if ctx.Value(key{}) != "v" {
+		t.Error("WithTimeout did not derive from the request context")
+	}
+
+	if NewContext(nil, nil).Context() == nil {
+		t.Error("detached Context() = nil")
+	}
+}
+
+func TestContext_DeadlineFromRouteTimeout(t *testing.T) {
+	app := New()
+	var remaining time.Duration
+	app.GET("/slow", func(c *Context) error {
+		deadline, ok := c.Deadline()
+		if !ok {
+			t.Error("no deadline inside a timed route")
+		}
+		remaining = time.Until(deadline)
+		return c.NoContent()
+	}).Timeout(time.Second)
+
+	app.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/slow", nil))
+	if remaining <= 0 || remaining > time.Second {
+		t.Errorf("remaining = %v, want within the 1s route timeout", remaining)
+	}
+}
+
+func TestRouter_AfterRequestSkippedOnDisconnect(t *testing.T) {
+	app := New()
+	calls := 0
+	app.Pipeline().AfterRequest(func(c *Context) { calls++ })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	app.GET("/", func(c *Context) error {
+		cancel() // the client goes away while the handler runs
+		return nil
+	})
+
+	app.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil).WithContext(ctx))
+	if calls != 0 {
+		t.Errorf("AfterRequest ran %d times after the client disconnected", calls)
+	}
+
+	app.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	if calls != 1 {
+		t.Errorf("AfterRequest ran %d times for a connected client, want 1", calls)
+	}
+}
@@ -1,10 +1,14 @@
 package poltergeist
 
 import (
+	"fmt"
 	"net/http"
-	"path"
+	"os"
+	"regexp"
 	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 )
 
 // =============================================================================
@@ -25,11 +29,68 @@ type Route struct {
 	Middlewares []MiddlewareFunc
 
 	// Metadata (for documentation generation)
-	RouteName        string
-	RouteDescription string
-	RouteTags        []string
-	RequestBody      any
-	ResponseBody     any
+	RouteName           string
+	RouteDescription    string
+	RouteTags           []string
+	RequestBody         any
+	ResponseBody        any
+	RouteSecurity       []map[string][]string // Alternative security requirements (OR of ANDs)
+	RouteResponses      []RouteResponse       // Additional documented responses by status
+	QueryParams         any                   // Struct describing query parameters (`query` tags)
+	HeaderParams        any                   // Struct describing header parameters (`header` tags)
+	PathParams          any                   // Struct describing path parameters (`param` tags)
+	RequestBodyExample  any                   // Example request body
+	ResponseBodyExample any                   // Example success response body
+	RouteHidden         bool                  // Excluded from generated documentation
+	RouteVersion        string                // API version of the Version() group (for documentation)
+	RouteProtocol       string                // ProtocolWebSocket or ProtocolSSE for realtime routes
+	RouteMessages       []RouteMessage        // Realtime messages (for AsyncAPI documentation)
+	RouteDeprecation    *Deprecation          // Set by Deprecated()
+	ResponseTransforms  []ResponseTransform   // Group and route response transforms
+	ParamPatterns       map[string]string     // Regex constraints on path params, set by Where
+	RouteTimeout        time.Duration         // Handler deadline set by Timeout (0: none)
+
+	deprecatedHits     int64
+	constraints        map[string]*regexp.Regexp
+	headerVersion      string // version a Versioned group restricts the route to
+	inheritedSecurity  bool   // RouteSecurity still holds the group's requirements
+	router             *Router
+	middlewares        []middlewareEntry     // group and route middleware, in registration order
+	messageMiddlewares []WSMessageMiddleware // wrap WebSocket messages, set by UseMessage
+	chain              atomic.Pointer[resolvedChain]
+}
+
+// Deprecation describes a deprecated route
+type Deprecation struct {
+	Sunset  time.Time // Zero if no removal date is planned
+	Message string    // Migration hint, e.g. "use /v2/users"
+	Link    string    // Successor URL taken from the message, if any
+}
+
+// RouteResponse documents a response for a specific status code
+type RouteResponse struct {
+	Status      int
+	Body        any // nil for responses without a body
+	Description string
+}
+
+// Realtime route protocols
+const (
+	ProtocolWebSocket = "ws"
+	ProtocolSSE       = "sse"
+)
+
+// Message directions, from the server's point of view
+const (
+	MessageSend    = "send"    // server -> client
+	MessageReceive = "receive" // client -> server
+)
+
+// RouteMessage documents a message exchanged over a realtime route
+type RouteMessage struct {
+	Event     string
+	Payload   any
+	Direction string // MessageSend or MessageReceive
 }
 
 // =============================================================================
@@ -39,20 +100,37 @@ type Route struct {
 // Router handles HTTP request routing
 type Router struct {
 	routes           []*Route
-	middlewares      []MiddlewareFunc
+	tree             *node
+	middlewares      []middlewareEntry
+	chainVersion     atomic.Uint64 // bumped when server middleware changes
 	groups           []*RouteGroup
 	notFound         HandlerFunc
 	methodNotAllowed HandlerFunc
 	pool             sync.Pool
 	pipeline         *EventPipeline
+	mock             bool // serve declared examples instead of handlers (RunMock)
+	errors           *errorRegistry
+	container        *Container
+	rewrites         []rewriteRule
+	transforms       []ResponseTransform
+	fallbacks        []fallbackRule
+	versioned        bool // a Versioned group exists
+	versionSelectors []VersionSelector
+	defaultVersion   string
+	trustedProxies   *TrustedProxies // proxies whose forwarding headers ClientIP believes
+	jsonConfig       *JSONConfig     // nil: DefaultJSONConfig
+	maxBodyBuffer    int64           // c.Body() limit (0: DefaultMaxBodyBuffer)
 }
 
 // NewRouter creates a new Router instance
 func NewRouter() *Router {
 	r := &Router{
-		routes:   make([]*Route, 0),
-		groups:   make([]*RouteGroup, 0),
-		pipeline: NewEventPipeline(),
+		routes:    make([]*Route, 0),
+		tree:      newNode(),
+		groups:    make([]*RouteGroup, 0),
+		pipeline:  NewEventPipeline(),
+		errors:    newErrorRegistry(),
+		container: NewContainer(),
 	}
 	r.pool.New = func() any {
 		return &Context{}
@@ -64,8 +142,7 @@ func NewRouter() *Router {
 
 // Use adds global middleware to the router
 func (r *Router) Use(middlewares ...MiddlewareFunc) *Router {
-	r.middlewares = append(r.middlewares, middlewares...)
-	return r
+	return r.useEntries(plainEntries(MiddlewareScopeServer, middlewares))
 }
 
 // --- Groups ---
@@ -74,13 +151,21 @@ func (r *Router) Use(middlewares ...MiddlewareFunc) *Router {
 func (r *Router) Group(prefix string, middlewares ...MiddlewareFunc) *RouteGroup {
 	group := &RouteGroup{
 		prefix:      prefix,
-		middlewares: middlewares,
+		middlewares: plainEntries(MiddlewareScopeGroup, middlewares),
 		router:      r,
 	}
 	r.groups = append(r.groups, group)
 	return group
 }
 
+// Version creates a group for an API version: routes are mounted under
+// "/<version>" and tagged with the version for per-version documentation
+func (r *Router) Version(version string, middlewares ...MiddlewareFunc) *RouteGroup {
+	group := r.Group("/"+version, middlewares...)
+	group.version = version
+	return group
+}
+
 // --- Pipeline ---
 
 // Pipeline returns the event pipeline for hooks
@@ -96,22 +181,78 @@ func (r *Router) NotFound(handler HandlerFunc) *Router {
 	return r
 }
 
-// MethodNotAllowed sets the custom 405 handler
+// MethodNotAllowed sets the custom 405 handler. The Allow header listing
+// the path's methods is already set when it runs.
 func (r *Router) MethodNotAllowed(handler HandlerFunc) *Router {
 	r.methodNotAllowed = handler
 	return r
 }
 
+// fallbackRule holds the 404/405 handlers of a group, used for unmatched
+// requests under its prefix
+type fallbackRule struct {
+	prefix           string
+	notFound         HandlerFunc
+	methodNotAllowed HandlerFunc
+}
+
+// fallback returns the 404 (or 405) handler for a path: the one of the
+// longest group prefix containing it, else the router's
+func (r *Router) fallback(reqPath string, methodNotAllowed bool) HandlerFunc {
+	handler, longest := r.notFound, -1
+	if methodNotAllowed {
+		handler = r.methodNotAllowed
+	}
+	for _, rule := range r.fallbacks {
+		h := rule.notFound
+		if methodNotAllowed {
+			h = rule.methodNotAllowed
+		}
+		if h != nil && len(rule.prefix) > longest && hasPathPrefix(reqPath, rule.prefix) {
+			handler, longest = h, len(rule.prefix)
+		}
+	}
+	return handler
+}
+
+// setFallback sets a group's 404 or 405 handler
+func (r *Router) setFallback(prefix string, handler HandlerFunc, methodNotAllowed bool) {
+	for i := range r.fallbacks {
+		if r.fallbacks[i].prefix == prefix {
+			if methodNotAllowed {
+				r.fallbacks[i].methodNotAllowed = handler
+			} else {
+				r.fallbacks[i].notFound = handler
+			}
+			return
+		}
+	}
+	rule := fallbackRule{prefix: prefix}
+	if methodNotAllowed {
+		rule.methodNotAllowed = handler
+	} else {
+		rule.notFound = handler
+	}
+	r.fallbacks = append(r.fallbacks, rule)
+}
+
 // --- Route Registration ---
 
 // addRoute is the internal method for registering routes (DRY)
 func (r *Router) addRoute(method, routePath string, handler HandlerFunc, middlewares ...MiddlewareFunc) *Route {
+	routePath, patterns := splitConstraints(routePath)
 	route := &Route{
 		Method:      method,
 		Path:        routePath,
 		Handler:     handler,
 		Middlewares: middlewares,
+		router:      r,
+		middlewares: plainEntries(MiddlewareScopeRoute, middlewares),
+	}
+	for param, pattern := range patterns {
+		route.Where(param, pattern)
 	}
+	r.tree.insert(route)
 	r.routes = append(r.routes, route)
 	return route
 }
@@ -147,19 +288,33 @@ func (r *Router) HEAD(path string, handler HandlerFunc, middlewares ...Middlewar
 }
 
 // Any registers a route for all standard HTTP methods
-func (r *Router) Any(path string, handler HandlerFunc, middlewares ...MiddlewareFunc) {
-	for _, method := range AllHTTPMethods {
-		r.addRoute(method, path, handler, middlewares...)
+func (r *Router) Any(path string, handler HandlerFunc, middlewares ...MiddlewareFunc) []*Route {
+	return addRoutes(r.addRoute, AllHTTPMethods, path, handler, middlewares)
+}
+
+// Match registers a route for each of the given methods
+//
+//	app.Match([]string{"GET", "POST"}, "/search", search)
+func (r *Router) Match(methods []string, path string, handler HandlerFunc, middlewares ...MiddlewareFunc) []*Route {
+	return addRoutes(r.addRoute, methods, path, handler, middlewares)
+}
+
+// addRoutes registers handler for several methods through add
+func addRoutes(add func(method, path string, handler HandlerFunc, middlewares ...MiddlewareFunc) *Route,
+	methods []string, path string, handler HandlerFunc, middlewares []MiddlewareFunc) []*Route {
+	routes := make([]*Route, 0, len(methods))
+	for _, method := range methods {
+		routes = append(routes, add(strings.ToUpper(method), path, handler, middlewares...))
 	}
+	return routes
 }
 
-// Static serves static files from a directory
+// Static serves static files from a directory, with directory listings
+// (see StaticFS for embedded files, caching and SPA mode)
 func (r *Router) Static(urlPath, dirPath string) {
-	r.GET(urlPath+"/*filepath", func(c *Context) error {
-		filepath := c.Param("filepath")
-		http.ServeFile(c.Writer, c.Request, path.Join(dirPath, filepath))
-		return nil
-	})
+	cfg := DefaultStaticConfig()
+	cfg.Browse = true
+	r.StaticFS(urlPath, os.DirFS(dirPath), cfg)
 }
 
 // Routes returns all registered routes
@@ -167,28 +322,59 @@ func (r *Router) Routes() []*Route {
 	return r.routes
 }
 
+// Lookup returns the route that would serve a request, or nil
+func (r *Router) Lookup(method, path string) *Route {
+	h, _ := r.tree.find(query{method: method}, path, nil)
+	if h == nil {
+		return nil
+	}
+	return h.route
+}
+
 // =============================================================================
 // HTTP HANDLER - Implements http.Handler interface
 // =============================================================================
 
 // ServeHTTP handles incoming HTTP requests
 func (r *Router) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	r.rewriteRequest(req)
+
+	var span Span
+	if tracer := r.pipeline.tracer(); tracer != nil {
+		req, span = startRequestSpan(tracer, req)
+	}
+
 	// Get context from pool (performance optimization)
 	c := r.pool.Get().(*Context)
 	c.reset(w, req)
 	c.pipeline = r.pipeline
+	c.container = r.container
+	c.router = r
 	defer r.pool.Put(c)
 
+	metrics := r.pipeline.instruments()
+	start := time.Now()
+	metrics.httpInFlight.Add(1)
+	defer metrics.httpInFlight.Add(-1)
+
 	// Emit BeforeRequest event
 	r.emitEvent(EventBeforeRequest, c)
 
 	// Find and execute matching route
-	if err := r.handleRequest(c, req); err != nil {
+	err := r.handleRequest(c, req)
+	if err != nil {
 		r.handleError(c, err)
 	}
+	r.flushResponse(c)
 
-	// Emit AfterRequest event
-	r.emitEvent(EventAfterRequest, c)
+	// Emit AfterRequest event, skipping hooks once the client has gone
+	if r.pipeline != nil {
+		r.pipeline.emitWhile(EventAfterRequest, c, clientConnected)
+	}
+	metrics.observeRequest(c, start)
+	if span != nil {
+		finishRequestSpan(span, c, err)
+	}
 }
 
 // handleRequest finds and executes the matching route (KISS: extracted for clarity)
@@ -196,65 +382,100 @@ func (r *Router) handleRequest(c *Context, req *http.Request) error {
 	reqPath := req.URL.Path
 
 	// Find matching route
-	route, params := r.findRoute(req.Method, reqPath)
+	route := r.findRoute(c, req.Method, reqPath)
+	if transforms := r.responseTransforms(route); len(transforms) > 0 && !c.IsWebSocket() {
+		r.bufferResponse(c, transforms)
+	}
 
 	if route == nil {
 		return r.handleNoMatch(c, reqPath)
 	}
 
-	// Set path parameters
-	c.Params = params
+	c.route = route
+
+	if route.RouteDeprecation != nil {
+		route.writeDeprecationHeaders(c)
+		r.emitEvent(EventDeprecated, c)
+	}
 
 	// Build and execute middleware chain
 	handler := r.buildMiddlewareChain(route)
 	return handler(c)
 }
 
-// findRoute searches for a matching route (KISS: single responsibility)
-func (r *Router) findRoute(method, path string) (*Route, map[string]string) {
-	for _, route := range r.routes {
-		if route.Method != method {
-			continue
-		}
-		if matched, params := matchPath(route.Path, path); matched {
-			return route, params
-		}
+// findRoute matches the routing tree and sets the path parameters on c,
+// reusing the context's value buffer so matching does not allocate
+func (r *Router) findRoute(c *Context, method, path string) *Route {
+	h, values := r.tree.find(r.query(c.Request, method), path, c.paramValues[:0])
+	c.paramValues = values
+	if h == nil {
+		return nil
 	}
-	return nil, nil
+	h.setParams(c.Params, values)
+	return h.route
 }
 
 // handleNoMatch handles 404/405 responses (KISS: extracted for clarity)
 func (r *Router) handleNoMatch(c *Context, reqPath string) error {
 	// Check if path exists with different method (405 vs 404)
-	for _, route := range r.routes {
-		if matched, _ := matchPath(route.Path, reqPath); matched {
-			// Path exists, method doesn't match
-			if r.methodNotAllowed != nil {
-				return r.methodNotAllowed(c)
-			}
-			return c.Error(http.StatusMethodNotAllowed, "Method Not Allowed")
+	if allowed := r.allowedMethods(c, reqPath); len(allowed) > 0 {
+		if c.Request.Method == http.MethodOptions {
+			return r.handleOptions(c, reqPath, allowed)
+		}
+		// Path exists, method doesn't match
+		c.SetHeader(HeaderAllow, strings.Join(allowed, ", "))
+		if handler := r.fallback(reqPath, true); handler != nil {
+			return handler(c)
 		}
+		return r.errors.respond(c, ErrMethodNotAllowed.WithMessage("Method Not Allowed"))
 	}
 
 	// Path doesn't exist
-	if r.notFound != nil {
-		return r.notFound(c)
+	if handler := r.fallback(reqPath, false); handler != nil {
+		return handler(c)
 	}
-	return c.Error(http.StatusNotFound, "Not Found")
+	return r.errors.respond(c, ErrNotFound)
+}
+
+// handleOptions answers OPTIONS for a path routed under other methods with
+// 204 and Allow, through the middleware of the path's route so CORS
+// middleware can answer preflight requests
+func (r *Router) handleOptions(c *Context, reqPath string, allowed []string) error {
+	c.SetHeader(HeaderAllow, strings.Join(append(allowed, http.MethodOptions), ", "))
+	route := r.findRoute(c, allowed[0], reqPath)
+	handler := HandlerFunc(func(c *Context) error { return c.NoContent() })
+	chain := route.resolveMiddleware()
+	for i := len(chain) - 1; i >= 0; i-- {
+		handler = chain[i].Func(handler)
+	}
+	return handler(c)
+}
+
+// allowedMethods lists the standard methods with a route matching the path
+func (r *Router) allowedMethods(c *Context, reqPath string) []string {
+	var allowed []string
+	for _, method := range AllHTTPMethods {
+		if h, _ := r.tree.find(r.query(c.Request, method), reqPath, c.paramValues[:0]); h != nil {
+			allowed = append(allowed, method)
+		}
+	}
+	return allowed
 }
 
 // buildMiddlewareChain creates the middleware execution chain (DRY)
 func (r *Router) buildMiddlewareChain(route *Route) HandlerFunc {
 	handler := route.Handler
-
-	// Apply route-specific middlewares (reverse order)
-	for i := len(route.Middlewares) - 1; i >= 0; i-- {
-		handler = route.Middlewares[i](handler)
+	if r.mock && route.hasMockResponse() {
+		handler = mockHandler(route)
+	}
+	if route.RouteTimeout > 0 && route.RouteProtocol == "" {
+		handler = withTimeout(handler, route.RouteTimeout)
 	}
 
-	// Apply global middlewares (reverse order)
-	for i := len(r.middlewares) - 1; i >= 0; i-- {
-		handler = r.middlewares[i](handler)
+	// Apply server, group and route middlewares in resolved order (reverse)
+	chain := route.resolveMiddleware()
+	for i := len(chain) - 1; i >= 0; i-- {
+		handler = chain[i].Func(handler)
 	}
 
 	return handler
@@ -262,11 +483,15 @@ func (r *Router) buildMiddlewareChain(route *Route) HandlerFunc {
 
 // handleError handles errors from handlers
 func (r *Router) handleError(c *Context, err error) {
-	c.Set("error", err)
-	r.emitEvent(EventError, c)
-	if !c.Written() {
-		c.InternalServerError(err.Error())
+	httpErr := r.errors.resolve(c, err)
+	c.Set(ContextKeyError, err)
+	c.Set(ContextKeyHTTPError, httpErr)
+	// Recovered panics were reported by the recovery middleware already
+	if httpErr.Code >= http.StatusInternalServerError && c.Panic() == nil {
+		c.CaptureError(err, &ReportDetails{Source: ReportSourceHandler})
 	}
+	r.emitEvent(EventError, c)
+	r.errors.respond(c, httpErr)
 }
 
 // emitEvent safely emits pipeline events
@@ -280,7 +505,8 @@ func (r *Router) emitEvent(event EventType, c *Context) {
 // PATH MATCHING - Route pattern matching engine
 // =============================================================================
 
-// matchPath matches a route pattern against a request path
+// matchPath matches a route pattern against a request path. Requests are
+// matched through the routing tree; this matches a single pattern.
 // Supports:
 //   - Exact matches: /users
 //   - Parameters: /users/:id
@@ -364,25 +590,39 @@ func validatePartCounts(patternParts, pathParts []string, hasWildcard bool) bool
 
 // RouteGroup represents a group of routes with shared configuration
 type RouteGroup struct {
-	prefix      string
-	middlewares []MiddlewareFunc
-	router      *Router
-	parent      *RouteGroup
+	prefix        string
+	middlewares   []middlewareEntry
+	router        *Router
+	parent        *RouteGroup
+	version       string
+	transforms    []ResponseTransform
+	timeout       time.Duration
+	headerVersion bool // routes are selected by header version, not prefix
+	tags          []string
+	description   string
+	security      []map[string][]string
 }
 
 // Use adds middleware to the group
 func (g *RouteGroup) Use(middlewares ...MiddlewareFunc) *RouteGroup {
-	g.middlewares = append(g.middlewares, middlewares...)
+	g.middlewares = append(g.middlewares, plainEntries(MiddlewareScopeGroup, middlewares)...)
 	return g
 }
 
 // Group creates a nested group
 func (g *RouteGroup) Group(prefix string, middlewares ...MiddlewareFunc) *RouteGroup {
 	newGroup := &RouteGroup{
-		prefix:      g.prefix + prefix,
-		middlewares: append(append([]MiddlewareFunc{}, g.middlewares...), middlewares...),
-		router:      g.router,
-		parent:      g,
+		prefix:        g.prefix + prefix,
+		middlewares:   append(append([]middlewareEntry{}, g.middlewares...), plainEntries(MiddlewareScopeGroup, middlewares)...),
+		router:        g.router,
+		parent:        g,
+		version:       g.version,
+		transforms:    append([]ResponseTransform{}, g.transforms...),
+		timeout:       g.timeout,
+		headerVersion: g.headerVersion,
+		tags:          append([]string{}, g.tags...),
+		description:   g.description,
+		security:      append([]map[string][]string{}, g.security...),
 	}
 	g.router.groups = append(g.router.groups, newGroup)
 	return newGroup
@@ -391,8 +631,83 @@ func (g *RouteGroup) Group(prefix string, middlewares ...MiddlewareFunc) *RouteG
 // addRoute is the internal method for registering routes in a group (DRY)
 func (g *RouteGroup) addRoute(method, routePath string, handler HandlerFunc, middlewares ...MiddlewareFunc) *Route {
 	fullPath := g.prefix + routePath
-	allMiddlewares := append(append([]MiddlewareFunc{}, g.middlewares...), middlewares...)
-	return g.router.addRoute(method, fullPath, handler, allMiddlewares...)
+	var allMiddlewares []MiddlewareFunc
+	for _, entry := range g.middlewares {
+		allMiddlewares = append(allMiddlewares, entry.Func)
+	}
+	allMiddlewares = append(allMiddlewares, middlewares...)
+	route := g.router.addRoute(method, fullPath, handler, allMiddlewares...)
+	route.middlewares = append(append([]middlewareEntry{}, g.middlewares...), plainEntries(MiddlewareScopeRoute, middlewares)...)
+	route.RouteVersion = g.version
+	route.RouteTimeout = g.timeout
+	if g.headerVersion {
+		route.headerVersion = g.version
+	}
+	route.ResponseTransforms = append(route.ResponseTransforms, g.transforms...)
+	route.RouteTags = append(route.RouteTags, g.tags...)
+	route.RouteDescription = g.description
+	if len(g.security) > 0 {
+		route.RouteSecurity = append([]map[string][]string{}, g.security...)
+		route.inheritedSecurity = true
+	}
+	return route
+}
+
+// Tag adds documentation tags to routes registered on the group afterwards,
+// including its subgroups; route tags are added after the group's
+func (g *RouteGroup) Tag(tags ...string) *RouteGroup {
+	g.tags = append(g.tags, tags...)
+	return g
+}
+
+// Desc sets the default description of routes registered on the group
+// afterwards; Route.Desc overrides it
+func (g *RouteGroup) Desc(description string) *RouteGroup {
+	g.description = description
+	return g
+}
+
+// Security adds a security requirement to routes registered on the group
+// afterwards, including its subgroups (see Route.Security). Calling
+// Security or SecurityScopes on a route replaces the inherited requirements.
+//
+//	admin := app.Group("/api/v1/admin").Tag("Admin").Security("bearerAuth")
+//	admin.GET("/users", listUsers)
+//	admin.GET("/health", health).Security() // auth optional here
+func (g *RouteGroup) Security(schemes ...string) *RouteGroup {
+	requirement := make(map[string][]string, len(schemes))
+	for _, scheme := range schemes {
+		requirement[scheme] = []string{}
+	}
+	g.security = append(g.security, requirement)
+	return g
+}
+
+// SecurityScopes adds a security requirement with OAuth2/OIDC scopes to
+// routes registered on the group afterwards
+func (g *RouteGroup) SecurityScopes(scheme string, scopes ...string) *RouteGroup {
+	g.security = append(g.security, map[string][]string{
+		scheme: append([]string{}, scopes...),
+	})
+	return g
+}
+
+// NotFound sets the 404 handler for unmatched requests under the group
+// prefix, e.g. a JSON error for "/api" while the app serves HTML pages
+func (g *RouteGroup) NotFound(handler HandlerFunc) *RouteGroup {
+	g.router.setFallback(g.prefix, handler, false)
+	return g
+}
+
+// MethodNotAllowed sets the 405 handler for requests under the group prefix
+func (g *RouteGroup) MethodNotAllowed(handler HandlerFunc) *RouteGroup {
+	g.router.setFallback(g.prefix, handler, true)
+	return g
+}
+
+// Prefix returns the full path prefix of the group
+func (g *RouteGroup) Prefix() string {
+	return g.prefix
 }
 
 // HTTP method shortcuts (all delegate to addRoute - DRY)
@@ -426,10 +741,13 @@ func (g *RouteGroup) HEAD(path string, handler HandlerFunc, middlewares ...Middl
 }
 
 // Any registers a route for all standard HTTP methods
-func (g *RouteGroup) Any(path string, handler HandlerFunc, middlewares ...MiddlewareFunc) {
-	for _, method := range AllHTTPMethods {
-		g.addRoute(method, path, handler, middlewares...)
-	}
+func (g *RouteGroup) Any(path string, handler HandlerFunc, middlewares ...MiddlewareFunc) []*Route {
+	return addRoutes(g.addRoute, AllHTTPMethods, path, handler, middlewares)
+}
+
+// Match registers a route for each of the given methods
+func (g *RouteGroup) Match(methods []string, path string, handler HandlerFunc, middlewares ...MiddlewareFunc) []*Route {
+	return addRoutes(g.addRoute, methods, path, handler, middlewares)
 }
 
 // =============================================================================
@@ -465,3 +783,161 @@ func (r *Route) Response(body any) *Route {
 	r.ResponseBody = body
 	return r
 }
+
+// Query sets the query parameter struct (for documentation), e.g.
+//
+//	type ListParams struct {
+//	    Page  int    `query:"page" default:"1"`
+//	    Limit int    `query:"limit" default:"20" doc:"Page size"`
+//	    Sort  string `query:"sort" enum:"name,created_at"`
+//	}
+func (r *Route) Query(params any) *Route {
+	r.QueryParams = params
+	return r
+}
+
+// Headers sets the header parameter struct (for documentation)
+func (r *Route) Headers(params any) *Route {
+	r.HeaderParams = params
+	return r
+}
+
+// Params sets the path parameter struct (for documentation)
+func (r *Route) Params(params any) *Route {
+	r.PathParams = params
+	return r
+}
+
+// ResponseStatus documents a response for a status code (for documentation),
+// e.g. .ResponseStatus(404, ErrBody{}, "user not found")
+func (r *Route) ResponseStatus(status int, body any, description string) *Route {
+	r.RouteResponses = append(r.RouteResponses, RouteResponse{
+		Status:      status,
+		Body:        body,
+		Description: description,
+	})
+	return r
+}
+
+// Example sets an example success response body (for documentation)
+func (r *Route) Example(example any) *Route {
+	r.ResponseBodyExample = example
+	return r
+}
+
+// RequestExample sets an example request body (for documentation)
+func (r *Route) RequestExample(example any) *Route {
+	r.RequestBodyExample = example
+	return r
+}
+
+// Where constrains a path parameter to a regular expression that must match
+// the whole segment (or the whole remainder for a wildcard). Requests that
+// don't match fall through to other routes, then to 404. Patterns can also
+// be written inline: "/users/:id([0-9]+)". Panics if the pattern is invalid.
+//
+//	app.GET("/users/:id", getUser).Where("id", `\d+`)
+func (r *Route) Where(param, pattern string) *Route {
+	re := regexp.MustCompile("^(?:" + pattern + ")$")
+	if r.constraints == nil {
+		r.constraints = make(map[string]*regexp.Regexp)
+		r.ParamPatterns = make(map[string]string)
+	}
+	r.constraints[param] = re
+	r.ParamPatterns[param] = pattern
+	return r
+}
+
+// Hidden excludes the route from generated documentation
+// (internal, admin and debug endpoints)
+func (r *Route) Hidden() *Route {
+	r.RouteHidden = true
+	return r
+}
+
+// Sends documents a message the server sends on a WebSocket or SSE route
+// (for AsyncAPI documentation); for SSE the event is the SSE event type
+func (r *Route) Sends(event string, payload any) *Route {
+	r.RouteMessages = append(r.RouteMessages, RouteMessage{Event: event, Payload: payload, Direction: MessageSend})
+	return r
+}
+
+// Receives documents a message clients send on a WebSocket route
+// (for AsyncAPI documentation)
+func (r *Route) Receives(event string, payload any) *Route {
+	r.RouteMessages = append(r.RouteMessages, RouteMessage{Event: event, Payload: payload, Direction: MessageReceive})
+	return r
+}
+
+// Deprecated marks the route deprecated: the operation is flagged in OpenAPI,
+// responses carry Deprecation, Sunset and Link headers, and each call emits
+// EventDeprecated. The sunset date is YYYY-MM-DD (empty for none); a path or
+// URL in the message becomes the successor Link, e.g.
+//
+//	app.GET("/users", listUsers).Deprecated("2025-12-31", "use /v2/users")
+func (r *Route) Deprecated(sunset, message string) *Route {
+	deprecation := &Deprecation{Message: message}
+	if sunset != "" {
+		t, err := time.Parse("2006-01-02", sunset)
+		if err != nil {
+			panic(fmt.Sprintf("poltergeist: invalid sunset date %q for %s %s: %v", sunset, r.Method, r.Path, err))
+		}
+		deprecation.Sunset = t
+	}
+	for _, word := range strings.Fields(message) {
+		if strings.HasPrefix(word, "/") || strings.HasPrefix(word, "http://") || strings.HasPrefix(word, "https://") {
+			deprecation.Link = strings.TrimRight(word, ".,;)")
+			break
+		}
+	}
+	r.RouteDeprecation = deprecation
+	return r
+}
+
+// DeprecatedHits returns how many times a deprecated route has been called
+func (r *Route) DeprecatedHits() int64 {
+	return atomic.LoadInt64(&r.deprecatedHits)
+}
+
+// writeDeprecationHeaders sets the deprecation headers and records the call
+func (r *Route) writeDeprecationHeaders(c *Context) {
+	atomic.AddInt64(&r.deprecatedHits, 1)
+	d := r.RouteDeprecation
+	c.SetHeader(HeaderDeprecation, "true")
+	if !d.Sunset.IsZero() {
+		c.SetHeader(HeaderSunset, d.Sunset.UTC().Format(http.TimeFormat))
+	}
+	if d.Link != "" {
+		c.Writer.Header().Add(HeaderLink, "<"+d.Link+`>; rel="successor-version"`)
+	}
+}
+
+// Security adds a security requirement naming schemes declared in the docs
+// configuration. Schemes passed in one call must all be satisfied; separate
+// calls are alternatives. Calling it without schemes marks auth as optional.
+func (r *Route) Security(schemes ...string) *Route {
+	r.dropInheritedSecurity()
+	requirement := make(map[string][]string, len(schemes))
+	for _, scheme := range schemes {
+		requirement[scheme] = []string{}
+	}
+	r.RouteSecurity = append(r.RouteSecurity, requirement)
+	return r
+}
+
+// dropInheritedSecurity clears group requirements before the route sets its own
+func (r *Route) dropInheritedSecurity() {
+	if r.inheritedSecurity {
+		r.RouteSecurity = nil
+		r.inheritedSecurity = false
+	}
+}
+
+// SecurityScopes adds a security requirement with OAuth2/OIDC scopes
+func (r *Route) SecurityScopes(scheme string, scopes ...string) *Route {
+	r.dropInheritedSecurity()
+	r.RouteSecurity = append(r.RouteSecurity, map[string][]string{
+		scheme: append([]string{}, scopes...),
+	})
+	return r
+}
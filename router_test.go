@@ -1,8 +1,11 @@
 package poltergeist
 
 import (
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -192,6 +195,372 @@ func TestMatchPath(t *testing.T) {
 	}
 }
 
+func TestRouter_TreeMatching(t *testing.T) {
+	router := NewRouter()
+	handler := func(name string) HandlerFunc {
+		return func(c *Context) error {
+			return c.String(200, name+" "+c.Param("id")+c.Param("path"))
+		}
+	}
+	router.GET("/users/:id", handler("show"))
+	router.GET("/users/new", handler("new"))
+	router.POST("/users/:id", handler("update"))
+	router.GET("/users/:id/files/*path", handler("files"))
+	router.GET("/*path", handler("fallback"))
+
+	tests := []struct {
+		method   string
+		path     string
+		wantCode int
+		wantBody string
+	}{
+		{"GET", "/users/new", 200, "new "},        // static beats param
+		{"POST", "/users/new", 200, "update new"}, // backtracks to a route for the method
+		{"GET", "/users/42/", 200, "show 42"},
+		{"GET", "/users/42/files/a/b.txt", 200, "files 42a/b.txt"},
+		{"GET", "/users/42/files", 200, "files 42"},
+		{"GET", "/about/team", 200, "fallback about/team"},
+		{"DELETE", "/users/42", 405, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.path, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(tt.method, tt.path, nil))
+			if w.Code != tt.wantCode {
+				t.Fatalf("Status = %d, want %d", w.Code, tt.wantCode)
+			}
+			if tt.wantBody != "" && w.Body.String() != tt.wantBody {
+				t.Errorf("Body = %q, want %q", w.Body.String(), tt.wantBody)
+			}
+		})
+	}
+
+	if route := router.Lookup("GET", "/users/7/files/x"); route == nil || route.Path != "/users/:id/files/*path" {
+		t.Errorf("Lookup = %v", route)
+	}
+}
+
+func TestRouter_ParamConstraints(t *testing.T) {
+	router := NewRouter()
+	router.GET("/users/:id([0-9]+)", func(c *Context) error {
+		return c.String(200, "id "+c.Param("id"))
+	})
+	router.GET("/users/:name", func(c *Context) error {
+		return c.String(200, "name "+c.Param("name"))
+	}).Where("name", `[a-z]+`)
+	router.GET("/files/*path", func(c *Context) error {
+		return c.String(200, c.Param("path"))
+	}).Where("path", `.+\.txt`)
+
+	tests := []struct {
+		path     string
+		wantCode int
+		wantBody string
+	}{
+		{"/users/42", 200, "id 42"},
+		{"/users/bob", 200, "name bob"},
+		{"/users/Bob42", 404, ""},
+		{"/files/a/b.txt", 200, "a/b.txt"},
+		{"/files/a/b.png", 404, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest("GET", tt.path, nil))
+			if w.Code != tt.wantCode {
+				t.Fatalf("Status = %d, want %d", w.Code, tt.wantCode)
+			}
+			if tt.wantBody != "" && w.Body.String() != tt.wantBody {
+				t.Errorf("Body = %q, want %q", w.Body.String(), tt.wantBody)
+			}
+		})
+	}
+
+	if route := router.Routes()[0]; route.Path != "/users/:id" || route.ParamPatterns["id"] != "[0-9]+" {
+		t.Errorf("inline constraint not split: %q %v", route.Path, route.ParamPatterns)
+	}
+}
+
+func TestSplitConstraints(t *testing.T) {
+	tests := []struct {
+		pattern string
+		want    string
+		params  map[string]string
+	}{
+		{"/users/:id", "/users/:id", nil},
+		{"/users/:id([0-9]+)/posts", "/users/:id/posts", map[string]string{"id": "[0-9]+"}},
+		{"/files/*path([a-z/]+)", "/files/*path", map[string]string{"path": "[a-z/]+"}},
+		{"/docs/*page([a-z/]+\\.(md|txt))", "/docs/*page", map[string]string{"page": `[a-z/]+\.(md|txt)`}},
+		{"/:a((?:x|y)+)/:b([)(])", "/:a/:b", map[string]string{"a": "(?:x|y)+", "b": "[)(]"}},
+		{"/:day?([0-9]+)", "/:day?", map[string]string{"day": "[0-9]+"}},
+		{"/static/a(b)", "/static/a(b)", nil},
+		{"/:id([0-9]+", "/:id([0-9]+", nil},
+	}
+	for _, tt := range tests {
+		got, params := splitConstraints(tt.pattern)
+		if got != tt.want || !reflect.DeepEqual(params, tt.params) {
+			t.Errorf("splitConstraints(%q) = %q, %v; want %q, %v", tt.pattern, got, params, tt.want, tt.params)
+		}
+	}
+
+	router := NewRouter()
+	router.GET("/files/*path([a-z/]+)", func(c *Context) error {
+		return c.String(200, c.Param("path"))
+	})
+	for path, want := range map[string]int{"/files/a/b/c": 200, "/files/a/B": 404} {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+		if w.Code != want {
+			t.Errorf("%s = %d, want %d", path, w.Code, want)
+		}
+	}
+}
+
+func TestRouter_OptionalParams(t *testing.T) {
+	router := NewRouter()
+	router.GET("/articles/:year/:month?/:day?([0-9]+)", func(c *Context) error {
+		return c.String(200, c.Param("year")+"|"+c.Param("month")+"|"+c.Param("day"))
+	})
+
+	tests := []struct {
+		path     string
+		wantCode int
+		wantBody string
+	}{
+		{"/articles/2024", 200, "2024||"},
+		{"/articles/2024/05", 200, "2024|05|"},
+		{"/articles/2024/05/17", 200, "2024|05|17"},
+		{"/articles/2024/05/xx", 404, ""},
+		{"/articles", 404, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest("GET", tt.path, nil))
+			if w.Code != tt.wantCode {
+				t.Fatalf("Status = %d, want %d", w.Code, tt.wantCode)
+			}
+			if tt.wantBody != "" && w.Body.String() != tt.wantBody {
+				t.Errorf("Body = %q, want %q", w.Body.String(), tt.wantBody)
+			}
+		})
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("optional param before a required segment should panic")
+		}
+	}()
+	router.GET("/bad/:id?/edit", func(c *Context) error { return nil })
+}
+
+func TestRouter_NotFoundHandlers(t *testing.T) {
+	router := NewRouter()
+	router.GET("/users", func(c *Context) error { return c.String(200, "users") })
+	router.POST("/users", func(c *Context) error { return c.String(201, "created") })
+	router.NotFound(func(c *Context) error { return c.HTML(404, "<h1>lost</h1>") })
+	router.MethodNotAllowed(func(c *Context) error { return c.String(405, "allowed: "+c.Writer.Header().Get(HeaderAllow)) })
+
+	api := router.Group("/api")
+	api.GET("/items", func(c *Context) error { return c.String(200, "items") })
+	api.NotFound(func(c *Context) error { return c.JSON(404, H{"error": "no such endpoint"}) })
+
+	tests := []struct {
+		method   string
+		path     string
+		wantCode int
+		wantBody string
+	}{
+		{"GET", "/missing", 404, "<h1>lost</h1>"},
+		{"GET", "/api/missing", 404, "{\"error\":\"no such endpoint\"}\n"},
+		{"GET", "/apis", 404, "<h1>lost</h1>"},
+		{"DELETE", "/users", 405, "allowed: GET, POST"},
+		{"DELETE", "/api/items", 405, "allowed: GET"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.path, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(tt.method, tt.path, nil))
+			if w.Code != tt.wantCode || w.Body.String() != tt.wantBody {
+				t.Errorf("got %d %q, want %d %q", w.Code, w.Body.String(), tt.wantCode, tt.wantBody)
+			}
+		})
+	}
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("DELETE", "/users", nil))
+	if allow := w.Header().Get(HeaderAllow); allow != "GET, POST" {
+		t.Errorf("Allow = %q, want %q", allow, "GET, POST")
+	}
+}
+
+func TestRouter_AutomaticOptions(t *testing.T) {
+	router := NewRouter()
+	router.Use(func(next HandlerFunc) HandlerFunc {
+		return func(c *Context) error {
+			c.SetHeader("X-Server", "yes")
+			return next(c)
+		}
+	})
+	api := router.Group("/api", func(next HandlerFunc) HandlerFunc {
+		return func(c *Context) error {
+			c.SetHeader("X-Group", "api")
+			return next(c)
+		}
+	})
+	api.GET("/users/:id", func(c *Context) error { return c.String(200, "user") })
+	api.PUT("/users/:id", func(c *Context) error { return c.String(200, "updated") })
+	api.OPTIONS("/custom", func(c *Context) error { return c.String(200, "custom") })
+	api.GET("/custom", func(c *Context) error { return c.String(200, "get") })
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("OPTIONS", "/api/users/7", nil))
+	if w.Code != 204 {
+		t.Fatalf("status = %d, want 204", w.Code)
+	}
+	if allow := w.Header().Get(HeaderAllow); allow != "GET, PUT, OPTIONS" {
+		t.Errorf("Allow = %q, want %q", allow, "GET, PUT, OPTIONS")
+	}
+	// Server and group middleware run, so CORS middleware can answer preflights
+	if w.Header().Get("X-Server") != "yes" || w.Header().Get("X-Group") != "api" {
+		t.Errorf("middleware headers = %v", w.Header())
+	}
+
+	// An explicit OPTIONS route wins; unknown paths are still 404
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("OPTIONS", "/api/custom", nil))
+	if w.Body.String() != "custom" {
+		t.Errorf("body = %q, want the OPTIONS handler's", w.Body.String())
+	}
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("OPTIONS", "/api/missing", nil))
+	if w.Code != 404 {
+		t.Errorf("status = %d, want 404", w.Code)
+	}
+}
+
+func TestRouter_Precedence(t *testing.T) {
+	patterns := []string{
+		"/static/*filepath",
+		"/static/:file",
+		"/static/health",
+		"/:dir/readme",
+		"/files/*path",
+		"/users/:id/profile",
+		"/users/*rest",
+		"/users/new",
+		"/users/:id",
+	}
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"/static/health", "/static/health"},
+		{"/static/app.js", "/static/:file"},
+		{"/static/css/app.css", "/static/*filepath"},
+		{"/static", "/static/*filepath"},
+		{"/files/readme", "/files/*path"},
+		{"/docs/readme", "/:dir/readme"},
+		{"/users/new", "/users/new"},
+		{"/users/42", "/users/:id"},
+		{"/users/42/profile", "/users/:id/profile"},
+		{"/users/42/settings", "/users/*rest"},
+	}
+
+	// Registration order must not matter: try forwards and backwards
+	for _, reverse := range []bool{false, true} {
+		router := NewRouter()
+		for i := range patterns {
+			pattern := patterns[i]
+			if reverse {
+				pattern = patterns[len(patterns)-1-i]
+			}
+			router.GET(pattern, func(c *Context) error { return nil })
+		}
+		for _, tt := range tests {
+			route := router.Lookup("GET", tt.path)
+			if route == nil || route.Path != tt.want {
+				t.Errorf("reverse=%v: %s matched %v, want %s", reverse, tt.path, route, tt.want)
+			}
+		}
+	}
+
+	// A more specific branch without the method falls back to the next one
+	router := NewRouter()
+	router.POST("/static/health", func(c *Context) error { return nil })
+	router.GET("/static/*filepath", func(c *Context) error { return nil })
+	if route := router.Lookup("GET", "/static/health"); route == nil || route.Path != "/static/*filepath" {
+		t.Errorf("GET /static/health matched %v", route)
+	}
+}
+
+func TestRouteGroup_Metadata(t *testing.T) {
+	router := NewRouter()
+	handler := func(c *Context) error { return nil }
+
+	api := router.Group("/api").Tag("API").Desc("Public API")
+	admin := api.Group("/admin").Tag("Admin").Security("bearerAuth")
+	list := admin.GET("/users", handler).Tag("Users")
+	health := admin.GET("/health", handler).Desc("Health check").Security()
+	scoped := admin.GET("/audit", handler).SecurityScopes("oauth2", "audit:read")
+	plain := api.GET("/ping", handler)
+
+	if got := strings.Join(list.RouteTags, ","); got != "API,Admin,Users" {
+		t.Errorf("tags = %q, want API,Admin,Users", got)
+	}
+	if list.RouteDescription != "Public API" || health.RouteDescription != "Health check" {
+		t.Errorf("descriptions = %q, %q", list.RouteDescription, health.RouteDescription)
+	}
+	if len(list.RouteSecurity) != 1 || list.RouteSecurity[0]["bearerAuth"] == nil {
+		t.Errorf("inherited security = %v", list.RouteSecurity)
+	}
+	if len(health.RouteSecurity) != 1 || len(health.RouteSecurity[0]) != 0 {
+		t.Errorf("overridden security = %v, want one optional requirement", health.RouteSecurity)
+	}
+	if len(scoped.RouteSecurity) != 1 || scoped.RouteSecurity[0]["oauth2"][0] != "audit:read" {
+		t.Errorf("scoped security = %v", scoped.RouteSecurity)
+	}
+	if len(plain.RouteSecurity) != 0 || strings.Join(plain.RouteTags, ",") != "API" {
+		t.Errorf("parent group picked up child metadata: %v %v", plain.RouteTags, plain.RouteSecurity)
+	}
+}
+
+func TestRouter_AnyAndMatch(t *testing.T) {
+	router := NewRouter()
+	handler := func(c *Context) error { return c.String(http.StatusOK, c.Request.Method) }
+
+	all := router.Any("/webhook", handler)
+	if len(all) != len(AllHTTPMethods) {
+		t.Fatalf("Any registered %d routes, want %d", len(all), len(AllHTTPMethods))
+	}
+	matched := router.Group("/api").Match([]string{"get", "POST"}, "/search", handler)
+	if len(matched) != 2 || matched[0].Method != http.MethodGet {
+		t.Fatalf("Match registered %v", matched)
+	}
+
+	tests := []struct {
+		method string
+		path   string
+		want   int
+	}{
+		{http.MethodDelete, "/webhook", http.StatusOK},
+		{http.MethodPatch, "/webhook", http.StatusOK},
+		{http.MethodGet, "/api/search", http.StatusOK},
+		{http.MethodPost, "/api/search", http.StatusOK},
+		{http.MethodPut, "/api/search", http.StatusMethodNotAllowed},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(tt.method, tt.path, nil))
+		if w.Code != tt.want {
+			t.Errorf("%s %s = %d, want %d", tt.method, tt.path, w.Code, tt.want)
+		}
+	}
+}
+
 // =============================================================================
 // ROUTER BENCHMARKS
 // =============================================================================
@@ -274,6 +643,25 @@ func BenchmarkRouter_WithMiddleware(b *testing.B) {
 	}
 }
 
+func BenchmarkRouter_LargeTable(b *testing.B) {
+	router := NewRouter()
+	for i := 0; i < 500; i++ {
+		router.GET(fmt.Sprintf("/resource%d/:id/items/:item", i), func(c *Context) error {
+			return c.String(200, "ok")
+		})
+	}
+
+	req := httptest.NewRequest("GET", "/resource499/1/items/2", nil)
+
+	b.ResetTimer()
+	b.ReportAllocs()
+
+	for i := 0; i < b.N; i++ {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+	}
+}
+
 func BenchmarkMatchPath_Static(b *testing.B) {
 	b.ReportAllocs()
 	for i := 0; i < b.N; i++ {
@@ -424,3 +812,49 @@ func TestServer_Integration(t *testing.T) {
 	}
 	resp.Body.Close()
 }
+
+func TestRouter_VersionGroup(t *testing.T) {
+	router := NewRouter()
+
+	v2 := router.Version("v2")
+	route := v2.Group("/users").GET("/:id", func(c *Context) error {
+		return c.String(200, c.Param("id"))
+	})
+
+	if route.Path != "/v2/users/:id" || route.RouteVersion != "v2" {
+		t.Errorf("route = %s (version %q), want /v2/users/:id (v2)", route.Path, route.RouteVersion)
+	}
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/v2/users/7", nil))
+	if w.Body.String() != "7" {
+		t.Errorf("Body = %q, want 7", w.Body.String())
+	}
+}
+
+func TestRouter_DeprecatedRoute(t *testing.T) {
+	router := NewRouter()
+
+	var events int
+	router.Pipeline().OnDeprecated(func(c *Context) { events++ })
+
+	route := router.GET("/users", func(c *Context) error {
+		return c.String(200, "users")
+	}).Deprecated("2025-12-31", "use /v2/users instead")
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/users", nil))
+
+	if got := w.Header().Get("Deprecation"); got != "true" {
+		t.Errorf("Deprecation = %q, want true", got)
+	}
+	if got := w.Header().Get("Sunset"); got != "Wed, 31 Dec 2025 00:00:00 GMT" {
+		t.Errorf("Sunset = %q", got)
+	}
+	if got := w.Header().Get("Link"); got != `</v2/users>; rel="successor-version"` {
+		t.Errorf("Link = %q", got)
+	}
+	if events != 1 || route.DeprecatedHits() != 1 {
+		t.Errorf("events = %d, hits = %d, want 1 and 1", events, route.DeprecatedHits())
+	}
+}
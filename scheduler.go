@@ -0,0 +1,298 @@
+package poltergeist
+
+import (
+	"context"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// =============================================================================
+// SCHEDULER CONFIGURATION
+// =============================================================================
+
+// Job is a unit of scheduled work
+type Job func(ctx context.Context) error
+
+// JobConfig holds per-job scheduling options
+type JobConfig struct {
+	Name         string         // Job name used in events and logs (default: the cron expression)
+	Location     *time.Location // Timezone the expression is evaluated in (default: time.Local)
+	Jitter       time.Duration  // Random delay up to this duration before each run
+	AllowOverlap bool           // Run even if the previous run is still in progress (default: false)
+}
+
+// =============================================================================
+// SCHEDULED JOB
+// =============================================================================
+
+// ScheduledJob is a job registered with the Scheduler
+type ScheduledJob struct {
+	name     string
+	schedule CronSchedule
+	job      Job
+	config   *JobConfig
+
+	mu      sync.Mutex
+	running bool
+	next    time.Time
+	stop    chan struct{}
+}
+
+// Name returns the job name
+func (j *ScheduledJob) Name() string {
+	return j.name
+}
+
+// NextRun returns the next planned activation time
+func (j *ScheduledJob) NextRun() time.Time {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.next
+}
+
+// IsRunning returns true while a run of the job is in progress
+func (j *ScheduledJob) IsRunning() bool {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.running
+}
+
+// =============================================================================
+// SCHEDULER - Cron-style job runner tied to the server lifecycle
+// =============================================================================
+
+// Scheduler runs jobs on cron schedules with timezone support, jitter,
+// and overlap prevention
+type Scheduler struct {
+	jobs     []*ScheduledJob
+	mu       sync.Mutex
+	wg       sync.WaitGroup
+	started  bool
+	stop     chan struct{}   // closed by Shutdown to end the timer loops
+	ctx      context.Context // passed to runs; cancelled once Shutdown gives up waiting
+	cancel   context.CancelFunc
+	pipeline *EventPipeline
+}
+
+// NewScheduler creates a new scheduler; pipeline may be nil
+func NewScheduler(pipeline *EventPipeline) *Scheduler {
+	s := &Scheduler{pipeline: pipeline}
+	s.reset()
+	return s
+}
+
+// Add registers a job on a cron expression (see ParseCron).
+// Jobs added after Start begin running immediately.
+func (s *Scheduler) Add(expr string, job Job, config ...*JobConfig) (*ScheduledJob, error) {
+	schedule, err := ParseCron(expr)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := getJobConfig(config)
+	name := cfg.Name
+	if name == "" {
+		name = expr
+	}
+
+	scheduled := &ScheduledJob{
+		name:     name,
+		schedule: schedule,
+		job:      job,
+		config:   cfg,
+		stop:     make(chan struct{}),
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.jobs = append(s.jobs, scheduled)
+	if s.started {
+		s.launch(scheduled)
+	}
+	return scheduled, nil
+}
+
+// Remove stops and unregisters a job
+func (s *Scheduler) Remove(job *ScheduledJob) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, j := range s.jobs {
+		if j == job {
+			s.jobs = append(s.jobs[:i], s.jobs[i+1:]...)
+			close(j.stop)
+			return
+		}
+	}
+}
+
+// Jobs returns all registered jobs
+func (s *Scheduler) Jobs() []*ScheduledJob {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]*ScheduledJob{}, s.jobs...)
+}
+
+// Start begins running all registered jobs (safe to call multiple times)
+func (s *Scheduler) Start() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.started {
+		return
+	}
+	s.started = true
+	for _, job := range s.jobs {
+		s.launch(job)
+	}
+}
+
+// Shutdown stops scheduling new runs and waits for running jobs to finish.
+// If ctx expires first, running jobs are cancelled and ctx.Err() is returned.
+// The scheduler can be started again afterwards.
+func (s *Scheduler) Shutdown(ctx context.Context) error {
+	s.mu.Lock()
+	if s.started {
+		s.started = false
+		close(s.stop)
+	}
+	cancel := s.cancel
+	s.reset()
+	s.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+
+	var err error
+	select {
+	case <-done:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+	cancel()
+	return err
+}
+
+// --- Internal helpers (KISS) ---
+
+// reset prepares the stop channel and run context for the next Start
+// (caller holds s.mu)
+func (s *Scheduler) reset() {
+	s.stop = make(chan struct{})
+	s.ctx, s.cancel = context.WithCancel(context.Background())
+}
+
+// launch starts the timer loop for a job (caller holds s.mu)
+func (s *Scheduler) launch(job *ScheduledJob) {
+	s.wg.Add(1)
+	go s.loop(s.ctx, job, s.stop)
+}
+
+// loop waits for each activation time and triggers the job until stop closes
+func (s *Scheduler) loop(ctx context.Context, job *ScheduledJob, stop <-chan struct{}) {
+	defer s.wg.Done()
+
+	loc := job.config.Location
+	if loc == nil {
+		loc = time.Local
+	}
+
+	for {
+		next := job.schedule.Next(time.Now().In(loc))
+		if next.IsZero() {
+			return
+		}
+		if job.config.Jitter > 0 {
+			next = next.Add(time.Duration(rand.Int63n(int64(job.config.Jitter))))
+		}
+
+		job.mu.Lock()
+		job.next = next
+		job.mu.Unlock()
+
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-timer.C:
+			s.trigger(ctx, job)
+		case <-job.stop:
+			timer.Stop()
+			return
+		case <-stop:
+			timer.Stop()
+			return
+		}
+	}
+}
+
+// trigger runs the job unless a previous run is still in progress
+func (s *Scheduler) trigger(ctx context.Context, job *ScheduledJob) {
+	job.mu.Lock()
+	if job.running && !job.config.AllowOverlap {
+		job.mu.Unlock()
+		s.pipeline.logger().Warn("job skipped: previous run still in progress", "job", job.name)
+		return
+	}
+	job.running = true
+	job.mu.Unlock()
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		defer func() {
+			job.mu.Lock()
+			job.running = false
+			job.mu.Unlock()
+		}()
+		s.run(ctx, job)
+	}()
+}
+
+// run executes a single job run, emitting pipeline events
+func (s *Scheduler) run(ctx context.Context, job *ScheduledJob) {
+	start := time.Now()
+	s.emit(EventJobStart, job, start, nil)
+
+	err := func() (err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				err = &TaskPanicError{Value: r}
+			}
+		}()
+		return job.job(ctx)
+	}()
+
+	if err != nil {
+		s.pipeline.logger().Error("job failed", "job", job.name, "error", err)
+		s.emit(EventJobError, job, start, err)
+		return
+	}
+	s.emit(EventJobFinish, job, start, nil)
+}
+
+// emit sends a job lifecycle event with job details stored on the context
+func (s *Scheduler) emit(event EventType, job *ScheduledJob, start time.Time, err error) {
+	if s.pipeline == nil {
+		return
+	}
+	ctx := newEventContext()
+	ctx.Set("job", job.name)
+	ctx.Set("job_started", start)
+	if event != EventJobStart {
+		ctx.Set("job_duration", time.Since(start))
+	}
+	if err != nil {
+		ctx.Set("error", err)
+	}
+	s.pipeline.Emit(event, ctx)
+}
+
+func getJobConfig(config []*JobConfig) *JobConfig {
+	if len(config) > 0 && config[0] != nil {
+		return config[0]
+	}
+	return &JobConfig{}
+}
@@ -0,0 +1,243 @@
+package poltergeist
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// =============================================================================
+// CRON PARSER TESTS
+// =============================================================================
+
+func TestParseCron_Next(t *testing.T) {
+	base := time.Date(2025, time.January, 1, 10, 2, 30, 0, time.UTC) // Wednesday
+
+	tests := []struct {
+		expr string
+		want time.Time
+	}{
+		{"*/5 * * * *", time.Date(2025, 1, 1, 10, 5, 0, 0, time.UTC)},
+		{"0 * * * *", time.Date(2025, 1, 1, 11, 0, 0, 0, time.UTC)},
+		{"30 9 * * MON-FRI", time.Date(2025, 1, 2, 9, 30, 0, 0, time.UTC)},
+		{"0 0 1 * *", time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 0 * * 7", time.Date(2025, 1, 5, 0, 0, 0, 0, time.UTC)},
+		{"0 12 15 jun *", time.Date(2025, 6, 15, 12, 0, 0, 0, time.UTC)},
+		{"@daily", time.Date(2025, 1, 2, 0, 0, 0, 0, time.UTC)},
+		{"@every 90s", base.Add(90 * time.Second)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			schedule, err := ParseCron(tt.expr)
+			if err != nil {
+				t.Fatalf("ParseCron(%q) error = %v", tt.expr, err)
+			}
+			if got := schedule.Next(base); !got.Equal(tt.want) {
+				t.Errorf("Next() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseCron_Invalid(t *testing.T) {
+	for _, expr := range []string{"", "* * * *", "60 * * * *", "*/0 * * * *", "5-1 * * * *", "@every nope"} {
+		if _, err := ParseCron(expr); err == nil {
+			t.Errorf("ParseCron(%q) expected error", expr)
+		}
+	}
+}
+
+// =============================================================================
+// SCHEDULER TESTS
+// =============================================================================
+
+func TestScheduler_RunsJobAndEmitsEvents(t *testing.T) {
+	pipeline := NewEventPipeline()
+	var started, finished int32
+	pipeline.OnJobStart(func(c *Context) { atomic.AddInt32(&started, 1) })
+	pipeline.OnJobFinish(func(c *Context) {
+		if c.GetString("job") == "tick" {
+			atomic.AddInt32(&finished, 1)
+		}
+	})
+
+	scheduler := NewScheduler(pipeline)
+	scheduler.Add("@every 10ms", func(ctx context.Context) error {
+		return nil
+	}, &JobConfig{Name: "tick"})
+	scheduler.Start()
+
+	time.Sleep(55 * time.Millisecond)
+	if err := scheduler.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown() error = %v", err)
+	}
+
+	if atomic.LoadInt32(&started) == 0 || atomic.LoadInt32(&finished) == 0 {
+		t.Errorf("started = %d, finished = %d, want both > 0", started, finished)
+	}
+}
+
+func TestScheduler_PreventsOverlap(t *testing.T) {
+	scheduler := NewScheduler(nil)
+	var concurrent, maxConcurrent int32
+
+	scheduler.Add("@every 5ms", func(ctx context.Context) error {
+		n := atomic.AddInt32(&concurrent, 1)
+		if n > atomic.LoadInt32(&maxConcurrent) {
+			atomic.StoreInt32(&maxConcurrent, n)
+		}
+		time.Sleep(30 * time.Millisecond)
+		atomic.AddInt32(&concurrent, -1)
+		return nil
+	})
+	scheduler.Start()
+
+	time.Sleep(80 * time.Millisecond)
+	scheduler.Shutdown(context.Background())
+
+	if maxConcurrent != 1 {
+		t.Errorf("max concurrent runs = %d, want 1", maxConcurrent)
+	}
+}
+
+func TestScheduler_ShutdownLetsRunsFinish(t *testing.T) {
+	scheduler := NewScheduler(nil)
+	running := make(chan struct{}, 1)
+	var cancelled atomic.Bool
+	scheduler.Add("@every 5ms", func(ctx context.Context) error {
+		select {
+		case running <- struct{}{}:
+		default:
+		}
+		select {
+		case <-time.After(30 * time.Millisecond):
+		case <-ctx.Done():
+			cancelled.Store(true)
+		}
+		return nil
+	})
+	scheduler.Start()
+	<-running
+
+	if err := scheduler.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown() error = %v", err)
+	}
+	if cancelled.Load() {
+		t.Error("running job was cancelled instead of finishing")
+	}
+}
+
+func TestScheduler_ShutdownTimeoutCancelsRuns(t *testing.T) {
+	scheduler := NewScheduler(nil)
+	running := make(chan struct{}, 1)
+	cancelled := make(chan struct{})
+	scheduler.Add("@every 5ms", func(ctx context.Context) error {
+		running <- struct{}{}
+		<-ctx.Done()
+		close(cancelled)
+		return ctx.Err()
+	})
+	scheduler.Start()
+	<-running
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := scheduler.Shutdown(ctx); err != context.DeadlineExceeded {
+		t.Fatalf("Shutdown() error = %v, want DeadlineExceeded", err)
+	}
+	select {
+	case <-cancelled:
+	case <-time.After(time.Second):
+		t.Fatal("running job was not cancelled after the deadline")
+	}
+}
+
+func TestScheduler_RestartAfterShutdown(t *testing.T) {
+	scheduler := NewScheduler(nil)
+	var runs atomic.Int32
+	scheduler.Add("@every 5ms", func(ctx context.Context) error {
+		if ctx.Err() != nil {
+			t.Error("run got a cancelled context")
+		}
+		runs.Add(1)
+		return nil
+	})
+
+	for i := 0; i < 2; i++ {
+		before := runs.Load()
+		scheduler.Start()
+		time.Sleep(30 * time.Millisecond)
+		if err := scheduler.Shutdown(context.Background()); err != nil {
+			t.Fatalf("Shutdown() error = %v", err)
+		}
+		if runs.Load() == before {
+			t.Fatalf("start %d: job never ran", i+1)
+		}
+	}
+}
+
+// =============================================================================
+// TIMEZONE TESTS
+// =============================================================================
+
+func TestParseCron_NextInLocation(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skip("timezone data unavailable:", err)
+	}
+	kolkata, err := time.LoadLocation("Asia/Kolkata")
+	if err != nil {
+		t.Skip("timezone data unavailable:", err)
+	}
+	base := time.Date(2025, time.January, 1, 10, 2, 30, 0, time.UTC) // 05:02 in New York, 15:32 in Kolkata
+
+	tests := []struct {
+		name string
+		expr string
+		loc  *time.Location
+		want time.Time
+	}{
+		{"later today", "0 9 * * *", newYork, time.Date(2025, 1, 1, 14, 0, 0, 0, time.UTC)},
+		{"already passed", "0 4 * * *", newYork, time.Date(2025, 1, 2, 9, 0, 0, 0, time.UTC)},
+		{"summer time", "0 9 1 jul *", newYork, time.Date(2025, 7, 1, 13, 0, 0, 0, time.UTC)},
+		{"half-hour offset", "0 16 * * *", kolkata, time.Date(2025, 1, 1, 10, 30, 0, 0, time.UTC)},
+		{"weekday across midnight", "0 0 * * THU", kolkata, time.Date(2025, 1, 1, 18, 30, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schedule, err := ParseCron(tt.expr)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := schedule.Next(base.In(tt.loc))
+			if !got.Equal(tt.want) || got.Location() != tt.loc {
+				t.Errorf("Next() = %v, want %v in %s", got, tt.want, tt.loc)
+			}
+		})
+	}
+}
+
+func TestScheduler_Location(t *testing.T) {
+	tokyo, err := time.LoadLocation("Asia/Tokyo")
+	if err != nil {
+		t.Skip("timezone data unavailable:", err)
+	}
+	scheduler := NewScheduler(nil)
+	job, _ := scheduler.Add("0 9 * * *", func(ctx context.Context) error { return nil }, &JobConfig{Location: tokyo})
+	scheduler.Start()
+	defer scheduler.Shutdown(context.Background())
+
+	deadline := time.Now().Add(time.Second)
+	for job.NextRun().IsZero() && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	next := job.NextRun()
+	if next.Location() != tokyo || next.Hour() != 9 || next.Minute() != 0 {
+		t.Errorf("NextRun() = %v, want 09:00 in Asia/Tokyo", next)
+	}
+	if wait := time.Until(next); wait <= 0 || wait > 24*time.Hour {
+		t.Errorf("NextRun() is %v away, want within a day", wait)
+	}
+}
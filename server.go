@@ -2,11 +2,13 @@ package poltergeist
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"log"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
+	"sync"
 	"syscall"
 	"time"
 )
@@ -27,6 +29,11 @@ type Config struct {
 	TLSCertFile      string        // TLS certificate file
 	TLSKeyFile       string        // TLS key file
 	DevMode          bool          // Development mode (verbose logging)
+	Tasks            *TaskConfig   // Background task queue (default: DefaultTaskConfig())
+	Logger           Logger        // Framework logger (default: slog.Default(); NopLogger silences it)
+	TrustedProxies   []string      // Proxy CIDRs/IPs whose X-Forwarded-For ClientIP believes (default: none)
+	JSON             *JSONConfig   // JSON rendering options (default: DefaultJSONConfig())
+	MaxBodyBuffer    int64         // Max request body bytes c.Body() buffers (default: 4MB)
 }
 
 // DefaultConfig returns sensible default configuration
@@ -49,17 +56,27 @@ func DefaultConfig() *Config {
 
 // Server represents the Poltergeist HTTP server
 type Server struct {
-	router     *Router
-	config     *Config
-	httpServer *http.Server
+	router        *Router
+	config        *Config
+	httpServer    *http.Server
+	tasks         *TaskQueue
+	tasksOnce     sync.Once
+	scheduler     *Scheduler
+	schedOnce     sync.Once
+	shutdownHooks []func(ctx context.Context) error
+	runHooks      []func() error
+	hooksMu       sync.Mutex
+	containerOnce sync.Once
+	listener      net.Listener // set by Serve and dev mode instead of listening on Addr
 }
 
+// ErrSkipServe can be returned by a BeforeRun hook to make Run return
+// without starting the listener (e.g. when only dumping generated docs)
+var ErrSkipServe = errors.New("poltergeist: serve skipped")
+
 // New creates a new Poltergeist server with default configuration
 func New() *Server {
-	return &Server{
-		router: NewRouter(),
-		config: DefaultConfig(),
-	}
+	return NewWithConfig(DefaultConfig())
 }
 
 // NewWithConfig creates a new Poltergeist server with custom configuration
@@ -67,10 +84,23 @@ func NewWithConfig(config *Config) *Server {
 	if config == nil {
 		config = DefaultConfig()
 	}
-	return &Server{
+	s := &Server{
 		router: NewRouter(),
 		config: config,
 	}
+	if config.Logger != nil {
+		s.UseLogger(config.Logger)
+	}
+	s.router.maxBodyBuffer = config.MaxBodyBuffer
+	if config.JSON != nil {
+		s.SetJSONConfig(config.JSON)
+	}
+	if len(config.TrustedProxies) > 0 {
+		if err := s.SetTrustedProxies(config.TrustedProxies...); err != nil {
+			panic(err.Error())
+		}
+	}
+	return s
 }
 
 // =============================================================================
@@ -97,6 +127,33 @@ func (s *Server) Routes() []*Route {
 	return s.router.Routes()
 }
 
+// Tasks returns the background task queue, starting its workers on first use.
+// Queued and in-flight tasks are drained when the server shuts down.
+func (s *Server) Tasks() *TaskQueue {
+	s.tasksOnce.Do(func() {
+		s.tasks = NewTaskQueue(s.config.Tasks)
+		s.tasks.metrics = s.router.pipeline.metrics
+		s.tasks.logs = s.router.pipeline.logs
+		s.tasks.Start()
+		s.OnShutdown(s.tasks.Shutdown)
+	})
+	return s.tasks
+}
+
+// Scheduler returns the job scheduler. Jobs start with Run and stop on shutdown.
+func (s *Server) Scheduler() *Scheduler {
+	s.schedOnce.Do(func() {
+		s.scheduler = NewScheduler(s.router.pipeline)
+		s.OnShutdown(s.scheduler.Shutdown)
+	})
+	return s.scheduler
+}
+
+// Schedule registers a job on a cron expression, e.g. "*/5 * * * *"
+func (s *Server) Schedule(expr string, job Job, config ...*JobConfig) (*ScheduledJob, error) {
+	return s.Scheduler().Add(expr, job, config...)
+}
+
 // =============================================================================
 // MIDDLEWARE - Global middleware management
 // =============================================================================
@@ -116,6 +173,11 @@ func (s *Server) Group(prefix string, middlewares ...MiddlewareFunc) *RouteGroup
 	return s.router.Group(prefix, middlewares...)
 }
 
+// Version creates a route group for an API version (see Router.Version)
+func (s *Server) Version(version string, middlewares ...MiddlewareFunc) *RouteGroup {
+	return s.router.Version(version, middlewares...)
+}
+
 // HTTP methods - all delegate to router
 
 func (s *Server) GET(path string, handler HandlerFunc, middlewares ...MiddlewareFunc) *Route {
@@ -146,8 +208,12 @@ func (s *Server) HEAD(path string, handler HandlerFunc, middlewares ...Middlewar
 	return s.router.HEAD(path, handler, middlewares...)
 }
 
-func (s *Server) Any(path string, handler HandlerFunc, middlewares ...MiddlewareFunc) {
-	s.router.Any(path, handler, middlewares...)
+func (s *Server) Any(path string, handler HandlerFunc, middlewares ...MiddlewareFunc) []*Route {
+	return s.router.Any(path, handler, middlewares...)
+}
+
+func (s *Server) Match(methods []string, path string, handler HandlerFunc, middlewares ...MiddlewareFunc) []*Route {
+	return s.router.Match(methods, path, handler, middlewares...)
 }
 
 // Static serves static files from a directory
@@ -173,18 +239,33 @@ func (s *Server) MethodNotAllowed(handler HandlerFunc) *Server {
 
 // Run starts the server (blocking)
 func (s *Server) Run(addr ...string) error {
+	if err := s.runBeforeRunHooks(); err != nil {
+		if errors.Is(err, ErrSkipServe) {
+			return nil
+		}
+		return err
+	}
+
 	address := s.resolveAddress(addr)
 	s.httpServer = s.createHTTPServer(address)
 
 	s.printBanner(address)
 	s.router.pipeline.Emit(EventServerStart, nil)
 
+	s.Scheduler().Start()
+
 	if s.config.GracefulShutdown {
 		return s.runWithGracefulShutdown()
 	}
 	return s.startServer()
 }
 
+// Serve runs the server on an existing listener (blocking)
+func (s *Server) Serve(listener net.Listener) error {
+	s.listener = listener
+	return s.Run(listener.Addr().String())
+}
+
 // RunTLS starts the server with TLS
 func (s *Server) RunTLS(addr, certFile, keyFile string) error {
 	s.config.Addr = addr
@@ -193,13 +274,34 @@ func (s *Server) RunTLS(addr, certFile, keyFile string) error {
 	return s.Run(addr)
 }
 
-// Shutdown stops the server gracefully
+// Shutdown stops the server gracefully, then runs the OnShutdown hooks; it
+// returns the errors of both joined
 func (s *Server) Shutdown(ctx context.Context) error {
 	if s.httpServer == nil {
-		return nil
+		return s.runShutdownHooks(ctx)
 	}
 	s.router.pipeline.Emit(EventServerStop, nil)
-	return s.httpServer.Shutdown(ctx)
+	// Hooks run even when draining connections failed, so workers still stop
+	err := s.httpServer.Shutdown(ctx)
+	return errors.Join(err, s.runShutdownHooks(ctx))
+}
+
+// BeforeRun registers a hook that runs when Run is called, before the listener
+// starts. Returning ErrSkipServe makes Run return nil without serving.
+func (s *Server) BeforeRun(hook func() error) *Server {
+	s.hooksMu.Lock()
+	defer s.hooksMu.Unlock()
+	s.runHooks = append(s.runHooks, hook)
+	return s
+}
+
+// OnShutdown registers a hook that runs after the HTTP server stopped accepting
+// requests, e.g. to drain background workers. Hooks run in registration order.
+func (s *Server) OnShutdown(hook func(ctx context.Context) error) *Server {
+	s.hooksMu.Lock()
+	defer s.hooksMu.Unlock()
+	s.shutdownHooks = append(s.shutdownHooks, hook)
+	return s
 }
 
 // =============================================================================
@@ -228,7 +330,14 @@ func (s *Server) createHTTPServer(address string) *http.Server {
 
 // startServer starts the HTTP(S) server
 func (s *Server) startServer() error {
-	if s.config.TLSCertFile != "" && s.config.TLSKeyFile != "" {
+	tls := s.config.TLSCertFile != "" && s.config.TLSKeyFile != ""
+	if s.listener != nil {
+		if tls {
+			return s.httpServer.ServeTLS(s.listener, s.config.TLSCertFile, s.config.TLSKeyFile)
+		}
+		return s.httpServer.Serve(s.listener)
+	}
+	if tls {
 		return s.httpServer.ListenAndServeTLS(s.config.TLSCertFile, s.config.TLSKeyFile)
 	}
 	return s.httpServer.ListenAndServe()
@@ -253,23 +362,51 @@ func (s *Server) runWithGracefulShutdown() error {
 	case err := <-errChan:
 		return err
 	case sig := <-quit:
-		log.Printf("⚡ Received signal %v, shutting down gracefully...\n", sig)
+		s.Logger().Info("shutting down gracefully", "signal", sig.String())
 	}
 
-	// Graceful shutdown with timeout
+	// Graceful shutdown with timeout; hooks run even if draining times out
 	ctx, cancel := context.WithTimeout(context.Background(), s.config.ShutdownTimeout)
 	defer cancel()
 
-	s.router.pipeline.Emit(EventServerStop, nil)
-
-	if err := s.httpServer.Shutdown(ctx); err != nil {
+	if err := s.Shutdown(ctx); err != nil {
 		return fmt.Errorf("server shutdown error: %w", err)
 	}
 
-	log.Println("👻 Server stopped gracefully")
+	s.Logger().Info("server stopped gracefully")
 	return nil
 }
 
+// runBeforeRunHooks runs registered BeforeRun hooks, stopping at the first error
+func (s *Server) runBeforeRunHooks() error {
+	s.hooksMu.Lock()
+	hooks := append([]func() error{}, s.runHooks...)
+	s.hooksMu.Unlock()
+
+	for _, hook := range hooks {
+		if err := hook(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// runShutdownHooks runs every registered shutdown hook, returning their
+// errors joined
+func (s *Server) runShutdownHooks(ctx context.Context) error {
+	s.hooksMu.Lock()
+	hooks := append([]func(context.Context) error{}, s.shutdownHooks...)
+	s.hooksMu.Unlock()
+
+	var errs []error
+	for _, hook := range hooks {
+		if err := hook(ctx); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
 // printBanner prints the startup banner
 func (s *Server) printBanner(addr string) {
 	banner := `
@@ -286,6 +423,9 @@ func (s *Server) printBanner(addr string) {
 	if s.config.DevMode {
 		fmt.Println("🔧 Development mode enabled")
 	}
+	if s.router.mock {
+		fmt.Println("🎭 Mock mode: serving declared example responses")
+	}
 	fmt.Println("────────────────────────────────────────")
 }
 
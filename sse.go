@@ -1,7 +1,9 @@
 package poltergeist
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"sync"
@@ -12,6 +14,9 @@ import (
 // SSE CONFIGURATION
 // =============================================================================
 
+// ErrSSEClosed is returned when sending on a closed SSE stream
+var ErrSSEClosed = errors.New("sse: stream closed")
+
 // SSEConfig holds SSE configuration options
 type SSEConfig struct {
 	RetryInterval     int           // Retry interval for client reconnection (ms)
@@ -36,10 +41,13 @@ func DefaultSSEConfig() *SSEConfig {
 
 // SSEEvent represents a Server-Sent Event
 type SSEEvent struct {
-	Event string // Event type
-	Data  any    // Event data
-	ID    string // Event ID
-	Retry int    // Retry interval (ms)
+	Event string            // Event type
+	Data  any               // Event data
+	ID    string            // Event ID
+	Retry int               // Retry interval (ms)
+	Trace map[string]string // Trace context, written as extra fields (traceparent, tracestate)
+
+	ctx context.Context // parent for the broadcast span
 }
 
 // =============================================================================
@@ -85,6 +93,7 @@ func newSSEWriter(w http.ResponseWriter, config *SSEConfig, pipeline *EventPipel
 		lastEventID = ctx.Request.Header.Get("Last-Event-ID")
 	}
 
+	pipeline.instruments().sseConnections.Add(1)
 	return &SSEWriter{
 		w:           w,
 		flusher:     flusher,
@@ -115,7 +124,7 @@ func (s *SSEWriter) Send(event *SSEEvent) error {
 	defer s.closeMu.Unlock()
 
 	if s.closed {
-		return fmt.Errorf("SSE writer closed")
+		return ErrSSEClosed
 	}
 
 	// Write event fields
@@ -134,6 +143,11 @@ func (s *SSEWriter) Send(event *SSEEvent) error {
 			return err
 		}
 	}
+	for _, key := range MapCarrier(event.Trace).Keys() {
+		if _, err := fmt.Fprintf(s.w, "%s: %s\n", key, event.Trace[key]); err != nil {
+			return err
+		}
+	}
 
 	// Write data (serialize if needed)
 	dataStr := s.serializeData(event.Data)
@@ -142,6 +156,7 @@ func (s *SSEWriter) Send(event *SSEEvent) error {
 	}
 
 	s.flusher.Flush()
+	s.pipeline.instruments().sseEvents.Add(1)
 	return nil
 }
 
@@ -181,7 +196,7 @@ func (s *SSEWriter) SendComment(comment string) error {
 	defer s.closeMu.Unlock()
 
 	if s.closed {
-		return fmt.Errorf("SSE writer closed")
+		return ErrSSEClosed
 	}
 
 	if _, err := fmt.Fprintf(s.w, ": %s\n\n", comment); err != nil {
@@ -203,6 +218,7 @@ func (s *SSEWriter) Close() {
 	}
 
 	s.closed = true
+	s.pipeline.instruments().sseConnections.Add(-1)
 	if s.pipeline != nil && s.ctx != nil {
 		s.pipeline.Emit(EventSSEDisconnect, s.ctx)
 	}
@@ -309,11 +325,37 @@ func (h *SSEHub) broadcastToAll(event *SSEEvent) {
 	h.clientMu.RLock()
 	defer h.clientMu.RUnlock()
 
+	clients := make([]*SSEWriter, 0, len(h.clients))
 	for client := range h.clients {
+		clients = append(clients, client)
+	}
+	h.deliver(event, clients)
+}
+
+// deliver sends one event to a batch of clients (clientMu held), tracing the
+// batch when a tracer is active
+func (h *SSEHub) deliver(event *SSEEvent, clients []*SSEWriter) {
+	var span Span
+	if tracer := h.pipeline().tracer(); tracer != nil {
+		event, span = traceBroadcast(tracer, event, len(clients))
+	}
+
+	failed := 0
+	for _, client := range clients {
 		if err := client.Send(event); err != nil {
+			failed++
+			if client.ctx != nil && !clientConnected(client.ctx) {
+				err = fmt.Errorf("%w: %v", ErrSSEClosed, err)
+			}
 			go func(c *SSEWriter) { h.unregister <- c }(client)
+			captureHubFailure(h.pipeline(), "sse", client.id, err)
 		}
 	}
+
+	if span != nil {
+		span.SetAttribute("sse.failed_deliveries", failed)
+		span.End()
+	}
 }
 
 // --- Public API ---
@@ -323,6 +365,14 @@ func (h *SSEHub) Broadcast(event *SSEEvent) {
 	h.broadcast <- event
 }
 
+// BroadcastContext sends an event to all clients; with tracing enabled the
+// delivery span is a child of the span in ctx
+func (h *SSEHub) BroadcastContext(ctx context.Context, event *SSEEvent) {
+	withCtx := *event
+	withCtx.ctx = ctx
+	h.broadcast <- &withCtx
+}
+
 // BroadcastData sends data to all clients
 func (h *SSEHub) BroadcastData(data any) {
 	h.Broadcast(&SSEEvent{Data: data})
@@ -335,16 +385,25 @@ func (h *SSEHub) BroadcastEvent(eventType string, data any) {
 
 // BroadcastToRoom sends an event to all clients in a room
 func (h *SSEHub) BroadcastToRoom(room string, event *SSEEvent) {
+	h.BroadcastToRoomContext(context.Background(), room, event)
+}
+
+// BroadcastToRoomContext sends an event to all clients in a room, tracing the
+// delivery as a child of the span in ctx
+func (h *SSEHub) BroadcastToRoomContext(ctx context.Context, room string, event *SSEEvent) {
 	h.clientMu.RLock()
 	defer h.clientMu.RUnlock()
 
+	var clients []*SSEWriter
 	for _, clientID := range h.getRoomClientIDs(room) {
 		if client, ok := h.clientIndex[clientID]; ok {
-			if err := client.Send(event); err != nil {
-				go func(c *SSEWriter) { h.unregister <- c }(client)
-			}
+			clients = append(clients, client)
 		}
 	}
+
+	withCtx := *event
+	withCtx.ctx = ctx
+	h.deliver(&withCtx, clients)
 }
 
 // JoinRoom adds a client to a room
@@ -380,7 +439,7 @@ type SSEHandler func(ctx *Context, sse *SSEWriter)
 func (s *Server) SSE(path string, handler SSEHandler, config ...*SSEConfig) *Route {
 	cfg := getSSEConfig(config)
 
-	return s.GET(path, func(c *Context) error {
+	route := s.GET(path, func(c *Context) error {
 		sse, err := newSSEWriter(c.Writer, cfg, s.Pipeline(), c)
 		if err != nil {
 			return c.Error(http.StatusInternalServerError, err.Error())
@@ -401,19 +460,22 @@ func (s *Server) SSE(path string, handler SSEHandler, config ...*SSEConfig) *Rou
 		<-done
 		return nil
 	})
+	route.RouteProtocol = ProtocolSSE
+	return route
 }
 
 // SSEWithHub creates an SSE handler with hub support
 func (s *Server) SSEWithHub(path string, hub *SSEHub, handler SSEHandler, config ...*SSEConfig) *Route {
 	cfg := getSSEConfig(config)
 
-	return s.GET(path, func(c *Context) error {
+	route := s.GET(path, func(c *Context) error {
 		sse, err := newSSEWriter(c.Writer, cfg, s.Pipeline(), c)
 		if err != nil {
 			return c.Error(http.StatusInternalServerError, err.Error())
 		}
 		c.SSE = sse
 
+		hub.attach(s.Pipeline())
 		hub.register <- sse
 
 		s.Pipeline().Emit(EventSSEConnect, c)
@@ -430,6 +492,8 @@ func (s *Server) SSEWithHub(path string, hub *SSEHub, handler SSEHandler, config
 		<-done
 		return nil
 	})
+	route.RouteProtocol = ProtocolSSE
+	return route
 }
 
 // --- Helpers (DRY) ---
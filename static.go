@@ -0,0 +1,170 @@
+package poltergeist
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/fs"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// =============================================================================
+// STATIC FILES - Serve directories and embedded file systems
+// =============================================================================
+
+// StaticConfig holds static file serving configuration
+type StaticConfig struct {
+	Index  string        // File served for directories (default: "index.html")
+	Browse bool          // List directories without an index file (default: false)
+	MaxAge time.Duration // Cache-Control max-age for files (default: 0, no header)
+	SPA    bool          // Serve the root index for unknown page paths (single-page apps)
+}
+
+// DefaultStaticConfig returns default static file serving configuration
+func DefaultStaticConfig() *StaticConfig {
+	return &StaticConfig{
+		Index: "index.html",
+	}
+}
+
+// StaticFS serves files from fsys under urlPath, e.g. an embed.FS for
+// single-binary deployments:
+//
+//	//go:embed dist
+//	var dist embed.FS
+//
+//	sub, _ := fs.Sub(dist, "dist")
+//	app.StaticFS("/", sub, &poltergeist.StaticConfig{SPA: true, MaxAge: time.Hour})
+//
+// In SPA mode, GET requests under urlPath for paths without a file extension
+// that accept HTML get the root index (with Cache-Control: no-cache), so
+// client-side routes survive a reload. Routes registered on the server take
+// precedence over the file tree.
+func (r *Router) StaticFS(urlPath string, fsys fs.FS, config ...*StaticConfig) []*Route {
+	cfg := getStaticConfig(config)
+	handler := staticHandler(fsys, cfg)
+	pattern := strings.TrimSuffix(urlPath, "/") + "/*filepath"
+	return []*Route{
+		r.GET(pattern, handler).Hidden(),
+		r.HEAD(pattern, handler).Hidden(),
+	}
+}
+
+// staticHandler serves the file named by the filepath param
+func staticHandler(fsys fs.FS, cfg *StaticConfig) HandlerFunc {
+	return func(c *Context) error {
+		name := strings.TrimPrefix(path.Clean("/"+c.Param("filepath")), "/")
+		if name == "" {
+			name = "."
+		}
+
+		err := serveStatic(c, fsys, name, cfg)
+		if errors.Is(err, fs.ErrNotExist) && cfg.SPA && wantsPage(c.Request, name) {
+			c.SetHeader(HeaderCacheControl, "no-cache")
+			err = serveFile(c, fsys, cfg.Index, 0)
+		}
+		if errors.Is(err, fs.ErrNotExist) {
+			return ErrNotFound
+		}
+		return err
+	}
+}
+
+// serveStatic serves a file, or a directory's index or listing
+func serveStatic(c *Context, fsys fs.FS, name string, cfg *StaticConfig) error {
+	info, err := fs.Stat(fsys, name)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return serveFile(c, fsys, name, cfg.MaxAge)
+	}
+
+	// Directories are served with a trailing slash so relative links resolve
+	if p := c.Request.URL.Path; !strings.HasSuffix(p, "/") {
+		return c.Redirect(http.StatusMovedPermanently, p+"/")
+	}
+	index := path.Join(name, cfg.Index)
+	if _, err := fs.Stat(fsys, index); err == nil {
+		return serveFile(c, fsys, index, cfg.MaxAge)
+	}
+	if !cfg.Browse {
+		return fs.ErrNotExist
+	}
+	req := c.Request.Clone(c.Request.Context())
+	req.URL.Path = "/"
+	if name != "." {
+		req.URL.Path += name + "/"
+	}
+	http.FileServer(http.FS(fsys)).ServeHTTP(c.Writer, req)
+	return nil
+}
+
+// serveFile writes one file with Range and conditional request support
+func serveFile(c *Context, fsys fs.FS, name string, maxAge time.Duration) error {
+	file, err := fsys.Open(name)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+	if info.IsDir() {
+		return fs.ErrNotExist
+	}
+
+	content, ok := file.(io.ReadSeeker)
+	if !ok {
+		data, err := io.ReadAll(file)
+		if err != nil {
+			return err
+		}
+		content = bytes.NewReader(data)
+	}
+	if maxAge > 0 && c.Writer.Header().Get(HeaderCacheControl) == "" {
+		c.SetHeader(HeaderCacheControl, "public, max-age="+strconv.Itoa(int(maxAge.Seconds())))
+	}
+	http.ServeContent(c.Writer, c.Request, info.Name(), info.ModTime(), content)
+	c.written = true
+	return nil
+}
+
+// wantsPage reports whether a missed request looks like a page navigation
+// rather than an asset or API call
+func wantsPage(req *http.Request, name string) bool {
+	if req.Method != http.MethodGet && req.Method != http.MethodHead {
+		return false
+	}
+	if path.Ext(name) != "" {
+		return false
+	}
+	accept := req.Header.Get(HeaderAccept)
+	return accept == "" || strings.Contains(accept, "text/html") || strings.Contains(accept, "*/*")
+}
+
+// getStaticConfig fills unset fields with defaults
+func getStaticConfig(config []*StaticConfig) *StaticConfig {
+	if len(config) == 0 || config[0] == nil {
+		return DefaultStaticConfig()
+	}
+	cfg := *config[0]
+	if cfg.Index == "" {
+		cfg.Index = DefaultStaticConfig().Index
+	}
+	return &cfg
+}
+
+// =============================================================================
+// SERVER INTEGRATION
+// =============================================================================
+
+// StaticFS serves files from fsys under urlPath (see Router.StaticFS)
+func (s *Server) StaticFS(urlPath string, fsys fs.FS, config ...*StaticConfig) []*Route {
+	return s.router.StaticFS(urlPath, fsys, config...)
+}
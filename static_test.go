@@ -0,0 +1,88 @@
+package poltergeist
+
+import (
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+// =============================================================================
+// STATIC FILE TESTS
+// =============================================================================
+
+func TestServer_StaticFS(t *testing.T) {
+	files := fstest.MapFS{
+		"index.html":      {Data: []byte("<app/>")},
+		"assets/app.js":   {Data: []byte("console.log(1)")},
+		"docs/index.html": {Data: []byte("<docs/>")},
+	}
+	app := New()
+	app.GET("/api/users", func(c *Context) error { return c.String(200, "users") })
+	app.StaticFS("/", files, &StaticConfig{SPA: true, MaxAge: time.Hour})
+
+	tests := []struct {
+		path         string
+		accept       string
+		wantCode     int
+		wantBody     string
+		wantCacheCtl string
+	}{
+		{"/", "", 200, "<app/>", "public, max-age=3600"},
+		{"/assets/app.js", "", 200, "console.log(1)", "public, max-age=3600"},
+		{"/docs/", "", 200, "<docs/>", "public, max-age=3600"},
+		{"/docs", "", 301, "", ""},
+		{"/settings/profile", "text/html", 200, "<app/>", "no-cache"},
+		{"/assets/missing.js", "", 404, "", ""},
+		{"/settings/profile", "application/json", 404, "", ""},
+		{"/api/users", "", 200, "users", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			req := httptest.NewRequest("GET", tt.path, nil)
+			if tt.accept != "" {
+				req.Header.Set(HeaderAccept, tt.accept)
+			}
+			w := httptest.NewRecorder()
+			app.ServeHTTP(w, req)
+			if w.Code != tt.wantCode {
+				t.Fatalf("status = %d, want %d", w.Code, tt.wantCode)
+			}
+			if tt.wantBody != "" && w.Body.String() != tt.wantBody {
+				t.Errorf("body = %q, want %q", w.Body.String(), tt.wantBody)
+			}
+			if got := w.Header().Get(HeaderCacheControl); got != tt.wantCacheCtl {
+				t.Errorf("Cache-Control = %q, want %q", got, tt.wantCacheCtl)
+			}
+		})
+	}
+}
+
+func TestServer_StaticDir(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "a.txt"), []byte("hello"), 0o644)
+
+	app := New()
+	app.Static("/files", dir)
+
+	w := httptest.NewRecorder()
+	app.ServeHTTP(w, httptest.NewRequest("GET", "/files/a.txt", nil))
+	if w.Code != 200 || w.Body.String() != "hello" {
+		t.Errorf("file: %d %q", w.Code, w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	app.ServeHTTP(w, httptest.NewRequest("GET", "/files/", nil))
+	if w.Code != 200 || !strings.Contains(w.Body.String(), "a.txt") {
+		t.Errorf("listing: %d %q", w.Code, w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	app.ServeHTTP(w, httptest.NewRequest("GET", "/files/../../etc/passwd", nil))
+	if w.Code != 404 {
+		t.Errorf("traversal: status %d", w.Code)
+	}
+}
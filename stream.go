@@ -0,0 +1,115 @@
+package poltergeist
+
+import (
+	"errors"
+	"io"
+	"net/http"
+)
+
+// =============================================================================
+// STREAMING - Incrementally flushed (chunked) responses
+// =============================================================================
+
+// streamBufferSize is the chunk size StreamReader reads and flushes
+const streamBufferSize = 32 << 10
+
+// Stream writes the response incrementally: step is called until it returns
+// false, and whatever it wrote is flushed to the client after each call, so
+// large exports are never buffered whole. The status is taken from c.Status
+// (default 200); set Content-Type before streaming.
+//
+//	c.SetHeader(poltergeist.HeaderContentType, "application/x-ndjson")
+//	return c.Stream(func(w io.Writer) bool {
+//	    row, ok := rows.Next()
+//	    if !ok {
+//	        return false
+//	    }
+//	    json.NewEncoder(w).Encode(row)
+//	    return true
+//	})
+//
+// Streaming stops when the client disconnects (returning nil) or a write
+// fails (returning the error).
+func (c *Context) Stream(step func(w io.Writer) bool) error {
+	sw := c.startStream()
+	done := c.Request.Context().Done()
+	for {
+		select {
+		case <-done:
+			return nil
+		default:
+		}
+		more := step(sw)
+		if sw.err != nil {
+			return c.streamError(sw.err)
+		}
+		sw.flush()
+		if !more {
+			return nil
+		}
+	}
+}
+
+// StreamReader copies r to the response, flushing after every chunk, e.g.
+// to relay a file or upstream body as it is produced. Close r yourself.
+func (c *Context) StreamReader(r io.Reader) error {
+	sw := c.startStream()
+	buf := make([]byte, streamBufferSize)
+	for {
+		n, readErr := r.Read(buf)
+		if n > 0 {
+			sw.Write(buf[:n])
+			if sw.err != nil {
+				return c.streamError(sw.err)
+			}
+			sw.flush()
+		}
+		if errors.Is(readErr, io.EOF) {
+			return nil
+		}
+		if readErr != nil {
+			return readErr
+		}
+	}
+}
+
+// startStream writes the status line and returns a flushing writer
+func (c *Context) startStream() *streamWriter {
+	if !c.written {
+		c.Writer.WriteHeader(c.statusCode)
+		c.written = true
+	}
+	flusher, _ := c.Writer.(http.Flusher)
+	return &streamWriter{w: c.Writer, flusher: flusher}
+}
+
+// streamError returns err unless the client went away
+func (c *Context) streamError(err error) error {
+	if c.Request.Context().Err() != nil {
+		return nil
+	}
+	return err
+}
+
+// streamWriter remembers the first write error so steps can ignore it
+type streamWriter struct {
+	w       io.Writer
+	flusher http.Flusher
+	err     error
+}
+
+func (s *streamWriter) Write(p []byte) (int, error) {
+	if s.err != nil {
+		return 0, s.err
+	}
+	n, err := s.w.Write(p)
+	s.err = err
+	return n, err
+}
+
+// flush sends buffered output if the writer supports it
+func (s *streamWriter) flush() {
+	if s.flusher != nil {
+		s.flusher.Flush()
+	}
+}
@@ -0,0 +1,108 @@
+package poltergeist
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// =============================================================================
+// STREAMING TESTS
+// =============================================================================
+
+// flushCounter records how often the response was flushed
+type flushCounter struct {
+	*httptest.ResponseRecorder
+	flushes int
+}
+
+func (f *flushCounter) Flush() {
+	f.flushes++
+	f.ResponseRecorder.Flush()
+}
+
+// failingWriter rejects every write
+type failingWriter struct {
+	*httptest.ResponseRecorder
+}
+
+func (failingWriter) Write([]byte) (int, error) { return 0, errors.New("broken pipe") }
+
+func TestContext_Stream(t *testing.T) {
+	w := &flushCounter{ResponseRecorder: httptest.NewRecorder()}
+	c := NewContext(w, httptest.NewRequest(http.MethodGet, "/export", nil))
+	c.SetHeader(HeaderContentType, "text/csv")
+
+	i := 0
+	err := c.Status(http.StatusAccepted).Stream(func(out io.Writer) bool {
+		i++
+		fmt.Fprintf(out, "row,%d\n", i)
+		return i < 3
+	})
+	if err != nil {
+		t.Fatalf("Stream() error = %v", err)
+	}
+	if w.Code != http.StatusAccepted || w.Body.String() != "row,1\nrow,2\nrow,3\n" {
+		t.Errorf("response = %d %q", w.Code, w.Body.String())
+	}
+	if w.flushes != 3 || !c.Written() {
+		t.Errorf("flushes = %d, written = %v", w.flushes, c.Written())
+	}
+}
+
+func TestContext_StreamStops(t *testing.T) {
+	// Client disconnect ends the stream without an error
+	ctx, cancel := context.WithCancel(context.Background())
+	req := httptest.NewRequest(http.MethodGet, "/", nil).WithContext(ctx)
+	c := NewContext(httptest.NewRecorder(), req)
+	steps := 0
+	err := c.Stream(func(out io.Writer) bool {
+		steps++
+		if steps == 2 {
+			cancel()
+		}
+		return true
+	})
+	if err != nil || steps != 2 {
+		t.Errorf("after disconnect: err = %v, steps = %d", err, steps)
+	}
+
+	// Write failures are returned
+	c = NewContext(failingWriter{httptest.NewRecorder()}, httptest.NewRequest(http.MethodGet, "/", nil))
+	err = c.Stream(func(out io.Writer) bool {
+		out.Write([]byte("data"))
+		return true
+	})
+	if err == nil || !strings.Contains(err.Error(), "broken pipe") {
+		t.Errorf("write failure: err = %v", err)
+	}
+}
+
+func TestContext_StreamReader(t *testing.T) {
+	w := &flushCounter{ResponseRecorder: httptest.NewRecorder()}
+	c := NewContext(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	data := strings.Repeat("x", streamBufferSize*2+10)
+
+	if err := c.StreamReader(strings.NewReader(data)); err != nil {
+		t.Fatalf("StreamReader() error = %v", err)
+	}
+	if w.Body.String() != data || w.flushes != 3 {
+		t.Errorf("body length = %d, flushes = %d", w.Body.Len(), w.flushes)
+	}
+
+	c = NewContext(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	readErr := errors.New("upstream reset")
+	if err := c.StreamReader(io.MultiReader(strings.NewReader("a"), &errReader{readErr})); !errors.Is(err, readErr) {
+		t.Errorf("read failure: err = %v", err)
+	}
+}
+
+// errReader fails every read
+type errReader struct{ err error }
+
+func (r *errReader) Read([]byte) (int, error) { return 0, r.err }
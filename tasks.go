@@ -0,0 +1,268 @@
+package poltergeist
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime"
+	"sync"
+	"time"
+)
+
+// =============================================================================
+// TASK QUEUE CONFIGURATION
+// =============================================================================
+
+// TaskConfig holds background task queue configuration options
+type TaskConfig struct {
+	Workers      int                              // Number of workers (default: 4)
+	QueueSize    int                              // Pending task buffer (default: 256)
+	MaxRetries   int                              // Retries after the first failure (default: 3)
+	RetryBackoff time.Duration                    // Initial retry backoff, doubled per attempt (default: 1s)
+	MaxBackoff   time.Duration                    // Upper bound for retry backoff (default: 30s)
+	OnFailure    func(name string, err error)     // Called when a task exhausts its retries
+	OnPanic      func(name string, recovered any) // Called when a task panics
+}
+
+// DefaultTaskConfig returns default task queue configuration
+func DefaultTaskConfig() *TaskConfig {
+	return &TaskConfig{
+		Workers:      DefaultTaskWorkers,
+		QueueSize:    DefaultTaskQueueSize,
+		MaxRetries:   DefaultTaskMaxRetries,
+		RetryBackoff: DefaultTaskRetryBackoff,
+		MaxBackoff:   DefaultTaskMaxBackoff,
+	}
+}
+
+// Task queue errors
+var (
+	ErrTaskQueueClosed = errors.New("task queue is closed")
+	ErrTaskQueueFull   = errors.New("task queue is full")
+)
+
+// =============================================================================
+// TASK - Unit of background work
+// =============================================================================
+
+// Task is a unit of background work executed by the TaskQueue.
+// The context is cancelled when the queue is forced to stop before the task finished.
+type Task func(ctx context.Context) error
+
+// TaskPanicError wraps a value recovered from a panicking task
+type TaskPanicError struct {
+	Value any
+	Stack string
+}
+
+func (e *TaskPanicError) Error() string {
+	return fmt.Sprintf("task panicked: %v", e.Value)
+}
+
+// queuedTask is a task waiting for a worker
+type queuedTask struct {
+	name string
+	run  Task
+}
+
+// =============================================================================
+// TASK QUEUE - Worker pool tied to the server lifecycle
+// =============================================================================
+
+// TaskQueue runs background tasks on a bounded worker pool with retries,
+// panic isolation, and graceful draining on shutdown
+type TaskQueue struct {
+	config  *TaskConfig
+	queue   chan queuedTask
+	wg      sync.WaitGroup
+	mu      sync.RWMutex
+	started bool
+	closed  bool
+	ctx     context.Context
+	cancel  context.CancelFunc
+	metrics *metricsRegistry // set by Server.Tasks
+	logs    *loggerSlot      // set by Server.Tasks
+}
+
+// NewTaskQueue creates a new task queue (call Start to launch workers)
+func NewTaskQueue(config *TaskConfig) *TaskQueue {
+	if config == nil {
+		config = DefaultTaskConfig()
+	} else {
+		copied := *config // the caller's config may be shared, e.g. Config.Tasks
+		config = &copied
+	}
+	if config.Workers <= 0 {
+		config.Workers = DefaultTaskWorkers
+	}
+	if config.QueueSize <= 0 {
+		config.QueueSize = DefaultTaskQueueSize
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	return &TaskQueue{
+		config: config,
+		queue:  make(chan queuedTask, config.QueueSize),
+		ctx:    ctx,
+		cancel: cancel,
+	}
+}
+
+// Start launches the worker pool (safe to call multiple times)
+func (q *TaskQueue) Start() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.started || q.closed {
+		return
+	}
+	q.started = true
+
+	for i := 0; i < q.config.Workers; i++ {
+		q.wg.Add(1)
+		go q.worker()
+	}
+}
+
+// Enqueue schedules an anonymous task
+func (q *TaskQueue) Enqueue(task Task) error {
+	return q.EnqueueNamed("", task)
+}
+
+// EnqueueNamed schedules a task with a name used in logs and failure callbacks
+func (q *TaskQueue) EnqueueNamed(name string, task Task) error {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+
+	if q.closed {
+		return ErrTaskQueueClosed
+	}
+
+	metrics := q.metrics.get()
+	select {
+	case q.queue <- queuedTask{name: name, run: task}:
+		metrics.tasks.Add(1, metricTaskEnqueued)
+		metrics.tasksPending.Set(float64(len(q.queue)))
+		return nil
+	default:
+		metrics.tasks.Add(1, metricTaskRejected)
+		return ErrTaskQueueFull
+	}
+}
+
+// Pending returns the number of tasks waiting for a worker
+func (q *TaskQueue) Pending() int {
+	return len(q.queue)
+}
+
+// Shutdown stops accepting tasks and waits for queued and in-flight tasks to finish.
+// If ctx expires first, running tasks are cancelled and ctx.Err() is returned.
+func (q *TaskQueue) Shutdown(ctx context.Context) error {
+	q.mu.Lock()
+	if q.closed {
+		q.mu.Unlock()
+		return nil
+	}
+	q.closed = true
+	close(q.queue)
+	if !q.started {
+		// Drain with a single worker so queued tasks are not lost
+		q.started = true
+		q.wg.Add(1)
+		go q.worker()
+	}
+	q.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		q.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		q.cancel()
+		return nil
+	case <-ctx.Done():
+		q.cancel()
+		return ctx.Err()
+	}
+}
+
+// --- Internal helpers (KISS) ---
+
+// worker processes tasks until the queue is closed and drained
+func (q *TaskQueue) worker() {
+	defer q.wg.Done()
+	for task := range q.queue {
+		q.process(task)
+	}
+}
+
+// process runs a task with retries and exponential backoff
+func (q *TaskQueue) process(task queuedTask) {
+	metrics := q.metrics.get()
+	metrics.tasksPending.Set(float64(len(q.queue)))
+	start := time.Now()
+	defer func() { metrics.taskDuration.Observe(time.Since(start).Seconds()) }()
+
+	backoff := q.config.RetryBackoff
+	var err error
+
+	for attempt := 0; attempt <= q.config.MaxRetries; attempt++ {
+		if attempt > 0 {
+			if !q.sleep(backoff) {
+				break
+			}
+			backoff = q.nextBackoff(backoff)
+		}
+
+		if err = q.runSafe(task); err == nil {
+			metrics.tasks.Add(1, metricTaskSucceeded)
+			return
+		}
+	}
+
+	metrics.tasks.Add(1, metricTaskFailed)
+	q.logs.get().Error("task failed", "task", task.name, "error", err)
+	if q.config.OnFailure != nil {
+		q.config.OnFailure(task.name, err)
+	}
+}
+
+// runSafe executes a task, converting panics into errors (panic isolation)
+func (q *TaskQueue) runSafe(task queuedTask) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			stack := make([]byte, 4096)
+			stack = stack[:runtime.Stack(stack, false)]
+			err = &TaskPanicError{Value: r, Stack: string(stack)}
+			if q.config.OnPanic != nil {
+				q.config.OnPanic(task.name, r)
+			}
+		}
+	}()
+	return task.run(q.ctx)
+}
+
+// sleep waits for the backoff duration, returning false if the queue was cancelled
+func (q *TaskQueue) sleep(d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return true
+	case <-q.ctx.Done():
+		return false
+	}
+}
+
+// nextBackoff doubles the backoff up to MaxBackoff
+func (q *TaskQueue) nextBackoff(current time.Duration) time.Duration {
+	next := current * 2
+	if q.config.MaxBackoff > 0 && next > q.config.MaxBackoff {
+		return q.config.MaxBackoff
+	}
+	return next
+}
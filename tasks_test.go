@@ -0,0 +1,147 @@
+package poltergeist
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// =============================================================================
+// TASK QUEUE TESTS
+// =============================================================================
+
+func TestTaskQueue_RetriesAndDrain(t *testing.T) {
+	queue := NewTaskQueue(&TaskConfig{
+		Workers:      2,
+		QueueSize:    8,
+		MaxRetries:   2,
+		RetryBackoff: time.Millisecond,
+	})
+	queue.Start()
+
+	var attempts, done int32
+	queue.Enqueue(func(ctx context.Context) error {
+		if atomic.AddInt32(&attempts, 1) < 3 {
+			return errors.New("transient")
+		}
+		atomic.AddInt32(&done, 1)
+		return nil
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := queue.Shutdown(ctx); err != nil {
+		t.Fatalf("Shutdown() error = %v", err)
+	}
+
+	if attempts != 3 || done != 1 {
+		t.Errorf("attempts = %d, done = %d, want 3 and 1", attempts, done)
+	}
+	if err := queue.Enqueue(func(ctx context.Context) error { return nil }); err != ErrTaskQueueClosed {
+		t.Errorf("Enqueue() after Shutdown error = %v, want ErrTaskQueueClosed", err)
+	}
+}
+
+func TestTaskQueue_PanicIsolation(t *testing.T) {
+	var failed atomic.Value
+	queue := NewTaskQueue(&TaskConfig{
+		Workers:   1,
+		QueueSize: 4,
+		OnFailure: func(name string, err error) { failed.Store(err) },
+	})
+	queue.Start()
+
+	queue.EnqueueNamed("boom", func(ctx context.Context) error { panic("boom") })
+
+	var ran int32
+	queue.Enqueue(func(ctx context.Context) error {
+		atomic.StoreInt32(&ran, 1)
+		return nil
+	})
+
+	queue.Shutdown(context.Background())
+
+	var panicErr *TaskPanicError
+	if err, _ := failed.Load().(error); !errors.As(err, &panicErr) {
+		t.Errorf("OnFailure error = %v, want *TaskPanicError", err)
+	}
+	if ran != 1 {
+		t.Error("Task after panic was not executed")
+	}
+}
+
+func TestServer_TasksDrainOnShutdown(t *testing.T) {
+	app := New()
+
+	var ran int32
+	app.Tasks().Enqueue(func(ctx context.Context) error {
+		time.Sleep(10 * time.Millisecond)
+		atomic.StoreInt32(&ran, 1)
+		return nil
+	})
+
+	if err := app.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown() error = %v", err)
+	}
+	if ran != 1 {
+		t.Error("Task was not drained on shutdown")
+	}
+}
+
+func TestServer_ShutdownHooksRunWhenDrainFails(t *testing.T) {
+	app := New()
+	hookErr := errors.New("hook failed")
+	var hooks int32
+	app.OnShutdown(func(context.Context) error {
+		atomic.AddInt32(&hooks, 1)
+		return hookErr
+	})
+	app.OnShutdown(func(context.Context) error {
+		atomic.AddInt32(&hooks, 1)
+		return nil
+	})
+
+	release := make(chan struct{})
+	defer close(release)
+	started := make(chan struct{})
+	app.GET("/slow", func(c *Context) error {
+		close(started)
+		<-release
+		return nil
+	})
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	app.httpServer = &http.Server{Handler: app}
+	go app.httpServer.Serve(ln)
+	go http.Get("http://" + ln.Addr().String() + "/slow")
+	<-started
+
+	// The busy connection makes the drain time out
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	err = app.Shutdown(ctx)
+	if !errors.Is(err, context.DeadlineExceeded) || !errors.Is(err, hookErr) {
+		t.Errorf("Shutdown() error = %v, want the drain and hook errors", err)
+	}
+	if ran := atomic.LoadInt32(&hooks); ran != 2 {
+		t.Errorf("ran %d shutdown hooks, want 2", ran)
+	}
+}
+
+func TestNewTaskQueue_KeepsConfig(t *testing.T) {
+	config := &TaskConfig{MaxRetries: 1}
+	queue := NewTaskQueue(config)
+	defer queue.Shutdown(context.Background())
+	if config.Workers != 0 || config.QueueSize != 0 {
+		t.Errorf("NewTaskQueue modified its config: %+v", config)
+	}
+	if queue.config.Workers != DefaultTaskWorkers {
+		t.Errorf("queue workers = %d, want %d", queue.config.Workers, DefaultTaskWorkers)
+	}
+}
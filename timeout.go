@@ -0,0 +1,194 @@
+package poltergeist
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// =============================================================================
+// TIMEOUTS - Per-route and per-group handler deadlines
+// =============================================================================
+
+// Timeout limits how long the route handler may run, independent of the
+// server's WriteTimeout. The request context gets the deadline; if the
+// handler hasn't returned by then, the client gets 504 Gateway Timeout and
+// anything the handler writes afterwards is discarded. Middleware runs
+// outside the deadline. Realtime routes are never timed out.
+//
+//	app.GET("/reports/:id", buildReport).Timeout(5 * time.Second)
+func (r *Route) Timeout(timeout time.Duration) *Route {
+	r.RouteTimeout = timeout
+	return r
+}
+
+// Timeout sets the handler deadline for routes registered on the group
+// afterwards, including its subgroups (see Route.Timeout)
+func (g *RouteGroup) Timeout(timeout time.Duration) *RouteGroup {
+	g.timeout = timeout
+	return g
+}
+
+// withTimeout runs handler with a deadline on a copy of the context, so a
+// handler that outlives the deadline never touches the pooled context
+func withTimeout(handler HandlerFunc, timeout time.Duration) HandlerFunc {
+	return func(c *Context) error {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), timeout)
+		defer cancel()
+
+		tw := &timeoutWriter{header: make(http.Header)}
+		detached := c.detach(tw, c.Request.WithContext(ctx))
+
+		done := make(chan error, 1)
+		panicked := make(chan any, 1)
+		go func() {
+			defer func() {
+				if p := recover(); p != nil {
+					panicked <- p
+				}
+			}()
+			done <- handler(detached)
+		}()
+
+		finish := func(err error) error {
+			if ctx.Err() != nil && errors.Is(err, context.DeadlineExceeded) {
+				return timeoutError(timeout)
+			}
+			c.adopt(detached)
+			tw.copyTo(c.Writer)
+			return err
+		}
+
+		select {
+		case err := <-done:
+			return finish(err)
+		case p := <-panicked:
+			panic(p)
+		case <-ctx.Done():
+			select {
+			case err := <-done: // finished as the deadline hit
+				return finish(err)
+			default:
+			}
+			tw.expire()
+			if errors.Is(ctx.Err(), context.Canceled) {
+				return nil // the client went away; nobody is waiting for a response
+			}
+			return timeoutError(timeout)
+		}
+	}
+}
+
+// timeoutError is returned when a handler misses its deadline
+func timeoutError(timeout time.Duration) error {
+	return ErrGatewayTimeout.WithMessage(fmt.Sprintf("handler did not finish within %s", timeout))
+}
+
+// detach returns a copy of c writing to w, sharing nothing mutable with c
+func (c *Context) detach(w http.ResponseWriter, req *http.Request) *Context {
+	params := make(map[string]string, len(c.Params))
+	for k, v := range c.Params {
+		params[k] = v
+	}
+	c.mu.RLock()
+	keys := make(map[string]any, len(c.keys))
+	for k, v := range c.keys {
+		keys[k] = v
+	}
+	logger, loggerID, loggerSet := c.logger, c.loggerID, c.loggerSet
+	c.mu.RUnlock()
+
+	return &Context{
+		Writer:     w,
+		Request:    req,
+		Params:     params,
+		statusCode: c.statusCode,
+		written:    c.written,
+		keys:       keys,
+		pipeline:   c.pipeline,
+		container:  c.container,
+		router:     c.router,
+		route:      c.route,
+		body:       c.body,
+		logger:     logger,
+		loggerID:   loggerID,
+		loggerSet:  loggerSet,
+	}
+}
+
+// adopt takes over the response state and values of a finished detached copy
+func (c *Context) adopt(detached *Context) {
+	detached.mu.RLock()
+	keys := detached.keys
+	detached.mu.RUnlock()
+
+	c.mu.Lock()
+	c.keys = keys
+	c.mu.Unlock()
+	c.statusCode = detached.statusCode
+	c.written = detached.written
+	if detached.body != nil {
+		// The handler drained the shared request body; keep it readable
+		c.body = detached.body
+		c.Request.Body = io.NopCloser(bytes.NewReader(c.body))
+	}
+}
+
+// timeoutWriter buffers a timed handler's response until it completes
+type timeoutWriter struct {
+	mu      sync.Mutex
+	header  http.Header
+	status  int
+	body    bytes.Buffer
+	expired bool
+}
+
+func (w *timeoutWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *timeoutWriter) WriteHeader(code int) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.status == 0 && !w.expired {
+		w.status = code
+	}
+}
+
+func (w *timeoutWriter) Write(data []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.expired {
+		return 0, http.ErrHandlerTimeout
+	}
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	return w.body.Write(data)
+}
+
+// expire discards the response and rejects further writes
+func (w *timeoutWriter) expire() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.expired = true
+}
+
+// copyTo writes the buffered response to the real writer
+func (w *timeoutWriter) copyTo(dst http.ResponseWriter) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	header := dst.Header()
+	for k, v := range w.header {
+		header[k] = v
+	}
+	if w.status != 0 {
+		dst.WriteHeader(w.status)
+	}
+	dst.Write(w.body.Bytes())
+}
@@ -0,0 +1,99 @@
+package poltergeist
+
+import (
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// =============================================================================
+// TIMEOUT TESTS
+// =============================================================================
+
+func TestRoute_Timeout(t *testing.T) {
+	app := New().UseLogger(NopLogger)
+	release := make(chan struct{})
+	defer close(release)
+
+	app.GET("/fast", func(c *Context) error {
+		c.Set("user", "ada")
+		c.SetHeader("X-Handler", "1")
+		return c.String(201, "done")
+	}).Timeout(time.Second)
+	app.GET("/cooperative", func(c *Context) error {
+		<-c.Request.Context().Done()
+		return c.Request.Context().Err()
+	}).Timeout(20 * time.Millisecond)
+
+	slow := app.Group("/slow").Timeout(20 * time.Millisecond)
+	slow.GET("/stuck", func(c *Context) error {
+		<-release // ignores the context entirely
+		return c.String(200, "too late")
+	})
+
+	var user any
+	app.Use(func(next HandlerFunc) HandlerFunc {
+		return func(c *Context) error {
+			err := next(c)
+			user, _ = c.Get("user")
+			return err
+		}
+	})
+
+	w := httptest.NewRecorder()
+	app.ServeHTTP(w, httptest.NewRequest("GET", "/fast", nil))
+	if w.Code != 201 || w.Body.String() != "done" || w.Header().Get("X-Handler") != "1" {
+		t.Errorf("fast: %d %q %v", w.Code, w.Body.String(), w.Header())
+	}
+	if user != "ada" {
+		t.Errorf("values set by the handler were lost: %v", user)
+	}
+
+	for _, path := range []string{"/cooperative", "/slow/stuck"} {
+		start := time.Now()
+		w = httptest.NewRecorder()
+		app.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+		if w.Code != 504 {
+			t.Errorf("%s: status %d, want 504", path, w.Code)
+		}
+		if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+			t.Errorf("%s: took %s", path, elapsed)
+		}
+	}
+}
+
+func TestRoute_TimeoutKeepsBody(t *testing.T) {
+	app := New().UseLogger(NopLogger)
+	var after, raw string
+	app.Use(func(next HandlerFunc) HandlerFunc {
+		return func(c *Context) error {
+			err := next(c)
+			body, _ := c.Body()
+			after = string(body)
+			return err
+		}
+	})
+	app.Use(func(next HandlerFunc) HandlerFunc {
+		return func(c *Context) error {
+			err := next(c)
+			data, _ := io.ReadAll(c.Request.Body)
+			raw = string(data)
+			return err
+		}
+	})
+	app.POST("/echo", func(c *Context) error {
+		body, err := c.Body()
+		if err != nil {
+			return err
+		}
+		return c.String(200, string(body))
+	}).Timeout(time.Second)
+
+	w := httptest.NewRecorder()
+	app.ServeHTTP(w, httptest.NewRequest("POST", "/echo", strings.NewReader("payload")))
+	if w.Body.String() != "payload" || after != "payload" || raw != "payload" {
+		t.Errorf("handler %q, middleware Body() %q, Request.Body %q; want the body readable after the handler", w.Body.String(), after, raw)
+	}
+}
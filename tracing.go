@@ -0,0 +1,244 @@
+package poltergeist
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"sync/atomic"
+)
+
+// =============================================================================
+// TRACING - Backend-agnostic spans for HTTP and realtime traffic
+// =============================================================================
+
+// TraceField is the envelope field carrying trace context in WebSocket
+// messages: {"event": "chat", "data": {...}, "trace": {"traceparent": "..."}}.
+// SSE events carry the same keys as extra fields ("traceparent: ..."), which
+// EventSource clients ignore.
+const TraceField = "trace"
+
+// SpanKind describes a span's role
+type SpanKind int
+
+// Span kinds used by the framework
+const (
+	SpanKindServer   SpanKind = iota // HTTP request handling
+	SpanKindConsumer                 // handling an inbound WebSocket message
+	SpanKindProducer                 // an SSE broadcast delivery batch
+)
+
+// TraceCarrier reads and writes propagated trace context (same method set as
+// OpenTelemetry's propagation.TextMapCarrier)
+type TraceCarrier interface {
+	Get(key string) string
+	Set(key, value string)
+	Keys() []string
+}
+
+// Span is an in-progress span
+type Span interface {
+	SetName(name string)
+	SetAttribute(key string, value any)
+	RecordError(err error)
+	End()
+}
+
+// Tracer is a tracing backend (see the tracing package for OpenTelemetry)
+type Tracer interface {
+	// Start begins a span. When carrier holds a trace context it becomes the
+	// parent and the span already in ctx (e.g. the connection's) is linked;
+	// otherwise ctx is the parent. carrier may be nil.
+	Start(ctx context.Context, name string, kind SpanKind, carrier TraceCarrier) (context.Context, Span)
+
+	// Inject writes the span context in ctx into carrier
+	Inject(ctx context.Context, carrier TraceCarrier)
+}
+
+// --- Carriers ---
+
+// HeaderCarrier adapts http.Header to TraceCarrier
+type HeaderCarrier http.Header
+
+func (h HeaderCarrier) Get(key string) string { return http.Header(h).Get(key) }
+func (h HeaderCarrier) Set(key, value string) { http.Header(h).Set(key, value) }
+func (h HeaderCarrier) Keys() []string {
+	keys := make([]string, 0, len(h))
+	for key := range h {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// MapCarrier adapts an envelope's trace map to TraceCarrier
+type MapCarrier map[string]string
+
+func (m MapCarrier) Get(key string) string { return m[key] }
+func (m MapCarrier) Set(key, value string) { m[key] = value }
+func (m MapCarrier) Keys() []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// =============================================================================
+// SERVER INTEGRATION
+// =============================================================================
+
+// tracerBox lets atomic.Value hold any Tracer implementation
+type tracerBox struct{ tracer Tracer }
+
+// tracerSlot holds the active tracer; nil means tracing is off
+type tracerSlot struct {
+	current atomic.Value // tracerBox
+}
+
+// get returns the active tracer or nil
+func (s *tracerSlot) get() Tracer {
+	if s == nil {
+		return nil
+	}
+	box, _ := s.current.Load().(tracerBox)
+	return box.tracer
+}
+
+// UseTracer enables spans for HTTP requests, handled WebSocket messages and
+// SSE hub broadcasts; trace context propagates through request headers and
+// the TraceField of realtime envelopes
+//
+//	app.UseTracer(tracing.NewOTel(otel.Tracer("api"), nil))
+func (s *Server) UseTracer(t Tracer) *Server {
+	s.router.pipeline.tracing.current.Store(tracerBox{t})
+	return s
+}
+
+// Tracer returns the active tracer (nil when tracing is off)
+func (s *Server) Tracer() Tracer {
+	return s.router.pipeline.tracer()
+}
+
+// --- HTTP spans ---
+
+// startRequestSpan starts the server span for a request
+func startRequestSpan(tracer Tracer, req *http.Request) (*http.Request, Span) {
+	ctx, span := tracer.Start(req.Context(), "HTTP "+req.Method, SpanKindServer, HeaderCarrier(req.Header))
+	return req.WithContext(ctx), span
+}
+
+// finishRequestSpan names the span after the matched route and ends it
+func finishRequestSpan(span Span, c *Context, err error) {
+	if c.route != nil {
+		span.SetName(c.Request.Method + " " + c.route.Path)
+		span.SetAttribute("http.route", c.route.Path)
+	}
+	span.SetAttribute("http.request.method", c.Request.Method)
+	span.SetAttribute("url.path", c.Request.URL.Path)
+	span.SetAttribute("http.response.status_code", c.statusCode)
+	if err != nil {
+		span.RecordError(err)
+	}
+	span.End()
+}
+
+// --- WebSocket spans ---
+
+// wsEnvelope is the event envelope realtime messages use
+type wsEnvelope struct {
+	Event   string            `json:"event"`
+	Data    any               `json:"data,omitempty"`
+	ID      string            `json:"id,omitempty"`
+	Trace   map[string]string `json:"trace,omitempty"`
+	EventID string            `json:"event_id,omitempty"` // history position, see WSHub.UseHistory
+}
+
+// startMessageSpan starts a consumer span for an inbound WebSocket message,
+// continuing the trace in the envelope's trace field when present
+func (c *WSConn) startMessageSpan(tracer Tracer, message []byte) (context.Context, Span) {
+	var envelope struct {
+		Event string            `json:"event"`
+		Trace map[string]string `json:"trace"`
+	}
+	if len(message) > 0 && message[0] == '{' {
+		json.Unmarshal(message, &envelope)
+	}
+
+	path := c.ctx.Request.URL.Path
+	if c.ctx.route != nil {
+		path = c.ctx.route.Path
+	}
+	name := "WS " + path + " receive"
+	if envelope.Event != "" {
+		name = "WS " + path + " " + envelope.Event
+	}
+
+	var carrier TraceCarrier
+	if len(envelope.Trace) > 0 {
+		carrier = MapCarrier(envelope.Trace)
+	}
+	ctx, span := tracer.Start(c.ctx.Request.Context(), name, SpanKindConsumer, carrier)
+	span.SetAttribute("messaging.system", "websocket")
+	span.SetAttribute("messaging.operation", "receive")
+	span.SetAttribute("messaging.destination.name", path)
+	span.SetAttribute("messaging.message.body.size", len(message))
+	if envelope.Event != "" {
+		span.SetAttribute("ws.event", envelope.Event)
+	}
+	return ctx, span
+}
+
+// Context returns the trace context of the message being handled (inside a
+// WSMessageHandler), or the upgrade request's context otherwise
+func (c *WSConn) Context() context.Context {
+	if c.msgCtx != nil {
+		return c.msgCtx
+	}
+	if c.ctx != nil && c.ctx.Request != nil {
+		return c.ctx.Request.Context()
+	}
+	return context.Background()
+}
+
+// SendEvent sends an {"event", "data", "trace"} envelope, injecting the trace
+// context from ctx so clients can continue the trace
+func (c *WSConn) SendEvent(ctx context.Context, event string, data any) error {
+	envelope := wsEnvelope{Event: event, Data: data}
+	if tracer := c.pipeline.tracer(); tracer != nil {
+		carrier := MapCarrier{}
+		tracer.Inject(ctx, carrier)
+		if len(carrier) > 0 {
+			envelope.Trace = carrier
+		}
+	}
+	return c.SendJSON(envelope)
+}
+
+// --- SSE spans ---
+
+// traceBroadcast starts a producer span for one broadcast delivery batch and
+// returns a copy of the event carrying the span's trace context
+func traceBroadcast(tracer Tracer, event *SSEEvent, recipients int) (*SSEEvent, Span) {
+	parent := event.ctx
+	if parent == nil {
+		parent = context.Background()
+	}
+	name := "SSE broadcast"
+	if event.Event != "" {
+		name += " " + event.Event
+	}
+
+	ctx, span := tracer.Start(parent, name, SpanKindProducer, nil)
+	span.SetAttribute("messaging.system", "sse")
+	span.SetAttribute("messaging.operation", "publish")
+	span.SetAttribute("messaging.batch.message_count", recipients)
+	if event.Event != "" {
+		span.SetAttribute("sse.event", event.Event)
+	}
+
+	traced := *event
+	traced.Trace = MapCarrier{}
+	tracer.Inject(ctx, MapCarrier(traced.Trace))
+	return &traced, span
+}
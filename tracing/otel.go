@@ -0,0 +1,119 @@
+// Package tracing provides an OpenTelemetry implementation of
+// poltergeist.Tracer:
+//
+//	app.UseTracer(tracing.NewOTel(otel.Tracer("api"), nil))
+//
+// HTTP requests get server spans continuing the caller's traceparent header.
+// Each handled WebSocket message gets a consumer span whose parent is the
+// trace in the message envelope ({"event", "data", "trace"}) and which links
+// to the connection's span. Each SSE hub broadcast gets a producer span, and
+// its trace context is sent to clients as traceparent/tracestate fields.
+package tracing
+
+import (
+	"context"
+	"fmt"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/gofuckbiz/poltergeist"
+)
+
+// =============================================================================
+// OPENTELEMETRY TRACER
+// =============================================================================
+
+// OTel adapts an OpenTelemetry tracer and propagator
+type OTel struct {
+	tracer     trace.Tracer
+	propagator propagation.TextMapPropagator
+}
+
+// NewOTel creates a tracer; a nil propagator uses W3C trace context and baggage
+func NewOTel(tracer trace.Tracer, propagator propagation.TextMapPropagator) *OTel {
+	if propagator == nil {
+		propagator = propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{})
+	}
+	return &OTel{tracer: tracer, propagator: propagator}
+}
+
+// Start begins a span, continuing a remote trace found in carrier and linking
+// the span already in ctx
+func (o *OTel) Start(ctx context.Context, name string, kind poltergeist.SpanKind, carrier poltergeist.TraceCarrier) (context.Context, poltergeist.Span) {
+	opts := []trace.SpanStartOption{trace.WithSpanKind(spanKind(kind))}
+
+	parent := ctx
+	if carrier != nil {
+		remote := o.propagator.Extract(ctx, carrier)
+		if sc := trace.SpanContextFromContext(remote); sc.IsValid() && sc.IsRemote() {
+			if local := trace.SpanContextFromContext(ctx); local.IsValid() && !local.Equal(sc) {
+				opts = append(opts, trace.WithLinks(trace.Link{SpanContext: local}))
+			}
+			parent = remote
+		}
+	}
+
+	ctx, span := o.tracer.Start(parent, name, opts...)
+	return ctx, &otelSpan{span: span}
+}
+
+// Inject writes the span context in ctx into carrier
+func (o *OTel) Inject(ctx context.Context, carrier poltergeist.TraceCarrier) {
+	o.propagator.Inject(ctx, carrier)
+}
+
+// spanKind maps framework span kinds to OpenTelemetry ones
+func spanKind(kind poltergeist.SpanKind) trace.SpanKind {
+	switch kind {
+	case poltergeist.SpanKindConsumer:
+		return trace.SpanKindConsumer
+	case poltergeist.SpanKindProducer:
+		return trace.SpanKindProducer
+	default:
+		return trace.SpanKindServer
+	}
+}
+
+// --- Span ---
+
+type otelSpan struct {
+	span trace.Span
+}
+
+func (s *otelSpan) SetName(name string) {
+	s.span.SetName(name)
+}
+
+func (s *otelSpan) SetAttribute(key string, value any) {
+	s.span.SetAttributes(attributeOf(key, value))
+}
+
+func (s *otelSpan) RecordError(err error) {
+	s.span.RecordError(err)
+	s.span.SetStatus(codes.Error, err.Error())
+}
+
+func (s *otelSpan) End() {
+	s.span.End()
+}
+
+// attributeOf converts a value into a typed attribute
+func attributeOf(key string, value any) attribute.KeyValue {
+	switch v := value.(type) {
+	case string:
+		return attribute.String(key, v)
+	case int:
+		return attribute.Int(key, v)
+	case int64:
+		return attribute.Int64(key, v)
+	case float64:
+		return attribute.Float64(key, v)
+	case bool:
+		return attribute.Bool(key, v)
+	default:
+		return attribute.String(key, fmt.Sprint(v))
+	}
+}
@@ -0,0 +1,197 @@
+package poltergeist
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// =============================================================================
+// TRACING TESTS
+// =============================================================================
+
+type spanKey struct{}
+
+// recordedSpan captures what the framework reported
+type recordedSpan struct {
+	mu     sync.Mutex
+	name   string
+	kind   SpanKind
+	parent string // traceparent the span continued, if any
+	attrs  map[string]any
+	ended  bool
+}
+
+func (s *recordedSpan) SetName(name string) {
+	s.mu.Lock()
+	s.name = name
+	s.mu.Unlock()
+}
+func (s *recordedSpan) SetAttribute(key string, value any) {
+	s.mu.Lock()
+	s.attrs[key] = value
+	s.mu.Unlock()
+}
+func (s *recordedSpan) RecordError(error) {}
+func (s *recordedSpan) End() {
+	s.mu.Lock()
+	s.ended = true
+	s.mu.Unlock()
+}
+func (s *recordedSpan) attr(key string) any {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.attrs[key]
+}
+
+// recordingTracer propagates the span name as the "traceparent"
+type recordingTracer struct {
+	mu    sync.Mutex
+	spans []*recordedSpan
+}
+
+func (t *recordingTracer) Start(ctx context.Context, name string, kind SpanKind, carrier TraceCarrier) (context.Context, Span) {
+	span := &recordedSpan{name: name, kind: kind, attrs: make(map[string]any)}
+	if carrier != nil {
+		span.parent = carrier.Get("traceparent")
+	}
+	t.mu.Lock()
+	t.spans = append(t.spans, span)
+	t.mu.Unlock()
+	return context.WithValue(ctx, spanKey{}, span), span
+}
+
+func (t *recordingTracer) Inject(ctx context.Context, carrier TraceCarrier) {
+	if span, ok := ctx.Value(spanKey{}).(*recordedSpan); ok {
+		span.mu.Lock()
+		carrier.Set("traceparent", span.name)
+		span.mu.Unlock()
+	}
+}
+
+func (t *recordingTracer) find(kind SpanKind) *recordedSpan {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, span := range t.spans {
+		if span.kind == kind {
+			return span
+		}
+	}
+	return nil
+}
+
+func TestTracing_HTTPSpan(t *testing.T) {
+	tracer := &recordingTracer{}
+	app := New().UseTracer(tracer)
+	app.GET("/users/:id", func(c *Context) error {
+		return c.String(201, "ok")
+	})
+
+	req := httptest.NewRequest("GET", "/users/7", nil)
+	req.Header.Set("traceparent", "caller")
+	app.Router().ServeHTTP(httptest.NewRecorder(), req)
+
+	span := tracer.find(SpanKindServer)
+	if span == nil {
+		t.Fatal("no server span recorded")
+	}
+	if span.name != "GET /users/:id" || span.parent != "caller" || !span.ended {
+		t.Errorf("span = %q parent %q ended %v", span.name, span.parent, span.ended)
+	}
+	if got := span.attr("http.response.status_code"); got != 201 {
+		t.Errorf("status attribute = %v, want 201", got)
+	}
+}
+
+func TestTracing_WebSocketMessageSpan(t *testing.T) {
+	tracer := &recordingTracer{}
+	app := New().UseTracer(tracer)
+	app.WebSocket("/ws", func(conn *WSConn, _ int, _ []byte) {
+		conn.SendEvent(conn.Context(), "ack", H{"ok": true})
+	})
+
+	server := httptest.NewServer(app.Router())
+	defer server.Close()
+
+	ws, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"/ws", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ws.Close()
+
+	ws.WriteJSON(H{"event": "chat", "data": "hi", TraceField: H{"traceparent": "client"}})
+	ws.SetReadDeadline(time.Now().Add(2 * time.Second))
+	var reply wsEnvelope
+	if err := ws.ReadJSON(&reply); err != nil {
+		t.Fatal(err)
+	}
+
+	span := tracer.find(SpanKindConsumer)
+	if span == nil {
+		t.Fatal("no message span recorded")
+	}
+	if span.name != "WS /ws chat" || span.parent != "client" {
+		t.Errorf("span = %q parent %q", span.name, span.parent)
+	}
+	if reply.Event != "ack" || reply.Trace["traceparent"] != "WS /ws chat" {
+		t.Errorf("reply = %+v, want ack carrying the message span", reply)
+	}
+}
+
+func TestTracing_SSEBroadcastSpan(t *testing.T) {
+	tracer := &recordingTracer{}
+	app := New().UseTracer(tracer)
+	hub := NewSSEHub()
+	go hub.Run()
+	defer hub.ShutdownWithTimeout(time.Second)
+
+	app.SSEWithHub("/events", hub, func(*Context, *SSEWriter) {})
+	server := httptest.NewServer(app.Router())
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/events")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for hub.ClientCount() == 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	hub.BroadcastEvent("tick", H{"n": 1})
+
+	// Skip the initial retry block, then read the broadcast event
+	fields := make(map[string]string)
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			if fields["event"] != "" {
+				break
+			}
+			continue
+		}
+		key, value, _ := strings.Cut(line, ": ")
+		fields[key] = value
+	}
+
+	if fields["event"] != "tick" || fields["traceparent"] != "SSE broadcast tick" {
+		t.Errorf("event fields = %v, want traceparent of the broadcast span", fields)
+	}
+	var data map[string]int
+	if err := json.Unmarshal([]byte(fields["data"]), &data); err != nil || data["n"] != 1 {
+		t.Errorf("data = %q", fields["data"])
+	}
+	if span := tracer.find(SpanKindProducer); span == nil || span.attr("messaging.batch.message_count") != 1 {
+		t.Error("broadcast span missing or without recipient count")
+	}
+}
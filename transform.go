@@ -0,0 +1,276 @@
+package poltergeist
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// =============================================================================
+// REQUEST REWRITES - Rewriting requests before routing
+// =============================================================================
+
+// RequestRewrite rewrites a request before it is routed (path, query,
+// headers), e.g. to map legacy URLs onto current routes
+type RequestRewrite func(req *http.Request)
+
+// rewriteRule is a RequestRewrite limited to a path prefix
+type rewriteRule struct {
+	prefix  string
+	rewrite RequestRewrite
+}
+
+// RewriteRequest registers a rewrite applied to every request before routing
+func (r *Router) RewriteRequest(rewrite RequestRewrite) *Router {
+	r.rewrites = append(r.rewrites, rewriteRule{rewrite: rewrite})
+	return r
+}
+
+// rewriteRequest runs the rewrites matching the request path, in order
+func (r *Router) rewriteRequest(req *http.Request) {
+	for _, rule := range r.rewrites {
+		if rule.prefix == "" || hasPathPrefix(req.URL.Path, rule.prefix) {
+			rule.rewrite(req)
+		}
+	}
+}
+
+// hasPathPrefix reports whether p is prefix or lies below it
+func hasPathPrefix(p, prefix string) bool {
+	prefix = strings.TrimSuffix(prefix, "/")
+	return p == prefix || strings.HasPrefix(p, prefix+"/")
+}
+
+// RewritePath returns a rewrite replacing the path prefix from with to,
+// e.g. RewritePath("/api/legacy/users", "/v2/users")
+func RewritePath(from, to string) RequestRewrite {
+	return func(req *http.Request) {
+		if hasPathPrefix(req.URL.Path, from) {
+			req.URL.Path = to + strings.TrimPrefix(req.URL.Path, strings.TrimSuffix(from, "/"))
+			req.URL.RawPath = ""
+		}
+	}
+}
+
+// =============================================================================
+// RESPONSE TRANSFORMS - Post-processing buffered responses
+// =============================================================================
+
+// Response is a buffered response handed to response transforms, which may
+// change any part of it
+type Response struct {
+	Status int
+	Header http.Header
+	Body   []byte
+}
+
+// IsJSON reports whether the response has a JSON content type
+func (res *Response) IsJSON() bool {
+	return strings.HasPrefix(res.Header.Get(HeaderContentType), "application/json")
+}
+
+// ResponseTransform post-processes a response after the handler and error
+// handling ran (envelopes, redaction, legacy field names). Returning an
+// error replaces the response with a 500. Responses already encoded by
+// middleware (Content-Encoding set, e.g. by middleware.Compress) are
+// passed through untransformed.
+type ResponseTransform func(c *Context, res *Response) error
+
+// TransformResponse registers a transform applied to every response
+func (r *Router) TransformResponse(transform ResponseTransform) *Router {
+	r.transforms = append(r.transforms, transform)
+	return r
+}
+
+// responseTransforms returns the transforms for a route (nil for 404/405):
+// global ones first, then group and route ones. Realtime routes are
+// streamed, never buffered.
+func (r *Router) responseTransforms(route *Route) []ResponseTransform {
+	if route == nil {
+		return r.transforms
+	}
+	if route.RouteProtocol != "" || len(r.transforms)+len(route.ResponseTransforms) == 0 {
+		return nil
+	}
+	return append(append([]ResponseTransform{}, r.transforms...), route.ResponseTransforms...)
+}
+
+// transformWriter buffers a response until the transforms have run
+type transformWriter struct {
+	http.ResponseWriter
+	status     int
+	body       bytes.Buffer
+	transforms []ResponseTransform
+}
+
+func (w *transformWriter) WriteHeader(code int) {
+	if w.status == 0 {
+		w.status = code
+	}
+}
+
+func (w *transformWriter) Write(data []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	return w.body.Write(data)
+}
+
+// bufferResponse starts buffering the response of c. The context keeps the
+// buffer itself, so middleware wrapping or replacing c.Writer can't hide it.
+func (r *Router) bufferResponse(c *Context, transforms []ResponseTransform) {
+	c.transform = &transformWriter{ResponseWriter: c.Writer, transforms: transforms}
+	c.Writer = c.transform
+}
+
+// flushResponse runs the transforms over a buffered response and writes it
+func (r *Router) flushResponse(c *Context) {
+	w := c.transform
+	if w == nil {
+		return
+	}
+	c.transform = nil
+	c.Writer = w.ResponseWriter
+
+	res := &Response{Status: w.status, Header: w.Header(), Body: w.body.Bytes()}
+	if res.Status == 0 {
+		res.Status = c.statusCode
+	}
+	transforms := w.transforms
+	if res.Header.Get("Content-Encoding") != "" {
+		transforms = nil // encoded bytes, e.g. by middleware.Compress
+	}
+	for _, transform := range transforms {
+		if err := transform(c, res); err != nil {
+			r.pipeline.logger().Error("response transform failed", "path", c.Path(), "error", err)
+			c.written = false
+			r.errors.respond(c, ErrInternalServerError.Wrap(err))
+			return
+		}
+	}
+
+	res.Header.Del("Content-Length")
+	if len(res.Body) > 0 {
+		res.Header.Set("Content-Length", strconv.Itoa(len(res.Body)))
+	}
+	c.Writer.WriteHeader(res.Status)
+	c.statusCode = res.Status
+	c.Writer.Write(res.Body)
+}
+
+// --- Group and route configuration ---
+
+// RewriteRequest registers a rewrite for requests under the group prefix
+func (g *RouteGroup) RewriteRequest(rewrite RequestRewrite) *RouteGroup {
+	g.router.rewrites = append(g.router.rewrites, rewriteRule{prefix: g.prefix, rewrite: rewrite})
+	return g
+}
+
+// TransformResponse registers a transform for routes added to the group
+// afterwards (and to its subgroups)
+func (g *RouteGroup) TransformResponse(transform ResponseTransform) *RouteGroup {
+	g.transforms = append(g.transforms, transform)
+	return g
+}
+
+// Transform adds a response transform to the route
+func (r *Route) Transform(transforms ...ResponseTransform) *Route {
+	r.ResponseTransforms = append(r.ResponseTransforms, transforms...)
+	return r
+}
+
+// --- Server delegation ---
+
+// RewriteRequest registers a rewrite applied to every request before routing
+func (s *Server) RewriteRequest(rewrite RequestRewrite) *Server {
+	s.router.RewriteRequest(rewrite)
+	return s
+}
+
+// TransformResponse registers a transform applied to every response
+func (s *Server) TransformResponse(transform ResponseTransform) *Server {
+	s.router.TransformResponse(transform)
+	return s
+}
+
+// =============================================================================
+// BUILT-IN TRANSFORMS
+// =============================================================================
+
+// Envelope wraps JSON responses as {"data": ..., "meta": ..., "errors": [...]}.
+// Error responses (status >= 400) become errors; meta is whatever the
+// handler stored with c.Set(ContextKeyResponseMeta, ...).
+func Envelope() ResponseTransform {
+	return func(c *Context, res *Response) error {
+		if !res.IsJSON() {
+			return nil
+		}
+		body := bytes.TrimSpace(res.Body)
+		if len(body) == 0 {
+			body = []byte("null")
+		}
+
+		envelope := map[string]any{"data": json.RawMessage(body), "errors": []json.RawMessage{}}
+		if res.Status >= http.StatusBadRequest {
+			envelope["data"] = nil
+			envelope["errors"] = []json.RawMessage{body}
+		}
+		if meta, ok := c.Get(ContextKeyResponseMeta); ok {
+			envelope["meta"] = meta
+		}
+
+		data, err := json.Marshal(envelope)
+		if err != nil {
+			return err
+		}
+		res.Body = append(data, '\n')
+		return nil
+	}
+}
+
+// Redact removes the named fields, at any depth, from JSON responses,
+// e.g. Redact("password", "ssn")
+func Redact(fields ...string) ResponseTransform {
+	redacted := make(map[string]bool, len(fields))
+	for _, f := range fields {
+		redacted[f] = true
+	}
+	return func(c *Context, res *Response) error {
+		if !res.IsJSON() || len(bytes.TrimSpace(res.Body)) == 0 {
+			return nil
+		}
+		var v any
+		decoder := json.NewDecoder(bytes.NewReader(res.Body))
+		decoder.UseNumber()
+		if err := decoder.Decode(&v); err != nil {
+			return err
+		}
+		data, err := json.Marshal(redact(v, redacted))
+		if err != nil {
+			return err
+		}
+		res.Body = append(data, '\n')
+		return nil
+	}
+}
+
+// redact walks a decoded JSON value removing redacted keys
+func redact(v any, fields map[string]bool) any {
+	switch value := v.(type) {
+	case map[string]any:
+		for key, item := range value {
+			if fields[key] {
+				delete(value, key)
+				continue
+			}
+			value[key] = redact(item, fields)
+		}
+	case []any:
+		for i, item := range value {
+			value[i] = redact(item, fields)
+		}
+	}
+	return v
+}
@@ -0,0 +1,158 @@
+package poltergeist
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+)
+
+// =============================================================================
+// TRANSFORM TESTS
+// =============================================================================
+
+func TestRouter_RewriteRequest(t *testing.T) {
+	router := NewRouter()
+	router.RewriteRequest(RewritePath("/api/legacy/users", "/v2/users"))
+	router.RewriteRequest(func(req *http.Request) {
+		if v := req.Header.Get("X-Api-Token"); v != "" {
+			req.Header.Set("Authorization", "Bearer "+v)
+		}
+	})
+
+	router.GET("/v2/users/:id", func(c *Context) error {
+		return c.String(200, c.Param("id")+" "+c.Header("Authorization"))
+	})
+
+	req := httptest.NewRequest("GET", "/api/legacy/users/7", nil)
+	req.Header.Set("X-Api-Token", "secret")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != 200 || w.Body.String() != "7 Bearer secret" {
+		t.Errorf("rewritten request = %d %q", w.Code, w.Body.String())
+	}
+}
+
+func TestRouter_GroupRewriteRequest(t *testing.T) {
+	router := NewRouter()
+	legacy := router.Group("/old")
+	legacy.RewriteRequest(RewritePath("/old", "/new"))
+	router.GET("/new/ping", func(c *Context) error {
+		return c.String(200, "pong")
+	})
+	router.GET("/other/old/ping", func(c *Context) error {
+		return c.String(200, "untouched")
+	})
+
+	for path, want := range map[string]string{"/old/ping": "pong", "/other/old/ping": "untouched"} {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+		if w.Body.String() != want {
+			t.Errorf("GET %s = %q, want %q", path, w.Body.String(), want)
+		}
+	}
+}
+
+func TestRouter_EnvelopeTransform(t *testing.T) {
+	router := NewRouter()
+	api := router.Group("/api")
+	api.TransformResponse(Envelope())
+
+	api.GET("/users", func(c *Context) error {
+		c.Set(ContextKeyResponseMeta, H{"total": 1})
+		return c.JSON(200, []H{{"name": "John"}})
+	})
+	api.GET("/missing", func(c *Context) error {
+		return ErrNotFound.WithMessage("user not found")
+	})
+	router.GET("/plain", func(c *Context) error {
+		return c.JSON(200, H{"ok": true})
+	})
+
+	var body struct {
+		Data   []map[string]any `json:"data"`
+		Meta   map[string]any   `json:"meta"`
+		Errors []map[string]any `json:"errors"`
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/api/users", nil))
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("decode: %v (%s)", err, w.Body.String())
+	}
+	if len(body.Data) != 1 || body.Data[0]["name"] != "John" || body.Meta["total"] != float64(1) || len(body.Errors) != 0 {
+		t.Errorf("envelope = %s", w.Body.String())
+	}
+
+	// Errors rendered by the central handler are enveloped too
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/api/missing", nil))
+	body.Data, body.Errors = nil, nil
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if w.Code != 404 || body.Data != nil || len(body.Errors) != 1 || body.Errors[0]["error"] != "user not found" {
+		t.Errorf("error envelope = %d %s", w.Code, w.Body.String())
+	}
+
+	// Routes outside the group are untouched
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/plain", nil))
+	if w.Body.String() != "{\"ok\":true}\n" {
+		t.Errorf("plain body = %q", w.Body.String())
+	}
+}
+
+func TestRouter_RedactTransform(t *testing.T) {
+	router := NewRouter()
+	router.TransformResponse(Redact("password"))
+	router.GET("/users/1", func(c *Context) error {
+		return c.JSON(200, H{"name": "John", "password": "x", "friends": []H{{"name": "Jane", "password": "y"}}})
+	})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/users/1", nil))
+	if w.Body.String() != "{\"friends\":[{\"name\":\"Jane\"}],\"name\":\"John\"}\n" {
+		t.Errorf("redacted body = %q", w.Body.String())
+	}
+	if got := w.Header().Get("Content-Length"); got != strconv.Itoa(w.Body.Len()) {
+		t.Errorf("Content-Length = %q, want %d", got, w.Body.Len())
+	}
+}
+
+// wrappingWriter stands in for middleware that wraps c.Writer
+type wrappingWriter struct {
+	http.ResponseWriter
+}
+
+func TestRouter_TransformWithWrappedWriter(t *testing.T) {
+	router := NewRouter()
+	router.TransformResponse(Redact("secret"))
+	router.Use(func(next HandlerFunc) HandlerFunc {
+		return func(c *Context) error {
+			c.Writer = &wrappingWriter{c.Writer} // never restored
+			return next(c)
+		}
+	})
+	router.GET("/user", func(c *Context) error {
+		return c.JSON(200, H{"name": "ada", "secret": "x"})
+	})
+	router.GET("/encoded", func(c *Context) error {
+		c.SetHeader("Content-Encoding", "gzip")
+		return c.Bytes(200, ContentTypeJSON, []byte("\x1f\x8bnot json"))
+	})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/user", nil))
+	if w.Body.String() != "{\"name\":\"ada\"}\n" {
+		t.Errorf("body = %q, want the redacted response", w.Body.String())
+	}
+
+	// Encoded bodies can't be transformed; they pass through untouched
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/encoded", nil))
+	if w.Code != 200 || w.Body.String() != "\x1f\x8bnot json" {
+		t.Errorf("encoded response = %d %q, want it unchanged", w.Code, w.Body.String())
+	}
+}
@@ -0,0 +1,279 @@
+package poltergeist
+
+import (
+	"fmt"
+	"strings"
+)
+
+// =============================================================================
+// ROUTING TREE - Segment trie used by the router to match requests
+// =============================================================================
+
+// node is one path segment in the routing tree. Children are tried most
+// specific first: static segments, then ":param", then a trailing "*wildcard",
+// backtracking when a branch has no route for the request method. Precedence
+// is decided segment by segment from the left, independent of registration
+// order (see the package documentation).
+type node struct {
+	static   map[string]*node
+	param    *node
+	wildcard *node
+	handles  map[string][]*handle // method -> routes ending at this node, in registration order
+}
+
+// handle is a route registered on a node, with the names of the values
+// captured on the way to it (params in order, then the wildcard)
+type handle struct {
+	route *Route
+	names []string
+}
+
+// newNode creates an empty tree node
+func newNode() *node {
+	return &node{}
+}
+
+// insert adds a route under its pattern. When several routes share a
+// method and pattern, the first whose constraints accept the request wins.
+// Trailing optional params ("/:year/:month?") register the route once per
+// URL shape: "/:year" and "/:year/:month".
+func (n *node) insert(route *Route) {
+	parts := splitPath(route.Path)
+	hasWildcard, wildcardName := checkWildcard(parts)
+	if hasWildcard {
+		parts = parts[:len(parts)-1]
+	}
+
+	firstOptional := -1
+	for i, part := range parts {
+		if isOptionalParam(part) {
+			if firstOptional < 0 {
+				firstOptional = i
+			}
+		} else if firstOptional >= 0 {
+			panic(fmt.Sprintf("poltergeist: optional params must end the pattern in %q", route.Path))
+		}
+	}
+	if firstOptional >= 0 && hasWildcard {
+		panic(fmt.Sprintf("poltergeist: optional params cannot precede a wildcard in %q", route.Path))
+	}
+
+	n.add(route, parts, hasWildcard, wildcardName)
+	for i := len(parts) - 1; firstOptional >= 0 && i >= firstOptional; i-- {
+		shape := parts[:i]
+		if len(shape) == 0 {
+			shape = []string{""} // the root path, as splitPath("/") gives
+		}
+		n.add(route, shape, false, "")
+	}
+}
+
+// add registers route at the node reached through parts
+func (n *node) add(route *Route, parts []string, hasWildcard bool, wildcardName string) {
+	var names []string
+	for _, part := range parts {
+		if strings.HasPrefix(part, ":") {
+			if n.param == nil {
+				n.param = newNode()
+			}
+			n = n.param
+			names = append(names, strings.TrimSuffix(part[1:], "?"))
+			continue
+		}
+		if n.static == nil {
+			n.static = make(map[string]*node)
+		}
+		child, ok := n.static[part]
+		if !ok {
+			child = newNode()
+			n.static[part] = child
+		}
+		n = child
+	}
+	if hasWildcard {
+		if n.wildcard == nil {
+			n.wildcard = newNode()
+		}
+		n = n.wildcard
+		names = append(names, wildcardName)
+	}
+
+	if n.handles == nil {
+		n.handles = make(map[string][]*handle)
+	}
+	n.handles[route.Method] = append(n.handles[route.Method], &handle{route: route, names: names})
+}
+
+// isOptionalParam reports whether a pattern segment is an optional param (":name?")
+func isOptionalParam(part string) bool {
+	return len(part) > 2 && part[0] == ':' && part[len(part)-1] == '?'
+}
+
+// query describes what a lookup must match besides the path
+type query struct {
+	method     string // "" matches a route of any method (to tell 405 from 404)
+	version    string // API version selected by the request
+	versioning bool   // enforce version: versioned routes must match it
+}
+
+// find matches a request path, appending captured values to values
+func (n *node) find(q query, path string, values []string) (*handle, []string) {
+	return n.match(q, strings.Trim(path, "/"), 0, values)
+}
+
+// match walks the segment of path starting at start; start past the end
+// of path means every segment has been consumed
+func (n *node) match(q query, path string, start int, values []string) (*handle, []string) {
+	if start > len(path) {
+		if h := n.handle(q, values); h != nil {
+			return h, values
+		}
+		if n.wildcard != nil {
+			values = append(values, "")
+			if h := n.wildcard.handle(q, values); h != nil {
+				return h, values
+			}
+		}
+		return nil, values
+	}
+
+	end := strings.IndexByte(path[start:], '/')
+	if end < 0 {
+		end = len(path)
+	} else {
+		end += start
+	}
+	segment := path[start:end]
+
+	if child := n.static[segment]; child != nil {
+		if h, v := child.match(q, path, end+1, values); h != nil {
+			return h, v
+		}
+	}
+	if n.param != nil {
+		if h, v := n.param.match(q, path, end+1, append(values, segment)); h != nil {
+			return h, v
+		}
+	}
+	if n.wildcard != nil {
+		values = append(values, path[start:])
+		if h := n.wildcard.handle(q, values); h != nil {
+			return h, values
+		}
+	}
+	return nil, values
+}
+
+// handle returns the first route for the query method (any method when
+// empty) whose constraints accept the captured values
+func (n *node) handle(q query, values []string) *handle {
+	if q.method != "" {
+		return firstAccepting(n.handles[q.method], values, q)
+	}
+	for _, handles := range n.handles {
+		if h := firstAccepting(handles, values, q); h != nil {
+			return h
+		}
+	}
+	return nil
+}
+
+// firstAccepting returns the first handle whose constraints accept values.
+// When versioning is enforced, a route of the requested version wins over
+// unversioned routes, and routes of other versions are skipped.
+func firstAccepting(handles []*handle, values []string, q query) *handle {
+	var fallback *handle
+	for _, h := range handles {
+		if !h.accepts(values) {
+			continue
+		}
+		version := h.route.headerVersion
+		if !q.versioning || version == q.version && version != "" {
+			return h
+		}
+		if version == "" && fallback == nil {
+			fallback = h
+		}
+	}
+	return fallback
+}
+
+// accepts reports whether the captured values satisfy the route's constraints
+func (h *handle) accepts(values []string) bool {
+	if len(h.route.constraints) == 0 {
+		return true
+	}
+	for i, name := range h.names {
+		if re := h.route.constraints[name]; re != nil && !re.MatchString(values[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// splitConstraints removes inline regex constraints from a pattern,
+// e.g. "/users/:id([0-9]+)" becomes "/users/:id" with {"id": "[0-9]+"}
+// and "/:page?([0-9]+)" becomes "/:page?" with {"page": "[0-9]+"}.
+// Constraints are matched by balanced parentheses, so they may contain
+// slashes and groups: "/files/*path([a-z/]+\.(txt|md))".
+func splitConstraints(pattern string) (string, map[string]string) {
+	if !strings.Contains(pattern, "(") {
+		return pattern, nil
+	}
+	var constraints map[string]string
+	var b strings.Builder
+	for rest := pattern; rest != ""; {
+		// rest starts at a segment or at the slash before one
+		slash := strings.IndexByte(rest, '/')
+		open := strings.IndexByte(rest, '(')
+		if (rest[0] == ':' || rest[0] == '*') && open >= 2 && (slash < 0 || open < slash) {
+			if end := closingParen(rest, open); end > 0 && (end+1 == len(rest) || rest[end+1] == '/') {
+				if constraints == nil {
+					constraints = make(map[string]string)
+				}
+				constraints[strings.TrimSuffix(rest[1:open], "?")] = rest[open+1 : end]
+				b.WriteString(rest[:open])
+				rest = rest[end+1:]
+				continue
+			}
+		}
+		if slash < 0 {
+			b.WriteString(rest)
+			break
+		}
+		b.WriteString(rest[:slash+1])
+		rest = rest[slash+1:]
+	}
+	return b.String(), constraints
+}
+
+// closingParen returns the index of the parenthesis closing the one at
+// open, skipping escaped characters and character classes, or -1
+func closingParen(s string, open int) int {
+	depth, inClass := 0, false
+	for i := open; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == '\\':
+			i++
+		case inClass:
+			inClass = c != ']'
+		case c == '[':
+			inClass = true
+		case c == '(':
+			depth++
+		case c == ')':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// setParams stores captured values under the route's parameter names
+func (h *handle) setParams(params map[string]string, values []string) {
+	for i, name := range h.names {
+		params[name] = values[i]
+	}
+}
@@ -0,0 +1,218 @@
+package poltergeist
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// =============================================================================
+// UPLOADS - Multipart file helpers and per-route limits
+// =============================================================================
+
+// UploadConfig holds upload limits for a route
+type UploadConfig struct {
+	MaxRequestSize int64    // Max request body size in bytes, 413 above it (default: 0, unlimited)
+	MaxFileSize    int64    // Max size of each file in bytes, 413 above it (default: 0, unlimited)
+	AllowedTypes   []string // Allowed file MIME types, e.g. "image/png" or "image/*"; 415 otherwise (default: any)
+	MaxMemory      int64    // Multipart bytes held in memory before spilling to disk (default: 32MB)
+}
+
+// DefaultUploadConfig returns default upload configuration
+func DefaultUploadConfig() *UploadConfig {
+	return &UploadConfig{
+		MaxMemory: DefaultMaxMultipartMemory,
+	}
+}
+
+// FormFile returns the first file uploaded under name. A missing file is a
+// 400 (errors.Is(err, http.ErrMissingFile) holds), an oversized body a 413.
+func (c *Context) FormFile(name string) (*multipart.FileHeader, error) {
+	if err := c.parseMultipart(DefaultMaxMultipartMemory); err != nil {
+		return nil, err
+	}
+	if files := c.Request.MultipartForm.File[name]; len(files) > 0 {
+		return files[0], nil
+	}
+	return nil, ErrBadRequest.WithMessage(fmt.Sprintf("missing file %q", name)).Wrap(http.ErrMissingFile)
+}
+
+// SaveUploadedFile writes an uploaded file to dst, creating parent directories.
+// dst is used as given: never build it from the client's file name unchecked.
+func (c *Context) SaveUploadedFile(file *multipart.FileHeader, dst string) error {
+	src, err := file.Open()
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	if err := os.MkdirAll(filepath.Dir(dst), 0o750); err != nil {
+		return err
+	}
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, src); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// parseMultipart parses the multipart body once, mapping a body over a
+// MaxBytesReader limit to 413
+func (c *Context) parseMultipart(maxMemory int64) error {
+	if c.Request.MultipartForm != nil {
+		return nil
+	}
+	err := c.Request.ParseMultipartForm(maxMemory)
+	var tooLarge *http.MaxBytesError
+	switch {
+	case err == nil:
+		return nil
+	case errors.As(err, &tooLarge):
+		return ErrRequestEntityTooLarge.WithMessage(fmt.Sprintf("request body exceeds %d bytes", tooLarge.Limit)).Wrap(err)
+	default:
+		return &BindError{Err: err}
+	}
+}
+
+// Upload enforces upload limits on the route. Multipart bodies are parsed
+// and checked before the handler runs, so FormFile and Bind see validated
+// files:
+//
+//	app.POST("/avatars", uploadAvatar).Upload(&poltergeist.UploadConfig{
+//	    MaxFileSize:  2 << 20,
+//	    AllowedTypes: []string{"image/png", "image/jpeg"},
+//	})
+//
+// File types are sniffed from the content; the client's declared type is only
+// trusted for text formats (text/csv, application/json, ...) when the content
+// sniffs as plain text, so binaries can't pass as images by claiming to be one.
+func (r *Route) Upload(config *UploadConfig) *Route {
+	return r.UseNamed(Middleware{Name: "upload", Func: uploadLimits(getUploadConfig(config))})
+}
+
+// uploadLimits returns middleware enforcing cfg on multipart requests
+func uploadLimits(cfg *UploadConfig) MiddlewareFunc {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(c *Context) error {
+			if cfg.MaxRequestSize > 0 {
+				if c.Request.ContentLength > cfg.MaxRequestSize {
+					return ErrRequestEntityTooLarge.WithMessage(fmt.Sprintf("request body exceeds %d bytes", cfg.MaxRequestSize))
+				}
+				c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, cfg.MaxRequestSize)
+			}
+			mediaType, _, _ := mime.ParseMediaType(c.Request.Header.Get(HeaderContentType))
+			if mediaType != ContentTypeMultipart {
+				return next(c)
+			}
+
+			if err := c.parseMultipart(cfg.MaxMemory); err != nil {
+				return err
+			}
+			for field, files := range c.Request.MultipartForm.File {
+				for _, file := range files {
+					if err := checkUpload(cfg, field, file); err != nil {
+						return err
+					}
+				}
+			}
+			return next(c)
+		}
+	}
+}
+
+// checkUpload validates one file against the size and type limits
+func checkUpload(cfg *UploadConfig, field string, file *multipart.FileHeader) error {
+	if cfg.MaxFileSize > 0 && file.Size > cfg.MaxFileSize {
+		return ErrRequestEntityTooLarge.WithMessage(fmt.Sprintf("file %q exceeds %d bytes", file.Filename, cfg.MaxFileSize)).
+			WithDetails([]FieldError{{Field: field, Message: "file too large"}})
+	}
+	if len(cfg.AllowedTypes) == 0 {
+		return nil
+	}
+	detected, err := sniffUpload(file)
+	if err != nil {
+		return err
+	}
+	if allowedType(cfg.AllowedTypes, detected) {
+		return nil
+	}
+	if detected == "text/plain" {
+		declared, _, _ := mime.ParseMediaType(file.Header.Get(HeaderContentType))
+		if textType(declared) && allowedType(cfg.AllowedTypes, declared) {
+			return nil
+		}
+	}
+	return ErrUnsupportedMediaType.WithMessage(fmt.Sprintf("file %q has type %s", file.Filename, detected)).
+		WithDetails(H{"field": field, "allowed": cfg.AllowedTypes})
+}
+
+// sniffUpload detects a file's MIME type from its first 512 bytes
+func sniffUpload(file *multipart.FileHeader) (string, error) {
+	f, err := file.Open()
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	head := make([]byte, 512)
+	n, err := io.ReadFull(f, head)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		return "", err
+	}
+	detected, _, _ := mime.ParseMediaType(http.DetectContentType(head[:n]))
+	return detected, nil
+}
+
+// allowedType matches a media type against patterns like "image/png" or "image/*"
+func allowedType(allowed []string, mediaType string) bool {
+	if mediaType == "" {
+		return false
+	}
+	for _, pattern := range allowed {
+		if prefix, ok := strings.CutSuffix(pattern, "/*"); ok {
+			if strings.HasPrefix(mediaType, prefix+"/") {
+				return true
+			}
+		} else if strings.EqualFold(pattern, mediaType) || pattern == "*/*" {
+			return true
+		}
+	}
+	return false
+}
+
+// textType reports whether a media type is a text format that sniffs as
+// plain text, e.g. text/csv, application/json or application/ld+json
+func textType(mediaType string) bool {
+	mediaType = strings.ToLower(mediaType)
+	if strings.HasPrefix(mediaType, "text/") ||
+		strings.HasSuffix(mediaType, "+json") || strings.HasSuffix(mediaType, "+xml") {
+		return true
+	}
+	switch mediaType {
+	case "application/json", "application/xml", "application/javascript",
+		"application/yaml", "application/x-yaml", "application/x-ndjson", "application/toml":
+		return true
+	}
+	return false
+}
+
+// getUploadConfig fills unset fields with defaults
+func getUploadConfig(config *UploadConfig) *UploadConfig {
+	if config == nil {
+		return DefaultUploadConfig()
+	}
+	cfg := *config
+	if cfg.MaxMemory <= 0 {
+		cfg.MaxMemory = DefaultMaxMultipartMemory
+	}
+	return &cfg
+}
@@ -0,0 +1,124 @@
+package poltergeist
+
+import (
+	"bytes"
+	"errors"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// =============================================================================
+// UPLOAD TESTS
+// =============================================================================
+
+// pngHeader is enough of a PNG for content sniffing
+var pngHeader = []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+
+type uploadPart struct {
+	field, filename, contentType string
+	data                         []byte
+}
+
+// multipartRequest builds a POST with the given file parts
+func multipartRequest(t *testing.T, path string, parts ...uploadPart) *http.Request {
+	t.Helper()
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	for _, p := range parts {
+		header := make(textproto.MIMEHeader)
+		header.Set("Content-Disposition", `form-data; name="`+p.field+`"; filename="`+p.filename+`"`)
+		header.Set("Content-Type", p.contentType)
+		w, err := mw.CreatePart(header)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write(p.data)
+	}
+	mw.Close()
+	req := httptest.NewRequest(http.MethodPost, path, &body)
+	req.Header.Set(HeaderContentType, mw.FormDataContentType())
+	return req
+}
+
+func TestContext_FormFileAndSave(t *testing.T) {
+	dir := t.TempDir()
+	app := New()
+	app.POST("/upload", func(c *Context) error {
+		file, err := c.FormFile("doc")
+		if err != nil {
+			return err
+		}
+		if err := c.SaveUploadedFile(file, filepath.Join(dir, "nested", "saved.txt")); err != nil {
+			return err
+		}
+		return c.String(http.StatusCreated, file.Filename)
+	})
+
+	w := httptest.NewRecorder()
+	app.ServeHTTP(w, multipartRequest(t, "/upload", uploadPart{"doc", "notes.txt", "text/plain", []byte("hello")}))
+	if w.Code != http.StatusCreated || w.Body.String() != "notes.txt" {
+		t.Fatalf("upload = %d %q", w.Code, w.Body.String())
+	}
+	if data, err := os.ReadFile(filepath.Join(dir, "nested", "saved.txt")); err != nil || string(data) != "hello" {
+		t.Errorf("saved file = %q, %v", data, err)
+	}
+
+	w = httptest.NewRecorder()
+	app.ServeHTTP(w, multipartRequest(t, "/upload", uploadPart{"other", "a.txt", "text/plain", []byte("x")}))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("missing file status = %d, want 400", w.Code)
+	}
+
+	c := NewContext(httptest.NewRecorder(), multipartRequest(t, "/", uploadPart{"other", "a.txt", "text/plain", []byte("x")}))
+	if _, err := c.FormFile("doc"); !errors.Is(err, http.ErrMissingFile) {
+		t.Errorf("FormFile(missing) error = %v, want http.ErrMissingFile", err)
+	}
+}
+
+func TestRoute_Upload(t *testing.T) {
+	app := New()
+	handled := false
+	app.POST("/avatars", func(c *Context) error {
+		handled = true
+		return c.NoContent()
+	}).Upload(&UploadConfig{
+		MaxRequestSize: 4 << 10,
+		MaxFileSize:    1 << 10,
+		AllowedTypes:   []string{"image/*", "text/csv", "application/json"},
+	})
+
+	tests := []struct {
+		name string
+		part uploadPart
+		want int
+	}{
+		{"sniffed image", uploadPart{"avatar", "a.png", "application/octet-stream", pngHeader}, http.StatusNoContent},
+		{"declared csv", uploadPart{"avatar", "a.csv", "text/csv", []byte("a,b\n1,2\n")}, http.StatusNoContent},
+		{"declared json", uploadPart{"avatar", "a.json", "application/json", []byte(`{"a":1}`)}, http.StatusNoContent},
+		{"binary declared as image", uploadPart{"avatar", "a.png", "image/png", []byte("\x7fELF\x02\x01\x01\x00\x00\x00")}, http.StatusUnsupportedMediaType},
+		{"binary declared as csv", uploadPart{"avatar", "a.csv", "text/csv", []byte{0x00, 0x01, 0x02, 0x03}}, http.StatusUnsupportedMediaType},
+		{"text declared as image", uploadPart{"avatar", "a.png", "image/png", []byte("just text")}, http.StatusUnsupportedMediaType},
+		{"disguised text", uploadPart{"avatar", "a.png", "image/png", []byte("<html><body>hi</body></html>")}, http.StatusUnsupportedMediaType},
+		{"file too large", uploadPart{"avatar", "a.png", "image/png", append(pngHeader, make([]byte, 2<<10)...)}, http.StatusRequestEntityTooLarge},
+		{"request too large", uploadPart{"avatar", "a.png", "image/png", append(pngHeader, make([]byte, 8<<10)...)}, http.StatusRequestEntityTooLarge},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handled = false
+			w := httptest.NewRecorder()
+			app.ServeHTTP(w, multipartRequest(t, "/avatars", tt.part))
+			if w.Code != tt.want {
+				t.Fatalf("status = %d, want %d (%s)", w.Code, tt.want, strings.TrimSpace(w.Body.String()))
+			}
+			if handled != (tt.want == http.StatusNoContent) {
+				t.Errorf("handler ran = %v", handled)
+			}
+		})
+	}
+}
@@ -0,0 +1,155 @@
+package poltergeist
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// =============================================================================
+// URL GENERATION - Build URLs from named routes
+// =============================================================================
+
+// URL generation errors
+var (
+	ErrRouteNotFound = errors.New("no route with that name")
+	ErrMissingParam  = errors.New("missing path parameter")
+)
+
+// URLFor builds the URL of the route registered with Name(name). Values are
+// given as key/value pairs; keys naming path params fill the pattern and the
+// rest become the query string, in order:
+//
+//	app.GET("/users/:id", getUser).Name("Get User")
+//	app.URLFor("Get User", "id", 42, "tab", "posts") // "/users/42?tab=posts"
+//
+// Omitted optional params are left out of the path.
+func (r *Router) URLFor(name string, pairs ...any) (string, error) {
+	route := r.namedRoute(name)
+	if route == nil {
+		return "", fmt.Errorf("%w: %q", ErrRouteNotFound, name)
+	}
+	if len(pairs)%2 != 0 {
+		return "", fmt.Errorf("URLFor %q: odd number of key/value arguments", name)
+	}
+
+	values := make(map[string]string, len(pairs)/2)
+	keys := make([]string, 0, len(pairs)/2)
+	for i := 0; i < len(pairs); i += 2 {
+		key := fmt.Sprint(pairs[i])
+		if _, dup := values[key]; !dup {
+			keys = append(keys, key)
+		}
+		values[key] = fmt.Sprint(pairs[i+1])
+	}
+
+	path, used, err := route.buildPath(values)
+	if err != nil {
+		return "", fmt.Errorf("URLFor %q: %w", name, err)
+	}
+
+	var query strings.Builder
+	for _, key := range keys {
+		if used[key] {
+			continue
+		}
+		if query.Len() > 0 {
+			query.WriteByte('&')
+		}
+		query.WriteString(url.QueryEscape(key) + "=" + url.QueryEscape(values[key]))
+	}
+	if query.Len() > 0 {
+		path += "?" + query.String()
+	}
+	return path, nil
+}
+
+// namedRoute returns the first route registered under name
+func (r *Router) namedRoute(name string) *Route {
+	for _, route := range r.routes {
+		if route.RouteName == name {
+			return route
+		}
+	}
+	return nil
+}
+
+// buildPath fills the route pattern with values, reporting which keys it used
+func (r *Route) buildPath(values map[string]string) (string, map[string]bool, error) {
+	parts := strings.Split(r.Path, "/")
+	used := make(map[string]bool)
+	out := parts[:0:0]
+	for _, part := range parts {
+		if !strings.HasPrefix(part, ":") && !strings.HasPrefix(part, "*") {
+			out = append(out, part)
+			continue
+		}
+
+		name := strings.TrimSuffix(part[1:], "?")
+		value, ok := values[name]
+		if !ok {
+			if isOptionalParam(part) {
+				break // optional params only end a pattern
+			}
+			if part[0] == '*' {
+				value = ""
+			} else {
+				return "", nil, fmt.Errorf("%w %q", ErrMissingParam, name)
+			}
+		}
+		if re := r.constraints[name]; re != nil && !re.MatchString(value) {
+			return "", nil, fmt.Errorf("param %q: %q does not match %s", name, value, r.ParamPatterns[name])
+		}
+		used[name] = true
+
+		if part[0] == '*' {
+			segments := strings.Split(value, "/")
+			for i, segment := range segments {
+				segments[i] = url.PathEscape(segment)
+			}
+			out = append(out, strings.Join(segments, "/"))
+			continue
+		}
+		out = append(out, url.PathEscape(value))
+	}
+
+	path := strings.Join(out, "/")
+	if path == "" {
+		path = "/"
+	}
+	return path, used, nil
+}
+
+// =============================================================================
+// SERVER & CONTEXT INTEGRATION
+// =============================================================================
+
+// URLFor builds the URL of a named route (see Router.URLFor)
+func (s *Server) URLFor(name string, pairs ...any) (string, error) {
+	return s.router.URLFor(name, pairs...)
+}
+
+// RouteURL builds the URL of a named route on the router serving the request
+//
+//	link, err := c.RouteURL("Get User", "id", user.ID)
+func (c *Context) RouteURL(name string, pairs ...any) (string, error) {
+	if c.router == nil {
+		return "", fmt.Errorf("%w: %q (context has no router)", ErrRouteNotFound, name)
+	}
+	return c.router.URLFor(name, pairs...)
+}
+
+// RedirectToRoute sends a 302 Found redirect to a named route, with values
+// given as in URLFor; an unknown route or missing param is returned as an
+// error and nothing is written
+//
+//	return c.RedirectToRoute("Get User", "id", user.ID)
+func (c *Context) RedirectToRoute(name string, pairs ...any) error {
+	location, err := c.RouteURL(name, pairs...)
+	if err != nil {
+		return err
+	}
+	return c.Redirect(http.StatusFound, location)
+}
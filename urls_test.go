@@ -0,0 +1,93 @@
+package poltergeist
+
+import (
+	"errors"
+	"net/http/httptest"
+	"testing"
+)
+
+// =============================================================================
+// URL GENERATION TESTS
+// =============================================================================
+
+func TestRouter_URLFor(t *testing.T) {
+	app := New()
+	noop := func(c *Context) error { return nil }
+	app.GET("/", noop).Name("Home")
+	app.GET("/users/:id", noop).Name("Get User").Where("id", `[0-9]+`)
+	app.GET("/articles/:year/:month?", noop).Name("Archive")
+	app.GET("/files/*path", noop).Name("File")
+
+	tests := []struct {
+		name  string
+		pairs []any
+		want  string
+	}{
+		{"Home", nil, "/"},
+		{"Get User", []any{"id", 42}, "/users/42"},
+		{"Get User", []any{"id", 42, "tab", "posts", "q", "a b"}, "/users/42?tab=posts&q=a+b"},
+		{"Archive", []any{"year", 2024}, "/articles/2024"},
+		{"Archive", []any{"year", 2024, "month", "05"}, "/articles/2024/05"},
+		{"File", []any{"path", "docs/read me.txt"}, "/files/docs/read%20me.txt"},
+	}
+	for _, tt := range tests {
+		got, err := app.URLFor(tt.name, tt.pairs...)
+		if err != nil || got != tt.want {
+			t.Errorf("URLFor(%q, %v) = %q, %v; want %q", tt.name, tt.pairs, got, err, tt.want)
+		}
+	}
+
+	if _, err := app.URLFor("Nope"); !errors.Is(err, ErrRouteNotFound) {
+		t.Errorf("unknown name: err = %v", err)
+	}
+	if _, err := app.URLFor("Get User"); !errors.Is(err, ErrMissingParam) {
+		t.Errorf("missing param: err = %v", err)
+	}
+	if _, err := app.URLFor("Get User", "id", "abc"); err == nil {
+		t.Error("value violating the param constraint should fail")
+	}
+}
+
+func TestContext_RouteURL(t *testing.T) {
+	app := New()
+	app.GET("/users/:id", func(c *Context) error {
+		link, err := c.RouteURL("Get User", "id", c.Param("id"))
+		if err != nil {
+			return err
+		}
+		return c.String(200, link)
+	}).Name("Get User")
+
+	w := httptest.NewRecorder()
+	app.Router().ServeHTTP(w, httptest.NewRequest("GET", "/users/7", nil))
+	if w.Body.String() != "/users/7" {
+		t.Errorf("body = %q", w.Body.String())
+	}
+}
+
+func TestContext_Redirects(t *testing.T) {
+	app := New()
+	app.GET("/users/:id", func(c *Context) error { return nil }).Name("Get User")
+	app.GET("/profile/:id", func(c *Context) error {
+		return c.RedirectToRoute("Get User", "id", c.Param("id"), "tab", "posts")
+	})
+	app.GET("/old", func(c *Context) error { return c.RedirectPermanent("/new") })
+	app.GET("/broken", func(c *Context) error { return c.RedirectToRoute("Missing") })
+
+	tests := []struct {
+		path     string
+		code     int
+		location string
+	}{
+		{"/profile/7", 302, "/users/7?tab=posts"},
+		{"/old", 301, "/new"},
+		{"/broken", 500, ""},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		app.ServeHTTP(w, httptest.NewRequest("GET", tt.path, nil))
+		if w.Code != tt.code || w.Header().Get("Location") != tt.location {
+			t.Errorf("%s = %d %q, want %d %q", tt.path, w.Code, w.Header().Get("Location"), tt.code, tt.location)
+		}
+	}
+}
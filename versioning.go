@@ -0,0 +1,113 @@
+package poltergeist
+
+import (
+	"net/http"
+	"strings"
+)
+
+// =============================================================================
+// HEADER VERSIONING - Dispatch one path to handler versions by header
+// =============================================================================
+
+// VersionSelector returns the API version a request asks for, or ""
+type VersionSelector func(req *http.Request) string
+
+// VersionFromAccept selects the version from a vendor media type in the
+// Accept header: "application/vnd.<vendor>.<version>+json" gives <version>
+func VersionFromAccept(vendor string) VersionSelector {
+	prefix := "application/vnd." + vendor + "."
+	return func(req *http.Request) string {
+		for _, accept := range req.Header.Values(HeaderAccept) {
+			for _, mediaType := range strings.Split(accept, ",") {
+				mediaType = strings.TrimSpace(mediaType)
+				if i := strings.IndexByte(mediaType, ';'); i >= 0 {
+					mediaType = strings.TrimSpace(mediaType[:i])
+				}
+				if !strings.HasPrefix(mediaType, prefix) {
+					continue
+				}
+				version := mediaType[len(prefix):]
+				if i := strings.IndexByte(version, '+'); i >= 0 {
+					version = version[:i]
+				}
+				return version
+			}
+		}
+		return ""
+	}
+}
+
+// VersionFromHeader selects the version from a request header, e.g. "X-API-Version"
+func VersionFromHeader(name string) VersionSelector {
+	return func(req *http.Request) string {
+		return strings.TrimSpace(req.Header.Get(name))
+	}
+}
+
+// UseVersioning enables header-based versioning for Versioned groups. The
+// selectors are tried in order; requests selecting no version get
+// defaultVersion ("" serves them unversioned routes only).
+//
+//	app.UseVersioning("v1", poltergeist.VersionFromAccept("myapp"), poltergeist.VersionFromHeader("X-API-Version"))
+//	v1, v2 := app.Versioned("v1"), app.Versioned("v2")
+//	v1.GET("/users/:id", getUserV1)
+//	v2.GET("/users/:id", getUserV2)
+func (r *Router) UseVersioning(defaultVersion string, selectors ...VersionSelector) *Router {
+	r.defaultVersion = defaultVersion
+	r.versionSelectors = selectors
+	return r
+}
+
+// Versioned creates a group without a path prefix whose routes only serve
+// requests selecting version (see UseVersioning). For a path with both
+// versioned and unversioned routes, the unversioned route serves requests
+// for versions that have no route of their own.
+func (r *Router) Versioned(version string, middlewares ...MiddlewareFunc) *RouteGroup {
+	group := r.Group("", middlewares...)
+	group.version = version
+	group.headerVersion = true
+	r.versioned = true
+	return group
+}
+
+// query builds the tree lookup for a request, selecting its API version
+// once any Versioned route exists
+func (r *Router) query(req *http.Request, method string) query {
+	if !r.versioned || req == nil {
+		return query{method: method}
+	}
+	return query{method: method, version: r.requestVersion(req), versioning: true}
+}
+
+// requestVersion returns the version selected by req, or the default
+func (r *Router) requestVersion(req *http.Request) string {
+	for _, selector := range r.versionSelectors {
+		if version := selector(req); version != "" {
+			return version
+		}
+	}
+	return r.defaultVersion
+}
+
+// =============================================================================
+// SERVER & CONTEXT INTEGRATION
+// =============================================================================
+
+// UseVersioning enables header-based versioning (see Router.UseVersioning)
+func (s *Server) UseVersioning(defaultVersion string, selectors ...VersionSelector) *Server {
+	s.router.UseVersioning(defaultVersion, selectors...)
+	return s
+}
+
+// Versioned creates a header-versioned group (see Router.Versioned)
+func (s *Server) Versioned(version string, middlewares ...MiddlewareFunc) *RouteGroup {
+	return s.router.Versioned(version, middlewares...)
+}
+
+// APIVersion returns the API version the request selected, or the default
+func (c *Context) APIVersion() string {
+	if c.router == nil || c.Request == nil {
+		return ""
+	}
+	return c.router.requestVersion(c.Request)
+}
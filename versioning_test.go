@@ -0,0 +1,56 @@
+package poltergeist
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+// =============================================================================
+// HEADER VERSIONING TESTS
+// =============================================================================
+
+func TestRouter_HeaderVersioning(t *testing.T) {
+	app := New()
+	app.UseVersioning("v1", VersionFromAccept("myapp"), VersionFromHeader("X-API-Version"))
+
+	v1, v2 := app.Versioned("v1"), app.Versioned("v2")
+	v1.GET("/users/:id", func(c *Context) error { return c.String(200, "v1 "+c.Param("id")) })
+	v2.GET("/users/:id", func(c *Context) error { return c.String(200, "v2 "+c.APIVersion()) })
+	app.GET("/health", func(c *Context) error { return c.String(200, "ok") })
+	app.GET("/users/:id/avatar", func(c *Context) error { return c.String(200, "avatar") })
+	v2.GET("/users/:id/avatar", func(c *Context) error { return c.String(200, "avatar v2") })
+
+	tests := []struct {
+		path     string
+		header   string
+		value    string
+		wantCode int
+		wantBody string
+	}{
+		{"/users/1", "", "", 200, "v1 1"},
+		{"/users/1", "Accept", "application/vnd.myapp.v2+json", 200, "v2 v2"},
+		{"/users/1", "Accept", "text/html, application/vnd.myapp.v1+json;q=0.9", 200, "v1 1"},
+		{"/users/1", "X-API-Version", "v2", 200, "v2 v2"},
+		{"/users/1", "X-API-Version", "v9", 404, ""},
+		{"/health", "X-API-Version", "v2", 200, "ok"},
+		{"/users/1/avatar", "X-API-Version", "v2", 200, "avatar v2"},
+		{"/users/1/avatar", "X-API-Version", "v1", 200, "avatar"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path+" "+tt.value, func(t *testing.T) {
+			req := httptest.NewRequest("GET", tt.path, nil)
+			if tt.header != "" {
+				req.Header.Set(tt.header, tt.value)
+			}
+			w := httptest.NewRecorder()
+			app.ServeHTTP(w, req)
+			if w.Code != tt.wantCode || (tt.wantBody != "" && w.Body.String() != tt.wantBody) {
+				t.Errorf("got %d %q, want %d %q", w.Code, w.Body.String(), tt.wantCode, tt.wantBody)
+			}
+		})
+	}
+
+	if route := app.Router().Lookup("GET", "/users/1"); route == nil || route.RouteVersion != "v1" {
+		t.Errorf("Lookup should return the first registered version, got %+v", route)
+	}
+}
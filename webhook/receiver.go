@@ -0,0 +1,267 @@
+package webhook
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofuckbiz/poltergeist"
+	"github.com/gofuckbiz/poltergeist/cache"
+	"github.com/gofuckbiz/poltergeist/middleware"
+)
+
+// =============================================================================
+// RECEIVER - Inbound signature verification with replay protection
+// =============================================================================
+
+// Scheme describes how a sender signs its webhooks
+type Scheme struct {
+	// Name namespaces replay keys
+	Name string
+	// Parse extracts the signed timestamp ("" when the scheme has none) and
+	// the candidate signatures from the request headers
+	Parse func(h http.Header) (timestamp string, signatures []string)
+	// Sign computes the expected signature for one secret
+	Sign func(secret, timestamp string, body []byte) string
+	// Header carrying a unique delivery ID; when set and present, replays
+	// are detected by ID as well as by signature
+	DeliveryHeader string
+}
+
+// SchemePoltergeist verifies deliveries from a webhook.Dispatcher
+// (Poltergeist-Signature: t=<unix>,v1=<hex>)
+var SchemePoltergeist = Scheme{
+	Name: "poltergeist",
+	Parse: func(h http.Header) (string, []string) {
+		return parseTimestamped(h.Get(HeaderSignature))
+	},
+	Sign:           computeSignature,
+	DeliveryHeader: HeaderDelivery,
+}
+
+// SchemeStripe verifies Stripe events (Stripe-Signature: t=<unix>,v1=<hex>,
+// signed over "<timestamp>.<body>")
+var SchemeStripe = Scheme{
+	Name: "stripe",
+	Parse: func(h http.Header) (string, []string) {
+		return parseTimestamped(h.Get("Stripe-Signature"))
+	},
+	Sign: computeSignature,
+}
+
+// SchemeGitHub verifies GitHub deliveries (X-Hub-Signature-256:
+// sha256=<hex> over the body). GitHub signs no timestamp, so freshness
+// can't be checked; replays are caught by signature and X-GitHub-Delivery.
+var SchemeGitHub = Scheme{
+	Name: "github",
+	Parse: func(h http.Header) (string, []string) {
+		sig, ok := strings.CutPrefix(h.Get("X-Hub-Signature-256"), "sha256=")
+		if !ok || sig == "" {
+			return "", nil
+		}
+		return "", []string{sig}
+	},
+	Sign: func(secret, _ string, body []byte) string {
+		mac := hmac.New(sha256.New, []byte(secret))
+		mac.Write(body)
+		return hex.EncodeToString(mac.Sum(nil))
+	},
+	DeliveryHeader: "X-GitHub-Delivery",
+}
+
+// parseTimestamped parses a "t=...,v1=..." header, which must carry a
+// timestamp so it can't dodge the freshness check
+func parseTimestamped(header string) (string, []string) {
+	ts, signatures := parseSignatureHeader(header)
+	if ts == "" {
+		return "", nil
+	}
+	return ts, signatures
+}
+
+// ErrReplayedDelivery is returned for a delivery that was already accepted
+var ErrReplayedDelivery = errors.New("webhook: delivery already received")
+
+// ReceiverConfig holds inbound verification configuration
+type ReceiverConfig struct {
+	// Signing secrets; any of them may match, which allows rotation
+	Secrets []string
+	// Signature scheme (default: SchemePoltergeist)
+	Scheme Scheme
+	// Maximum age of a signed timestamp, in either direction (default: 5 minutes)
+	Tolerance time.Duration
+	// Store remembering accepted deliveries; use a shared store such as
+	// cache.NewRedis when several instances receive webhooks (default: in-memory)
+	ReplayStore poltergeist.Cache
+	// How long accepted deliveries are remembered (default: twice Tolerance,
+	// or 24 hours for schemes without timestamps)
+	ReplayTTL time.Duration
+	// Skip verification for some requests
+	SkipFunc middleware.Skipper
+}
+
+// DefaultReceiverConfig returns default inbound verification configuration
+func DefaultReceiverConfig() *ReceiverConfig {
+	return &ReceiverConfig{
+		Scheme:    SchemePoltergeist,
+		Tolerance: 5 * time.Minute,
+	}
+}
+
+// getReceiverConfig fills unset fields with defaults
+func getReceiverConfig(config *ReceiverConfig) *ReceiverConfig {
+	defaults := DefaultReceiverConfig()
+	if config == nil {
+		return defaults
+	}
+	cfg := *config
+	if cfg.Scheme.Parse == nil || cfg.Scheme.Sign == nil {
+		cfg.Scheme = defaults.Scheme
+	}
+	if cfg.Tolerance == 0 {
+		cfg.Tolerance = defaults.Tolerance
+	}
+	if cfg.ReplayStore == nil {
+		cfg.ReplayStore = cache.NewMemory(&cache.MemoryConfig{MaxEntries: 100_000})
+	}
+	return &cfg
+}
+
+// RequireSignature returns a middleware that accepts only requests signed
+// by a webhook.Dispatcher with one of secrets
+//
+//	app.POST("/hooks/orders", handleOrder, webhook.RequireSignature(os.Getenv("WEBHOOK_SECRET")))
+func RequireSignature(secrets ...string) poltergeist.MiddlewareFunc {
+	return RequireSignatureWithConfig(&ReceiverConfig{Secrets: secrets})
+}
+
+// RequireSignatureWithConfig returns a signature verification middleware
+// with custom config. Unsigned, badly signed or stale requests get 401 and
+// replayed deliveries 409. A delivery whose handler fails is forgotten, so
+// the sender's retry is accepted.
+//
+//	app.POST("/hooks/stripe", handleStripe, webhook.RequireSignatureWithConfig(&webhook.ReceiverConfig{
+//	    Secrets: []string{os.Getenv("STRIPE_WEBHOOK_SECRET")},
+//	    Scheme:  webhook.SchemeStripe,
+//	}))
+func RequireSignatureWithConfig(config *ReceiverConfig) poltergeist.MiddlewareFunc {
+	cfg := getReceiverConfig(config)
+	if len(cfg.Secrets) == 0 {
+		panic("webhook: RequireSignature needs at least one secret")
+	}
+
+	return func(next poltergeist.HandlerFunc) poltergeist.HandlerFunc {
+		return func(c *poltergeist.Context) error {
+			// Skip if configured
+			if cfg.SkipFunc != nil && cfg.SkipFunc(c) {
+				return next(c)
+			}
+
+			body, err := c.Body()
+			if err != nil {
+				return err
+			}
+			signature, err := cfg.verify(c.Request.Header, body)
+			if err != nil {
+				return poltergeist.ErrUnauthorized.WithMessage("Invalid webhook signature").Wrap(err)
+			}
+
+			ctx := c.Context()
+			keys, replayed := cfg.claim(c, signature)
+			if replayed {
+				return poltergeist.ErrConflict.WithMessage("Webhook delivery already received").Wrap(ErrReplayedDelivery)
+			}
+
+			err = next(c)
+			if err != nil || c.StatusCode() >= 500 {
+				cfg.release(ctx, keys)
+			}
+			return err
+		}
+	}
+}
+
+// verify checks the request's signatures and timestamp, returning the
+// signature that matched
+func (cfg *ReceiverConfig) verify(header http.Header, body []byte) (string, error) {
+	ts, signatures := cfg.Scheme.Parse(header)
+	if len(signatures) == 0 {
+		return "", ErrInvalidSignature
+	}
+	if ts != "" {
+		unix, err := strconv.ParseInt(ts, 10, 64)
+		if err != nil {
+			return "", ErrInvalidSignature
+		}
+		if age := time.Since(time.Unix(unix, 0)); age > cfg.Tolerance || age < -cfg.Tolerance {
+			return "", ErrSignatureExpired
+		}
+	}
+	for _, secret := range cfg.Secrets {
+		expected := cfg.Scheme.Sign(secret, ts, body)
+		for _, sig := range signatures {
+			if hmac.Equal([]byte(expected), []byte(sig)) {
+				return sig, nil
+			}
+		}
+	}
+	return "", ErrInvalidSignature
+}
+
+// replayKeys identifies a delivery by its verified signature and, when
+// present, its ID header. The header isn't signed, so it only adds a key:
+// changing it must not make a captured delivery new again.
+func (cfg *ReceiverConfig) replayKeys(header http.Header, signature string) []string {
+	keys := []string{"webhook:" + cfg.Scheme.Name + ":sig:" + signature}
+	if cfg.Scheme.DeliveryHeader != "" {
+		if id := header.Get(cfg.Scheme.DeliveryHeader); id != "" {
+			keys = append(keys, "webhook:"+cfg.Scheme.Name+":id:"+id)
+		}
+	}
+	return keys
+}
+
+// claim atomically records the delivery's replay keys, reporting a replay
+// when any of them was already recorded. Store failures are logged and let
+// the delivery through.
+func (cfg *ReceiverConfig) claim(c *poltergeist.Context, signature string) (claimed []string, replayed bool) {
+	ctx := c.Context()
+	ttl := cfg.replayTTL(c.Request.Header)
+	for _, key := range cfg.replayKeys(c.Request.Header, signature) {
+		added, err := cfg.ReplayStore.Add(ctx, key, []byte{1}, ttl)
+		if err != nil {
+			c.Logger().Warn("webhook replay store failed", "error", err)
+			continue
+		}
+		if !added {
+			cfg.release(ctx, claimed)
+			return nil, true
+		}
+		claimed = append(claimed, key)
+	}
+	return claimed, false
+}
+
+// release forgets keys claimed for a delivery, so its retry is accepted
+func (cfg *ReceiverConfig) release(ctx context.Context, keys []string) {
+	for _, key := range keys {
+		cfg.ReplayStore.Delete(ctx, key)
+	}
+}
+
+// replayTTL is how long a delivery must be remembered to reject replays
+func (cfg *ReceiverConfig) replayTTL(header http.Header) time.Duration {
+	if cfg.ReplayTTL > 0 {
+		return cfg.ReplayTTL
+	}
+	if ts, _ := cfg.Scheme.Parse(header); ts != "" {
+		return 2 * cfg.Tolerance
+	}
+	return 24 * time.Hour
+}
@@ -0,0 +1,146 @@
+package webhook
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gofuckbiz/poltergeist"
+)
+
+// =============================================================================
+// RECEIVER TESTS
+// =============================================================================
+
+// receiverApp serves POST /hook behind a receiver with config
+func receiverApp(config *ReceiverConfig) *poltergeist.Server {
+	app := poltergeist.New()
+	app.POST("/hook", func(c *poltergeist.Context) error {
+		return c.NoContent()
+	}, RequireSignatureWithConfig(config))
+	return app
+}
+
+// deliver posts body with headers and returns the status code
+func deliver(app *poltergeist.Server, body string, headers map[string]string) int {
+	req := httptest.NewRequest(http.MethodPost, "/hook", strings.NewReader(body))
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+	w := httptest.NewRecorder()
+	app.ServeHTTP(w, req)
+	return w.Code
+}
+
+func TestRequireSignature(t *testing.T) {
+	const body = `{"id":1}`
+	now := time.Now()
+	ts := strconv.FormatInt(now.Unix(), 10)
+
+	tests := []struct {
+		name   string
+		header string
+		want   int
+	}{
+		{"valid", Sign([]string{"secret"}, now, []byte(body)), http.StatusNoContent},
+		{"rotated secret", Sign([]string{"old", "secret"}, now, []byte(body)), http.StatusNoContent},
+		{"wrong secret", Sign([]string{"other"}, now, []byte(body)), http.StatusUnauthorized},
+		{"tampered", "t=" + ts + ",v1=" + computeSignature("secret", ts, []byte(`{"id":2}`)), http.StatusUnauthorized},
+		{"missing", "", http.StatusUnauthorized},
+		{"no timestamp", "v1=" + computeSignature("secret", "", []byte(body)), http.StatusUnauthorized},
+		{"stale", Sign([]string{"secret"}, now.Add(-10*time.Minute), []byte(body)), http.StatusUnauthorized},
+		{"future", Sign([]string{"secret"}, now.Add(10*time.Minute), []byte(body)), http.StatusUnauthorized},
+		{"within tolerance", Sign([]string{"secret"}, now.Add(-4*time.Minute), []byte(body)), http.StatusNoContent},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := receiverApp(&ReceiverConfig{Secrets: []string{"secret"}})
+			if got := deliver(app, body, map[string]string{HeaderSignature: tt.header}); got != tt.want {
+				t.Errorf("status = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRequireSignature_Replay(t *testing.T) {
+	const body = `{"action":"opened"}`
+	signature := "sha256=" + SchemeGitHub.Sign("secret", "", []byte(body))
+	app := receiverApp(&ReceiverConfig{Secrets: []string{"secret"}, Scheme: SchemeGitHub})
+
+	headers := func(id string) map[string]string {
+		return map[string]string{"X-Hub-Signature-256": signature, "X-GitHub-Delivery": id}
+	}
+	if got := deliver(app, body, headers("a")); got != http.StatusNoContent {
+		t.Fatalf("first delivery = %d", got)
+	}
+	if got := deliver(app, body, headers("a")); got != http.StatusConflict {
+		t.Errorf("same delivery = %d, want 409", got)
+	}
+	// The delivery ID isn't signed: changing it doesn't make a capture new
+	if got := deliver(app, body, headers("b")); got != http.StatusConflict {
+		t.Errorf("replay with a new delivery ID = %d, want 409", got)
+	}
+	if got := deliver(app, body, map[string]string{"X-Hub-Signature-256": signature}); got != http.StatusConflict {
+		t.Errorf("replay without a delivery ID = %d, want 409", got)
+	}
+
+	// A new payload reusing a seen delivery ID is a replay as well
+	other := `{"action":"closed"}`
+	if got := deliver(app, other, map[string]string{
+		"X-Hub-Signature-256": "sha256=" + SchemeGitHub.Sign("secret", "", []byte(other)),
+		"X-GitHub-Delivery":   "a",
+	}); got != http.StatusConflict {
+		t.Errorf("new payload with a seen delivery ID = %d, want 409", got)
+	}
+}
+
+func TestRequireSignature_ConcurrentReplays(t *testing.T) {
+	const body = `{"id":1}`
+	header := Sign([]string{"secret"}, time.Now(), []byte(body))
+	app := receiverApp(&ReceiverConfig{Secrets: []string{"secret"}})
+
+	var mu sync.Mutex
+	accepted := 0
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if deliver(app, body, map[string]string{HeaderSignature: header}) == http.StatusNoContent {
+				mu.Lock()
+				accepted++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	if accepted != 1 {
+		t.Errorf("accepted %d concurrent copies, want 1", accepted)
+	}
+}
+
+func TestRequireSignature_FailedDeliveryRetried(t *testing.T) {
+	const body = `{"id":1}`
+	header := Sign([]string{"secret"}, time.Now(), []byte(body))
+	fail := true
+	app := poltergeist.New()
+	app.POST("/hook", func(c *poltergeist.Context) error {
+		if fail {
+			return poltergeist.ErrInternalServerError
+		}
+		return c.NoContent()
+	}, RequireSignature("secret"))
+
+	headers := map[string]string{HeaderSignature: header, HeaderDelivery: "d1"}
+	if got := deliver(app, body, headers); got != http.StatusInternalServerError {
+		t.Fatalf("failing delivery = %d", got)
+	}
+	fail = false
+	if got := deliver(app, body, headers); got != http.StatusNoContent {
+		t.Errorf("retry after a failure = %d, want 204", got)
+	}
+}
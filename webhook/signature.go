@@ -0,0 +1,92 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// =============================================================================
+// SIGNATURES - HMAC-SHA256 payload signing
+// =============================================================================
+
+// Signature errors
+var (
+	ErrInvalidSignature = errors.New("webhook: invalid signature")
+	ErrSignatureExpired = errors.New("webhook: signature timestamp outside tolerance")
+)
+
+// Sign builds a signature header value of the form "t=<unix>,v1=<hex>[,v1=<hex>]".
+// Each secret produces one v1 entry over "<timestamp>.<body>", which lets
+// receivers verify with either key while secrets are being rotated.
+func Sign(secrets []string, timestamp time.Time, body []byte) string {
+	ts := strconv.FormatInt(timestamp.Unix(), 10)
+
+	var sb strings.Builder
+	sb.WriteString("t=" + ts)
+	for _, secret := range secrets {
+		sb.WriteString(",v1=" + computeSignature(secret, ts, body))
+	}
+	return sb.String()
+}
+
+// Verify checks a signature header against a body using any of the given secrets.
+// A zero tolerance disables the timestamp freshness check.
+func Verify(secrets []string, header string, body []byte, tolerance time.Duration) error {
+	ts, signatures := parseSignatureHeader(header)
+	if ts == "" || len(signatures) == 0 {
+		return ErrInvalidSignature
+	}
+
+	if tolerance > 0 {
+		unix, err := strconv.ParseInt(ts, 10, 64)
+		if err != nil {
+			return ErrInvalidSignature
+		}
+		if age := time.Since(time.Unix(unix, 0)); age > tolerance || age < -tolerance {
+			return ErrSignatureExpired
+		}
+	}
+
+	for _, secret := range secrets {
+		expected := computeSignature(secret, ts, body)
+		for _, sig := range signatures {
+			if hmac.Equal([]byte(expected), []byte(sig)) {
+				return nil
+			}
+		}
+	}
+	return ErrInvalidSignature
+}
+
+// computeSignature returns the hex HMAC-SHA256 of "<timestamp>.<body>"
+func computeSignature(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// parseSignatureHeader splits a signature header into its timestamp and v1 values
+func parseSignatureHeader(header string) (string, []string) {
+	var ts string
+	var signatures []string
+	for _, part := range strings.Split(header, ",") {
+		key, value, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok {
+			continue
+		}
+		switch key {
+		case "t":
+			ts = value
+		case "v1":
+			signatures = append(signatures, value)
+		}
+	}
+	return ts, signatures
+}
@@ -0,0 +1,58 @@
+package webhook
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// =============================================================================
+// SIGNATURE TESTS
+// =============================================================================
+
+func TestSignVerify(t *testing.T) {
+	body := []byte(`{"id":1}`)
+	now := time.Now()
+
+	tests := []struct {
+		name      string
+		header    string
+		secrets   []string
+		tolerance time.Duration
+		want      error
+	}{
+		{"valid", Sign([]string{"a"}, now, body), []string{"a"}, time.Minute, nil},
+		{"rotation, old key", Sign([]string{"new", "old"}, now, body), []string{"old"}, time.Minute, nil},
+		{"rotation, new key", Sign([]string{"new", "old"}, now, body), []string{"new"}, time.Minute, nil},
+		{"wrong secret", Sign([]string{"a"}, now, body), []string{"b"}, time.Minute, ErrInvalidSignature},
+		{"empty", "", []string{"a"}, time.Minute, ErrInvalidSignature},
+		{"no signature", "t=1", []string{"a"}, time.Minute, ErrInvalidSignature},
+		{"no timestamp", "v1=" + computeSignature("a", "", body), []string{"a"}, time.Minute, ErrInvalidSignature},
+		{"bad timestamp", "t=x,v1=" + computeSignature("a", "x", body), []string{"a"}, time.Minute, ErrInvalidSignature},
+		{"stale", Sign([]string{"a"}, now.Add(-2*time.Minute), body), []string{"a"}, time.Minute, ErrSignatureExpired},
+		{"future", Sign([]string{"a"}, now.Add(2*time.Minute), body), []string{"a"}, time.Minute, ErrSignatureExpired},
+		{"stale, no tolerance", Sign([]string{"a"}, now.Add(-time.Hour), body), []string{"a"}, 0, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := Verify(tt.secrets, tt.header, body, tt.tolerance); !errors.Is(err, tt.want) {
+				t.Errorf("Verify = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestSign_Format(t *testing.T) {
+	header := Sign([]string{"a", "b"}, time.Unix(1700000000, 0), []byte("{}"))
+	parts := strings.Split(header, ",")
+	if len(parts) != 3 || parts[0] != "t=1700000000" || !strings.HasPrefix(parts[1], "v1=") || !strings.HasPrefix(parts[2], "v1=") {
+		t.Fatalf("Sign = %q, want t=<unix>,v1=<hex>,v1=<hex>", header)
+	}
+	if want := "v1=" + computeSignature("a", "1700000000", []byte("{}")); parts[1] != want {
+		t.Errorf("primary signature = %s, want %s", parts[1], want)
+	}
+	if Verify([]string{"a"}, header, []byte("{ }"), 0) == nil {
+		t.Error("signature verified for a modified body")
+	}
+}
@@ -0,0 +1,622 @@
+// Package webhook provides outbound webhook delivery for Poltergeist applications:
+// endpoint registration, HMAC payload signing with key rotation, retries with
+// backoff, dead-letter capture, and a delivery status API. On the receiving
+// side, RequireSignature verifies signed deliveries and rejects replays.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gofuckbiz/poltergeist"
+)
+
+// =============================================================================
+// CONFIGURATION
+// =============================================================================
+
+// Header names sent with every delivery
+const (
+	HeaderSignature = "Poltergeist-Signature"
+	HeaderEvent     = "Poltergeist-Event"
+	HeaderDelivery  = "Poltergeist-Delivery"
+)
+
+// Config holds dispatcher configuration
+type Config struct {
+	// Number of concurrent delivery workers (default: 4)
+	Workers int
+	// Pending delivery buffer (default: 1024)
+	QueueSize int
+	// Retries after the first failed attempt (default: 5; kept as set in a
+	// non-nil config, so 0 disables retries)
+	MaxRetries int
+	// Initial retry backoff, doubled per attempt (default: 5s)
+	RetryBackoff time.Duration
+	// Upper bound for retry backoff (default: 10m)
+	MaxBackoff time.Duration
+	// Per-attempt request timeout, applied to Client too (default: 10s)
+	Timeout time.Duration
+	// HTTP client used for deliveries (default: http.Client{})
+	Client *http.Client
+	// Number of finished deliveries kept for the status API (default: 1000)
+	MaxHistory int
+	// Called when a delivery exhausts its retries
+	OnDeadLetter func(d Delivery)
+}
+
+// DefaultConfig returns default dispatcher configuration
+func DefaultConfig() *Config {
+	return &Config{
+		Workers:      4,
+		QueueSize:    1024,
+		MaxRetries:   5,
+		RetryBackoff: 5 * time.Second,
+		MaxBackoff:   10 * time.Minute,
+		Timeout:      10 * time.Second,
+		MaxHistory:   1000,
+	}
+}
+
+// getConfig fills unset fields with defaults
+func getConfig(config *Config) *Config {
+	defaults := DefaultConfig()
+	if config == nil {
+		return defaults
+	}
+	cfg := *config
+	if cfg.Workers <= 0 {
+		cfg.Workers = defaults.Workers
+	}
+	if cfg.QueueSize <= 0 {
+		cfg.QueueSize = defaults.QueueSize
+	}
+	if cfg.RetryBackoff <= 0 {
+		cfg.RetryBackoff = defaults.RetryBackoff
+	}
+	if cfg.MaxBackoff <= 0 {
+		cfg.MaxBackoff = defaults.MaxBackoff
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = defaults.Timeout
+	}
+	if cfg.MaxHistory <= 0 {
+		cfg.MaxHistory = defaults.MaxHistory
+	}
+	return &cfg
+}
+
+// Dispatcher errors
+var (
+	ErrEndpointNotFound = errors.New("webhook: endpoint not found")
+	ErrDeliveryNotFound = errors.New("webhook: delivery not found")
+	ErrDispatcherClosed = errors.New("webhook: dispatcher shut down before the retry")
+)
+
+// =============================================================================
+// TYPES - Endpoints and deliveries
+// =============================================================================
+
+// Endpoint is a registered webhook receiver
+type Endpoint struct {
+	ID      string            `json:"id"`
+	URL     string            `json:"url"`
+	Events  []string          `json:"events,omitempty"` // Subscribed events (empty = all)
+	Headers map[string]string `json:"headers,omitempty"`
+	Active  bool              `json:"active"`
+
+	// Signing secrets; the first is primary. During rotation every secret
+	// signs the payload so receivers can switch keys without downtime.
+	Secrets []string `json:"-"`
+}
+
+// subscribed reports whether the endpoint receives an event
+func (e *Endpoint) subscribed(event string) bool {
+	if !e.Active {
+		return false
+	}
+	if len(e.Events) == 0 {
+		return true
+	}
+	for _, ev := range e.Events {
+		if ev == event || ev == "*" {
+			return true
+		}
+	}
+	return false
+}
+
+// DeliveryStatus is the state of a delivery
+type DeliveryStatus string
+
+// Delivery statuses
+const (
+	StatusPending   DeliveryStatus = "pending"
+	StatusRetrying  DeliveryStatus = "retrying"
+	StatusDelivered DeliveryStatus = "delivered"
+	StatusDead      DeliveryStatus = "dead"
+)
+
+// Delivery is a single event sent to a single endpoint
+type Delivery struct {
+	ID             string          `json:"id"`
+	EndpointID     string          `json:"endpoint_id"`
+	Event          string          `json:"event"`
+	Payload        json.RawMessage `json:"payload"`
+	Status         DeliveryStatus  `json:"status"`
+	Attempts       int             `json:"attempts"`
+	LastStatusCode int             `json:"last_status_code,omitempty"`
+	LastError      string          `json:"last_error,omitempty"`
+	CreatedAt      time.Time       `json:"created_at"`
+	CompletedAt    *time.Time      `json:"completed_at,omitempty"`
+}
+
+// =============================================================================
+// DISPATCHER
+// =============================================================================
+
+// Dispatcher delivers events to registered endpoints
+type Dispatcher struct {
+	config     *Config
+	client     *http.Client
+	queue      *poltergeist.TaskQueue
+	mu         sync.RWMutex
+	endpoints  map[string]*Endpoint
+	deliveries map[string]*Delivery
+	history    []string               // Delivery IDs in creation order
+	retries    map[string]*time.Timer // Deliveries waiting out a retry backoff
+	closed     bool
+}
+
+// NewDispatcher creates a dispatcher and starts its delivery workers
+func NewDispatcher(config *Config) *Dispatcher {
+	config = getConfig(config)
+	client := config.Client
+	if client == nil {
+		client = &http.Client{}
+	}
+
+	d := &Dispatcher{
+		config:     config,
+		client:     client,
+		endpoints:  make(map[string]*Endpoint),
+		deliveries: make(map[string]*Delivery),
+		retries:    make(map[string]*time.Timer),
+	}
+	// The dispatcher schedules its own retries, so a worker is never held
+	// for a backoff
+	d.queue = poltergeist.NewTaskQueue(&poltergeist.TaskConfig{
+		Workers:   config.Workers,
+		QueueSize: config.QueueSize,
+		OnFailure: d.deadLetter,
+	})
+	d.queue.Start()
+	return d
+}
+
+// Attach ties the dispatcher to the server lifecycle, draining deliveries on shutdown
+func (d *Dispatcher) Attach(server *poltergeist.Server) *Dispatcher {
+	server.OnShutdown(d.Shutdown)
+	return d
+}
+
+// Shutdown stops accepting events and waits for in-flight deliveries.
+// Deliveries still waiting for a retry are dead-lettered with
+// ErrDispatcherClosed, so OnDeadLetter can persist them.
+func (d *Dispatcher) Shutdown(ctx context.Context) error {
+	d.mu.Lock()
+	d.closed = true
+	var stopped []string
+	for id, timer := range d.retries {
+		if timer.Stop() {
+			stopped = append(stopped, id)
+		}
+		delete(d.retries, id)
+	}
+	d.mu.Unlock()
+
+	for _, id := range stopped {
+		d.deadLetter(id, ErrDispatcherClosed)
+	}
+	return d.queue.Shutdown(ctx)
+}
+
+// --- Endpoints ---
+
+// Register adds or replaces an endpoint; an ID is generated when empty
+func (d *Dispatcher) Register(endpoint Endpoint) *Endpoint {
+	if endpoint.ID == "" {
+		endpoint.ID = newID()
+	}
+	ep := endpoint
+	ep.Events = append([]string{}, endpoint.Events...)
+	ep.Secrets = append([]string{}, endpoint.Secrets...)
+
+	d.mu.Lock()
+	d.endpoints[ep.ID] = &ep
+	d.mu.Unlock()
+
+	copied := ep
+	return &copied
+}
+
+// Unregister removes an endpoint
+func (d *Dispatcher) Unregister(id string) {
+	d.mu.Lock()
+	delete(d.endpoints, id)
+	d.mu.Unlock()
+}
+
+// Endpoint returns a copy of a registered endpoint
+func (d *Dispatcher) Endpoint(id string) (Endpoint, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	ep, ok := d.endpoints[id]
+	if !ok {
+		return Endpoint{}, ErrEndpointNotFound
+	}
+	return *ep, nil
+}
+
+// Endpoints returns copies of all registered endpoints
+func (d *Dispatcher) Endpoints() []Endpoint {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	result := make([]Endpoint, 0, len(d.endpoints))
+	for _, ep := range d.endpoints {
+		result = append(result, *ep)
+	}
+	return result
+}
+
+// RotateSecret makes secret the primary signing key while keeping the previous
+// primary active, so receivers can verify with either during the rollover
+func (d *Dispatcher) RotateSecret(id, secret string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	ep, ok := d.endpoints[id]
+	if !ok {
+		return ErrEndpointNotFound
+	}
+	secrets := []string{secret}
+	if len(ep.Secrets) > 0 {
+		secrets = append(secrets, ep.Secrets[0])
+	}
+	ep.Secrets = secrets
+	return nil
+}
+
+// RetireSecrets drops all but the primary signing key, ending a rotation
+func (d *Dispatcher) RetireSecrets(id string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	ep, ok := d.endpoints[id]
+	if !ok {
+		return ErrEndpointNotFound
+	}
+	if len(ep.Secrets) > 1 {
+		ep.Secrets = ep.Secrets[:1]
+	}
+	return nil
+}
+
+// --- Publishing ---
+
+// Publish queues an event for every subscribed endpoint and returns the deliveries
+func (d *Dispatcher) Publish(event string, payload any) ([]Delivery, error) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	d.mu.RLock()
+	var targets []string
+	for id, ep := range d.endpoints {
+		if ep.subscribed(event) {
+			targets = append(targets, id)
+		}
+	}
+	d.mu.RUnlock()
+
+	deliveries := make([]Delivery, 0, len(targets))
+	for _, endpointID := range targets {
+		delivery := d.track(&Delivery{
+			ID:         newID(),
+			EndpointID: endpointID,
+			Event:      event,
+			Payload:    body,
+			Status:     StatusPending,
+			CreatedAt:  time.Now(),
+		})
+		if err := d.enqueue(delivery.ID, 0); err != nil {
+			return deliveries, err
+		}
+		deliveries = append(deliveries, delivery)
+	}
+	return deliveries, nil
+}
+
+// Forward publishes a pipeline event to webhooks. The payload function extracts
+// the body from the event context (e.g. a value stored with c.Set).
+func (d *Dispatcher) Forward(pipeline *poltergeist.EventPipeline, event poltergeist.EventType, payload func(c *poltergeist.Context) any) {
+	pipeline.On(event, func(c *poltergeist.Context) {
+		d.Publish(string(event), payload(c))
+	})
+}
+
+// Redeliver requeues a dead or delivered delivery
+func (d *Dispatcher) Redeliver(id string) error {
+	d.mu.Lock()
+	delivery, ok := d.deliveries[id]
+	if ok {
+		delivery.Status = StatusPending
+		delivery.CompletedAt = nil
+		if timer := d.retries[id]; timer != nil && timer.Stop() {
+			delete(d.retries, id)
+		}
+	}
+	d.mu.Unlock()
+
+	if !ok {
+		return ErrDeliveryNotFound
+	}
+	return d.enqueue(id, 0)
+}
+
+// --- Status API ---
+
+// Delivery returns a copy of a tracked delivery
+func (d *Dispatcher) Delivery(id string) (Delivery, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	delivery, ok := d.deliveries[id]
+	if !ok {
+		return Delivery{}, ErrDeliveryNotFound
+	}
+	return *delivery, nil
+}
+
+// Deliveries returns tracked deliveries, newest first, optionally filtered by status
+func (d *Dispatcher) Deliveries(status ...DeliveryStatus) []Delivery {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	result := make([]Delivery, 0, len(d.history))
+	for i := len(d.history) - 1; i >= 0; i-- {
+		delivery := d.deliveries[d.history[i]]
+		if len(status) == 0 || hasStatus(status, delivery.Status) {
+			result = append(result, *delivery)
+		}
+	}
+	return result
+}
+
+// DeadLetters returns deliveries that exhausted their retries
+func (d *Dispatcher) DeadLetters() []Delivery {
+	return d.Deliveries(StatusDead)
+}
+
+// Routes registers the delivery status API on a router or group:
+//
+//	GET  /deliveries            list deliveries (?status=dead)
+//	GET  /deliveries/:id        get one delivery
+//	POST /deliveries/:id/retry  requeue a delivery
+//	GET  /endpoints             list endpoints
+func (d *Dispatcher) Routes(r poltergeist.RouteRegistrar) {
+	r.GET("/deliveries", func(c *poltergeist.Context) error {
+		if status := c.Query("status"); status != "" {
+			return c.JSON(http.StatusOK, d.Deliveries(DeliveryStatus(status)))
+		}
+		return c.JSON(http.StatusOK, d.Deliveries())
+	}).Name("List Webhook Deliveries").Tag("Webhooks")
+
+	r.GET("/deliveries/:id", func(c *poltergeist.Context) error {
+		delivery, err := d.Delivery(c.Param("id"))
+		if err != nil {
+			return c.NotFound(err.Error())
+		}
+		return c.JSON(http.StatusOK, delivery)
+	}).Name("Get Webhook Delivery").Tag("Webhooks")
+
+	r.POST("/deliveries/:id/retry", func(c *poltergeist.Context) error {
+		if err := d.Redeliver(c.Param("id")); err != nil {
+			if errors.Is(err, ErrDeliveryNotFound) {
+				return c.NotFound(err.Error())
+			}
+			return err
+		}
+		return c.JSON(http.StatusAccepted, poltergeist.H{"status": StatusPending})
+	}).Name("Retry Webhook Delivery").Tag("Webhooks")
+
+	r.GET("/endpoints", func(c *poltergeist.Context) error {
+		return c.JSON(http.StatusOK, d.Endpoints())
+	}).Name("List Webhook Endpoints").Tag("Webhooks")
+}
+
+// =============================================================================
+// DELIVERY - Internal delivery machinery
+// =============================================================================
+
+// track stores a delivery and trims finished history beyond MaxHistory
+func (d *Dispatcher) track(delivery *Delivery) Delivery {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.deliveries[delivery.ID] = delivery
+	d.history = append(d.history, delivery.ID)
+
+	for len(d.history) > d.config.MaxHistory {
+		oldest := d.deliveries[d.history[0]]
+		if oldest.Status == StatusPending || oldest.Status == StatusRetrying {
+			break
+		}
+		delete(d.deliveries, oldest.ID)
+		d.history = d.history[1:]
+	}
+	return *delivery
+}
+
+// enqueue schedules a delivery attempt on the worker pool. A failed attempt
+// schedules the next retry; once retries run out the error reaches the
+// queue, which dead-letters the delivery.
+func (d *Dispatcher) enqueue(id string, retry int) error {
+	return d.queue.EnqueueNamed(id, func(ctx context.Context) error {
+		err := d.attempt(ctx, id)
+		if err == nil || retry >= d.config.MaxRetries || !d.scheduleRetry(id, retry+1) {
+			return err
+		}
+		return nil
+	})
+}
+
+// scheduleRetry re-enqueues a delivery after its backoff, returning false
+// once the dispatcher is shutting down
+func (d *Dispatcher) scheduleRetry(id string, retry int) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.closed {
+		return false
+	}
+	d.retries[id] = time.AfterFunc(d.backoff(retry), func() {
+		d.mu.Lock()
+		delete(d.retries, id)
+		d.mu.Unlock()
+		if err := d.enqueue(id, retry); err != nil {
+			d.deadLetter(id, err)
+		}
+	})
+	return true
+}
+
+// backoff returns the wait before a retry: RetryBackoff doubled per earlier
+// retry, up to MaxBackoff
+func (d *Dispatcher) backoff(retry int) time.Duration {
+	wait := d.config.RetryBackoff
+	for i := 1; i < retry && wait < d.config.MaxBackoff; i++ {
+		wait *= 2
+	}
+	return min(wait, d.config.MaxBackoff)
+}
+
+// attempt performs a single delivery attempt
+func (d *Dispatcher) attempt(ctx context.Context, id string) error {
+	d.mu.Lock()
+	delivery, ok := d.deliveries[id]
+	if !ok {
+		d.mu.Unlock()
+		return nil
+	}
+	endpoint, ok := d.endpoints[delivery.EndpointID]
+	if !ok {
+		d.mu.Unlock()
+		return ErrEndpointNotFound
+	}
+	delivery.Attempts++
+	ep := *endpoint
+	event, body := delivery.Event, delivery.Payload
+	d.mu.Unlock()
+
+	code, err := d.send(ctx, &ep, id, event, body)
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	delivery.LastStatusCode = code
+	if err != nil {
+		delivery.Status = StatusRetrying
+		delivery.LastError = err.Error()
+		return err
+	}
+	now := time.Now()
+	delivery.Status = StatusDelivered
+	delivery.LastError = ""
+	delivery.CompletedAt = &now
+	return nil
+}
+
+// send posts a signed payload to an endpoint; non-2xx responses are errors
+func (d *Dispatcher) send(ctx context.Context, ep *Endpoint, id, event string, body []byte) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, d.config.Timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, ep.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	// Endpoint headers go first, so they can't replace the signed ones
+	for key, value := range ep.Headers {
+		req.Header.Set(key, value)
+	}
+	req.Header.Set(poltergeist.HeaderContentType, poltergeist.ContentTypeJSON)
+	req.Header.Set(HeaderEvent, event)
+	req.Header.Set(HeaderDelivery, id)
+	req.Header.Set(HeaderSignature, Sign(ep.Secrets, time.Now(), body))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("webhook: endpoint responded with status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+// deadLetter marks a delivery dead once its retries are exhausted
+func (d *Dispatcher) deadLetter(id string, err error) {
+	d.mu.Lock()
+	delivery, ok := d.deliveries[id]
+	if !ok {
+		d.mu.Unlock()
+		return
+	}
+	now := time.Now()
+	delivery.Status = StatusDead
+	delivery.LastError = err.Error()
+	delivery.CompletedAt = &now
+	dead := *delivery
+	d.mu.Unlock()
+
+	if d.config.OnDeadLetter != nil {
+		d.config.OnDeadLetter(dead)
+	}
+}
+
+// =============================================================================
+// HELPERS
+// =============================================================================
+
+func hasStatus(statuses []DeliveryStatus, status DeliveryStatus) bool {
+	for _, s := range statuses {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
+
+// newID generates a random delivery/endpoint ID
+func newID() string {
+	b := make([]byte, 12)
+	if _, err := rand.Read(b); err != nil {
+		return strconv.FormatInt(time.Now().UnixNano(), 36)
+	}
+	return hex.EncodeToString(b)
+}
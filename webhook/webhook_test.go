@@ -0,0 +1,201 @@
+package webhook
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gofuckbiz/poltergeist"
+)
+
+// =============================================================================
+// DISPATCHER TESTS
+// =============================================================================
+
+// waitDelivery polls a delivery until it reaches status
+func waitDelivery(t *testing.T, d *Dispatcher, id string, status DeliveryStatus) Delivery {
+	t.Helper()
+	deadline := time.Now().Add(3 * time.Second)
+	for {
+		delivery, err := d.Delivery(id)
+		if err == nil && delivery.Status == status {
+			return delivery
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("delivery = %+v, %v; want status %s", delivery, err, status)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestDispatcher_Deliver(t *testing.T) {
+	received := make(chan *http.Request, 1)
+	var body []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ = io.ReadAll(r.Body)
+		received <- r
+	}))
+	defer server.Close()
+
+	d := NewDispatcher(nil)
+	defer d.Shutdown(context.Background())
+	d.Register(Endpoint{ID: "other", URL: server.URL, Events: []string{"order.paid"}, Active: true})
+	ep := d.Register(Endpoint{
+		URL:     server.URL,
+		Events:  []string{"user.created"},
+		Active:  true,
+		Secrets: []string{"secret"},
+		Headers: map[string]string{
+			"Authorization": "Bearer token",
+			HeaderSignature: "t=0,v1=forged",
+			HeaderEvent:     "forged",
+			"Content-Type":  "text/plain",
+		},
+	})
+
+	deliveries, err := d.Publish("user.created", map[string]string{"name": "ada"})
+	if err != nil || len(deliveries) != 1 || deliveries[0].EndpointID != ep.ID {
+		t.Fatalf("Publish = %+v, %v; want one delivery to %s", deliveries, err, ep.ID)
+	}
+	r := <-received
+	delivery := waitDelivery(t, d, deliveries[0].ID, StatusDelivered)
+
+	if string(body) != `{"name":"ada"}` {
+		t.Errorf("body = %s", body)
+	}
+	if err := Verify([]string{"secret"}, r.Header.Get(HeaderSignature), body, time.Minute); err != nil {
+		t.Errorf("signature: %v", err)
+	}
+	if r.Header.Get(HeaderEvent) != "user.created" || r.Header.Get(HeaderDelivery) != delivery.ID {
+		t.Errorf("event/delivery headers = %q, %q", r.Header.Get(HeaderEvent), r.Header.Get(HeaderDelivery))
+	}
+	if r.Header.Get("Content-Type") != poltergeist.ContentTypeJSON || r.Header.Get("Authorization") != "Bearer token" {
+		t.Errorf("content type = %q, authorization = %q", r.Header.Get("Content-Type"), r.Header.Get("Authorization"))
+	}
+	if delivery.Attempts != 1 || delivery.LastStatusCode != http.StatusOK || delivery.CompletedAt == nil {
+		t.Errorf("delivery = %+v", delivery)
+	}
+}
+
+func TestDispatcher_RetryAndDeadLetter(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	dead := make(chan Delivery, 1)
+	d := NewDispatcher(&Config{
+		MaxRetries:   2,
+		RetryBackoff: time.Millisecond,
+		OnDeadLetter: func(delivery Delivery) { dead <- delivery },
+	})
+	defer d.Shutdown(context.Background())
+	d.Register(Endpoint{ID: "ep", URL: server.URL, Active: true, Secrets: []string{"s"}})
+
+	deliveries, _ := d.Publish("ping", nil)
+	select {
+	case delivery := <-dead:
+		if delivery.Attempts != 3 || delivery.LastStatusCode != http.StatusBadGateway || !strings.Contains(delivery.LastError, "502") {
+			t.Errorf("dead letter = %+v", delivery)
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatal("delivery never dead-lettered")
+	}
+	if got := d.DeadLetters(); len(got) != 1 || got[0].ID != deliveries[0].ID {
+		t.Errorf("DeadLetters = %+v", got)
+	}
+	if calls.Load() != 3 {
+		t.Errorf("endpoint called %d times, want 3", calls.Load())
+	}
+
+	// Redelivery runs the attempts again
+	if err := d.Redeliver(deliveries[0].ID); err != nil {
+		t.Fatal(err)
+	}
+	<-dead
+	if err := d.Redeliver("missing"); err != ErrDeliveryNotFound {
+		t.Errorf("Redeliver(missing) = %v", err)
+	}
+}
+
+func TestDispatcher_RetryFreesWorker(t *testing.T) {
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer failing.Close()
+	ok := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer ok.Close()
+
+	dead := make(chan Delivery, 1)
+	d := NewDispatcher(&Config{
+		Workers:      1,
+		MaxRetries:   3,
+		RetryBackoff: time.Hour,
+		OnDeadLetter: func(delivery Delivery) { dead <- delivery },
+	})
+	d.Register(Endpoint{ID: "failing", URL: failing.URL, Active: true, Events: []string{"a"}})
+	d.Register(Endpoint{ID: "ok", URL: ok.URL, Active: true, Events: []string{"b"}})
+
+	retrying, _ := d.Publish("a", nil)
+	waitDelivery(t, d, retrying[0].ID, StatusRetrying)
+
+	// The only worker must not be sleeping through the hour-long backoff
+	delivered, _ := d.Publish("b", nil)
+	waitDelivery(t, d, delivered[0].ID, StatusDelivered)
+
+	if err := d.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case delivery := <-dead:
+		if delivery.ID != retrying[0].ID || delivery.Attempts != 1 || delivery.LastError != ErrDispatcherClosed.Error() {
+			t.Errorf("dead letter = %+v, want the pending retry", delivery)
+		}
+	default:
+		t.Error("pending retry was not dead-lettered on shutdown")
+	}
+}
+
+func TestNewDispatcher_PartialConfig(t *testing.T) {
+	config := &Config{MaxRetries: 1}
+	d := NewDispatcher(config)
+	defer d.Shutdown(context.Background())
+
+	defaults := DefaultConfig()
+	if d.config.Workers != defaults.Workers || d.config.RetryBackoff != defaults.RetryBackoff ||
+		d.config.MaxBackoff != defaults.MaxBackoff || d.config.Timeout != defaults.Timeout || d.config.MaxRetries != 1 {
+		t.Errorf("config = %+v, want defaults for unset fields", d.config)
+	}
+	if config.Workers != 0 || config.RetryBackoff != 0 || config.Timeout != 0 {
+		t.Errorf("caller's config modified: %+v", config)
+	}
+}
+
+func TestDispatcher_TimeoutWithCustomClient(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer server.Close()
+	defer close(release)
+
+	d := NewDispatcher(&Config{Client: &http.Client{}, Timeout: 20 * time.Millisecond})
+	defer d.Shutdown(context.Background())
+	d.Register(Endpoint{URL: server.URL, Active: true})
+
+	deliveries, _ := d.Publish("slow", nil)
+	delivery := waitDelivery(t, d, deliveries[0].ID, StatusDead)
+	if !strings.Contains(delivery.LastError, "deadline exceeded") {
+		t.Errorf("last error = %q, want a timeout", delivery.LastError)
+	}
+}
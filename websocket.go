@@ -1,10 +1,13 @@
 package poltergeist
 
 import (
+	"context"
 	"encoding/json"
-	"log"
+	"errors"
 	"net/http"
+	"strconv"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/gorilla/websocket"
@@ -26,6 +29,29 @@ type WSConfig struct {
 	ReadTimeout       time.Duration              // Read timeout (default: 60s)
 	MaxMessageSize    int64                      // Max message size (default: 512KB)
 	HandshakeTimeout  time.Duration              // Handshake timeout (default: 10s)
+	Subprotocols      []string                   // Supported subprotocols, in order of preference
+
+	// OnUpgrade runs before the handshake, after the route's middleware;
+	// returning an error (e.g. ErrUnauthorized, ErrForbidden) rejects the
+	// upgrade with that HTTP response. Values it stores with c.Set are
+	// available to the message handler through WSConn.Get.
+	OnUpgrade func(c *Context) error
+	// OnConnect runs once the connection is established, before any message
+	// is read; the place to register event handlers with WSConn.On
+	OnConnect func(conn *WSConn)
+	// ConnID names the connection, e.g. by user ID, for WSHub.SendTo
+	// (default: generated). IDs are unique within a hub: a connection
+	// registering a taken ID replaces the older one, which is closed with
+	// code 4409.
+	ConnID func(c *Context) string
+
+	// Inbound limits per connection, with a burst of one second's worth
+	// (default: unlimited). A message larger than MaxBytesPerSecond is
+	// always over the limit.
+	MaxMessagesPerSecond float64
+	MaxBytesPerSecond    int
+	// What happens to messages over the limits (default: WSLimitDrop)
+	LimitAction WSLimitAction
 }
 
 // DefaultWSConfig returns default WebSocket configuration
@@ -57,42 +83,81 @@ type WSConn struct {
 	closeMu  sync.Mutex
 	pipeline *EventPipeline
 	ctx      *Context
-	id       string // Unique connection ID for room management
+	msgCtx   context.Context // trace context of the message being handled
+	id       string          // Unique connection ID for room management
+
+	eventsMu sync.RWMutex
+	events   map[string]WSEventHandler // handlers registered with On
+
+	acksMu      sync.Mutex
+	acks        map[string]*wsAck // pending EmitWithAck replies by message ID
+	acksPending atomic.Int64      // len(acks), read without the lock
+	ackSeq      atomic.Uint64
+
+	metaMu sync.RWMutex
+	meta   map[string]any // values stored with Set
+
+	identity   atomic.Pointer[string] // presence identity (default: id)
+	lastActive atomic.Int64           // unix nanoseconds of the last message received
+
+	hub     *WSHub     // hub the connection is registered with, set before the pumps start
+	limiter *wsLimiter // inbound limits, nil without any; used by readPump only
+
+	customID       bool         // id was set by WSConfig.ConnID
+	resuming       *wsSuspended // session named by the resume token, claimed on registration
+	resumeToken    string       // token the session event gave the client
+	closedByClient atomic.Bool  // the client closed with a normal closure
 }
 
 // newWSConn creates a new WebSocket connection wrapper
 func newWSConn(conn *websocket.Conn, config *WSConfig, pipeline *EventPipeline, ctx *Context) *WSConn {
-	return &WSConn{
+	pipeline.instruments().wsConnections.Add(1)
+	c := &WSConn{
 		conn:     conn,
 		config:   config,
 		send:     make(chan []byte, DefaultBufferSize),
 		pipeline: pipeline,
 		ctx:      ctx,
 		id:       generateConnID(),
+		limiter:  newWSLimiter(config),
 	}
+	c.lastActive.Store(time.Now().UnixNano())
+	return c
 }
 
+// connSeq disambiguates connection IDs generated in the same nanosecond
+var connSeq atomic.Uint64
+
 // generateConnID generates a unique connection ID
 func generateConnID() string {
-	return time.Now().Format("20060102150405.000000000")
+	return time.Now().Format("20060102150405.000000000") + "-" + strconv.FormatUint(connSeq.Add(1), 10)
 }
 
 // --- Send Methods ---
 
 // Send sends a raw message to the connection
 func (c *WSConn) Send(message []byte) error {
+	if queued, _ := c.enqueue(message); !queued {
+		return websocket.ErrCloseSent
+	}
+	return nil
+}
+
+// enqueue queues message without blocking, reporting whether it was queued
+// and whether the connection is still open
+func (c *WSConn) enqueue(message []byte) (queued, open bool) {
 	c.closeMu.Lock()
 	defer c.closeMu.Unlock()
 
 	if c.closed {
-		return websocket.ErrCloseSent
+		return false, false
 	}
 
 	select {
 	case c.send <- message:
-		return nil
+		return true, true
 	default:
-		return websocket.ErrCloseSent
+		return false, true
 	}
 }
 
@@ -110,6 +175,61 @@ func (c *WSConn) SendText(text string) error {
 	return c.Send([]byte(text))
 }
 
+// Subprotocol returns the subprotocol negotiated during the handshake
+func (c *WSConn) Subprotocol() string {
+	return c.conn.Subprotocol()
+}
+
+// Set stores a value on the connection (tenant, permissions, ...) for the
+// connection's lifetime; safe to call while other goroutines read it, e.g.
+// in WSHub.BroadcastWhere predicates
+func (c *WSConn) Set(key string, value any) {
+	c.metaMu.Lock()
+	defer c.metaMu.Unlock()
+	if c.meta == nil {
+		c.meta = make(map[string]any)
+	}
+	c.meta[key] = value
+}
+
+// Get returns a value stored with Set, else one stored on the upgrade
+// request's Context, such as the principal set by auth middleware or
+// OnUpgrade
+func (c *WSConn) Get(key string) (any, bool) {
+	c.metaMu.RLock()
+	value, ok := c.meta[key]
+	c.metaMu.RUnlock()
+	if ok || c.ctx == nil {
+		return value, ok
+	}
+	return c.ctx.Get(key)
+}
+
+// ID returns the connection's ID, as used by WSHub.SendTo
+func (c *WSConn) ID() string {
+	return c.id
+}
+
+// SetIdentity sets who the connection belongs to (e.g. a user ID), for hub
+// presence; set it before joining rooms
+func (c *WSConn) SetIdentity(identity string) {
+	c.identity.Store(&identity)
+}
+
+// Identity returns the identity set with SetIdentity, or the connection ID
+func (c *WSConn) Identity() string {
+	if identity := c.identity.Load(); identity != nil {
+		return *identity
+	}
+	return c.id
+}
+
+// LastSeen returns when the connection was established or last received a
+// message
+func (c *WSConn) LastSeen() time.Time {
+	return time.Unix(0, c.lastActive.Load())
+}
+
 // --- Lifecycle ---
 
 // Close closes the connection
@@ -123,9 +243,17 @@ func (c *WSConn) Close() error {
 
 	c.closed = true
 	close(c.send)
+	c.pipeline.instruments().wsConnections.Add(-1)
 	return c.conn.Close()
 }
 
+// CloseWithReason sends a close frame with a status code (e.g. 4401 for
+// application-defined errors) and reason, then closes the connection
+func (c *WSConn) CloseWithReason(code int, reason string) error {
+	c.conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(code, reason), time.Now().Add(c.config.WriteTimeout))
+	return c.Close()
+}
+
 // readPump reads messages from the connection
 func (c *WSConn) readPump(handler WSMessageHandler) {
 	defer func() {
@@ -133,8 +261,10 @@ func (c *WSConn) readPump(handler WSMessageHandler) {
 			c.pipeline.Emit(EventWSDisconnect, c.ctx)
 		}
 		c.Close()
+		c.failAcks()
 	}()
 
+	dispatch := c.messageChain(handler)
 	c.conn.SetReadLimit(c.config.MaxMessageSize)
 	c.conn.SetReadDeadline(time.Now().Add(c.config.ReadTimeout))
 	c.conn.SetPongHandler(func(string) error {
@@ -146,17 +276,40 @@ func (c *WSConn) readPump(handler WSMessageHandler) {
 	for {
 		messageType, message, err := c.conn.ReadMessage()
 		if err != nil {
+			c.closedByClient.Store(websocket.IsCloseError(err, websocket.CloseNormalClosure))
 			if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseAbnormalClosure, websocket.CloseNormalClosure) {
-				log.Printf("WebSocket error: %v", err)
+				c.pipeline.logger().Warn("websocket read error", "client_id", c.id, "error", err)
 			}
 			break
 		}
 
 		// Reset read deadline after each message
-		c.conn.SetReadDeadline(time.Now().Add(c.config.ReadTimeout))
+		now := time.Now()
+		c.conn.SetReadDeadline(now.Add(c.config.ReadTimeout))
+		c.lastActive.Store(now.UnixNano())
+		c.pipeline.instruments().wsMessages.Add(1, metricDirectionIn)
+		if c.hub != nil {
+			c.hub.countMessage(metricDirectionIn)
+		}
+		handle, closed := c.admit(message)
+		if closed {
+			break
+		}
+		if !handle {
+			continue
+		}
 
-		if handler != nil {
-			handler(c, messageType, message)
+		if handler == nil && !c.wantsEvents() {
+			continue
+		}
+		if tracer := c.pipeline.tracer(); tracer != nil {
+			var span Span
+			c.msgCtx, span = c.startMessageSpan(tracer, message)
+			dispatch(c, messageType, message)
+			span.End()
+			c.msgCtx = nil
+		} else {
+			dispatch(c, messageType, message)
 		}
 	}
 }
@@ -180,6 +333,10 @@ func (c *WSConn) writePump() {
 			if err := c.conn.WriteMessage(websocket.TextMessage, message); err != nil {
 				return
 			}
+			c.pipeline.instruments().wsMessages.Add(1, metricDirectionOut)
+			if c.hub != nil {
+				c.hub.countMessage(metricDirectionOut)
+			}
 
 		case <-ticker.C:
 			c.conn.SetWriteDeadline(time.Now().Add(c.config.WriteTimeout))
@@ -194,15 +351,27 @@ func (c *WSConn) writePump() {
 // WEBSOCKET HUB - Manages multiple connections
 // =============================================================================
 
+// ErrWSConnNotFound is returned by WSHub.SendTo for an unknown connection ID
+var ErrWSConnNotFound = errors.New("websocket: connection not found")
+
+// wsCloseReplaced is the close code sent to a connection whose ID was taken
+// over by a new one
+const wsCloseReplaced = 4409
+
 // WSHub manages multiple WebSocket connections
 type WSHub struct {
-	*BaseHub                       // Embed common hub functionality (DRY)
-	connections map[*WSConn]bool   // Active connections
-	connMu      sync.RWMutex       // Connection mutex
-	broadcast   chan []byte        // Broadcast channel
-	register    chan *WSConn       // Register channel
-	unregister  chan *WSConn       // Unregister channel
-	connIndex   map[string]*WSConn // ID -> connection mapping for rooms
+	*BaseHub                                 // Embed common hub functionality (DRY)
+	connections map[*WSConn]bool             // Active connections
+	connMu      sync.RWMutex                 // Connection mutex
+	broadcast   chan []byte                  // Broadcast channel
+	register    chan *WSConn                 // Register channel
+	unregister  chan *WSConn                 // Unregister channel
+	connIndex   map[string]*WSConn           // ID -> connection mapping for rooms
+	presence    *presenceTracker             // identities online per room
+	counters    wsHubCounters                // activity for Stats and UseMetrics
+	relay       atomic.Pointer[wsHubRelay]   // broadcasts across instances, see UseBackend
+	resume      atomic.Pointer[wsResumption] // sessions of dropped clients, see EnableResumption
+	history     atomic.Pointer[wsHistory]    // recorded room events, see UseHistory
 }
 
 // NewWSHub creates a new WebSocket hub
@@ -214,6 +383,7 @@ func NewWSHub() *WSHub {
 		register:    make(chan *WSConn),
 		unregister:  make(chan *WSConn),
 		connIndex:   make(map[string]*WSConn),
+		presence:    newPresenceTracker(),
 	}
 }
 
@@ -226,6 +396,7 @@ func (h *WSHub) Run() {
 		select {
 		case <-h.shutdownChan():
 			h.closeAllConnections()
+			h.closeBackend()
 			return
 		case conn := <-h.register:
 			h.registerConn(conn)
@@ -248,12 +419,9 @@ func (h *WSHub) closeAllConnections() {
 	defer h.connMu.Unlock()
 
 	for conn := range h.connections {
-		// Send close message before closing
-		conn.conn.WriteMessage(
-			websocket.CloseMessage,
-			websocket.FormatCloseMessage(websocket.CloseGoingAway, "server shutdown"),
-		)
-		conn.Close()
+		// Send close message before closing (as a control frame, which may
+		// be written concurrently with the write pump)
+		conn.CloseWithReason(websocket.CloseGoingAway, "server shutdown")
 		delete(h.connections, conn)
 		delete(h.connIndex, conn.id)
 	}
@@ -263,39 +431,96 @@ func (h *WSHub) closeAllConnections() {
 
 func (h *WSHub) registerConn(conn *WSConn) {
 	h.connMu.Lock()
-	defer h.connMu.Unlock()
+	if previous, ok := h.connIndex[conn.id]; ok && previous != conn {
+		// A custom ID taken over by a new connection
+		delete(h.connections, previous)
+		go previous.CloseWithReason(wsCloseReplaced, "replaced by a new connection")
+	}
 	h.connections[conn] = true
 	h.connIndex[conn.id] = conn
+	rooms, superseded := h.resumeSession(conn)
+	open := len(h.connections)
+	h.connMu.Unlock()
+
+	h.countConnections(open, 1)
+	if superseded != nil {
+		h.releaseRooms(superseded)
+	}
+	for _, room := range rooms {
+		h.countRooms(room)
+		h.syncRoomSubscription(room)
+		if event := h.presence.join(conn, room); event != nil {
+			h.emitPresence(*event)
+		}
+	}
 }
 
 func (h *WSHub) unregisterConn(conn *WSConn) {
 	h.connMu.Lock()
-	defer h.connMu.Unlock()
-
-	if _, ok := h.connections[conn]; ok {
+	_, ok := h.connections[conn]
+	var rooms []string
+	if ok {
 		delete(h.connections, conn)
 		delete(h.connIndex, conn.id)
-		h.removeFromAllRooms(conn.id)
+		rooms = h.removeFromAllRooms(conn.id)
+		h.suspend(conn, rooms)
+	}
+	open := len(h.connections)
+	h.connMu.Unlock()
+
+	if ok {
+		h.countConnections(open, 0)
+		h.countRooms(rooms...)
+		for _, room := range rooms {
+			h.syncRoomSubscription(room)
+		}
 	}
+	h.emitPresence(h.presence.disconnect(conn)...)
 }
 
 func (h *WSHub) broadcastToAll(message []byte) {
+	h.broadcastExcept(nil, message)
+}
+
+// broadcastExcept sends message to every connection but except
+func (h *WSHub) broadcastExcept(except *WSConn, message []byte) {
 	h.connMu.RLock()
 	defer h.connMu.RUnlock()
 
 	for conn := range h.connections {
-		select {
-		case conn.send <- message:
-		default:
-			go conn.Close()
+		if conn != except {
+			h.deliver(conn, message)
 		}
 	}
+	h.queueSuspended("", message)
+}
+
+// deliver queues message on conn, dropping the connection if it can't keep
+// up
+func (h *WSHub) deliver(conn *WSConn, message []byte) error {
+	queued, open := conn.enqueue(message)
+	switch {
+	case queued:
+		return nil
+	case !open:
+		return ErrWSClosed
+	}
+	h.countDropped()
+	h.dropSlowConn(conn)
+	return ErrWSSendBufferFull
+}
+
+// dropSlowConn closes a connection whose send buffer is full and reports it
+func (h *WSHub) dropSlowConn(conn *WSConn) {
+	go conn.Close()
+	captureHubFailure(h.pipeline(), "websocket", conn.id, ErrWSSendBufferFull)
 }
 
 // --- Public API ---
 
 // Broadcast sends a message to all connections
 func (h *WSHub) Broadcast(message []byte) {
+	h.publish(HubChannelAll, message)
 	h.broadcast <- message
 }
 
@@ -309,20 +534,83 @@ func (h *WSHub) BroadcastJSON(v any) error {
 	return nil
 }
 
+// SendTo sends a message to the connection with the given ID, for direct
+// messages and targeted notifications; ErrWSConnNotFound if it isn't
+// connected to the hub
+//
+//	cfg := poltergeist.DefaultWSConfig()
+//	cfg.ConnID = func(c *poltergeist.Context) string { return currentUser(c).ID }
+//	app.WebSocketWithHub("/ws", hub, onMessage, cfg)
+//
+//	hub.SendJSONTo(order.CustomerID, poltergeist.H{"event": "order:shipped"})
+func (h *WSHub) SendTo(connID string, message []byte) error {
+	h.connMu.RLock()
+	defer h.connMu.RUnlock()
+
+	conn, ok := h.connIndex[connID]
+	if !ok {
+		if h.sendSuspended(connID, message) {
+			return nil
+		}
+		return ErrWSConnNotFound
+	}
+	return h.deliver(conn, message)
+}
+
+// SendJSONTo sends a JSON message to the connection with the given ID
+func (h *WSHub) SendJSONTo(connID string, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return h.SendTo(connID, data)
+}
+
+// BroadcastExcept sends a message to all connections but conn, typically
+// the sender of what is being relayed
+func (h *WSHub) BroadcastExcept(conn *WSConn, message []byte) {
+	h.broadcastExcept(conn, message)
+	h.publish(HubChannelAll, message)
+}
+
+// BroadcastJSONExcept sends a JSON message to all connections but conn
+func (h *WSHub) BroadcastJSONExcept(conn *WSConn, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	h.BroadcastExcept(conn, data)
+	return nil
+}
+
 // BroadcastToRoom sends a message to all connections in a room
 func (h *WSHub) BroadcastToRoom(room string, message []byte) {
+	h.BroadcastToRoomExcept(room, nil, message)
+}
+
+// BroadcastToRoomExcept sends a message to all connections in a room but
+// conn, so chat messages and edits don't echo back to their sender:
+//
+//	conn.On("doc:edit", func(conn *poltergeist.WSConn, e *poltergeist.WSEvent) {
+//	    hub.BroadcastToRoomExcept(docRoom, conn, e.Data)
+//	})
+func (h *WSHub) BroadcastToRoomExcept(room string, except *WSConn, message []byte) {
+	h.broadcastToRoomLocal(room, except, message)
+	h.publishToRoom(room, message)
+}
+
+// broadcastToRoomLocal sends message to the room's connections on this
+// instance but except
+func (h *WSHub) broadcastToRoomLocal(room string, except *WSConn, message []byte) {
 	h.connMu.RLock()
 	defer h.connMu.RUnlock()
 
 	for _, clientID := range h.getRoomClientIDs(room) {
-		if conn, ok := h.connIndex[clientID]; ok {
-			select {
-			case conn.send <- message:
-			default:
-				go conn.Close()
-			}
+		if conn, ok := h.connIndex[clientID]; ok && conn != except {
+			h.deliver(conn, message)
 		}
 	}
+	h.queueSuspended(room, message)
 }
 
 // BroadcastJSONToRoom sends a JSON message to all connections in a room
@@ -335,14 +623,83 @@ func (h *WSHub) BroadcastJSONToRoom(room string, v any) error {
 	return nil
 }
 
+// BroadcastJSONToRoomExcept sends a JSON message to all connections in a
+// room but conn
+func (h *WSHub) BroadcastJSONToRoomExcept(room string, conn *WSConn, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	h.BroadcastToRoomExcept(room, conn, data)
+	return nil
+}
+
 // JoinRoom adds a connection to a room
 func (h *WSHub) JoinRoom(conn *WSConn, room string) {
 	h.addToRoom(conn.id, room)
+	h.countRooms(room)
+	h.syncRoomSubscription(room)
+	if event := h.presence.join(conn, room); event != nil {
+		h.emitPresence(*event)
+	}
 }
 
 // LeaveRoom removes a connection from a room
 func (h *WSHub) LeaveRoom(conn *WSConn, room string) {
 	h.removeFromRoom(conn.id, room)
+	h.countRooms(room)
+	h.syncRoomSubscription(room)
+	if event := h.presence.leave(conn, room); event != nil {
+		h.emitPresence(*event)
+	}
+}
+
+// ForEach calls fn for every connection until it returns false. It works on
+// a snapshot, so fn may use the hub; connections joining meanwhile are
+// missed.
+func (h *WSHub) ForEach(fn func(conn *WSConn) bool) {
+	for _, conn := range h.snapshot() {
+		if !fn(conn) {
+			return
+		}
+	}
+}
+
+// BroadcastWhere sends a message to the connections matching predicate,
+// e.g. by values stored with WSConn.Set:
+//
+//	hub.BroadcastWhere(func(conn *poltergeist.WSConn) bool {
+//	    tenant, _ := conn.Get("tenant")
+//	    return tenant == invoice.TenantID
+//	}, message)
+func (h *WSHub) BroadcastWhere(predicate func(conn *WSConn) bool, message []byte) {
+	for _, conn := range h.snapshot() {
+		if predicate(conn) {
+			h.deliver(conn, message)
+		}
+	}
+}
+
+// BroadcastJSONWhere sends a JSON message to the connections matching
+// predicate
+func (h *WSHub) BroadcastJSONWhere(predicate func(conn *WSConn) bool, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	h.BroadcastWhere(predicate, data)
+	return nil
+}
+
+// snapshot returns the current connections
+func (h *WSHub) snapshot() []*WSConn {
+	h.connMu.RLock()
+	defer h.connMu.RUnlock()
+	conns := make([]*WSConn, 0, len(h.connections))
+	for conn := range h.connections {
+		conns = append(conns, conn)
+	}
+	return conns
 }
 
 // ConnectionCount returns the number of active connections
@@ -366,25 +723,43 @@ type WSMessageHandler func(conn *WSConn, messageType int, message []byte)
 
 // WebSocket creates a WebSocket handler
 func (s *Server) WebSocket(path string, handler WSMessageHandler, config ...*WSConfig) *Route {
+	route := s.GET(path, s.WebSocketHandler(handler, config...))
+	route.RouteProtocol = ProtocolWebSocket
+	return route
+}
+
+// WebSocketHandler returns a handler that upgrades the request and serves the
+// connection until it closes, for routes that also answer plain HTTP:
+//
+//	ws := app.WebSocketHandler(onMessage)
+//	app.GET("/live", func(c *poltergeist.Context) error {
+//	    if c.IsWebSocket() {
+//	        return ws(c)
+//	    }
+//	    return c.HTML(200, page)
+//	})
+func (s *Server) WebSocketHandler(handler WSMessageHandler, config ...*WSConfig) HandlerFunc {
 	cfg := getWSConfig(config)
 	upgrader := createUpgrader(cfg)
 
-	return s.GET(path, func(c *Context) error {
-		conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
+	return func(c *Context) error {
+		wsConn, err := s.upgradeWS(c, cfg, &upgrader, nil)
 		if err != nil {
 			return err
 		}
 
-		wsConn := newWSConn(conn, cfg, s.Pipeline(), c)
-		c.WS = wsConn
-
 		s.Pipeline().Emit(EventWSConnect, c)
 
 		go wsConn.writePump()
 		wsConn.readPump(handler)
 
 		return nil
-	})
+	}
+}
+
+// IsWebSocket reports whether the request asks for a WebSocket upgrade
+func (c *Context) IsWebSocket() bool {
+	return websocket.IsWebSocketUpgrade(c.Request)
 }
 
 // WebSocketWithHub creates a WebSocket handler with hub support
@@ -392,17 +767,19 @@ func (s *Server) WebSocketWithHub(path string, hub *WSHub, handler WSMessageHand
 	cfg := getWSConfig(config)
 	upgrader := createUpgrader(cfg)
 
-	return s.GET(path, func(c *Context) error {
-		conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
+	route := s.GET(path, func(c *Context) error {
+		wsConn, err := s.upgradeWS(c, cfg, &upgrader, func(conn *WSConn) {
+			conn.hub = hub
+			hub.prepareResume(conn)
+		})
 		if err != nil {
 			return err
 		}
 
-		wsConn := newWSConn(conn, cfg, s.Pipeline(), c)
-		c.WS = wsConn
-
+		hub.attach(s.Pipeline())
 		hub.register <- wsConn
 		defer func() { hub.unregister <- wsConn }()
+		hub.replayOnConnect(wsConn)
 
 		s.Pipeline().Emit(EventWSConnect, c)
 
@@ -411,10 +788,43 @@ func (s *Server) WebSocketWithHub(path string, hub *WSHub, handler WSMessageHand
 
 		return nil
 	})
+	route.RouteProtocol = ProtocolWebSocket
+	return route
 }
 
 // --- Helpers (DRY) ---
 
+// upgradeWS runs the OnUpgrade hook and completes the handshake; prepare,
+// if any, sees the connection before OnConnect
+func (s *Server) upgradeWS(c *Context, cfg *WSConfig, upgrader *websocket.Upgrader, prepare func(conn *WSConn)) (*WSConn, error) {
+	if cfg.OnUpgrade != nil {
+		if err := cfg.OnUpgrade(c); err != nil {
+			return nil, err
+		}
+	}
+
+	conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	wsConn := newWSConn(conn, cfg, s.Pipeline(), c)
+	if cfg.ConnID != nil {
+		if id := cfg.ConnID(c); id != "" {
+			wsConn.id = id
+			wsConn.customID = true
+		}
+	}
+	if prepare != nil {
+		prepare(wsConn)
+	}
+	c.WS = wsConn
+	if cfg.OnConnect != nil {
+		cfg.OnConnect(wsConn)
+	}
+	return wsConn, nil
+}
+
 func getWSConfig(config []*WSConfig) *WSConfig {
 	if len(config) > 0 && config[0] != nil {
 		return config[0]
@@ -429,5 +839,6 @@ func createUpgrader(cfg *WSConfig) websocket.Upgrader {
 		EnableCompression: cfg.EnableCompression,
 		CheckOrigin:       cfg.CheckOrigin,
 		HandshakeTimeout:  cfg.HandshakeTimeout,
+		Subprotocols:      cfg.Subprotocols,
 	}
 }
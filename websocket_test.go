@@ -0,0 +1,265 @@
+package poltergeist
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// =============================================================================
+// WEBSOCKET TESTS
+// =============================================================================
+
+func TestWebSocketHandler_SharedRoute(t *testing.T) {
+	app := New()
+	cfg := DefaultWSConfig()
+	cfg.Subprotocols = []string{"chat.v2", "chat.v1"}
+	ws := app.WebSocketHandler(func(conn *WSConn, _ int, msg []byte) {
+		conn.SendText(conn.Subprotocol() + ":" + string(msg))
+	}, cfg)
+	app.GET("/live", func(c *Context) error {
+		if c.IsWebSocket() {
+			return ws(c)
+		}
+		return c.String(http.StatusOK, "page")
+	})
+
+	server := httptest.NewServer(app.Router())
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/live")
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if string(body) != "page" {
+		t.Errorf("plain GET body = %q, want page", body)
+	}
+
+	dialer := websocket.Dialer{Subprotocols: []string{"chat.v1"}}
+	conn, _, err := dialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"/live", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	conn.WriteMessage(websocket.TextMessage, []byte("hi"))
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	_, msg, err := conn.ReadMessage()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(msg) != "chat.v1:hi" {
+		t.Errorf("reply = %q, want the negotiated subprotocol echoed", msg)
+	}
+}
+
+func TestWSConn_CloseWithReason(t *testing.T) {
+	app := New()
+	app.WebSocket("/ws", func(conn *WSConn, _ int, _ []byte) {
+		conn.CloseWithReason(4401, "Unauthorized")
+	})
+	server := httptest.NewServer(app.Router())
+	defer server.Close()
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"/ws", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	conn.WriteMessage(websocket.TextMessage, []byte("hello"))
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	_, _, err = conn.ReadMessage()
+	if !websocket.IsCloseError(err, 4401) {
+		t.Errorf("read error = %v, want close 4401", err)
+	}
+}
+
+func TestWSConfig_OnUpgrade(t *testing.T) {
+	app := New()
+	cfg := DefaultWSConfig()
+	cfg.OnUpgrade = func(c *Context) error {
+		token := c.Query("token")
+		if token == "" {
+			return ErrUnauthorized
+		}
+		c.Set(ContextKeyPrincipal, token)
+		return nil
+	}
+	app.WebSocket("/ws", func(conn *WSConn, _ int, _ []byte) {
+		principal, _ := conn.Get(ContextKeyPrincipal)
+		conn.SendText(principal.(string))
+	}, cfg)
+	server := httptest.NewServer(app.Router())
+	defer server.Close()
+	url := "ws" + strings.TrimPrefix(server.URL, "http") + "/ws"
+
+	_, resp, err := websocket.DefaultDialer.Dial(url, nil)
+	if err == nil || resp == nil || resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("dial without token: err = %v, resp = %v, want 401", err, resp)
+	}
+
+	conn, _, err := websocket.DefaultDialer.Dial(url+"?token=alice", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	conn.WriteMessage(websocket.TextMessage, []byte("who"))
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	_, msg, err := conn.ReadMessage()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(msg) != "alice" {
+		t.Errorf("principal = %q, want alice", msg)
+	}
+}
+
+func TestWSHub_BroadcastExcept(t *testing.T) {
+	hub := NewWSHub()
+	go hub.Run()
+	defer hub.ShutdownWithTimeout(time.Second)
+
+	app := New()
+	cfg := DefaultWSConfig()
+	cfg.OnConnect = func(conn *WSConn) { hub.JoinRoom(conn, "doc") }
+	app.WebSocketWithHub("/ws", hub, func(conn *WSConn, _ int, msg []byte) {
+		if string(msg) == "all" {
+			hub.BroadcastExcept(conn, msg)
+		} else {
+			hub.BroadcastToRoomExcept("doc", conn, msg)
+		}
+		conn.SendText("done")
+	}, cfg)
+	server := httptest.NewServer(app.Router())
+	defer server.Close()
+
+	var conns []*websocket.Conn
+	for i := 0; i < 3; i++ {
+		conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"/ws", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer conn.Close()
+		conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+		conns = append(conns, conn)
+	}
+	waitFor(t, func() bool { return hub.ConnectionCount() == 3 })
+
+	for _, text := range []string{"edit", "all"} {
+		conns[0].WriteMessage(websocket.TextMessage, []byte(text))
+		if _, msg, err := conns[0].ReadMessage(); err != nil || string(msg) != "done" {
+			t.Fatalf("%s: sender got %q, %v; want only done", text, msg, err)
+		}
+		for _, other := range conns[1:] {
+			if _, msg, err := other.ReadMessage(); err != nil || string(msg) != text {
+				t.Fatalf("%s: other got %q, %v", text, msg, err)
+			}
+		}
+	}
+}
+
+func TestWSHub_SendTo(t *testing.T) {
+	hub := NewWSHub()
+	go hub.Run()
+	defer hub.ShutdownWithTimeout(time.Second)
+
+	app := New()
+	cfg := DefaultWSConfig()
+	cfg.ConnID = func(c *Context) string { return c.Query("user") }
+	app.WebSocketWithHub("/ws", hub, func(conn *WSConn, _ int, msg []byte) {
+		hub.SendTo(string(msg), []byte("from "+conn.ID()))
+	}, cfg)
+	server := httptest.NewServer(app.Router())
+	defer server.Close()
+
+	dial := func(user string) *websocket.Conn {
+		conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"/ws?user="+user, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+		return conn
+	}
+	alice, bob := dial("alice"), dial("bob")
+	defer alice.Close()
+	defer bob.Close()
+	waitFor(t, func() bool { return hub.ConnectionCount() == 2 })
+
+	alice.WriteMessage(websocket.TextMessage, []byte("bob"))
+	if _, msg, err := bob.ReadMessage(); err != nil || string(msg) != "from alice" {
+		t.Fatalf("bob got %q, %v", msg, err)
+	}
+	if err := hub.SendTo("carol", []byte("hi")); err != ErrWSConnNotFound {
+		t.Errorf("SendTo unknown = %v, want ErrWSConnNotFound", err)
+	}
+
+	// A second connection with the same ID replaces the first
+	bob2 := dial("bob")
+	defer bob2.Close()
+	if _, _, err := bob.ReadMessage(); !websocket.IsCloseError(err, wsCloseReplaced) {
+		t.Fatalf("replaced connection read = %v, want close %d", err, wsCloseReplaced)
+	}
+	if err := hub.SendJSONTo("bob", H{"to": "bob2"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, msg, err := bob2.ReadMessage(); err != nil || string(msg) != `{"to":"bob2"}` {
+		t.Fatalf("bob2 got %q, %v", msg, err)
+	}
+}
+
+func TestWSHub_BroadcastWhere(t *testing.T) {
+	hub := NewWSHub()
+	go hub.Run()
+	defer hub.ShutdownWithTimeout(time.Second)
+
+	app := New()
+	cfg := DefaultWSConfig()
+	cfg.OnConnect = func(conn *WSConn) { conn.Set("tenant", conn.ctx.Query("tenant")) }
+	app.WebSocketWithHub("/ws", hub, nil, cfg)
+	server := httptest.NewServer(app.Router())
+	defer server.Close()
+
+	var conns []*websocket.Conn
+	for _, tenant := range []string{"acme", "globex", "acme"} {
+		conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"/ws?tenant="+tenant, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer conn.Close()
+		conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+		conns = append(conns, conn)
+	}
+	waitFor(t, func() bool { return hub.ConnectionCount() == 3 })
+
+	visited := 0
+	hub.ForEach(func(conn *WSConn) bool {
+		visited++
+		return visited < 2
+	})
+	if visited != 2 {
+		t.Errorf("ForEach visited %d connections after stopping, want 2", visited)
+	}
+
+	isTenant := func(tenant string) func(*WSConn) bool {
+		return func(conn *WSConn) bool {
+			v, _ := conn.Get("tenant")
+			return v == tenant
+		}
+	}
+	hub.BroadcastWhere(isTenant("globex"), []byte("globex only"))
+	hub.BroadcastWhere(isTenant("acme"), []byte("acme only"))
+	want := []string{"acme only", "globex only", "acme only"}
+	for i, conn := range conns {
+		if _, msg, err := conn.ReadMessage(); err != nil || string(msg) != want[i] {
+			t.Errorf("conn %d got %q, %v; want %q", i, msg, err, want[i])
+		}
+	}
+}
@@ -0,0 +1,170 @@
+package poltergeist
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"strings"
+	"sync"
+)
+
+// =============================================================================
+// WSHUB BACKEND - Broadcasts across the instances of an app
+// =============================================================================
+
+// ErrWSBackendInUse is returned by WSHub.UseBackend for a hub that already
+// has a backend
+var ErrWSBackendInUse = errors.New("websocket: hub already has a backend")
+
+// Backend channels: HubChannelAll carries hub-wide broadcasts and
+// HubChannelRoomPrefix+room those of a room, so no room name, "*" included,
+// can pass for the hub-wide channel
+const (
+	HubChannelAll        = "*"
+	HubChannelRoomPrefix = "room:"
+)
+
+// HubBackend relays WSHub broadcasts between the instances of an app, so a
+// message broadcast on one reaches the connections of all. A backend maps
+// channels (HubChannelAll or a room channel) to its own topics; the hub
+// subscribes to a room only while one of its connections is in it.
+//
+// The pubsub package provides a NATS implementation (pubsub.NewNATS).
+type HubBackend interface {
+	// Publish sends payload to every instance subscribed to channel
+	Publish(ctx context.Context, channel string, payload []byte) error
+	// Subscribe calls handler with every payload published to channel,
+	// including the instance's own, until Unsubscribe
+	Subscribe(channel string, handler func(payload []byte)) error
+	// Unsubscribe stops the deliveries of channel
+	Unsubscribe(channel string) error
+	// Close releases the backend
+	Close() error
+}
+
+// wsHubRelay connects a hub to its backend
+type wsHubRelay struct {
+	backend HubBackend
+	origin  string // tells this instance's messages apart from others'
+	mu      sync.Mutex
+	rooms   map[string]bool // rooms subscribed on the backend
+}
+
+// wsRelayMessage is a broadcast as published on the backend
+type wsRelayMessage struct {
+	Origin string `json:"origin"`
+	Data   []byte `json:"data"`
+}
+
+// UseBackend relays the hub's broadcasts through backend, for apps running
+// several instances behind a load balancer. Broadcast, BroadcastToRoom and
+// their Except and JSON variants reach every instance; SendTo,
+// BroadcastWhere and ForEach stay local. Delivery is as reliable as the
+// backend: messages published while an instance is disconnected from it
+// are not replayed.
+//
+//	nc, _ := nats.Connect(nats.DefaultURL)
+//	hub := poltergeist.NewWSHub()
+//	if err := hub.UseBackend(pubsub.NewNATS(nc, "chat")); err != nil {
+//	    log.Fatal(err)
+//	}
+func (h *WSHub) UseBackend(backend HubBackend) error {
+	origin := make([]byte, 8)
+	if _, err := rand.Read(origin); err != nil {
+		return err
+	}
+	relay := &wsHubRelay{backend: backend, origin: hex.EncodeToString(origin), rooms: make(map[string]bool)}
+	if err := backend.Subscribe(HubChannelAll, func(payload []byte) { h.receive(relay, HubChannelAll, payload) }); err != nil {
+		return err
+	}
+	if !h.relay.CompareAndSwap(nil, relay) {
+		backend.Unsubscribe(HubChannelAll)
+		return ErrWSBackendInUse
+	}
+	for room := range h.roomSizes() {
+		h.syncRoomSubscription(room)
+	}
+	return nil
+}
+
+// publishToRoom relays a room's message to the other instances
+func (h *WSHub) publishToRoom(room string, message []byte) {
+	h.publish(HubChannelRoomPrefix+room, message)
+}
+
+// publish relays message to the other instances; a no-op without backend
+func (h *WSHub) publish(channel string, message []byte) {
+	relay := h.relay.Load()
+	if relay == nil {
+		return
+	}
+	payload, err := json.Marshal(wsRelayMessage{Origin: relay.origin, Data: message})
+	if err == nil {
+		err = relay.backend.Publish(context.Background(), channel, payload)
+	}
+	if err != nil {
+		captureHubFailure(h.pipeline(), "websocket", "backend:"+channel, err)
+	}
+}
+
+// receive delivers a message published by another instance to the local
+// connections of channel
+func (h *WSHub) receive(relay *wsHubRelay, channel string, payload []byte) {
+	var msg wsRelayMessage
+	if err := json.Unmarshal(payload, &msg); err != nil {
+		captureHubFailure(h.pipeline(), "websocket", "backend:"+channel, err)
+		return
+	}
+	if msg.Origin == relay.origin {
+		return // already delivered locally
+	}
+	if room, ok := strings.CutPrefix(channel, HubChannelRoomPrefix); ok {
+		h.broadcastToRoomLocal(room, nil, msg.Data)
+		return
+	}
+	h.broadcastExcept(nil, msg.Data)
+}
+
+// syncRoomSubscription subscribes to room on the backend while the hub has
+// connections, or suspended sessions, in it and unsubscribes once it has
+// none
+func (h *WSHub) syncRoomSubscription(room string) {
+	relay := h.relay.Load()
+	if relay == nil {
+		return
+	}
+	relay.mu.Lock()
+	defer relay.mu.Unlock()
+
+	want := h.roomCount(room) > 0 || h.suspendedIn(room)
+	if want == relay.rooms[room] {
+		return
+	}
+	channel := HubChannelRoomPrefix + room
+	var err error
+	if want {
+		err = relay.backend.Subscribe(channel, func(payload []byte) { h.receive(relay, channel, payload) })
+	} else {
+		err = relay.backend.Unsubscribe(channel)
+	}
+	if err != nil {
+		captureHubFailure(h.pipeline(), "websocket", "backend:"+channel, err)
+		return
+	}
+	if want {
+		relay.rooms[room] = true
+	} else {
+		delete(relay.rooms, room)
+	}
+}
+
+// closeBackend releases the backend on shutdown
+func (h *WSHub) closeBackend() {
+	if relay := h.relay.Load(); relay != nil {
+		if err := relay.backend.Close(); err != nil {
+			captureHubFailure(h.pipeline(), "websocket", "backend", err)
+		}
+	}
+}
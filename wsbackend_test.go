@@ -0,0 +1,158 @@
+package poltergeist
+
+import (
+	"context"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// =============================================================================
+// WSHUB BACKEND TESTS
+// =============================================================================
+
+// memoryBus connects memoryBackends like a pub/sub server
+type memoryBus struct {
+	mu   sync.Mutex
+	subs map[*memoryBackend]map[string]func([]byte)
+}
+
+type memoryBackend struct{ bus *memoryBus }
+
+func (b *memoryBus) backend() *memoryBackend {
+	backend := &memoryBackend{b}
+	b.mu.Lock()
+	b.subs[backend] = make(map[string]func([]byte))
+	b.mu.Unlock()
+	return backend
+}
+
+func (b *memoryBus) subscribed(backend *memoryBackend, channel string) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	_, ok := b.subs[backend][channel]
+	return ok
+}
+
+func (m *memoryBackend) Publish(_ context.Context, channel string, payload []byte) error {
+	m.bus.mu.Lock()
+	var handlers []func([]byte)
+	for _, channels := range m.bus.subs {
+		if handler, ok := channels[channel]; ok {
+			handlers = append(handlers, handler)
+		}
+	}
+	m.bus.mu.Unlock()
+	for _, handler := range handlers {
+		handler(payload)
+	}
+	return nil
+}
+
+func (m *memoryBackend) Subscribe(channel string, handler func([]byte)) error {
+	m.bus.mu.Lock()
+	m.bus.subs[m][channel] = handler
+	m.bus.mu.Unlock()
+	return nil
+}
+
+func (m *memoryBackend) Unsubscribe(channel string) error {
+	m.bus.mu.Lock()
+	delete(m.bus.subs[m], channel)
+	m.bus.mu.Unlock()
+	return nil
+}
+
+func (m *memoryBackend) Close() error {
+	m.bus.mu.Lock()
+	delete(m.bus.subs, m)
+	m.bus.mu.Unlock()
+	return nil
+}
+
+func TestWSHub_UseBackend(t *testing.T) {
+	bus := &memoryBus{subs: make(map[*memoryBackend]map[string]func([]byte))}
+	type instance struct {
+		hub     *WSHub
+		backend *memoryBackend
+		url     string
+	}
+	instances := make([]instance, 2)
+	for i := range instances {
+		hub := NewWSHub()
+		go hub.Run()
+		defer hub.ShutdownWithTimeout(time.Second)
+		backend := bus.backend()
+		if err := hub.UseBackend(backend); err != nil {
+			t.Fatal(err)
+		}
+
+		app := New()
+		cfg := DefaultWSConfig()
+		cfg.OnConnect = func(conn *WSConn) {
+			if room := conn.ctx.Query("room"); room != "" {
+				hub.JoinRoom(conn, room)
+			}
+		}
+		app.WebSocketWithHub("/ws", hub, nil, cfg)
+		server := httptest.NewServer(app.Router())
+		defer server.Close()
+		instances[i] = instance{hub, backend, "ws" + strings.TrimPrefix(server.URL, "http") + "/ws"}
+	}
+	if err := instances[0].hub.UseBackend(bus.backend()); err != ErrWSBackendInUse {
+		t.Errorf("second UseBackend = %v, want ErrWSBackendInUse", err)
+	}
+
+	dial := func(url string) *websocket.Conn {
+		conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+		return conn
+	}
+	read := func(conn *websocket.Conn) string {
+		_, msg, err := conn.ReadMessage()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(msg)
+	}
+
+	local := dial(instances[0].url + "?room=lobby")
+	defer local.Close()
+	remote := dial(instances[1].url + "?room=lobby")
+	waitFor(t, func() bool { return bus.subscribed(instances[1].backend, HubChannelRoomPrefix+"lobby") })
+	if bus.subscribed(instances[1].backend, HubChannelRoomPrefix+"kitchen") {
+		t.Error("subscribed to a room without connections")
+	}
+
+	// Each connection gets a room broadcast exactly once, wherever it is
+	instances[0].hub.BroadcastToRoom("lobby", []byte("room"))
+	instances[1].hub.Broadcast([]byte("all"))
+	for _, conn := range []*websocket.Conn{local, remote} {
+		if got := read(conn) + "," + read(conn); got != "room,all" {
+			t.Errorf("received %s, want room,all", got)
+		}
+	}
+
+	// A room named like the hub-wide channel stays a room
+	star := dial(instances[1].url + "?room=*")
+	defer star.Close()
+	waitFor(t, func() bool { return bus.subscribed(instances[1].backend, HubChannelRoomPrefix+"*") })
+	instances[0].hub.BroadcastToRoom("*", []byte("star"))
+	instances[0].hub.BroadcastToRoom("lobby", []byte("after"))
+	if got := read(star); got != "star" {
+		t.Errorf("room * received %s, want star", got)
+	}
+	if got := read(remote); got != "after" {
+		t.Errorf("lobby received %s, want only its own broadcast", got)
+	}
+
+	remote.Close()
+	waitFor(t, func() bool { return !bus.subscribed(instances[1].backend, HubChannelRoomPrefix+"lobby") })
+}
@@ -0,0 +1,246 @@
+package poltergeist
+
+import (
+	"encoding/json"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// =============================================================================
+// WEBSOCKET EVENTS - Named events on top of raw frames
+// =============================================================================
+
+// WSEventAck is the event of acknowledgment envelopes: {"event": "ack",
+// "id": <id of the acknowledged message>, "data": <reply>}
+const WSEventAck = "ack"
+
+// WebSocket acknowledgment errors
+var (
+	ErrWSAckTimeout = errors.New("websocket: no acknowledgment within timeout")
+	ErrWSClosed     = errors.New("websocket: connection closed")
+)
+
+// WSEvent is an inbound {"event", "data", "id"} envelope
+type WSEvent struct {
+	Event string          `json:"event"`
+	Data  json.RawMessage `json:"data,omitempty"`
+	ID    string          `json:"id,omitempty"` // set by clients that expect a reply
+
+	conn *WSConn
+}
+
+// Bind decodes the event's data into v
+func (e *WSEvent) Bind(v any) error {
+	if len(e.Data) == 0 {
+		return nil
+	}
+	return json.Unmarshal(e.Data, v)
+}
+
+// WSEventHandler handles one named event
+type WSEventHandler func(conn *WSConn, event *WSEvent)
+
+// On registers handler for the named event, replacing any previous one.
+// Envelopes without a registered handler, and non-envelope frames, still go
+// to the route's WSMessageHandler. Register handlers in WSConfig.OnConnect
+// so none of the client's first messages are missed:
+//
+//	cfg := poltergeist.DefaultWSConfig()
+//	cfg.OnConnect = func(conn *poltergeist.WSConn) {
+//	    conn.On("chat:message", func(conn *poltergeist.WSConn, e *poltergeist.WSEvent) {
+//	        var msg ChatMessage
+//	        if err := e.Bind(&msg); err != nil {
+//	            conn.Emit("error", poltergeist.H{"message": "invalid message"})
+//	            return
+//	        }
+//	        hub.BroadcastJSONToRoom(msg.Room, msg)
+//	    })
+//	}
+//	app.WebSocketWithHub("/ws", hub, nil, cfg)
+func (c *WSConn) On(event string, handler WSEventHandler) {
+	c.eventsMu.Lock()
+	defer c.eventsMu.Unlock()
+	if c.events == nil {
+		c.events = make(map[string]WSEventHandler)
+	}
+	c.events[event] = handler
+}
+
+// Off removes the named event's handler
+func (c *WSConn) Off(event string) {
+	c.eventsMu.Lock()
+	defer c.eventsMu.Unlock()
+	delete(c.events, event)
+}
+
+// Emit sends an {"event", "data"} envelope, carrying the trace context of
+// the message being handled when tracing is enabled
+func (c *WSConn) Emit(event string, data any) error {
+	return c.SendEvent(c.Context(), event, data)
+}
+
+// eventHandler returns the handler registered for event, or nil
+func (c *WSConn) eventHandler(event string) WSEventHandler {
+	c.eventsMu.RLock()
+	defer c.eventsMu.RUnlock()
+	return c.events[event]
+}
+
+// hasEventHandlers reports whether any event handler is registered
+func (c *WSConn) hasEventHandlers() bool {
+	c.eventsMu.RLock()
+	defer c.eventsMu.RUnlock()
+	return len(c.events) > 0
+}
+
+// wantsEvents reports whether inbound frames must be parsed as envelopes
+func (c *WSConn) wantsEvents() bool {
+	return c.hasEventHandlers() || c.acksPending.Load() > 0
+}
+
+// dispatch routes acknowledgments to their waiters, event envelopes to their
+// On handler and anything else to the route's message handler
+func (c *WSConn) dispatch(handler WSMessageHandler, messageType int, message []byte) {
+	if len(message) > 0 && message[0] == '{' && c.wantsEvents() {
+		event := WSEvent{conn: c}
+		if json.Unmarshal(message, &event) == nil && event.Event != "" {
+			if event.Event == WSEventAck && event.ID != "" && c.resolveAck(event.ID, event.Data, nil) {
+				return
+			}
+			if h := c.eventHandler(event.Event); h != nil {
+				h(c, &event)
+				return
+			}
+		}
+	}
+	if handler != nil {
+		handler(c, messageType, message)
+	}
+}
+
+// =============================================================================
+// ACKNOWLEDGMENTS - Request/reply over events
+// =============================================================================
+
+// wsAck is a pending acknowledgment
+type wsAck struct {
+	callback func(reply json.RawMessage, err error)
+	timer    *time.Timer
+}
+
+// Ack replies to an event the client sent with an id, as {"event": "ack",
+// "id": e.ID, "data": data}; a no-op for events without one
+//
+//	conn.On("orders:get", func(conn *poltergeist.WSConn, e *poltergeist.WSEvent) {
+//	    order, err := repo.Find(id)
+//	    e.Ack(poltergeist.H{"order": order, "error": errString(err)})
+//	})
+func (e *WSEvent) Ack(data any) error {
+	if e.ID == "" || e.conn == nil {
+		return nil
+	}
+	return e.conn.SendJSON(wsEnvelope{Event: WSEventAck, ID: e.ID, Data: data})
+}
+
+// EmitWithAck sends an event with a fresh id and waits up to timeout for the
+// client's acknowledgment, returning its data. Replies are read by the
+// connection's read loop, so call it from another goroutine than the
+// message handlers (or use EmitWithAckFunc there):
+//
+//	go func() {
+//	    reply, err := conn.EmitWithAck("confirm", poltergeist.H{"amount": 10}, 5*time.Second)
+//	    ...
+//	}()
+func (c *WSConn) EmitWithAck(event string, data any, timeout time.Duration) (json.RawMessage, error) {
+	type result struct {
+		reply json.RawMessage
+		err   error
+	}
+	done := make(chan result, 1)
+	if err := c.EmitWithAckFunc(event, data, timeout, func(reply json.RawMessage, err error) {
+		done <- result{reply, err}
+	}); err != nil {
+		return nil, err
+	}
+	r := <-done
+	return r.reply, r.err
+}
+
+// EmitWithAckFunc sends an event with a fresh id and calls callback once
+// with the client's acknowledgment, or with ErrWSAckTimeout or ErrWSClosed.
+// The callback runs on the read loop (replies) or a timer goroutine
+// (failures) and must not block.
+func (c *WSConn) EmitWithAckFunc(event string, data any, timeout time.Duration, callback func(reply json.RawMessage, err error)) error {
+	id := strconv.FormatUint(c.ackSeq.Add(1), 10)
+	envelope := wsEnvelope{Event: event, Data: data, ID: id}
+	if tracer := c.pipeline.tracer(); tracer != nil {
+		carrier := MapCarrier{}
+		tracer.Inject(c.Context(), carrier)
+		if len(carrier) > 0 {
+			envelope.Trace = carrier
+		}
+	}
+
+	c.acksMu.Lock()
+	if c.acks == nil {
+		c.acks = make(map[string]*wsAck)
+	}
+	c.acks[id] = &wsAck{
+		callback: callback,
+		timer:    time.AfterFunc(timeout, func() { c.resolveAck(id, nil, ErrWSAckTimeout) }),
+	}
+	c.acksPending.Add(1)
+	c.acksMu.Unlock()
+
+	if err := c.SendJSON(envelope); err != nil {
+		if ack := c.takeAck(id); ack != nil {
+			ack.timer.Stop()
+		}
+		if errors.Is(err, websocket.ErrCloseSent) {
+			return ErrWSClosed
+		}
+		return err
+	}
+	return nil
+}
+
+// takeAck removes and returns the pending acknowledgment id, or nil
+func (c *WSConn) takeAck(id string) *wsAck {
+	c.acksMu.Lock()
+	defer c.acksMu.Unlock()
+	ack, ok := c.acks[id]
+	if !ok {
+		return nil
+	}
+	delete(c.acks, id)
+	c.acksPending.Add(-1)
+	return ack
+}
+
+// resolveAck completes the pending acknowledgment id, reporting whether
+// there was one
+func (c *WSConn) resolveAck(id string, reply json.RawMessage, err error) bool {
+	ack := c.takeAck(id)
+	if ack == nil {
+		return false
+	}
+	ack.timer.Stop()
+	ack.callback(reply, err)
+	return true
+}
+
+// failAcks completes every pending acknowledgment with ErrWSClosed
+func (c *WSConn) failAcks() {
+	c.acksMu.Lock()
+	ids := make([]string, 0, len(c.acks))
+	for id := range c.acks {
+		ids = append(ids, id)
+	}
+	c.acksMu.Unlock()
+	for _, id := range ids {
+		c.resolveAck(id, nil, ErrWSClosed)
+	}
+}
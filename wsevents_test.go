@@ -0,0 +1,126 @@
+package poltergeist
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// =============================================================================
+// WEBSOCKET EVENT TESTS
+// =============================================================================
+
+func TestWSConn_OnEmit(t *testing.T) {
+	app := New()
+	cfg := DefaultWSConfig()
+	cfg.OnConnect = func(conn *WSConn) {
+		conn.On("chat:message", func(conn *WSConn, e *WSEvent) {
+			var msg struct {
+				Text string `json:"text"`
+			}
+			if err := e.Bind(&msg); err != nil {
+				conn.Emit("error", H{"message": err.Error()})
+				return
+			}
+			conn.Emit("chat:echo", H{"text": strings.ToUpper(msg.Text)})
+		})
+	}
+	app.WebSocket("/ws", func(conn *WSConn, _ int, msg []byte) {
+		conn.SendText("raw:" + string(msg))
+	}, cfg)
+	server := httptest.NewServer(app.Router())
+	defer server.Close()
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"/ws", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+
+	conn.WriteJSON(H{"event": "chat:message", "data": H{"text": "hi"}})
+	var reply struct {
+		Event string `json:"event"`
+		Data  struct {
+			Text string `json:"text"`
+		} `json:"data"`
+	}
+	if err := conn.ReadJSON(&reply); err != nil {
+		t.Fatal(err)
+	}
+	if reply.Event != "chat:echo" || reply.Data.Text != "HI" {
+		t.Errorf("reply = %+v, want chat:echo HI", reply)
+	}
+
+	// Unregistered events fall through to the message handler
+	conn.WriteMessage(websocket.TextMessage, []byte(`{"event":"other"}`))
+	_, msg, err := conn.ReadMessage()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(msg) != `raw:{"event":"other"}` {
+		t.Errorf("fallthrough = %q", msg)
+	}
+}
+
+func TestWSConn_Acks(t *testing.T) {
+	app := New()
+	cfg := DefaultWSConfig()
+	cfg.OnConnect = func(conn *WSConn) {
+		conn.On("sum", func(conn *WSConn, e *WSEvent) {
+			var nums []int
+			e.Bind(&nums)
+			total := 0
+			for _, n := range nums {
+				total += n
+			}
+			e.Ack(total)
+		})
+		go func() {
+			reply, err := conn.EmitWithAck("confirm", H{"amount": 10}, 2*time.Second)
+			conn.Emit("confirmed", H{"reply": string(reply), "err": err != nil})
+			_, err = conn.EmitWithAck("ignored", nil, 50*time.Millisecond)
+			conn.Emit("timeout", H{"timeout": err == ErrWSAckTimeout})
+		}()
+	}
+	app.WebSocket("/ws", nil, cfg)
+	server := httptest.NewServer(app.Router())
+	defer server.Close()
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"/ws", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+
+	type envelope struct {
+		Event string          `json:"event"`
+		ID    string          `json:"id"`
+		Data  json.RawMessage `json:"data"`
+	}
+	var msg envelope
+	if err := conn.ReadJSON(&msg); err != nil || msg.Event != "confirm" || msg.ID == "" {
+		t.Fatalf("confirm = %+v, %v", msg, err)
+	}
+	conn.WriteJSON(H{"event": WSEventAck, "id": msg.ID, "data": "yes"})
+	if err := conn.ReadJSON(&msg); err != nil || msg.Event != "confirmed" || string(msg.Data) != `{"err":false,"reply":"\"yes\""}` {
+		t.Fatalf("confirmed = %+v (%s), %v", msg, msg.Data, err)
+	}
+
+	if err := conn.ReadJSON(&msg); err != nil || msg.Event != "ignored" {
+		t.Fatalf("ignored = %+v, %v", msg, err)
+	}
+	if err := conn.ReadJSON(&msg); err != nil || msg.Event != "timeout" || string(msg.Data) != `{"timeout":true}` {
+		t.Fatalf("timeout = %+v (%s), %v", msg, msg.Data, err)
+	}
+
+	conn.WriteJSON(H{"event": "sum", "id": "c1", "data": []int{1, 2, 3}})
+	if err := conn.ReadJSON(&msg); err != nil || msg.Event != WSEventAck || msg.ID != "c1" || string(msg.Data) != "6" {
+		t.Fatalf("ack = %+v (%s), %v", msg, msg.Data, err)
+	}
+}
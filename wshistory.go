@@ -0,0 +1,236 @@
+package poltergeist
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// =============================================================================
+// WEBSOCKET HISTORY - Replaying room events to reconnecting clients
+// =============================================================================
+
+// WSEventHistoryGap is sent, as {"event": "history:gap", "data": {"room":
+// ...}}, after replaying a room whose history no longer reaches back to the
+// client's last event; the client should reload that room's state
+const WSEventHistoryGap = "history:gap"
+
+// WSLastEventIDParam is the query parameter reconnecting clients pass the
+// event_id of the last room event they received in, like SSE's
+// Last-Event-ID header
+const WSLastEventIDParam = "last_event_id"
+
+// ErrWSHistoryGap is returned by WSHub.Replay when events since the given
+// ID are missing from the history (evicted, or the ID is unknown)
+var ErrWSHistoryGap = errors.New("websocket: history incomplete since last event ID")
+
+// WSHistoryEntry is a room event recorded by EmitToRoom
+type WSHistoryEntry struct {
+	ID    string          `json:"id"`
+	Event string          `json:"event"`
+	Data  json.RawMessage `json:"data,omitempty"`
+	At    time.Time       `json:"at"`
+}
+
+// WSHistoryStore keeps the recent events of each room. IDs must increase
+// across every room of a store, so one last event ID serves all the rooms a
+// client is in. NewWSMemoryHistory is the in-memory implementation; shared
+// stores let reconnecting clients land on any instance.
+type WSHistoryStore interface {
+	// Append records entry in room's history and sets its ID
+	Append(ctx context.Context, room string, entry *WSHistoryEntry) error
+	// Since returns room's entries recorded after lastEventID, oldest
+	// first, or all it holds for "". complete is false when some of those
+	// entries are no longer held or lastEventID is unknown.
+	Since(ctx context.Context, room, lastEventID string) (entries []WSHistoryEntry, complete bool, err error)
+}
+
+// wsHistory holds a hub's store, so it can be swapped atomically
+type wsHistory struct {
+	store WSHistoryStore
+}
+
+// UseHistory records the events sent with EmitToRoom in store, so clients
+// reconnecting with ?last_event_id=<id> get the events they missed in the
+// rooms they are in once connected (joined in OnConnect); nil stops
+// recording. Replayed events may repeat ones the client receives live
+// meanwhile, so clients should skip event IDs they have seen.
+//
+//	hub.UseHistory(poltergeist.NewWSMemoryHistory(100))
+//	hub.EmitToRoom("lobby", "chat:message", msg)
+//	// client: {"event": "chat:message", "data": {...}, "event_id": "42"}
+//	// reconnect: new WebSocket(`${url}?last_event_id=42`)
+func (h *WSHub) UseHistory(store WSHistoryStore) {
+	if store == nil {
+		h.history.Store(nil)
+		return
+	}
+	h.history.Store(&wsHistory{store})
+}
+
+// historyStore returns the hub's history store, or nil
+func (h *WSHub) historyStore() WSHistoryStore {
+	if history := h.history.Load(); history != nil {
+		return history.store
+	}
+	return nil
+}
+
+// EmitToRoom sends {"event", "data", "event_id"} to all connections in a
+// room, recording it in the history first when the hub has one
+func (h *WSHub) EmitToRoom(room, event string, data any) error {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	envelope := wsEnvelope{Event: event, Data: json.RawMessage(raw)}
+	if store := h.historyStore(); store != nil {
+		entry := &WSHistoryEntry{Event: event, Data: raw, At: time.Now()}
+		if err := store.Append(context.Background(), room, entry); err != nil {
+			return err
+		}
+		envelope.EventID = entry.ID
+	}
+	return h.BroadcastJSONToRoom(room, envelope)
+}
+
+// History returns room's events since lastEventID ("" for all), e.g. to
+// serve a room's backlog over HTTP; see WSHistoryStore.Since
+func (h *WSHub) History(ctx context.Context, room, lastEventID string) ([]WSHistoryEntry, bool, error) {
+	store := h.historyStore()
+	if store == nil {
+		return nil, false, nil
+	}
+	return store.Since(ctx, room, lastEventID)
+}
+
+// Replay sends conn room's events since lastEventID, for clients joining
+// rooms after connecting. It returns ErrWSHistoryGap, after sending what
+// the history holds, when events are missing.
+//
+//	conn.On("room:join", func(conn *poltergeist.WSConn, e *poltergeist.WSEvent) {
+//	    var req struct{ Room, LastEventID string }
+//	    e.Bind(&req)
+//	    hub.JoinRoom(conn, req.Room)
+//	    if err := hub.Replay(conn, req.Room, req.LastEventID); err != nil {
+//	        conn.Emit("room:reload", poltergeist.H{"room": req.Room})
+//	    }
+//	})
+func (h *WSHub) Replay(conn *WSConn, room, lastEventID string) error {
+	entries, complete, err := h.History(conn.Context(), room, lastEventID)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if err := conn.SendJSON(wsEnvelope{Event: entry.Event, Data: entry.Data, EventID: entry.ID}); err != nil {
+			return err
+		}
+	}
+	if !complete {
+		return ErrWSHistoryGap
+	}
+	return nil
+}
+
+// replayOnConnect replays the rooms conn is in since the last event ID it
+// connected with, if any
+func (h *WSHub) replayOnConnect(conn *WSConn) {
+	lastEventID := conn.ctx.Query(WSLastEventIDParam)
+	if lastEventID == "" || h.historyStore() == nil {
+		return
+	}
+	for _, room := range h.clientRooms(conn.id) {
+		err := h.Replay(conn, room, lastEventID)
+		if errors.Is(err, ErrWSHistoryGap) {
+			err = conn.Emit(WSEventHistoryGap, H{"room": room})
+		}
+		if err != nil {
+			captureHubFailure(h.pipeline(), "websocket", conn.id, err)
+			return
+		}
+	}
+}
+
+// =============================================================================
+// MEMORY HISTORY - Ring buffer per room
+// =============================================================================
+
+// WSMemoryHistory keeps the last events of each room in memory, for single
+// instances. IDs are sequence numbers, so histories don't survive restarts:
+// clients reconnecting with an earlier ID get a gap.
+type WSMemoryHistory struct {
+	size  int
+	mu    sync.Mutex
+	seq   atomic.Uint64
+	rooms map[string]*historyRing
+}
+
+// historyRing is one room's last events
+type historyRing struct {
+	entries []WSHistoryEntry
+	next    int    // index the next entry goes to once full
+	evicted uint64 // ID of the last entry overwritten
+}
+
+// NewWSMemoryHistory creates an in-memory history keeping size events per
+// room (default 100). A ring stays allocated for every room that had events.
+func NewWSMemoryHistory(size int) *WSMemoryHistory {
+	if size <= 0 {
+		size = 100
+	}
+	return &WSMemoryHistory{size: size, rooms: make(map[string]*historyRing)}
+}
+
+// Append records entry in room's ring, evicting its oldest event when full
+func (m *WSMemoryHistory) Append(_ context.Context, room string, entry *WSHistoryEntry) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	id := m.seq.Add(1)
+	entry.ID = strconv.FormatUint(id, 10)
+	ring := m.rooms[room]
+	if ring == nil {
+		ring = &historyRing{entries: make([]WSHistoryEntry, 0, m.size)}
+		m.rooms[room] = ring
+	}
+	if len(ring.entries) < m.size {
+		ring.entries = append(ring.entries, *entry)
+		return nil
+	}
+	ring.evicted, _ = strconv.ParseUint(ring.entries[ring.next].ID, 10, 64)
+	ring.entries[ring.next] = *entry
+	ring.next = (ring.next + 1) % m.size
+	return nil
+}
+
+// Since returns room's entries after lastEventID
+func (m *WSMemoryHistory) Since(_ context.Context, room, lastEventID string) ([]WSHistoryEntry, bool, error) {
+	var after uint64
+	known := true
+	if lastEventID != "" {
+		var err error
+		after, err = strconv.ParseUint(lastEventID, 10, 64)
+		known = err == nil && after <= m.seq.Load()
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	ring := m.rooms[room]
+	if ring == nil {
+		return nil, known, nil
+	}
+	var entries []WSHistoryEntry
+	for i := range ring.entries {
+		entry := ring.entries[(ring.next+i)%len(ring.entries)]
+		if id, _ := strconv.ParseUint(entry.ID, 10, 64); id > after {
+			entries = append(entries, entry)
+		}
+	}
+	return entries, known && after >= ring.evicted, nil
+}
+
+var _ WSHistoryStore = (*WSMemoryHistory)(nil)
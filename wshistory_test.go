@@ -0,0 +1,117 @@
+package poltergeist
+
+import (
+	"context"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// =============================================================================
+// WEBSOCKET HISTORY TESTS
+// =============================================================================
+
+func TestWSMemoryHistory(t *testing.T) {
+	ctx := context.Background()
+	history := NewWSMemoryHistory(2)
+	for _, room := range []string{"a", "b", "a", "a"} {
+		history.Append(ctx, room, &WSHistoryEntry{Event: "e"})
+	}
+
+	ids := func(entries []WSHistoryEntry) string {
+		var s []string
+		for _, e := range entries {
+			s = append(s, e.ID)
+		}
+		return strings.Join(s, ",")
+	}
+	tests := []struct {
+		room, since string
+		want        string
+		complete    bool
+	}{
+		{"a", "", "3,4", false}, // 1 was evicted
+		{"a", "1", "3,4", true},
+		{"a", "3", "4", true},
+		{"a", "0", "3,4", false},
+		{"a", "9", "", false}, // unknown ID
+		{"b", "", "2", true},
+		{"c", "4", "", true},
+	}
+	for _, tt := range tests {
+		entries, complete, err := history.Since(ctx, tt.room, tt.since)
+		if err != nil || ids(entries) != tt.want || complete != tt.complete {
+			t.Errorf("Since(%q, %q) = %s, %v, %v; want %s, %v", tt.room, tt.since, ids(entries), complete, err, tt.want, tt.complete)
+		}
+	}
+}
+
+func TestWSHub_HistoryReplay(t *testing.T) {
+	hub := NewWSHub()
+	go hub.Run()
+	defer hub.ShutdownWithTimeout(time.Second)
+	hub.UseHistory(NewWSMemoryHistory(2))
+
+	app := New()
+	cfg := DefaultWSConfig()
+	cfg.OnConnect = func(conn *WSConn) { hub.JoinRoom(conn, "lobby") }
+	app.WebSocketWithHub("/ws", hub, nil, cfg)
+	server := httptest.NewServer(app.Router())
+	defer server.Close()
+	url := "ws" + strings.TrimPrefix(server.URL, "http") + "/ws"
+
+	type event struct {
+		Event   string `json:"event"`
+		Data    H      `json:"data"`
+		EventID string `json:"event_id"`
+	}
+	dial := func(query string) *websocket.Conn {
+		conn, _, err := websocket.DefaultDialer.Dial(url+query, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+		return conn
+	}
+	read := func(conn *websocket.Conn) event {
+		var e event
+		if err := conn.ReadJSON(&e); err != nil {
+			t.Fatal(err)
+		}
+		return e
+	}
+
+	live := dial("")
+	defer live.Close()
+	waitFor(t, func() bool { return hub.ConnectionCount() == 1 })
+	for i := 1; i <= 3; i++ {
+		if err := hub.EmitToRoom("lobby", "chat", H{"n": i}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, want := range []string{"1", "2", "3"} {
+		if e := read(live); e.Event != "chat" || e.EventID != want {
+			t.Errorf("live event = %+v, want event_id %s", e, want)
+		}
+	}
+
+	// Everything since 1 is still held
+	caughtUp := dial("?" + WSLastEventIDParam + "=1")
+	defer caughtUp.Close()
+	for _, want := range []string{"2", "3"} {
+		if e := read(caughtUp); e.EventID != want {
+			t.Errorf("replayed %+v, want event_id %s", e, want)
+		}
+	}
+
+	// Event 1 was evicted: replay what is left, then report the gap
+	behind := dial("?" + WSLastEventIDParam + "=0")
+	defer behind.Close()
+	got := []string{read(behind).EventID, read(behind).EventID}
+	if e := read(behind); e.Event != WSEventHistoryGap || e.Data["room"] != "lobby" {
+		t.Errorf("after %v got %+v, want a history gap for lobby", got, e)
+	}
+}
@@ -0,0 +1,92 @@
+package poltergeist
+
+import (
+	"math"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"golang.org/x/time/rate"
+)
+
+// =============================================================================
+// WEBSOCKET RATE LIMITS - Inbound limits per connection
+// =============================================================================
+
+// WSLimitAction is what happens to a message over a connection's limits
+type WSLimitAction string
+
+// WebSocket limit actions
+const (
+	WSLimitDrop  WSLimitAction = "drop"  // discard the message
+	WSLimitWarn  WSLimitAction = "warn"  // discard it and send a rate_limited event
+	WSLimitClose WSLimitAction = "close" // close the connection with 1008 (policy violation)
+)
+
+// WSEventRateLimited is sent, as {"event": "rate_limited", "data":
+// {"limit": "messages" or "bytes"}}, when WSLimitWarn discards a message;
+// once until a message is accepted again
+const WSEventRateLimited = "rate_limited"
+
+// wsLimiter enforces WSConfig's inbound limits on one connection
+type wsLimiter struct {
+	messages *rate.Limiter
+	bytes    *rate.Limiter
+	action   WSLimitAction
+	warned   bool // a warning was sent since the last accepted message
+}
+
+// newWSLimiter returns the limiter for cfg's limits, or nil without any
+func newWSLimiter(cfg *WSConfig) *wsLimiter {
+	if cfg.MaxMessagesPerSecond <= 0 && cfg.MaxBytesPerSecond <= 0 {
+		return nil
+	}
+	l := &wsLimiter{action: cfg.LimitAction}
+	if l.action == "" {
+		l.action = WSLimitDrop
+	}
+	if cfg.MaxMessagesPerSecond > 0 {
+		l.messages = rate.NewLimiter(rate.Limit(cfg.MaxMessagesPerSecond), int(math.Ceil(cfg.MaxMessagesPerSecond)))
+	}
+	if cfg.MaxBytesPerSecond > 0 {
+		l.bytes = rate.NewLimiter(rate.Limit(cfg.MaxBytesPerSecond), cfg.MaxBytesPerSecond)
+	}
+	return l
+}
+
+// exceeded returns the limit a message of size bytes is over, or ""
+func (l *wsLimiter) exceeded(size int) string {
+	if l.messages != nil && !l.messages.Allow() {
+		return "messages"
+	}
+	if l.bytes != nil && !l.bytes.AllowN(time.Now(), size) {
+		return "bytes"
+	}
+	return ""
+}
+
+// admit applies the connection's limits to a received message, reporting
+// whether to handle it and whether the connection was closed
+func (c *WSConn) admit(message []byte) (handle, closed bool) {
+	l := c.limiter
+	if l == nil {
+		return true, false
+	}
+	limit := l.exceeded(len(message))
+	if limit == "" {
+		l.warned = false
+		return true, false
+	}
+
+	switch l.action {
+	case WSLimitClose:
+		c.pipeline.logger().Warn("websocket rate limit exceeded", "client_id", c.id, "limit", limit)
+		c.CloseWithReason(websocket.ClosePolicyViolation, "rate limit exceeded")
+		return false, true
+	case WSLimitWarn:
+		if !l.warned {
+			l.warned = true
+			c.Emit(WSEventRateLimited, H{"limit": limit})
+		}
+	}
+	return false, false
+}
@@ -0,0 +1,71 @@
+package poltergeist
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// =============================================================================
+// WEBSOCKET RATE LIMIT TESTS
+// =============================================================================
+
+func TestWSConfig_RateLimits(t *testing.T) {
+	app := New()
+	echo := func(conn *WSConn, _ int, msg []byte) { conn.Send(msg) }
+
+	warn := DefaultWSConfig()
+	warn.MaxMessagesPerSecond = 2
+	warn.LimitAction = WSLimitWarn
+	app.WebSocket("/warn", echo, warn)
+
+	closing := DefaultWSConfig()
+	closing.MaxBytesPerSecond = 10
+	closing.LimitAction = WSLimitClose
+	app.WebSocket("/close", echo, closing)
+
+	server := httptest.NewServer(app.Router())
+	defer server.Close()
+	dial := func(path string) *websocket.Conn {
+		conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+path, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+		return conn
+	}
+	read := func(conn *websocket.Conn) string {
+		_, msg, err := conn.ReadMessage()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(msg)
+	}
+
+	// Over the message rate: one warning, the rest discarded
+	conn := dial("/warn")
+	defer conn.Close()
+	for _, msg := range []string{"1", "2", "3", "4", "5"} {
+		conn.WriteMessage(websocket.TextMessage, []byte(msg))
+	}
+	got := []string{read(conn), read(conn), read(conn)}
+	if got[0] != "1" || got[1] != "2" || got[2] != `{"event":"rate_limited","data":{"limit":"messages"}}` {
+		t.Errorf("received %q", got)
+	}
+	time.Sleep(600 * time.Millisecond) // refill one message
+	conn.WriteMessage(websocket.TextMessage, []byte("6"))
+	if msg := read(conn); msg != "6" {
+		t.Errorf("after refill received %q, want 6", msg)
+	}
+
+	// Over the byte rate: closed with a policy violation
+	conn = dial("/close")
+	defer conn.Close()
+	conn.WriteMessage(websocket.TextMessage, []byte("0123456789abcdef"))
+	if _, _, err := conn.ReadMessage(); !websocket.IsCloseError(err, websocket.ClosePolicyViolation) {
+		t.Errorf("read after byte limit = %v, want close 1008", err)
+	}
+}
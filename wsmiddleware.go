@@ -0,0 +1,79 @@
+package poltergeist
+
+import (
+	"runtime"
+)
+
+// =============================================================================
+// WEBSOCKET MESSAGE MIDDLEWARE - Cross-cutting logic per inbound message
+// =============================================================================
+
+// WSMessageMiddleware wraps the handling of each inbound message: named
+// events dispatched to On handlers and frames going to the route's
+// WSMessageHandler alike. Not calling next drops the message.
+type WSMessageMiddleware func(next WSMessageHandler) WSMessageHandler
+
+// UseMessage adds middleware around every message of the route's WebSocket
+// connections, outermost first; connections opened afterwards use it
+//
+//	app.WebSocket("/ws", onMessage).UseMessage(
+//	    poltergeist.WSRecover(),
+//	    func(next poltergeist.WSMessageHandler) poltergeist.WSMessageHandler {
+//	        return func(conn *poltergeist.WSConn, messageType int, message []byte) {
+//	            if !json.Valid(message) {
+//	                conn.Emit("error", poltergeist.H{"message": "invalid JSON"})
+//	                return
+//	            }
+//	            next(conn, messageType, message)
+//	        }
+//	    },
+//	)
+func (r *Route) UseMessage(middlewares ...WSMessageMiddleware) *Route {
+	r.messageMiddlewares = append(r.messageMiddlewares, middlewares...)
+	return r
+}
+
+// messageChain wraps dispatching to handler in the message middleware of
+// the connection's route
+func (c *WSConn) messageChain(handler WSMessageHandler) WSMessageHandler {
+	chain := func(conn *WSConn, messageType int, message []byte) {
+		conn.dispatch(handler, messageType, message)
+	}
+	if c.ctx == nil || c.ctx.Route() == nil {
+		return chain
+	}
+	middlewares := c.ctx.Route().messageMiddlewares
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		chain = middlewares[i](chain)
+	}
+	return chain
+}
+
+// WSRecover returns a message middleware recovering panics further down the
+// chain, so a failing message is logged and reported instead of closing
+// the connection
+func WSRecover() WSMessageMiddleware {
+	return func(next WSMessageHandler) WSMessageHandler {
+		return func(conn *WSConn, messageType int, message []byte) {
+			defer func() {
+				rec := recover()
+				if rec == nil {
+					return
+				}
+				stack := make([]byte, 4096)
+				stack = stack[:runtime.Stack(stack, false)]
+				conn.pipeline.logger().Error("websocket message handler panicked", "client_id", conn.id, "panic", rec)
+
+				if reporter := conn.pipeline.reporter(); reporter != nil {
+					reporter.CaptureError(&PanicError{Value: rec, Stack: string(stack)}, &ReportDetails{
+						Level:  ReportLevelError,
+						Source: ReportSourcePanic,
+						Tags:   map[string]string{"protocol": "websocket", "client_id": conn.id},
+						Stack:  string(stack),
+					})
+				}
+			}()
+			next(conn, messageType, message)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package poltergeist
+
+import (
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// =============================================================================
+// WEBSOCKET MESSAGE MIDDLEWARE TESTS
+// =============================================================================
+
+func TestRoute_UseMessage(t *testing.T) {
+	app := New()
+	var seen atomic.Int64
+	var order []string
+	cfg := DefaultWSConfig()
+	cfg.OnConnect = func(conn *WSConn) {
+		conn.On("ping", func(conn *WSConn, _ *WSEvent) { conn.Emit("pong", nil) })
+	}
+	app.WebSocket("/ws", func(conn *WSConn, _ int, msg []byte) {
+		if string(msg) == "panic" {
+			panic("boom")
+		}
+		conn.SendText("echo:" + strings.Join(order, ">") + ":" + string(msg))
+	}, cfg).UseMessage(
+		WSRecover(),
+		func(next WSMessageHandler) WSMessageHandler {
+			return func(conn *WSConn, messageType int, message []byte) {
+				seen.Add(1)
+				order = append(order[:0], "count")
+				next(conn, messageType, message)
+			}
+		},
+		func(next WSMessageHandler) WSMessageHandler {
+			return func(conn *WSConn, messageType int, message []byte) {
+				if string(message) == "bad" {
+					conn.SendText("rejected")
+					return
+				}
+				order = append(order, "validate")
+				next(conn, messageType, message)
+			}
+		},
+	)
+	server := httptest.NewServer(app.Router())
+	defer server.Close()
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"/ws", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+
+	for _, tt := range []struct{ send, want string }{
+		{"hi", "echo:count>validate:hi"},
+		{"bad", "rejected"},
+		{"panic", ""}, // recovered, the connection stays open
+		{`{"event":"ping"}`, `{"event":"pong"}`},
+		{"still there", "echo:count>validate:still there"},
+	} {
+		conn.WriteMessage(websocket.TextMessage, []byte(tt.send))
+		if tt.want == "" {
+			continue
+		}
+		_, msg, err := conn.ReadMessage()
+		if err != nil {
+			t.Fatalf("after %q: %v", tt.send, err)
+		}
+		if string(msg) != tt.want {
+			t.Errorf("sent %q, received %q, want %q", tt.send, msg, tt.want)
+		}
+	}
+	if seen.Load() != 5 {
+		t.Errorf("middleware saw %d messages, want 5", seen.Load())
+	}
+}
@@ -0,0 +1,249 @@
+package poltergeist
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"slices"
+	"sync"
+	"time"
+)
+
+// =============================================================================
+// WEBSOCKET RESUMPTION - Surviving brief network drops
+// =============================================================================
+
+// WSEventSession is the event giving a connection its resumption token
+const WSEventSession = "session"
+
+// WSResumeParam is the query parameter reconnecting clients pass their
+// resumption token in
+const WSResumeParam = "resume"
+
+// WSSession is the data of the session event, sent first on every
+// connection of a hub with resumption enabled
+type WSSession struct {
+	ID      string `json:"id"`      // connection ID, kept across resumptions
+	Token   string `json:"token"`   // reconnect with ?resume=<token>; valid once
+	TTL     int    `json:"ttl"`     // seconds the session is kept after a disconnect
+	Resumed bool   `json:"resumed"` // the connection's rooms and missed messages were restored
+}
+
+// wsResumption holds the sessions of disconnected clients
+type wsResumption struct {
+	ttl     time.Duration
+	mu      sync.Mutex
+	byToken map[string]*wsSuspended
+	byID    map[string]*wsSuspended
+}
+
+// wsSuspended is the state of a disconnected client, kept for ttl
+type wsSuspended struct {
+	id    string
+	token string
+	rooms []string
+	queue [][]byte // messages sent to it meanwhile
+	timer *time.Timer
+}
+
+// EnableResumption lets clients that lose their connection reclaim its ID,
+// rooms and the messages sent to it meanwhile by reconnecting within ttl
+// (default DefaultWSResumeTTL). Each connection is first sent
+// {"event": "session", "data": WSSession}; a client reconnecting with
+// ?resume=<token> gets its session back, or a fresh one with resumed false
+// once it expired. A session that misses more than DefaultBufferSize
+// messages is discarded, so the client knows to reload its state.
+//
+// Clients closing with a normal closure (1000) are gone for good. Presence
+// still reports the disconnect and the resumed connection's return, and a
+// token only resumes a session with the ID WSConfig.ConnID gives the new
+// connection, when set.
+//
+//	hub.EnableResumption(time.Minute)
+//	// client: new WebSocket(`${url}?resume=${lastSession.token}`)
+func (h *WSHub) EnableResumption(ttl time.Duration) {
+	if ttl <= 0 {
+		ttl = DefaultWSResumeTTL
+	}
+	h.resume.CompareAndSwap(nil, &wsResumption{
+		ttl:     ttl,
+		byToken: make(map[string]*wsSuspended),
+		byID:    make(map[string]*wsSuspended),
+	})
+}
+
+// prepareResume gives conn the ID of the session its resume token names,
+// before OnConnect runs; registerConn claims the session
+func (h *WSHub) prepareResume(conn *WSConn) {
+	r := h.resume.Load()
+	if r == nil {
+		return
+	}
+	token := conn.ctx.Query(WSResumeParam)
+	if token == "" {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	session, ok := r.byToken[token]
+	if !ok || (conn.customID && session.id != conn.id) {
+		return
+	}
+	conn.id = session.id
+	conn.resuming = session
+}
+
+// resumeSession issues conn a new token and hands it the session it
+// resumes, if still there, queuing the session event and missed messages
+// first. It returns the rooms rejoined, and the session of the conn's ID
+// that a new connection superseded; h.connMu must be held.
+func (h *WSHub) resumeSession(conn *WSConn) (rejoined []string, superseded *wsSuspended) {
+	r := h.resume.Load()
+	if r == nil {
+		return nil, nil
+	}
+	r.mu.Lock()
+	session := r.byID[conn.id]
+	if session != nil {
+		r.discardLocked(session)
+		if session != conn.resuming {
+			superseded, session = session, nil
+		}
+	}
+	conn.resuming = nil
+	r.mu.Unlock()
+
+	conn.resumeToken = newResumeToken()
+	event, _ := json.Marshal(wsEnvelope{Event: WSEventSession, Data: WSSession{
+		ID:      conn.id,
+		Token:   conn.resumeToken,
+		TTL:     int(r.ttl / time.Second),
+		Resumed: session != nil,
+	}})
+	conn.enqueue(event)
+	if session == nil {
+		return nil, superseded
+	}
+	for _, message := range session.queue {
+		conn.enqueue(message)
+	}
+	for _, room := range session.rooms {
+		h.addToRoom(conn.id, room)
+	}
+	return session.rooms, nil
+}
+
+// suspend keeps the session of a client that dropped; h.connMu must be held
+func (h *WSHub) suspend(conn *WSConn, rooms []string) {
+	r := h.resume.Load()
+	if r == nil || conn.resumeToken == "" || conn.closedByClient.Load() {
+		return
+	}
+	session := &wsSuspended{id: conn.id, token: conn.resumeToken, rooms: rooms}
+
+	r.mu.Lock()
+	r.byToken[session.token] = session
+	r.byID[session.id] = session
+	session.timer = time.AfterFunc(r.ttl, func() { h.expireSession(session) })
+	r.mu.Unlock()
+}
+
+// expireSession discards session once its TTL is over
+func (h *WSHub) expireSession(session *wsSuspended) {
+	r := h.resume.Load()
+	r.mu.Lock()
+	if r.byID[session.id] != session {
+		r.mu.Unlock()
+		return
+	}
+	r.discardLocked(session)
+	r.mu.Unlock()
+	h.releaseRooms(session)
+}
+
+// releaseRooms drops the backend subscriptions only discarded sessions
+// still needed
+func (h *WSHub) releaseRooms(discarded ...*wsSuspended) {
+	for _, session := range discarded {
+		for _, room := range session.rooms {
+			h.syncRoomSubscription(room)
+		}
+	}
+}
+
+// queueSuspended keeps message for the suspended sessions that match, in
+// room or, for "", all of them; h.connMu must be read-held
+func (h *WSHub) queueSuspended(room string, message []byte) {
+	r := h.resume.Load()
+	if r == nil {
+		return
+	}
+	var discarded []*wsSuspended
+	r.mu.Lock()
+	for _, session := range r.byID {
+		if (room == "" || slices.Contains(session.rooms, room)) && !r.queueLocked(session, message) {
+			discarded = append(discarded, session)
+		}
+	}
+	r.mu.Unlock()
+	h.releaseRooms(discarded...)
+}
+
+// sendSuspended keeps message for the suspended session id, reporting
+// whether there is one; h.connMu must be read-held
+func (h *WSHub) sendSuspended(id string, message []byte) bool {
+	r := h.resume.Load()
+	if r == nil {
+		return false
+	}
+	r.mu.Lock()
+	session, ok := r.byID[id]
+	if ok && !r.queueLocked(session, message) {
+		r.mu.Unlock()
+		h.releaseRooms(session)
+		return true
+	}
+	r.mu.Unlock()
+	return ok
+}
+
+// suspendedIn reports whether a suspended session is in room
+func (h *WSHub) suspendedIn(room string) bool {
+	r := h.resume.Load()
+	if r == nil {
+		return false
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, session := range r.byID {
+		if slices.Contains(session.rooms, room) {
+			return true
+		}
+	}
+	return false
+}
+
+// queueLocked appends message to session, or discards the session when it
+// has missed too much and reports false; r.mu must be held
+func (r *wsResumption) queueLocked(session *wsSuspended, message []byte) bool {
+	if len(session.queue) < DefaultBufferSize-1 { // room for the session event
+		session.queue = append(session.queue, message)
+		return true
+	}
+	r.discardLocked(session)
+	return false
+}
+
+// discardLocked forgets session; r.mu must be held
+func (r *wsResumption) discardLocked(session *wsSuspended) {
+	session.timer.Stop()
+	delete(r.byID, session.id)
+	delete(r.byToken, session.token)
+}
+
+// newResumeToken returns an unguessable token
+func newResumeToken() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
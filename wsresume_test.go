@@ -0,0 +1,124 @@
+package poltergeist
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// =============================================================================
+// WEBSOCKET RESUMPTION TESTS
+// =============================================================================
+
+func TestWSHub_Resumption(t *testing.T) {
+	hub := NewWSHub()
+	go hub.Run()
+	defer hub.ShutdownWithTimeout(time.Second)
+	hub.EnableResumption(time.Minute)
+
+	app := New()
+	cfg := DefaultWSConfig()
+	cfg.OnConnect = func(conn *WSConn) {
+		if conn.ctx.Query("join") != "" {
+			hub.JoinRoom(conn, "lobby")
+		}
+	}
+	app.WebSocketWithHub("/ws", hub, nil, cfg)
+	server := httptest.NewServer(app.Router())
+	defer server.Close()
+	url := "ws" + strings.TrimPrefix(server.URL, "http") + "/ws?"
+
+	dial := func(query string) (*websocket.Conn, WSSession) {
+		conn, _, err := websocket.DefaultDialer.Dial(url+query, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+		var msg struct {
+			Event string    `json:"event"`
+			Data  WSSession `json:"data"`
+		}
+		if err := conn.ReadJSON(&msg); err != nil || msg.Event != WSEventSession || msg.Data.Token == "" {
+			t.Fatalf("session event = %+v, %v", msg, err)
+		}
+		return conn, msg.Data
+	}
+	read := func(conn *websocket.Conn) string {
+		_, msg, err := conn.ReadMessage()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(msg)
+	}
+
+	first, session := dial("join=1")
+	if session.Resumed || session.TTL != 60 {
+		t.Errorf("first session = %+v", session)
+	}
+
+	// Drop the network without a close frame; messages sent meanwhile queue
+	first.UnderlyingConn().Close()
+	waitFor(t, func() bool { return hub.ConnectionCount() == 0 })
+	hub.BroadcastToRoom("lobby", []byte("room"))
+	if err := hub.SendTo(session.ID, []byte("direct")); err != nil {
+		t.Errorf("SendTo suspended session = %v", err)
+	}
+
+	resumed, next := dial(WSResumeParam + "=" + session.Token)
+	if !next.Resumed || next.ID != session.ID || next.Token == session.Token {
+		t.Errorf("resumed session = %+v, want ID %s and a new token", next, session.ID)
+	}
+	if got := read(resumed) + "," + read(resumed); got != "room,direct" {
+		t.Errorf("missed messages = %s, want room,direct", got)
+	}
+	if hub.RoomCount("lobby") != 1 {
+		t.Errorf("RoomCount = %d, want the resumed connection back in lobby", hub.RoomCount("lobby"))
+	}
+
+	// Tokens are single use
+	reused, fresh := dial(WSResumeParam + "=" + session.Token)
+	defer reused.Close()
+	if fresh.Resumed || fresh.ID == session.ID {
+		t.Errorf("reused token session = %+v", fresh)
+	}
+
+	// A normal closure ends the session
+	resumed.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+	resumed.Close()
+	waitFor(t, func() bool { return hub.ConnectionCount() == 1 })
+	if err := hub.SendTo(next.ID, []byte("gone")); err != ErrWSConnNotFound {
+		t.Errorf("SendTo after normal closure = %v, want ErrWSConnNotFound", err)
+	}
+}
+
+func TestWSHub_ResumptionExpires(t *testing.T) {
+	hub := NewWSHub()
+	go hub.Run()
+	defer hub.ShutdownWithTimeout(time.Second)
+	hub.EnableResumption(50 * time.Millisecond)
+
+	app := New()
+	app.WebSocketWithHub("/ws", hub, nil)
+	server := httptest.NewServer(app.Router())
+	defer server.Close()
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"/ws", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	var msg struct {
+		Data WSSession `json:"data"`
+	}
+	_, raw, err := conn.ReadMessage()
+	if err != nil || json.Unmarshal(raw, &msg) != nil {
+		t.Fatalf("session event = %s, %v", raw, err)
+	}
+	conn.UnderlyingConn().Close()
+
+	waitFor(t, func() bool { return hub.SendTo(msg.Data.ID, []byte("late")) == ErrWSConnNotFound })
+}
@@ -0,0 +1,135 @@
+package poltergeist
+
+import (
+	"sync/atomic"
+)
+
+// =============================================================================
+// WSHUB STATS - Activity counters and optional metrics per hub
+// =============================================================================
+
+// WSHub metric names recorded by WSHub.UseMetrics (backends add their
+// namespace); every series carries a "hub" label
+const (
+	MetricWSHubConnections      = "ws_hub_connections"            // hub
+	MetricWSHubConnectionsTotal = "ws_hub_connections_total"      // hub
+	MetricWSHubMessages         = "ws_hub_messages_total"         // hub, direction: in, out
+	MetricWSHubDropped          = "ws_hub_dropped_messages_total" // hub
+	MetricWSHubRoomConnections  = "ws_hub_room_connections"       // hub, room
+)
+
+// WSHubStats is a snapshot of a hub's activity
+type WSHubStats struct {
+	Connections      int            `json:"connections"`       // open now
+	TotalConnections uint64         `json:"total_connections"` // registered since the hub was created
+	MessagesIn       uint64         `json:"messages_in"`       // received from clients
+	MessagesOut      uint64         `json:"messages_out"`      // written to clients
+	Dropped          uint64         `json:"dropped"`           // not delivered because a send buffer was full
+	Rooms            map[string]int `json:"rooms"`             // connections per room
+}
+
+// wsHubCounters counts a hub's activity
+type wsHubCounters struct {
+	total       atomic.Uint64
+	in          atomic.Uint64
+	out         atomic.Uint64
+	dropped     atomic.Uint64
+	instruments atomic.Pointer[wsHubInstruments]
+}
+
+// wsHubInstruments are a hub's instruments on one metrics backend
+type wsHubInstruments struct {
+	name             string
+	connections      Gauge
+	connectionsTotal Counter
+	messages         Counter
+	dropped          Counter
+	rooms            Gauge
+}
+
+// Stats returns a snapshot of the hub's activity, e.g. for an admin
+// endpoint:
+//
+//	app.GET("/admin/ws", func(c *poltergeist.Context) error {
+//	    return c.JSON(200, hub.Stats())
+//	})
+func (h *WSHub) Stats() WSHubStats {
+	return WSHubStats{
+		Connections:      h.ConnectionCount(),
+		TotalConnections: h.counters.total.Load(),
+		MessagesIn:       h.counters.in.Load(),
+		MessagesOut:      h.counters.out.Load(),
+		Dropped:          h.counters.dropped.Load(),
+		Rooms:            h.roomSizes(),
+	}
+}
+
+// UseMetrics records the hub's activity into m (e.g. metrics.NewPrometheus)
+// under the "hub" label name, so several hubs can share a backend. Room
+// sizes are one series per room: avoid it for per-user rooms.
+//
+//	hub.UseMetrics(prom, "chat")
+func (h *WSHub) UseMetrics(m Metrics, name string) {
+	if m == nil {
+		h.counters.instruments.Store(nil)
+		return
+	}
+	inst := &wsHubInstruments{
+		name:             name,
+		connections:      m.Gauge(MetricWSHubConnections, "Open WebSocket connections per hub", "hub"),
+		connectionsTotal: m.Counter(MetricWSHubConnectionsTotal, "WebSocket connections registered per hub", "hub"),
+		messages:         m.Counter(MetricWSHubMessages, "WebSocket messages per hub", "hub", "direction"),
+		dropped:          m.Counter(MetricWSHubDropped, "WebSocket messages dropped on full send buffers per hub", "hub"),
+		rooms:            m.Gauge(MetricWSHubRoomConnections, "WebSocket connections per hub room", "hub", "room"),
+	}
+	inst.connections.Set(float64(h.ConnectionCount()), name)
+	for room, size := range h.roomSizes() {
+		inst.rooms.Set(float64(size), name, room)
+	}
+	h.counters.instruments.Store(inst)
+}
+
+// --- Recording ---
+
+// countConnections records a registration change; total is 1 for a new
+// connection
+func (h *WSHub) countConnections(open int, total uint64) {
+	h.counters.total.Add(total)
+	if inst := h.counters.instruments.Load(); inst != nil {
+		inst.connections.Set(float64(open), inst.name)
+		if total > 0 {
+			inst.connectionsTotal.Add(float64(total), inst.name)
+		}
+	}
+}
+
+// countMessage records a message received (in) or written (out)
+func (h *WSHub) countMessage(direction string) {
+	if direction == metricDirectionIn {
+		h.counters.in.Add(1)
+	} else {
+		h.counters.out.Add(1)
+	}
+	if inst := h.counters.instruments.Load(); inst != nil {
+		inst.messages.Add(1, inst.name, direction)
+	}
+}
+
+// countDropped records a message dropped on a full send buffer
+func (h *WSHub) countDropped() {
+	h.counters.dropped.Add(1)
+	if inst := h.counters.instruments.Load(); inst != nil {
+		inst.dropped.Add(1, inst.name)
+	}
+}
+
+// countRooms records the size of rooms after joins or leaves
+func (h *WSHub) countRooms(rooms ...string) {
+	inst := h.counters.instruments.Load()
+	if inst == nil {
+		return
+	}
+	for _, room := range rooms {
+		inst.rooms.Set(float64(h.roomCount(room)), inst.name, room)
+	}
+}
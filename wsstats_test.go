@@ -0,0 +1,70 @@
+package poltergeist
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// =============================================================================
+// WSHUB STATS TESTS
+// =============================================================================
+
+func TestWSHub_Stats(t *testing.T) {
+	hub := NewWSHub()
+	go hub.Run()
+	defer hub.ShutdownWithTimeout(time.Second)
+	metrics := &recordingMetrics{samples: make(map[string]float64)}
+	hub.UseMetrics(metrics, "chat")
+
+	app := New()
+	cfg := DefaultWSConfig()
+	cfg.OnConnect = func(conn *WSConn) { hub.JoinRoom(conn, "lobby") }
+	app.WebSocketWithHub("/ws", hub, func(conn *WSConn, _ int, msg []byte) {
+		conn.Send(msg)
+	}, cfg)
+	server := httptest.NewServer(app.Router())
+	defer server.Close()
+
+	dial := func() *websocket.Conn {
+		conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"/ws", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+		return conn
+	}
+	first, second := dial(), dial()
+	defer second.Close()
+	waitFor(t, func() bool { return hub.ConnectionCount() == 2 })
+
+	first.WriteMessage(websocket.TextMessage, []byte("ping"))
+	if _, _, err := first.ReadMessage(); err != nil {
+		t.Fatal(err)
+	}
+	first.Close()
+	waitFor(t, func() bool { return hub.ConnectionCount() == 1 })
+
+	stats := hub.Stats()
+	if stats.Connections != 1 || stats.TotalConnections != 2 || stats.MessagesIn != 1 || stats.MessagesOut != 1 {
+		t.Errorf("stats = %+v", stats)
+	}
+	if stats.Rooms["lobby"] != 1 {
+		t.Errorf("rooms = %v, want lobby: 1", stats.Rooms)
+	}
+
+	for key, want := range map[string]float64{
+		MetricWSHubConnections + "{chat}":           1,
+		MetricWSHubConnectionsTotal + "{chat}":      2,
+		MetricWSHubMessages + "{chat,in}":           1,
+		MetricWSHubMessages + "{chat,out}":          1,
+		MetricWSHubRoomConnections + "{chat,lobby}": 1,
+	} {
+		if got := metrics.get(key); got != want {
+			t.Errorf("%s = %v, want %v", key, got, want)
+		}
+	}
+}